@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/agent"
+	"github.com/agusespa/diffpector/internal/prompts"
+	"github.com/agusespa/diffpector/internal/usage"
+)
+
+// abSide is one configuration of --ab, a prompt variant or else a model of the configured
+// provider
+type abSide struct {
+	label  string
+	prompt string
+	model  string
+}
+
+// parseABSides reads the two comma separated configurations of --ab
+func parseABSides(value string) ([2]abSide, error) {
+	var sides [2]abSide
+	names := strings.Split(value, ",")
+	if len(names) != 2 {
+		return sides, fmt.Errorf("--ab takes two prompt variants or models separated by a comma, got %q", value)
+	}
+
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return sides, fmt.Errorf("--ab takes two prompt variants or models separated by a comma, got %q", value)
+		}
+		sides[i].label = name
+		if _, err := prompts.GetPromptVariant(name); err == nil {
+			sides[i].prompt = name
+		} else {
+			sides[i].model = name
+		}
+	}
+	if sides[0].label == sides[1].label {
+		return sides, fmt.Errorf("--ab compares two different configurations, got %s twice", sides[0].label)
+	}
+	return sides, nil
+}
+
+// runABReview reviews the staged changes once with each configuration and writes a report
+// of the issues found by each one only, to pick a prompt or model on the user's own code
+func runABReview(ctx context.Context, sides [2]abSide, opts options) error {
+	var results [2]agent.ABSide
+	var reportAgent *agent.CodeReviewAgent
+
+	for i, side := range sides {
+		fmt.Printf("=== %s: %s ===\n", []string{"A", "B"}[i], side.label)
+
+		sideOpts := opts
+		sideOpts.promptVariant = side.prompt
+		sideOpts.model = side.model
+		recorder := usage.NewRecorder()
+		codeReviewAgent, err := newReviewAgent(".", recorder, sideOpts)
+		if err != nil {
+			return fmt.Errorf("failed to set up review %s: %w", side.label, err)
+		}
+		defer codeReviewAgent.Close()
+		codeReviewAgent.SetPathFilter(opts.paths)
+		if reportAgent == nil {
+			reportAgent = codeReviewAgent
+			checkReportIgnored([]string{reportAgent.ABReportPath()}, opts)
+		}
+
+		issues, err := codeReviewAgent.CollectStagedIssues(ctx)
+		if err != nil {
+			return fmt.Errorf("review %s failed: %w", side.label, err)
+		}
+		summary := recorder.Summary()
+		results[i] = agent.ABSide{Label: side.label, Issues: issues, LLMCalls: summary.LLMCalls, LLMTime: summary.LLMWallTime}
+		fmt.Println()
+	}
+
+	comparison := agent.CompareIssues(results[0], results[1])
+	reportPath, err := reportAgent.GenerateABReport(comparison)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("[i] %d issue(s) found by both, %d only by %s, %d only by %s\n", len(comparison.Both),
+		len(comparison.Only[0]), sides[0].label, len(comparison.Only[1]), sides[1].label)
+	fmt.Printf("Comparison saved to %s\n", reportPath)
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agusespa/diffpector/internal/integrations/bitbucket"
+	"github.com/agusespa/diffpector/internal/usage"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// runBitbucketReview reviews a pull request against the current checkout, which should be on
+// the pull request's source branch, and posts the issues found as comments.
+func runBitbucketReview(ctx context.Context, id int, opts options) error {
+	cfg, err := config.LoadConfig("diffpectrc.json")
+	if err != nil {
+		return fmt.Errorf("failed to load config from diffpectrc.json: %w", err)
+	}
+
+	client, err := bitbucket.NewClient(cfg.Integrations.Bitbucket)
+	if err != nil {
+		return err
+	}
+
+	recorder := usage.NewRecorder()
+	codeReviewAgent, err := newReviewAgent(".", recorder, opts)
+	if err != nil {
+		return err
+	}
+	defer codeReviewAgent.Close()
+
+	fmt.Printf("Fetching pull request #%d...\n", id)
+	prDiff, err := client.PullRequestDiff(id)
+	if err != nil {
+		return fmt.Errorf("failed to fetch pull request diff: %w", err)
+	}
+
+	issues, err := codeReviewAgent.ReviewDiff(ctx, prDiff, ".")
+	if err != nil {
+		return err
+	}
+
+	posted, err := client.PostIssues(id, prDiff, issues)
+	if err != nil {
+		return fmt.Errorf("failed to post review comments: %w", err)
+	}
+	fmt.Printf("Posted %d comment(s) on pull request #%d\n", posted, id)
+
+	if questions := codeReviewAgent.Result().Questions; len(questions) > 0 {
+		asked, err := client.PostQuestions(id, prDiff, questions)
+		if err != nil {
+			return fmt.Errorf("failed to post questions for the author: %w", err)
+		}
+		fmt.Printf("Posted %d question(s) on pull request #%d\n", asked, id)
+	}
+
+	fmt.Println()
+	recorder.Summary().Print()
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// applyFlagOverrides sets the config fields given by flags, which override every config file,
+// and returns the JSON paths of the fields set with their flag. The fields of the --profile
+// come first, the other flags override them.
+func applyFlagOverrides(cfg *config.Config, opts options) (map[string]string, error) {
+	overrides := make(map[string]string)
+	if opts.profile != "" {
+		profile, ok := cfg.Profiles[opts.profile]
+		if !ok && opts.profile != profileSecurity {
+			available := slices.Sorted(maps.Keys(cfg.Profiles))
+			if !slices.Contains(available, profileSecurity) {
+				available = append(available, profileSecurity)
+			}
+			return nil, fmt.Errorf("unknown profile %q, available profiles: %s", opts.profile, strings.Join(available, ", "))
+		}
+		if profile.Model != "" {
+			cfg.LLM.Model = profile.Model
+			overrides["llm.model"] = "--profile " + opts.profile
+		}
+		if profile.ContextStrategy != "" {
+			cfg.Context.Strategy = profile.ContextStrategy
+			overrides["context.strategy"] = "--profile " + opts.profile
+		}
+	}
+	if opts.model != "" {
+		cfg.LLM.Model = opts.model
+		overrides["llm.model"] = "--ab"
+	}
+	if opts.minConfidence > 0 {
+		cfg.Report.MinConfidence = opts.minConfidence
+		overrides["report.min_confidence"] = "--min-confidence"
+	}
+	return overrides, nil
+}
+
+// runConfigShow prints the config files merged into the configuration, from the least to the
+// most specific. With effective it also prints every field of the merged configuration with
+// the layer or flag its value comes from.
+func runConfigShow(effective bool, opts options) error {
+	merged, err := config.LoadEffective("diffpectrc.json")
+	if err != nil {
+		return err
+	}
+	overrides, err := applyFlagOverrides(merged.Config, opts)
+	if err != nil {
+		return err
+	}
+	for path, flagName := range overrides {
+		merged.Set(path, "flag "+flagName)
+	}
+
+	fmt.Println()
+	fmt.Println("Config files, later ones override earlier ones:")
+	for _, layer := range config.Layers("diffpectrc.json") {
+		status := "not found"
+		for _, loaded := range merged.Loaded {
+			if loaded == layer {
+				status = "loaded"
+			}
+		}
+		fmt.Printf("  %-10s %s (%s)\n", layer.Origin, layer.Path, status)
+	}
+	if !effective {
+		return nil
+	}
+
+	fields, err := merged.Fields()
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	fmt.Println("Effective configuration:")
+	for _, field := range fields {
+		fmt.Printf("  %s = %s  [%s]\n", field.Path, field.Value, field.Origin)
+	}
+	return nil
+}
+
+// runConfigValidate checks the merged configuration. The unknown keys of the config files
+// are printed as warnings when loading them, invalid values fail.
+func runConfigValidate(opts options) error {
+	merged, err := config.LoadEffective("diffpectrc.json")
+	if err != nil {
+		return err
+	}
+	if _, err := applyFlagOverrides(merged.Config, opts); err != nil {
+		return err
+	}
+	if err := config.Validate(merged.Config); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+
+	fmt.Println()
+	if len(merged.Warnings) > 0 {
+		fmt.Printf("[✓] Configuration is valid, with %d warning(s)\n", len(merged.Warnings))
+	} else {
+		fmt.Println("[✓] Configuration is valid")
+	}
+	return nil
+}
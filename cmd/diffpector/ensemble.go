@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/agusespa/diffpector/internal/agent"
+	"github.com/agusespa/diffpector/internal/audit"
+	"github.com/agusespa/diffpector/internal/usage"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// newEnsemble connects to the models of the ensemble section, named after their model and
+// numbered when several share it. It also returns whether one of them is remote.
+func newEnsemble(cfg *config.Config, auditLog *audit.Log, recorder *usage.Recorder, opts options) ([]agent.EnsembleMember, bool, error) {
+	if len(cfg.Ensemble.Models) == 0 {
+		return nil, false, nil
+	}
+
+	var members []agent.EnsembleMember
+	remote := false
+	seen := make(map[string]int)
+	for _, model := range cfg.Ensemble.Models {
+		memberConfig := model.Inherit(cfg.LLM)
+		provider, memberRemote, err := newProvider(memberConfig, cfg, auditLog, recorder, opts)
+		if err != nil {
+			return nil, false, fmt.Errorf("ensemble model %s: %w", memberConfig.Model, err)
+		}
+		remote = remote || memberRemote
+
+		name := memberConfig.Model
+		if name == "" {
+			name = memberConfig.Provider
+		}
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s#%d", name, seen[name])
+		}
+		members = append(members, agent.EnsembleMember{Name: name, Provider: provider})
+	}
+
+	fmt.Printf("[i] Ensemble of %d model(s), %s policy\n", len(members), ensemblePolicyName(cfg.Ensemble.Policy))
+	return members, remote, nil
+}
+
+func ensemblePolicyName(policy string) string {
+	if policy == "" {
+		return agent.EnsemblePolicyConsensus
+	}
+	return policy
+}
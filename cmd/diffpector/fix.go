@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/usage"
+	"github.com/agusespa/diffpector/internal/utils"
+)
+
+// runFix reviews the staged changes asking for a fix per issue, then shows the fixes one by
+// one. Accepted fixes are applied to the working tree and their files staged again.
+func runFix(ctx context.Context, opts options) error {
+	recorder := usage.NewRecorder()
+	codeReviewAgent, err := newReviewAgent(".", recorder, opts)
+	if err != nil {
+		return err
+	}
+	defer codeReviewAgent.Close()
+	codeReviewAgent.SetPathFilter(opts.paths)
+
+	issues, err := codeReviewAgent.CollectStagedIssues(ctx)
+	if err != nil {
+		return err
+	}
+
+	applied, offered := confirmFixes(ctx, issues, tools.NewApplyPatchTool("."), os.Stdin)
+
+	fmt.Println()
+	if offered == 0 {
+		fmt.Println("[i] No fixes to apply")
+	} else {
+		fmt.Printf("[✓] %d of %d fix(es) applied and staged\n", applied, offered)
+	}
+
+	fmt.Println()
+	recorder.Summary().Print()
+	return nil
+}
+
+// confirmFixes asks on input whether to apply the fix of each issue and applies the accepted
+// ones with applyTool. It returns the number of fixes applied and offered.
+func confirmFixes(ctx context.Context, issues []types.Issue, applyTool tools.Tool, input io.Reader) (applied, offered int) {
+	reader := bufio.NewReader(input)
+
+	for _, issue := range issues {
+		if issue.SuggestedFix == "" {
+			continue
+		}
+
+		patch, err := utils.NormalizePatch(issue.FilePath, issue.SuggestedFix, issue.StartLine)
+		if err != nil {
+			fmt.Printf("[!] Skipping the fix for %s:%d: %v\n", issue.FilePath, issue.StartLine, err)
+			continue
+		}
+		offered++
+
+		fmt.Println()
+		fmt.Printf("%s %s:%d: %s\n", issue.Severity, issue.FilePath, issue.StartLine, issue.Description)
+		fmt.Print(patch)
+		fmt.Print("Apply this fix? [y/N/q] ")
+
+		answer, err := reader.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer == "q" || (err != nil && answer == "") {
+			break
+		}
+		if answer != "y" && answer != "yes" {
+			continue
+		}
+
+		if _, err := applyTool.Execute(ctx, map[string]any{"patch": patch, "stage": true}); err != nil {
+			fmt.Printf("[!] Could not apply the fix: %v\n", err)
+			continue
+		}
+		applied++
+	}
+
+	return applied, offered
+}
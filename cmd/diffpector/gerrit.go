@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agusespa/diffpector/internal/integrations/gerrit"
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/usage"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// runGerritReview reviews a patch set against the current checkout, which should be on the
+// patch set, and posts the issues found as robot comments voting on the configured label.
+func runGerritReview(ctx context.Context, reference string, opts options) error {
+	change, revision, err := gerrit.ParseChange(reference)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig("diffpectrc.json")
+	if err != nil {
+		return fmt.Errorf("failed to load config from diffpectrc.json: %w", err)
+	}
+	severities, err := severity.New(cfg.Severities)
+	if err != nil {
+		return fmt.Errorf("invalid severities config: %w", err)
+	}
+
+	client, err := gerrit.NewClient(cfg.Integrations.Gerrit)
+	if err != nil {
+		return err
+	}
+
+	recorder := usage.NewRecorder()
+	codeReviewAgent, err := newReviewAgent(".", recorder, opts)
+	if err != nil {
+		return err
+	}
+	defer codeReviewAgent.Close()
+
+	fmt.Printf("Fetching change %s, patch set %s...\n", change, revision)
+	changeDiff, err := client.PatchSetDiff(change, revision)
+	if err != nil {
+		return fmt.Errorf("failed to fetch patch set diff: %w", err)
+	}
+
+	issues, err := codeReviewAgent.ReviewDiff(ctx, changeDiff, ".")
+	if err != nil {
+		return err
+	}
+
+	questions := codeReviewAgent.Result().Questions
+	if err := client.PostReview(change, revision, issues, questions, severities); err != nil {
+		return fmt.Errorf("failed to post review: %w", err)
+	}
+	fmt.Printf("Posted %d comment(s) and %d question(s) on change %s\n", len(issues), len(questions), change)
+
+	fmt.Println()
+	recorder.Summary().Print()
+
+	return nil
+}
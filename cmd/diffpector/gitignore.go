@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/agent"
+)
+
+// ignoreReports adds the report files to .gitignore and tells which entries were added
+func ignoreReports(reportPaths []string) error {
+	added, err := agent.IgnoreReports(".gitignore", reportPaths)
+	if err != nil {
+		return err
+	}
+	if len(added) == 0 {
+		fmt.Println("[i] .gitignore already ignores the report files")
+	} else {
+		fmt.Printf("[✓] Added %s to .gitignore\n", strings.Join(added, ", "))
+	}
+	return nil
+}
+
+// checkReportIgnored makes sure a report file does not end up in a commit. With
+// --fix-gitignore, or when the user accepts from the menu, the report files are added to
+// .gitignore, otherwise a report that is not ignored is only warned about.
+func checkReportIgnored(reportPaths []string, opts options) {
+	if opts.fixGitignore {
+		if err := ignoreReports(reportPaths); err != nil {
+			fmt.Printf("[!] %v\n", err)
+		}
+		return
+	}
+
+	notIgnored := agent.NotifyUserIfReportNotIgnored(".gitignore", reportPaths)
+	if notIgnored == nil {
+		return
+	}
+	if !opts.interactive {
+		fmt.Printf("[!] %v. Run with --fix-gitignore to ignore it, or set report.path to a file in .diffpector/\n", notIgnored)
+		return
+	}
+	fmt.Printf("[!] %v\n", notIgnored)
+	if confirm(bufio.NewReader(os.Stdin), "Add the report files to .gitignore?", true) {
+		if err := ignoreReports(reportPaths); err != nil {
+			fmt.Printf("[!] %v\n", err)
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/agent"
+	"github.com/agusespa/diffpector/internal/llm"
+	"github.com/agusespa/diffpector/internal/utils"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+const (
+	initConfigFile  = "diffpectrc.json"
+	ollamaLocalURL  = "http://localhost:11434"
+	llamaCppURL     = "http://localhost:8080"
+	openAIURL       = "https://api.openai.com"
+	defaultModel    = "qwen2.5-coder:14b"
+	openAIModel     = "gpt-4o-mini"
+	azureDeployment = "gpt-4o"
+)
+
+// providerChoice is a provider the init command offers, with the llm section it writes
+type providerChoice struct {
+	label string
+	llm   map[string]any
+	// models are the models the provider serves, empty when they cannot be listed
+	models []string
+	remote bool
+}
+
+// runInit asks for the provider, model, prompt and report settings, writes them to
+// diffpectrc.json and offers to add the report files to .gitignore
+func runInit() error {
+	reader := bufio.NewReader(os.Stdin)
+
+	if _, err := os.Stat(initConfigFile); err == nil {
+		if !confirm(reader, fmt.Sprintf("%s already exists, overwrite it?", initConfigFile), false) {
+			fmt.Println("[i] Keeping the existing configuration")
+			return nil
+		}
+	}
+
+	fmt.Println("Looking for LLM providers...")
+	choices := detectProviders()
+	if len(choices) == 0 {
+		fmt.Println("[!] No provider found: start Ollama or llama-server, or set OPENAI_API_KEY, then run init again or edit the config")
+		choices = []providerChoice{{label: "llama.cpp at " + llamaCppURL + " (not running)", llm: map[string]any{"provider": "openai", "base_url": llamaCppURL}}}
+	}
+	labels := make([]string, len(choices))
+	for i, choice := range choices {
+		labels[i] = choice.label
+	}
+	provider := choices[choose(reader, "Provider", labels, 0)]
+
+	cfg := map[string]any{"llm": provider.llm}
+	switch provider.llm["provider"] {
+	case "ollama":
+		provider.llm["model"] = chooseOllamaModel(reader, provider.models)
+	case "openai":
+		// llama.cpp serves the model it was started with
+		if provider.remote {
+			provider.llm["model"] = ask(reader, "Model", openAIModel)
+		}
+	case "azure-openai":
+		provider.llm["model"] = ask(reader, "Deployment", azureDeployment)
+	}
+	if provider.remote {
+		if confirm(reader, "This provider is remote, allow sending the staged code to it?", false) {
+			cfg["security"] = map[string]any{"allow_remote": true}
+		} else {
+			fmt.Println("[i] Reviews will need --allow-remote, see security.allow_remote")
+		}
+	}
+
+	if confirm(reader, "Let the model ask you questions about the changes?", false) {
+		cfg["prompts"] = map[string]any{"questions": true}
+	}
+	if confirm(reader, "Summarize the whole changeset before reviewing each file? Slower, but aware of changes across files", false) {
+		cfg["pipeline"] = map[string]any{"summary": true}
+	}
+	if choose(reader, "Report format", []string{"markdown", "html"}, 0) == 1 {
+		cfg["report"] = map[string]any{"format": "html"}
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the config: %w", err)
+	}
+	written, err := validateInitConfig(data)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(initConfigFile, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", initConfigFile, err)
+	}
+	fmt.Printf("[✓] Configuration written to %s\n", initConfigFile)
+
+	if confirm(reader, "Add the report files and the .diffpector directory to .gitignore?", true) {
+		if err := ignoreReports(agent.ReportPaths(written.Report)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// detectProviders probes the local Ollama and llama.cpp servers and looks for the API keys of
+// the remote providers in the environment
+func detectProviders() []providerChoice {
+	var choices []providerChoice
+	if models, err := llm.NewOllamaProvider(ollamaLocalURL, "").ListModels(); err == nil {
+		choices = append(choices, providerChoice{
+			label:  fmt.Sprintf("Ollama at %s (%d model(s))", ollamaLocalURL, len(models)),
+			llm:    map[string]any{"provider": "ollama", "base_url": ollamaLocalURL},
+			models: models,
+		})
+	}
+	if err := llm.NewOpenAIProvider(llamaCppURL, "", "").HealthCheck(); err == nil {
+		choices = append(choices, providerChoice{
+			label: "llama.cpp at " + llamaCppURL,
+			llm:   map[string]any{"provider": "openai", "base_url": llamaCppURL},
+		})
+	}
+	// The keys are written as references, so that the config can be committed
+	if os.Getenv("OPENAI_API_KEY") != "" {
+		choices = append(choices, providerChoice{
+			label:  "OpenAI, with OPENAI_API_KEY",
+			llm:    map[string]any{"provider": "openai", "base_url": openAIURL, "api_key": "${OPENAI_API_KEY}"},
+			remote: true,
+		})
+	}
+	if os.Getenv("AZURE_OPENAI_API_KEY") != "" && os.Getenv("AZURE_OPENAI_ENDPOINT") != "" {
+		choices = append(choices, providerChoice{
+			label:  "Azure OpenAI, with AZURE_OPENAI_ENDPOINT and AZURE_OPENAI_API_KEY",
+			llm:    map[string]any{"provider": "azure-openai", "base_url": "${AZURE_OPENAI_ENDPOINT}", "api_key": "${AZURE_OPENAI_API_KEY}"},
+			remote: true,
+		})
+	}
+	return choices
+}
+
+// chooseOllamaModel offers the pulled models, the tested ones first, or asks for a model to
+// pull when there are none
+func chooseOllamaModel(reader *bufio.Reader, models []string) string {
+	if len(models) == 0 {
+		model := ask(reader, "No model pulled yet, model to use", defaultModel)
+		fmt.Printf("[i] Run 'ollama pull %s' before reviewing, or set llm.auto_pull\n", model)
+		return model
+	}
+
+	var tested, others []string
+	for _, model := range models {
+		if slices.Contains(utils.ApprovedModels, model) {
+			tested = append(tested, model+" (tested)")
+		} else {
+			others = append(others, model)
+		}
+	}
+	labels := append(tested, others...)
+	return strings.TrimSuffix(labels[choose(reader, "Model", labels, 0)], " (tested)")
+}
+
+// validateInitConfig checks the written config as it will be loaded, over the defaults, and
+// returns it
+func validateInitConfig(data []byte) (*config.Config, error) {
+	cfg := config.DefaultConfig()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// choose asks to pick one of options by number and returns its index, def on an empty answer
+func choose(reader *bufio.Reader, question string, options []string, def int) int {
+	fmt.Println()
+	fmt.Printf("%s:\n", question)
+	for i, option := range options {
+		fmt.Printf("%d. %s\n", i+1, option)
+	}
+	for {
+		fmt.Printf("Choose 1-%d [%d]: ", len(options), def+1)
+		answer, err := reader.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			return def
+		}
+		if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(options) {
+			return n - 1
+		}
+		if err != nil {
+			return def
+		}
+		fmt.Println("[!] Invalid choice")
+	}
+}
+
+// ask asks for a value, def on an empty answer
+func ask(reader *bufio.Reader, question, def string) string {
+	fmt.Printf("%s [%s]: ", question, def)
+	answer, _ := reader.ReadString('\n')
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// confirm asks a yes or no question, def on an empty answer
+func confirm(reader *bufio.Reader, question string, def bool) bool {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	fmt.Printf("%s %s ", question, hint)
+	answer, _ := reader.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	}
+	return def
+}
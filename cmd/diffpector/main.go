@@ -1,32 +1,325 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/agusespa/diffpector/internal/agent"
+	"github.com/agusespa/diffpector/internal/audit"
+	"github.com/agusespa/diffpector/internal/chaos"
+	"github.com/agusespa/diffpector/internal/dependencies"
 	"github.com/agusespa/diffpector/internal/llm"
+	"github.com/agusespa/diffpector/internal/logging"
+	"github.com/agusespa/diffpector/internal/mcp"
+	"github.com/agusespa/diffpector/internal/owners"
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/privacy"
 	"github.com/agusespa/diffpector/internal/prompts"
+	"github.com/agusespa/diffpector/internal/secrets"
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/telemetry"
 	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/trends"
+	"github.com/agusespa/diffpector/internal/usage"
 	"github.com/agusespa/diffpector/internal/utils"
+	"github.com/agusespa/diffpector/internal/vcs"
 	"github.com/agusespa/diffpector/pkg/config"
 )
 
+// profileSecurity focuses the review on vulnerabilities, see newReviewAgent
+const profileSecurity = "security"
+
+// Values of --output, compact prints a file:line:col: severity: message line per finding,
+// rdjson and rdjsonl the reviewdog diagnostic formats, junit a test report for CI and
+// checkstyle the report read by CI annotators
+const (
+	outputMarkdown   = "markdown"
+	outputCompact    = "compact"
+	outputRDJSON     = "rdjson"
+	outputRDJSONL    = "rdjsonl"
+	outputJUnit      = "junit"
+	outputCheckstyle = "checkstyle"
+)
+
+var outputFormats = []string{outputMarkdown, outputCompact, outputRDJSON, outputRDJSONL, outputJUnit, outputCheckstyle}
+
+// options holds the command line flags shared by the review modes
+type options struct {
+	summaryLine bool
+	profile     string
+	// chaos is the fault injection spec of the hidden --chaos flag, see chaos.ParseConfig
+	chaos string
+	// paths restricts the staged review to these files and directories
+	paths []string
+	// minConfidence overrides report.min_confidence when set
+	minConfidence float64
+	// full reviews every staged file, even the ones unchanged since the last review
+	full bool
+	// resume continues the interrupted staged review recorded in the checkpoint
+	resume bool
+	// emitPatches is the directory the suggested fixes are written to, empty writes none
+	emitPatches string
+	// suggestFixes reviews with the prompt asking for a fix per issue
+	suggestFixes bool
+	output       string
+	// junitThreshold is the least severe level failing a file of the junit output, empty
+	// fails on any issue
+	junitThreshold string
+	// lookupTickets adds the tickets the branch and the commit message reference as intent
+	// context, for the reviews of the local changes
+	lookupTickets bool
+	// findings receives the findings in the formats other than markdown, it is the original
+	// stdout as the progress goes to stderr then
+	findings io.Writer
+	// allowRemote lets the code go to providers outside the machine and its private network,
+	// like security.allow_remote
+	allowRemote bool
+	// telemetry exports the spans and metrics of the reviews when OTEL_ variables configure
+	// it, nil otherwise
+	telemetry *telemetry.Telemetry
+	// rateLimiter queues the calls of every review agent of the run under the provider quota
+	rateLimiter *llm.RateLimiter
+	// promptVariant and model replace the configured ones for a side of --ab
+	promptVariant string
+	model         string
+	// fixGitignore adds the report files to .gitignore before the review
+	fixGitignore bool
+	// interactive is set when the review was started from the menu, so questions can be asked
+	interactive bool
+	// base is the branch whose merge base with HEAD the usages of the changed symbols are
+	// searched in, empty searches the working tree
+	base string
+}
+
+// hiddenFlags are left out of the usage message
+var hiddenFlags = []string{"chaos"}
+
 func main() {
+	watch := flag.Bool("watch", false, "Watch the working tree and continuously review modified files")
+	var repos repoList
+	flag.Var(&repos, "repo", "Review the staged changes of this repository (repeatable)")
+	bitbucketPR := flag.Int("bitbucket-pr", 0, "Review a Bitbucket pull request and post the findings as comments")
+	gerritChange := flag.String("gerrit-change", "", "Review a Gerrit change, as <change>[/<patch set>], and post the findings as robot comments")
+	var opts options
+	flag.BoolVar(&opts.summaryLine, "summary-line", false, "Print a final DIFFPECTOR_RESULT line for shell scripts")
+	flag.StringVar(&opts.profile, "profile", "", "Review profile: one of the profiles of the config, or \"security\" which focuses on vulnerabilities reachable from HTTP handlers")
+	flag.StringVar(&opts.chaos, "chaos", "", "Inject faults into the review pipeline, for testing")
+	flag.Float64Var(&opts.minConfidence, "min-confidence", 0, "Hide findings the model is less confident about than this value, between 0 and 1")
+	flag.BoolVar(&opts.full, "full", false, "Review every staged file, also the ones whose diff did not change since the last review")
+	flag.BoolVar(&opts.resume, "resume", false, "Continue an interrupted review, skipping the files it already reviewed")
+	flag.StringVar(&opts.output, "output", outputMarkdown, "Output format: \"compact\" prints a file:line:col: severity: message line per finding for problem matchers, \"rdjson\" and \"rdjsonl\" the reviewdog diagnostic formats, \"junit\" a JUnit XML test report, \"checkstyle\" a Checkstyle XML report")
+	flag.StringVar(&opts.junitThreshold, "junit-threshold", "", "Least severe level of the issues failing a file in the junit output (default any issue)")
+	flag.StringVar(&opts.emitPatches, "emit-patches", "", "Ask the model for fixes and write them as .patch files into this directory")
+	flag.BoolVar(&opts.fixGitignore, "fix-gitignore", false, "Add the report files and the .diffpector directory to .gitignore before reviewing")
+	flag.BoolVar(&opts.allowRemote, "allow-remote", false, "Allow LLM providers outside this machine and its private network to receive the code")
+	flag.StringVar(&opts.base, "base", "", "Search the usages of the changed symbols in the merge base with this branch, e.g. main, instead of the working tree")
+	logLevel := flag.String("log-level", "", "Level of the structured JSON log of the pipeline stages: debug, info, warn or error (default error)")
+	logFile := flag.String("log-file", "", "Write the structured log to this file instead of stderr")
+	ab := flag.String("ab", "", "Review the staged changes with two prompt variants or models, as <a>,<b>, and compare their findings")
+	listen := flag.String("listen", defaultServeAddress, "serve: address the API listens on")
+	writeMessage := flag.Bool("write", false, "describe: write the description into .git/COMMIT_EDITMSG instead of printing it")
+	showEffective := flag.Bool("effective", false, "config show: print every field of the merged configuration with the file or flag it comes from")
+	flag.Usage = printUsage
+	flag.Parse()
+
+	// "review [paths...]" reviews the staged changes directly, "fix [paths...]" applies the
+	// fixes of the review, "describe [commit|pr]" describes them, "mcp-serve" offers the
+	// review as MCP tools, "serve" over HTTP, "stats" prints the trends of the recorded
+	// reviews, "config show" the merged configuration and "config validate" checks it, "init"
+	// writes a config asking for each setting, flags may follow the command
+	command := flag.Arg(0)
+
+	// The repositories of --repo are relative to where diffpector was started
+	workDir, err := os.Getwd()
+	if len(repos) == 0 {
+		workDir, err = enterRepoRoot()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var describeVariant, configCommand string
+	switch command {
+	case "":
+	case "review", "fix":
+		flag.CommandLine.Parse(flag.Args()[1:])
+		paths, err := repoRelativePaths(workDir, flag.Args())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.paths = paths
+	case "mcp-serve", "serve", "stats", "init":
+		flag.CommandLine.Parse(flag.Args()[1:])
+	case "describe":
+		flag.CommandLine.Parse(flag.Args()[1:])
+		describeVariant = prompts.DEFAULT_DESCRIBE_PROMPT
+		if flag.NArg() > 0 {
+			describeVariant = flag.Arg(0)
+		}
+		if _, err := prompts.GetDescribeVariant(describeVariant); err != nil || flag.NArg() > 1 {
+			fmt.Fprintf(os.Stderr, "Error: use \"describe [commit|pr]\"\n")
+			os.Exit(1)
+		}
+	case "config":
+		flag.CommandLine.Parse(flag.Args()[1:])
+		if flag.Arg(0) != "show" && flag.Arg(0) != "validate" {
+			fmt.Fprintf(os.Stderr, "Error: use \"config show [--effective]\" or \"config validate\"\n")
+			os.Exit(1)
+		}
+		configCommand = flag.Arg(0)
+		flag.CommandLine.Parse(flag.Args()[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown command %q, use \"review [paths...]\", \"fix [paths...]\", \"describe [commit|pr]\", \"mcp-serve\", \"serve\", \"stats\", \"init\" or \"config show|validate\"\n", command)
+		os.Exit(1)
+	}
+
+	if opts.minConfidence < 0 || opts.minConfidence > 1 {
+		fmt.Fprintf(os.Stderr, "Error: --min-confidence must be between 0 and 1\n")
+		os.Exit(1)
+	}
+
+	var abSides [2]abSide
+	if *ab != "" {
+		var err error
+		abSides, err = parseABSides(*ab)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !slices.Contains(outputFormats, opts.output) {
+		fmt.Fprintf(os.Stderr, "Error: unknown output %q, available outputs: %s\n", opts.output, strings.Join(outputFormats, ", "))
+		os.Exit(1)
+	}
+
+	closeLog, err := logging.Setup(*logLevel, *logFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer closeLog()
+
+	opts.telemetry, err = telemetry.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// The long running modes export as they go, their spans have no common parent
+	var runSpan *telemetry.Span
+	if !*watch && command != "serve" && command != "mcp-serve" {
+		runSpan = opts.telemetry.Start("diffpector", telemetry.String("command", command))
+	}
+
+	opts.suggestFixes = opts.emitPatches != "" || command == "fix"
+	opts.rateLimiter = llm.NewRateLimiter()
+
+	// The first interrupt cancels the review, which stops at the next model or tool call and
+	// writes what it found so far. A second one kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if command == "describe" {
+		finishRun(runSpan, opts.telemetry, runDescribe(ctx, describeVariant, *writeMessage, opts))
+		return
+	}
+
+	if command == "config" && configCommand == "validate" {
+		finishRun(runSpan, opts.telemetry, runConfigValidate(opts))
+		return
+	}
+
+	if command == "config" {
+		finishRun(runSpan, opts.telemetry, runConfigShow(*showEffective, opts))
+		return
+	}
+
+	if command == "stats" {
+		finishRun(runSpan, opts.telemetry, runStats("."))
+		return
+	}
+
+	if command == "init" {
+		finishRun(runSpan, opts.telemetry, runInit())
+		return
+	}
+
+	if command == "mcp-serve" {
+		finishRun(runSpan, opts.telemetry, runMCPServe(ctx, opts))
+		return
+	}
+
+	if opts.output != outputMarkdown {
+		opts.findings = os.Stdout
+		os.Stdout = os.Stderr
+	}
+
 	fmt.Println("")
 	fmt.Println("=========================")
 	fmt.Println(" Diffpector Review Agent ")
 	fmt.Println("=========================")
 	fmt.Println("")
 
-	if err := runMainMenu(); err != nil {
+	if *watch {
+		err = runWatchMode(ctx, opts)
+	} else if *bitbucketPR > 0 {
+		err = runBitbucketReview(ctx, *bitbucketPR, opts)
+	} else if *gerritChange != "" {
+		err = runGerritReview(ctx, *gerritChange, opts)
+	} else if len(repos) > 0 {
+		err = runMultiRepoReview(ctx, repos, opts)
+	} else if *ab != "" {
+		err = runABReview(ctx, abSides, opts)
+	} else if command == "review" {
+		err = runCodeReview(ctx, "diff", "", opts)
+	} else if command == "fix" {
+		err = runFix(ctx, opts)
+	} else if command == "serve" {
+		err = runServe(ctx, *listen, opts)
+	} else {
+		err = runMainMenu(ctx, opts)
+	}
+
+	finishRun(runSpan, opts.telemetry, err)
+}
+
+// finishRun ends the span of the run and exports the telemetry before exiting with the error
+// of the run, if any
+func finishRun(span *telemetry.Span, t *telemetry.Telemetry, err error) {
+	span.Fail(err)
+	span.End()
+	flushTelemetry(t)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func runMainMenu() error {
+// flushTelemetry exports the telemetry recorded so far, a failed export does not fail the
+// review
+func flushTelemetry(t *telemetry.Telemetry) {
+	if err := t.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "[!] %v\n", err)
+	}
+}
+
+func runMainMenu(ctx context.Context, opts options) error {
 	for {
 		fmt.Println("Which mode do you want to run?")
 		fmt.Println()
@@ -47,7 +340,8 @@ func runMainMenu() error {
 
 		switch choice {
 		case "1":
-			return runCodeReview("diff", "")
+			opts.interactive = true
+			return runCodeReview(ctx, "diff", "", opts)
 		case "2":
 			fmt.Println("Branch Review")
 			fmt.Println("-------------")
@@ -70,7 +364,8 @@ func runMainMenu() error {
 			}
 
 			fmt.Println()
-			return runCodeReview("branch", branchName)
+			opts.interactive = true
+			return runCodeReview(ctx, "branch", branchName, opts)
 		case "3":
 			showHelp()
 			fmt.Println()
@@ -86,69 +381,593 @@ func runMainMenu() error {
 	}
 }
 
-func runCodeReview(mode, target string) error {
-	reportErr := agent.NotifyUserIfReportNotIgnored(".gitignore")
-	if reportErr != nil {
-		return fmt.Errorf("report check failed: %w", reportErr)
+func runCodeReview(ctx context.Context, mode, target string, opts options) error {
+	recorder := usage.NewRecorder()
+	opts.lookupTickets = true
+	codeReviewAgent, err := newReviewAgent(".", recorder, opts)
+	if err != nil {
+		return err
+	}
+	defer codeReviewAgent.Close()
+	checkReportIgnored(codeReviewAgent.ReportPaths(), opts)
+	codeReviewAgent.SetPathFilter(opts.paths)
+	if !opts.full {
+		manifestPath, err := agent.DefaultManifestPath(".")
+		if err != nil {
+			fmt.Printf("[!] Reviewing every file: %v\n", err)
+		}
+		codeReviewAgent.SetReviewManifest(manifestPath)
+	}
+	codeReviewAgent.SetCheckpoint(agent.DefaultCheckpointPath, opts.resume)
+
+	switch mode {
+	case "diff":
+		err = codeReviewAgent.ReviewStagedChanges(ctx)
+	case "branch":
+		return fmt.Errorf("%s mode is not supported yet", mode)
+	default:
+		return fmt.Errorf("invalid mode: %s", mode)
+	}
+
+	if opts.emitPatches != "" && err == nil {
+		written, patchErr := agent.WritePatches(opts.emitPatches, codeReviewAgent.Result().Issues)
+		if patchErr != nil {
+			fmt.Printf("[!] %v\n", patchErr)
+		}
+		if written > 0 {
+			fmt.Printf("[i] %d patch(es) written to %s, apply them with 'git apply --unidiff-zero'\n", written, opts.emitPatches)
+		}
+	}
+
+	fmt.Println()
+	recorder.Summary().Print()
+
+	if err == nil {
+		err = printFindings(opts, codeReviewAgent.Result())
+	}
+	if opts.summaryLine {
+		result := codeReviewAgent.Result()
+		if err != nil {
+			result.Error = err.Error()
+		}
+		fmt.Println(result.SummaryLine())
+	}
+	if err == nil {
+		err = gateError(codeReviewAgent.Result())
+	}
+
+	return err
+}
+
+// gateError fails the run when the profile sets a severity gate and an issue reaches it, so
+// that CI can stop on the issues that matter. Without a gate the issues do not fail the run.
+func gateError(result agent.ReviewResult) error {
+	if result.FailOn == "" || !result.Failed() {
+		return nil
 	}
+	return fmt.Errorf("the review found issues at least as severe as %s", result.FailOn)
+}
+
+// printFindings writes the issues of the result to opts.findings in the --output format, the
+// markdown report is already written by then
+func printFindings(opts options, result agent.ReviewResult) error {
+	var output string
+	var err error
+	switch opts.output {
+	case outputCompact:
+		output = result.CompactLines()
+	case outputRDJSON:
+		output, err = result.RDJSON()
+	case outputRDJSONL:
+		output, err = result.RDJSONL()
+	case outputJUnit:
+		output, err = result.JUnit(opts.junitThreshold)
+	case outputCheckstyle:
+		output, err = result.Checkstyle()
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(opts.findings, output)
+	return err
+}
+
+// runDescribe prints a description of the staged changes, or writes it as the message of
+// the next commit. Progress goes to stderr so the printed description can be piped.
+func runDescribe(ctx context.Context, variant string, write bool, opts options) error {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	codeReviewAgent, err := newReviewAgent(".", nil, opts)
+	if err != nil {
+		return err
+	}
+	defer codeReviewAgent.Close()
+
+	description, err := codeReviewAgent.DescribeStagedChanges(ctx, variant)
+	if err != nil || description == "" {
+		return err
+	}
+
+	if !write {
+		fmt.Fprint(stdout, description)
+		return nil
+	}
+
+	messagePath, err := vcs.NewGit(".").CommitMessagePath()
+	if err != nil {
+		return fmt.Errorf("failed to locate the commit message file: %w", err)
+	}
+	if err := os.WriteFile(messagePath, []byte(description), 0644); err != nil {
+		return fmt.Errorf("failed to write the commit message: %w", err)
+	}
+	fmt.Printf("Description written to %s, commit with 'git commit -e -F %s'\n", messagePath, messagePath)
+	return nil
+}
+
+// repoRelativePaths makes the paths, relative ones being relative to workDir where diffpector
+// was started, relative to the current directory, the repository root
+func repoRelativePaths(workDir string, paths []string) ([]string, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	relative := make([]string, len(paths))
+	for i, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = platform.JoinPath(workDir, path)
+		}
+		rel, err := platform.RelPath(root, path)
+		if err != nil || platform.IsOutside(rel) {
+			return nil, fmt.Errorf("path %s is outside the repository", path)
+		}
+		relative[i] = rel
+	}
+	return relative, nil
+}
+
+// printUsage prints the flags like the default usage message, without the hidden ones
+func printUsage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s [flags] [review [paths...] | fix [paths...] | describe [commit|pr] | mcp-serve | serve | stats | config show [--effective] | config validate]:\n", os.Args[0])
+
+	visible := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	visible.SetOutput(flag.CommandLine.Output())
+	flag.VisitAll(func(f *flag.Flag) {
+		if !slices.Contains(hiddenFlags, f.Name) {
+			visible.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	visible.PrintDefaults()
+}
+
+// newReviewAgent builds the agent for the repository at rootDir from diffpectrc.json.
+// The recorder, when not nil, meters the LLM traffic. The security profile gathers
+// taint-style context and reviews every file with the security prompt, the profiles of the
+// config replace their settings.
+func newReviewAgent(rootDir string, recorder *usage.Recorder, opts options) (*agent.CodeReviewAgent, error) {
+	profile := opts.profile
 
 	cfg, err := config.LoadConfig("diffpectrc.json")
 	if err != nil {
-		return fmt.Errorf("failed to load config from diffpectrc.json: %w", err)
+		return nil, fmt.Errorf("failed to load config from diffpectrc.json: %w", err)
+	}
+	if _, err := applyFlagOverrides(cfg, opts); err != nil {
+		return nil, err
+	}
+	profileConfig := cfg.Profiles[profile]
+	if err := config.Validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid config, see 'diffpector config validate':\n%w", err)
 	}
 
-	if !slices.Contains(llm.SupportedProviders, cfg.LLM.Provider) {
-		return fmt.Errorf("unsupported LLM provider: %s", cfg.LLM.Provider)
+	if err := validatePromptConfig(cfg.Prompts); err != nil {
+		return nil, err
 	}
 
-	if err := utils.ValidateModel(cfg.LLM.Model); err != nil {
-		return fmt.Errorf("model validation failed: %w", err)
+	severities, err := severity.New(cfg.Severities)
+	if err != nil {
+		return nil, fmt.Errorf("invalid severities config: %w", err)
+	}
+	if opts.junitThreshold != "" && severities.Rank(opts.junitThreshold) == 0 {
+		return nil, fmt.Errorf("unknown --junit-threshold %q, available severities: %s", opts.junitThreshold, strings.Join(severities.Levels(), ", "))
+	}
+	if profileConfig.FailOn != "" && severities.Rank(profileConfig.FailOn) == 0 {
+		return nil, fmt.Errorf("unknown fail_on %q of profile %s, available severities: %s", profileConfig.FailOn, profile, strings.Join(severities.Levels(), ", "))
+	}
+	if profileConfig.Prompt != "" {
+		if _, err := prompts.GetPromptVariant(profileConfig.Prompt); err != nil {
+			return nil, fmt.Errorf("invalid prompt of profile %s: %w", profile, err)
+		}
 	}
 
-	providerConfig := llm.ProviderConfig{
-		Type:    llm.ProviderType(cfg.LLM.Provider),
-		Model:   cfg.LLM.Model,
-		BaseURL: cfg.LLM.BaseURL,
-		APIKey:  cfg.LLM.APIKey,
+	var auditLog *audit.Log
+	if cfg.Audit.Enabled {
+		auditDir := cfg.Audit.Dir
+		if auditDir == "" {
+			auditDir = audit.DefaultDir
+		}
+		if !filepath.IsAbs(auditDir) {
+			auditDir = filepath.Join(rootDir, auditDir)
+		}
+		auditLog, err = audit.New(auditDir, cfg.Audit.Redact)
+		if err != nil {
+			return nil, fmt.Errorf("invalid audit config: %w", err)
+		}
+		fmt.Printf("[i] Recording the model calls in %s\n", auditLog.Path())
 	}
 
-	llmProvider, err := llm.NewProvider(providerConfig)
+	llmProvider, remote, err := newProvider(cfg.LLM, cfg, auditLog, recorder, opts)
 	if err != nil {
-		return fmt.Errorf("failed to create LLM provider: %w", err)
+		return nil, err
 	}
+	members, membersRemote, err := newEnsemble(cfg, auditLog, recorder, opts)
+	if err != nil {
+		return nil, err
+	}
+	remote = remote || membersRemote
 
-	modelDisplay := llmProvider.GetModel()
-	if modelDisplay == "" || modelDisplay == "llama.cpp" {
-		modelDisplay = "model loaded at server startup"
+	var triageProvider llm.Provider
+	if cfg.Pipeline.TriageModel != "" {
+		triageConfig := cfg.LLM
+		triageConfig.Model = cfg.Pipeline.TriageModel
+		var triageRemote bool
+		triageProvider, triageRemote, err = newProvider(triageConfig, cfg, auditLog, recorder, opts)
+		if err != nil {
+			return nil, fmt.Errorf("triage model %s: %w", triageConfig.Model, err)
+		}
+		remote = remote || triageRemote
+	}
+
+	// The diffs are flagged once for every model, so all of them redact when one is remote
+	redactSecrets := cfg.Checks.Secrets && remote
+	if redactSecrets {
+		llmProvider = secrets.NewRedactingProvider(llmProvider)
+		for i := range members {
+			members[i].Provider = secrets.NewRedactingProvider(members[i].Provider)
+		}
+		if triageProvider != nil {
+			triageProvider = secrets.NewRedactingProvider(triageProvider)
+		}
+	}
+
+	repo, err := vcs.Detect(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect version control system: %w", err)
 	}
-	fmt.Printf("Using %s API with %s\n\n", cfg.LLM.Provider, modelDisplay)
 
 	parserRegistry := tools.NewParserRegistry()
+	symbolContextTool := tools.NewSymbolContextTool(rootDir, parserRegistry)
+	symbolContextTool.SetContextConfig(cfg.Context)
+	symbolContextTool.SetSecurityProfile(profile == profileSecurity)
+	if opts.base != "" {
+		if err := symbolContextTool.SetBase(opts.base); err != nil {
+			return nil, err
+		}
+	}
+
 	toolRegistry := tools.NewToolRegistry()
-	rootDir := "."
+	toolRegistry.SetPolicy(toolPolicy(rootDir, cfg.Report, auditLog))
 	toolsToRegister := map[tools.ToolName]tools.Tool{
-		tools.ToolNameGitDiff:       &tools.GitDiffTool{},
+		tools.ToolNameGitDiff:       tools.NewGitDiffTool(repo),
 		tools.ToolNameGitGrep:       &tools.GitGrepTool{},
 		tools.ToolNameWriteFile:     &tools.WriteFileTool{},
 		tools.ToolNameReadFile:      &tools.ReadFileTool{},
 		tools.ToolNameHumanLoop:     &tools.HumanLoopTool{},
-		tools.ToolNameSymbolContext: tools.NewSymbolContextTool(rootDir, parserRegistry),
+		tools.ToolNameSymbolContext: symbolContextTool,
 	}
 
 	for name, tool := range toolsToRegister {
 		toolRegistry.Register(name, tool)
 	}
 
-	codeReviewAgent := agent.NewCodeReviewAgent(llmProvider, parserRegistry, toolRegistry, prompts.DEFAULT_PROMPT)
+	if cfg.Context.History {
+		if repo.Name() == "git" {
+			toolRegistry.Register(tools.ToolNameGitBlame, tools.NewGitBlameTool(rootDir, cfg.Context.HistoryCommits))
+		} else {
+			fmt.Printf("[!] Change history requires git, skipping it for %s\n", repo.Name())
+		}
+	}
 
-	switch mode {
-	case "diff":
-		return codeReviewAgent.ReviewStagedChanges()
-	case "branch":
-		return fmt.Errorf("%s mode is not supported yet", mode)
-	default:
-		return fmt.Errorf("invalid mode: %s", mode)
+	if cfg.Checks.Duplicates {
+		toolRegistry.Register(tools.ToolNameSimilarCode, tools.NewSimilarCodeTool(rootDir, parserRegistry))
+	}
+
+	if cfg.Checks.BreakingChanges {
+		toolRegistry.Register(tools.ToolNameCallSites, tools.NewCallSitesTool(rootDir, parserRegistry))
+	}
+
+	if cfg.Checks.UnusedSymbols {
+		toolRegistry.Register(tools.ToolNameReferences, tools.NewReferencesTool(rootDir, parserRegistry))
+	}
+
+	if opts.chaos != "" {
+		chaosConfig, err := chaos.ParseConfig(opts.chaos)
+		if err != nil {
+			return nil, err
+		}
+		injector := chaos.NewInjector(chaosConfig)
+		injector.WrapTools(toolRegistry)
+		llmProvider = injector.WrapProvider(llmProvider)
+		fmt.Printf("[!] Chaos mode: injecting %v at rate %.2f\n\n", chaosConfig.Faults, chaosConfig.Rate)
+	}
+
+	promptVariant := prompts.DEFAULT_PROMPT
+	if opts.suggestFixes {
+		promptVariant = prompts.FIXES_PROMPT
+	}
+	if profile == profileSecurity {
+		promptVariant = prompts.SECURITY_PROMPT
+	}
+
+	codeReviewAgent := agent.NewCodeReviewAgent(llmProvider, parserRegistry, toolRegistry, promptVariant)
+	if opts.promptVariant != "" {
+		codeReviewAgent.SetFixedPromptVariant(opts.promptVariant)
+	} else if profileConfig.Prompt != "" {
+		codeReviewAgent.SetFixedPromptVariant(profileConfig.Prompt)
+	}
+	codeReviewAgent.SetFailOn(profileConfig.FailOn)
+	if err := codeReviewAgent.SetContextStrategy(cfg.Context.Strategy); err != nil {
+		return nil, fmt.Errorf("invalid context config: %w", err)
+	}
+	codeReviewAgent.SetChunkLines(cfg.Context.ChunkLines)
+	if triageProvider != nil {
+		codeReviewAgent.SetTriageProvider(triageProvider)
+	}
+	codeReviewAgent.SetChangesetSummary(cfg.Pipeline.Summary)
+	codeReviewAgent.SetReportConfig(cfg.Report)
+	if cfg.Report.Owners.Enabled {
+		rules, err := owners.Load(rootDir, cfg.Report.Owners.File)
+		if err != nil {
+			fmt.Printf("[!] Not grouping the report by owner: %v\n", err)
+		} else {
+			codeReviewAgent.SetOwners(rules)
+		}
+	}
+	if cfg.Report.Template != "" {
+		tmpl, err := agent.LoadReportTemplate(rootDir, cfg.Report.Template)
+		if err != nil {
+			fmt.Printf("[!] Using the default report layout: %v\n", err)
+		} else {
+			codeReviewAgent.SetReportTemplate(tmpl, reviewMetadata(rootDir))
+		}
+	}
+	if cfg.History.Enabled {
+		store, err := trends.Open(historyPath(rootDir, cfg.History))
+		if err != nil {
+			fmt.Printf("[!] Not recording the review in the history: %v\n", err)
+		} else {
+			codeReviewAgent.SetHistory(store, reviewMetadata(rootDir), recorder)
+		}
+	}
+	if opts.lookupTickets && cfg.Integrations.Tickets.Provider != "" {
+		intent, err := intentContext(rootDir, cfg.Integrations.Tickets)
+		if err != nil {
+			fmt.Printf("[!] Reviewing without the referenced tickets: %v\n", err)
+		} else {
+			codeReviewAgent.SetIntentContext(intent)
+		}
+	}
+	if cfg.Integrations.OSV.Enabled {
+		codeReviewAgent.SetVulnerabilitySource(dependencies.NewOSVClient(cfg.Integrations.OSV.BaseURL))
+	}
+	codeReviewAgent.SetStructuredOutput(cfg.LLM.StructuredOutput)
+	if profile != profileSecurity {
+		codeReviewAgent.SetPromptConfig(cfg.Prompts)
+	}
+	codeReviewAgent.SetChecksConfig(cfg.Checks)
+	codeReviewAgent.SetSecretRedaction(redactSecrets)
+	codeReviewAgent.SetTelemetry(opts.telemetry)
+	if cfg.Privacy.Enabled && remote {
+		codeReviewAgent.SetAnonymizer(privacy.NewAnonymizer(cfg.Privacy.Identifiers))
+		fmt.Println("[i] Privacy mode: anonymizing the code sent to the model")
+	}
+	codeReviewAgent.SetGeneratedConfig(cfg.Generated)
+	if err := codeReviewAgent.SetVerificationConfig(cfg.Verification); err != nil {
+		return nil, fmt.Errorf("invalid verification config: %w", err)
+	}
+	for _, plugin := range cfg.Plugins {
+		pluginTool, err := tools.NewPluginTool(plugin, rootDir)
+		if err == nil {
+			err = codeReviewAgent.AddReviewTool(pluginTool)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid plugin config: %w", err)
+		}
 	}
+	for _, server := range cfg.MCPServers {
+		if err := addMCPTools(codeReviewAgent, server, rootDir); err != nil {
+			codeReviewAgent.Close()
+			return nil, fmt.Errorf("invalid MCP server config: %w", err)
+		}
+	}
+	if cfg.Exploration.Enabled {
+		codeReviewAgent.SetExplorationTools(cfg.Exploration.MaxCalls,
+			tools.NewRepositoryReadFileTool(rootDir, cfg.Exploration.ReadPaths),
+			toolRegistry.Get(tools.ToolNameGitGrep),
+			tools.NewSymbolLookupTool(symbolContextTool))
+	}
+	codeReviewAgent.SetSeverities(severities)
+	if err := codeReviewAgent.SetEnsemble(members, cfg.Ensemble.Policy, cfg.Ensemble.MinAgreement); err != nil {
+		return nil, fmt.Errorf("invalid ensemble config: %w", err)
+	}
+
+	return codeReviewAgent, nil
+}
+
+// toolPolicy confines the tool calls of the model to the repository, writing at most the
+// report, into its directory when it has one of its own. The calls go to the audit log.
+func toolPolicy(rootDir string, reportConfig config.ReportConfig, auditLog *audit.Log) *tools.Policy {
+	policy := &tools.Policy{Root: rootDir}
+	for _, reportPath := range agent.ReportPaths(reportConfig) {
+		if dir := filepath.Dir(reportPath); dir != "." {
+			reportPath = dir
+		}
+		policy.WritePaths = append(policy.WritePaths, reportPath)
+	}
+	if auditLog != nil {
+		policy.Record = func(invocation tools.Invocation) {
+			if err := auditLog.RecordTool(invocation); err != nil {
+				fmt.Printf("[!] %v\n", err)
+			}
+		}
+	}
+	return policy
+}
+
+// reviewMetadata describes the checked out commit and branch to the report template, left
+// empty outside a git repository
+func reviewMetadata(rootDir string) agent.ReportMetadata {
+	repo := vcs.NewGit(rootDir)
+	commit, _ := repo.HeadCommit()
+	branch, _ := repo.Branch()
+	return agent.ReportMetadata{Commit: commit, Branch: branch}
+}
+
+// newProvider connects to the model of llmConfig and wraps it with the timeout, the quota,
+// the logging, the telemetry and the audit log of the review. It also returns whether the
+// model is remote.
+func newProvider(llmConfig config.LLMConfig, cfg *config.Config, auditLog *audit.Log, recorder *usage.Recorder, opts options) (llm.Provider, bool, error) {
+	if !slices.Contains(llm.SupportedProviders, llmConfig.Provider) {
+		return nil, false, fmt.Errorf("unsupported LLM provider: %s", llmConfig.Provider)
+	}
+
+	if err := utils.ValidateModel(llmConfig.Model); err != nil {
+		return nil, false, fmt.Errorf("model validation failed: %w", err)
+	}
+
+	providerConfig := llm.ProviderConfig{
+		Type:    llm.ProviderType(llmConfig.Provider),
+		Model:   llmConfig.Model,
+		BaseURL: llmConfig.BaseURL,
+		APIKey:  llmConfig.APIKey,
+		Azure: llm.AzureConfig{
+			Deployment:   llmConfig.Azure.Deployment,
+			APIVersion:   llmConfig.Azure.APIVersion,
+			ADToken:      llmConfig.Azure.ADToken,
+			TenantID:     llmConfig.Azure.TenantID,
+			ClientID:     llmConfig.Azure.ClientID,
+			ClientSecret: llmConfig.Azure.ClientSecret,
+		},
+		Embedded: llm.EmbeddedConfig{
+			ModelPath:   llmConfig.Embedded.ModelPath,
+			ContextSize: llmConfig.Embedded.ContextSize,
+			Threads:     llmConfig.Embedded.Threads,
+		},
+		Policy: llm.RemotePolicy{
+			AllowRemote: opts.allowRemote || cfg.Security.AllowRemote,
+			DenyHosts:   cfg.Security.DenyHosts,
+		},
+	}
+	if recorder != nil {
+		providerConfig.Transport = recorder.Transport(nil)
+	}
+
+	llmProvider, err := llm.NewProvider(providerConfig)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create LLM provider: %w", err)
+	}
+
+	modelDisplay := llmProvider.GetModel()
+	if modelDisplay == "" || modelDisplay == "llama.cpp" {
+		modelDisplay = "model loaded at server startup"
+	}
+	fmt.Printf("Using %s API with %s\n\n", llmConfig.Provider, modelDisplay)
+
+	if err := checkProvider(llmProvider, llmConfig.AutoPull); err != nil {
+		return nil, false, err
+	}
+	llmProvider = llm.WithTimeout(llmProvider, time.Duration(llmConfig.TimeoutSeconds)*time.Second)
+	// Outside the timeout, waiting for the quota does not count as a slow call
+	llmProvider = opts.rateLimiter.Wrap(llmProvider, llmConfig.BaseURL, llm.RateLimit{
+		RequestsPerMinute: llmConfig.RateLimit.RequestsPerMinute,
+		TokensPerMinute:   llmConfig.RateLimit.TokensPerMinute,
+	})
+	llmProvider = logging.WrapProvider(llmProvider)
+	llmProvider = opts.telemetry.WrapProvider(llmProvider, llmConfig.Provider)
+	if auditLog != nil {
+		llmProvider = auditLog.Wrap(llmProvider, llmConfig.Provider, llmConfig.BaseURL)
+	}
+
+	return llmProvider, llm.IsRemote(providerConfig), nil
+}
+
+// checkProvider fails fast when the LLM backend cannot serve the review, instead of on the
+// first chat call. A missing Ollama model is pulled first when autoPull is set.
+func checkProvider(provider llm.Provider, autoPull bool) error {
+	err := provider.HealthCheck()
+
+	puller, canPull := provider.(llm.ModelPuller)
+	if errors.Is(err, llm.ErrModelNotFound) && autoPull && canPull {
+		fmt.Printf("Pulling %s, this may take a while...\n", provider.GetModel())
+		if pullErr := puller.PullModel(); pullErr != nil {
+			return fmt.Errorf("LLM health check failed: %w", pullErr)
+		}
+		err = provider.HealthCheck()
+	}
+
+	if err != nil {
+		return fmt.Errorf("LLM health check failed: %w", err)
+	}
+	return nil
+}
+
+// addMCPTools offers the tools of an MCP server to the model. An unreachable server only
+// costs its context, so the review goes on without it.
+func addMCPTools(codeReviewAgent *agent.CodeReviewAgent, server config.MCPServerConfig, rootDir string) error {
+	if server.Name == "" {
+		return fmt.Errorf("MCP server without name")
+	}
+
+	var client *mcp.Client
+	if server.URL != "" {
+		client = mcp.NewHTTPClient(server.Name, server.URL, server.Headers, nil)
+	} else {
+		var err error
+		client, err = mcp.NewStdioClient(server.Name, server.Command, server.Env, rootDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	var mcpTools []*tools.MCPTool
+	err := client.Initialize()
+	if err == nil {
+		mcpTools, err = tools.NewMCPTools(client, server.Tools)
+	}
+	if err != nil {
+		_ = client.Close()
+		fmt.Printf("[!] Reviewing without the tools of MCP server %s: %v\n", server.Name, err)
+		return nil
+	}
+
+	for _, tool := range mcpTools {
+		if err := codeReviewAgent.AddReviewTool(tool); err != nil {
+			_ = client.Close()
+			return err
+		}
+	}
+	if len(mcpTools) == 0 {
+		_ = client.Close()
+	}
+	fmt.Printf("Using %d tool(s) of MCP server %s\n", len(mcpTools), server.Name)
+	return nil
+}
+
+func validatePromptConfig(promptConfig config.PromptConfig) error {
+	for language, variant := range promptConfig.Languages {
+		if _, err := prompts.GetPromptVariant(variant); err != nil {
+			return fmt.Errorf("invalid prompt for language %s: %w", language, err)
+		}
+	}
+	for _, rule := range promptConfig.Paths {
+		if _, err := prompts.GetPromptVariant(rule.Variant); err != nil {
+			return fmt.Errorf("invalid prompt for path %s: %w", rule.Pattern, err)
+		}
+	}
+	return nil
 }
 
 func showHelp() {
@@ -168,4 +987,35 @@ func showHelp() {
 	fmt.Println("• For branch reviews, ensure SSH keys are set up for your Git platform")
 	fmt.Println("• Always run from your Git repository root for proper symbol analysis")
 	fmt.Println()
+	fmt.Println("Flags:")
+	fmt.Println("• --watch: continuously review modified files while you edit")
+	fmt.Println("• --repo <path>: review the staged changes of several repositories (repeatable)")
+	fmt.Println("• --profile security: focus on vulnerabilities, tracing changes back to HTTP handlers")
+	fmt.Println("• --profile <name>: review with the model, prompt, context strategy and severity gate of a profile of the config")
+	fmt.Println("• --summary-line: end with a DIFFPECTOR_RESULT line for shell scripts")
+	fmt.Println("• --min-confidence <0-1>: hide findings the model is not confident about")
+	fmt.Println("• --full: review every staged file, also the ones unchanged since the last review")
+	fmt.Println("• --resume: continue an interrupted review without reviewing its finished files again")
+	fmt.Println("• --ab <a>,<b>: review with two prompt variants or models and compare what each finds")
+	fmt.Println("• --bitbucket-pr <id>: review a Bitbucket pull request and comment on it")
+	fmt.Println("• --gerrit-change <change>[/<patch set>]: review a Gerrit change and vote on it")
+	fmt.Println("• --emit-patches <dir>: ask for a fix per issue and write them as .patch files")
+	fmt.Println("• --fix-gitignore: add the report files to .gitignore, so they do not end up in a commit")
+	fmt.Println("• --allow-remote: let a provider outside this machine and its private network receive the code")
+	fmt.Println("• --log-level debug --log-file <path>: write JSON events of each pipeline stage, such as the model call durations, to diagnose a failing review")
+	fmt.Println("• --output compact|rdjson|rdjsonl: print the findings as file:line:col: severity: message lines or reviewdog diagnostics, the progress goes to stderr")
+	fmt.Println("• --output junit --junit-threshold <severity>: print a JUnit XML test report with a test case per file, failing on the issues at least that severe")
+	fmt.Println("• --output checkstyle: print a Checkstyle XML report for CI annotators such as Jenkins warnings-ng")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("• review [paths...]: review the staged changes right away, only of the given files and directories")
+	fmt.Println("• fix [paths...]: review the staged changes, then apply and stage the suggested fixes you accept")
+	fmt.Println("• describe [commit|pr]: generate a commit message or PR description of the staged changes, --write saves it to .git/COMMIT_EDITMSG")
+	fmt.Println("• mcp-serve: offer the review, symbol context and file symbols as MCP tools over stdio")
+	fmt.Println("• serve: keep the review warm and answer /review, /context and /symbols requests over HTTP, --listen sets the address")
+	fmt.Println("• config show [--effective]: list the system, user and repository config files, --effective prints every merged field with the file or flag it comes from")
+	fmt.Println("• config validate: check the merged configuration, warning about unknown keys and failing on invalid values")
+	fmt.Println("• init: detect the available providers and write a diffpectrc.json from your answers, optionally adding the report files to .gitignore")
+	fmt.Println("• stats: print the trends of the reviews recorded with history enabled, issues per KLOC changed, most flagged directories and critical issues per week")
+	fmt.Println()
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/agusespa/diffpector/internal/mcp"
+)
+
+// runMCPServe offers the review and the context extraction as MCP tools over stdio. Stdout
+// carries the protocol only, progress goes to stderr.
+func runMCPServe(ctx context.Context, opts options) error {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	service := newAnalysisService(opts)
+	defer service.Close()
+
+	server := mcp.NewServer("diffpector", "1.0.0")
+
+	server.AddTool(mcp.Tool{
+		Name:        "review_diff",
+		Description: "Review code changes and return the issues found as JSON. Reviews the staged changes unless a unified diff is given.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"diff":  map[string]any{"type": "string", "description": "Unified diff with repository-relative paths, the staged changes when empty"},
+				"paths": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Only review the staged changes under these files and directories"},
+			},
+		},
+	}, func(arguments map[string]any) (string, error) {
+		var request reviewRequest
+		if err := decodeArguments(arguments, &request); err != nil {
+			return "", err
+		}
+		issues, err := service.review(ctx, request)
+		if err != nil {
+			return "", err
+		}
+		return toJSON(issues)
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "symbol_context",
+		Description: "Return the declarations touched by code changes and the definitions and usages of the affected symbols. Uses the staged changes unless a unified diff is given.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"diff": map[string]any{"type": "string", "description": "Unified diff with repository-relative paths, the staged changes when empty"},
+			},
+		},
+	}, func(arguments map[string]any) (string, error) {
+		var request contextRequest
+		if err := decodeArguments(arguments, &request); err != nil {
+			return "", err
+		}
+		files, err := service.context(request)
+		if err != nil {
+			return "", err
+		}
+		return toJSON(files)
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "file_symbols",
+		Description: "Parse a source file and return the symbols it declares with their line ranges",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"file_path": map[string]any{"type": "string", "description": "Path of the file relative to the repository root"},
+			},
+			"required": []string{"file_path"},
+		},
+	}, func(arguments map[string]any) (string, error) {
+		var request symbolsRequest
+		if err := decodeArguments(arguments, &request); err != nil {
+			return "", err
+		}
+		symbols, err := service.symbols(request)
+		if err != nil {
+			return "", err
+		}
+		return toJSON(symbols)
+	})
+
+	fmt.Fprintln(os.Stderr, "Serving MCP tools on stdio")
+	return server.Serve(os.Stdin, stdout)
+}
+
+func toJSON(value any) (string, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(data), nil
+}
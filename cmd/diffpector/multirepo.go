@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/agent"
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/usage"
+)
+
+// repoList collects the values of a repeatable --repo flag
+type repoList []string
+
+func (r *repoList) String() string {
+	return strings.Join(*r, ",")
+}
+
+func (r *repoList) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// runMultiRepoReview reviews the staged changes of every repository and writes one report
+// grouped by repository. A repository that fails to be set up or reviewed does not stop the
+// others, it is listed in the report and fails the run once the others are reviewed.
+func runMultiRepoReview(ctx context.Context, repos []string, opts options) error {
+	recorder := usage.NewRecorder()
+
+	var reportAgent *agent.CodeReviewAgent
+	var groups []agent.RepositoryIssues
+	var failed []string
+	files := 0
+
+	for _, repoPath := range repos {
+		fmt.Printf("=== Repository: %s ===\n", repoPath)
+
+		codeReviewAgent, err := newReviewAgent(repoPath, recorder, opts)
+		if err != nil {
+			fmt.Printf("[!] Could not set up the review of %s: %v\n\n", repoPath, err)
+			groups = append(groups, agent.RepositoryIssues{Repository: repoPath, Error: err.Error()})
+			failed = append(failed, repoPath)
+			continue
+		}
+		defer codeReviewAgent.Close()
+		if reportAgent == nil {
+			reportAgent = codeReviewAgent
+			checkReportIgnored(reportAgent.ReportPaths(), opts)
+		}
+
+		issues, err := codeReviewAgent.CollectStagedIssues(ctx)
+		files += codeReviewAgent.Result().Files
+		// An interrupted review keeps what the repository found so far and skips the rest
+		stopped := ctx.Err() != nil
+		if err != nil && !stopped {
+			fmt.Printf("[!] Review of %s failed: %v\n\n", repoPath, err)
+			groups = append(groups, agent.RepositoryIssues{Repository: repoPath, Error: err.Error()})
+			failed = append(failed, repoPath)
+			continue
+		}
+
+		// Issue paths are relative to their repository, the report reads them from here
+		for i := range issues {
+			issues[i].FilePath = platform.NormalizePath(filepath.Join(repoPath, issues[i].FilePath))
+		}
+
+		questions := codeReviewAgent.Result().Questions
+		for i := range questions {
+			questions[i].FilePath = platform.NormalizePath(filepath.Join(repoPath, questions[i].FilePath))
+		}
+
+		groups = append(groups, agent.RepositoryIssues{Repository: repoPath, Issues: issues, Questions: questions})
+		fmt.Println()
+		if stopped {
+			fmt.Printf("[!] Review interrupted, the repositories after %s are not reviewed\n\n", repoPath)
+			break
+		}
+	}
+
+	if reportAgent != nil {
+		if err := reportAgent.GenerateGroupedReport(groups); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println()
+	recorder.Summary().Print()
+
+	if reportAgent != nil {
+		if err := printFindings(opts, reportAgent.Result()); err != nil {
+			return err
+		}
+	}
+	if opts.summaryLine {
+		// Without a repository set up there is no result, only the failed repositories
+		result := agent.ReviewResult{FailedRepositories: failed}
+		if reportAgent != nil {
+			result = reportAgent.Result()
+		}
+		result.Files = files
+		fmt.Println(result.SummaryLine())
+	}
+	if reportAgent != nil {
+		if err := gateError(reportAgent.Result()); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("review interrupted: %w", err)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("the review of %d repositories failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/agusespa/diffpector/internal/vcs"
+)
+
+// enterRepoRoot changes to the root of the repository containing the working directory, so
+// that diffpector runs the same from any of its subdirectories, and returns the directory it
+// was started from. Within a submodule the root is the submodule's, whose changes are the
+// ones staged there. Outside of a repository it stays, for the commands needing none.
+func enterRepoRoot() (string, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	// The root printed by git has its symlinks resolved, the paths given from here must too
+	if resolved, err := filepath.EvalSymlinks(workDir); err == nil {
+		workDir = resolved
+	}
+
+	repo, err := vcs.Detect(workDir)
+	if err != nil {
+		return workDir, nil
+	}
+	root, err := repo.Root()
+	if err != nil {
+		return workDir, nil
+	}
+
+	if git, ok := repo.(*vcs.Git); ok {
+		if superproject, err := git.SuperprojectRoot(); err == nil && superproject != "" {
+			fmt.Fprintf(os.Stderr, "[i] %s is a submodule of %s, reviewing the changes staged in the submodule\n", root, superproject)
+		}
+	}
+
+	if err := os.Chdir(root); err != nil {
+		return "", fmt.Errorf("failed to change to the repository root %s: %w", root, err)
+	}
+	return workDir, nil
+}
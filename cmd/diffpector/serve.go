@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultServeAddress only accepts local connections
+const defaultServeAddress = "127.0.0.1:8471"
+
+// serveTokenVariable sets the bearer token of the API, a random one is generated otherwise
+const serveTokenVariable = "DIFFPECTOR_SERVE_TOKEN"
+
+// runServe keeps the review agent and the parsers warm and answers review, context and
+// symbols requests over HTTP until interrupted
+func runServe(ctx context.Context, address string, opts options) error {
+	service := newAnalysisService(opts)
+	defer service.Close()
+
+	if err := service.warmUp(); err != nil {
+		fmt.Printf("[!] The review agent is not ready, /review will retry: %v\n", err)
+	}
+
+	token := os.Getenv(serveTokenVariable)
+	if token == "" {
+		var err error
+		if token, err = randomToken(); err != nil {
+			return fmt.Errorf("failed to generate the API token: %w", err)
+		}
+	}
+	listenHost, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %s: %w", address, err)
+	}
+
+	server := &http.Server{Addr: address, Handler: guardRequests(newServeMux(service), token, listenHost)}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("[i] Send the API token as \"Authorization: Bearer %s\"\n", token)
+	fmt.Printf("Serving on http://%s (press Ctrl+C to stop)...\n", address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve on %s: %w", address, err)
+	}
+	return nil
+}
+
+// randomToken returns a token of 32 random bytes, hex encoded
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// guardRequests only passes the requests bearing the token, with a JSON body, addressed to a
+// loopback host or to the host listened on. Browsers cannot send such requests from another
+// site, neither directly nor through a domain rebound to the local address.
+func guardRequests(next http.Handler, token, listenHost string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowedHost(r.Host, listenHost) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %s is not allowed", r.Host))
+			return
+		}
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, errors.New("the request body must be application/json"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost reports whether the Host header names a loopback address, localhost or the
+// host listened on when it is not a wildcard address
+func allowedHost(host, listenHost string) bool {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	listenIP := net.ParseIP(listenHost)
+	unspecified := listenHost == "" || (listenIP != nil && listenIP.IsUnspecified())
+	return !unspecified && strings.EqualFold(host, strings.Trim(listenHost, "[]"))
+}
+
+// newServeMux routes the endpoints of the daemon, each taking a JSON request body
+func newServeMux(service *analysisService) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /review", func(w http.ResponseWriter, r *http.Request) {
+		var request reviewRequest
+		if !decodeRequest(w, r, &request) {
+			return
+		}
+		issues, err := service.review(r.Context(), request)
+		writeResponse(w, map[string]any{"issues": issues}, err)
+	})
+	mux.HandleFunc("POST /context", func(w http.ResponseWriter, r *http.Request) {
+		var request contextRequest
+		if !decodeRequest(w, r, &request) {
+			return
+		}
+		files, err := service.context(request)
+		writeResponse(w, map[string]any{"files": files}, err)
+	})
+	mux.HandleFunc("POST /symbols", func(w http.ResponseWriter, r *http.Request) {
+		var request symbolsRequest
+		if !decodeRequest(w, r, &request) {
+			return
+		}
+		if request.FilePath == "" {
+			writeError(w, http.StatusBadRequest, errMissingFilePath)
+			return
+		}
+		symbols, err := service.symbols(request)
+		writeResponse(w, map[string]any{"symbols": symbols}, err)
+	})
+	return mux
+}
+
+// decodeRequest reads the JSON body into request, an empty body leaving the defaults. It
+// answers with an error and returns false when the body is invalid.
+func decodeRequest(w http.ResponseWriter, r *http.Request, request any) bool {
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeResponse(w http.ResponseWriter, body any, err error) {
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/agusespa/diffpector/internal/agent"
+	"github.com/agusespa/diffpector/internal/types"
+	diffcontext "github.com/agusespa/diffpector/pkg/context"
+)
+
+// reviewRequest asks for the review of a unified diff, or of the staged changes under paths
+// when the diff is empty
+type reviewRequest struct {
+	Diff  string   `json:"diff"`
+	Paths []string `json:"paths"`
+}
+
+// contextRequest asks for the symbol context of a unified diff, or of the staged changes
+type contextRequest struct {
+	Diff string `json:"diff"`
+}
+
+type symbolsRequest struct {
+	FilePath string `json:"file_path"`
+}
+
+// errMissingFilePath is returned for a symbols request without a file
+var errMissingFilePath = errors.New("file_path is required")
+
+// analysisService answers the requests of mcp-serve and serve, keeping the review agent and
+// the parsers of the repository in the current directory between them. The agent is created
+// on the first review so the context requests also work without an LLM configured.
+// Requests are handled one at a time.
+type analysisService struct {
+	opts      options
+	extractor *diffcontext.Extractor
+
+	mu              sync.Mutex
+	codeReviewAgent *agent.CodeReviewAgent
+}
+
+func newAnalysisService(opts options) *analysisService {
+	return &analysisService{opts: opts, extractor: diffcontext.NewExtractor(".")}
+}
+
+// reviewAgent returns the agent, creating it on the first call. A failed creation is retried
+// on the next call.
+func (s *analysisService) reviewAgent() (*agent.CodeReviewAgent, error) {
+	if s.codeReviewAgent == nil {
+		codeReviewAgent, err := newReviewAgent(".", nil, s.opts)
+		if err != nil {
+			return nil, err
+		}
+		s.codeReviewAgent = codeReviewAgent
+	}
+	return s.codeReviewAgent, nil
+}
+
+// warmUp creates the review agent ahead of the first review
+func (s *analysisService) warmUp() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.reviewAgent()
+	return err
+}
+
+func (s *analysisService) review(ctx context.Context, request reviewRequest) ([]types.Issue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	codeReviewAgent, err := s.reviewAgent()
+	if err != nil {
+		return nil, err
+	}
+	defer flushTelemetry(s.opts.telemetry)
+
+	var issues []types.Issue
+	if request.Diff != "" {
+		issues, err = codeReviewAgent.ReviewDiff(ctx, []byte(request.Diff), ".")
+	} else {
+		codeReviewAgent.SetPathFilter(request.Paths)
+		issues, err = codeReviewAgent.CollectStagedIssues(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if issues == nil {
+		issues = []types.Issue{}
+	}
+	return issues, nil
+}
+
+func (s *analysisService) context(request contextRequest) ([]diffcontext.FileContext, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var files []diffcontext.FileContext
+	var err error
+	if request.Diff != "" {
+		files, err = s.extractor.FromDiff([]byte(request.Diff))
+	} else {
+		files, err = s.extractor.FromStagedChanges()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if files == nil {
+		files = []diffcontext.FileContext{}
+	}
+	return files, nil
+}
+
+func (s *analysisService) symbols(request symbolsRequest) ([]diffcontext.Symbol, error) {
+	if request.FilePath == "" {
+		return nil, errMissingFilePath
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.extractor.FileSymbols(request.FilePath)
+}
+
+func (s *analysisService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.codeReviewAgent != nil {
+		s.codeReviewAgent.Close()
+	}
+}
+
+// decodeArguments converts the arguments of a tool call into a request
+func decodeArguments(arguments map[string]any, request any) error {
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return fmt.Errorf("failed to encode arguments: %w", err)
+	}
+	if err := json.Unmarshal(data, request); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
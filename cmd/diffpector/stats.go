@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/trends"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// statsDirectories is the number of most flagged directories printed by the stats command
+const statsDirectories = 10
+
+// historyPath resolves the history file of the repository at rootDir
+func historyPath(rootDir string, cfg config.HistoryConfig) string {
+	path := cfg.Path
+	if path == "" {
+		path = trends.DefaultPath
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(rootDir, path)
+	}
+	return path
+}
+
+// runStats prints the trends of the reviews recorded in the history of the repository at
+// rootDir
+func runStats(rootDir string) error {
+	cfg, err := config.LoadConfig(filepath.Join(rootDir, "diffpectrc.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load config from diffpectrc.json: %w", err)
+	}
+	severities, err := severity.New(cfg.Severities)
+	if err != nil {
+		return fmt.Errorf("invalid severities config: %w", err)
+	}
+
+	store, err := trends.Open(historyPath(rootDir, cfg.History))
+	if err != nil {
+		return err
+	}
+	runs, err := store.Load()
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		fmt.Printf("No reviews recorded in %s, enable \"history\" in diffpectrc.json to record them\n", store.Path())
+		return nil
+	}
+
+	critical := severities.Levels()[0]
+	fmt.Print(trends.Compute(runs, critical).Format(critical, statsDirectories))
+	return nil
+}
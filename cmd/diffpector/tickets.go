@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/agusespa/diffpector/internal/integrations/tickets"
+	"github.com/agusespa/diffpector/internal/vcs"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// intentContext looks up the tickets referenced by the checked out branch and by the commit
+// message prepared for the staged changes
+func intentContext(rootDir string, cfg config.TicketsConfig) (string, error) {
+	fetcher, err := tickets.NewFetcher(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	repo := vcs.NewGit(rootDir)
+	branch, err := repo.Branch()
+	if err != nil {
+		return "", err
+	}
+	message, err := repo.PendingCommitMessage()
+	if err != nil {
+		return "", err
+	}
+
+	found := tickets.Lookup(fetcher, branch, message)
+	if len(found) > 0 {
+		fmt.Printf("[i] Reviewing against %d referenced ticket(s)\n\n", len(found))
+	}
+	return tickets.Format(found), nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/usage"
+	"github.com/agusespa/diffpector/internal/watch"
+)
+
+const watchDebounce = 2 * time.Second
+
+func runWatchMode(ctx context.Context, opts options) error {
+	recorder := usage.NewRecorder()
+	codeReviewAgent, err := newReviewAgent(".", recorder, opts)
+	if err != nil {
+		return err
+	}
+	defer codeReviewAgent.Close()
+
+	watcher, err := watch.New(".", watchDebounce, func(relPath string) bool {
+		return slices.Contains(codeReviewAgent.ReportPaths(), relPath)
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = watcher.Close()
+	}()
+
+	fmt.Println("Watching for changes (press Ctrl+C to stop)...")
+
+	defer func() {
+		fmt.Println()
+		recorder.Summary().Print()
+	}()
+
+	return watcher.Run(ctx.Done(), func(paths []string) {
+		fmt.Println()
+		fmt.Printf("[%s] Changes detected in: %s\n", time.Now().Format("15:04:05"), strings.Join(paths, ", "))
+
+		if _, err := codeReviewAgent.ReviewWorkingTreeFiles(ctx, paths); err != nil {
+			fmt.Printf("[!] Review failed: %v\n", err)
+		}
+		flushTelemetry(opts.telemetry)
+
+		fmt.Println()
+		fmt.Println("Watching for changes...")
+	})
+}
@@ -9,9 +9,19 @@ import (
 	"github.com/agusespa/diffpector/internal/evaluation"
 	"github.com/agusespa/diffpector/internal/llm"
 	"github.com/agusespa/diffpector/internal/prompts"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/vcs"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		if err := runGenerate(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating test cases: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var (
 		suiteFile      = flag.String("suite", "evaluation/test_suite.json", "Path to evaluation test suite")
 		resultsDir     = flag.String("results", "evaluation/results", "Directory to store results")
@@ -20,9 +30,16 @@ func main() {
 		compare        = flag.Bool("compare", false, "Compare existing results instead of running new evaluation")
 		comparePrompts = flag.Bool("compare-prompts", false, "Compare prompt variants")
 		listPrompts    = flag.Bool("list-prompts", false, "List available prompt variants")
+		regress        = flag.String("regress", "", "Baseline result file to compare the latest results of its model and prompt against, exits 1 on regressions")
+		candidate      = flag.String("candidate", "", "Result file checked by --regress instead of the latest one in the results directory")
+		contextOnly    = flag.Bool("context-only", false, "Score only the context gathering of the annotated test cases, without an LLM")
 		llamaServer    = flag.String("llama-server", "llama-server", "Path to llama-server executable")
 		port           = flag.Int("port", 8080, "Port for llama-server")
 		serverArgs     = flag.String("server-args", "-c 65536 -n 8192 -ngl 99 -b 2048 -ub 1024 --threads 12", "Additional arguments for llama-server")
+		parallel       = flag.Int("parallel", 1, "Number of models evaluated concurrently, each on its own llama-server port")
+		providerLimit  = flag.Int("provider-concurrency", 1, "Maximum concurrent requests to each llama-server when evaluating in parallel")
+		requestsPerMin = flag.Int("requests-per-minute", 0, "Maximum requests per minute to each server, 0 is unlimited")
+		tokensPerMin   = flag.Int("tokens-per-minute", 0, "Maximum tokens per minute sent to and received from each server, 0 is unlimited")
 	)
 	flag.Parse()
 
@@ -53,18 +70,49 @@ func main() {
 		return
 	}
 
+	if *regress != "" {
+		regressed, err := runRegressionCheck(*regress, *candidate, *resultsDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error checking for regressions: %v\n", err)
+			os.Exit(1)
+		}
+		if regressed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *contextOnly {
+		if err := runContextEvaluation(*suiteFile, *resultsDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error running context evaluation: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if *variant == "" {
 		printHelp()
 		return
 	}
 
-	if err := runEvaluation(*suiteFile, *resultsDir, *configFile, *variant, *llamaServer, *port, *serverArgs); err != nil {
+	rate := llm.RateLimit{RequestsPerMinute: *requestsPerMin, TokensPerMinute: *tokensPerMin}
+
+	if *parallel > 1 {
+		err := runParallelEvaluation(*suiteFile, *resultsDir, *configFile, *variant, *llamaServer, *port, *serverArgs, *parallel, *providerLimit, rate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error running evaluation: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := runEvaluation(*suiteFile, *resultsDir, *configFile, *variant, *llamaServer, *port, *serverArgs, rate); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running evaluation: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func runEvaluation(suiteFile, resultsDir, configFile, variantKey, llamaServerPath string, port int, serverArgs string) error {
+func runEvaluation(suiteFile, resultsDir, configFile, variantKey, llamaServerPath string, port int, serverArgs string, rate llm.RateLimit) error {
 	configs, err := evaluation.LoadConfigs(configFile)
 	if err != nil {
 		return fmt.Errorf("failed to load evaluation configs: %w", err)
@@ -80,6 +128,8 @@ func runEvaluation(suiteFile, resultsDir, configFile, variantKey, llamaServerPat
 	serverManager := evaluation.NewServerManager(llamaServerPath, port, args...)
 	defer serverManager.StopServer()
 
+	limits := providerLimits{quota: llm.NewRateLimiter(), rate: rate}
+
 	for _, config := range configs {
 		if variantKey != "" && config.Key != variantKey {
 			continue
@@ -102,7 +152,7 @@ func runEvaluation(suiteFile, resultsDir, configFile, variantKey, llamaServerPat
 			serverCopy.BaseURL = fmt.Sprintf("http://localhost:%d", port)
 
 			for _, prompt := range config.Prompts {
-				runSingleEvaluation(evaluator, serverCopy, prompt, config.Runs)
+				runSingleEvaluation(evaluator, serverCopy, prompt, config.Runs, limits)
 			}
 
 			fmt.Printf("\nStopping server for %s...\n", server.Name)
@@ -110,16 +160,22 @@ func runEvaluation(suiteFile, resultsDir, configFile, variantKey, llamaServerPat
 		}
 	}
 
+	printCompletion()
+
+	return nil
+}
+
+func printCompletion() {
 	fmt.Println("\n------------------------------")
 	fmt.Println("All evaluations complete!")
 	fmt.Println("To compare results, run:")
 	fmt.Println("  make eval-compare-models")
 	fmt.Println("  make eval-compare-prompts")
-
-	return nil
 }
 
-func runSingleEvaluation(evaluator *evaluation.Evaluator, server evaluation.ServerConfig, prompt string, runs int) {
+// runSingleEvaluation evaluates one model/prompt combination, the limits queueing the
+// requests sent to the server when other combinations share it.
+func runSingleEvaluation(evaluator *evaluation.Evaluator, server evaluation.ServerConfig, prompt string, runs int, limits providerLimits) {
 	prompt = strings.TrimSpace(prompt)
 
 	if _, err := prompts.GetPromptVariant(prompt); err != nil {
@@ -142,7 +198,14 @@ func runSingleEvaluation(evaluator *evaluation.Evaluator, server evaluation.Serv
 
 	fmt.Printf("=== Running evaluation: %s with %s prompt ===\n", server.Name, prompt)
 
-	result, err := evaluator.RunEvaluation(llmConfig, server.Name, prompt, runs)
+	provider, err := llm.NewProvider(llmConfig)
+	if err != nil {
+		fmt.Printf("Error creating provider for %s: %v\n", server.Name, err)
+		return
+	}
+	provider = limits.wrap(provider, server.BaseURL)
+
+	result, err := evaluator.RunEvaluationWithProvider(provider, server.Name, prompt, runs)
 	if err != nil {
 		fmt.Printf("Error running evaluation for %s/%s: %v\n", server.Name, prompt, err)
 		return
@@ -161,6 +224,100 @@ func runSingleEvaluation(evaluator *evaluation.Evaluator, server evaluation.Serv
 	}
 }
 
+// runRegressionCheck reports whether the candidate scores dropped significantly from the baseline
+func runRegressionCheck(baselinePath, candidatePath, resultsDir string) (bool, error) {
+	baseline, err := evaluation.LoadEvaluationResult(baselinePath)
+	if err != nil {
+		return false, err
+	}
+
+	var candidate *evaluation.EvaluationResult
+	if candidatePath != "" {
+		candidate, err = evaluation.LoadEvaluationResult(candidatePath)
+	} else {
+		candidate, candidatePath, err = evaluation.LatestEvaluationResult(resultsDir, baseline.Model, baseline.PromptVariant)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Printf("Baseline:  %s\n", baselinePath)
+	fmt.Printf("Candidate: %s\n\n", candidatePath)
+
+	regressions := evaluation.DetectRegressions(baseline, candidate)
+	evaluation.PrintRegressions(baseline, candidate, regressions)
+
+	return len(regressions) > 0, nil
+}
+
+func runContextEvaluation(suiteFile, resultsDir string) error {
+	evaluator, err := evaluation.NewEvaluator(suiteFile, resultsDir)
+	if err != nil {
+		return fmt.Errorf("failed to create evaluator: %w", err)
+	}
+
+	result, err := evaluator.RunContextEvaluation()
+	if err != nil {
+		return err
+	}
+
+	evaluation.PrintContextEvaluation(result)
+
+	return evaluator.SaveContextEvaluation(result)
+}
+
+func runGenerate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	repoPath := flags.String("repo", ".", "Path to the repository to mine test cases from")
+	commitsFile := flags.String("commits", "", "JSON file listing buggy commits and the commits that fixed them")
+	suiteFile := flags.String("suite", "evaluation/test_suite.json", "Path to the evaluation test suite to extend")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *commitsFile == "" {
+		return fmt.Errorf("the --commits file is required")
+	}
+
+	commits, err := evaluation.LoadBugFixCommits(*commitsFile)
+	if err != nil {
+		return err
+	}
+
+	suite, err := evaluation.LoadSuite(*suiteFile)
+	if err != nil {
+		return err
+	}
+
+	repo, err := vcs.Detect(*repoPath)
+	if err != nil {
+		return err
+	}
+
+	generator := evaluation.NewSuiteGenerator(repo, suite)
+
+	var testCases []types.TestCase
+	for _, commit := range commits {
+		testCase, err := generator.Generate(commit)
+		if err != nil {
+			fmt.Printf("Warning: skipping %s: %v\n", commit.Commit, err)
+			continue
+		}
+		fmt.Printf("Generated '%s' (%d expected file(s))\n", testCase.Name, len(testCase.Expected.ExpectedFiles))
+		testCases = append(testCases, testCase)
+	}
+
+	added, err := evaluation.AddTestCases(*suiteFile, testCases)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nAdded %d test case(s) to %s\n", added, *suiteFile)
+	fmt.Println("Review the generated expectations before running evaluations.")
+
+	return nil
+}
+
 func printHelp() {
 	fmt.Println("Use 'make eval-help' to see available evaluation commands")
 }
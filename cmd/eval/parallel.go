@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/agusespa/diffpector/internal/evaluation"
+	"github.com/agusespa/diffpector/internal/llm"
+)
+
+// providerLimits queue the requests of the evaluations sharing a server
+type providerLimits struct {
+	// concurrency bounds the requests in flight, nil leaves them unbounded
+	concurrency *evaluation.RateLimiter
+	// quota spreads the requests and tokens over time as rate says
+	quota *llm.RateLimiter
+	rate  llm.RateLimit
+}
+
+// wrap limits the requests of provider under the server key
+func (l providerLimits) wrap(provider llm.Provider, key string) llm.Provider {
+	if l.quota != nil {
+		provider = l.quota.Wrap(provider, key, l.rate)
+	}
+	if l.concurrency != nil {
+		provider = l.concurrency.Wrap(provider, key)
+	}
+	return provider
+}
+
+// modelJob is one model of the evaluation matrix with the prompts to evaluate it with
+type modelJob struct {
+	config evaluation.EvaluationConfig
+	server evaluation.ServerConfig
+}
+
+// runParallelEvaluation evaluates up to parallel models at a time, each on its own
+// llama-server listening on port, port+1, ... The prompts of a model run concurrently
+// against its server, with at most providerLimit requests in flight per server and within
+// the rate per server.
+func runParallelEvaluation(suiteFile, resultsDir, configFile, variantKey, llamaServerPath string, port int, serverArgs string, parallel, providerLimit int, rate llm.RateLimit) error {
+	configs, err := evaluation.LoadConfigs(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load evaluation configs: %w", err)
+	}
+
+	evaluator, err := evaluation.NewEvaluator(suiteFile, resultsDir)
+	if err != nil {
+		return fmt.Errorf("failed to create evaluator: %w", err)
+	}
+
+	var jobs []modelJob
+	for _, config := range configs {
+		if variantKey != "" && config.Key != variantKey {
+			continue
+		}
+		for _, server := range config.Servers {
+			if server.ModelPath == "" {
+				return fmt.Errorf("server '%s' missing model_path", server.Name)
+			}
+			jobs = append(jobs, modelJob{config: config, server: server})
+		}
+	}
+
+	fmt.Printf("Running %d model(s), %d at a time\n\n", len(jobs), parallel)
+
+	args := strings.Fields(serverArgs)
+	limits := providerLimits{
+		concurrency: evaluation.NewRateLimiter(providerLimit),
+		quota:       llm.NewRateLimiter(),
+		rate:        rate,
+	}
+
+	// Each port hosts one llama-server at a time, taking one bounds the running models
+	ports := make(chan int, parallel)
+	for i := 0; i < parallel; i++ {
+		ports <- port + i
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for _, job := range jobs {
+		serverPort := <-ports
+		wg.Add(1)
+		go func(job modelJob, serverPort int) {
+			defer wg.Done()
+			defer func() { ports <- serverPort }()
+
+			if err := runModelJob(evaluator, job, llamaServerPath, serverPort, args, limits); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(job, serverPort)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	printCompletion()
+
+	return nil
+}
+
+func runModelJob(evaluator *evaluation.Evaluator, job modelJob, llamaServerPath string, port int, args []string, limits providerLimits) error {
+	serverManager := evaluation.NewServerManager(llamaServerPath, port, args...)
+	defer serverManager.StopServer()
+
+	fmt.Printf("Loading model %s on port %d\n", job.server.Name, port)
+	if err := serverManager.StartServer(job.server.ModelPath); err != nil {
+		return fmt.Errorf("failed to start server for %s: %w", job.server.Name, err)
+	}
+
+	server := job.server
+	server.BaseURL = fmt.Sprintf("http://localhost:%d", port)
+
+	var wg sync.WaitGroup
+	for _, prompt := range job.config.Prompts {
+		wg.Add(1)
+		go func(prompt string) {
+			defer wg.Done()
+			runSingleEvaluation(evaluator.Fork(), server, prompt, job.config.Runs, limits)
+		}(prompt)
+	}
+	wg.Wait()
+
+	fmt.Printf("\nStopping server for %s...\n", job.server.Name)
+	return nil
+}
@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// ABReportFileName is the report of an A/B review, apart from the review report
+const ABReportFileName = "diffpector_ab_report.md"
+
+// ABReportPath returns where the A/B report is written: next to the configured report.path,
+// or in the current directory by default
+func (a *CodeReviewAgent) ABReportPath() string {
+	if a.reportConfig.Path == "" {
+		return ABReportFileName
+	}
+	return path.Join(path.Dir(platform.NormalizePath(a.reportConfig.Path)), ABReportFileName)
+}
+
+// ABSide is the outcome of one configuration of an A/B review
+type ABSide struct {
+	Label  string
+	Issues []types.Issue
+	// LLMCalls and LLMTime measure the cost of the configuration
+	LLMCalls int
+	LLMTime  time.Duration
+}
+
+// ABComparison pairs the findings of two reviews of the same changes
+type ABComparison struct {
+	Sides [2]ABSide
+	// Both pairs the findings of the two sides at the same location
+	Both [][2]types.Issue
+	// Only holds the findings of each side the other one missed
+	Only [2][]types.Issue
+}
+
+// CompareIssues pairs the findings of the two sides by location: the same file and check
+// with overlapping or neighboring lines. Descriptions are not compared, the wording of two
+// prompts or models differing even for the same issue. Among several candidates the one with
+// the most similar description is paired.
+func CompareIssues(a, b ABSide) ABComparison {
+	comparison := ABComparison{Sides: [2]ABSide{a, b}}
+	paired := make([]bool, len(b.Issues))
+
+	for _, issue := range a.Issues {
+		issueWords := descriptionWords(issue.Description)
+		match, best := -1, -1.0
+		for i, candidate := range b.Issues {
+			if paired[i] || !sameLocation(issue, candidate) {
+				continue
+			}
+			if similarity := jaccard(issueWords, descriptionWords(candidate.Description)); similarity > best {
+				match, best = i, similarity
+			}
+		}
+
+		if match == -1 {
+			comparison.Only[0] = append(comparison.Only[0], issue)
+			continue
+		}
+		paired[match] = true
+		comparison.Both = append(comparison.Both, [2]types.Issue{issue, b.Issues[match]})
+	}
+
+	for i, issue := range b.Issues {
+		if !paired[i] {
+			comparison.Only[1] = append(comparison.Only[1], issue)
+		}
+	}
+	return comparison
+}
+
+func sameLocation(a, b types.Issue) bool {
+	if a.FilePath != b.FilePath || a.Category != b.Category {
+		return false
+	}
+	return b.StartLine <= a.EndLine+neighborLines && a.StartLine <= b.EndLine+neighborLines
+}
+
+// GenerateABReport writes the side by side comparison of an A/B review and returns its path
+func (a *CodeReviewAgent) GenerateABReport(comparison ABComparison) (string, error) {
+	readTool := a.toolRegistry.Get(tools.ToolNameReadFile)
+	writeTool := a.toolRegistry.Get(tools.ToolNameWriteFile)
+	reportGen := NewReportGenerator(readTool, writeTool, a.reportConfig, a.severities)
+	sides := comparison.Sides
+
+	var reportBuilder strings.Builder
+	reportBuilder.WriteString("# A/B Review Report\n\n")
+	reportBuilder.WriteString(fmt.Sprintf("| | A: `%s` | B: `%s` |\n|---|---|---|\n", sides[0].Label, sides[1].Label))
+	reportBuilder.WriteString(fmt.Sprintf("| Issues | %d | %d |\n", len(sides[0].Issues), len(sides[1].Issues)))
+	for _, level := range a.severities.Levels() {
+		reportBuilder.WriteString(fmt.Sprintf("| %s %s | %d | %d |\n", a.severities.Icon(level), strings.ToLower(level),
+			a.countLevel(sides[0].Issues, level), a.countLevel(sides[1].Issues, level)))
+	}
+	reportBuilder.WriteString(fmt.Sprintf("| Only found by this side | %d | %d |\n", len(comparison.Only[0]), len(comparison.Only[1])))
+	reportBuilder.WriteString(fmt.Sprintf("| LLM calls | %d | %d |\n", sides[0].LLMCalls, sides[1].LLMCalls))
+	reportBuilder.WriteString(fmt.Sprintf("| LLM time | %s | %s |\n\n", sides[0].LLMTime.Round(time.Second), sides[1].LLMTime.Round(time.Second)))
+	reportBuilder.WriteString(fmt.Sprintf("%d issue(s) found by both\n\n", len(comparison.Both)))
+
+	counts := make(severityCounts)
+	for i, name := range []string{"A", "B"} {
+		reportBuilder.WriteString(fmt.Sprintf("# Only found by %s: `%s`\n\n", name, sides[i].Label))
+		if len(comparison.Only[i]) == 0 {
+			reportBuilder.WriteString("No issues the other side missed\n\n")
+			continue
+		}
+		reportGen.writeIssues(&reportBuilder, comparison.Only[i], counts)
+		reportBuilder.WriteString("\n")
+	}
+
+	if len(comparison.Both) > 0 {
+		reportBuilder.WriteString("# Found by both\n\n| Location | A | B |\n|---|---|---|\n")
+		for _, pair := range comparison.Both {
+			reportBuilder.WriteString(fmt.Sprintf("| `%s:%d` | %s %s | %s %s |\n", pair[0].FilePath, pair[0].StartLine,
+				a.severities.Icon(pair[0].Severity), tableCell(pair[0].Description),
+				a.severities.Icon(pair[1].Severity), tableCell(pair[1].Description)))
+		}
+	}
+
+	reportPath := a.ABReportPath()
+	if dir := filepath.Dir(reportPath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create the report directory: %w", err)
+		}
+	}
+	if err := platform.IgnoreStateDir(reportPath); err != nil {
+		fmt.Printf("[!] Could not ignore %s: %v\n", platform.StateDir, err)
+	}
+	_, err := writeTool.Execute(context.Background(), map[string]any{"filename": reportPath, "content": reportBuilder.String()})
+	if err != nil {
+		return "", fmt.Errorf("failed to write the A/B report: %w", err)
+	}
+	return reportPath, nil
+}
+
+func (a *CodeReviewAgent) countLevel(issues []types.Issue, level string) int {
+	count := 0
+	for _, issue := range issues {
+		if a.severities.Normalize(issue.Severity) == level {
+			count++
+		}
+	}
+	return count
+}
+
+// tableCell keeps text on one markdown table row
+func tableCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.Join(strings.Fields(text), " ")
+}
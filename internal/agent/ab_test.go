@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestCompareIssues(t *testing.T) {
+	a := ABSide{Label: "optimized", Issues: []types.Issue{
+		{Severity: "CRITICAL", FilePath: "main.go", StartLine: 10, EndLine: 12, Description: "SQL built from user input"},
+		{Severity: "MINOR", FilePath: "main.go", StartLine: 40, EndLine: 40, Description: "Typo in log message"},
+	}}
+	b := ABSide{Label: "security", Issues: []types.Issue{
+		{Severity: "WARNING", FilePath: "util.go", StartLine: 10, EndLine: 10, Description: "Unchecked error"},
+		{Severity: "CRITICAL", FilePath: "main.go", StartLine: 14, EndLine: 14, Description: "Injection through the query string"},
+	}}
+
+	comparison := CompareIssues(a, b)
+
+	if len(comparison.Both) != 1 || comparison.Both[0][0].StartLine != 10 || comparison.Both[0][1].StartLine != 14 {
+		t.Fatalf("Expected the neighboring findings of main.go to be paired, got %+v", comparison.Both)
+	}
+	if len(comparison.Only[0]) != 1 || comparison.Only[0][0].Description != "Typo in log message" {
+		t.Errorf("Expected the typo found only by A, got %+v", comparison.Only[0])
+	}
+	if len(comparison.Only[1]) != 1 || comparison.Only[1][0].FilePath != "util.go" {
+		t.Errorf("Expected the unchecked error found only by B, got %+v", comparison.Only[1])
+	}
+}
+
+func TestGenerateABReport(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	registry := tools.NewToolRegistry()
+	registry.Register(tools.ToolNameReadFile, &stubReadTool{content: "line\n"})
+	registry.Register(tools.ToolNameWriteFile, writeTool)
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), registry, "optimized")
+
+	comparison := CompareIssues(
+		ABSide{Label: "optimized", Issues: []types.Issue{
+			{Severity: "WARNING", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Unchecked | error"},
+		}, LLMCalls: 3},
+		ABSide{Label: "qwen2.5-coder:7b", Issues: []types.Issue{
+			{Severity: "WARNING", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Error ignored"},
+			{Severity: "MINOR", FilePath: "main.go", StartLine: 30, EndLine: 30, Description: "Shadowed variable"},
+		}, LLMCalls: 4},
+	)
+
+	path, err := agent.GenerateABReport(comparison)
+	if err != nil || path != ABReportFileName {
+		t.Fatalf("Expected the report at %s, got %q, %v", ABReportFileName, path, err)
+	}
+
+	report := writeTool.written[ABReportFileName]
+	for _, want := range []string{
+		"| | A: `optimized` | B: `qwen2.5-coder:7b` |",
+		"| Issues | 1 | 2 |",
+		"| Only found by this side | 0 | 1 |",
+		"| LLM calls | 3 | 4 |",
+		"No issues the other side missed",
+		"Shadowed variable",
+		"Unchecked \\| error",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestGenerateABReport_ConfiguredPath(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTool := &captureWriteTool{}
+	registry := tools.NewToolRegistry()
+	registry.Register(tools.ToolNameReadFile, &stubReadTool{content: "line\n"})
+	registry.Register(tools.ToolNameWriteFile, writeTool)
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), registry, "optimized")
+	agent.SetReportConfig(config.ReportConfig{Path: ".diffpector/review.md"})
+
+	path, err := agent.GenerateABReport(CompareIssues(ABSide{Label: "optimized"}, ABSide{Label: "default"}))
+	if err != nil || path != ".diffpector/diffpector_ab_report.md" {
+		t.Fatalf("Expected the report next to the configured path, got %q, %v", path, err)
+	}
+	if _, ok := writeTool.written[path]; !ok {
+		t.Errorf("Expected the report to be written to %s", path)
+	}
+	if _, err := os.Stat(".diffpector/.gitignore"); err != nil {
+		t.Errorf("Expected the .diffpector directory to be ignored: %v", err)
+	}
+}
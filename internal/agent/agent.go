@@ -1,22 +1,122 @@
 package agent
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
 	"strings"
+	"text/template"
+	"time"
 
+	"github.com/agusespa/diffpector/internal/dependencies"
 	"github.com/agusespa/diffpector/internal/llm"
+	"github.com/agusespa/diffpector/internal/owners"
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/privacy"
 	"github.com/agusespa/diffpector/internal/prompts"
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/telemetry"
 	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/trends"
 	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/usage"
 	"github.com/agusespa/diffpector/internal/utils"
+	"github.com/agusespa/diffpector/pkg/config"
 	"github.com/agusespa/diffpector/pkg/spinner"
 )
 
+// defaultExplorationCalls bounds the exploration tool calls of a review when unset
+const defaultExplorationCalls = 10
+
+// auxiliaryLanguages accompany the code of any language, such as migrations and the
+// infrastructure, manifests, workflows and scripts it is deployed with, so they are reviewed
+// alongside it
+var auxiliaryLanguages = []string{"sql", "hcl", "yaml", "bash"}
+
+// defaultLanguageVariants are the prompt variants of the languages whose review differs
+// from code, unless the prompt config overrides them
+var defaultLanguageVariants = map[string]string{
+	"hcl": prompts.INFRASTRUCTURE_PROMPT,
+}
+
 type CodeReviewAgent struct {
 	llmProvider    llm.Provider
 	promptVariant  string
 	parserRegistry *tools.ParserRegistry
 	toolRegistry   *tools.ToolRegistry
+	reportConfig   config.ReportConfig
+	promptConfig   config.PromptConfig
+	checksConfig   config.ChecksConfig
+	severities     *severity.Registry
+	// verification runs the verifier on the model findings, see SetVerificationConfig
+	verification config.VerificationConfig
+	// reviewTools are offered to the model next to human_loop, see AddReviewTool
+	reviewTools []tools.ToolName
+	// explorationTools are the read-only tools offered to the model next to the review tools,
+	// explorationCalls times at most in each review, see SetExplorationTools
+	explorationTools []tools.Tool
+	explorationCalls int
+	// structuredOutput constrains review answers to the issues JSON schema
+	structuredOutput bool
+	// pathFilter restricts the staged review to these files and directories, empty reviews all
+	pathFilter []string
+	// fixedPrompt reviews every file with promptVariant, see SetFixedPromptVariant
+	fixedPrompt bool
+	// failOn is the severity gate of the review, see SetFailOn
+	failOn string
+	// contextBuilder gathers the context of the changed files, nil uses the affected symbols
+	contextBuilder ContextBuilder
+	// manifestPath keeps the findings of the last staged review, see SetReviewManifest
+	manifestPath string
+	// checkpointPath records the progress of the staged review and resume continues the one
+	// recorded there, see SetCheckpoint
+	checkpointPath string
+	resume         bool
+	// owners groups the report by the owners of the files, nil when not configured
+	owners *owners.Rules
+	// intent describes the tickets the change references, see SetIntentContext
+	intent string
+	// generated classifies the files noted instead of reviewed, see SetGeneratedConfig
+	generated config.GeneratedConfig
+	// redactSecrets flags the secrets of the diffs, which the provider redacts, see
+	// SetSecretRedaction
+	redactSecrets bool
+	// anonymizer rewrites the code sent to the model, nil sends it as is, see SetAnonymizer
+	anonymizer *privacy.Anonymizer
+	// vulnerabilities looks up the new dependency versions, see SetVulnerabilitySource
+	vulnerabilities dependencies.VulnerabilitySource
+	// telemetry records the spans and metrics of the pipeline, nil records nothing
+	telemetry *telemetry.Telemetry
+	// ensemble reviews every file with several models instead of llmProvider, their findings
+	// merged with ensemblePolicy, see SetEnsemble
+	ensemble       []EnsembleMember
+	ensemblePolicy string
+	minAgreement   int
+	// triageProvider classifies the diffs before the review, nil reviews all of them, see
+	// SetTriageProvider
+	triageProvider llm.Provider
+	// summarize summarizes the changeset before reviewing its files, summary holding the
+	// summary given to the file reviews, see SetChangesetSummary
+	summarize bool
+	summary   string
+	// chunkLines splits the diffs of more lines into chunks reviewed one by one, 0 reviews
+	// every diff whole, see SetChunkLines
+	chunkLines int
+	// reportTemplate replaces the default layout of the report when set, reportMetadata
+	// describing the review to it, see SetReportTemplate
+	reportTemplate *template.Template
+	reportMetadata ReportMetadata
+	// history records a summary of the review when set, with the resources the recorder
+	// measured, see SetHistory
+	history  *trends.Store
+	recorder *usage.Recorder
+	// started is when the first file review started, for the duration of the review
+	started time.Time
+	result  ReviewResult
 }
 
 func NewCodeReviewAgent(provider llm.Provider, parserRegistry *tools.ParserRegistry, registry *tools.ToolRegistry, promptVariant string) *CodeReviewAgent {
@@ -25,48 +125,281 @@ func NewCodeReviewAgent(provider llm.Provider, parserRegistry *tools.ParserRegis
 		promptVariant:  promptVariant,
 		parserRegistry: parserRegistry,
 		toolRegistry:   registry,
+		reportConfig:   config.DefaultReportConfig(),
+		checksConfig:   config.DefaultChecksConfig(),
+		severities:     severity.Default(),
 	}
 }
 
-func (a *CodeReviewAgent) ReviewStagedChanges() error {
+// SetFixedPromptVariant reviews every file with variant, instead of the variants configured
+// per path and per language, so that prompts can be compared
+func (a *CodeReviewAgent) SetFixedPromptVariant(variant string) {
+	a.promptVariant = variant
+	a.fixedPrompt = true
+}
+
+// SetOwners groups the issues of the report by the owners of their files
+func (a *CodeReviewAgent) SetOwners(rules *owners.Rules) {
+	a.owners = rules
+}
+
+// SetIntentContext adds the tickets the change references to the review prompt
+func (a *CodeReviewAgent) SetIntentContext(intent string) {
+	a.intent = intent
+}
+
+// SetVulnerabilitySource looks up the known vulnerabilities of the dependency versions the
+// change adds or upgrades
+func (a *CodeReviewAgent) SetVulnerabilitySource(source dependencies.VulnerabilitySource) {
+	a.vulnerabilities = source
+}
+
+// SetSecretRedaction reports the secrets the diffs add as CRITICAL issues, for providers
+// that redact them from the prompts
+func (a *CodeReviewAgent) SetSecretRedaction(enabled bool) {
+	a.redactSecrets = enabled
+}
+
+// SetTelemetry records the spans and metrics of the review stages
+func (a *CodeReviewAgent) SetTelemetry(t *telemetry.Telemetry) {
+	a.telemetry = t
+}
+
+func (a *CodeReviewAgent) SetReportConfig(reportConfig config.ReportConfig) {
+	a.reportConfig = reportConfig
+}
+
+// ReportPaths returns the paths the report file may be written to
+func (a *CodeReviewAgent) ReportPaths() []string {
+	return ReportPaths(a.reportConfig)
+}
+
+// SetPromptConfig sets the per-path and per-language prompt variant overrides
+func (a *CodeReviewAgent) SetPromptConfig(promptConfig config.PromptConfig) {
+	a.promptConfig = promptConfig
+}
+
+func (a *CodeReviewAgent) SetChecksConfig(checksConfig config.ChecksConfig) {
+	a.checksConfig = checksConfig
+}
+
+// SetSeverities replaces the default CRITICAL/WARNING/MINOR taxonomy
+func (a *CodeReviewAgent) SetSeverities(severities *severity.Registry) {
+	a.severities = severities
+}
+
+// SetFailOn sets the severity gate: only the issues at least as severe as level fail the
+// review. Empty fails on any issue.
+func (a *CodeReviewAgent) SetFailOn(level string) {
+	a.failOn = level
+}
+
+// Result returns the outcome of the reviews run by the agent so far
+func (a *CodeReviewAgent) Result() ReviewResult {
+	result := a.result
+	result.severities = a.severities
+	result.FailOn = a.failOn
+	return result
+}
+
+// SetStructuredOutput makes the provider constrain review answers to the issues JSON schema
+func (a *CodeReviewAgent) SetStructuredOutput(enabled bool) {
+	a.structuredOutput = enabled
+}
+
+// SetChunkLines reviews the file diffs of more than lines lines in chunks, grouping their
+// hunks by the declaration they change. Zero reviews every diff whole.
+func (a *CodeReviewAgent) SetChunkLines(lines int) {
+	a.chunkLines = lines
+}
+
+// SetContextStrategy selects how much context the model gets next to the diffs, see
+// newContextBuilder
+func (a *CodeReviewAgent) SetContextStrategy(strategy string) error {
+	builder, err := newContextBuilder(strategy, a.toolRegistry)
+	if err != nil {
+		return err
+	}
+	a.contextBuilder = builder
+	return nil
+}
+
+// AddReviewTool registers a tool the model may call while reviewing, next to human_loop.
+// Its result is handed back to the model as is, a failing call is reported to the model.
+func (a *CodeReviewAgent) AddReviewTool(tool tools.Tool) error {
+	name := tools.ToolName(tool.Name())
+	if _, exists := a.toolRegistry.GetAll()[name]; exists {
+		return fmt.Errorf("tool '%s' is already registered", name)
+	}
+	a.toolRegistry.Register(name, tool)
+	a.reviewTools = append(a.reviewTools, name)
+	return nil
+}
+
+// Close releases the tools holding resources, such as the MCP server sessions and the reader
+// of the base revision of the symbol context
+func (a *CodeReviewAgent) Close() {
+	for _, tool := range a.toolRegistry.GetAll() {
+		if closer, ok := tool.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+}
+
+// SetExplorationTools offers read-only tools to the model while reviewing, so that it can
+// look up the context it is missing. Each review may call them maxCalls times in all,
+// defaultExplorationCalls when zero.
+func (a *CodeReviewAgent) SetExplorationTools(maxCalls int, explorationTools ...tools.Tool) {
+	if maxCalls <= 0 {
+		maxCalls = defaultExplorationCalls
+	}
+	a.explorationTools = explorationTools
+	a.explorationCalls = maxCalls
+}
+
+// explorationTool returns the exploration tool of the name, nil when there is none
+func (a *CodeReviewAgent) explorationTool(name string) tools.Tool {
+	for _, tool := range a.explorationTools {
+		if tool.Name() == name {
+			return tool
+		}
+	}
+	return nil
+}
+
+func (a *CodeReviewAgent) explorationNames() []tools.ToolName {
+	names := make([]tools.ToolName, len(a.explorationTools))
+	for i, tool := range a.explorationTools {
+		names[i] = tools.ToolName(tool.Name())
+	}
+	return names
+}
+
+// SetPathFilter restricts the staged review to the given repository-relative files and
+// directories
+func (a *CodeReviewAgent) SetPathFilter(paths []string) {
+	a.pathFilter = paths
+}
+
+// ReviewStagedChanges reviews the staged changes and writes the report. When the context is
+// canceled, the files reviewed so far make a partial report and the interruption is returned.
+func (a *CodeReviewAgent) ReviewStagedChanges(ctx context.Context) error {
 	fmt.Println("Starting code review on staged changes...")
-	return a.executeReview()
+	return a.executeReview(ctx)
+}
+
+func (a *CodeReviewAgent) executeReview(ctx context.Context) error {
+	diffMap, err := a.stagedDiff(ctx)
+	if err != nil || len(diffMap) == 0 {
+		return err
+	}
+
+	a.setAsideGenerated(diffMap)
+
+	primaryLanguage, err := a.ValidateAndDetectLanguage(slices.Collect(maps.Keys(diffMap)))
+	if err != nil {
+		return err
+	}
+
+	return a.ReviewChanges(ctx, diffMap, primaryLanguage)
 }
 
-func (a *CodeReviewAgent) executeReview() error {
+// CollectStagedIssues reviews the staged changes and returns the issues found without
+// writing a report. Used to consolidate the reviews of several repositories. An interrupted
+// review returns the issues of the files reviewed so far along with the interruption.
+func (a *CodeReviewAgent) CollectStagedIssues(ctx context.Context) ([]types.Issue, error) {
+	diffMap, err := a.stagedDiff(ctx)
+	if err != nil || len(diffMap) == 0 {
+		return nil, err
+	}
+
+	a.setAsideGenerated(diffMap)
+
+	primaryLanguage, err := a.ValidateAndDetectLanguage(slices.Collect(maps.Keys(diffMap)))
+	if err != nil {
+		return nil, err
+	}
+
+	return a.collectIssues(ctx, diffMap, primaryLanguage), interrupted(ctx)
+}
+
+// ReviewDiff reviews an externally provided unified diff, such as a pull request fetched from
+// a hosting service, against the sources checked out at repoRoot. No report is written.
+func (a *CodeReviewAgent) ReviewDiff(ctx context.Context, diff []byte, repoRoot string) ([]types.Issue, error) {
+	diffMap, err := tools.ParseDiffData(diff, repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	if len(diffMap) == 0 {
+		fmt.Println("No changes found in the diff")
+		return nil, nil
+	}
+
+	a.setAsideGenerated(diffMap)
+
+	primaryLanguage, err := a.ValidateAndDetectLanguage(slices.Collect(maps.Keys(diffMap)))
+	if err != nil {
+		return nil, err
+	}
+
+	issues := a.collectIssues(ctx, diffMap, primaryLanguage)
+	// The findings of part of a pull request are not worth posting
+	if err := interrupted(ctx); err != nil {
+		return nil, err
+	}
+	return SortIssues(issues, a.severities), nil
+}
+
+// interrupted returns the error of a review stopped by its context, nil when it completed
+func interrupted(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("review interrupted: %w", err)
+	}
+	return nil
+}
+
+// stagedDiff returns the staged changes and lists the files to be reviewed
+func (a *CodeReviewAgent) stagedDiff(ctx context.Context) (map[string]types.DiffData, error) {
 	diffTool := a.toolRegistry.Get(tools.ToolNameGitDiff)
 
-	diffResult, err := diffTool.Execute(map[string]any{})
+	span := a.telemetry.Start("collect diff")
+	defer span.End()
+	start := time.Now()
+	diffResult, err := diffTool.Execute(ctx, map[string]any{})
+	a.telemetry.Record(telemetry.MetricStageDuration, time.Since(start).Seconds(), telemetry.String("stage", "diff"))
+	span.Fail(err)
 	if err != nil {
-		return fmt.Errorf("failed to get staged diff list: %w", err)
+		return nil, fmt.Errorf("failed to get staged diff list: %w", err)
 	}
 	diffMap, ok := diffResult.(map[string]types.DiffData)
 	if !ok {
-		return fmt.Errorf("diff tool returned unexpected type: %T", diffResult)
-	}
-
-	changedFilesPaths := make([]string, 0, len(diffMap))
-	for fileName := range diffMap {
-		changedFilesPaths = append(changedFilesPaths, fileName)
+		return nil, fmt.Errorf("diff tool returned unexpected type: %T", diffResult)
 	}
+	span.SetAttributes(telemetry.Int("files", len(diffMap)))
+	slog.Debug("diff collected", "files", len(diffMap), "duration_ms", time.Since(start).Milliseconds())
 
 	fmt.Print("Files to be reviewed:")
-	if len(changedFilesPaths) == 0 {
+	if len(diffMap) == 0 {
 		fmt.Println("- no staged changes found (use 'git add' to stage files for review)")
-		return nil
+		return nil, nil
 	}
 
-	for _, file := range changedFilesPaths {
-		fmt.Printf("\n- %s", file)
+	if len(a.pathFilter) > 0 {
+		diffMap = FilterDiffPaths(diffMap, a.pathFilter)
+		if len(diffMap) == 0 {
+			fmt.Printf("- no staged changes under %s\n", strings.Join(a.pathFilter, ", "))
+			return nil, nil
+		}
 	}
-	fmt.Println()
 
-	primaryLanguage, err := a.ValidateAndDetectLanguage(changedFilesPaths)
-	if err != nil {
-		return err
+	for fileName := range diffMap {
+		fmt.Printf("\n- %s", fileName)
 	}
+	fmt.Println()
+
+	a.annotateChangeHistory(ctx, diffMap)
 
-	return a.ReviewChanges(diffMap, primaryLanguage)
+	return diffMap, nil
 }
 
 func (a *CodeReviewAgent) ValidateAndDetectLanguage(changedFiles []string) (string, error) {
@@ -76,6 +409,9 @@ func (a *CodeReviewAgent) ValidateAndDetectLanguage(changedFiles []string) (stri
 		parser := a.parserRegistry.GetParser(filePath)
 		if parser != nil {
 			lang := strings.ToLower(parser.Language())
+			if slices.Contains(auxiliaryLanguages, lang) {
+				continue
+			}
 
 			if primaryLanguage == "" {
 				primaryLanguage = lang
@@ -90,34 +426,193 @@ func (a *CodeReviewAgent) ValidateAndDetectLanguage(changedFiles []string) (stri
 	return primaryLanguage, nil
 }
 
-func (a *CodeReviewAgent) ReviewChanges(diffMap map[string]types.DiffData, primaryLanguage string) error {
+func (a *CodeReviewAgent) ReviewChanges(ctx context.Context, diffMap map[string]types.DiffData, primaryLanguage string) error {
+	allIssues := a.collectIssues(ctx, diffMap, primaryLanguage)
+	if err := a.GenerateFinalReport(allIssues); err != nil {
+		return err
+	}
+	return interrupted(ctx)
+}
+
+// FilterDiffPaths keeps the diffs of the files that are one of the paths or inside one of
+// them. Paths are relative to the repository root.
+func FilterDiffPaths(diffMap map[string]types.DiffData, paths []string) map[string]types.DiffData {
+	filtered := make(map[string]types.DiffData)
+	for file, data := range diffMap {
+		for _, path := range paths {
+			path = platform.NormalizePath(path)
+			if path == "." || file == path || strings.HasPrefix(file, path+"/") {
+				filtered[file] = data
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// ReviewWorkingTreeFiles reviews the uncommitted changes of the given files and prints the
+// findings to the console instead of writing a report. Used by watch mode.
+func (a *CodeReviewAgent) ReviewWorkingTreeFiles(ctx context.Context, paths []string) ([]types.Issue, error) {
+	diffTool := a.toolRegistry.Get(tools.ToolNameGitDiff)
+
+	diffResult, err := diffTool.Execute(ctx, map[string]any{"working_tree": true, "paths": paths})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working tree diff: %w", err)
+	}
+	diffMap, ok := diffResult.(map[string]types.DiffData)
+	if !ok {
+		return nil, fmt.Errorf("diff tool returned unexpected type: %T", diffResult)
+	}
+
+	if len(diffMap) == 0 {
+		fmt.Println("No uncommitted changes in the modified files")
+		return nil, nil
+	}
+
+	a.setAsideGenerated(diffMap)
+
+	changedFilesPaths := make([]string, 0, len(diffMap))
+	for fileName := range diffMap {
+		changedFilesPaths = append(changedFilesPaths, fileName)
+	}
+
+	primaryLanguage, err := a.ValidateAndDetectLanguage(changedFilesPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	a.annotateChangeHistory(ctx, diffMap)
+
+	issues := a.collectIssues(ctx, diffMap, primaryLanguage)
+	PrintIssues(issues, a.severities)
+
+	return issues, nil
+}
+
+// collectIssues reviews the files one by one. Once the context is canceled the remaining
+// files are left unreviewed and the issues found so far are returned.
+func (a *CodeReviewAgent) collectIssues(ctx context.Context, diffMap map[string]types.DiffData, primaryLanguage string) []types.Issue {
 	var allIssues []types.Issue
 	totalFiles := len(diffMap)
+	a.result.Files += totalFiles
+	a.result.ChangedFiles = append(a.result.ChangedFiles, slices.Sorted(maps.Keys(diffMap))...)
+	for _, diffData := range diffMap {
+		a.result.ChangedLines += utils.CountChangedLines(diffData.Diff)
+	}
+	if a.started.IsZero() {
+		a.started = time.Now()
+	}
 	currentFile := 0
 
 	fmt.Println()
 	fmt.Printf("Starting review of %d file(s):", totalFiles)
 	fmt.Println()
 
+	reviewSpan := a.telemetry.Start("review", telemetry.Int("files", totalFiles))
+	defer reviewSpan.End()
+
+	if missingTests := a.checkMissingTests(diffMap); len(missingTests) > 0 {
+		fmt.Printf("[i] %d changed file(s) lack a test update\n", len(missingTests))
+		allIssues = append(allIssues, missingTests...)
+	}
+	if breakingChanges := a.checkBreakingChanges(ctx, diffMap); len(breakingChanges) > 0 {
+		fmt.Printf("[i] %d changed signature(s) have callers not updated in this diff\n", len(breakingChanges))
+		allIssues = append(allIssues, breakingChanges...)
+	}
+	if unused := a.checkUnusedSymbols(ctx, diffMap); len(unused) > 0 {
+		fmt.Printf("[i] %d symbol(s) left unused by this diff\n", len(unused))
+		allIssues = append(allIssues, unused...)
+	}
+
+	a.annotateSymbolMoves(diffMap)
+	a.annotateSimilarCode(ctx, diffMap)
+	a.annotateDependencies(diffMap)
+	a.summarizeChanges(ctx, diffMap)
+
+	previous := a.loadManifest()
+	next := previous.fresh()
+	checkpoint := a.startCheckpoint(diffMap)
+	a.saveCheckpoint(checkpoint)
+
 	for filePath, diffData := range diffMap {
+		if ctx.Err() != nil {
+			a.result.UnreviewedFiles = append(a.result.UnreviewedFiles, filePath)
+			continue
+		}
 		currentFile++
 		fmt.Printf("- [%d/%d] Reviewing %s\n", currentFile, totalFiles, filePath)
+		fileSpan := a.telemetry.Start("review file", telemetry.String("file", filePath))
+
+		if entry, ok := checkpoint.completed(filePath, diffData.Diff); ok {
+			fmt.Printf("  [=] Reviewed before the interruption, reusing %d issue(s)\n", len(entry.Issues))
+			fileSpan.SetAttributes(telemetry.Bool("resumed", true))
+			fileSpan.End()
+			next.record(filePath, diffData.Diff, entry.Issues, entry.Questions)
+			allIssues = append(allIssues, entry.Issues...)
+			a.result.Questions = append(a.result.Questions, entry.Questions...)
+			a.result.ResumedFiles++
+			continue
+		}
 
-		singleFileMap := map[string]types.DiffData{filePath: diffData}
+		if entry, ok := previous.unchanged(filePath, diffData.Diff); ok {
+			fmt.Printf("  [=] Unchanged since last review, reusing %d issue(s)\n", len(entry.Issues))
+			fileSpan.SetAttributes(telemetry.Bool("unchanged", true))
+			fileSpan.End()
+			next.Files[filePath] = entry
+			for _, issue := range entry.Issues {
+				issue.Unchanged = true
+				allIssues = append(allIssues, issue)
+			}
+			a.result.Questions = append(a.result.Questions, entry.Questions...)
+			a.result.UnchangedFiles++
+			checkpoint.record(filePath, diffData.Diff, entry.Issues, entry.Questions)
+			a.saveCheckpoint(checkpoint)
+			continue
+		}
 
-		review, err := a.analyzeDiffs(singleFileMap, primaryLanguage)
+		start := time.Now()
+		checkIssues := a.runChecks(filePath, diffData)
+		slog.Debug("checks run", "file", filePath, "issues", len(checkIssues))
+		if len(checkIssues) > 0 {
+			fmt.Printf("  [i] Checks flagged %d issue(s)\n", len(checkIssues))
+			allIssues = append(allIssues, checkIssues...)
+		}
+
+		if trivial, reason := a.triage(ctx, filePath, diffData); trivial {
+			fmt.Printf("  [~] Triaged as trivial, not reviewed in depth: %s\n", reason)
+			fileSpan.SetAttributes(telemetry.Bool("triaged", true))
+			fileSpan.End()
+			a.result.TriagedFiles = append(a.result.TriagedFiles, filePath)
+			next.record(filePath, diffData.Diff, checkIssues, nil)
+			checkpoint.record(filePath, diffData.Diff, checkIssues, nil)
+			a.saveCheckpoint(checkpoint)
+			continue
+		}
+
+		review, err := a.reviewDiff(ctx, filePath, diffData, primaryLanguage)
+		if err != nil && ctx.Err() != nil {
+			fmt.Printf("  [!] Review interrupted\n")
+			fileSpan.Fail(err)
+			fileSpan.End()
+			a.result.UnreviewedFiles = append(a.result.UnreviewedFiles, filePath)
+			continue
+		}
 		if err != nil {
 			fmt.Printf("  [!] Review failed: %v\n", err)
+			slog.Warn("review failed", "file", filePath, "error", err)
+			fileSpan.Fail(err)
+			fileSpan.End()
+			a.result.FailedFiles = append(a.result.FailedFiles, filePath)
 			continue
 		}
 
 		// Update the original map with the gathered context
-		diffMap[filePath] = singleFileMap[filePath]
+		diffMap[filePath] = review.data
 
-		issues, err := utils.ParseIssuesFromResponse(review)
-		if err != nil {
-			fmt.Printf("  [!] Failed to parse review: %v\n", err)
-			continue
+		issues, rejected := review.issues, review.rejected
+		if rejected > 0 {
+			fmt.Printf("  [-] The verifier rejected %d finding(s)\n", rejected)
+			a.result.RejectedIssues += rejected
 		}
 
 		if len(issues) == 0 {
@@ -125,19 +620,67 @@ func (a *CodeReviewAgent) ReviewChanges(diffMap map[string]types.DiffData, prima
 		} else {
 			fmt.Printf("  [✕] Found %d issue(s)\n", len(issues))
 		}
+		slog.Debug("file reviewed", "file", filePath, "issues", len(issues), "rejected", rejected,
+			"duration_ms", time.Since(start).Milliseconds())
+		a.telemetry.Record(telemetry.MetricStageDuration, time.Since(start).Seconds(), telemetry.String("stage", "review"))
+		a.telemetry.Add(telemetry.MetricFilesReviewed, 1)
+		for _, issue := range slices.Concat(checkIssues, issues) {
+			a.telemetry.Add(telemetry.MetricIssues, 1, telemetry.String("severity", issue.Severity))
+		}
+		fileSpan.SetAttributes(telemetry.Int("issues", len(checkIssues)+len(issues)), telemetry.Int("rejected", rejected))
+		fileSpan.End()
+
+		var questions []types.Question
+		if a.promptConfig.Questions {
+			for _, answer := range review.answers {
+				questions = append(questions, utils.ParseQuestions(answer.Answer)...)
+			}
+			for i := range questions {
+				questions[i].Question = a.restore(questions[i].Question)
+				if questions[i].FilePath == "" {
+					questions[i].FilePath = filePath
+				}
+			}
+			if len(questions) > 0 {
+				fmt.Printf("  [?] %d question(s) for the author\n", len(questions))
+				a.result.Questions = append(a.result.Questions, questions...)
+			}
+		}
 
 		allIssues = append(allIssues, issues...)
+		next.record(filePath, diffData.Diff, slices.Concat(checkIssues, issues), questions)
+		checkpoint.record(filePath, diffData.Diff, slices.Concat(checkIssues, issues), questions)
+		a.saveCheckpoint(checkpoint)
 	}
+	a.saveManifest(next)
 
 	fmt.Println()
 	fmt.Printf("Review complete - analyzed %d file(s)\n", totalFiles)
+	if a.result.UnchangedFiles > 0 {
+		fmt.Printf("[=] %d file(s) unchanged since last review, run with --full to review them again\n", a.result.UnchangedFiles)
+	}
+	if len(a.result.FailedFiles) > 0 {
+		fmt.Printf("[!] The review of %d file(s) failed: %s\n", len(a.result.FailedFiles), strings.Join(a.result.FailedFiles, ", "))
+	}
+	if len(a.result.UnreviewedFiles) > 0 {
+		fmt.Printf("[!] Review interrupted, %d file(s) not reviewed: %s\n", len(a.result.UnreviewedFiles), strings.Join(a.result.UnreviewedFiles, ", "))
+	}
+	a.finishCheckpoint(checkpoint)
+
+	a.severities.NormalizeIssues(allIssues)
 
-	return a.GenerateFinalReport(allIssues)
+	allIssues, hidden := FilterLowConfidence(allIssues, a.reportConfig.MinConfidence)
+	if hidden > 0 {
+		fmt.Printf("[i] %d finding(s) with a confidence below %.2f hidden\n", hidden, a.reportConfig.MinConfidence)
+		a.result.HiddenIssues += hidden
+	}
+
+	return SampleIssues(SortIssues(allIssues, a.severities), a.reportConfig.Sampling, a.severities)
 }
 
 // Minimal logging and no report for Eval Pipeline
-func (a *CodeReviewAgent) ReviewChangesWithoutReport(diffMap map[string]types.DiffData, primaryLanguage string) (string, error) {
-	review, err := a.analyzeDiffs(diffMap, primaryLanguage)
+func (a *CodeReviewAgent) ReviewChangesWithoutReport(ctx context.Context, diffMap map[string]types.DiffData, primaryLanguage string) (string, error) {
+	review, err := a.analyzeDiffs(ctx, diffMap, primaryLanguage)
 	if err != nil {
 		return "", err
 	}
@@ -145,16 +688,10 @@ func (a *CodeReviewAgent) ReviewChangesWithoutReport(diffMap map[string]types.Di
 	return review, nil
 }
 
-func (a *CodeReviewAgent) analyzeDiffs(diffMap map[string]types.DiffData, primaryLanguage string) (string, error) {
-	ctxSpinner := spinner.New("Gathering context...")
-	ctxSpinner.Start()
-	err := a.UpdateDiffContext(diffMap, primaryLanguage)
-	ctxSpinner.Stop()
-	if err != nil {
-		return "", fmt.Errorf("context gathering failed: %w", err)
-	}
+func (a *CodeReviewAgent) analyzeDiffs(ctx context.Context, diffMap map[string]types.DiffData, primaryLanguage string) (string, error) {
+	a.gatherContext(ctx, diffMap, primaryLanguage)
 
-	review, err := a.GenerateReview(diffMap)
+	review, err := a.GenerateReview(ctx, diffMap)
 	if err != nil {
 		return "", fmt.Errorf("generate review failed: %w", err)
 	}
@@ -162,48 +699,86 @@ func (a *CodeReviewAgent) analyzeDiffs(diffMap map[string]types.DiffData, primar
 	return review, nil
 }
 
-func (a *CodeReviewAgent) UpdateDiffContext(diffMap map[string]types.DiffData, primaryLanguage string) error {
-	symbolContextTool := a.toolRegistry.Get(tools.ToolNameSymbolContext)
+// gatherContext adds the context of the changes to the diffs, which are still reviewed alone
+// when it fails
+func (a *CodeReviewAgent) gatherContext(ctx context.Context, diffMap map[string]types.DiffData, primaryLanguage string) {
+	ctxSpinner := spinner.New("Gathering context...")
+	ctxSpinner.Start()
+	err := a.UpdateDiffContext(ctx, diffMap, primaryLanguage)
+	ctxSpinner.Stop()
+	if err != nil {
+		// The diff alone still deserves a review
+		fmt.Printf("  [!] Context gathering failed, reviewing the diff alone: %v\n", err)
+		slog.Warn("context gathering failed", "error", err)
+	}
+}
+
+func (a *CodeReviewAgent) UpdateDiffContext(ctx context.Context, diffMap map[string]types.DiffData, primaryLanguage string) error {
+	builder := a.contextBuilder
+	if builder == nil {
+		builder = &affectedSymbolsBuilder{registry: a.toolRegistry}
+	}
 
 	for key, diffData := range diffMap {
-		updatedDataResult, err := symbolContextTool.Execute(map[string]any{"diffData": diffData, "primaryLanguage": primaryLanguage})
-		if err != nil {
-			return fmt.Errorf("symbol analysis failed: %w", err)
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		updatedData, ok := updatedDataResult.(types.DiffData)
-		if !ok {
-			return fmt.Errorf("symbol context tool returned unexpected type: %T", updatedDataResult)
+		span := a.telemetry.Start("gather context", telemetry.String("file", key))
+		start := time.Now()
+		// Builders return the context they managed to gather along with their error
+		updatedData, err := builder.Build(ctx, diffData, primaryLanguage)
+		diffMap[key] = updatedData
+		slog.Debug("context gathered", "file", key, "symbols", len(updatedData.AffectedSymbols),
+			"context_bytes", len(updatedData.DiffContext), "duration_ms", time.Since(start).Milliseconds())
+		a.telemetry.Record(telemetry.MetricStageDuration, time.Since(start).Seconds(), telemetry.String("stage", "context"))
+		span.SetAttributes(telemetry.Int("symbols", len(updatedData.AffectedSymbols)))
+		span.Fail(err)
+		span.End()
+		if err != nil {
+			return err
 		}
-
-		diffData.DiffContext = updatedData.DiffContext
-		diffData.AffectedSymbols = updatedData.AffectedSymbols
-
-		diffMap[key] = diffData
 	}
 
 	return nil
 }
 
-func (a *CodeReviewAgent) GenerateReview(diffMap map[string]types.DiffData) (string, error) {
-	var combinedContext strings.Builder
-
-	for path, data := range diffMap {
-		fmt.Fprintf(&combinedContext, ">>> Diff for changed file: %s\n%s\n", path, data.Diff)
+func (a *CodeReviewAgent) GenerateReview(ctx context.Context, diffMap map[string]types.DiffData) (string, error) {
+	return a.generateReview(ctx, a.llmProvider, diffMap)
+}
 
-		if data.DiffContext != "" {
-			fmt.Fprintf(&combinedContext, "\n>>>> Expanded Diff Context\n%s\n", data.DiffContext)
-		}
+func (a *CodeReviewAgent) generateReview(ctx context.Context, provider llm.Provider, diffMap map[string]types.DiffData) (string, error) {
+	prompt, err := prompts.BuildPromptWithTemplate(a.selectPromptVariant(diffMap), a.payload(diffMap))
+	// fmt.Println(prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to build review prompt: %w", err)
+	}
 
-		combinedContext.WriteString("\n>>>> Affected Symbols\n")
-		for _, usage := range data.AffectedSymbols {
-			combinedContext.WriteString(usage.Snippets)
+	if a.promptConfig.Questions {
+		prompt = prompts.WithQuestions(prompt)
+	}
+	if a.intent != "" {
+		prompt = prompts.WithIntent(prompt, a.intent)
+	}
+	if a.summary != "" {
+		prompt = prompts.WithChangesetSummary(prompt, a.summary)
+	}
+	for path := range diffMap {
+		if tools.IsMigrationFile(path) {
+			prompt = prompts.WithMigrationChecks(prompt)
+			break
 		}
 	}
 
-	prompt, err := prompts.BuildPromptWithTemplate(a.promptVariant, combinedContext.String())
-	// fmt.Println(prompt)
-	if err != nil {
-		return "", fmt.Errorf("failed to build review prompt: %w", err)
+	var responseSchema *llm.ResponseSchema
+	if a.structuredOutput {
+		prompt = prompts.WithStructuredOutput(prompt)
+		responseSchema = &llm.ResponseSchema{
+			Name:   utils.IssuesSchemaName,
+			Schema: utils.IssuesSchema(),
+		}
+		if a.promptConfig.Questions {
+			responseSchema.Schema = utils.IssuesWithQuestionsSchema()
+		}
 	}
 
 	history := []llm.Message{
@@ -214,15 +789,25 @@ func (a *CodeReviewAgent) GenerateReview(diffMap map[string]types.DiffData) (str
 	}
 
 	humanLoopTool := a.toolRegistry.Get(tools.ToolNameHumanLoop)
-	availableTools := a.toLLMTools(humanLoopTool)
+	offeredTools := []tools.Tool{humanLoopTool}
+	for _, name := range a.reviewTools {
+		offeredTools = append(offeredTools, a.toolRegistry.Get(name))
+	}
+	offeredTools = append(offeredTools, a.explorationTools...)
+	availableTools := a.toLLMTools(offeredTools...)
+	explorationCalls := 0
 
+	// The exploration tool calls may take an iteration each
 	maxIterations := 10
+	if len(a.explorationTools) > 0 {
+		maxIterations += a.explorationCalls
+	}
 
 	for range maxIterations {
 		spinner := spinner.New("Analyzing changes...")
 		spinner.Start()
 
-		response, err := a.llmProvider.ChatWithTools(history, availableTools)
+		response, err := provider.ChatWithSchema(ctx, history, availableTools, responseSchema)
 		spinner.Stop()
 
 		if err != nil {
@@ -237,8 +822,8 @@ func (a *CodeReviewAgent) GenerateReview(diffMap map[string]types.DiffData) (str
 						return "", fmt.Errorf("invalid question argument in tool call")
 					}
 
-					userResponse, err := humanLoopTool.Execute(map[string]any{
-						"question": question,
+					userResponse, err := a.toolRegistry.Invoke(ctx, humanLoopTool, map[string]any{
+						"question": a.restore(question),
 					})
 					if err != nil {
 						return "", fmt.Errorf("failed to get user input: %w", err)
@@ -257,6 +842,17 @@ func (a *CodeReviewAgent) GenerateReview(diffMap map[string]types.DiffData) (str
 						Role:    "user",
 						Content: userInput,
 					})
+				} else if slices.Contains(a.reviewTools, tools.ToolName(toolCall.Name)) {
+					history = append(history, a.callReviewTool(ctx, a.toolRegistry.Get(tools.ToolName(toolCall.Name)), toolCall)...)
+				} else if tool := a.explorationTool(toolCall.Name); tool != nil {
+					if explorationCalls == a.explorationCalls {
+						slog.Warn("exploration budget spent", "tool", toolCall.Name, "calls", explorationCalls)
+						history = append(history, a.refuseToolCall(toolCall,
+							fmt.Sprintf("The %d tool calls of this review are spent, answer with the context you have", a.explorationCalls))...)
+						continue
+					}
+					explorationCalls++
+					history = append(history, a.callReviewTool(ctx, tool, toolCall)...)
 				}
 			}
 		} else if response.Content != "" {
@@ -271,6 +867,131 @@ func (a *CodeReviewAgent) GenerateReview(diffMap map[string]types.DiffData) (str
 	return "", fmt.Errorf("conversation exceeded maximum iterations without completion")
 }
 
+// callReviewTool runs a tool call of the model and returns the messages handing the result
+// back to it. Every call is logged, its result too at the debug level.
+func (a *CodeReviewAgent) callReviewTool(ctx context.Context, tool tools.Tool, toolCall llm.ToolCall) []llm.Message {
+	arguments, _ := json.Marshal(toolCall.Arguments)
+	fmt.Printf("  [>] Calling %s\n", toolCall.Name)
+
+	start := time.Now()
+	toolArguments := a.restoreArguments(toolCall.Arguments)
+	result, err := a.toolRegistry.Invoke(ctx, tool, toolArguments)
+	content := fmt.Sprintf("Result of %s:\n%s", toolCall.Name, a.anonymizeToolResult(toolArguments, fmt.Sprint(result)))
+	if err != nil {
+		fmt.Printf("  [!] %s failed: %v\n", toolCall.Name, err)
+		content = fmt.Sprintf("The %s tool failed: %v", toolCall.Name, err)
+	}
+	slog.Info("tool called", "tool", toolCall.Name, "arguments", string(arguments), "result_bytes", len(content),
+		"duration_ms", time.Since(start).Milliseconds(), "error", err)
+	slog.Debug("tool result", "tool", toolCall.Name, "result", content)
+
+	return []llm.Message{
+		{Role: "assistant", Content: fmt.Sprintf("Calling %s with %s", toolCall.Name, arguments)},
+		{Role: "user", Content: content},
+	}
+}
+
+// refuseToolCall returns the messages telling the model why its tool call was not run
+func (a *CodeReviewAgent) refuseToolCall(toolCall llm.ToolCall, reason string) []llm.Message {
+	arguments, _ := json.Marshal(toolCall.Arguments)
+	return []llm.Message{
+		{Role: "assistant", Content: fmt.Sprintf("Calling %s with %s", toolCall.Name, arguments)},
+		{Role: "user", Content: reason},
+	}
+}
+
+// buildPayload writes the diffs with their expanded context and affected symbols, the
+// input of the review and describe prompts
+func buildPayload(diffMap map[string]types.DiffData) string {
+	var combinedContext strings.Builder
+
+	for path, data := range diffMap {
+		fmt.Fprintf(&combinedContext, ">>> Diff for changed file: %s\n%s\n", path, data.Diff)
+
+		if len(data.SymbolMoves) > 0 {
+			combinedContext.WriteString("\n>>>> Renamed or Moved Functions (not removed, review the new code as the same function)\n")
+			for _, move := range data.SymbolMoves {
+				fmt.Fprintf(&combinedContext, "- %s\n", move)
+			}
+		}
+
+		if data.ChangeHistory != "" {
+			fmt.Fprintf(&combinedContext, "\n>>>> Change History (last commits touching the changed lines)\n%s", data.ChangeHistory)
+		}
+
+		if data.DiffContext != "" {
+			fmt.Fprintf(&combinedContext, "\n>>>> Expanded Diff Context\n%s\n", data.DiffContext)
+		}
+
+		if len(data.SimilarCode) > 0 {
+			combinedContext.WriteString("\n>>>> Similar Existing Code (the added lines may duplicate it)\n")
+			for _, code := range data.SimilarCode {
+				fmt.Fprintf(&combinedContext, "Added lines %d-%d resemble %s (lines %d-%d):\n%s\n",
+					code.AddedStart, code.AddedEnd, code.FilePath, code.StartLine, code.EndLine, code.Snippet)
+			}
+		}
+
+		if data.DependencyChanges != "" {
+			fmt.Fprintf(&combinedContext, "\n>>>> Dependency Changes\n%s", data.DependencyChanges)
+		}
+
+		if len(data.StaleCallers) > 0 {
+			combinedContext.WriteString("\n>>>> Callers Not Updated In This Diff (they still pass the old arguments)\n")
+			for _, caller := range data.StaleCallers {
+				fmt.Fprintf(&combinedContext, "%s called in %s (line %d):\n%s\n", caller.Name, caller.FilePath, caller.Line, caller.Code)
+			}
+		}
+
+		combinedContext.WriteString("\n>>>> Affected Symbols\n")
+		for _, usage := range data.AffectedSymbols {
+			combinedContext.WriteString(usage.Snippets)
+		}
+	}
+
+	return combinedContext.String()
+}
+
+// selectPromptVariant picks the prompt variant for the reviewed files. When the files resolve
+// to different variants the agent's default variant is used.
+func (a *CodeReviewAgent) selectPromptVariant(diffMap map[string]types.DiffData) string {
+	if a.fixedPrompt {
+		return a.promptVariant
+	}
+	selected := ""
+	for path := range diffMap {
+		variant := a.promptVariantFor(path)
+		if selected != "" && selected != variant {
+			return a.promptVariant
+		}
+		selected = variant
+	}
+
+	if selected == "" {
+		return a.promptVariant
+	}
+	return selected
+}
+
+func (a *CodeReviewAgent) promptVariantFor(filePath string) string {
+	for _, rule := range a.promptConfig.Paths {
+		if utils.MatchGlob(rule.Pattern, filePath) {
+			return rule.Variant
+		}
+	}
+
+	if parser := a.parserRegistry.GetParser(filePath); parser != nil {
+		language := strings.ToLower(parser.Language())
+		if variant, ok := a.promptConfig.Languages[language]; ok {
+			return variant
+		}
+		if variant, ok := defaultLanguageVariants[language]; ok {
+			return variant
+		}
+	}
+
+	return a.promptVariant
+}
+
 func (a *CodeReviewAgent) toLLMTools(toolsToConvert ...tools.Tool) []llm.Tool {
 	llmTools := make([]llm.Tool, len(toolsToConvert))
 	for i, tool := range toolsToConvert {
@@ -286,13 +1007,64 @@ func (a *CodeReviewAgent) toLLMTools(toolsToConvert ...tools.Tool) []llm.Tool {
 	return llmTools
 }
 
+// GenerateGroupedReport writes a single report for a multi-repo review, the repositories whose
+// review failed included. Issue paths must be readable from the current directory.
+func (a *CodeReviewAgent) GenerateGroupedReport(groups []RepositoryIssues) error {
+	writeTool := a.toolRegistry.Get(tools.ToolNameWriteFile)
+	readTool := a.toolRegistry.Get(tools.ToolNameReadFile)
+	reportGen := NewReportGenerator(readTool, writeTool, a.reportConfig, a.severities)
+
+	a.result.Issues = nil
+	a.result.Questions = nil
+	a.result.FailedRepositories = nil
+	for i := range groups {
+		groups[i].Issues = MergeDuplicateIssues(groups[i].Issues, a.severities)
+	}
+	for _, group := range groups {
+		a.result.Issues = append(a.result.Issues, group.Issues...)
+		a.result.Questions = append(a.result.Questions, group.Questions...)
+		if group.Error != "" {
+			a.result.FailedRepositories = append(a.result.FailedRepositories, group.Repository)
+		}
+	}
+
+	if len(a.result.Issues) > 0 || len(a.result.Questions) > 0 || len(a.result.FailedRepositories) > 0 {
+		a.result.ReportPath = reportGen.GenerateGroupedMarkdownReport(groups)
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println("[✓] Code review passed - no issues found")
+	return nil
+}
+
+// GenerateFinalReport merges near-duplicate issues and writes the report
 func (a *CodeReviewAgent) GenerateFinalReport(allIssues []types.Issue) error {
+	allIssues = MergeDuplicateIssues(allIssues, a.severities)
+
 	writeTool := a.toolRegistry.Get(tools.ToolNameWriteFile)
 	readTool := a.toolRegistry.Get(tools.ToolNameReadFile)
-	reportGen := NewReportGenerator(readTool, writeTool)
+	reportGen := NewReportGenerator(readTool, writeTool, a.reportConfig, a.severities)
+
+	a.result.Issues = allIssues
+	a.recordRun(allIssues)
+	reportGen.SetQuestions(a.result.Questions)
+	reportGen.SetHiddenIssues(a.result.HiddenIssues)
+	reportGen.SetRejectedIssues(a.result.RejectedIssues, a.verification.Action)
+	reportGen.SetOwners(a.owners)
+	reportGen.SetGeneratedFiles(a.result.GeneratedFiles)
+	reportGen.SetUnreviewedFiles(a.result.UnreviewedFiles)
+	reportGen.SetChunkedFiles(a.result.ChunkedFiles)
+	reportGen.SetTriagedFiles(a.result.TriagedFiles)
+	reportGen.SetSummary(a.result.Summary)
+	if a.reportTemplate != nil {
+		reportGen.SetTemplate(a.reportTemplate)
+		reportGen.SetMetadata(a.metadata())
+	}
 
-	if len(allIssues) > 0 {
-		reportGen.GenerateMarkdownReport(allIssues)
+	// A partial review is reported even without findings, it did not pass
+	if len(allIssues) > 0 || len(a.result.Questions) > 0 || len(a.result.UnreviewedFiles) > 0 {
+		a.result.ReportPath = reportGen.GenerateMarkdownReport(allIssues)
 	} else {
 		fmt.Println()
 		fmt.Println("[✓] Code review passed - no issues found")
@@ -1,10 +1,20 @@
 package agent
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/agusespa/diffpector/internal/llm"
 	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
 )
 
 func TestValidateAndDetectLanguage(t *testing.T) {
@@ -38,6 +48,30 @@ func TestValidateAndDetectLanguage(t *testing.T) {
 			expectedLang: "go",
 			expectError:  false,
 		},
+		{
+			name:         "go with sql migration",
+			files:        []string{"db/migrations/001_users.sql", "store.go"},
+			expectedLang: "go",
+			expectError:  false,
+		},
+		{
+			name:         "typescript with terraform",
+			files:        []string{"infra/main.tf", "src/app.ts"},
+			expectedLang: "typescript",
+			expectError:  false,
+		},
+		{
+			name:         "go with manifests and workflow",
+			files:        []string{"deploy/web.yaml", ".github/workflows/ci.yml", "main.go"},
+			expectedLang: "go",
+			expectError:  false,
+		},
+		{
+			name:         "sql only",
+			files:        []string{"schema.sql"},
+			expectedLang: "",
+			expectError:  false,
+		},
 		{
 			name:         "html and css files",
 			files:        []string{"index.html", "styles.css"},
@@ -95,3 +129,179 @@ func TestValidateAndDetectLanguage(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectPromptVariant(t *testing.T) {
+	agent := &CodeReviewAgent{
+		parserRegistry: tools.NewParserRegistry(),
+		promptVariant:  "optimized",
+		promptConfig: config.PromptConfig{
+			Languages: map[string]string{"java": "comprehensive"},
+			Paths: []config.PromptPathRule{
+				{Pattern: "internal/auth/**", Variant: "default"},
+				{Pattern: "*.sql", Variant: "comprehensive"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		files    []string
+		expected string
+	}{
+		{"path rule", []string{"internal/auth/token.go"}, "default"},
+		{"path rule wins over language", []string{"internal/auth/Token.java"}, "default"},
+		{"file name glob", []string{"db/migrations/001.sql"}, "comprehensive"},
+		{"language mapping", []string{"src/App.java"}, "comprehensive"},
+		{"no override", []string{"cmd/main.go"}, "optimized"},
+		{"terraform default variant", []string{"infra/network.tf"}, "infrastructure"},
+		{"same variant for all files", []string{"src/App.java", "db/001.sql"}, "comprehensive"},
+		{"conflicting variants use default", []string{"internal/auth/token.go", "src/App.java"}, "optimized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffMap := make(map[string]types.DiffData)
+			for _, file := range tt.files {
+				diffMap[file] = types.DiffData{}
+			}
+
+			if got := agent.selectPromptVariant(diffMap); got != tt.expected {
+				t.Errorf("Expected variant '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFilterDiffPaths(t *testing.T) {
+	diffMap := map[string]types.DiffData{
+		"main.go":              {},
+		"internal/db/db.go":    {},
+		"internal/db/query.go": {},
+		"internal/dbx/x.go":    {},
+		"cmd/app/app.go":       {},
+	}
+
+	tests := []struct {
+		name     string
+		paths    []string
+		expected []string
+	}{
+		{"single file", []string{"main.go"}, []string{"main.go"}},
+		{"directory", []string{"internal/db/"}, []string{"internal/db/db.go", "internal/db/query.go"}},
+		{"dot prefix", []string{"./cmd"}, []string{"cmd/app/app.go"}},
+		{"windows separators", []string{`.\internal\db\`}, []string{"internal/db/db.go", "internal/db/query.go"}},
+		{"several paths", []string{"main.go", "internal/dbx"}, []string{"internal/dbx/x.go", "main.go"}},
+		{"no match", []string{"pkg"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := FilterDiffPaths(diffMap, tt.paths)
+			got := slices.Sorted(maps.Keys(filtered))
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// catalogProvider calls the service_catalog tool once, then reviews with the tool result
+type catalogProvider struct {
+	unreachableProvider
+}
+
+func (p catalogProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, tools []llm.Tool, schema *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	last := messages[len(messages)-1]
+	if !strings.HasPrefix(last.Content, "Result of service_catalog") {
+		return &llm.ChatResponse{ToolCalls: []llm.ToolCall{{Name: "service_catalog", Arguments: map[string]any{"service": "billing"}}}}, nil
+	}
+	return &llm.ChatResponse{Content: fmt.Sprintf("reviewed with %q", last.Content)}, nil
+}
+
+type stubCatalogTool struct{}
+
+func (stubCatalogTool) Name() string           { return "service_catalog" }
+func (stubCatalogTool) Description() string    { return "Look up the owner of a service" }
+func (stubCatalogTool) Schema() map[string]any { return map[string]any{"type": "object"} }
+
+func (stubCatalogTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	return fmt.Sprintf("%s is owned by payments", args["service"]), nil
+}
+
+func TestGenerateReview_CallsReviewTools(t *testing.T) {
+	registry := tools.NewToolRegistry()
+	registry.Register(tools.ToolNameHumanLoop, &tools.HumanLoopTool{})
+	agent := NewCodeReviewAgent(catalogProvider{unreachableProvider{t: t}}, tools.NewParserRegistry(), registry, "optimized")
+
+	if err := agent.AddReviewTool(stubCatalogTool{}); err != nil {
+		t.Fatalf("AddReviewTool failed: %v", err)
+	}
+	if err := agent.AddReviewTool(stubCatalogTool{}); err == nil {
+		t.Error("Expected an error when registering the same tool twice")
+	}
+
+	review, err := agent.GenerateReview(context.Background(), map[string]types.DiffData{"main.go": {Diff: "+x := 1"}})
+	if err != nil {
+		t.Fatalf("GenerateReview failed: %v", err)
+	}
+	if !strings.Contains(review, "billing is owned by payments") {
+		t.Errorf("Expected the tool result to reach the model, got %s", review)
+	}
+}
+
+// exploringProvider reads a file until the budget of the exploration calls is spent, then
+// reviews with the answer
+type exploringProvider struct {
+	unreachableProvider
+}
+
+func (p exploringProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, tools []llm.Tool, schema *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	if last := messages[len(messages)-1]; strings.HasPrefix(last.Content, "The 2 tool calls of this review are spent") {
+		return &llm.ChatResponse{Content: fmt.Sprintf("reviewed after %d messages", len(messages))}, nil
+	}
+	return &llm.ChatResponse{ToolCalls: []llm.ToolCall{{Name: "read_file", Arguments: map[string]any{"filename": "main.go"}}}}, nil
+}
+
+func TestGenerateReview_ExplorationBudget(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry := tools.NewToolRegistry()
+	registry.Register(tools.ToolNameHumanLoop, &tools.HumanLoopTool{})
+	agent := NewCodeReviewAgent(exploringProvider{unreachableProvider{t: t}}, tools.NewParserRegistry(), registry, "optimized")
+	agent.SetExplorationTools(2, tools.NewRepositoryReadFileTool(root, nil))
+
+	for range 2 {
+		review, err := agent.GenerateReview(context.Background(), map[string]types.DiffData{"main.go": {Diff: "+x := 1"}})
+		if err != nil {
+			t.Fatalf("GenerateReview failed: %v", err)
+		}
+		// The prompt, two calls with their results and the refused third call
+		if review != "reviewed after 7 messages" {
+			t.Errorf("Expected the third call to be refused, got %s", review)
+		}
+	}
+}
+
+func TestCollectIssues_Interrupted(t *testing.T) {
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	diffMap := map[string]types.DiffData{
+		"main.go":   {Diff: "@@ -1,1 +1,1 @@\n-old\n+new\n"},
+		"helper.go": {Diff: "@@ -1,1 +1,1 @@\n-old\n+new\n"},
+	}
+	issues := agent.collectIssues(ctx, diffMap, "go")
+
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %+v", issues)
+	}
+	if unreviewed := agent.Result().UnreviewedFiles; len(unreviewed) != 2 {
+		t.Errorf("Expected both files left unreviewed, got %v", unreviewed)
+	}
+	if err := interrupted(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the interruption error, got %v", err)
+	}
+}
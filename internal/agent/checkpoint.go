@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// DefaultCheckpointPath is where the staged review records its progress, relative to the
+// repository root
+const DefaultCheckpointPath = ".diffpector/checkpoint.json"
+
+// reviewCheckpoint records the files of the running review as they complete, so an
+// interrupted review can resume without sending them to the model again
+type reviewCheckpoint struct {
+	// Fingerprint identifies the model and configuration the findings come from
+	Fingerprint string                   `json:"fingerprint"`
+	Files       map[string]manifestEntry `json:"files"`
+	// Remaining lists the files still to review, for the user reading the checkpoint
+	Remaining []string `json:"remaining"`
+}
+
+// SetCheckpoint records the progress of the staged review at path after every file. With
+// resume, the files a previous interrupted review completed with the same diff are taken
+// from the checkpoint. An empty path records nothing.
+func (a *CodeReviewAgent) SetCheckpoint(path string, resume bool) {
+	a.checkpointPath = path
+	a.resume = resume
+}
+
+// startCheckpoint returns the checkpoint of the review of diffMap, holding the files of the
+// previous one when resuming. It returns nil when no checkpoint is set.
+func (a *CodeReviewAgent) startCheckpoint(diffMap map[string]types.DiffData) *reviewCheckpoint {
+	if a.checkpointPath == "" {
+		return nil
+	}
+
+	checkpoint := &reviewCheckpoint{Fingerprint: a.reviewFingerprint(), Files: make(map[string]manifestEntry)}
+	if a.resume {
+		var previous reviewCheckpoint
+		data, err := os.ReadFile(a.checkpointPath)
+		if err == nil {
+			err = json.Unmarshal(data, &previous)
+		}
+		if err != nil || previous.Fingerprint != checkpoint.Fingerprint || len(previous.Files) == 0 {
+			fmt.Println("[i] No checkpoint of this review to resume, reviewing every file")
+		} else {
+			for filePath, entry := range previous.Files {
+				if diffData, ok := diffMap[filePath]; ok && entry.DiffHash == hashString(diffData.Diff) {
+					checkpoint.Files[filePath] = entry
+				}
+			}
+			fmt.Printf("[i] Resuming the review, %d file(s) already reviewed\n", len(checkpoint.Files))
+		}
+	}
+
+	for filePath := range diffMap {
+		if _, ok := checkpoint.Files[filePath]; !ok {
+			checkpoint.Remaining = append(checkpoint.Remaining, filePath)
+		}
+	}
+	slices.Sort(checkpoint.Remaining)
+	return checkpoint
+}
+
+// completed returns the entry of the file when the resumed review already reviewed its diff
+func (c *reviewCheckpoint) completed(filePath, diff string) (manifestEntry, bool) {
+	if c == nil {
+		return manifestEntry{}, false
+	}
+	entry, ok := c.Files[filePath]
+	return entry, ok && entry.DiffHash == hashString(diff)
+}
+
+func (c *reviewCheckpoint) record(filePath, diff string, issues []types.Issue, questions []types.Question) {
+	if c == nil {
+		return
+	}
+	c.Files[filePath] = manifestEntry{DiffHash: hashString(diff), Issues: issues, Questions: questions}
+	if i := slices.Index(c.Remaining, filePath); i >= 0 {
+		c.Remaining = slices.Delete(c.Remaining, i, i+1)
+	}
+}
+
+// saveCheckpoint replaces the stored checkpoint. Failing to save only costs reviewing the
+// files again on resume, so errors are reported without failing the review.
+func (a *CodeReviewAgent) saveCheckpoint(checkpoint *reviewCheckpoint) {
+	if checkpoint == nil {
+		return
+	}
+
+	if err := platform.IgnoreStateDir(a.checkpointPath); err != nil {
+		fmt.Printf("[!] Could not ignore %s: %v\n", platform.StateDir, err)
+	}
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(a.checkpointPath), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(a.checkpointPath, data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("[!] Failed to save the review checkpoint: %v\n", err)
+	}
+}
+
+// finishCheckpoint removes the checkpoint of a review that got through every file, and
+// points to --resume otherwise
+func (a *CodeReviewAgent) finishCheckpoint(checkpoint *reviewCheckpoint) {
+	if checkpoint == nil {
+		return
+	}
+	if len(checkpoint.Remaining) > 0 {
+		fmt.Printf("[i] Progress saved to %s, run with --resume to review the %d remaining file(s)\n", a.checkpointPath, len(checkpoint.Remaining))
+		return
+	}
+	if err := os.Remove(a.checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("[!] Failed to remove the review checkpoint: %v\n", err)
+	}
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestCollectIssuesResumesFromCheckpoint(t *testing.T) {
+	checkpointPath := filepath.Join(t.TempDir(), ".diffpector", "checkpoint.json")
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	agent.SetCheckpoint(checkpointPath, true)
+
+	diff := "@@ -1,1 +1,1 @@\n-old\n+new\n"
+	diffMap := map[string]types.DiffData{"main.go": {Diff: diff}}
+
+	interrupted := agent.startCheckpoint(diffMap)
+	interrupted.record("main.go", diff, []types.Issue{
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Unchecked error"},
+	}, nil)
+	agent.saveCheckpoint(interrupted)
+
+	issues := agent.collectIssues(context.Background(), diffMap, "go")
+
+	if len(issues) != 1 || issues[0].Unchanged || issues[0].Description != "Unchecked error" {
+		t.Fatalf("Expected the checkpoint issue, got %+v", issues)
+	}
+	if agent.Result().ResumedFiles != 1 {
+		t.Errorf("Expected one resumed file, got %+v", agent.Result())
+	}
+	if _, err := os.Stat(checkpointPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the checkpoint of the finished review to be removed, got %v", err)
+	}
+}
+
+func TestCollectIssuesKeepsCheckpointWhenInterrupted(t *testing.T) {
+	checkpointPath := filepath.Join(t.TempDir(), "checkpoint.json")
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	agent.SetCheckpoint(checkpointPath, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	agent.collectIssues(ctx, map[string]types.DiffData{
+		"main.go":   {Diff: "+a"},
+		"helper.go": {Diff: "+b"},
+	}, "go")
+
+	data, err := os.ReadFile(checkpointPath)
+	if err != nil {
+		t.Fatalf("Expected the checkpoint to be kept: %v", err)
+	}
+	var checkpoint reviewCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		t.Fatalf("Invalid checkpoint: %v", err)
+	}
+	if !slices.Equal(checkpoint.Remaining, []string{"helper.go", "main.go"}) || len(checkpoint.Files) != 0 {
+		t.Errorf("Expected both files remaining, got %+v", checkpoint)
+	}
+}
+
+func TestStartCheckpoint_SkipsChangedDiffs(t *testing.T) {
+	checkpointPath := filepath.Join(t.TempDir(), "checkpoint.json")
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	agent.SetCheckpoint(checkpointPath, true)
+
+	previous := agent.startCheckpoint(map[string]types.DiffData{"main.go": {Diff: "+a"}, "helper.go": {Diff: "+b"}})
+	previous.record("main.go", "+a", nil, nil)
+	previous.record("helper.go", "+b", nil, nil)
+	agent.saveCheckpoint(previous)
+
+	checkpoint := agent.startCheckpoint(map[string]types.DiffData{"main.go": {Diff: "+a"}, "helper.go": {Diff: "+c"}})
+
+	if _, ok := checkpoint.completed("main.go", "+a"); !ok {
+		t.Error("Expected the unchanged file to be resumed")
+	}
+	if _, ok := checkpoint.completed("helper.go", "+c"); ok {
+		t.Error("Expected the file with a new diff to be reviewed again")
+	}
+	if !slices.Equal(checkpoint.Remaining, []string{"helper.go"}) {
+		t.Errorf("Expected helper.go remaining, got %v", checkpoint.Remaining)
+	}
+}
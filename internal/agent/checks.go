@@ -0,0 +1,196 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/analysis"
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/utils"
+)
+
+// crossFileLanguages are the languages whose changes are checked against the rest of the
+// repository, for callers not updated and symbols left unused
+var crossFileLanguages = []string{"go", "java"}
+
+// maxReferenceLookups bounds the repository searches of the unused symbol check
+const maxReferenceLookups = 50
+
+// runChecks runs the enabled deterministic checks on a changed file. Files that cannot
+// be read or parsed are skipped silently, the LLM review still covers them.
+func (a *CodeReviewAgent) runChecks(filePath string, diffData types.DiffData) []types.Issue {
+	var issues []types.Issue
+	if a.redactSecrets {
+		issues = append(issues, analysis.CheckSecrets(filePath, diffData.Diff)...)
+	}
+	if a.checksConfig.Duplicates {
+		issues = append(issues, analysis.CheckDuplicates(filePath, diffData.SimilarCode)...)
+	}
+
+	if !a.checksConfig.DocDrift {
+		return issues
+	}
+
+	content, err := os.ReadFile(diffData.AbsolutePath)
+	if err != nil {
+		return issues
+	}
+
+	symbols, err := a.parserRegistry.ParseFile(filePath, content)
+	if err != nil {
+		slog.Warn("parse failed", "file", filePath, "error", err)
+		return issues
+	}
+
+	return append(issues, analysis.CheckDocDrift(filePath, diffData.Diff, content, symbols)...)
+}
+
+// checkMissingTests flags the changed public functions of the diff that no changed test
+// covers. Unlike the other checks it needs the whole diff, a test update may live in any
+// of the changed files.
+func (a *CodeReviewAgent) checkMissingTests(diffMap map[string]types.DiffData) []types.Issue {
+	if !a.checksConfig.MissingTests {
+		return nil
+	}
+
+	var files []analysis.ChangedFile
+	for filePath, diffData := range diffMap {
+		parser := a.parserRegistry.GetParser(filePath)
+		if parser == nil {
+			continue
+		}
+		file := analysis.ChangedFile{
+			Path:   filePath,
+			Diff:   diffData.Diff,
+			IsTest: parser.IsTestFile(filePath),
+		}
+		if !file.IsTest {
+			content, err := os.ReadFile(diffData.AbsolutePath)
+			if err != nil {
+				continue
+			}
+			symbols, err := a.parserRegistry.ParseFile(filePath, content)
+			if err != nil {
+				slog.Warn("parse failed", "file", filePath, "error", err)
+				continue
+			}
+			file.Content = content
+			file.Symbols = symbols
+		}
+		files = append(files, file)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	severity := a.checksConfig.MissingTestsSeverity
+	if severity == "" {
+		severity = "MINOR"
+	}
+	return analysis.CheckMissingTests(files, severity)
+}
+
+// checkBreakingChanges flags the Go and Java functions whose parameter count the diff
+// changed while some of their callers, outside the changed lines, still pass the old
+// arguments. The stale callers are listed in the diff data of the function's file too.
+func (a *CodeReviewAgent) checkBreakingChanges(ctx context.Context, diffMap map[string]types.DiffData) []types.Issue {
+	callSitesTool, ok := a.toolRegistry.GetAll()[tools.ToolNameCallSites]
+	if !a.checksConfig.BreakingChanges || !ok {
+		return nil
+	}
+
+	changedLines := make(map[string]map[int]bool, len(diffMap))
+	for filePath, diffData := range diffMap {
+		changedLines[filePath] = utils.GetDiffChangedLines(diffData.Diff)
+	}
+
+	var issues []types.Issue
+	for _, filePath := range slices.Sorted(maps.Keys(diffMap)) {
+		diffData := diffMap[filePath]
+		parser := a.parserRegistry.GetParser(filePath)
+		if parser == nil || !slices.Contains(crossFileLanguages, strings.ToLower(parser.Language())) {
+			continue
+		}
+		content, err := os.ReadFile(diffData.AbsolutePath)
+		if err != nil {
+			continue
+		}
+		symbols, err := a.parserRegistry.ParseFile(filePath, content)
+		if err != nil {
+			slog.Warn("parse failed", "file", filePath, "error", err)
+			continue
+		}
+
+		for _, change := range analysis.FindSignatureChanges(filePath, diffData.Diff, content, symbols) {
+			result, err := callSitesTool.Execute(ctx, map[string]any{"name": change.Name, "language": strings.ToLower(parser.Language())})
+			if err != nil {
+				fmt.Printf("[!] Could not look up the callers of %s: %v\n", change.Name, err)
+				continue
+			}
+			callSites, _ := result.([]types.CallSite)
+
+			var untouched []types.CallSite
+			for _, callSite := range callSites {
+				if !changedLines[callSite.FilePath][callSite.Line] {
+					untouched = append(untouched, callSite)
+				}
+			}
+			stale := analysis.StaleCallers(change, untouched)
+			if len(stale) == 0 {
+				continue
+			}
+			issues = append(issues, analysis.CheckBreakingChange(change, stale))
+			diffData.StaleCallers = append(diffData.StaleCallers, stale...)
+		}
+		diffMap[filePath] = diffData
+	}
+	return issues
+}
+
+// checkUnusedSymbols flags the symbols defined in the repository whose last reference the
+// diff removed. At most maxReferenceLookups names are looked up, in the order of the files.
+func (a *CodeReviewAgent) checkUnusedSymbols(ctx context.Context, diffMap map[string]types.DiffData) []types.Issue {
+	referencesTool, ok := a.toolRegistry.GetAll()[tools.ToolNameReferences]
+	if !a.checksConfig.UnusedSymbols || !ok {
+		return nil
+	}
+
+	var issues []types.Issue
+	lookedUp := make(map[string]bool)
+	for _, filePath := range slices.Sorted(maps.Keys(diffMap)) {
+		parser := a.parserRegistry.GetParser(filePath)
+		if parser == nil || !slices.Contains(crossFileLanguages, strings.ToLower(parser.Language())) {
+			continue
+		}
+		language := strings.ToLower(parser.Language())
+
+		for _, removed := range analysis.RemovedReferences(diffMap[filePath].Diff) {
+			key := language + ":" + removed.Name
+			if lookedUp[key] {
+				continue
+			}
+			if len(lookedUp) == maxReferenceLookups {
+				fmt.Printf("[!] Unused symbol check stopped after %d lookups\n", maxReferenceLookups)
+				return issues
+			}
+			lookedUp[key] = true
+
+			result, err := referencesTool.Execute(ctx, map[string]any{"name": removed.Name, "language": language})
+			if err != nil {
+				fmt.Printf("[!] Could not look up the references of %s: %v\n", removed.Name, err)
+				continue
+			}
+			references, _ := result.(types.SymbolReferences)
+			if issue, ok := analysis.CheckUnusedSymbol(filePath, removed, references); ok {
+				issues = append(issues, issue)
+			}
+		}
+	}
+	return issues
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/severity"
+)
+
+// The Checkstyle XML format read by Jenkins warnings-ng, Bitbucket and most CI annotators, see
+// https://checkstyle.sourceforge.io
+type checkstyleResult struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line   int `xml:"line,attr"`
+	Column int `xml:"column,attr"`
+	// Severity is error, warning or info
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// Checkstyle formats the issues as a Checkstyle XML report, grouped by file in the order of
+// the issues. The severities are mapped like in the compact output and the source is the
+// check of the issue, diffpector.llm for the findings of the model.
+func (r ReviewResult) Checkstyle() (string, error) {
+	severities := r.severities
+	if severities == nil {
+		severities = severity.Default()
+	}
+
+	result := checkstyleResult{Version: "4.3", Files: []checkstyleFile{}}
+	index := make(map[string]int)
+	for _, issue := range r.Issues {
+		position, ok := index[issue.FilePath]
+		if !ok {
+			position = len(result.Files)
+			index[issue.FilePath] = position
+			result.Files = append(result.Files, checkstyleFile{Name: issue.FilePath})
+		}
+
+		source := "diffpector.llm"
+		if issue.Category != "" {
+			source = "diffpector." + issue.Category
+		}
+		result.Files[position].Errors = append(result.Files[position].Errors, checkstyleError{
+			Line:     max(issue.StartLine, 1),
+			Column:   1,
+			Severity: problemLevel(severities, issue.Severity),
+			Message:  strings.Join(strings.Fields(issue.Description), " "),
+			Source:   source,
+		})
+	}
+
+	data, err := xml.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode Checkstyle report: %w", err)
+	}
+	return xml.Header + string(data) + "\n", nil
+}
@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestReviewResult_Checkstyle(t *testing.T) {
+	result := ReviewResult{
+		Issues: []types.Issue{
+			{Severity: "CRITICAL", FilePath: "main.go", StartLine: 10, EndLine: 12, Category: "secrets", Description: "Hardcoded\n  token"},
+			{Severity: "MINOR", FilePath: "util.go", StartLine: 0, Description: "Name \"x\" & <y> is unclear"},
+			{Severity: "WARNING", FilePath: "main.go", StartLine: 3, Description: "Unchecked error"},
+		},
+	}
+
+	output, err := result.Checkstyle()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="main.go">
+    <error line="10" column="1" severity="error" message="Hardcoded token" source="diffpector.secrets"></error>
+    <error line="3" column="1" severity="warning" message="Unchecked error" source="diffpector.llm"></error>
+  </file>
+  <file name="util.go">
+    <error line="1" column="1" severity="info" message="Name &#34;x&#34; &amp; &lt;y&gt; is unclear" source="diffpector.llm"></error>
+  </file>
+</checkstyle>
+`
+	if output != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, output)
+	}
+
+	empty, err := ReviewResult{}.Checkstyle()
+	if err != nil || empty != "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<checkstyle version=\"4.3\"></checkstyle>\n" {
+		t.Errorf("Expected an empty report, got %q, %v", empty, err)
+	}
+}
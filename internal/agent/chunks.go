@@ -0,0 +1,301 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/utils"
+)
+
+// fileReview is the outcome of the review of a file diff
+type fileReview struct {
+	issues []types.Issue
+	// answers are the model answers of every chunk, the questions are parsed from them
+	answers  []memberReview
+	rejected int
+	// data is the diff data with the context gathered for the review
+	data types.DiffData
+}
+
+// reviewDiff reviews the diff of a file, chunk by chunk when it is oversized, and returns
+// the verified issues of all chunks with the near-duplicates found at their boundaries
+// merged. The review fails when one of the chunks fails.
+func (a *CodeReviewAgent) reviewDiff(ctx context.Context, filePath string, diffData types.DiffData, primaryLanguage string) (fileReview, error) {
+	chunks := a.chunkDiff(filePath, diffData)
+	if len(chunks) > 1 {
+		fmt.Printf("  [i] Oversized diff, reviewing it in %d chunks\n", len(chunks))
+		if a.result.ChunkedFiles == nil {
+			a.result.ChunkedFiles = make(map[string]int)
+		}
+		a.result.ChunkedFiles[filePath] = len(chunks)
+	}
+
+	result := fileReview{data: diffData}
+	var contexts []string
+	var symbols []types.SymbolUsage
+	for i, chunk := range chunks {
+		if len(chunks) > 1 {
+			fmt.Printf("  [>] Chunk %d/%d\n", i+1, len(chunks))
+		}
+		chunkMap := map[string]types.DiffData{filePath: chunk}
+
+		reviews, err := a.reviewFile(ctx, chunkMap, primaryLanguage)
+		if err != nil {
+			return result, err
+		}
+		issues, err := a.parseReviews(filePath, reviews)
+		if err != nil {
+			return result, fmt.Errorf("failed to parse review: %w", err)
+		}
+
+		issues, rejected := a.verifyIssues(ctx, issues, a.payload(chunkMap))
+		a.restoreIssues(issues)
+		result.issues = append(result.issues, issues...)
+		result.answers = append(result.answers, reviews...)
+		result.rejected += rejected
+
+		gathered := chunkMap[filePath]
+		if len(chunks) == 1 {
+			result.data = gathered
+			break
+		}
+		if gathered.DiffContext != "" {
+			contexts = append(contexts, gathered.DiffContext)
+		}
+		symbols = append(symbols, gathered.AffectedSymbols...)
+	}
+
+	if len(chunks) > 1 {
+		result.data.DiffContext = strings.Join(contexts, "\n\n")
+		result.data.AffectedSymbols = symbols
+		result.issues = MergeDuplicateIssues(result.issues, a.severities)
+	}
+	return result, nil
+}
+
+// chunkDiff splits a diff of more than chunkLines lines into chunks of about chunkLines
+// lines. Hunks changing the same declaration stay in one chunk unless they exceed the
+// budget alone, so that every chunk is a coherent part of the change. A hunk larger than
+// the budget, such as the single hunk of a new file, is first split at the declarations it
+// adds. Smaller diffs are returned whole.
+func (a *CodeReviewAgent) chunkDiff(filePath string, diffData types.DiffData) []types.DiffData {
+	whole := []types.DiffData{diffData}
+	if a.chunkLines <= 0 {
+		return whole
+	}
+	header, hunks := utils.SplitDiffHunks(diffData.Diff)
+	total := 0
+	for _, hunk := range hunks {
+		total += hunk.Lines
+	}
+	if total <= a.chunkLines {
+		return whole
+	}
+
+	declarations := a.declarations(filePath, diffData)
+	var pieces []utils.DiffHunk
+	for _, hunk := range hunks {
+		pieces = append(pieces, splitHunk(hunk, declarations, a.chunkLines)...)
+	}
+	if len(pieces) < 2 {
+		return whole
+	}
+
+	var chunks [][]utils.DiffHunk
+	size := 0
+	for _, group := range groupHunks(pieces, declarations) {
+		groupSize := 0
+		for _, hunk := range group {
+			groupSize += hunk.Lines
+		}
+		for i, hunk := range group {
+			// A group that does not fit starts a new chunk, and is only split when larger
+			// than a chunk itself
+			overflow := size+hunk.Lines > a.chunkLines
+			if i == 0 {
+				overflow = size+groupSize > a.chunkLines
+			}
+			if len(chunks) == 0 || (overflow && size > 0) {
+				chunks = append(chunks, nil)
+				size = 0
+			}
+			chunks[len(chunks)-1] = append(chunks[len(chunks)-1], hunk)
+			size += hunk.Lines
+		}
+	}
+
+	result := make([]types.DiffData, 0, len(chunks))
+	for _, chunk := range chunks {
+		data := diffData
+		texts := []string{header}
+		var similar []types.SimilarCode
+		for _, hunk := range chunk {
+			texts = append(texts, hunk.Text)
+		}
+		for _, code := range diffData.SimilarCode {
+			for _, hunk := range chunk {
+				if code.AddedStart <= hunk.EndLine && hunk.StartLine <= code.AddedEnd {
+					similar = append(similar, code)
+					break
+				}
+			}
+		}
+		data.Diff = strings.Join(texts, "\n") + "\n"
+		data.SimilarCode = similar
+		result = append(result, data)
+	}
+	return result
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)$`)
+
+// splitHunk splits a hunk of more than budget lines into hunks of at most budget lines,
+// cutting before the last declaration starting in each of them when there is one. Every
+// piece gets the header of the lines it holds, so that it is a valid hunk on its own.
+func splitHunk(hunk utils.DiffHunk, declarations []types.Symbol, budget int) []utils.DiffHunk {
+	lines := strings.Split(hunk.Text, "\n")
+	matches := hunkHeaderRegex.FindStringSubmatch(lines[0])
+	if hunk.Lines <= budget || matches == nil {
+		return []utils.DiffHunk{hunk}
+	}
+	body := lines[1:]
+	oldLine, _ := strconv.Atoi(matches[1])
+	newLine, _ := strconv.Atoi(matches[3])
+	// An empty side is numbered after the line preceding it, the lines of the hunk follow it
+	if matches[2] == "0" {
+		oldLine++
+	}
+	if matches[4] == "0" {
+		newLine++
+	}
+
+	starts := make(map[int]bool, len(declarations))
+	for _, symbol := range declarations {
+		starts[symbol.StartLine] = true
+	}
+	// boundary marks the lines starting a declaration of the new file, newLines numbering
+	// the lines of the body in it
+	boundary := make([]bool, len(body))
+	newLines := make([]int, len(body))
+	line := newLine
+	for i, text := range body {
+		newLines[i] = line
+		if strings.HasPrefix(text, "+") || strings.HasPrefix(text, " ") {
+			boundary[i] = starts[line]
+			line++
+		}
+	}
+
+	var pieces []utils.DiffHunk
+	heading := matches[5]
+	start := 0
+	for start < len(body) {
+		end := min(start+budget, len(body))
+		if end < len(body) {
+			for cut := end; cut > start; cut-- {
+				if boundary[cut] {
+					end = cut
+					break
+				}
+			}
+			// The marker of a missing final newline stays with the line it follows
+			for end < len(body) && strings.HasPrefix(body[end], "\\") {
+				end++
+			}
+		}
+
+		piece := body[start:end]
+		oldCount, newCount := 0, 0
+		for _, text := range piece {
+			switch {
+			case strings.HasPrefix(text, " "):
+				oldCount++
+				newCount++
+			case strings.HasPrefix(text, "-"):
+				oldCount++
+			case strings.HasPrefix(text, "+"):
+				newCount++
+			}
+		}
+		// An empty side is numbered after the line preceding it
+		oldStart, newStart := oldLine, newLines[start]
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+
+		header := fmt.Sprintf("@@ -%d,%d +%d,%d @@%s", oldStart, oldCount, newStart, newCount, heading)
+		pieces = append(pieces, utils.DiffHunk{
+			Text:      header + "\n" + strings.Join(piece, "\n"),
+			StartLine: newLines[start],
+			EndLine:   newLines[start] + max(newCount, 1) - 1,
+			Lines:     len(piece),
+		})
+		oldLine += oldCount
+		heading = ""
+		start = end
+	}
+	return pieces
+}
+
+// groupHunks groups the consecutive hunks changing the same declaration, hunks outside any
+// declaration being groups of their own
+func groupHunks(hunks []utils.DiffHunk, declarations []types.Symbol) [][]utils.DiffHunk {
+	var groups [][]utils.DiffHunk
+	last := -1
+	for _, hunk := range hunks {
+		declaration := enclosingDeclaration(declarations, hunk)
+		if declaration >= 0 && declaration == last {
+			groups[len(groups)-1] = append(groups[len(groups)-1], hunk)
+		} else {
+			groups = append(groups, []utils.DiffHunk{hunk})
+		}
+		last = declaration
+	}
+	return groups
+}
+
+// enclosingDeclaration returns the index of the smallest declaration the hunk overlaps, -1
+// when it overlaps none
+func enclosingDeclaration(declarations []types.Symbol, hunk utils.DiffHunk) int {
+	best := -1
+	for i, symbol := range declarations {
+		if symbol.StartLine > hunk.EndLine || hunk.StartLine > symbol.EndLine {
+			continue
+		}
+		if best < 0 || symbol.EndLine-symbol.StartLine < declarations[best].EndLine-declarations[best].StartLine {
+			best = i
+		}
+	}
+	return best
+}
+
+// declarations returns the declarations of the changed file, none when it cannot be read
+// or parsed, the hunks are then chunked by size alone
+func (a *CodeReviewAgent) declarations(filePath string, diffData types.DiffData) []types.Symbol {
+	content, err := os.ReadFile(diffData.AbsolutePath)
+	if err != nil {
+		return nil
+	}
+	symbols, err := a.parserRegistry.ParseFile(filePath, content)
+	if err != nil {
+		slog.Warn("parse failed", "file", filePath, "error", err)
+		return nil
+	}
+
+	var declarations []types.Symbol
+	for _, symbol := range symbols {
+		if utils.IsDeclaration(symbol.Type) {
+			declarations = append(declarations, symbol)
+		}
+	}
+	return declarations
+}
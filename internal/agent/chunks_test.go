@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/sourcegraph/go-diff/diff"
+)
+
+const chunkedDiff = `--- a/main.go
++++ b/main.go
+@@ -4,2 +4,2 @@ func a() {
+ 	x := 1
+-	_ = 0
++	_ = x
+@@ -9,2 +9,2 @@ func b() {
+ 	y := 1
+-	_ = 0
++	_ = y
+@@ -11,2 +11,2 @@ func b() {
+ 	z := 2
+-	_ = 0
++	_ = z
+`
+
+func TestChunkDiffGroupsHunksByDeclaration(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "main.go")
+	content := "package main\n\nfunc a() {\n\tx := 1\n\t_ = x\n}\n\nfunc b() {\n\ty := 1\n\t_ = y\n\tz := 2\n\t_ = z\n}\n"
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	agent.SetChunkLines(6)
+	diffData := types.DiffData{AbsolutePath: filePath, Diff: chunkedDiff, SimilarCode: []types.SimilarCode{
+		{AddedStart: 12, AddedEnd: 12, FilePath: "util.go"},
+	}}
+
+	chunks := agent.chunkDiff("main.go", diffData)
+
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d", len(chunks))
+	}
+	if !strings.HasPrefix(chunks[1].Diff, "--- a/main.go\n+++ b/main.go\n") {
+		t.Errorf("Expected every chunk to keep the file headers, got %q", chunks[1].Diff)
+	}
+	if strings.Count(chunks[0].Diff, "@@ -") != 1 || !strings.Contains(chunks[0].Diff, "+\t_ = x") {
+		t.Errorf("Expected the hunk of a() alone in the first chunk, got %q", chunks[0].Diff)
+	}
+	if !strings.Contains(chunks[1].Diff, "+\t_ = y") || !strings.Contains(chunks[1].Diff, "+\t_ = z") {
+		t.Errorf("Expected both hunks of b() in the second chunk, got %q", chunks[1].Diff)
+	}
+	if len(chunks[0].SimilarCode) != 0 || len(chunks[1].SimilarCode) != 1 {
+		t.Errorf("Expected the similar code to follow its hunk, got %v and %v", chunks[0].SimilarCode, chunks[1].SimilarCode)
+	}
+}
+
+func TestChunkDiffKeepsSmallDiffsWhole(t *testing.T) {
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	diffData := types.DiffData{AbsolutePath: "missing.go", Diff: chunkedDiff}
+
+	if chunks := agent.chunkDiff("main.go", diffData); len(chunks) != 1 || chunks[0].Diff != chunkedDiff {
+		t.Errorf("Expected the diff whole without chunk lines, got %d chunk(s)", len(chunks))
+	}
+
+	agent.SetChunkLines(9)
+	if chunks := agent.chunkDiff("main.go", diffData); len(chunks) != 1 {
+		t.Errorf("Expected a diff within the budget whole, got %d chunk(s)", len(chunks))
+	}
+
+	// Without declarations the hunks are packed by size alone
+	agent.SetChunkLines(6)
+	if chunks := agent.chunkDiff("main.go", diffData); len(chunks) != 2 || strings.Count(chunks[0].Diff, "@@ -") != 2 {
+		t.Errorf("Expected the first two hunks in one chunk, got %+v", chunks)
+	}
+}
+
+func TestChunkDiffSplitsSingleHunk(t *testing.T) {
+	content := "package main\n\nfunc a() {\n\tx := 1\n\t_ = x\n}\n\nfunc b() {\n\ty := 1\n\t_ = y\n}\n\nfunc c() {\n\tz := 1\n\t_ = z\n}\n"
+	filePath := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	newFileDiff := "--- /dev/null\n+++ b/main.go\n@@ -0,0 +1,16 @@\n+" + strings.Join(lines, "\n+") + "\n"
+
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	agent.SetChunkLines(7)
+
+	for _, tt := range []struct {
+		name  string
+		path  string
+		first []string
+	}{
+		{"at declarations", filePath, []string{"+package main", "+func b() {", "+func c() {"}},
+		{"by size", "missing.go", []string{"+package main", "+func b() {", "+\t_ = z"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := agent.chunkDiff("main.go", types.DiffData{AbsolutePath: tt.path, Diff: newFileDiff})
+			if len(chunks) != len(tt.first) {
+				t.Fatalf("Expected %d chunks, got %d", len(tt.first), len(chunks))
+			}
+
+			var added []string
+			for i, chunk := range chunks {
+				fileDiff, err := diff.ParseFileDiff([]byte(chunk.Diff))
+				if err != nil || len(fileDiff.Hunks) != 1 {
+					t.Fatalf("Expected chunk %d to be a valid single hunk diff, got %v:\n%s", i, err, chunk.Diff)
+				}
+				hunk := fileDiff.Hunks[0]
+				body := strings.Split(strings.TrimSuffix(string(hunk.Body), "\n"), "\n")
+				if hunk.OrigStartLine != 0 || hunk.OrigLines != 0 || hunk.NewLines != int32(len(body)) || hunk.NewStartLine != int32(len(added)+1) {
+					t.Errorf("Expected the header of chunk %d to number its lines, got %+v", i, hunk)
+				}
+				if body[0] != tt.first[i] {
+					t.Errorf("Expected chunk %d to start with %q, got %q", i, tt.first[i], body[0])
+				}
+				added = append(added, body...)
+			}
+			if len(added) != len(lines) {
+				t.Errorf("Expected the chunks to hold the %d lines of the file, got %d", len(lines), len(added))
+			}
+		})
+	}
+}
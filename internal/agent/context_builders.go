@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+const (
+	ContextStrategyHunksOnly       = "hunks-only"
+	ContextStrategyAffectedSymbols = "affected-symbols"
+	ContextStrategyFullFile        = "full-file"
+)
+
+// ContextBuilder gathers the context given to the model next to the diff of a changed file
+type ContextBuilder interface {
+	Name() string
+	// Build returns the diff data with its DiffContext and AffectedSymbols filled in
+	Build(ctx context.Context, diffData types.DiffData, primaryLanguage string) (types.DiffData, error)
+}
+
+// newContextBuilder returns the builder of a context strategy, an empty strategy being the
+// affected symbols one
+func newContextBuilder(strategy string, registry *tools.ToolRegistry) (ContextBuilder, error) {
+	switch strategy {
+	case ContextStrategyHunksOnly:
+		return hunksOnlyBuilder{}, nil
+	case ContextStrategyAffectedSymbols, "":
+		return &affectedSymbolsBuilder{registry: registry}, nil
+	case ContextStrategyFullFile:
+		return &fullFileBuilder{symbols: &affectedSymbolsBuilder{registry: registry}}, nil
+	default:
+		return nil, fmt.Errorf("unknown context strategy %q (supported: %s, %s, %s)", strategy,
+			ContextStrategyHunksOnly, ContextStrategyAffectedSymbols, ContextStrategyFullFile)
+	}
+}
+
+// hunksOnlyBuilder sends the diff alone, the cheapest and least informed review
+type hunksOnlyBuilder struct{}
+
+func (hunksOnlyBuilder) Name() string {
+	return ContextStrategyHunksOnly
+}
+
+func (hunksOnlyBuilder) Build(ctx context.Context, diffData types.DiffData, primaryLanguage string) (types.DiffData, error) {
+	return diffData, nil
+}
+
+// affectedSymbolsBuilder adds the declarations touched by the diff and the usages of the
+// changed symbols across the project
+type affectedSymbolsBuilder struct {
+	registry *tools.ToolRegistry
+}
+
+func (b *affectedSymbolsBuilder) Name() string {
+	return ContextStrategyAffectedSymbols
+}
+
+func (b *affectedSymbolsBuilder) Build(ctx context.Context, diffData types.DiffData, primaryLanguage string) (types.DiffData, error) {
+	symbolContextTool := b.registry.Get(tools.ToolNameSymbolContext)
+
+	updatedDataResult, err := symbolContextTool.Execute(ctx, map[string]any{"diffData": diffData, "primaryLanguage": primaryLanguage})
+	if err != nil {
+		return diffData, fmt.Errorf("symbol analysis failed: %w", err)
+	}
+	updatedData, ok := updatedDataResult.(types.DiffData)
+	if !ok {
+		return diffData, fmt.Errorf("symbol context tool returned unexpected type: %T", updatedDataResult)
+	}
+
+	diffData.DiffContext = updatedData.DiffContext
+	diffData.AffectedSymbols = updatedData.AffectedSymbols
+	return diffData, nil
+}
+
+// fullFileBuilder replaces the touched declarations with the whole changed file, keeping
+// the usages of the changed symbols
+type fullFileBuilder struct {
+	symbols *affectedSymbolsBuilder
+}
+
+func (b *fullFileBuilder) Name() string {
+	return ContextStrategyFullFile
+}
+
+func (b *fullFileBuilder) Build(ctx context.Context, diffData types.DiffData, primaryLanguage string) (types.DiffData, error) {
+	content, err := os.ReadFile(diffData.AbsolutePath)
+	if err != nil {
+		return diffData, fmt.Errorf("failed to read changed file: %w", err)
+	}
+
+	diffData, err = b.symbols.Build(ctx, diffData, primaryLanguage)
+	diffData.DiffContext = string(content)
+	return diffData, err
+}
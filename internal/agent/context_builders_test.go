@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// stubSymbolContextTool fills the diff data like the symbol context tool would
+type stubSymbolContextTool struct{}
+
+func (stubSymbolContextTool) Name() string           { return string(tools.ToolNameSymbolContext) }
+func (stubSymbolContextTool) Description() string    { return "" }
+func (stubSymbolContextTool) Schema() map[string]any { return nil }
+
+func (stubSymbolContextTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	diffData := args["diffData"].(types.DiffData)
+	diffData.DiffContext = "func Add(a, b int) int {"
+	diffData.AffectedSymbols = []types.SymbolUsage{{Snippets: "usage of Add"}}
+	return diffData, nil
+}
+
+func TestContextBuilders(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "main.go")
+	content := "package main\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n"
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	registry := tools.NewToolRegistry()
+	registry.Register(tools.ToolNameSymbolContext, stubSymbolContextTool{})
+	diffData := types.DiffData{AbsolutePath: filePath, Diff: "+\treturn a + b"}
+
+	tests := []struct {
+		strategy        string
+		expectedContext string
+		expectedUsages  int
+	}{
+		{ContextStrategyHunksOnly, "", 0},
+		{ContextStrategyAffectedSymbols, "func Add(a, b int) int {", 1},
+		{"", "func Add(a, b int) int {", 1},
+		{ContextStrategyFullFile, content, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			builder, err := newContextBuilder(tt.strategy, registry)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			built, err := builder.Build(context.Background(), diffData, "go")
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+			if built.DiffContext != tt.expectedContext {
+				t.Errorf("Expected context %q, got %q", tt.expectedContext, built.DiffContext)
+			}
+			if len(built.AffectedSymbols) != tt.expectedUsages {
+				t.Errorf("Expected %d affected symbols, got %d", tt.expectedUsages, len(built.AffectedSymbols))
+			}
+			if built.Diff != diffData.Diff {
+				t.Errorf("Expected the diff to be kept, got %q", built.Diff)
+			}
+		})
+	}
+
+	if _, err := newContextBuilder("everything", registry); err == nil {
+		t.Error("Expected an error for an unknown strategy")
+	}
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// neighborLines is the gap between line ranges still considered the same location
+const neighborLines = 3
+
+// descriptionSimilarity is the share of common description words above which two findings
+// at the same location are the same issue
+const descriptionSimilarity = 0.6
+
+// MergeDuplicateIssues folds findings of the same file and check whose line ranges overlap or
+// touch and whose descriptions are similar, as produced when one change is flagged on
+// neighboring lines or the same issue is reported twice from overlapping context. The merged
+// issue spans all ranges, keeps the highest severity and confidence and counts the occurrences.
+func MergeDuplicateIssues(issues []types.Issue, severities *severity.Registry) []types.Issue {
+	var merged []types.Issue
+	var words [][]string
+
+	for _, issue := range issues {
+		issueWords := descriptionWords(issue.Description)
+
+		duplicate := -1
+		for i := range merged {
+			if isDuplicate(merged[i], issue, words[i], issueWords) {
+				duplicate = i
+				break
+			}
+		}
+
+		if duplicate == -1 {
+			merged = append(merged, issue)
+			words = append(words, issueWords)
+			continue
+		}
+
+		mergeInto(&merged[duplicate], issue, severities)
+	}
+
+	return merged
+}
+
+func isDuplicate(a, b types.Issue, aWords, bWords []string) bool {
+	if a.FilePath != b.FilePath || a.Category != b.Category {
+		return false
+	}
+	if b.StartLine > a.EndLine+neighborLines || a.StartLine > b.EndLine+neighborLines {
+		return false
+	}
+	return jaccard(aWords, bWords) >= descriptionSimilarity
+}
+
+func mergeInto(target *types.Issue, duplicate types.Issue, severities *severity.Registry) {
+	if target.Occurrences == 0 {
+		target.Occurrences = 1
+	}
+	target.Occurrences += max(duplicate.Occurrences, 1)
+
+	target.StartLine = min(target.StartLine, duplicate.StartLine)
+	target.EndLine = max(target.EndLine, duplicate.EndLine)
+	if severities.Rank(duplicate.Severity) > severities.Rank(target.Severity) {
+		target.Severity = duplicate.Severity
+	}
+	if duplicate.Confidence > target.Confidence {
+		target.Confidence = duplicate.Confidence
+	}
+	target.Unchanged = target.Unchanged && duplicate.Unchanged
+	if target.SuggestedFix == "" {
+		target.SuggestedFix = duplicate.SuggestedFix
+	}
+	// The snippet of the first finding no longer covers the merged range
+	target.CodeSnippet = ""
+}
+
+// descriptionWords returns the distinct lower case words of a description, ignoring short ones
+func descriptionWords(description string) []string {
+	seen := make(map[string]bool)
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if len([]rune(word)) > 2 && !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	set := make(map[string]bool, len(a))
+	for _, word := range a {
+		set[word] = true
+	}
+	common := 0
+	for _, word := range b {
+		if set[word] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
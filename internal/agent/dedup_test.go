@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestMergeDuplicateIssues_MergesNeighboringSimilarIssues(t *testing.T) {
+	issues := []types.Issue{
+		{Severity: "WARNING", FilePath: "db.go", StartLine: 10, EndLine: 10, Description: "Error returned by rows.Close is ignored", CodeSnippet: "rows.Close()", Confidence: 0.6},
+		{Severity: "CRITICAL", FilePath: "db.go", StartLine: 12, EndLine: 13, Description: "The error returned by rows.Close is ignored", Confidence: 0.9},
+		{Severity: "WARNING", FilePath: "db.go", StartLine: 40, EndLine: 40, Description: "Error returned by rows.Close is ignored"},
+		{Severity: "WARNING", FilePath: "api.go", StartLine: 11, EndLine: 11, Description: "Error returned by rows.Close is ignored"},
+		{Severity: "WARNING", FilePath: "db.go", StartLine: 11, EndLine: 11, Description: "Query built by string concatenation allows SQL injection"},
+	}
+
+	merged := MergeDuplicateIssues(issues, severity.Default())
+
+	if len(merged) != 4 {
+		t.Fatalf("Expected 4 issues after merging, got %d: %v", len(merged), merged)
+	}
+	first := merged[0]
+	if first.Occurrences != 2 || first.StartLine != 10 || first.EndLine != 13 {
+		t.Errorf("Expected 2 occurrences over lines 10-13, got %d over %d-%d", first.Occurrences, first.StartLine, first.EndLine)
+	}
+	if first.Severity != "CRITICAL" || first.Confidence != 0.9 {
+		t.Errorf("Expected the highest severity and confidence to be kept, got %s %.2f", first.Severity, first.Confidence)
+	}
+	if first.CodeSnippet != "" {
+		t.Errorf("Expected the snippet of a merged issue to be dropped, got %q", first.CodeSnippet)
+	}
+	for _, issue := range merged[1:] {
+		if issue.Occurrences != 0 {
+			t.Errorf("Expected %s:%d to stay unmerged, got %d occurrences", issue.FilePath, issue.StartLine, issue.Occurrences)
+		}
+	}
+}
+
+func TestMergeDuplicateIssues_KeepsChecksApart(t *testing.T) {
+	issues := []types.Issue{
+		{Severity: "MINOR", FilePath: "a.go", StartLine: 5, EndLine: 5, Description: "Doc comment of Run may be outdated", Category: "doc_drift"},
+		{Severity: "MINOR", FilePath: "a.go", StartLine: 5, EndLine: 5, Description: "Doc comment of Run may be outdated"},
+	}
+
+	if merged := MergeDuplicateIssues(issues, severity.Default()); len(merged) != 2 {
+		t.Errorf("Expected findings of different checks to stay apart, got %v", merged)
+	}
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/agusespa/diffpector/internal/dependencies"
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// annotateDependencies summarizes the dependencies added, removed and upgraded by the changed
+// manifests, with the known vulnerabilities of the new versions when a source is set
+func (a *CodeReviewAgent) annotateDependencies(diffMap map[string]types.DiffData) {
+	for filePath, diffData := range diffMap {
+		if !dependencies.IsManifest(filePath) {
+			continue
+		}
+
+		newContent, err := os.ReadFile(diffData.AbsolutePath)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Printf("[!] No dependency summary for %s: %v\n", filePath, err)
+			continue
+		}
+		oldContent, err := tools.ReconstructOriginal(newContent, diffData.Diff)
+		if err != nil {
+			fmt.Printf("[!] No dependency summary for %s: %v\n", filePath, err)
+			continue
+		}
+
+		changes, err := dependencies.Compare(filePath, oldContent, newContent)
+		if err != nil {
+			fmt.Printf("[!] No dependency summary for %s: %v\n", filePath, err)
+			continue
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		if a.vulnerabilities != nil {
+			dependencies.Lookup(a.vulnerabilities, changes)
+		}
+
+		fmt.Printf("[i] %s changes %d dependencies\n", filePath, len(changes))
+		diffData.DependencyChanges = dependencies.Format(changes)
+		diffMap[filePath] = diffData
+	}
+}
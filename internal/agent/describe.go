@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/prompts"
+	"github.com/agusespa/diffpector/pkg/spinner"
+)
+
+// DescribeStagedChanges writes a commit message or pull request description of the staged
+// changes with the given describe variant, from the same context the review gathers.
+// It returns an empty description when nothing is staged.
+func (a *CodeReviewAgent) DescribeStagedChanges(ctx context.Context, variant string) (string, error) {
+	diffMap, err := a.stagedDiff(ctx)
+	if err != nil || len(diffMap) == 0 {
+		return "", err
+	}
+
+	primaryLanguage, err := a.ValidateAndDetectLanguage(slices.Collect(maps.Keys(diffMap)))
+	if err != nil {
+		return "", err
+	}
+
+	a.annotateSymbolMoves(diffMap)
+
+	ctxSpinner := spinner.New("Gathering context...")
+	ctxSpinner.Start()
+	err = a.UpdateDiffContext(ctx, diffMap, primaryLanguage)
+	ctxSpinner.Stop()
+	if err != nil {
+		fmt.Printf("[!] Context gathering failed, describing the diff alone: %v\n", err)
+	}
+
+	prompt, err := prompts.BuildDescribePrompt(variant, a.payload(diffMap))
+	if err != nil {
+		return "", fmt.Errorf("failed to build describe prompt: %w", err)
+	}
+
+	describeSpinner := spinner.New("Describing changes...")
+	describeSpinner.Start()
+	description, err := a.llmProvider.Generate(ctx, prompt)
+	describeSpinner.Stop()
+	if err != nil {
+		return "", fmt.Errorf("failed to describe changes: %w", err)
+	}
+
+	description = stripCodeFence(a.restore(description))
+	if description == "" {
+		return "", fmt.Errorf("LLM returned an empty description")
+	}
+	return description + "\n", nil
+}
+
+// stripCodeFence removes the code fence models tend to wrap their whole answer in
+func stripCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") || len(text) < 6 {
+		return text
+	}
+
+	text = strings.TrimSuffix(text, "```")
+	if newline := strings.Index(text, "\n"); newline >= 0 {
+		text = text[newline+1:]
+	} else {
+		text = strings.TrimPrefix(text, "```")
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,24 @@
+package agent
+
+import "testing"
+
+func TestStripCodeFence(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain text", "Add retries\n\nSummary", "Add retries\n\nSummary"},
+		{"fenced with language", "```markdown\n# Add retries\n\n## Summary\n```", "# Add retries\n\n## Summary"},
+		{"fenced without language", "\n```\nAdd retries\n```\n", "Add retries"},
+		{"inner fence kept", "Add retries\n\n```go\nretry()\n```", "Add retries\n\n```go\nretry()\n```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripCodeFence(tt.input); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/llm"
+	"github.com/agusespa/diffpector/internal/telemetry"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/utils"
+)
+
+const (
+	// EnsemblePolicyConsensus reports the issues found by at least the minimum agreement
+	EnsemblePolicyConsensus = "consensus"
+	// EnsemblePolicyUnion reports every issue, annotated with the models that found it
+	EnsemblePolicyUnion = "union"
+)
+
+// EnsembleMember is a model of the ensemble, Name identifies it in the report
+type EnsembleMember struct {
+	Name     string
+	Provider llm.Provider
+}
+
+// memberReview is the answer of a model to the review prompt, Member is empty outside an
+// ensemble
+type memberReview struct {
+	Member string
+	Answer string
+}
+
+// SetEnsemble reviews every file with each member instead of the agent provider and merges
+// their findings with the policy. A zero minAgreement is a majority of the members.
+func (a *CodeReviewAgent) SetEnsemble(members []EnsembleMember, policy string, minAgreement int) error {
+	if len(members) == 0 {
+		a.ensemble = nil
+		return nil
+	}
+	if policy == "" {
+		policy = EnsemblePolicyConsensus
+	}
+	if policy != EnsemblePolicyConsensus && policy != EnsemblePolicyUnion {
+		return fmt.Errorf("unknown ensemble policy %q, use %s or %s", policy, EnsemblePolicyConsensus, EnsemblePolicyUnion)
+	}
+	if minAgreement == 0 {
+		minAgreement = len(members)/2 + 1
+	}
+	if minAgreement < 1 || minAgreement > len(members) {
+		return fmt.Errorf("ensemble min_agreement must be between 1 and %d, got %d", len(members), minAgreement)
+	}
+
+	a.ensemble = members
+	a.ensemblePolicy = policy
+	a.minAgreement = minAgreement
+	return nil
+}
+
+// reviewFile gathers the context of the file and reviews it with the agent provider, or with
+// every member of the ensemble. Failing members are skipped as long as one of them answers.
+func (a *CodeReviewAgent) reviewFile(ctx context.Context, diffMap map[string]types.DiffData, primaryLanguage string) ([]memberReview, error) {
+	if len(a.ensemble) == 0 {
+		review, err := a.analyzeDiffs(ctx, diffMap, primaryLanguage)
+		if err != nil {
+			return nil, err
+		}
+		return []memberReview{{Answer: review}}, nil
+	}
+
+	a.gatherContext(ctx, diffMap, primaryLanguage)
+
+	var reviews []memberReview
+	var lastErr error
+	for _, member := range a.ensemble {
+		fmt.Printf("  [>] Reviewing with %s\n", member.Name)
+		review, err := a.generateReview(ctx, member.Provider, diffMap)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			fmt.Printf("  [!] %s failed: %v\n", member.Name, err)
+			slog.Warn("ensemble member failed", "member", member.Name, "error", err)
+			lastErr = err
+			continue
+		}
+		reviews = append(reviews, memberReview{Member: member.Name, Answer: review})
+	}
+	if len(reviews) == 0 {
+		return nil, fmt.Errorf("generate review failed: every ensemble member failed, last: %w", lastErr)
+	}
+	return reviews, nil
+}
+
+// parseReviews parses the issues of the reviews and merges the ones of an ensemble. A member
+// answer failing to parse only drops that member, the review fails when none parses.
+func (a *CodeReviewAgent) parseReviews(filePath string, reviews []memberReview) ([]types.Issue, error) {
+	parseStart := time.Now()
+	defer func() {
+		a.telemetry.Record(telemetry.MetricStageDuration, time.Since(parseStart).Seconds(), telemetry.String("stage", "parse"))
+	}()
+
+	var findings [][]types.Issue
+	var members []string
+	var lastErr error
+	for _, review := range reviews {
+		issues, err := utils.ParseIssuesWithSeverities(review.Answer, a.severities)
+		if err != nil {
+			slog.Warn("failed to parse review", "file", filePath, "member", review.Member, "error", err, "response", review.Answer)
+			if review.Member != "" && len(reviews) > 1 {
+				fmt.Printf("  [!] Failed to parse the review of %s: %v\n", review.Member, err)
+			}
+			lastErr = err
+			continue
+		}
+		findings = append(findings, issues)
+		members = append(members, review.Member)
+	}
+	if len(findings) == 0 {
+		return nil, lastErr
+	}
+	if len(a.ensemble) == 0 {
+		return findings[0], nil
+	}
+	return a.mergeEnsemble(findings, members), nil
+}
+
+// mergeEnsemble groups the findings of the members reporting the same location, taking at
+// most one finding per member into each group, and keeps the groups the policy accepts. The
+// kept issue is the first finding of the group with its highest severity and confidence,
+// and lists the members that found it.
+func (a *CodeReviewAgent) mergeEnsemble(findings [][]types.Issue, members []string) []types.Issue {
+	var merged []types.Issue
+	for i, issues := range findings {
+		for _, issue := range issues {
+			words := descriptionWords(issue.Description)
+			best, bestScore := -1, -1.0
+			for j := range merged {
+				if slices.Contains(merged[j].Models, members[i]) || !sameLocation(merged[j], issue) {
+					continue
+				}
+				if score := jaccard(words, descriptionWords(merged[j].Description)); score > bestScore {
+					best, bestScore = j, score
+				}
+			}
+			if best < 0 {
+				issue.Models = []string{members[i]}
+				merged = append(merged, issue)
+				continue
+			}
+
+			target := &merged[best]
+			target.Models = append(target.Models, members[i])
+			if a.severities.Rank(issue.Severity) > a.severities.Rank(target.Severity) {
+				target.Severity = issue.Severity
+			}
+			target.Confidence = max(target.Confidence, issue.Confidence)
+			if target.SuggestedFix == "" {
+				target.SuggestedFix = issue.SuggestedFix
+			}
+		}
+	}
+
+	if a.ensemblePolicy == EnsemblePolicyUnion {
+		return merged
+	}
+	var agreed []types.Issue
+	for _, issue := range merged {
+		if len(issue.Models) >= a.minAgreement {
+			agreed = append(agreed, issue)
+		}
+	}
+	if dropped := len(merged) - len(agreed); dropped > 0 {
+		fmt.Printf("  [-] %d finding(s) found by fewer than %d model(s) dropped\n", dropped, a.minAgreement)
+	}
+	return agreed
+}
+
+// ensembleNames returns the names of the members, for the review fingerprint
+func (a *CodeReviewAgent) ensembleNames() []string {
+	var names []string
+	for _, member := range a.ensemble {
+		names = append(names, member.Provider.GetModel())
+	}
+	return names
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func newEnsembleAgent(t *testing.T, policy string, minAgreement int) *CodeReviewAgent {
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	members := []EnsembleMember{
+		{Name: "qwen", Provider: unreachableProvider{t: t}},
+		{Name: "llama", Provider: unreachableProvider{t: t}},
+		{Name: "gemma", Provider: unreachableProvider{t: t}},
+	}
+	if err := agent.SetEnsemble(members, policy, minAgreement); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return agent
+}
+
+var ensembleFindings = [][]types.Issue{
+	{
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 10, EndLine: 12, Description: "SQL query built from user input", Confidence: 0.6},
+		{Severity: "MINOR", FilePath: "main.go", StartLine: 40, EndLine: 40, Description: "Typo in log message"},
+	},
+	{
+		{Severity: "CRITICAL", FilePath: "main.go", StartLine: 11, EndLine: 11, Description: "SQL injection through user input", Confidence: 0.9},
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 12, EndLine: 12, Description: "Query error not checked"},
+	},
+	{
+		{Severity: "WARNING", FilePath: "util.go", StartLine: 3, EndLine: 3, Description: "Unchecked error"},
+	},
+}
+
+func TestMergeEnsembleConsensus(t *testing.T) {
+	agent := newEnsembleAgent(t, "", 0)
+
+	issues := agent.mergeEnsemble(ensembleFindings, []string{"qwen", "llama", "gemma"})
+
+	if len(issues) != 1 {
+		t.Fatalf("Expected only the issue found by a majority, got %+v", issues)
+	}
+	issue := issues[0]
+	if !slices.Equal(issue.Models, []string{"qwen", "llama"}) {
+		t.Errorf("Expected the issue to be found by qwen and llama, got %v", issue.Models)
+	}
+	if issue.Severity != "CRITICAL" || issue.Confidence != 0.9 {
+		t.Errorf("Expected the highest severity and confidence, got %s %.2f", issue.Severity, issue.Confidence)
+	}
+	if issue.Description != "SQL query built from user input" {
+		t.Errorf("Expected the first finding to be kept, got %q", issue.Description)
+	}
+}
+
+func TestMergeEnsembleUnion(t *testing.T) {
+	agent := newEnsembleAgent(t, EnsemblePolicyUnion, 0)
+
+	issues := agent.mergeEnsemble(ensembleFindings, []string{"qwen", "llama", "gemma"})
+
+	// The unchecked query error of llama stays apart, llama already agreed on the injection
+	if len(issues) != 4 {
+		t.Fatalf("Expected every distinct issue, got %+v", issues)
+	}
+	for _, issue := range issues[1:] {
+		if len(issue.Models) != 1 {
+			t.Errorf("Expected %q to be found by one model, got %v", issue.Description, issue.Models)
+		}
+	}
+}
+
+func TestSetEnsembleValidation(t *testing.T) {
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	members := []EnsembleMember{{Name: "qwen", Provider: unreachableProvider{t: t}}, {Name: "llama", Provider: unreachableProvider{t: t}}}
+
+	if err := agent.SetEnsemble(members, "majority", 0); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+	if err := agent.SetEnsemble(members, EnsemblePolicyConsensus, 3); err == nil {
+		t.Error("Expected a min agreement above the number of models to be rejected")
+	}
+	if err := agent.SetEnsemble(members, "", 0); err != nil || agent.minAgreement != 2 {
+		t.Errorf("Expected a majority of 2 by default, got %d, %v", agent.minAgreement, err)
+	}
+}
@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/utils"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// DefaultGeneratedPatterns match lockfiles and the output of common code generators, which
+// are noted in the report instead of reviewed
+var DefaultGeneratedPatterns = []string{
+	"go.sum",
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"Cargo.lock",
+	"Gemfile.lock",
+	"poetry.lock",
+	"composer.lock",
+	"gradle.lockfile",
+	"*.pb.go",
+	"*_grpc.pb.go",
+	"*.pb.gw.go",
+	"*_pb2.py",
+	"*_pb2_grpc.py",
+	"*.gen.go",
+	"*_generated.go",
+	"zz_generated.*",
+	"mock_*.go",
+	"*_mock.go",
+	"**/mocks/**",
+	"**/__generated__/**",
+	"swagger.json",
+	"swagger.yaml",
+	"**/swagger/docs.go",
+}
+
+// generatedHeaderRegex matches the header go generate and other generators write, see
+// https://go.dev/s/generatedcode, also on the lines of a diff
+var generatedHeaderRegex = regexp.MustCompile(`(?m)^[-+ ]?\s*(?://|#|/\*|--)\s*Code generated .* DO NOT EDIT\.?`)
+
+// generatedHeaderSize is how much of the head of a file is searched for the header
+const generatedHeaderSize = 1024
+
+// SetGeneratedConfig adds patterns of generated files to the built-in ones, or turns the
+// classification off
+func (a *CodeReviewAgent) SetGeneratedConfig(generated config.GeneratedConfig) {
+	a.generated = generated
+}
+
+// setAsideGenerated removes the generated files from the diffs to review and records them
+// in the result, the report notes they changed
+func (a *CodeReviewAgent) setAsideGenerated(diffMap map[string]types.DiffData) {
+	if a.generated.Review {
+		return
+	}
+
+	var generated []string
+	for filePath, diffData := range diffMap {
+		if a.isGenerated(filePath, diffData) {
+			generated = append(generated, filePath)
+			delete(diffMap, filePath)
+		}
+	}
+	if len(generated) == 0 {
+		return
+	}
+
+	slices.Sort(generated)
+	fmt.Printf("[i] %d generated file(s) changed, not reviewed: %s\n", len(generated), strings.Join(generated, ", "))
+	a.result.GeneratedFiles = append(a.result.GeneratedFiles, generated...)
+}
+
+// isGenerated matches the path against the generated file patterns, then looks for a
+// "Code generated ... DO NOT EDIT." header at the top of the file
+func (a *CodeReviewAgent) isGenerated(filePath string, diffData types.DiffData) bool {
+	for _, pattern := range slices.Concat(DefaultGeneratedPatterns, a.generated.Patterns) {
+		if utils.MatchGlob(pattern, filePath) {
+			return true
+		}
+	}
+
+	file, err := os.Open(diffData.AbsolutePath)
+	if err != nil {
+		// Deleted files only have their diff left
+		return generatedHeaderRegex.MatchString(diffData.Diff)
+	}
+	defer file.Close()
+
+	head := make([]byte, generatedHeaderSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false
+	}
+	return generatedHeaderRegex.Match(head[:n])
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestSetAsideGenerated(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":        "package main\n",
+		"schema.go":      "// Code generated by sqlc. DO NOT EDIT.\n\npackage db\n",
+		"api/client.ts":  "export const client = {}\n",
+		"api/openapi.ts": "/* Code generated by openapi-ts. DO NOT EDIT. */\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diffMap := map[string]types.DiffData{
+		"main.go":               {AbsolutePath: filepath.Join(dir, "main.go")},
+		"go.sum":                {AbsolutePath: filepath.Join(dir, "go.sum")},
+		"web/package-lock.json": {AbsolutePath: filepath.Join(dir, "web/package-lock.json")},
+		"api/v1/user.pb.go":     {AbsolutePath: filepath.Join(dir, "api/v1/user.pb.go")},
+		"internal/mocks/db.go":  {AbsolutePath: filepath.Join(dir, "internal/mocks/db.go")},
+		"schema.go":             {AbsolutePath: filepath.Join(dir, "schema.go")},
+		"api/client.ts":         {AbsolutePath: filepath.Join(dir, "api/client.ts")},
+		"api/openapi.ts":        {AbsolutePath: filepath.Join(dir, "api/openapi.ts")},
+		"old_gen.go":            {AbsolutePath: filepath.Join(dir, "old_gen.go"), Diff: "@@ -1,3 +0,0 @@\n-// Code generated by stringer. DO NOT EDIT.\n-\n-package main\n"},
+		"assets/bundle.min.js":  {AbsolutePath: filepath.Join(dir, "assets/bundle.min.js")},
+	}
+
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	agent.SetGeneratedConfig(config.GeneratedConfig{Patterns: []string{"*.min.js"}})
+	agent.setAsideGenerated(diffMap)
+
+	if remaining := slices.Sorted(maps.Keys(diffMap)); !slices.Equal(remaining, []string{"api/client.ts", "main.go"}) {
+		t.Errorf("Expected only the hand-written files to be reviewed, got %v", remaining)
+	}
+
+	expected := []string{"api/openapi.ts", "api/v1/user.pb.go", "assets/bundle.min.js", "go.sum", "internal/mocks/db.go", "old_gen.go", "schema.go", "web/package-lock.json"}
+	if generated := agent.Result().GeneratedFiles; !slices.Equal(generated, expected) {
+		t.Errorf("Expected %v, got %v", expected, generated)
+	}
+}
+
+func TestSetAsideGenerated_Review(t *testing.T) {
+	diffMap := map[string]types.DiffData{"go.sum": {}}
+
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	agent.SetGeneratedConfig(config.GeneratedConfig{Review: true})
+	agent.setAsideGenerated(diffMap)
+
+	if len(diffMap) != 1 || len(agent.Result().GeneratedFiles) != 0 {
+		t.Errorf("Expected generated files to be reviewed, got %v", agent.Result().GeneratedFiles)
+	}
+}
+
+func TestReportGenerator_NotesGeneratedFiles(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: "line\n"}
+	reportGen := NewReportGenerator(readTool, writeTool, config.ReportConfig{}, severity.Default())
+	reportGen.SetGeneratedFiles([]string{"go.sum", "api/user.pb.go"})
+
+	reportGen.GenerateMarkdownReport([]types.Issue{{Severity: "MINOR", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Typo"}})
+
+	if report := writeTool.written["diffpector_report.md"]; !strings.Contains(report, "_Generated files changed, not reviewed: `go.sum`, `api/user.pb.go`_") {
+		t.Errorf("Expected the generated files to be noted, got:\n%s", report)
+	}
+}
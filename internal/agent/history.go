@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// annotateChangeHistory adds the last commits that touched the changed lines of every file
+// when the git_blame tool is registered. The history is read from HEAD, the base of staged
+// and working tree changes but not of pull requests, which are left without it.
+func (a *CodeReviewAgent) annotateChangeHistory(ctx context.Context, diffMap map[string]types.DiffData) {
+	blameTool, ok := a.toolRegistry.GetAll()[tools.ToolNameGitBlame]
+	if !ok {
+		return
+	}
+
+	for filePath, diffData := range diffMap {
+		result, err := blameTool.Execute(ctx, map[string]any{"file_path": filePath, "diff": diffData.Diff})
+		if err != nil {
+			fmt.Printf("[!] No change history for %s: %v\n", filePath, err)
+			continue
+		}
+		if history, ok := result.(string); ok {
+			diffData.ChangeHistory = history
+			diffMap[filePath] = diffData
+		}
+	}
+}
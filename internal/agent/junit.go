@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// The JUnit XML format read by Jenkins, GitLab and most CI test report views, see
+// https://github.com/testmoapp/junitxml
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit formats the review as a JUnit XML report with a test case per reviewed file. A file
+// fails with its issues at least as severe as threshold, the less severe ones are listed in
+// its output. An empty threshold is the severity gate of the review, like its verdict. Files
+// whose review failed are errors and the ones left by an interruption are skipped.
+func (r ReviewResult) JUnit(threshold string) (string, error) {
+	severities := r.severities
+	if severities == nil {
+		severities = severity.Default()
+	}
+	if threshold == "" {
+		threshold = r.FailOn
+	}
+	minRank := 1
+	if threshold != "" {
+		if minRank = severities.Rank(threshold); minRank == 0 {
+			return "", fmt.Errorf("unknown severity %q, available severities: %s", threshold, strings.Join(severities.Levels(), ", "))
+		}
+	}
+
+	byFile := make(map[string][]types.Issue)
+	for _, issue := range SortIssues(r.Issues, severities) {
+		byFile[issue.FilePath] = append(byFile[issue.FilePath], issue)
+	}
+	files := slices.Clone(r.ChangedFiles)
+	for filePath := range byFile {
+		if !slices.Contains(files, filePath) {
+			files = append(files, filePath)
+		}
+	}
+	slices.Sort(files)
+
+	suite := junitTestSuite{Name: "diffpector"}
+	for _, filePath := range files {
+		testCase := junitTestCase{Name: filePath, ClassName: "diffpector", File: filePath}
+		var failing, passing []string
+		for _, issue := range byFile[filePath] {
+			line := junitIssueLine(issue)
+			if severities.Rank(issue.Severity) >= minRank {
+				failing = append(failing, line)
+			} else {
+				passing = append(passing, line)
+			}
+		}
+
+		switch {
+		case slices.Contains(r.FailedFiles, filePath):
+			testCase.Error = &junitMessage{Message: "review failed"}
+			suite.Errors++
+		case slices.Contains(r.UnreviewedFiles, filePath):
+			testCase.Skipped = &junitMessage{Message: "review interrupted"}
+			suite.Skipped++
+		}
+		if len(failing) > 0 {
+			testCase.Failure = &junitMessage{
+				Message: fmt.Sprintf("%d issue(s) found", len(failing)),
+				Type:    severities.Normalize(byFile[filePath][0].Severity),
+				Text:    strings.Join(failing, "\n"),
+			}
+			suite.Failures++
+		}
+		testCase.SystemOut = strings.Join(passing, "\n")
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	suite.Tests = len(suite.Cases)
+
+	report := junitTestSuites{
+		Name:     "diffpector",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	return xml.Header + string(data) + "\n", nil
+}
+
+// junitIssueLine formats an issue as a SEVERITY file:start-end [check]: description line
+func junitIssueLine(issue types.Issue) string {
+	location := fmt.Sprintf("%s:%d", issue.FilePath, max(issue.StartLine, 1))
+	if issue.EndLine > issue.StartLine {
+		location += fmt.Sprintf("-%d", issue.EndLine)
+	}
+	check := ""
+	if issue.Category != "" {
+		check = fmt.Sprintf(" [%s]", issue.Category)
+	}
+	return fmt.Sprintf("%s %s%s: %s", issue.Severity, location, check, strings.Join(strings.Fields(issue.Description), " "))
+}
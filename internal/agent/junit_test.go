@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestReviewResult_JUnit(t *testing.T) {
+	result := ReviewResult{
+		ChangedFiles:    []string{"main.go", "util.go", "store.go", "api.go"},
+		FailedFiles:     []string{"store.go"},
+		UnreviewedFiles: []string{"api.go"},
+		Issues: []types.Issue{
+			{Severity: "MINOR", FilePath: "main.go", StartLine: 3, EndLine: 3, Description: "Typo in log message"},
+			{Severity: "CRITICAL", FilePath: "main.go", StartLine: 10, EndLine: 12, Category: "secrets", Description: "Hardcoded\n  token"},
+			{Severity: "MINOR", FilePath: "util.go", StartLine: 1, EndLine: 1, Description: "Unclear name <x>"},
+		},
+	}
+
+	output, err := result.JUnit("WARNING")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(output, xml.Header) {
+		t.Errorf("Expected an XML declaration, got:\n%s", output)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Failed to parse the report: %v\n%s", err, output)
+	}
+	if report.Tests != 4 || report.Failures != 1 || report.Errors != 1 || report.Skipped != 1 {
+		t.Errorf("Unexpected totals: %d tests, %d failures, %d errors, %d skipped", report.Tests, report.Failures, report.Errors, report.Skipped)
+	}
+
+	cases := make(map[string]junitTestCase)
+	for _, testCase := range report.Suites[0].Cases {
+		cases[testCase.Name] = testCase
+	}
+	mainCase := cases["main.go"]
+	if mainCase.Failure == nil || mainCase.Failure.Type != "CRITICAL" || mainCase.Failure.Text != "CRITICAL main.go:10-12 [secrets]: Hardcoded token" {
+		t.Errorf("Expected main.go to fail with the critical issue only, got %+v", mainCase.Failure)
+	}
+	if mainCase.SystemOut != "MINOR main.go:3: Typo in log message" {
+		t.Errorf("Expected the minor issue in the output, got %q", mainCase.SystemOut)
+	}
+	if util := cases["util.go"]; util.Failure != nil || util.SystemOut != "MINOR util.go:1: Unclear name <x>" {
+		t.Errorf("Expected util.go to pass with its issue listed, got %+v", util)
+	}
+	if cases["store.go"].Error == nil || cases["api.go"].Skipped == nil {
+		t.Errorf("Expected the failed review to be an error and the interrupted one skipped, got %+v", report.Suites[0].Cases)
+	}
+
+	output, err = result.JUnit("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(output, `<testsuites name="diffpector" tests="4" failures="2"`) {
+		t.Errorf("Expected every file with an issue to fail without threshold, got:\n%s", output)
+	}
+
+	if _, err := result.JUnit("BLOCKER"); err == nil {
+		t.Error("Expected an unknown threshold to be rejected")
+	}
+}
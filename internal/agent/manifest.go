@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// reviewManifest remembers the diff of every file of the last staged review together with
+// its findings, so files whose diff did not change are not sent to the model again
+type reviewManifest struct {
+	// Fingerprint identifies the model and configuration the findings come from
+	Fingerprint string                   `json:"fingerprint"`
+	Files       map[string]manifestEntry `json:"files"`
+}
+
+type manifestEntry struct {
+	DiffHash  string           `json:"diff_hash"`
+	Issues    []types.Issue    `json:"issues,omitempty"`
+	Questions []types.Question `json:"questions,omitempty"`
+}
+
+// DefaultManifestPath returns where the manifest of the repository at repoRoot is kept,
+// in the user cache directory so it never shows up in the working tree
+func DefaultManifestPath(repoRoot string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user cache directory: %w", err)
+	}
+	absRoot, err := filepath.Abs(repoRoot)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve repository root %s: %w", repoRoot, err)
+	}
+	return filepath.Join(cacheDir, "diffpector", "manifests", hashString(absRoot)[:16]+".json"), nil
+}
+
+// SetReviewManifest reuses the findings of the last review for files whose diff did not
+// change, reading and updating the manifest at path. An empty path reviews every file.
+func (a *CodeReviewAgent) SetReviewManifest(path string) {
+	a.manifestPath = path
+}
+
+// loadManifest returns the manifest of the last review, empty when there is none or it was
+// produced with another model or configuration. It returns nil when no manifest is set.
+func (a *CodeReviewAgent) loadManifest() *reviewManifest {
+	if a.manifestPath == "" {
+		return nil
+	}
+
+	empty := &reviewManifest{Fingerprint: a.reviewFingerprint(), Files: make(map[string]manifestEntry)}
+	data, err := os.ReadFile(a.manifestPath)
+	if err != nil {
+		return empty
+	}
+
+	var manifest reviewManifest
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.Fingerprint != empty.Fingerprint || manifest.Files == nil {
+		return empty
+	}
+	return &manifest
+}
+
+// saveManifest replaces the stored manifest. Failing to save only costs a full review next
+// time, so errors are reported without failing the review.
+func (a *CodeReviewAgent) saveManifest(manifest *reviewManifest) {
+	if manifest == nil {
+		return
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(a.manifestPath), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(a.manifestPath, data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("[!] Failed to save the review manifest: %v\n", err)
+	}
+}
+
+// reviewFingerprint changes whenever the model or the configuration shaping the findings do
+func (a *CodeReviewAgent) reviewFingerprint() string {
+	data, _ := json.Marshal(map[string]any{
+		"model":             a.llmProvider.GetModel(),
+		"prompt":            a.promptVariant,
+		"prompts":           a.promptConfig,
+		"checks":            a.checksConfig,
+		"context":           a.contextStrategy(),
+		"structured_output": a.structuredOutput,
+		"verification":      a.verification,
+		"tools":             append(slices.Clone(a.reviewTools), a.explorationNames()...),
+		"ensemble":          []any{a.ensembleNames(), a.ensemblePolicy, a.minAgreement},
+		"chunk_lines":       a.chunkLines,
+		"triage":            a.triageModel(),
+		"summary":           a.summarize,
+	})
+	return hashString(string(data))
+}
+
+func (a *CodeReviewAgent) contextStrategy() string {
+	if a.contextBuilder == nil {
+		return ContextStrategyAffectedSymbols
+	}
+	return a.contextBuilder.Name()
+}
+
+// unchanged returns the entry of the file when its diff is the one of the last review
+func (m *reviewManifest) unchanged(filePath, diff string) (manifestEntry, bool) {
+	if m == nil {
+		return manifestEntry{}, false
+	}
+	entry, ok := m.Files[filePath]
+	return entry, ok && entry.DiffHash == hashString(diff)
+}
+
+// fresh returns an empty manifest with the same fingerprint, to be filled by this review
+func (m *reviewManifest) fresh() *reviewManifest {
+	if m == nil {
+		return nil
+	}
+	return &reviewManifest{Fingerprint: m.Fingerprint, Files: make(map[string]manifestEntry)}
+}
+
+func (m *reviewManifest) record(filePath, diff string, issues []types.Issue, questions []types.Question) {
+	if m == nil {
+		return
+	}
+	m.Files[filePath] = manifestEntry{DiffHash: hashString(diff), Issues: issues, Questions: questions}
+}
+
+func hashString(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
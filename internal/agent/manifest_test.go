@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/llm"
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// unreachableProvider fails the test when the review reaches the model
+type unreachableProvider struct {
+	t *testing.T
+}
+
+func (p unreachableProvider) GetModel() string { return "test-model" }
+
+func (p unreachableProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	p.t.Error("Unexpected call to the model")
+	return "", fmt.Errorf("unexpected call")
+}
+
+func (p unreachableProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	return p.ChatWithSchema(ctx, messages, tools, nil)
+}
+
+func (p unreachableProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, tools []llm.Tool, schema *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	p.t.Error("Unexpected call to the model")
+	return nil, fmt.Errorf("unexpected call")
+}
+
+func (p unreachableProvider) HealthCheck() error { return nil }
+
+func TestCollectIssuesReusesUnchangedFiles(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	agent.SetReviewManifest(manifestPath)
+
+	diff := "@@ -1,1 +1,1 @@\n-old\n+new\n"
+	previous := agent.loadManifest()
+	previous.record("main.go", diff, []types.Issue{
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Unchecked error"},
+	}, []types.Question{{FilePath: "main.go", Line: 1, Question: "Is the retry meant to be unbounded?"}})
+	agent.saveManifest(previous)
+
+	issues := agent.collectIssues(context.Background(), map[string]types.DiffData{"main.go": {Diff: diff}}, "go")
+
+	if len(issues) != 1 || !issues[0].Unchanged || issues[0].Description != "Unchecked error" {
+		t.Fatalf("Expected the previous issue marked as unchanged, got %+v", issues)
+	}
+	if len(agent.result.Questions) != 1 || agent.result.UnchangedFiles != 1 {
+		t.Errorf("Expected the previous question and one unchanged file, got %+v", agent.result)
+	}
+
+	// The manifest carries the reused entry over to the next review
+	if _, ok := agent.loadManifest().unchanged("main.go", diff); !ok {
+		t.Error("Expected the reused entry to be kept in the manifest")
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+
+	if agent.loadManifest() != nil {
+		t.Fatal("Expected no manifest when none is set")
+	}
+
+	agent.SetReviewManifest(manifestPath)
+	manifest := agent.loadManifest()
+	manifest.record("main.go", "+new", nil, nil)
+	agent.saveManifest(manifest)
+
+	if _, ok := agent.loadManifest().unchanged("main.go", "+new"); !ok {
+		t.Error("Expected the saved diff to be unchanged")
+	}
+	if _, ok := agent.loadManifest().unchanged("main.go", "+newer"); ok {
+		t.Error("Expected a different diff to be reviewed again")
+	}
+
+	agent.promptVariant = "security"
+	if _, ok := agent.loadManifest().unchanged("main.go", "+new"); ok {
+		t.Error("Expected a configuration change to discard the manifest")
+	}
+}
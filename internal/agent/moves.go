@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// annotateSymbolMoves tells the review of every file which of its functions the diff
+// renamed or moved, so the model does not take them for removed code. The versions before
+// the diff are rebuilt from the diffs, which works for staged changes and pull requests alike.
+func (a *CodeReviewAgent) annotateSymbolMoves(diffMap map[string]types.DiffData) {
+	var files []tools.FileVersions
+	for filePath, diffData := range diffMap {
+		if a.parserRegistry.GetParser(filePath) == nil {
+			continue
+		}
+
+		newContent, err := os.ReadFile(diffData.AbsolutePath)
+		if err != nil && !os.IsNotExist(err) {
+			continue
+		}
+		oldContent, err := tools.ReconstructOriginal(newContent, diffData.Diff)
+		if err != nil {
+			continue
+		}
+		files = append(files, tools.FileVersions{Path: filePath, Old: oldContent, New: newContent})
+	}
+
+	moves := tools.DetectSymbolMoves(a.parserRegistry, files)
+	if len(moves) > 0 {
+		fmt.Printf("[i] %d function(s) renamed or moved\n", len(moves))
+	}
+
+	for _, move := range moves {
+		paths := []string{move.OldFile}
+		if move.NewFile != move.OldFile {
+			paths = append(paths, move.NewFile)
+		}
+		for _, path := range paths {
+			diffData := diffMap[path]
+			if !slices.Contains(diffData.SymbolMoves, move.Describe()) {
+				diffData.SymbolMoves = append(diffData.SymbolMoves, move.Describe())
+			}
+			diffMap[path] = diffData
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/utils"
+)
+
+// WritePatches writes the suggested fix of every issue into dir as a .patch file git apply
+// accepts, numbered in the order of the issues and named after their location. Fixes that
+// are not a usable diff are skipped. It returns the number of patches written.
+func WritePatches(dir string, issues []types.Issue) (int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create patch directory %s: %w", dir, err)
+	}
+
+	written := 0
+	for i, issue := range issues {
+		if issue.SuggestedFix == "" {
+			continue
+		}
+
+		patch, err := utils.NormalizePatch(issue.FilePath, issue.SuggestedFix, issue.StartLine)
+		if err != nil {
+			fmt.Printf("[!] Skipping the fix for %s:%d: %v\n", issue.FilePath, issue.StartLine, err)
+			continue
+		}
+
+		name := fmt.Sprintf("%03d-%s-L%d.patch", i+1, patchFileStem(issue.FilePath), issue.StartLine)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(patch), 0644); err != nil {
+			return written, fmt.Errorf("failed to write patch %s: %w", name, err)
+		}
+		written++
+	}
+
+	return written, nil
+}
+
+// patchFileStem flattens a file path into a file name, e.g. internal/auth.go becomes
+// internal-auth.go
+func patchFileStem(filePath string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return '-'
+	}, platform.NormalizePath(filePath))
+}
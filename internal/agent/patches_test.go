@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestWritePatches(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "patches")
+	issues := []types.Issue{
+		{FilePath: "internal/auth/handler.go", StartLine: 42, SuggestedFix: "@@ -42,1 +42,1 @@\n-return nil\n+return err"},
+		{FilePath: "main.go", StartLine: 3},
+		{FilePath: "main.go", StartLine: 7, SuggestedFix: "Check the error"},
+	}
+
+	written, err := WritePatches(dir, issues)
+	if err != nil {
+		t.Fatalf("WritePatches failed: %v", err)
+	}
+	if written != 1 {
+		t.Fatalf("Expected 1 patch, got %d", written)
+	}
+
+	patch, err := os.ReadFile(filepath.Join(dir, "001-internal-auth-handler.go-L42.patch"))
+	if err != nil {
+		t.Fatalf("Expected the patch of the first issue: %v", err)
+	}
+	if !strings.HasPrefix(string(patch), "--- a/internal/auth/handler.go\n+++ b/internal/auth/handler.go\n@@ -42,1 +42,1 @@\n") {
+		t.Errorf("Unexpected patch:\n%s", patch)
+	}
+}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"github.com/agusespa/diffpector/internal/privacy"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// SetAnonymizer anonymizes the code of the prompts with the anonymizer and restores the
+// findings of the model, see payload and restoreIssues
+func (a *CodeReviewAgent) SetAnonymizer(anonymizer *privacy.Anonymizer) {
+	a.anonymizer = anonymizer
+}
+
+// payload builds the prompt input of the diffs, anonymized when an anonymizer is set. The
+// file paths are kept so the findings can be located.
+func (a *CodeReviewAgent) payload(diffMap map[string]types.DiffData) string {
+	if a.anonymizer == nil {
+		return buildPayload(diffMap)
+	}
+
+	anonymized := make(map[string]types.DiffData, len(diffMap))
+	for filePath, data := range diffMap {
+		data.Diff = a.anonymizer.AnonymizeDiff(filePath, data.Diff)
+		data.DiffContext = a.anonymizer.AnonymizeCode(filePath, data.DiffContext)
+		// Commit messages are prose, only the names they share with the code are masked
+		data.ChangeHistory = a.anonymizer.Mask(data.ChangeHistory)
+
+		symbols := make([]types.SymbolUsage, len(data.AffectedSymbols))
+		for i, usage := range data.AffectedSymbols {
+			usage.Snippets = a.anonymizer.AnonymizeCode(filePath, usage.Snippets)
+			symbols[i] = usage
+		}
+		data.AffectedSymbols = symbols
+
+		similar := make([]types.SimilarCode, len(data.SimilarCode))
+		for i, code := range data.SimilarCode {
+			code.Snippet = a.anonymizer.AnonymizeCode(code.FilePath, code.Snippet)
+			similar[i] = code
+		}
+		data.SimilarCode = similar
+
+		callers := make([]types.CallSite, len(data.StaleCallers))
+		for i, caller := range data.StaleCallers {
+			caller.Code = a.anonymizer.AnonymizeCode(caller.FilePath, caller.Code)
+			callers[i] = caller
+		}
+		data.StaleCallers = callers
+
+		moves := make([]string, len(data.SymbolMoves))
+		for i, move := range data.SymbolMoves {
+			moves[i] = a.anonymizer.Mask(move)
+		}
+		data.SymbolMoves = moves
+
+		anonymized[filePath] = data
+	}
+	return buildPayload(anonymized)
+}
+
+// restore replaces the placeholders of a model answer with the original code
+func (a *CodeReviewAgent) restore(text string) string {
+	if a.anonymizer == nil {
+		return text
+	}
+	return a.anonymizer.Restore(text)
+}
+
+func (a *CodeReviewAgent) restoreIssues(issues []types.Issue) {
+	for i := range issues {
+		issues[i].Description = a.restore(issues[i].Description)
+		issues[i].CodeSnippet = a.restore(issues[i].CodeSnippet)
+		issues[i].SuggestedFix = a.restore(issues[i].SuggestedFix)
+	}
+}
+
+// restoreArguments restores the string arguments of a tool call of the model, e.g. the
+// names it looks up
+func (a *CodeReviewAgent) restoreArguments(arguments map[string]any) map[string]any {
+	if a.anonymizer == nil {
+		return arguments
+	}
+	restored := make(map[string]any, len(arguments))
+	for name, value := range arguments {
+		if text, ok := value.(string); ok {
+			value = a.anonymizer.Restore(text)
+		}
+		restored[name] = value
+	}
+	return restored
+}
+
+// anonymizeToolResult anonymizes what a tool hands back to the model, as code of the file the
+// tool was called on
+func (a *CodeReviewAgent) anonymizeToolResult(arguments map[string]any, content string) string {
+	if a.anonymizer == nil {
+		return content
+	}
+	filePath, _ := arguments["file_path"].(string)
+	return a.anonymizer.AnonymizeCode(filePath, content)
+}
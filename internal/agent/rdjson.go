@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/utils"
+)
+
+// The reviewdog diagnostic format, see
+// https://github.com/reviewdog/reviewdog/tree/master/proto/rdf
+type rdjsonResult struct {
+	Source      rdjsonSource       `json:"source"`
+	Diagnostics []rdjsonDiagnostic `json:"diagnostics"`
+}
+
+type rdjsonSource struct {
+	Name string `json:"name"`
+}
+
+type rdjsonDiagnostic struct {
+	Message  string         `json:"message"`
+	Location rdjsonLocation `json:"location"`
+	// Severity is ERROR, WARNING or INFO
+	Severity    string             `json:"severity"`
+	Source      *rdjsonSource      `json:"source,omitempty"`
+	Code        *rdjsonCode        `json:"code,omitempty"`
+	Suggestions []rdjsonSuggestion `json:"suggestions,omitempty"`
+}
+
+type rdjsonLocation struct {
+	Path  string      `json:"path"`
+	Range rdjsonRange `json:"range"`
+}
+
+type rdjsonRange struct {
+	Start rdjsonPosition  `json:"start"`
+	End   *rdjsonPosition `json:"end,omitempty"`
+}
+
+// rdjsonPosition has no column for whole lines
+type rdjsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column,omitempty"`
+}
+
+type rdjsonCode struct {
+	Value string `json:"value"`
+}
+
+// rdjsonSuggestion replaces the lines of its range with text
+type rdjsonSuggestion struct {
+	Range rdjsonRange `json:"range"`
+	Text  string      `json:"text"`
+}
+
+var normalizedHunkPattern = regexp.MustCompile(`^@@ -(\d+),(\d+) `)
+
+// RDJSON formats the issues as a reviewdog diagnostic result, for reviewdog -f=rdjson
+func (r ReviewResult) RDJSON() (string, error) {
+	result := rdjsonResult{Source: rdjsonSource{Name: "diffpector"}, Diagnostics: r.rdjsonDiagnostics(nil)}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode diagnostics: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// RDJSONL formats the issues as one reviewdog diagnostic per line, for reviewdog -f=rdjsonl
+func (r ReviewResult) RDJSONL() (string, error) {
+	var builder strings.Builder
+	for _, diagnostic := range r.rdjsonDiagnostics(&rdjsonSource{Name: "diffpector"}) {
+		data, err := json.Marshal(diagnostic)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode diagnostic: %w", err)
+		}
+		builder.Write(data)
+		builder.WriteString("\n")
+	}
+	return builder.String(), nil
+}
+
+// rdjsonDiagnostics converts the issues, source is set on each diagnostic when the lines
+// are read without a result carrying it
+func (r ReviewResult) rdjsonDiagnostics(source *rdjsonSource) []rdjsonDiagnostic {
+	severities := r.severities
+	if severities == nil {
+		severities = severity.Default()
+	}
+
+	diagnostics := []rdjsonDiagnostic{}
+	for _, issue := range r.Issues {
+		diagnostics = append(diagnostics, rdjsonIssue(issue, severities, source))
+	}
+	return diagnostics
+}
+
+func rdjsonIssue(issue types.Issue, severities *severity.Registry, source *rdjsonSource) rdjsonDiagnostic {
+	startLine := max(issue.StartLine, 1)
+	location := rdjsonLocation{Path: issue.FilePath, Range: rdjsonRange{Start: rdjsonPosition{Line: startLine, Column: 1}}}
+	if issue.EndLine > startLine {
+		location.Range.End = &rdjsonPosition{Line: issue.EndLine}
+	}
+
+	diagnostic := rdjsonDiagnostic{
+		Message:     issue.Description,
+		Location:    location,
+		Severity:    strings.ToUpper(problemLevel(severities, issue.Severity)),
+		Source:      source,
+		Suggestions: rdjsonSuggestions(issue),
+	}
+	if issue.Category != "" {
+		diagnostic.Code = &rdjsonCode{Value: issue.Category}
+	}
+	return diagnostic
+}
+
+// rdjsonSuggestions turns the suggested fix into line replacements, one per hunk. Fixes
+// that only insert lines have no line to anchor a replacement to and are left out.
+func rdjsonSuggestions(issue types.Issue) []rdjsonSuggestion {
+	if issue.SuggestedFix == "" {
+		return nil
+	}
+	patch, err := utils.NormalizePatch(issue.FilePath, issue.SuggestedFix, issue.StartLine)
+	if err != nil {
+		return nil
+	}
+
+	var suggestions []rdjsonSuggestion
+	var current *rdjsonSuggestion
+	var text []string
+	flush := func() {
+		if current != nil {
+			current.Text = strings.Join(text, "\n")
+			suggestions = append(suggestions, *current)
+		}
+		current, text = nil, nil
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(patch, "\n"), "\n") {
+		if match := normalizedHunkPattern.FindStringSubmatch(line); match != nil {
+			flush()
+			oldStart, _ := strconv.Atoi(match[1])
+			oldCount, _ := strconv.Atoi(match[2])
+			if oldCount == 0 {
+				return nil
+			}
+			current = &rdjsonSuggestion{Range: rdjsonRange{
+				Start: rdjsonPosition{Line: oldStart},
+				End:   &rdjsonPosition{Line: oldStart + oldCount - 1},
+			}}
+			continue
+		}
+		if current == nil || line == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '+' {
+			text = append(text, line[1:])
+		}
+	}
+	flush()
+	return suggestions
+}
@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestReviewResult_RDJSON(t *testing.T) {
+	result := ReviewResult{Issues: []types.Issue{
+		{
+			Severity:     "CRITICAL",
+			FilePath:     "api/handler.go",
+			StartLine:    12,
+			EndLine:      14,
+			Description:  "SQL built from user input",
+			SuggestedFix: "@@ -12,2 +12,2 @@\n-\tquery := \"SELECT \" + id\n+\tquery := \"SELECT ?\"\n \trows, err := db.Query(query)\n",
+		},
+		{Severity: "MINOR", FilePath: "api/handler_test.go", Description: "No test update", Category: "missing-tests"},
+	}}
+
+	output, err := result.RDJSON()
+	if err != nil {
+		t.Fatalf("RDJSON failed: %v", err)
+	}
+
+	var decoded rdjsonResult
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("Invalid RDJSON %s: %v", output, err)
+	}
+	if decoded.Source.Name != "diffpector" || len(decoded.Diagnostics) != 2 {
+		t.Fatalf("Expected two diffpector diagnostics, got %s", output)
+	}
+
+	first := decoded.Diagnostics[0]
+	if first.Severity != "ERROR" || first.Location.Path != "api/handler.go" || first.Location.Range.Start.Line != 12 || first.Location.Range.End.Line != 14 {
+		t.Errorf("Unexpected diagnostic %+v", first)
+	}
+	if len(first.Suggestions) != 1 {
+		t.Fatalf("Expected a suggestion for the fix, got %+v", first.Suggestions)
+	}
+	suggestion := first.Suggestions[0]
+	if suggestion.Range.Start.Line != 12 || suggestion.Range.End.Line != 13 || suggestion.Text != "\tquery := \"SELECT ?\"\n\trows, err := db.Query(query)" {
+		t.Errorf("Unexpected suggestion %+v", suggestion)
+	}
+
+	second := decoded.Diagnostics[1]
+	if second.Severity != "INFO" || second.Location.Range.Start.Line != 1 || second.Location.Range.End != nil || second.Code == nil || second.Code.Value != "missing-tests" {
+		t.Errorf("Unexpected diagnostic %+v", second)
+	}
+	if first.Source != nil {
+		t.Errorf("Expected the source only on the result, got %+v", first.Source)
+	}
+
+	empty, err := (ReviewResult{}).RDJSON()
+	if err != nil || !strings.Contains(empty, `"diagnostics": []`) {
+		t.Errorf("Expected an empty diagnostics list, got %s, %v", empty, err)
+	}
+}
+
+func TestReviewResult_RDJSONL(t *testing.T) {
+	result := ReviewResult{Issues: []types.Issue{
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 3, Description: "Error ignored"},
+		{Severity: "CRITICAL", FilePath: "main.go", StartLine: 9, Description: "Nil dereference"},
+	}}
+
+	output, err := result.RDJSONL()
+	if err != nil {
+		t.Fatalf("RDJSONL failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a line per issue, got %q", output)
+	}
+	for i, severity := range []string{"WARNING", "ERROR"} {
+		var diagnostic rdjsonDiagnostic
+		if err := json.Unmarshal([]byte(lines[i]), &diagnostic); err != nil {
+			t.Fatalf("Invalid line %q: %v", lines[i], err)
+		}
+		if diagnostic.Severity != severity || diagnostic.Source == nil || diagnostic.Source.Name != "diffpector" {
+			t.Errorf("Unexpected diagnostic %+v", diagnostic)
+		}
+	}
+
+	if empty, err := (ReviewResult{}).RDJSONL(); err != nil || empty != "" {
+		t.Errorf("Expected no lines without issues, got %q, %v", empty, err)
+	}
+}
+
+func TestRDJSONSuggestions_InsertionOnly(t *testing.T) {
+	issue := types.Issue{FilePath: "main.go", StartLine: 4, SuggestedFix: "+\tdefer f.Close()\n"}
+	if suggestions := rdjsonSuggestions(issue); suggestions != nil {
+		t.Errorf("Expected no suggestion for a pure insertion, got %+v", suggestions)
+	}
+}
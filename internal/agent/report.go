@@ -1,106 +1,477 @@
 package agent
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
+	"text/template"
 
+	"github.com/agusespa/diffpector/internal/owners"
+	"github.com/agusespa/diffpector/internal/severity"
 	"github.com/agusespa/diffpector/internal/tools"
 	"github.com/agusespa/diffpector/internal/types"
 	"github.com/agusespa/diffpector/internal/utils"
+	"github.com/agusespa/diffpector/pkg/config"
 )
 
 type ReportGenerator struct {
-	readTool  tools.Tool
-	writeTool tools.Tool
+	readTool   tools.Tool
+	sinks      []ReportSink
+	config     config.ReportConfig
+	severities *severity.Registry
+	questions  []types.Question
+	// hidden counts the findings left out for their low confidence
+	hidden int
+	// rejected counts the findings rejected by the verifier, handled as rejectedAction says
+	rejected       int
+	rejectedAction string
+	// owners groups the issues of the single repository report by owner when set
+	owners *owners.Rules
+	// generated lists the generated files that changed without being reviewed
+	generated []string
+	// unreviewed lists the files left when the review was interrupted
+	unreviewed []string
+	// chunked counts the chunks of the file diffs reviewed in chunks
+	chunked map[string]int
+	// triaged lists the files the triage model found trivial
+	triaged []string
+	// summary of the changeset, shown above the issues
+	summary string
+	// template replaces the default layout of the single repository report when set, with
+	// metadata describing the review
+	template *template.Template
+	metadata ReportMetadata
 }
 
-func NewReportGenerator(readTool, writeTool tools.Tool) *ReportGenerator {
+func NewReportGenerator(readTool, writeTool tools.Tool, reportConfig config.ReportConfig, severities *severity.Registry) *ReportGenerator {
 	return &ReportGenerator{
-		readTool:  readTool,
-		writeTool: writeTool,
+		readTool:   readTool,
+		sinks:      newReportSinks(reportConfig, writeTool, severities),
+		config:     reportConfig,
+		severities: severities,
 	}
 }
 
-func (r *ReportGenerator) GenerateMarkdownReport(issues []types.Issue) {
+// SetQuestions adds the questions for the author to the single repository report
+func (r *ReportGenerator) SetQuestions(questions []types.Question) {
+	r.questions = questions
+}
+
+// SetHiddenIssues notes in the report how many findings were hidden by min_confidence
+func (r *ReportGenerator) SetHiddenIssues(count int) {
+	r.hidden = count
+}
+
+// SetRejectedIssues notes in the report how many findings the verifier rejected and whether
+// they were dropped or downgraded
+func (r *ReportGenerator) SetRejectedIssues(count int, action string) {
+	r.rejected = count
+	r.rejectedAction = action
+}
+
+// SetOwners groups the issues of the single repository report by the owners of their files
+func (r *ReportGenerator) SetOwners(rules *owners.Rules) {
+	r.owners = rules
+}
+
+// SetGeneratedFiles notes in the report which generated files changed without a review
+func (r *ReportGenerator) SetGeneratedFiles(files []string) {
+	r.generated = files
+}
+
+// SetUnreviewedFiles marks the report as partial, listing the files the interrupted review
+// did not get to
+func (r *ReportGenerator) SetUnreviewedFiles(files []string) {
+	r.unreviewed = files
+}
+
+// SetChunkedFiles notes in the report which file diffs were too large to review at once and
+// in how many chunks they were reviewed
+func (r *ReportGenerator) SetChunkedFiles(files map[string]int) {
+	r.chunked = files
+}
+
+// SetTriagedFiles notes in the report which files the triage model found trivial, only their
+// deterministic checks ran
+func (r *ReportGenerator) SetTriagedFiles(files []string) {
+	r.triaged = files
+}
+
+// SetSummary adds the summary of the changeset written before the review to the report
+func (r *ReportGenerator) SetSummary(summary string) {
+	r.summary = summary
+}
+
+// severityCounts counts the reported issues per severity level
+type severityCounts map[string]int
+
+// RepositoryIssues holds the issues found in one repository of a multi-repo review
+type RepositoryIssues struct {
+	Repository string
+	Issues     []types.Issue
+	Questions  []types.Question
+	// Error is why the review of the repository failed, empty when it succeeded
+	Error string
+}
+
+// GenerateMarkdownReport writes the report and returns its path, empty if it could not be written
+func (r *ReportGenerator) GenerateMarkdownReport(issues []types.Issue) string {
+	if r.template != nil {
+		if reportPath, ok := r.generateTemplatedReport(issues); ok {
+			return reportPath
+		}
+	}
+
 	var reportBuilder strings.Builder
 	reportBuilder.WriteString("# Code Review Report\n\n")
+	if r.summary != "" {
+		reportBuilder.WriteString(fmt.Sprintf("## Summary of Changes\n\n%s\n\n---\n\n", r.summary))
+	}
+
+	counts := make(severityCounts)
+	if r.owners != nil {
+		r.writeOwnerSections(&reportBuilder, issues, counts)
+	} else {
+		r.writeIssues(&reportBuilder, issues, counts)
+	}
+	for _, note := range r.notes() {
+		reportBuilder.WriteString(fmt.Sprintf("_%s_\n\n", note))
+	}
+	writeQuestions(&reportBuilder, r.questions)
 
-	criticalCount := 0
-	warningCount := 0
-	minorCount := 0
+	return r.saveReport(&reportBuilder, counts, issues, r.questions)
+}
 
-	for _, issue := range issues {
-		result, err := r.readTool.Execute(map[string]any{"filename": issue.FilePath})
-		content, ok := result.(string)
-		if !ok || err != nil {
-			reportBuilder.WriteString(fmt.Sprintf("## ⚪️ Could not retrieve code for issue: %s\n", issue.Description))
-			reportBuilder.WriteString(fmt.Sprintf("**File:** `%s`\n", issue.FilePath))
-			reportBuilder.WriteString(fmt.Sprintf("**Error:** %v\n\n---\n\n", err))
-			continue
+// notes returns the notes written below the issues of the single repository report
+func (r *ReportGenerator) notes() []string {
+	var notes []string
+	if r.hidden > 0 {
+		notes = append(notes, fmt.Sprintf("%d finding(s) with a confidence below %.2f hidden", r.hidden, r.config.MinConfidence))
+	}
+	if r.rejected > 0 {
+		verb := "dropped"
+		if r.rejectedAction == VerifyActionDowngrade {
+			verb = "downgraded"
+		}
+		notes = append(notes, fmt.Sprintf("%d finding(s) %s after the verifier rejected them", r.rejected, verb))
+	}
+	if len(r.unreviewed) > 0 {
+		notes = append(notes, fmt.Sprintf("Review interrupted, not reviewed: `%s`", strings.Join(r.unreviewed, "`, `")))
+	}
+	if len(r.chunked) > 0 {
+		var files []string
+		for _, file := range slices.Sorted(maps.Keys(r.chunked)) {
+			files = append(files, fmt.Sprintf("`%s` (%d)", file, r.chunked[file]))
+		}
+		notes = append(notes, fmt.Sprintf("Oversized diffs reviewed in chunks: %s", strings.Join(files, ", ")))
+	}
+	if len(r.triaged) > 0 {
+		notes = append(notes, fmt.Sprintf("Triaged as trivial, not reviewed in depth: `%s`", strings.Join(r.triaged, "`, `")))
+	}
+	if len(r.generated) > 0 {
+		notes = append(notes, fmt.Sprintf("Generated files changed, not reviewed: `%s`", strings.Join(r.generated, "`, `")))
+	}
+	return notes
+}
+
+// GenerateGroupedMarkdownReport writes a single report with one section per repository
+func (r *ReportGenerator) GenerateGroupedMarkdownReport(groups []RepositoryIssues) string {
+	var reportBuilder strings.Builder
+	reportBuilder.WriteString("# Code Review Report\n\n")
+
+	counts := make(severityCounts)
+	var issues []types.Issue
+	var questions []types.Question
+	for _, group := range groups {
+		reportBuilder.WriteString(fmt.Sprintf("# Repository: `%s`\n\n", group.Repository))
+		if group.Error != "" {
+			reportBuilder.WriteString(fmt.Sprintf("**Review failed:** %s\n\n", group.Error))
+		} else if len(group.Issues) == 0 {
+			reportBuilder.WriteString("No issues found\n\n")
+		} else {
+			r.writeIssues(&reportBuilder, group.Issues, counts)
 		}
+		writeQuestions(&reportBuilder, group.Questions)
+		issues = append(issues, group.Issues...)
+		questions = append(questions, group.Questions...)
+	}
 
-		lines := strings.Split(content, "\n")
-		if issue.StartLine > len(lines) || issue.EndLine > len(lines) || issue.StartLine > issue.EndLine || issue.StartLine <= 0 {
-			reportBuilder.WriteString(fmt.Sprintf("## ⚪️ Invalid line numbers for issue: %s\n", issue.Description))
-			reportBuilder.WriteString(fmt.Sprintf("**File:** `%s`\n", issue.FilePath))
-			reportBuilder.WriteString(fmt.Sprintf("**Line Range:** %d-%d\n\n---\n\n", issue.StartLine, issue.EndLine))
-			continue
+	return r.saveReport(&reportBuilder, counts, issues, questions)
+}
+
+func (r *ReportGenerator) writeIssues(reportBuilder *strings.Builder, issues []types.Issue, counts severityCounts) {
+	prominent, collapsed := r.partitionIssues(SortIssues(issues, r.severities))
+
+	for _, issue := range prominent {
+		r.writeIssue(reportBuilder, issue, counts)
+	}
+
+	if len(collapsed) > 0 {
+		reportBuilder.WriteString(fmt.Sprintf("<details>\n<summary>Possibly noteworthy (%d)</summary>\n\n", len(collapsed)))
+		for _, issue := range collapsed {
+			r.writeIssue(reportBuilder, issue, counts)
+		}
+		reportBuilder.WriteString("</details>\n")
+	}
+}
+
+// writeOwnerSections writes a section per set of owners, the owners of the most severe
+// issues first and the issues of unowned files last
+func (r *ReportGenerator) writeOwnerSections(reportBuilder *strings.Builder, issues []types.Issue, counts severityCounts) {
+	var keys []string
+	groups := make(map[string][]types.Issue)
+	for _, issue := range SortIssues(issues, r.severities) {
+		key := strings.Join(r.owners.Owners(issue.FilePath), " ")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
 		}
+		groups[key] = append(groups[key], issue)
+	}
+	if index := slices.Index(keys, ""); index >= 0 {
+		keys = append(slices.Delete(keys, index, index+1), "")
+	}
 
-		severityIcon := r.getSeverityIcon(issue.Severity)
-		switch issue.Severity {
-		case "CRITICAL":
-			criticalCount++
-		case "WARNING":
-			warningCount++
-		case "MINOR":
-			minorCount++
+	for _, key := range keys {
+		if key == "" {
+			reportBuilder.WriteString("# Unowned\n\n")
+		} else {
+			reportBuilder.WriteString(fmt.Sprintf("# Owners: %s\n\n", key))
 		}
+		r.writeIssues(reportBuilder, groups[key], counts)
+	}
+}
 
-		reportBuilder.WriteString(fmt.Sprintf("## %s %s: %s\n", severityIcon, issue.Severity, issue.Description))
+// writeQuestions lists the questions for the author, they are not counted as issues
+func writeQuestions(reportBuilder *strings.Builder, questions []types.Question) {
+	if len(questions) == 0 {
+		return
+	}
+
+	reportBuilder.WriteString("## ❓ Questions for the author\n\n")
+	for _, question := range questions {
+		reportBuilder.WriteString(fmt.Sprintf("- `%s:%d` %s\n", question.FilePath, question.Line, question.Question))
+	}
+	reportBuilder.WriteString("\n")
+}
+
+// saveReport completes the report with its summary and hands it to every sink. It returns
+// the path of the written report file, empty if none was written.
+func (r *ReportGenerator) saveReport(reportBuilder *strings.Builder, counts severityCounts, issues []types.Issue, questions []types.Question) string {
+	reportBuilder.WriteString(fmt.Sprintf("\n\n**Summary:** %s\n", r.countsSummary(counts, questions)))
+	return r.deliverReport(reportBuilder.String(), counts, issues, questions)
+}
+
+// countsSummary lists the number of issues of every level and of questions
+func (r *ReportGenerator) countsSummary(counts severityCounts, questions []types.Question) string {
+	countsSummary := r.formatCounts(counts)
+	if len(questions) > 0 {
+		countsSummary += fmt.Sprintf(", %d question(s) for the author", len(questions))
+	}
+	return countsSummary
+}
+
+// deliverReport announces the outcome of the review and hands the report to every sink. It
+// returns the path of the written report file, empty if none was written.
+func (r *ReportGenerator) deliverReport(markdown string, counts severityCounts, issues []types.Issue, questions []types.Question) string {
+	countsSummary := r.countsSummary(counts, questions)
+
+	fmt.Println()
+	issuesFound := 0
+	for _, count := range counts {
+		issuesFound += count
+	}
+	if issuesFound > 0 {
+		fmt.Printf("[✕] Code review didn't pass - issues found: %s\n", countsSummary)
+	} else {
+		fmt.Printf("[✓] Code review passed - no issues found, %d question(s) for the author\n", len(questions))
+	}
+
+	var page string
+	if r.config.Format == FormatHTML {
+		var err error
+		if page, err = r.generateHTMLReport(issues, questions, countsSummary); err != nil {
+			fmt.Printf("[!] %v, writing the markdown report instead\n", err)
+		}
+	}
+
+	report := Report{
+		HTML:      page,
+		Markdown:  markdown,
+		Summary:   countsSummary,
+		Passed:    issuesFound == 0,
+		Counts:    counts,
+		Issues:    issues,
+		Questions: questions,
+	}
+
+	reportPath := ""
+	for _, sink := range r.sinks {
+		path, err := sink.Write(report)
+		if err != nil {
+			fmt.Printf("failed to write code review to %s: %s\n", sink.Name(), err)
+			continue
+		}
+		if path != "" {
+			reportPath = path
+		}
+	}
+
+	return reportPath
+}
+
+// formatCounts lists the number of issues of every level, most severe first
+func (r *ReportGenerator) formatCounts(counts severityCounts) string {
+	parts := make([]string, 0, len(counts))
+	for _, level := range r.severities.Levels() {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[level], strings.ToLower(level)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r *ReportGenerator) writeIssue(reportBuilder *strings.Builder, issue types.Issue, counts severityCounts) {
+	// Only the lines of the issue are read, the report is written even when the review was
+	// interrupted
+	var result any
+	err := tools.ErrLineRange
+	if issue.StartLine > 0 && issue.StartLine <= issue.EndLine {
+		result, err = r.readTool.Execute(context.Background(), map[string]any{"filename": issue.FilePath, "start_line": issue.StartLine, "end_line": issue.EndLine})
+	}
+	if errors.Is(err, tools.ErrLineRange) {
+		reportBuilder.WriteString(fmt.Sprintf("## ⚪️ Invalid line numbers for issue: %s\n", issue.Description))
 		reportBuilder.WriteString(fmt.Sprintf("**File:** `%s`\n", issue.FilePath))
-		reportBuilder.WriteString(fmt.Sprintf("**Location:** Lines %d-%d\n", issue.StartLine, issue.EndLine))
+		reportBuilder.WriteString(fmt.Sprintf("**Line Range:** %d-%d\n\n---\n\n", issue.StartLine, issue.EndLine))
+		return
+	}
+	if _, ok := result.(string); !ok || err != nil {
+		reportBuilder.WriteString(fmt.Sprintf("## ⚪️ Could not retrieve code for issue: %s\n", issue.Description))
+		reportBuilder.WriteString(fmt.Sprintf("**File:** `%s`\n", issue.FilePath))
+		reportBuilder.WriteString(fmt.Sprintf("**Error:** %v\n\n---\n\n", err))
+		return
+	}
 
-		language := utils.DetectLanguageFromFilePath(issue.FilePath)
+	level := r.severities.Normalize(issue.Severity)
+	severityIcon := r.severities.Icon(level)
+	counts[level]++
 
-		if issue.CodeSnippet != "" {
-			reportBuilder.WriteString("**Code:**\n")
-			reportBuilder.WriteString(fmt.Sprintf("```%s\n", language))
-			reportBuilder.WriteString(issue.CodeSnippet)
-			reportBuilder.WriteString("\n```\n\n---\n\n")
+	reportBuilder.WriteString(fmt.Sprintf("## %s %s: %s\n", severityIcon, level, issue.Description))
+	reportBuilder.WriteString(fmt.Sprintf("**File:** `%s`\n", issue.FilePath))
+	if r.owners != nil {
+		issueOwners := "none"
+		if fileOwners := r.owners.Owners(issue.FilePath); len(fileOwners) > 0 {
+			issueOwners = strings.Join(fileOwners, ", ")
 		}
+		reportBuilder.WriteString(fmt.Sprintf("**Owners:** %s\n", issueOwners))
+	}
+	reportBuilder.WriteString(fmt.Sprintf("**Location:** Lines %d-%d\n", issue.StartLine, issue.EndLine))
+	if issue.Confidence > 0 {
+		reportBuilder.WriteString(fmt.Sprintf("**Confidence:** %.2f\n", issue.Confidence))
+	}
+	if issue.Category != "" {
+		reportBuilder.WriteString(fmt.Sprintf("**Check:** %s\n", issue.Category))
+	}
+	if len(issue.Models) > 0 {
+		reportBuilder.WriteString(fmt.Sprintf("**Found by:** %s\n", strings.Join(issue.Models, ", ")))
+	}
+	if issue.Occurrences > 1 {
+		reportBuilder.WriteString(fmt.Sprintf("**Occurrences:** %d similar findings merged\n", issue.Occurrences))
+	}
+	if issue.Unchanged {
+		reportBuilder.WriteString("**Status:** unchanged since last review\n")
 	}
 
-	fmt.Println()
-	fmt.Printf("[✕] Code review didn't pass - %d critical, %d warnings and %d minor issues were found\n",
-		criticalCount, warningCount, minorCount)
+	language := utils.DetectLanguageFromFilePath(issue.FilePath)
 
-	var summary = fmt.Sprintf("\n\n**Summary:** %d critical, %d warnings, %d minor issues\n", criticalCount, warningCount, minorCount)
-	reportBuilder.WriteString(summary)
+	if issue.CodeSnippet != "" {
+		reportBuilder.WriteString("**Code:**\n")
+		reportBuilder.WriteString(fmt.Sprintf("```%s\n", language))
+		reportBuilder.WriteString(issue.CodeSnippet)
+		reportBuilder.WriteString("\n```\n")
+	}
+	if fix := stripCodeFence(issue.SuggestedFix); fix != "" {
+		reportBuilder.WriteString("**Suggested fix:**\n```diff\n")
+		reportBuilder.WriteString(fix)
+		reportBuilder.WriteString("\n```\n")
+	}
+	if issue.CodeSnippet != "" || issue.SuggestedFix != "" {
+		reportBuilder.WriteString("\n---\n\n")
+	}
+}
 
-	writeArgs := map[string]any{
-		"filename": "diffpector_report.md",
-		"content":  reportBuilder.String(),
+// partitionIssues splits the issues into the ones shown directly and the ones folded
+// under the "possibly noteworthy" section, preserving their order.
+func (r *ReportGenerator) partitionIssues(issues []types.Issue) (prominent, collapsed []types.Issue) {
+	for _, issue := range issues {
+		if r.shouldCollapse(issue) {
+			collapsed = append(collapsed, issue)
+		} else {
+			prominent = append(prominent, issue)
+		}
 	}
+	return prominent, collapsed
+}
 
-	_, err := r.writeTool.Execute(writeArgs)
-	if err != nil {
-		fmt.Printf("failed to write markdown code review: %s", err)
-	} else {
-		fmt.Println()
-		fmt.Println("Detailed report saved to diffpector_report.md")
+func (r *ReportGenerator) shouldCollapse(issue types.Issue) bool {
+	normalized := r.severities.Normalize(issue.Severity)
+	for _, collapsed := range r.config.CollapseSeverities {
+		if r.severities.Normalize(collapsed) == normalized {
+			return true
+		}
 	}
+	// A zero confidence means the model did not report one
+	return issue.Confidence > 0 && issue.Confidence < r.config.CollapseBelowConfidence
 }
 
-func (r *ReportGenerator) getSeverityIcon(severity string) string {
-	switch severity {
-	case "CRITICAL":
-		return "🔴"
-	case "WARNING":
-		return "🟡"
-	case "MINOR":
-		return "🔵"
-	default:
-		return "⚪️"
+// FilterLowConfidence drops the findings whose confidence is below minConfidence and
+// returns how many were dropped. Findings without a confidence, such as the ones of the
+// checks, are kept.
+func FilterLowConfidence(issues []types.Issue, minConfidence float64) ([]types.Issue, int) {
+	if minConfidence <= 0 {
+		return issues, 0
+	}
+
+	kept := make([]types.Issue, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Confidence > 0 && issue.Confidence < minConfidence {
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	return kept, len(issues) - len(kept)
+}
+
+// SortIssues orders issues by severity and then by confidence, both descending.
+// Issues without a reported confidence rank as fully confident.
+func SortIssues(issues []types.Issue, severities *severity.Registry) []types.Issue {
+	sorted := slices.Clone(issues)
+	slices.SortStableFunc(sorted, func(a, b types.Issue) int {
+		if rankA, rankB := severities.Rank(a.Severity), severities.Rank(b.Severity); rankA != rankB {
+			return rankB - rankA
+		}
+		return cmp.Compare(effectiveConfidence(b), effectiveConfidence(a))
+	})
+	return sorted
+}
+
+func effectiveConfidence(issue types.Issue) float64 {
+	if issue.Confidence <= 0 {
+		return 1
+	}
+	return issue.Confidence
+}
+
+// PrintIssues writes a compact one-line-per-issue listing to the console
+func PrintIssues(issues []types.Issue, severities *severity.Registry) {
+	if len(issues) == 0 {
+		fmt.Println("[✓] No issues found")
+		return
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("%s %s %s:%d-%d %s\n", severities.Icon(issue.Severity), severities.Normalize(issue.Severity),
+			issue.FilePath, issue.StartLine, issue.EndLine, issue.Description)
 	}
 }
@@ -0,0 +1,371 @@
+package agent
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/utils"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+const htmlReportFileName = "diffpector_report.html"
+
+// htmlIssue is an issue of the HTML report with its anchor and highlighted code
+type htmlIssue struct {
+	types.Issue
+	Anchor string
+	Level  string
+	Icon   string
+	Code   template.HTML
+	Fix    template.HTML
+}
+
+type htmlFile struct {
+	FilePath string
+	Anchor   string
+	Issues   []htmlIssue
+	// Collapsed are the issues folded under "possibly noteworthy", see partitionIssues
+	Collapsed []htmlIssue
+	// Worst is the level of the most severe issue of the file, for its badge
+	Worst string
+}
+
+// Total counts the issues of the file, collapsed or not
+func (f htmlFile) Total() int {
+	return len(f.Issues) + len(f.Collapsed)
+}
+
+type htmlReport struct {
+	CountsSummary string
+	Counts        []htmlCount
+	Passed        bool
+	Summary       string
+	Notes         []string
+	Files         []htmlFile
+	Questions     []types.Question
+}
+
+type htmlCount struct {
+	Level string
+	Icon  string
+	Count int
+	// Color is the background of the badges of the level
+	Color string
+}
+
+// badgeColors are the badge backgrounds from the most to the least severe level, the
+// levels in between taking the nearest
+var badgeColors = []string{"#cf222e", "#bf8700", "#0969da"}
+
+// badgeColor returns the badge background of the level at index among count levels
+func badgeColor(index, count int) string {
+	if count < 2 {
+		return badgeColors[0]
+	}
+	return badgeColors[index*(len(badgeColors)-1)/(count-1)]
+}
+
+var anchorRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// generateHTMLReport renders the issues as a self-contained page: a section per file that
+// can be collapsed, every issue with its severity badge, highlighted code and an anchor to
+// link to it. The issues the markdown report folds are folded in their file section.
+func (r *ReportGenerator) generateHTMLReport(issues []types.Issue, questions []types.Question, countsSummary string) (string, error) {
+	report := htmlReport{
+		CountsSummary: countsSummary,
+		Passed:        len(issues) == 0,
+		Summary:       r.summary,
+		Notes:         r.notes(),
+		Questions:     questions,
+	}
+
+	counts := make(map[string]int)
+	byFile := make(map[string]*htmlFile)
+	var order []string
+	prominent, collapsed := r.partitionIssues(SortIssues(issues, r.severities))
+	for i, issue := range append(prominent, collapsed...) {
+		level := r.severities.Normalize(issue.Severity)
+		counts[level]++
+
+		file, ok := byFile[issue.FilePath]
+		if !ok {
+			file = &htmlFile{FilePath: issue.FilePath, Anchor: "file-" + anchorName(issue.FilePath), Worst: level}
+			byFile[issue.FilePath] = file
+			order = append(order, issue.FilePath)
+		}
+		if r.severities.Rank(level) > r.severities.Rank(file.Worst) {
+			file.Worst = level
+		}
+		language := utils.DetectLanguageFromFilePath(issue.FilePath)
+		entry := htmlIssue{
+			Issue:  issue,
+			Anchor: fmt.Sprintf("issue-%d", i+1),
+			Level:  level,
+			Icon:   r.severities.Icon(level),
+			Code:   highlightCode(issue.CodeSnippet, language),
+			Fix:    highlightDiff(stripCodeFence(issue.SuggestedFix)),
+		}
+		if i < len(prominent) {
+			file.Issues = append(file.Issues, entry)
+		} else {
+			file.Collapsed = append(file.Collapsed, entry)
+		}
+	}
+	slices.Sort(order)
+	for _, path := range order {
+		report.Files = append(report.Files, *byFile[path])
+	}
+	levels := r.severities.Levels()
+	for i, level := range levels {
+		report.Counts = append(report.Counts, htmlCount{Level: level, Icon: r.severities.Icon(level), Count: counts[level], Color: badgeColor(i, len(levels))})
+	}
+
+	var page strings.Builder
+	if err := htmlReportTemplate.Execute(&page, report); err != nil {
+		return "", fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return page.String(), nil
+}
+
+func anchorName(path string) string {
+	return strings.Trim(anchorRegex.ReplaceAllString(strings.ToLower(path), "-"), "-")
+}
+
+// highlightKeywords are the keywords highlighted per language, other languages only get
+// their strings, numbers and comments highlighted
+var highlightKeywords = map[string][]string{
+	"go": {"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func",
+		"go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct",
+		"switch", "type", "var", "nil", "true", "false"},
+	"java": {"abstract", "boolean", "break", "case", "catch", "class", "continue", "default", "do", "else", "enum",
+		"extends", "final", "finally", "for", "if", "implements", "import", "instanceof", "interface", "new",
+		"package", "private", "protected", "public", "return", "static", "super", "switch", "synchronized",
+		"this", "throw", "throws", "try", "void", "while", "null", "true", "false"},
+	"typescript": {"as", "async", "await", "break", "case", "catch", "class", "const", "continue", "default",
+		"else", "enum", "export", "extends", "finally", "for", "from", "function", "if", "implements", "import",
+		"interface", "let", "new", "return", "switch", "this", "throw", "try", "type", "var", "while", "null",
+		"undefined", "true", "false"},
+	"python": {"and", "as", "async", "await", "class", "def", "elif", "else", "except", "finally", "for", "from",
+		"if", "import", "in", "is", "lambda", "not", "or", "pass", "raise", "return", "try", "while", "with",
+		"yield", "None", "True", "False"},
+	"sql": {"select", "from", "where", "insert", "into", "update", "delete", "create", "alter", "drop", "table",
+		"index", "view", "join", "left", "right", "inner", "on", "and", "or", "not", "null", "as", "set",
+		"values", "primary", "key", "references", "group", "by", "order"},
+	"bash": {"if", "then", "else", "elif", "fi", "for", "while", "do", "done", "case", "esac", "function",
+		"return", "local", "export"},
+}
+
+func init() {
+	highlightKeywords["javascript"] = highlightKeywords["typescript"]
+	highlightKeywords["jsx"] = highlightKeywords["typescript"]
+	highlightKeywords["tsx"] = highlightKeywords["typescript"]
+}
+
+// lineComments are the line comment markers per language, // by default
+var lineComments = map[string][]string{
+	"python": {"#"},
+	"bash":   {"#"},
+	"ruby":   {"#"},
+	"yaml":   {"#"},
+	"hcl":    {"#", "//"},
+	"sql":    {"--"},
+}
+
+// highlightCode escapes the code and wraps its keywords, strings, numbers and comments in
+// spans styled by the page
+func highlightCode(code, language string) template.HTML {
+	if code == "" {
+		return ""
+	}
+	keywords := highlightKeywords[language]
+	comments, ok := lineComments[language]
+	if !ok {
+		comments = []string{"//"}
+	}
+	blockComments := !slices.Contains(comments, "#") && language != "sql"
+
+	var out strings.Builder
+	for i := 0; i < len(code); {
+		rest := code[i:]
+		switch c := code[i]; {
+		case blockComments && strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			length := len(rest)
+			if end >= 0 {
+				length = end + 4
+			}
+			writeSpan(&out, "comment", rest[:length])
+			i += length
+		case slices.ContainsFunc(comments, func(marker string) bool { return strings.HasPrefix(rest, marker) }):
+			length := strings.IndexByte(rest, '\n')
+			if length < 0 {
+				length = len(rest)
+			}
+			writeSpan(&out, "comment", rest[:length])
+			i += length
+		case c == '"' || c == '\'' || c == '`':
+			length := stringLength(rest)
+			writeSpan(&out, "string", rest[:length])
+			i += length
+		case c >= '0' && c <= '9':
+			length := 1
+			for length < len(rest) && (isIdentifierChar(rest[length]) || rest[length] == '.') {
+				length++
+			}
+			writeSpan(&out, "number", rest[:length])
+			i += length
+		case isIdentifierChar(c):
+			length := 1
+			for length < len(rest) && isIdentifierChar(rest[length]) {
+				length++
+			}
+			word := rest[:length]
+			if slices.Contains(keywords, word) || (language == "sql" && slices.Contains(keywords, strings.ToLower(word))) {
+				writeSpan(&out, "keyword", word)
+			} else {
+				out.WriteString(html.EscapeString(word))
+			}
+			i += length
+		default:
+			out.WriteString(html.EscapeString(string(c)))
+			i++
+		}
+	}
+	return template.HTML(out.String())
+}
+
+// highlightDiff escapes a suggested fix and colors its added and removed lines
+func highlightDiff(diff string) template.HTML {
+	if diff == "" {
+		return ""
+	}
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			lines[i] = fmt.Sprintf(`<span class="added">%s</span>`, html.EscapeString(line))
+		case strings.HasPrefix(line, "-"):
+			lines[i] = fmt.Sprintf(`<span class="removed">%s</span>`, html.EscapeString(line))
+		default:
+			lines[i] = html.EscapeString(line)
+		}
+	}
+	return template.HTML(strings.Join(lines, "\n"))
+}
+
+func writeSpan(out *strings.Builder, class, text string) {
+	fmt.Fprintf(out, `<span class="%s">%s</span>`, class, html.EscapeString(text))
+}
+
+// stringLength returns the length of the string literal starting the text, up to the end of
+// the line when it is not closed. Only backquoted strings span lines.
+func stringLength(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && quote != '`':
+			i++
+		case text[i] == quote:
+			return i + 1
+		case text[i] == '\n' && quote != '`':
+			return i
+		}
+	}
+	return len(text)
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// inlineCode escapes a markdown note and turns its backquoted spans into code elements
+func inlineCode(note string) template.HTML {
+	parts := strings.Split(note, "`")
+	for i, part := range parts {
+		parts[i] = html.EscapeString(part)
+		if i%2 == 1 && i < len(parts)-1 {
+			parts[i] = "<code>" + parts[i] + "</code>"
+		} else if i%2 == 1 {
+			parts[i] = "`" + parts[i]
+		}
+	}
+	return template.HTML(strings.Join(parts, ""))
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"class":      anchorName,
+	"inlineCode": inlineCode,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Code Review Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0 auto; max-width: 980px; padding: 24px; color: #1f2328; }
+a { color: #0969da; text-decoration: none; }
+a:hover { text-decoration: underline; }
+.counts span { margin-right: 16px; }
+.verdict { font-weight: 600; }
+.verdict.failed { color: #cf222e; }
+.verdict.passed { color: #1a7f37; }
+.note { font-style: italic; color: #57606a; }
+details.file { border: 1px solid #d0d7de; border-radius: 6px; margin: 16px 0; }
+details.file > summary { cursor: pointer; padding: 8px 12px; background: #f6f8fa; font-family: monospace; font-size: 14px; }
+.issue { padding: 8px 16px; border-top: 1px solid #d0d7de; }
+.issue h3 { font-size: 15px; margin: 8px 0; }
+.issue .anchor { color: #57606a; margin-left: 6px; font-weight: normal; }
+.meta { font-size: 13px; color: #57606a; }
+.badge { display: inline-block; padding: 1px 8px; border-radius: 12px; font-size: 12px; font-weight: 600; color: #fff; background: #6e7781; }
+{{range .Counts}}.badge.{{.Level | class}} { background: {{.Color}}; }
+{{end}}details.noteworthy { border-top: 1px solid #d0d7de; }
+details.noteworthy > summary { cursor: pointer; padding: 8px 16px; color: #57606a; }
+pre { background: #f6f8fa; border-radius: 6px; padding: 12px; overflow-x: auto; font-size: 13px; }
+.keyword { color: #cf222e; }
+.string { color: #0a3069; }
+.number { color: #0550ae; }
+.comment { color: #6e7781; font-style: italic; }
+.added { color: #116329; background: #dafbe1; }
+.removed { color: #82071e; background: #ffebe9; }
+</style>
+</head>
+<body>
+<h1>Code Review Report</h1>
+<p class="verdict {{if .Passed}}passed{{else}}failed{{end}}">{{if .Passed}}Review passed{{else}}Review did not pass{{end}}: {{.CountsSummary}}</p>
+<p class="counts">{{range .Counts}}<span>{{.Icon}} {{.Level}}: {{.Count}}</span>{{end}}</p>
+{{if .Summary}}<h2>Summary of Changes</h2>
+<p>{{.Summary}}</p>
+{{end}}{{if .Files}}<h2>Files</h2>
+<ul>
+{{range .Files}}<li><span class="badge {{.Worst | class}}">{{.Worst}}</span> <a href="#{{.Anchor}}">{{.FilePath}}</a> ({{.Total}})</li>
+{{end}}</ul>
+{{end}}{{range .Files}}<details class="file" id="{{.Anchor}}" open>
+<summary>{{.FilePath}} ({{.Total}})</summary>
+{{range .Issues}}{{template "issue" .}}{{end}}{{if .Collapsed}}<details class="noteworthy">
+<summary>Possibly noteworthy ({{len .Collapsed}})</summary>
+{{range .Collapsed}}{{template "issue" .}}{{end}}</details>
+{{end}}</details>
+{{end}}{{if .Questions}}<h2>Questions for the author</h2>
+<ul>
+{{range .Questions}}<li><code>{{.FilePath}}:{{.Line}}</code> {{.Question}}</li>
+{{end}}</ul>
+{{end}}{{range .Notes}}<p class="note">{{inlineCode .}}</p>
+{{end}}</body>
+</html>
+{{define "issue"}}<section class="issue" id="{{.Anchor}}">
+<h3><span class="badge {{.Level | class}}">{{.Icon}} {{.Level}}</span> {{.Description}}<a class="anchor" href="#{{.Anchor}}">#</a></h3>
+<p class="meta">Lines {{.StartLine}}-{{.EndLine}}{{if gt .Confidence 0.0}} &middot; confidence {{printf "%.2f" .Confidence}}{{end}}{{if .Category}} &middot; check {{.Category}}{{end}}{{if gt .Occurrences 1}} &middot; {{.Occurrences}} similar findings merged{{end}}{{if .Unchanged}} &middot; unchanged since last review{{end}}</p>
+{{if .Code}}<pre><code>{{.Code}}</code></pre>
+{{end}}{{if .Fix}}<p class="meta">Suggested fix</p>
+<pre><code>{{.Fix}}</code></pre>
+{{end}}</section>
+{{end}}`))
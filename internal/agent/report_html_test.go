@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestReportGenerator_HTMLFormat(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: strings.Repeat("line\n", 20)}
+	reportGen := NewReportGenerator(readTool, writeTool, config.ReportConfig{Format: FormatHTML}, severity.Default())
+	reportGen.SetGeneratedFiles([]string{"go.sum"})
+
+	path := reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "MINOR", FilePath: "web/app.ts", StartLine: 2, EndLine: 2, Description: "Unused <div> wrapper"},
+		{Severity: "CRITICAL", FilePath: "store/db.go", StartLine: 10, EndLine: 11, Description: "SQL injection",
+			CodeSnippet: "return db.Query(\"SELECT * FROM users WHERE id = \" + id) // TODO", SuggestedFix: "-db.Query(q + id)\n+db.Query(q, id)"},
+	})
+
+	if path != htmlReportFileName {
+		t.Fatalf("Expected the HTML report path, got %q", path)
+	}
+	page := writeTool.written[htmlReportFileName]
+	if _, ok := writeTool.written[reportFileName]; ok {
+		t.Error("Expected only the HTML report to be written")
+	}
+
+	for _, expected := range []string{
+		`<details class="file" id="file-store-db-go" open>`,
+		`<a href="#file-web-app-ts">web/app.ts</a>`,
+		`<section class="issue" id="issue-1">`,
+		`<span class="badge critical">🔴 CRITICAL</span> SQL injection<a class="anchor" href="#issue-1">#</a>`,
+		`<span class="keyword">return</span> db.Query(<span class="string">&#34;SELECT * FROM users WHERE id = &#34;</span> + id) <span class="comment">// TODO</span>`,
+		`<span class="removed">-db.Query(q + id)</span>`,
+		`Unused &lt;div&gt; wrapper`,
+		`Generated files changed, not reviewed: <code>go.sum</code>`,
+		`Review did not pass: 1 critical, 0 warning, 1 minor`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the page to contain %q, got:\n%s", expected, page)
+		}
+	}
+	if strings.Index(page, "store/db.go") > strings.Index(page, "web/app.ts (1)") {
+		t.Error("Expected the files in path order")
+	}
+}
+
+func TestReportGenerator_HTMLFoldsAndCustomLevels(t *testing.T) {
+	custom, err := severity.New(config.SeverityConfig{
+		Levels:  []config.SeverityLevel{{Name: "BLOCKER"}, {Name: "MAJOR"}, {Name: "NIT PICK"}},
+		Mapping: map[string]string{"CRITICAL": "BLOCKER", "WARNING": "MAJOR", "MINOR": "NIT PICK"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create severities: %v", err)
+	}
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: strings.Repeat("line\n", 20)}
+	reportGen := NewReportGenerator(readTool, writeTool, config.ReportConfig{Format: FormatHTML, CollapseSeverities: []string{"NIT PICK"}}, custom)
+
+	reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "NIT PICK", FilePath: "main.go", StartLine: 3, EndLine: 3, Description: "Naming"},
+		{Severity: "BLOCKER", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Data race", Unchanged: true},
+	})
+
+	page := writeTool.written[htmlReportFileName]
+	for _, expected := range []string{
+		`.badge.blocker { background: #cf222e; }`,
+		`.badge.major { background: #bf8700; }`,
+		`.badge.nit-pick { background: #0969da; }`,
+		`<span class="badge nit-pick">`,
+		`<summary>main.go (2)</summary>`,
+		`Lines 1-1 &middot; unchanged since last review</p>`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the page to contain %q, got:\n%s", expected, page)
+		}
+	}
+	fold := strings.Index(page, `<details class="noteworthy">`)
+	if fold < 0 || strings.Index(page, "Data race") > fold || strings.Index(page, "Naming") < fold {
+		t.Errorf("Expected the nit pick folded under possibly noteworthy, got:\n%s", page)
+	}
+	if strings.Contains(page, `<details class="noteworthy" open`) {
+		t.Error("Expected the fold to be closed")
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		language string
+		expected string
+	}{
+		{
+			name:     "python comment and keyword",
+			code:     "def f(): # 'x'",
+			language: "python",
+			expected: `<span class="keyword">def</span> f(): <span class="comment"># &#39;x&#39;</span>`,
+		},
+		{
+			name:     "sql keywords in any case",
+			code:     "SELECT 1 -- one",
+			language: "sql",
+			expected: `<span class="keyword">SELECT</span> <span class="number">1</span> <span class="comment">-- one</span>`,
+		},
+		{
+			name:     "unclosed string stops at the line end",
+			code:     "s := \"open\nnext",
+			language: "go",
+			expected: "s := <span class=\"string\">&#34;open</span>\nnext",
+		},
+		{
+			name:     "unknown language is escaped",
+			code:     "<a href=\"x\">",
+			language: "",
+			expected: `&lt;a href=<span class="string">&#34;x&#34;</span>&gt;`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(highlightCode(tt.code, tt.language)); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/utils"
+)
+
+// ReportMetadata describes the review in a templated report
+type ReportMetadata struct {
+	Model  string
+	Commit string
+	Branch string
+	// Duration is the time spent from the first file review to the report
+	Duration time.Duration
+	Date     time.Time
+}
+
+// FileIssues are the issues of one file, most severe first
+type FileIssues struct {
+	FilePath string
+	Issues   []types.Issue
+}
+
+// ReportTemplateData is what a report template is executed with
+type ReportTemplateData struct {
+	// Issues are sorted by severity then confidence, like in the default layout
+	Issues []types.Issue
+	// Files groups the issues by file, in path order
+	Files []FileIssues
+	// Counts holds the number of issues per severity level, CountsSummary lists them all
+	Counts        map[string]int
+	CountsSummary string
+	Total         int
+	Passed        bool
+	Questions     []types.Question
+	// Summary is the summary of the changeset, empty when disabled
+	Summary string
+	// Notes are the notes of the default layout, such as the findings hidden or the files not
+	// reviewed, without their formatting
+	Notes    []string
+	Metadata ReportMetadata
+}
+
+// LoadReportTemplate parses the report template at path, relative to the repository root.
+// Besides the text/template builtins, templates can use lower, upper, join, icon, which
+// returns the icon of a severity, and language, which returns the code fence language of a
+// file path.
+func LoadReportTemplate(repoRoot, path string) (*template.Template, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repoRoot, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report template: %w", err)
+	}
+
+	funcs := template.FuncMap{
+		"lower":    strings.ToLower,
+		"upper":    strings.ToUpper,
+		"join":     strings.Join,
+		"icon":     func(level string) string { return "" },
+		"language": utils.DetectLanguageFromFilePath,
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(funcs).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse report template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// SetTemplate replaces the default layout of the single repository report with a template
+// loaded by LoadReportTemplate
+func (r *ReportGenerator) SetTemplate(tmpl *template.Template) {
+	r.template = tmpl
+}
+
+// SetMetadata sets the description of the review available to the report template
+func (r *ReportGenerator) SetMetadata(metadata ReportMetadata) {
+	r.metadata = metadata
+}
+
+// SetReportTemplate writes the report with a template loaded by LoadReportTemplate instead
+// of the default layout. The metadata gives the commit and branch reviewed, the model and the
+// duration are filled in by the agent.
+func (a *CodeReviewAgent) SetReportTemplate(tmpl *template.Template, metadata ReportMetadata) {
+	a.reportTemplate = tmpl
+	a.reportMetadata = metadata
+}
+
+// metadata completes the report metadata with the models and the duration of the review
+func (a *CodeReviewAgent) metadata() ReportMetadata {
+	metadata := a.reportMetadata
+	if metadata.Model == "" {
+		metadata.Model = a.llmProvider.GetModel()
+		if len(a.ensemble) > 0 {
+			metadata.Model = strings.Join(a.ensembleNames(), ", ")
+		}
+	}
+	if !a.started.IsZero() {
+		metadata.Duration = time.Since(a.started).Round(time.Second)
+	}
+	if metadata.Date.IsZero() {
+		metadata.Date = time.Now()
+	}
+	return metadata
+}
+
+// templateData groups and counts the issues for the report template
+func (r *ReportGenerator) templateData(issues []types.Issue) ReportTemplateData {
+	data := ReportTemplateData{
+		Issues:    SortIssues(issues, r.severities),
+		Counts:    make(map[string]int),
+		Total:     len(issues),
+		Passed:    len(issues) == 0,
+		Questions: r.questions,
+		Summary:   r.summary,
+		Notes:     r.notes(),
+		Metadata:  r.metadata,
+	}
+
+	byFile := make(map[string][]types.Issue)
+	for _, issue := range data.Issues {
+		data.Counts[r.severities.Normalize(issue.Severity)]++
+		byFile[issue.FilePath] = append(byFile[issue.FilePath], issue)
+	}
+	paths := make([]string, 0, len(byFile))
+	for path := range byFile {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		data.Files = append(data.Files, FileIssues{FilePath: path, Issues: byFile[path]})
+	}
+	return data
+}
+
+// generateTemplatedReport writes the report with the user template. When the template
+// fails, the report is written with the default layout.
+func (r *ReportGenerator) generateTemplatedReport(issues []types.Issue) (string, bool) {
+	data := r.templateData(issues)
+	data.CountsSummary = r.countsSummary(data.Counts, r.questions)
+
+	// The icons depend on the severity levels of the review
+	r.template.Funcs(template.FuncMap{
+		"icon": func(level string) string { return r.severities.Icon(r.severities.Normalize(level)) },
+	})
+
+	var reportBuilder strings.Builder
+	if err := r.template.Execute(&reportBuilder, data); err != nil {
+		fmt.Printf("[!] Report template failed, using the default layout: %v\n", err)
+		return "", false
+	}
+	return r.deliverReport(reportBuilder.String(), data.Counts, issues, r.questions), true
+}
@@ -0,0 +1,381 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/owners"
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+type stubReadTool struct {
+	content string
+}
+
+func (t *stubReadTool) Name() string           { return "read_file" }
+func (t *stubReadTool) Description() string    { return "stub read tool" }
+func (t *stubReadTool) Schema() map[string]any { return map[string]any{} }
+func (t *stubReadTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	return t.content, nil
+}
+
+type captureWriteTool struct {
+	written map[string]string
+}
+
+func (t *captureWriteTool) Name() string           { return "write_file" }
+func (t *captureWriteTool) Description() string    { return "capturing write tool" }
+func (t *captureWriteTool) Schema() map[string]any { return map[string]any{} }
+func (t *captureWriteTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	if t.written == nil {
+		t.written = make(map[string]string)
+	}
+	t.written[args["filename"].(string)] = args["content"].(string)
+	return "ok", nil
+}
+
+func TestSortIssues(t *testing.T) {
+	issues := []types.Issue{
+		{Severity: "MINOR", Description: "minor"},
+		{Severity: "WARNING", Description: "warning low", Confidence: 0.3},
+		{Severity: "CRITICAL", Description: "critical"},
+		{Severity: "WARNING", Description: "warning unknown"},
+		{Severity: "WARNING", Description: "warning high", Confidence: 0.9},
+	}
+
+	sorted := SortIssues(issues, severity.Default())
+
+	expected := []string{"critical", "warning unknown", "warning high", "warning low", "minor"}
+	for i, description := range expected {
+		if sorted[i].Description != description {
+			t.Errorf("Position %d: expected %q, got %q", i, description, sorted[i].Description)
+		}
+	}
+
+	if issues[0].Description != "minor" {
+		t.Error("SortIssues should not modify the input slice")
+	}
+}
+
+func TestReportGenerator_CollapsesLowPriorityIssues(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: strings.Repeat("line\n", 20)}
+	reportGen := NewReportGenerator(readTool, writeTool, config.DefaultReportConfig(), severity.Default())
+
+	issues := []types.Issue{
+		{Severity: "MINOR", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Naming could be clearer", CodeSnippet: "x := 1"},
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 2, EndLine: 2, Description: "Unsure about leak", CodeSnippet: "f, _ := os.Open(p)", Confidence: 0.2},
+		{Severity: "CRITICAL", FilePath: "main.go", StartLine: 3, EndLine: 3, Description: "SQL injection", CodeSnippet: "db.Query(q + id)", Confidence: 0.95},
+	}
+
+	reportGen.GenerateMarkdownReport(issues)
+
+	report := writeTool.written["diffpector_report.md"]
+	foldIdx := strings.Index(report, "<summary>Possibly noteworthy (2)</summary>")
+	if foldIdx == -1 {
+		t.Fatalf("Expected a fold with two collapsed issues, got:\n%s", report)
+	}
+
+	criticalIdx := strings.Index(report, "CRITICAL: SQL injection")
+	if criticalIdx == -1 || criticalIdx > foldIdx {
+		t.Errorf("Expected the critical issue before the fold")
+	}
+	if idx := strings.Index(report, "WARNING: Unsure about leak"); idx < foldIdx {
+		t.Errorf("Expected the low confidence warning inside the fold")
+	}
+	if idx := strings.Index(report, "MINOR: Naming could be clearer"); idx < foldIdx {
+		t.Errorf("Expected the minor issue inside the fold")
+	}
+	if !strings.Contains(report, "**Confidence:** 0.95") {
+		t.Errorf("Expected the confidence to be rendered")
+	}
+	if !strings.Contains(report, "**Summary:** 1 critical, 1 warning, 1 minor") {
+		t.Errorf("Expected collapsed issues to be counted in the summary")
+	}
+}
+
+func TestReportGenerator_ValidatesLineNumbers(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(filename, []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeTool := &captureWriteTool{}
+	reportGen := NewReportGenerator(&tools.ReadFileTool{}, writeTool, config.ReportConfig{}, severity.Default())
+
+	reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "MINOR", FilePath: filename, StartLine: 3, EndLine: 3, Description: "Empty main"},
+		{Severity: "MINOR", FilePath: filename, StartLine: 3, EndLine: 9, Description: "Past the end"},
+		{Severity: "MINOR", FilePath: filepath.Join(filepath.Dir(filename), "missing.go"), StartLine: 1, EndLine: 1, Description: "Missing file"},
+	})
+
+	report := writeTool.written["diffpector_report.md"]
+	for _, expected := range []string{"MINOR: Empty main", "Invalid line numbers for issue: Past the end", "Could not retrieve code for issue: Missing file"} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected %q in the report, got:\n%s", expected, report)
+		}
+	}
+}
+
+func TestFilterLowConfidence(t *testing.T) {
+	issues := []types.Issue{
+		{Description: "confident", Confidence: 0.9},
+		{Description: "unsure", Confidence: 0.3},
+		{Description: "from a check"},
+	}
+
+	kept, hidden := FilterLowConfidence(issues, 0.5)
+	if hidden != 1 || len(kept) != 2 || kept[0].Description != "confident" || kept[1].Description != "from a check" {
+		t.Errorf("Expected only the unsure finding to be hidden, got %+v (%d hidden)", kept, hidden)
+	}
+
+	if kept, hidden := FilterLowConfidence(issues, 0); hidden != 0 || len(kept) != 3 {
+		t.Errorf("Expected no filtering without a threshold, got %d hidden", hidden)
+	}
+}
+
+func TestReportGenerator_NotesHiddenIssues(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: strings.Repeat("line\n", 20)}
+	reportConfig := config.DefaultReportConfig()
+	reportConfig.MinConfidence = 0.6
+	reportGen := NewReportGenerator(readTool, writeTool, reportConfig, severity.Default())
+	reportGen.SetHiddenIssues(2)
+
+	reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Unchecked error", CodeSnippet: "f.Close()", Confidence: 0.8},
+	})
+
+	if report := writeTool.written["diffpector_report.md"]; !strings.Contains(report, "_2 finding(s) with a confidence below 0.60 hidden_") {
+		t.Errorf("Expected the hidden findings to be noted, got:\n%s", report)
+	}
+}
+
+func TestReportGenerator_RendersSuggestedFix(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: strings.Repeat("line\n", 20)}
+	reportGen := NewReportGenerator(readTool, writeTool, config.ReportConfig{}, severity.Default())
+
+	reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 2, EndLine: 2, Description: "Unchecked error", CodeSnippet: "f.Close()", SuggestedFix: "```diff\n@@ -2,1 +2,3 @@\n-f.Close()\n+if err := f.Close(); err != nil {\n+\treturn err\n+}\n```"},
+	})
+
+	expected := "**Suggested fix:**\n```diff\n@@ -2,1 +2,3 @@\n-f.Close()\n+if err := f.Close(); err != nil {\n+\treturn err\n+}\n```\n\n---"
+	if report := writeTool.written["diffpector_report.md"]; !strings.Contains(report, expected) {
+		t.Errorf("Expected the suggested fix as a diff block, got:\n%s", report)
+	}
+}
+
+func TestReportGenerator_NoFoldWhenDisabled(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: "line\n"}
+	reportGen := NewReportGenerator(readTool, writeTool, config.ReportConfig{}, severity.Default())
+
+	reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "MINOR", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "minor", Confidence: 0.1},
+	})
+
+	if strings.Contains(writeTool.written["diffpector_report.md"], "<details>") {
+		t.Error("Expected no fold when collapsing is disabled")
+	}
+}
+
+func TestReportGenerator_GroupsIssuesByRepository(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: strings.Repeat("line\n", 10)}
+	reportGen := NewReportGenerator(readTool, writeTool, config.ReportConfig{}, severity.Default())
+
+	reportGen.GenerateGroupedMarkdownReport([]RepositoryIssues{
+		{Repository: "svc-a", Issues: []types.Issue{
+			{Severity: "CRITICAL", FilePath: "svc-a/main.go", StartLine: 1, EndLine: 1, Description: "Nil dereference"},
+		}},
+		{Repository: "svc-b"},
+		{Repository: "svc-c", Issues: []types.Issue{
+			{Severity: "WARNING", FilePath: "svc-c/db.go", StartLine: 2, EndLine: 2, Description: "Unclosed rows"},
+		}},
+		{Repository: "svc-d", Error: "model not found"},
+	})
+
+	report := writeTool.written["diffpector_report.md"]
+	sections := []string{"# Repository: `svc-a`", "Nil dereference", "# Repository: `svc-b`", "No issues found", "# Repository: `svc-c`", "Unclosed rows", "# Repository: `svc-d`", "**Review failed:** model not found"}
+	last := -1
+	for _, section := range sections {
+		idx := strings.Index(report, section)
+		if idx <= last {
+			t.Fatalf("Expected %q after the previous section, got:\n%s", section, report)
+		}
+		last = idx
+	}
+	if !strings.Contains(report, "**Summary:** 1 critical, 1 warning, 0 minor") {
+		t.Errorf("Expected the summary to count issues across repositories")
+	}
+}
+
+func TestReportGenerator_GroupsIssuesByOwner(t *testing.T) {
+	rules, err := owners.Parse(strings.NewReader("*.go @org/core\napi/ @org/api @alice\n/vendor/\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse owners: %v", err)
+	}
+
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: strings.Repeat("line\n", 10)}
+	reportGen := NewReportGenerator(readTool, writeTool, config.ReportConfig{}, severity.Default())
+	reportGen.SetOwners(rules)
+
+	reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Unclosed file"},
+		{Severity: "MINOR", FilePath: "vendor/lib.go", StartLine: 1, EndLine: 1, Description: "Vendored"},
+		{Severity: "CRITICAL", FilePath: "api/handler.go", StartLine: 2, EndLine: 2, Description: "Missing auth"},
+		{Severity: "MINOR", FilePath: "api/routes.go", StartLine: 3, EndLine: 3, Description: "Typo"},
+	})
+
+	report := writeTool.written["diffpector_report.md"]
+	sections := []string{"# Owners: @org/api @alice", "Missing auth", "**Owners:** @org/api, @alice", "Typo", "# Owners: @org/core", "Unclosed file", "# Unowned", "Vendored", "**Owners:** none"}
+	last := -1
+	for _, section := range sections {
+		idx := strings.Index(report, section)
+		if idx <= last {
+			t.Fatalf("Expected %q after the previous section, got:\n%s", section, report)
+		}
+		last = idx
+	}
+}
+
+func TestReportGenerator_CustomSeverities(t *testing.T) {
+	severities, err := severity.New(config.SeverityConfig{
+		Levels: []config.SeverityLevel{{Name: "BLOCKER", Icon: "⛔"}, {Name: "MAJOR"}, {Name: "INFO"}},
+		Mapping: map[string]string{
+			"CRITICAL": "BLOCKER",
+			"WARNING":  "MAJOR",
+			"MINOR":    "INFO",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create severities: %v", err)
+	}
+
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: strings.Repeat("line\n", 10)}
+	reportGen := NewReportGenerator(readTool, writeTool, config.DefaultReportConfig(), severities)
+
+	reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "MINOR", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Naming"},
+		{Severity: "CRITICAL", FilePath: "main.go", StartLine: 2, EndLine: 2, Description: "Data race"},
+	})
+
+	report := writeTool.written["diffpector_report.md"]
+	if !strings.Contains(report, "## ⛔ BLOCKER: Data race") {
+		t.Errorf("Expected the critical issue to be rendered as BLOCKER, got:\n%s", report)
+	}
+	if !strings.Contains(report, "<summary>Possibly noteworthy (1)</summary>") {
+		t.Errorf("Expected MINOR in collapse_severities to fold INFO issues")
+	}
+	if !strings.Contains(report, "**Summary:** 1 blocker, 0 major, 1 info") {
+		t.Errorf("Expected the summary to use the custom levels")
+	}
+}
+
+func TestReportGenerator_NonASCIIIdentifiers(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: "package main\n\nfunc 测试() {}\n"}
+	reportGen := NewReportGenerator(readTool, writeTool, config.ReportConfig{}, severity.Default())
+
+	reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "WARNING", FilePath: "测试/main.go", StartLine: 3, EndLine: 3, Description: "测试 is never called", CodeSnippet: "func 测试() {}"},
+	})
+
+	report := writeTool.written["diffpector_report.md"]
+	for _, expected := range []string{"WARNING: 测试 is never called", "**File:** `测试/main.go`", "```go\nfunc 测试() {}\n```"} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected report to contain %q, got:\n%s", expected, report)
+		}
+	}
+}
+
+func TestReportGenerator_QuestionsDoNotCountAsIssues(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: "line\n"}
+	reportGen := NewReportGenerator(readTool, writeTool, config.ReportConfig{}, severity.Default())
+	reportGen.SetQuestions([]types.Question{
+		{FilePath: "main.go", Line: 3, Question: "Is the retry meant to be unbounded?"},
+	})
+
+	reportGen.GenerateMarkdownReport(nil)
+
+	report := writeTool.written["diffpector_report.md"]
+	if !strings.Contains(report, "## ❓ Questions for the author") {
+		t.Fatalf("Expected a questions section, got:\n%s", report)
+	}
+	if !strings.Contains(report, "- `main.go:3` Is the retry meant to be unbounded?") {
+		t.Errorf("Expected the question with its location, got:\n%s", report)
+	}
+	if !strings.Contains(report, "**Summary:** 0 critical, 0 warning, 0 minor, 1 question(s) for the author") {
+		t.Errorf("Expected questions to be summarized apart from issues, got:\n%s", report)
+	}
+}
+
+func TestReportGenerator_NotesUnreviewedFiles(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	reportGen := NewReportGenerator(&stubReadTool{}, writeTool, config.ReportConfig{}, severity.Default())
+	reportGen.SetUnreviewedFiles([]string{"main.go", "helper.go"})
+
+	reportGen.GenerateMarkdownReport(nil)
+
+	if report := writeTool.written["diffpector_report.md"]; !strings.Contains(report, "_Review interrupted, not reviewed: `main.go`, `helper.go`_") {
+		t.Errorf("Expected the unreviewed files to be noted, got:\n%s", report)
+	}
+}
+
+func TestReportGenerator_Template(t *testing.T) {
+	dir := t.TempDir()
+	templateText := `# Review of {{.Metadata.Commit}} by {{.Metadata.Model}}
+{{range .Files}}## {{.FilePath}}
+{{range .Issues}}- {{icon .Severity}} {{lower .Severity}} L{{.StartLine}}: {{.Description}}
+{{end}}{{end}}{{range .Notes}}> {{.}}
+{{end}}Total: {{.Total}} ({{.CountsSummary}})
+`
+	if err := os.WriteFile(filepath.Join(dir, "report.tmpl"), []byte(templateText), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := LoadReportTemplate(dir, "report.tmpl")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	writeTool := &captureWriteTool{}
+	reportGen := NewReportGenerator(&stubReadTool{}, writeTool, config.ReportConfig{}, severity.Default())
+	reportGen.SetTemplate(tmpl)
+	reportGen.SetMetadata(ReportMetadata{Model: "qwen", Commit: "3b45395"})
+	reportGen.SetTriagedFiles([]string{"go.mod"})
+
+	reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "MINOR", FilePath: "util.go", StartLine: 3, Description: "Unused parameter"},
+		{Severity: "CRITICAL", FilePath: "main.go", StartLine: 10, Description: "SQL injection"},
+		{Severity: "WARNING", FilePath: "util.go", StartLine: 1, Description: "Unchecked error"},
+	})
+
+	expected := `# Review of 3b45395 by qwen
+## main.go
+- 🔴 critical L10: SQL injection
+## util.go
+- 🟡 warning L1: Unchecked error
+- 🔵 minor L3: Unused parameter
+> Triaged as trivial, not reviewed in depth: ` + "`go.mod`" + `
+Total: 3 (1 critical, 1 warning, 1 minor)
+`
+	if report := writeTool.written["diffpector_report.md"]; report != expected {
+		t.Errorf("Expected the templated report:\n%s\ngot:\n%s", expected, report)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte("{{.Missing}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadReportTemplate(dir, "broken.tmpl"); err == nil {
+		t.Error("Expected an invalid template to be rejected")
+	}
+}
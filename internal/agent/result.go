@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// ReviewResult summarizes the outcome of a review
+type ReviewResult struct {
+	Files int
+	// ChangedFiles lists the files of the review, reviewed or not
+	ChangedFiles []string
+	// ChangedLines counts the lines the diffs of the changed files added and removed
+	ChangedLines int
+	Issues       []types.Issue
+	Questions    []types.Question
+	// FailedFiles lists the files whose review failed, their issues are missing
+	FailedFiles []string
+	// FailedRepositories lists the repositories of a multi-repo review whose review failed
+	FailedRepositories []string
+	// UnreviewedFiles lists the files left when the review was interrupted
+	UnreviewedFiles []string
+	// HiddenIssues counts the findings dropped for a confidence below min_confidence
+	HiddenIssues int
+	// RejectedIssues counts the findings the verifier rejected, dropped or downgraded
+	RejectedIssues int
+	// UnchangedFiles counts the files whose findings were reused from the last review
+	UnchangedFiles int
+	// ResumedFiles counts the files taken from the checkpoint of an interrupted review
+	ResumedFiles int
+	// ChunkedFiles counts the chunks of the oversized file diffs reviewed in chunks
+	ChunkedFiles map[string]int
+	// Summary is the summary of the changeset written before the review, empty when disabled
+	Summary string
+	// TriagedFiles lists the files the triage model found trivial, not reviewed in depth
+	TriagedFiles []string
+	// GeneratedFiles lists the changed lockfiles and generated files, which are not reviewed
+	GeneratedFiles []string
+	// ReportPath is empty when no report was written
+	ReportPath string
+	// FailOn is the least severe level of the issues failing the review, empty fails on any
+	FailOn string
+	// Error is why the review failed, empty when it completed
+	Error string
+
+	severities *severity.Registry
+}
+
+// SummaryLine formats the result as a single line for shell scripts, e.g.
+// DIFFPECTOR_RESULT critical=1 warning=3 minor=2 files=5 verdict=fail report=diffpector_report.md
+func (r ReviewResult) SummaryLine() string {
+	severities := r.severities
+	if severities == nil {
+		severities = severity.Default()
+	}
+
+	counts := make(map[string]int)
+	for _, issue := range r.Issues {
+		counts[severities.Normalize(issue.Severity)]++
+	}
+
+	fields := []string{"DIFFPECTOR_RESULT"}
+	for _, level := range severities.Levels() {
+		key := strings.ReplaceAll(strings.ToLower(level), " ", "_")
+		fields = append(fields, fmt.Sprintf("%s=%d", key, counts[level]))
+	}
+
+	verdict := "pass"
+	if r.Error != "" || len(r.FailedRepositories) > 0 {
+		verdict = "error"
+	} else if r.Failed() {
+		verdict = "fail"
+	}
+
+	report := r.ReportPath
+	if report == "" {
+		report = "none"
+	}
+
+	fields = append(fields, fmt.Sprintf("files=%d", r.Files))
+	if len(r.FailedRepositories) > 0 {
+		fields = append(fields, fmt.Sprintf("failed_repositories=%d", len(r.FailedRepositories)))
+	}
+	fields = append(fields, "verdict="+verdict, "report="+report)
+	return strings.Join(fields, " ")
+}
+
+// Failed reports whether an issue is at least as severe as FailOn, any issue when it is empty
+func (r ReviewResult) Failed() bool {
+	if r.FailOn == "" {
+		return len(r.Issues) > 0
+	}
+	severities := r.severities
+	if severities == nil {
+		severities = severity.Default()
+	}
+	for _, issue := range r.Issues {
+		if severities.Rank(issue.Severity) >= severities.Rank(r.FailOn) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompactLines formats every issue as a file:line:col: severity: message line for editor
+// problem matchers and reviewdog. The most severe level is reported as error, the least
+// severe as info and the others as warning. Issues have no column, 1 is used.
+func (r ReviewResult) CompactLines() string {
+	severities := r.severities
+	if severities == nil {
+		severities = severity.Default()
+	}
+
+	var builder strings.Builder
+	for _, issue := range r.Issues {
+		line := max(issue.StartLine, 1)
+		message := strings.Join(strings.Fields(issue.Description), " ")
+		fmt.Fprintf(&builder, "%s:%d:1: %s: %s\n", issue.FilePath, line, problemLevel(severities, issue.Severity), message)
+	}
+	return builder.String()
+}
+
+// problemLevel maps a severity onto the error, warning and info levels of editors and
+// annotation tools
+func problemLevel(severities *severity.Registry, issueSeverity string) string {
+	switch rank := severities.Rank(issueSeverity); {
+	case rank == len(severities.Levels()):
+		return "error"
+	case rank == 1:
+		return "info"
+	default:
+		return "warning"
+	}
+}
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestReviewResult_SummaryLine(t *testing.T) {
+	custom, err := severity.New(config.SeverityConfig{
+		Levels:  []config.SeverityLevel{{Name: "BLOCKER"}, {Name: "NIT PICK"}},
+		Mapping: map[string]string{"CRITICAL": "BLOCKER", "WARNING": "BLOCKER", "MINOR": "NIT PICK"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create severities: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		result   ReviewResult
+		expected string
+	}{
+		{
+			name:     "passed review",
+			result:   ReviewResult{Files: 2},
+			expected: "DIFFPECTOR_RESULT critical=0 warning=0 minor=0 files=2 verdict=pass report=none",
+		},
+		{
+			name: "issues found",
+			result: ReviewResult{
+				Files:      5,
+				Issues:     []types.Issue{{Severity: "CRITICAL"}, {Severity: "WARNING"}, {Severity: "high"}, {Severity: "MINOR"}},
+				ReportPath: "diffpector_report.md",
+			},
+			expected: "DIFFPECTOR_RESULT critical=2 warning=1 minor=1 files=5 verdict=fail report=diffpector_report.md",
+		},
+		{
+			name: "custom severities",
+			result: ReviewResult{
+				Files:      1,
+				Issues:     []types.Issue{{Severity: "WARNING"}, {Severity: "MINOR"}},
+				ReportPath: "diffpector_report.md",
+				severities: custom,
+			},
+			expected: "DIFFPECTOR_RESULT blocker=1 nit_pick=1 files=1 verdict=fail report=diffpector_report.md",
+		},
+		{
+			name: "issues below the severity gate",
+			result: ReviewResult{
+				Files:  3,
+				Issues: []types.Issue{{Severity: "WARNING"}, {Severity: "MINOR"}},
+				FailOn: "CRITICAL",
+			},
+			expected: "DIFFPECTOR_RESULT critical=0 warning=1 minor=1 files=3 verdict=pass report=none",
+		},
+		{
+			name: "issue at the severity gate",
+			result: ReviewResult{
+				Files:  3,
+				Issues: []types.Issue{{Severity: "WARNING"}, {Severity: "MINOR"}},
+				FailOn: "WARNING",
+			},
+			expected: "DIFFPECTOR_RESULT critical=0 warning=1 minor=1 files=3 verdict=fail report=none",
+		},
+		{
+			name: "failed repository",
+			result: ReviewResult{
+				Files:              4,
+				Issues:             []types.Issue{{Severity: "CRITICAL"}},
+				FailedRepositories: []string{"svc-b"},
+				ReportPath:         "diffpector_report.md",
+			},
+			expected: "DIFFPECTOR_RESULT critical=1 warning=0 minor=0 files=4 failed_repositories=1 verdict=error report=diffpector_report.md",
+		},
+		{
+			name: "failed review",
+			result: ReviewResult{
+				Files:  2,
+				Issues: []types.Issue{{Severity: "MINOR"}},
+				Error:  "model not found",
+			},
+			expected: "DIFFPECTOR_RESULT critical=0 warning=0 minor=1 files=2 verdict=error report=none",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.SummaryLine(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReviewResult_CompactLines(t *testing.T) {
+	result := ReviewResult{Issues: []types.Issue{
+		{Severity: "CRITICAL", FilePath: "api/handler.go", StartLine: 12, Description: "SQL built from\n  user input"},
+		{Severity: "WARNING", FilePath: "api/handler.go", StartLine: 30, Description: "Error ignored"},
+		{Severity: "MINOR", FilePath: "README.md", Description: "Typo"},
+		{Severity: "UNKNOWN", FilePath: "main.go", StartLine: 3, Description: "Odd"},
+	}}
+
+	expected := "api/handler.go:12:1: error: SQL built from user input\n" +
+		"api/handler.go:30:1: warning: Error ignored\n" +
+		"README.md:1:1: info: Typo\n" +
+		"main.go:3:1: warning: Odd\n"
+	if got := result.CompactLines(); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	if got := (ReviewResult{}).CompactLines(); got != "" {
+		t.Errorf("Expected no lines without issues, got %q", got)
+	}
+}
@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// llmCategory is the sampling category of the model's findings, which have no check category
+const llmCategory = "llm"
+
+// patternWords is the number of description words identifying a repeated pattern
+const patternWords = 6
+
+// maxListedFiles bounds the files listed in an aggregated entry
+const maxListedFiles = 10
+
+// SampleIssues keeps the first issues of every repeated pattern of a sampled severity and
+// replaces the rest with one aggregated issue listing their count and files. The input order
+// is preserved, so issues should be sorted first for the most relevant ones to be kept.
+func SampleIssues(issues []types.Issue, samplingConfig config.SamplingConfig, severities *severity.Registry) []types.Issue {
+	type pattern struct {
+		kept       int
+		aggregated []types.Issue
+		index      int
+	}
+
+	patterns := make(map[string]*pattern)
+	var order []string
+	sampled := make([]types.Issue, 0, len(issues))
+
+	for _, issue := range issues {
+		keep, ok := sampleLimit(issue, samplingConfig, severities)
+		if !ok {
+			sampled = append(sampled, issue)
+			continue
+		}
+
+		key := patternKey(issue, severities)
+		p, seen := patterns[key]
+		if !seen {
+			p = &pattern{}
+			patterns[key] = p
+		}
+
+		if p.kept < keep {
+			p.kept++
+			sampled = append(sampled, issue)
+			continue
+		}
+
+		if len(p.aggregated) == 0 {
+			// Reserve the position of the aggregated entry right after the kept issues
+			p.index = len(sampled)
+			sampled = append(sampled, types.Issue{})
+			order = append(order, key)
+		}
+		p.aggregated = append(p.aggregated, issue)
+	}
+
+	for _, key := range order {
+		p := patterns[key]
+		sampled[p.index] = aggregateIssues(p.aggregated)
+	}
+
+	return sampled
+}
+
+// sampleLimit returns how many similar issues to keep, false when the issue is not sampled
+func sampleLimit(issue types.Issue, samplingConfig config.SamplingConfig, severities *severity.Registry) (int, bool) {
+	keep := samplingConfig.Keep
+	category := issue.Category
+	if category == "" {
+		category = llmCategory
+	}
+	if override, ok := samplingConfig.Categories[category]; ok {
+		keep = override
+	}
+	if keep <= 0 {
+		return 0, false
+	}
+
+	level := severities.Normalize(issue.Severity)
+	for _, sampledSeverity := range samplingConfig.Severities {
+		if severities.Normalize(sampledSeverity) == level {
+			return keep, true
+		}
+	}
+	return 0, false
+}
+
+// patternKey identifies similar issues by severity, category and the leading words of the
+// description, ignoring quoted code and identifiers so "missing comment on Foo" and
+// "missing comment on Bar" match.
+func patternKey(issue types.Issue, severities *severity.Registry) string {
+	var words []string
+	for i, word := range strings.Fields(stripQuoted(issue.Description)) {
+		if i > 0 && looksLikeIdentifier(word) {
+			continue
+		}
+		word = strings.ToLower(strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }))
+		if word == "" {
+			continue
+		}
+		words = append(words, word)
+		if len(words) == patternWords {
+			break
+		}
+	}
+	return severities.Normalize(issue.Severity) + "|" + issue.Category + "|" + strings.Join(words, " ")
+}
+
+// stripQuoted removes the text between backticks and quotes
+func stripQuoted(description string) string {
+	var stripped strings.Builder
+	var quote rune
+	for _, r := range description {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '`' || r == '"' || r == '\'':
+			quote = r
+		default:
+			stripped.WriteRune(r)
+		}
+	}
+	return stripped.String()
+}
+
+func looksLikeIdentifier(word string) bool {
+	hasUpper := false
+	for _, r := range word {
+		if unicode.IsDigit(r) || r == '_' || r == '.' || r == '(' {
+			return true
+		}
+		if unicode.IsUpper(r) {
+			hasUpper = true
+		}
+	}
+	return hasUpper
+}
+
+// aggregateIssues summarizes similar issues in one entry located at the first of them
+func aggregateIssues(issues []types.Issue) types.Issue {
+	var files []string
+	seen := make(map[string]bool)
+	for _, issue := range issues {
+		if !seen[issue.FilePath] {
+			seen[issue.FilePath] = true
+			files = append(files, issue.FilePath)
+		}
+	}
+
+	listed := files
+	if len(listed) > maxListedFiles {
+		listed = listed[:maxListedFiles]
+	}
+	fileList := strings.Join(listed, ", ")
+	if len(files) > len(listed) {
+		fileList += fmt.Sprintf(" and %d more", len(files)-len(listed))
+	}
+
+	aggregated := issues[0]
+	aggregated.Description = fmt.Sprintf("%d more similar issue(s): %s (files: %s)", len(issues), issues[0].Description, fileList)
+	aggregated.CodeSnippet = ""
+	return aggregated
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func minorIssue(file, function string) types.Issue {
+	return types.Issue{Severity: "MINOR", FilePath: file, StartLine: 1, EndLine: 1, Description: fmt.Sprintf("Missing doc comment on exported function %s", function)}
+}
+
+func TestSampleIssues_AggregatesRepeatedPatterns(t *testing.T) {
+	var issues []types.Issue
+	for i := 0; i < 6; i++ {
+		issues = append(issues, minorIssue(fmt.Sprintf("file%d.go", i%4), fmt.Sprintf("Handler%d", i)))
+	}
+	issues = append(issues, types.Issue{Severity: "MINOR", FilePath: "db.go", Description: "Unused variable `rows`"})
+
+	sampled := SampleIssues(issues, config.SamplingConfig{Severities: []string{"MINOR"}, Keep: 2}, severity.Default())
+
+	if len(sampled) != 4 {
+		t.Fatalf("Expected 2 kept, 1 aggregated and 1 unrelated issue, got %d: %v", len(sampled), sampled)
+	}
+	aggregated := sampled[2]
+	if !strings.HasPrefix(aggregated.Description, "4 more similar issue(s): Missing doc comment on exported function Handler2") {
+		t.Errorf("Unexpected aggregated description: %s", aggregated.Description)
+	}
+	if !strings.Contains(aggregated.Description, "(files: file2.go, file3.go, file0.go, file1.go)") {
+		t.Errorf("Expected the aggregated files to be listed, got: %s", aggregated.Description)
+	}
+	if sampled[3].FilePath != "db.go" {
+		t.Errorf("Expected the unrelated issue to be kept, got %v", sampled[3])
+	}
+}
+
+func TestSampleIssues_OnlySampledSeverities(t *testing.T) {
+	var issues []types.Issue
+	for i := 0; i < 5; i++ {
+		issue := minorIssue("main.go", fmt.Sprintf("F%d", i))
+		issue.Severity = "CRITICAL"
+		issues = append(issues, issue)
+	}
+
+	sampled := SampleIssues(issues, config.SamplingConfig{Severities: []string{"MINOR"}, Keep: 1}, severity.Default())
+	if len(sampled) != 5 {
+		t.Errorf("Expected critical issues not to be sampled, got %d", len(sampled))
+	}
+}
+
+func TestSampleIssues_CategoryOverride(t *testing.T) {
+	var issues []types.Issue
+	for i := 0; i < 4; i++ {
+		issue := minorIssue("main.go", fmt.Sprintf("F%d", i))
+		issue.Category = "doc_drift"
+		issues = append(issues, issue)
+		issues = append(issues, minorIssue("main.go", fmt.Sprintf("G%d", i)))
+	}
+
+	samplingConfig := config.SamplingConfig{
+		Severities: []string{"MINOR"},
+		Keep:       1,
+		Categories: map[string]int{"doc_drift": 0, "llm": 2},
+	}
+	sampled := SampleIssues(issues, samplingConfig, severity.Default())
+
+	checks, aggregated := 0, 0
+	for _, issue := range sampled {
+		if issue.Category == "doc_drift" {
+			checks++
+		} else if strings.Contains(issue.Description, "more similar issue(s)") {
+			aggregated++
+		}
+	}
+	if checks != 4 {
+		t.Errorf("Expected sampling disabled for doc_drift, got %d issues", checks)
+	}
+	if len(sampled) != 7 || aggregated != 1 {
+		t.Errorf("Expected 2 kept and 1 aggregated model findings, got %v", sampled)
+	}
+}
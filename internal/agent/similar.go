@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// annotateSimilarCode adds the existing code the added lines of every file nearly duplicate
+// when the similar_code tool is registered
+func (a *CodeReviewAgent) annotateSimilarCode(ctx context.Context, diffMap map[string]types.DiffData) {
+	similarCodeTool, ok := a.toolRegistry.GetAll()[tools.ToolNameSimilarCode]
+	if !ok {
+		return
+	}
+
+	for filePath, diffData := range diffMap {
+		result, err := similarCodeTool.Execute(ctx, map[string]any{"file_path": filePath, "diff": diffData.Diff})
+		if err != nil {
+			fmt.Printf("[!] No duplicate detection for %s: %v\n", filePath, err)
+			continue
+		}
+		if similar, ok := result.([]types.SimilarCode); ok && len(similar) > 0 {
+			diffData.SimilarCode = similar
+			diffMap[filePath] = diffData
+		}
+	}
+}
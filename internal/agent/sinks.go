@@ -0,0 +1,293 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/integrations/slack"
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+const (
+	SinkFile    = "file"
+	SinkStdout  = "stdout"
+	SinkWebhook = "webhook"
+	SinkSlack   = "slack"
+)
+
+const (
+	reportFileName = "diffpector_report.md"
+	webhookTimeout = 30 * time.Second
+	// slackTopIssues bounds the issues listed in a Slack summary
+	slackTopIssues = 5
+)
+
+// Report is the final output of a review, handed to every configured sink
+type Report struct {
+	Markdown  string           `json:"markdown"`
+	Summary   string           `json:"summary"`
+	Passed    bool             `json:"passed"`
+	Counts    map[string]int   `json:"counts"`
+	Issues    []types.Issue    `json:"issues"`
+	Questions []types.Question `json:"questions,omitempty"`
+	// HTML is the page written by the file sink instead of the markdown with the html format
+	HTML string `json:"-"`
+}
+
+// ReportSink delivers the final report to one destination
+type ReportSink interface {
+	Name() string
+	// Write delivers the report and returns the path of the written file, empty when the
+	// sink does not write one
+	Write(report Report) (string, error)
+}
+
+// newReportSinks creates the sinks listed in the report configuration, the markdown file
+// when none are. Unknown or incomplete sinks are skipped with a warning.
+func newReportSinks(reportConfig config.ReportConfig, writeTool tools.Tool, severities *severity.Registry) []ReportSink {
+	names := reportConfig.Sinks
+	if len(names) == 0 {
+		names = []string{SinkFile}
+	}
+
+	if format := reportConfig.Format; format != "" && format != FormatMarkdown && format != FormatHTML {
+		fmt.Printf("WARNING: Unknown report format '%s', writing markdown\n", format)
+	}
+
+	var sinks []ReportSink
+	for _, name := range names {
+		switch name {
+		case SinkFile:
+			sinks = append(sinks, newFileSink(reportConfig, writeTool))
+		case SinkStdout:
+			sinks = append(sinks, &stdoutSink{})
+		case SinkWebhook:
+			if reportConfig.WebhookURL == "" {
+				fmt.Println("WARNING: The webhook report sink requires report.webhook_url, skipping it")
+				continue
+			}
+			sinks = append(sinks, newWebhookSink(reportConfig.WebhookURL))
+		case SinkSlack:
+			client, err := slack.NewClient(reportConfig.Slack)
+			if err != nil {
+				fmt.Printf("WARNING: The slack report sink is misconfigured, skipping it: %v\n", err)
+				continue
+			}
+			sinks = append(sinks, &slackSink{client: client, config: reportConfig.Slack, severities: severities})
+		default:
+			fmt.Printf("WARNING: Unknown report sink '%s', skipping it\n", name)
+		}
+	}
+	return sinks
+}
+
+// fileSink writes the markdown report with the write tool, or the HTML page to htmlPath when
+// it is set and the page could be rendered
+type fileSink struct {
+	writeTool tools.Tool
+	path      string
+	htmlPath  string
+}
+
+// newFileSink writes the report to report.path, or to the default name of the format
+func newFileSink(reportConfig config.ReportConfig, writeTool tools.Tool) *fileSink {
+	sink := &fileSink{writeTool: writeTool, path: reportFileName}
+	if reportConfig.Format == FormatHTML {
+		sink.htmlPath = htmlReportFileName
+	}
+	if reportConfig.Path != "" {
+		sink.path = reportConfig.Path
+		if sink.htmlPath != "" {
+			sink.htmlPath = reportConfig.Path
+		}
+	}
+	return sink
+}
+
+func (s *fileSink) Name() string { return SinkFile }
+
+func (s *fileSink) Write(report Report) (string, error) {
+	path, content := s.path, report.Markdown
+	if s.htmlPath != "" && report.HTML != "" {
+		path, content = s.htmlPath, report.HTML
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create the report directory: %w", err)
+		}
+	}
+	if err := platform.IgnoreStateDir(path); err != nil {
+		fmt.Printf("[!] Could not ignore %s: %v\n", platform.StateDir, err)
+	}
+	writeArgs := map[string]any{
+		"filename": path,
+		"content":  content,
+	}
+	if _, err := s.writeTool.Execute(context.Background(), writeArgs); err != nil {
+		return "", err
+	}
+
+	fmt.Println()
+	fmt.Printf("Detailed report saved to %s\n", path)
+	return path, nil
+}
+
+// stdoutSink prints the markdown report
+type stdoutSink struct{}
+
+func (s *stdoutSink) Name() string { return SinkStdout }
+
+func (s *stdoutSink) Write(report Report) (string, error) {
+	fmt.Println()
+	fmt.Print(report.Markdown)
+	return "", nil
+}
+
+// webhookSink POSTs the report as JSON, e.g. to a Slack or Teams bridge
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func newWebhookSink(url string) *webhookSink {
+	return &webhookSink{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (s *webhookSink) Name() string { return SinkWebhook }
+
+func (s *webhookSink) Write(report Report) (string, error) {
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to post report: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("webhook returned %s: %s", resp.Status, string(body))
+	}
+
+	fmt.Println()
+	fmt.Println("Report posted to the webhook")
+	return "", nil
+}
+
+// slackSink posts a summary of the review: the counts per severity and the most severe issues
+type slackSink struct {
+	client     *slack.Client
+	config     config.SlackConfig
+	severities *severity.Registry
+}
+
+func (s *slackSink) Name() string { return SinkSlack }
+
+func (s *slackSink) Write(report Report) (string, error) {
+	if !s.shouldNotify(report.Issues) {
+		return "", nil
+	}
+
+	if err := s.client.Post(s.summaryMessage(report)); err != nil {
+		return "", err
+	}
+
+	fmt.Println()
+	fmt.Println("Review summary posted to Slack")
+	return "", nil
+}
+
+// shouldNotify reports whether an issue reaches the configured minimum severity
+func (s *slackSink) shouldNotify(issues []types.Issue) bool {
+	if s.config.MinSeverity == "" {
+		return true
+	}
+
+	threshold := s.severities.Rank(s.config.MinSeverity)
+	for _, issue := range issues {
+		if s.severities.Rank(issue.Severity) >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *slackSink) summaryMessage(report Report) slack.Message {
+	headline := "Diffpector review passed"
+	if !report.Passed {
+		headline = "Diffpector review didn't pass"
+	}
+
+	var counts []string
+	for _, level := range s.severities.Levels() {
+		counts = append(counts, fmt.Sprintf("%s %d %s", s.severities.Icon(level), report.Counts[level], strings.ToLower(level)))
+	}
+
+	blocks := []slack.Block{
+		slack.Header(headline),
+		slack.Section(strings.Join(counts, "   ")),
+	}
+
+	top := s.topIssues(report.Issues)
+	if len(top) > 0 {
+		var lines []string
+		for _, issue := range top {
+			lines = append(lines, fmt.Sprintf("%s %s %s", s.severities.Icon(issue.Severity), s.client.Location(issue.FilePath, issue.StartLine), issue.Description))
+		}
+		if more := s.countAtRank(report.Issues, s.severities.Rank(top[0].Severity)) - len(top); more > 0 {
+			lines = append(lines, fmt.Sprintf("_and %d more_", more))
+		}
+		blocks = append(blocks, slack.Section(strings.Join(lines, "\n")))
+	}
+
+	return slack.Message{
+		Text:   fmt.Sprintf("%s: %s", headline, report.Summary),
+		Blocks: blocks,
+	}
+}
+
+// topIssues returns the first issues of the most severe level found
+func (s *slackSink) topIssues(issues []types.Issue) []types.Issue {
+	sorted := SortIssues(issues, s.severities)
+	if len(sorted) == 0 {
+		return nil
+	}
+
+	rank := s.severities.Rank(sorted[0].Severity)
+	var top []types.Issue
+	for _, issue := range sorted {
+		if s.severities.Rank(issue.Severity) != rank || len(top) == slackTopIssues {
+			break
+		}
+		top = append(top, issue)
+	}
+	return top
+}
+
+func (s *slackSink) countAtRank(issues []types.Issue, rank int) int {
+	count := 0
+	for _, issue := range issues {
+		if s.severities.Rank(issue.Severity) == rank {
+			count++
+		}
+	}
+	return count
+}
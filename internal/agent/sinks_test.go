@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestReportGenerator_WritesToEverySink(t *testing.T) {
+	var received Report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON payload, got %s", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode webhook payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: "line1\nline2\n"}
+	reportConfig := config.ReportConfig{
+		Sinks:      []string{SinkFile, SinkStdout, SinkWebhook},
+		WebhookURL: server.URL,
+	}
+	reportGen := NewReportGenerator(readTool, writeTool, reportConfig, severity.Default())
+
+	path := reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "CRITICAL", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Nil dereference"},
+	})
+
+	if path != reportFileName {
+		t.Errorf("Expected the report path of the file sink, got %q", path)
+	}
+	if writeTool.written[reportFileName] == "" {
+		t.Error("Expected the markdown report to be written")
+	}
+	if received.Markdown != writeTool.written[reportFileName] {
+		t.Error("Expected the webhook to receive the same markdown as the file")
+	}
+	if received.Passed || received.Counts["CRITICAL"] != 1 || len(received.Issues) != 1 {
+		t.Errorf("Unexpected webhook payload: %+v", received)
+	}
+}
+
+func TestReportGenerator_FailingSinkDoesNotStopOthers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bridge down", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	writeTool := &captureWriteTool{}
+	reportConfig := config.ReportConfig{
+		Sinks:      []string{SinkWebhook, SinkFile},
+		WebhookURL: server.URL,
+	}
+	reportGen := NewReportGenerator(&stubReadTool{content: "line\n"}, writeTool, reportConfig, severity.Default())
+
+	if path := reportGen.GenerateMarkdownReport(nil); path != reportFileName {
+		t.Errorf("Expected the file to be written after the webhook failed, got %q", path)
+	}
+}
+
+func TestFileSink_ConfiguredPath(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTool := &captureWriteTool{}
+	reportGen := NewReportGenerator(&stubReadTool{content: "line\n"}, writeTool, config.ReportConfig{Path: ".diffpector/review.md"}, severity.Default())
+
+	if path := reportGen.GenerateMarkdownReport(nil); path != ".diffpector/review.md" {
+		t.Fatalf("Expected the configured report path, got %q", path)
+	}
+	if _, ok := writeTool.written[".diffpector/review.md"]; !ok {
+		t.Error("Expected the report to be written to the configured path")
+	}
+	if content, err := os.ReadFile(".diffpector/.gitignore"); err != nil || string(content) != "*\n" {
+		t.Errorf("Expected the .diffpector directory to ignore itself, got %q, %v", content, err)
+	}
+}
+
+func TestNewReportSinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   config.ReportConfig
+		expected []string
+	}{
+		{"defaults to file", config.ReportConfig{}, []string{SinkFile}},
+		{"skips unknown sinks", config.ReportConfig{Sinks: []string{"stdout", "email"}}, []string{SinkStdout}},
+		{"skips webhook without url", config.ReportConfig{Sinks: []string{"file", "webhook"}}, []string{SinkFile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sinks := newReportSinks(tt.config, &captureWriteTool{}, severity.Default())
+			if len(sinks) != len(tt.expected) {
+				t.Fatalf("Expected %d sinks, got %d", len(tt.expected), len(sinks))
+			}
+			for i, sink := range sinks {
+				if sink.Name() != tt.expected[i] {
+					t.Errorf("Sink %d: expected %s, got %s", i, tt.expected[i], sink.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestSlackSink_NotifiesAboveThreshold(t *testing.T) {
+	var messages []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message map[string]any
+		json.NewDecoder(r.Body).Decode(&message)
+		messages = append(messages, message)
+	}))
+	defer server.Close()
+
+	reportConfig := config.ReportConfig{
+		Sinks: []string{SinkSlack},
+		Slack: config.SlackConfig{WebhookURL: server.URL, MinSeverity: "WARNING"},
+	}
+	readTool := &stubReadTool{content: "1\n2\n3\n4\n5\n6\n7\n8\n"}
+
+	minorOnly := []types.Issue{{Severity: "MINOR", FilePath: "a.go", StartLine: 1, EndLine: 1, Description: "Typo"}}
+	NewReportGenerator(readTool, &captureWriteTool{}, reportConfig, severity.Default()).GenerateMarkdownReport(minorOnly)
+	if len(messages) != 0 {
+		t.Fatalf("Expected no notification below the threshold, got %v", messages)
+	}
+
+	var issues []types.Issue
+	for line := 1; line <= 7; line++ {
+		issues = append(issues, types.Issue{Severity: "CRITICAL", FilePath: "a.go", StartLine: line, EndLine: line, Description: "Unchecked error"})
+	}
+	issues = append(issues, minorOnly...)
+	NewReportGenerator(readTool, &captureWriteTool{}, reportConfig, severity.Default()).GenerateMarkdownReport(issues)
+	if len(messages) != 1 {
+		t.Fatalf("Expected one notification, got %d", len(messages))
+	}
+
+	payload, _ := json.Marshal(messages[0])
+	for _, expected := range []string{"Diffpector review didn't pass", "🔴 7 critical", "`a.go:5` Unchecked error", "_and 2 more_"} {
+		if !strings.Contains(string(payload), expected) {
+			t.Errorf("Expected %q in the summary, got %s", expected, payload)
+		}
+	}
+	if strings.Contains(string(payload), "Typo") {
+		t.Errorf("Expected only the most severe issues to be listed, got %s", payload)
+	}
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/prompts"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/spinner"
+)
+
+// summaryDiffLines bounds the lines of each file diff the summary is written from, so that
+// the diffs of large changesets fit the context of the model
+const summaryDiffLines = 200
+
+// SetChangesetSummary summarizes the whole changeset before reviewing its files, giving the
+// summary to the review of each file and adding it to the report
+func (a *CodeReviewAgent) SetChangesetSummary(enabled bool) {
+	a.summarize = enabled
+}
+
+// summarizeChanges writes the summary of the changeset from the diffs of all its files. The
+// files are reviewed without it when it fails.
+func (a *CodeReviewAgent) summarizeChanges(ctx context.Context, diffMap map[string]types.DiffData) {
+	a.summary = ""
+	if !a.summarize || len(diffMap) == 0 || ctx.Err() != nil {
+		return
+	}
+
+	diffs := make(map[string]types.DiffData, len(diffMap))
+	for path, data := range diffMap {
+		diff := data.Diff
+		if lines := strings.Split(diff, "\n"); len(lines) > summaryDiffLines {
+			diff = strings.Join(lines[:summaryDiffLines], "\n") + fmt.Sprintf("\n... %d more lines\n", len(lines)-summaryDiffLines)
+		}
+		diffs[path] = types.DiffData{Diff: diff, SymbolMoves: data.SymbolMoves}
+	}
+
+	prompt, err := prompts.BuildSummaryPrompt(a.payload(diffs))
+	if err != nil {
+		fmt.Printf("[!] Reviewing the files without a changeset summary: %v\n", err)
+		return
+	}
+
+	summarySpinner := spinner.New("Summarizing changes...")
+	summarySpinner.Start()
+	summary, err := a.llmProvider.Generate(ctx, prompt)
+	summarySpinner.Stop()
+	if err == nil && strings.TrimSpace(summary) == "" {
+		err = fmt.Errorf("LLM returned an empty summary")
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Printf("[!] Reviewing the files without a changeset summary: %v\n", err)
+			slog.Warn("changeset summary failed", "error", err)
+		}
+		return
+	}
+
+	// The prompts keep the summary as the model wrote it, anonymized like the code it saw
+	a.summary = stripCodeFence(summary)
+	a.result.Summary = a.restore(a.summary)
+	fmt.Println("[i] Summarized the changeset for the review of each file")
+}
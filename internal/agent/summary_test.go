@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/llm"
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// summaryProvider summarizes the changeset and records the review prompts, approving them
+type summaryProvider struct {
+	unreachableProvider
+	summaryPrompt *string
+	reviewPrompts *[]string
+}
+
+func (p summaryProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	*p.summaryPrompt = prompt
+	return "```markdown\n**What changed:** Add renames Sum\n```", nil
+}
+
+func (p summaryProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, tools []llm.Tool, schema *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	*p.reviewPrompts = append(*p.reviewPrompts, messages[0].Content)
+	return &llm.ChatResponse{Content: "APPROVED"}, nil
+}
+
+func TestChangesetSummary(t *testing.T) {
+	var summaryPrompt string
+	var reviewPrompts []string
+	registry := tools.NewToolRegistry()
+	registry.Register(tools.ToolNameHumanLoop, &tools.HumanLoopTool{})
+	agent := NewCodeReviewAgent(summaryProvider{unreachableProvider{t: t}, &summaryPrompt, &reviewPrompts}, tools.NewParserRegistry(), registry, "optimized")
+	if err := agent.SetContextStrategy(ContextStrategyHunksOnly); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	agent.SetChangesetSummary(true)
+	diffMap := map[string]types.DiffData{
+		"notes.txt": {Diff: strings.Repeat("+line\n", summaryDiffLines+10)},
+		"todo.txt":  {Diff: "+renamed Sum"},
+	}
+
+	agent.collectIssues(context.Background(), diffMap, "go")
+
+	if !strings.Contains(summaryPrompt, "... 11 more lines") || !strings.Contains(summaryPrompt, "+renamed Sum") {
+		t.Errorf("Expected the summary to see every diff, the long one truncated, got:\n%s", summaryPrompt)
+	}
+	if agent.result.Summary != "**What changed:** Add renames Sum" {
+		t.Errorf("Expected the summary without its code fence, got %q", agent.result.Summary)
+	}
+	if len(reviewPrompts) != 2 {
+		t.Fatalf("Expected a review per file, got %d", len(reviewPrompts))
+	}
+	for _, prompt := range reviewPrompts {
+		if !strings.Contains(prompt, "=== CHANGESET SUMMARY ===") || !strings.Contains(prompt, "Add renames Sum") {
+			t.Errorf("Expected the summary in the review prompt, got:\n%s", prompt)
+		}
+	}
+}
+
+func TestReportShowsSummary(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	reportGen := NewReportGenerator(&stubReadTool{content: "line\n"}, writeTool, config.ReportConfig{}, severity.Default())
+	reportGen.SetSummary("**What changed:** Add renames Sum")
+
+	reportGen.GenerateMarkdownReport([]types.Issue{{Severity: "MINOR", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Typo"}})
+
+	report := writeTool.written["diffpector_report.md"]
+	if !strings.HasPrefix(report, "# Code Review Report\n\n## Summary of Changes\n\n**What changed:** Add renames Sum\n") {
+		t.Errorf("Expected the summary above the issues, got:\n%s", report)
+	}
+}
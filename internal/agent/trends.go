@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/agusespa/diffpector/internal/trends"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/usage"
+)
+
+// SetHistory records a summary of every review in the store, read by the stats command.
+// The metadata gives the commit and branch reviewed, the model and the duration are filled
+// in by the agent. The resources measured by the recorder, when not nil, are recorded too.
+func (a *CodeReviewAgent) SetHistory(store *trends.Store, metadata ReportMetadata, recorder *usage.Recorder) {
+	a.history = store
+	a.reportMetadata = metadata
+	a.recorder = recorder
+}
+
+// recordRun appends the review to the history. An interrupted review is not recorded, its
+// counts would lower the trends.
+func (a *CodeReviewAgent) recordRun(issues []types.Issue) {
+	if a.history == nil || len(a.result.UnreviewedFiles) > 0 {
+		return
+	}
+
+	metadata := a.metadata()
+	run := trends.Run{
+		Time:         metadata.Date,
+		Commit:       metadata.Commit,
+		Branch:       metadata.Branch,
+		Model:        metadata.Model,
+		Files:        slices.Clone(a.result.ChangedFiles),
+		ChangedLines: a.result.ChangedLines,
+		Counts:       make(map[string]int),
+		Directories:  make(map[string]int),
+		Duration:     metadata.Duration.Milliseconds(),
+	}
+	if a.recorder != nil {
+		summary := a.recorder.Summary()
+		run.Usage = &summary
+	}
+	for _, issue := range issues {
+		run.Counts[a.severities.Normalize(issue.Severity)]++
+		run.Directories[trends.Directory(issue.FilePath)]++
+	}
+
+	if err := a.history.Record(run); err != nil {
+		fmt.Printf("[!] Review not recorded in the history: %v\n", err)
+	}
+}
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/trends"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/usage"
+)
+
+func TestRecordRun(t *testing.T) {
+	store, err := trends.Open(filepath.Join(t.TempDir(), "history.jsonl"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	agent.SetHistory(store, ReportMetadata{Commit: "abc123", Branch: "main"}, usage.NewRecorder())
+	agent.result.ChangedFiles = []string{"internal/api/handler.go", "main.go"}
+	agent.result.ChangedLines = 120
+
+	agent.recordRun([]types.Issue{
+		{Severity: "critical", FilePath: "internal/api/handler.go"},
+		{Severity: "WARNING", FilePath: "internal/api/handler.go"},
+		{Severity: "MINOR", FilePath: "main.go"},
+	})
+
+	runs, err := store.Load()
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("Expected one recorded run, got %v, %v", runs, err)
+	}
+	run := runs[0]
+	if run.Commit != "abc123" || run.Branch != "main" || run.Model != "test-model" || run.ChangedLines != 120 {
+		t.Errorf("Expected the metadata of the review, got %+v", run)
+	}
+	if !slices.Equal(run.Files, agent.result.ChangedFiles) || run.Counts["CRITICAL"] != 1 || run.Directories["internal/api"] != 2 || run.Directories["."] != 1 {
+		t.Errorf("Expected the issues counted per severity and directory, got %+v", run)
+	}
+	if run.Usage == nil || run.Usage.WallTime <= 0 {
+		t.Errorf("Expected the resource usage of the run, got %+v", run.Usage)
+	}
+
+	agent.result.UnreviewedFiles = []string{"main.go"}
+	agent.recordRun(nil)
+	if runs, _ := store.Load(); len(runs) != 1 {
+		t.Errorf("Expected an interrupted review not to be recorded, got %d runs", len(runs))
+	}
+}
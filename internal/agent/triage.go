@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/llm"
+	"github.com/agusespa/diffpector/internal/prompts"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/spinner"
+)
+
+// TriageVerdictTrivial is the verdict of the triage model for diffs not worth a deep review
+const TriageVerdictTrivial = "trivial"
+
+// SetTriageProvider has a fast model classify the diff of every file before the review.
+// Only the diffs it does not find trivial are reviewed by the review model.
+func (a *CodeReviewAgent) SetTriageProvider(provider llm.Provider) {
+	a.triageProvider = provider
+}
+
+func (a *CodeReviewAgent) triageModel() string {
+	if a.triageProvider == nil {
+		return ""
+	}
+	return a.triageProvider.GetModel()
+}
+
+// triage asks the triage model whether the diff of a file is trivial, returning its reason.
+// Files the triage model fails to classify are reviewed.
+func (a *CodeReviewAgent) triage(ctx context.Context, filePath string, diffData types.DiffData) (bool, string) {
+	if a.triageProvider == nil {
+		return false, ""
+	}
+
+	prompt, err := prompts.BuildTriagePrompt(prompts.TriageInput{
+		FilePath: filePath,
+		Changes:  a.payload(map[string]types.DiffData{filePath: {Diff: diffData.Diff}}),
+	})
+	if err != nil {
+		slog.Warn("failed to build triage prompt", "file", filePath, "error", err)
+		return false, ""
+	}
+
+	triageSpinner := spinner.New("Triaging changes...")
+	triageSpinner.Start()
+	response, err := a.triageProvider.Generate(ctx, prompt)
+	triageSpinner.Stop()
+	if err == nil {
+		var answer verification
+		answer, err = parseVerification(response)
+		if err == nil {
+			return strings.EqualFold(strings.TrimSpace(answer.Verdict), TriageVerdictTrivial), a.restore(answer.Reason)
+		}
+	}
+
+	if ctx.Err() == nil {
+		fmt.Printf("  [!] Triage failed, reviewing the file: %v\n", err)
+		slog.Warn("triage failed", "file", filePath, "error", err)
+	}
+	return false, ""
+}
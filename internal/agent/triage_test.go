@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// triageProvider finds the diffs touching only comments trivial and fails on the ones
+// containing fail
+type triageProvider struct {
+	unreachableProvider
+}
+
+func (p triageProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	switch {
+	case strings.Contains(prompt, "+// "):
+		return `{"verdict": "trivial", "reason": "comment edit"}`, nil
+	case strings.Contains(prompt, "fail"):
+		return "", fmt.Errorf("model unavailable")
+	default:
+		return "Sure:\n{\"verdict\": \"review\", \"reason\": \"changes a condition\"}", nil
+	}
+}
+
+func TestTriage(t *testing.T) {
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+
+	if trivial, _ := agent.triage(context.Background(), "main.go", types.DiffData{Diff: "+// comment"}); trivial {
+		t.Error("Expected every file to be reviewed without a triage model")
+	}
+
+	agent.SetTriageProvider(triageProvider{unreachableProvider{t: t}})
+	tests := []struct {
+		diff    string
+		trivial bool
+	}{
+		{"+// Add returns the sum", true},
+		{"-if a > b {\n+if a >= b {", false},
+		{"+fail()", false},
+	}
+	for _, tt := range tests {
+		trivial, reason := agent.triage(context.Background(), "main.go", types.DiffData{Diff: tt.diff})
+		if trivial != tt.trivial {
+			t.Errorf("Expected %q to be triaged trivial=%v, got %v (%s)", tt.diff, tt.trivial, trivial, reason)
+		}
+	}
+}
+
+func TestCollectIssuesSkipsTrivialFiles(t *testing.T) {
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	agent.SetTriageProvider(triageProvider{unreachableProvider{t: t}})
+	diffMap := map[string]types.DiffData{"notes.txt": {Diff: "+// typo fixed"}}
+
+	issues := agent.collectIssues(context.Background(), diffMap, "go")
+
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %+v", issues)
+	}
+	if len(agent.result.TriagedFiles) != 1 || agent.result.TriagedFiles[0] != "notes.txt" {
+		t.Errorf("Expected notes.txt to be triaged, got %v", agent.result.TriagedFiles)
+	}
+}
@@ -4,25 +4,108 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/pkg/config"
 )
 
-func NotifyUserIfReportNotIgnored(gitignorePath string) error {
-	const reportFilename = "diffpector_report.md"
+// ReportPaths returns the paths the report file may be written to: the configured path, or
+// the markdown and HTML default names
+func ReportPaths(reportConfig config.ReportConfig) []string {
+	if reportConfig.Path != "" {
+		return []string{platform.NormalizePath(reportConfig.Path)}
+	}
+	return []string{reportFileName, htmlReportFileName}
+}
+
+// inStateDir reports whether the relative path is within the .diffpector directory
+func inStateDir(path string) bool {
+	return strings.HasPrefix(platform.NormalizePath(path), platform.StateDir+"/")
+}
+
+// NotifyUserIfReportNotIgnored returns an error naming the first existing report file that
+// the .gitignore file at gitignorePath does not ignore. Reports in .diffpector/ are ignored.
+func NotifyUserIfReportNotIgnored(gitignorePath string, reportPaths []string) error {
+	for _, fileName := range reportPaths {
+		if inStateDir(fileName) {
+			continue
+		}
+		if err := notifyIfNotIgnored(gitignorePath, fileName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IgnoreReports adds the report files and the .diffpector directory, where the checkpoints,
+// the history and the audit logs go, to the .gitignore file at gitignorePath, creating it if
+// needed. Entries already in it are kept as they are. It returns the entries added.
+func IgnoreReports(gitignorePath string, reportPaths []string) ([]string, error) {
+	content, err := os.ReadFile(gitignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not read .gitignore file: %w", err)
+	}
+
+	existing := strings.Split(string(content), "\n")
+	var added []string
+	var entries []string
+	for _, path := range reportPaths {
+		if !inStateDir(path) {
+			entries = append(entries, path)
+		}
+	}
+	for _, entry := range append(entries, platform.StateDir+"/") {
+		found := false
+		for _, line := range existing {
+			line = strings.TrimSpace(line)
+			if line == entry || line == "/"+entry || line == strings.TrimSuffix(entry, "/") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			added = append(added, entry)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	var addition strings.Builder
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		addition.WriteString("\n")
+	}
+	addition.WriteString("# diffpector\n")
+	for _, entry := range added {
+		addition.WriteString(entry + "\n")
+	}
+
+	file, err := os.OpenFile(gitignorePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open .gitignore file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(addition.String()); err != nil {
+		return nil, fmt.Errorf("could not write .gitignore file: %w", err)
+	}
+	return added, nil
+}
 
-	if _, err := os.Stat(reportFilename); os.IsNotExist(err) {
+func notifyIfNotIgnored(gitignorePath, fileName string) error {
+	if _, err := os.Stat(fileName); os.IsNotExist(err) {
 		return nil
 	}
 
 	content, err := os.ReadFile(gitignorePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("'%s' exists but is not in a .gitignore file", reportFilename)
+			return fmt.Errorf("'%s' exists but is not in a .gitignore file", fileName)
 		}
 		return fmt.Errorf("could not read .gitignore file: %w", err)
 	}
 
-	if !strings.Contains(string(content), reportFilename) {
-		return fmt.Errorf("'%s' exists but is not in your .gitignore file. Please consider adding it to avoid including it in the context of future analyses", reportFilename)
+	if !strings.Contains(string(content), fileName) {
+		return fmt.Errorf("'%s' exists but is not in your .gitignore file. Please consider adding it to avoid including it in the context of future analyses", fileName)
 	}
 
 	return nil
@@ -2,7 +2,11 @@ package agent
 
 import (
 	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+
+	"github.com/agusespa/diffpector/pkg/config"
 )
 
 func TestNotifyUserIfReportNotIgnored(t *testing.T) {
@@ -62,7 +66,7 @@ func TestNotifyUserIfReportNotIgnored(t *testing.T) {
 				}
 			}
 
-			err := NotifyUserIfReportNotIgnored(gitignoreFilename)
+			err := NotifyUserIfReportNotIgnored(gitignoreFilename, ReportPaths(config.ReportConfig{}))
 
 			var gotError string
 			if err != nil {
@@ -78,3 +82,56 @@ func TestNotifyUserIfReportNotIgnored(t *testing.T) {
 		})
 	}
 }
+
+func TestIgnoreReports(t *testing.T) {
+	gitignorePath := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(gitignorePath, []byte("node_modules/\n/diffpector_report.md"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := IgnoreReports(gitignorePath, ReportPaths(config.ReportConfig{}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !slices.Equal(added, []string{"diffpector_report.html", ".diffpector/"}) {
+		t.Errorf("Expected the missing entries to be added, got %v", added)
+	}
+
+	content, _ := os.ReadFile(gitignorePath)
+	expected := "node_modules/\n/diffpector_report.md\n# diffpector\ndiffpector_report.html\n.diffpector/\n"
+	if string(content) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, content)
+	}
+
+	added, err = IgnoreReports(gitignorePath, ReportPaths(config.ReportConfig{}))
+	if err != nil || len(added) != 0 {
+		t.Errorf("Expected nothing to add the second time, got %v, %v", added, err)
+	}
+
+	added, err = IgnoreReports(gitignorePath, ReportPaths(config.ReportConfig{Path: "reports/review.md"}))
+	if err != nil || !slices.Equal(added, []string{"reports/review.md"}) {
+		t.Errorf("Expected the configured report path to be added, got %v, %v", added, err)
+	}
+}
+
+func TestReportPathsInStateDir(t *testing.T) {
+	t.Chdir(t.TempDir())
+	reportPaths := ReportPaths(config.ReportConfig{Path: "./.diffpector/report.md"})
+	if !slices.Equal(reportPaths, []string{".diffpector/report.md"}) {
+		t.Fatalf("Expected the cleaned report path, got %v", reportPaths)
+	}
+	if err := os.MkdirAll(".diffpector", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(".diffpector/report.md", []byte("# report\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NotifyUserIfReportNotIgnored(".gitignore", reportPaths); err != nil {
+		t.Errorf("Expected a report in .diffpector to need no .gitignore entry, got %v", err)
+	}
+	added, err := IgnoreReports(".gitignore", reportPaths)
+	if err != nil || !slices.Equal(added, []string{".diffpector/"}) {
+		t.Errorf("Expected only the .diffpector directory to be added, got %v, %v", added, err)
+	}
+}
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/prompts"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+	"github.com/agusespa/diffpector/pkg/spinner"
+)
+
+// Actions applied to the findings rejected by the verifier
+const (
+	VerifyActionDrop      = "drop"
+	VerifyActionDowngrade = "downgrade"
+)
+
+// SetVerificationConfig configures the verifier, the second LLM pass run on the findings of
+// the model before they are reported
+func (a *CodeReviewAgent) SetVerificationConfig(verification config.VerificationConfig) error {
+	if verification.Action == "" {
+		verification.Action = VerifyActionDrop
+	}
+	if verification.Action != VerifyActionDrop && verification.Action != VerifyActionDowngrade {
+		return fmt.Errorf("unknown verification action '%s', expected %s or %s", verification.Action, VerifyActionDrop, VerifyActionDowngrade)
+	}
+
+	if verification.Prompt == "" {
+		verification.Prompt = prompts.DEFAULT_VERIFY_PROMPT
+	}
+	if _, err := prompts.GetVerifyVariant(verification.Prompt); err != nil {
+		return err
+	}
+
+	a.verification = verification
+	return nil
+}
+
+// verification is the answer of the verifier about one finding
+type verification struct {
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason"`
+}
+
+// verifyIssues asks the verifier about each finding of the model, with the changes of the
+// file they were found in. Rejected findings are dropped or downgraded depending on the
+// configured action, the count of rejections is returned. Findings the verifier could not
+// judge are kept as they are.
+func (a *CodeReviewAgent) verifyIssues(ctx context.Context, issues []types.Issue, changes string) ([]types.Issue, int) {
+	if !a.verification.Enabled || len(issues) == 0 {
+		return issues, 0
+	}
+
+	verifySpinner := spinner.New("Verifying findings...")
+	verifySpinner.Start()
+
+	var kept []types.Issue
+	rejected, failed := 0, 0
+	for _, issue := range issues {
+		answer, err := a.verifyIssue(ctx, issue, changes)
+		if err != nil {
+			failed++
+			kept = append(kept, issue)
+			continue
+		}
+		if !strings.EqualFold(strings.TrimSpace(answer.Verdict), "rejected") {
+			kept = append(kept, issue)
+			continue
+		}
+
+		rejected++
+		if a.verification.Action == VerifyActionDowngrade {
+			kept = append(kept, a.downgrade(issue, answer.Reason))
+		}
+	}
+	verifySpinner.Stop()
+
+	if failed > 0 {
+		fmt.Printf("  [!] The verifier could not judge %d finding(s), keeping them\n", failed)
+	}
+	return kept, rejected
+}
+
+func (a *CodeReviewAgent) verifyIssue(ctx context.Context, issue types.Issue, changes string) (verification, error) {
+	prompt, err := prompts.BuildVerifyPrompt(a.verification.Prompt, prompts.VerifyInput{Issue: issue, Changes: changes})
+	if err != nil {
+		return verification{}, fmt.Errorf("failed to build verify prompt: %w", err)
+	}
+
+	response, err := a.llmProvider.Generate(ctx, prompt)
+	if err != nil {
+		return verification{}, fmt.Errorf("failed to verify finding: %w", err)
+	}
+	return parseVerification(response)
+}
+
+// parseVerification reads the verdict object out of a verifier or triage answer, tolerating
+// code fences and text around it
+func parseVerification(response string) (verification, error) {
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start == -1 || end < start {
+		return verification{}, fmt.Errorf("no verdict in answer")
+	}
+
+	var answer verification
+	if err := json.Unmarshal([]byte(response[start:end+1]), &answer); err != nil {
+		return verification{}, fmt.Errorf("failed to parse verdict: %w", err)
+	}
+	if answer.Verdict == "" {
+		return verification{}, fmt.Errorf("no verdict in answer")
+	}
+	return answer, nil
+}
+
+// downgrade moves a rejected finding to the least severe level, keeping the verifier's
+// reason next to its description
+func (a *CodeReviewAgent) downgrade(issue types.Issue, reason string) types.Issue {
+	if levels := a.severities.Levels(); len(levels) > 0 {
+		issue.Severity = levels[len(levels)-1]
+	}
+	if reason = strings.TrimSpace(reason); reason != "" {
+		issue.Description = fmt.Sprintf("%s (verifier: %s)", issue.Description, reason)
+	}
+	return issue
+}
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// verdictProvider rejects the findings whose description contains reject, and fails to
+// answer about the ones containing fail
+type verdictProvider struct {
+	unreachableProvider
+}
+
+func (p verdictProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	switch {
+	case strings.Contains(prompt, "Description: reject"):
+		return "```json\n{\"verdict\": \"rejected\", \"reason\": \"pre-existing\"}\n```", nil
+	case strings.Contains(prompt, "Description: fail"):
+		return "", fmt.Errorf("model unavailable")
+	default:
+		return `{"verdict": "confirmed", "reason": "introduced by the diff"}`, nil
+	}
+}
+
+func verifierAgent(t *testing.T, action string) *CodeReviewAgent {
+	agent := NewCodeReviewAgent(verdictProvider{unreachableProvider{t: t}}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+	if err := agent.SetVerificationConfig(config.VerificationConfig{Enabled: true, Action: action}); err != nil {
+		t.Fatalf("SetVerificationConfig failed: %v", err)
+	}
+	return agent
+}
+
+func TestVerifyIssues(t *testing.T) {
+	issues := []types.Issue{
+		{Severity: "CRITICAL", FilePath: "main.go", StartLine: 1, Description: "keep"},
+		{Severity: "CRITICAL", FilePath: "main.go", StartLine: 2, Description: "reject"},
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 3, Description: "fail"},
+	}
+
+	t.Run("drop", func(t *testing.T) {
+		kept, rejected := verifierAgent(t, "").verifyIssues(context.Background(), issues, "+x := 1")
+		if rejected != 1 || len(kept) != 2 {
+			t.Fatalf("Expected 1 rejection and 2 kept findings, got %d and %+v", rejected, kept)
+		}
+		if kept[0].Description != "keep" || kept[1].Description != "fail" {
+			t.Errorf("Expected the confirmed and unjudged findings to be kept, got %+v", kept)
+		}
+	})
+
+	t.Run("downgrade", func(t *testing.T) {
+		kept, rejected := verifierAgent(t, VerifyActionDowngrade).verifyIssues(context.Background(), issues, "+x := 1")
+		if rejected != 1 || len(kept) != 3 {
+			t.Fatalf("Expected 1 rejection and 3 kept findings, got %d and %+v", rejected, kept)
+		}
+		if kept[1].Severity != "MINOR" || kept[1].Description != "reject (verifier: pre-existing)" {
+			t.Errorf("Expected the rejected finding to be downgraded, got %+v", kept[1])
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+		if kept, rejected := agent.verifyIssues(context.Background(), issues, "+x := 1"); rejected != 0 || len(kept) != len(issues) {
+			t.Errorf("Expected every finding to be kept, got %d rejections and %+v", rejected, kept)
+		}
+	})
+}
+
+func TestSetVerificationConfig(t *testing.T) {
+	agent := NewCodeReviewAgent(unreachableProvider{t: t}, tools.NewParserRegistry(), tools.NewToolRegistry(), "optimized")
+
+	if err := agent.SetVerificationConfig(config.VerificationConfig{Enabled: true, Action: "ignore"}); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+	if err := agent.SetVerificationConfig(config.VerificationConfig{Enabled: true, Prompt: "unknown"}); err == nil {
+		t.Error("Expected an error for an unknown prompt variant")
+	}
+	if err := agent.SetVerificationConfig(config.VerificationConfig{Enabled: true, Prompt: "strict"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestParseVerification(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		verdict  string
+		wantErr  bool
+	}{
+		{"plain object", `{"verdict": "confirmed", "reason": "ok"}`, "confirmed", false},
+		{"surrounded by text", "Here you go:\n{\"verdict\": \"rejected\"}\nDone.", "rejected", false},
+		{"no object", "The finding is valid", "", true},
+		{"missing verdict", `{"reason": "ok"}`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, err := parseVerification(tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if answer.Verdict != tt.verdict {
+				t.Errorf("Expected verdict %q, got %q", tt.verdict, answer.Verdict)
+			}
+		})
+	}
+}
+
+func TestReportGenerator_NotesRejectedIssues(t *testing.T) {
+	writeTool := &captureWriteTool{}
+	readTool := &stubReadTool{content: strings.Repeat("line\n", 20)}
+	reportGen := NewReportGenerator(readTool, writeTool, config.DefaultReportConfig(), severity.Default())
+	reportGen.SetRejectedIssues(3, VerifyActionDrop)
+
+	reportGen.GenerateMarkdownReport([]types.Issue{
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 1, EndLine: 1, Description: "Unchecked error", Confidence: 0.8},
+	})
+
+	if report := writeTool.written["diffpector_report.md"]; !strings.Contains(report, "_3 finding(s) dropped after the verifier rejected them_") {
+		t.Errorf("Expected the rejected findings to be noted, got:\n%s", report)
+	}
+}
@@ -0,0 +1,71 @@
+// Package analysis holds the deterministic checks that run alongside the LLM review.
+package analysis
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkChanges holds the lines a unified diff touches, in new file numbering
+type hunkChanges struct {
+	added map[int]bool
+	// removed lists the deleted lines, anchored at the new file line that follows them
+	removed []removedLine
+}
+
+type removedLine struct {
+	anchor int
+	text   string
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@\s+-\d+(?:,\d+)?\s+\+(\d+)(?:,\d+)?\s+@@`)
+
+func parseHunks(diffContent string) hunkChanges {
+	changes := hunkChanges{added: make(map[int]bool)}
+	newLine := 0
+	inHunk := false
+
+	for _, line := range strings.Split(diffContent, "\n") {
+		if matches := hunkHeaderRegex.FindStringSubmatch(line); len(matches) == 2 {
+			start, err := strconv.Atoi(matches[1])
+			if err != nil {
+				inHunk = false
+				continue
+			}
+			newLine = start
+			inHunk = true
+			continue
+		}
+		if !inHunk {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+"):
+			changes.added[newLine] = true
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			changes.removed = append(changes.removed, removedLine{anchor: newLine, text: line[1:]})
+		case strings.HasPrefix(line, " "):
+			newLine++
+		}
+	}
+
+	return changes
+}
+
+// touches reports whether the diff adds or removes lines within [start, end]
+func (c hunkChanges) touches(start, end int) bool {
+	for line := start; line <= end; line++ {
+		if c.added[line] {
+			return true
+		}
+	}
+	for _, removed := range c.removed {
+		if removed.anchor >= start && removed.anchor <= end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+const CategoryDocDrift = "doc-drift"
+
+var callableTypes = []string{"func_decl", "method_decl", "constructor_decl", "iface_method_decl"}
+
+var identifierRegex = regexp.MustCompile(`[\p{L}_][\p{L}\p{Mn}\p{Nd}_]*`)
+
+// Frequent tokens that say nothing about what a comment documents
+var ignoredIdentifiers = []string{
+	"return", "returns", "func", "function", "public", "private", "protected", "static", "final",
+	"const", "var", "let", "new", "nil", "null", "undefined", "true", "false", "this", "err", "error",
+	"the", "and", "for", "if", "else", "int", "string", "bool", "void",
+}
+
+// CheckDocDrift flags changed functions whose doc comment was left untouched although the
+// signature changed or the comment still mentions identifiers the change removed.
+func CheckDocDrift(filePath, diffContent string, content []byte, symbols []types.Symbol) []types.Issue {
+	changes := parseHunks(diffContent)
+	lines := strings.Split(string(content), "\n")
+
+	var issues []types.Issue
+	for _, symbol := range symbols {
+		if !slices.Contains(callableTypes, symbol.Type) || symbol.StartLine <= 0 || symbol.EndLine > len(lines) {
+			continue
+		}
+		if !changes.touches(symbol.StartLine, symbol.EndLine) {
+			continue
+		}
+
+		docStart, docEnd := findDocComment(lines, symbol.StartLine)
+		if docStart == 0 || changes.touches(docStart, docEnd) {
+			continue
+		}
+
+		signatureEnd := findSignatureEnd(lines, symbol.StartLine, symbol.EndLine)
+		docText := strings.Join(lines[docStart-1:docEnd], "\n")
+
+		var reason string
+		if changes.touches(symbol.StartLine, signatureEnd) {
+			reason = "its signature changed"
+		} else if stale := staleReferences(changes, docText, lines, symbol); len(stale) > 0 {
+			reason = fmt.Sprintf("it still mentions %s, which the change removed", formatIdentifiers(stale))
+		} else {
+			continue
+		}
+
+		issues = append(issues, types.Issue{
+			Severity:    "MINOR",
+			Category:    CategoryDocDrift,
+			FilePath:    filePath,
+			StartLine:   docStart,
+			EndLine:     signatureEnd,
+			Description: fmt.Sprintf("Doc comment of %s may be stale: %s but the comment was not updated", symbol.Name, reason),
+			CodeSnippet: strings.Join(lines[docStart-1:signatureEnd], "\n"),
+		})
+	}
+
+	return issues
+}
+
+// findDocComment returns the line range of the comment block directly above a declaration,
+// or zeros when there is none
+func findDocComment(lines []string, declarationLine int) (int, int) {
+	start, end := 0, 0
+	for line := declarationLine - 1; line >= 1; line-- {
+		trimmed := strings.TrimSpace(lines[line-1])
+		if !isCommentLine(trimmed) {
+			break
+		}
+		if end == 0 {
+			end = line
+		}
+		start = line
+	}
+	return start, end
+}
+
+func isCommentLine(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") ||
+		strings.HasPrefix(trimmed, "*")
+}
+
+// findSignatureEnd returns the line where the declaration body starts
+func findSignatureEnd(lines []string, start, end int) int {
+	for line := start; line <= end; line++ {
+		if strings.Contains(lines[line-1], "{") {
+			return line
+		}
+	}
+	return start
+}
+
+// staleReferences returns the identifiers that were removed from the function, no longer
+// appear in it and are still mentioned by its doc comment
+func staleReferences(changes hunkChanges, docText string, lines []string, symbol types.Symbol) []string {
+	body := strings.Join(lines[symbol.StartLine-1:symbol.EndLine], "\n")
+	current := make(map[string]bool)
+	for _, identifier := range identifierRegex.FindAllString(body, -1) {
+		current[identifier] = true
+	}
+
+	documented := make(map[string]bool)
+	for _, identifier := range identifierRegex.FindAllString(docText, -1) {
+		documented[identifier] = true
+	}
+
+	var stale []string
+	for _, removed := range changes.removed {
+		if removed.anchor < symbol.StartLine || removed.anchor > symbol.EndLine+1 {
+			continue
+		}
+		for _, identifier := range identifierRegex.FindAllString(removed.text, -1) {
+			if utf8.RuneCountInString(identifier) < 3 || slices.Contains(ignoredIdentifiers, strings.ToLower(identifier)) {
+				continue
+			}
+			if documented[identifier] && !current[identifier] && !slices.Contains(stale, identifier) {
+				stale = append(stale, identifier)
+			}
+		}
+	}
+
+	return stale
+}
+
+func formatIdentifiers(identifiers []string) string {
+	quoted := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		quoted[i] = "`" + identifier + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
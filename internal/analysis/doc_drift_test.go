@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/tools"
+)
+
+const docDriftSource = `package store
+
+// Save writes the user to the cache and returns the cache key.
+func Save(u User, ttl int) string {
+	key := db.Insert(u)
+	return key
+}
+
+// Load reads a user by id.
+func Load(id string) User {
+	return db.Get(id)
+}
+
+// Count returns the number of users.
+func Count() int {
+	return db.Count()
+}
+
+// 测试 reports whether 旧缓存 holds the entry.
+func 测试() bool {
+	return 新缓存.Has()
+}
+`
+
+func TestCheckDocDrift(t *testing.T) {
+	tests := []struct {
+		name          string
+		diff          string
+		expectedCount int
+		contains      string
+	}{
+		{
+			name: "signature changed without doc update",
+			diff: `@@ -14,4 +14,4 @@
+ // Count returns the number of users.
+-func Count() int {
++func Count() int64 {
+ 	return db.Count()
+ }
+`,
+			expectedCount: 1,
+			contains:      "Count may be stale: its signature changed",
+		},
+		{
+			name: "removed identifier still documented",
+			diff: `@@ -3,6 +3,6 @@
+ // Save writes the user to the cache and returns the cache key.
+ func Save(u User, ttl int) string {
+-	key := cache.Put(u, ttl)
++	key := db.Insert(u)
+ 	return key
+ }
+`,
+			expectedCount: 1,
+			contains:      "still mentions `cache`",
+		},
+		{
+			name: "doc updated together with the code",
+			diff: `@@ -14,4 +14,4 @@
+-// Count returns the number of active users.
++// Count returns the number of users.
+-func Count() int32 {
++func Count() int {
+ 	return db.Count()
+ }
+`,
+			expectedCount: 0,
+		},
+		{
+			name: "body change unrelated to the doc",
+			diff: `@@ -9,4 +9,4 @@
+ // Load reads a user by id.
+ func Load(id string) User {
+-	return db.Find(id)
++	return db.Get(id)
+ }
+`,
+			expectedCount: 0,
+		},
+		{
+			name: "non-ASCII identifier still documented",
+			diff: `@@ -19,4 +19,4 @@
+ // 测试 reports whether 旧缓存 holds the entry.
+ func 测试() bool {
+-	return 旧缓存.Has()
++	return 新缓存.Has()
+ }
+`,
+			expectedCount: 1,
+			contains:      "测试 may be stale: it still mentions `旧缓存`",
+		},
+	}
+
+	parser, err := tools.NewGoParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	symbols, err := parser.ParseFile("store.go", []byte(docDriftSource))
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := CheckDocDrift("store.go", tt.diff, []byte(docDriftSource), symbols)
+
+			if len(issues) != tt.expectedCount {
+				t.Fatalf("Expected %d issues, got %d: %+v", tt.expectedCount, len(issues), issues)
+			}
+			if tt.expectedCount == 0 {
+				return
+			}
+
+			issue := issues[0]
+			if !strings.Contains(issue.Description, tt.contains) {
+				t.Errorf("Expected description to contain %q, got %q", tt.contains, issue.Description)
+			}
+			if issue.Severity != "MINOR" || issue.Category != CategoryDocDrift {
+				t.Errorf("Expected a MINOR doc-drift issue, got %s/%s", issue.Severity, issue.Category)
+			}
+			if !strings.Contains(issue.CodeSnippet, "//") || !strings.Contains(issue.CodeSnippet, "func ") {
+				t.Errorf("Expected the snippet to show the comment and the signature, got:\n%s", issue.CodeSnippet)
+			}
+		})
+	}
+}
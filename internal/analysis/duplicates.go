@@ -0,0 +1,32 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+const CategoryDuplicatedLogic = "duplicated-logic"
+
+// CheckDuplicates reports each block of added lines nearly duplicating existing code
+func CheckDuplicates(filePath string, similar []types.SimilarCode) []types.Issue {
+	var issues []types.Issue
+	reported := make(map[int]bool)
+	for _, code := range similar {
+		if reported[code.AddedStart] {
+			continue
+		}
+		reported[code.AddedStart] = true
+
+		issues = append(issues, types.Issue{
+			Severity:  "MINOR",
+			Category:  CategoryDuplicatedLogic,
+			FilePath:  filePath,
+			StartLine: code.AddedStart,
+			EndLine:   code.AddedEnd,
+			Description: fmt.Sprintf("The added lines duplicate %s (lines %d-%d, %.0f%% similar): reuse it or extract the shared logic",
+				code.FilePath, code.StartLine, code.EndLine, code.Similarity*100),
+		})
+	}
+	return issues
+}
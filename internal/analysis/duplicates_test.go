@@ -0,0 +1,32 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestCheckDuplicates(t *testing.T) {
+	similar := []types.SimilarCode{
+		{AddedStart: 3, AddedEnd: 19, FilePath: "store/users.go", StartLine: 4, EndLine: 20, Similarity: 0.95},
+		{AddedStart: 3, AddedEnd: 19, FilePath: "store/teams.go", StartLine: 8, EndLine: 24, Similarity: 0.7},
+		{AddedStart: 30, AddedEnd: 36, FilePath: "store/cache.go", StartLine: 1, EndLine: 7, Similarity: 0.8},
+	}
+
+	issues := CheckDuplicates("store/admins.go", similar)
+	if len(issues) != 2 {
+		t.Fatalf("Expected an issue per added block, got %+v", issues)
+	}
+
+	issue := issues[0]
+	if issue.Category != CategoryDuplicatedLogic || issue.Severity != "MINOR" || issue.FilePath != "store/admins.go" {
+		t.Errorf("Unexpected issue %+v", issue)
+	}
+	if issue.StartLine != 3 || issue.EndLine != 19 {
+		t.Errorf("Expected the added block lines, got %d-%d", issue.StartLine, issue.EndLine)
+	}
+	if !strings.Contains(issue.Description, "store/users.go (lines 4-20, 95% similar)") {
+		t.Errorf("Expected the most similar code in the description, got %q", issue.Description)
+	}
+}
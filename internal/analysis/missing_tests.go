@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+const CategoryMissingTests = "missing-tests"
+
+var publicModifierRegex = regexp.MustCompile(`\bpublic\b`)
+
+// ChangedFile is a file of the reviewed diff with the symbols of its new version
+type ChangedFile struct {
+	Path    string
+	Diff    string
+	Content []byte
+	Symbols []types.Symbol
+	IsTest  bool
+}
+
+// CheckMissingTests flags the source files whose public functions changed without a test
+// update in the same diff. A changed test file covers a function when its changes mention
+// the function or when it is the test file of the function's file.
+func CheckMissingTests(files []ChangedFile, severity string) []types.Issue {
+	var tests []ChangedFile
+	for _, file := range files {
+		if file.IsTest {
+			tests = append(tests, file)
+		}
+	}
+
+	var issues []types.Issue
+	for _, file := range files {
+		if file.IsTest || testedBy(file.Path, tests) {
+			continue
+		}
+
+		changes := parseHunks(file.Diff)
+		lines := strings.Split(string(file.Content), "\n")
+
+		var untested []types.Symbol
+		for _, symbol := range file.Symbols {
+			if !slices.Contains(callableTypes, symbol.Type) || symbol.StartLine <= 0 || symbol.EndLine > len(lines) {
+				continue
+			}
+			if !changes.touches(symbol.StartLine, symbol.EndLine) || !isPublic(file.Path, symbol, lines) {
+				continue
+			}
+			if !mentionedByTests(symbol.Name, tests) {
+				untested = append(untested, symbol)
+			}
+		}
+		if len(untested) == 0 {
+			continue
+		}
+
+		names := make([]string, len(untested))
+		for i, symbol := range untested {
+			names[i] = symbol.Name
+		}
+		issues = append(issues, types.Issue{
+			Severity:    severity,
+			Category:    CategoryMissingTests,
+			FilePath:    file.Path,
+			StartLine:   untested[0].StartLine,
+			EndLine:     untested[0].EndLine,
+			Description: fmt.Sprintf("Public behavior of %s changed without a test update: no changed test covers it", formatIdentifiers(names)),
+		})
+	}
+
+	return issues
+}
+
+// testedBy reports whether one of the tests is the test file of the source file, such as
+// store_test.go for store.go or StoreTest.java for Store.java
+func testedBy(sourcePath string, tests []ChangedFile) bool {
+	for _, test := range tests {
+		if testSubject(test.Path) == filepath.Base(sourcePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// testSubject returns the file name a test file is named after
+func testSubject(testPath string) string {
+	base := filepath.Base(testPath)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	for _, marker := range []string{"_test", ".test", ".spec", "Tests", "Test", "IT"} {
+		if strings.HasSuffix(name, marker) {
+			return strings.TrimSuffix(name, marker) + ext
+		}
+	}
+	return base
+}
+
+// mentionedByTests reports whether the changed lines of a test use the identifier
+func mentionedByTests(name string, tests []ChangedFile) bool {
+	for _, test := range tests {
+		for _, line := range strings.Split(test.Diff, "\n") {
+			if !strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "-") {
+				continue
+			}
+			if slices.Contains(identifierRegex.FindAllString(line[1:], -1), name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isPublic reports whether the function is part of the file's public API: exported Go
+// identifiers, public Java members and exported or non-private TypeScript declarations
+func isPublic(filePath string, symbol types.Symbol, lines []string) bool {
+	signature := strings.Join(lines[symbol.StartLine-1:findSignatureEnd(lines, symbol.StartLine, symbol.EndLine)], "\n")
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".go":
+		first, _ := utf8.DecodeRuneInString(symbol.Name)
+		return unicode.IsUpper(first)
+	case ".java":
+		return publicModifierRegex.MatchString(signature)
+	case ".ts", ".tsx":
+		if symbol.Type == "method_decl" {
+			return !strings.Contains(signature, "private ") && !strings.HasPrefix(symbol.Name, "#")
+		}
+		return strings.Contains(signature, "export ")
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/tools"
+)
+
+const missingTestsSource = `package store
+
+func Save(u User) string {
+	return db.Insert(u)
+}
+
+func normalize(u User) User {
+	return u
+}
+`
+
+const saveDiff = `@@ -3,3 +3,3 @@
+ func Save(u User) string {
+-	return db.Put(u)
++	return db.Insert(u)
+ }
+`
+
+const normalizeDiff = `@@ -7,3 +7,3 @@
+ func normalize(u User) User {
+-	return u.Trim()
++	return u
+ }
+`
+
+func TestCheckMissingTests(t *testing.T) {
+	tests := []struct {
+		name          string
+		sourceDiff    string
+		tests         []ChangedFile
+		severity      string
+		expectedCount int
+	}{
+		{
+			name:          "public function changed without tests",
+			sourceDiff:    saveDiff,
+			severity:      "MINOR",
+			expectedCount: 1,
+		},
+		{
+			name:          "unexported function changed without tests",
+			sourceDiff:    normalizeDiff,
+			severity:      "MINOR",
+			expectedCount: 0,
+		},
+		{
+			name:       "test file of the source changed",
+			sourceDiff: saveDiff,
+			tests: []ChangedFile{
+				{Path: "store/store_test.go", Diff: "@@ -1,1 +1,2 @@\n+// more cases\n", IsTest: true},
+			},
+			severity:      "MINOR",
+			expectedCount: 0,
+		},
+		{
+			name:       "other test mentions the function",
+			sourceDiff: saveDiff,
+			tests: []ChangedFile{
+				{Path: "api/handler_test.go", Diff: "@@ -10,1 +10,2 @@\n+\tkey := store.Save(user)\n", IsTest: true},
+			},
+			severity:      "MINOR",
+			expectedCount: 0,
+		},
+		{
+			name:       "unrelated test changed",
+			sourceDiff: saveDiff,
+			tests: []ChangedFile{
+				{Path: "api/handler_test.go", Diff: "@@ -10,1 +10,2 @@\n+\tSaveAll(users)\n", IsTest: true},
+			},
+			severity:      "WARNING",
+			expectedCount: 1,
+		},
+	}
+
+	parser, err := tools.NewGoParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	symbols, err := parser.ParseFile("store/store.go", []byte(missingTestsSource))
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := append([]ChangedFile{{
+				Path:    "store/store.go",
+				Diff:    tt.sourceDiff,
+				Content: []byte(missingTestsSource),
+				Symbols: symbols,
+			}}, tt.tests...)
+
+			issues := CheckMissingTests(files, tt.severity)
+
+			if len(issues) != tt.expectedCount {
+				t.Fatalf("Expected %d issues, got %d: %+v", tt.expectedCount, len(issues), issues)
+			}
+			if tt.expectedCount == 0 {
+				return
+			}
+
+			issue := issues[0]
+			if issue.Severity != tt.severity || issue.Category != CategoryMissingTests {
+				t.Errorf("Expected a %s missing-tests issue, got %s/%s", tt.severity, issue.Severity, issue.Category)
+			}
+			if issue.FilePath != "store/store.go" || issue.StartLine != 3 {
+				t.Errorf("Expected the issue at store/store.go:3, got %s:%d", issue.FilePath, issue.StartLine)
+			}
+			if !strings.Contains(issue.Description, "`Save`") {
+				t.Errorf("Expected description to name Save, got %q", issue.Description)
+			}
+		})
+	}
+}
+
+func TestTestSubject(t *testing.T) {
+	tests := map[string]string{
+		"store/store_test.go":          "store.go",
+		"src/test/java/StoreTest.java": "Store.java",
+		"src/test/java/StoreIT.java":   "Store.java",
+		"src/store.spec.ts":            "store.ts",
+		"src/__tests__/store.test.tsx": "store.tsx",
+		"store/helpers.go":             "helpers.go",
+	}
+
+	for path, expected := range tests {
+		if got := testSubject(path); got != expected {
+			t.Errorf("testSubject(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
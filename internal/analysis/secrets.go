@@ -0,0 +1,27 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/agusespa/diffpector/internal/secrets"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+const CategorySecrets = "secrets"
+
+// CheckSecrets reports the credentials found in the lines a diff adds
+func CheckSecrets(filePath, diff string) []types.Issue {
+	var issues []types.Issue
+	for _, finding := range secrets.ScanDiff(diff) {
+		issues = append(issues, types.Issue{
+			Severity:  "CRITICAL",
+			Category:  CategorySecrets,
+			FilePath:  filePath,
+			StartLine: finding.Line,
+			EndLine:   finding.Line,
+			Description: fmt.Sprintf("Possible %s committed in the code: move it to a secret store or the environment and rotate it. It was redacted from the prompt sent to the model",
+				finding.Rule),
+		})
+	}
+	return issues
+}
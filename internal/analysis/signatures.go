@@ -0,0 +1,230 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+const CategoryBreakingChange = "breaking-change"
+
+// maxListedCallers bounds the callers named by a breaking change issue
+const maxListedCallers = 5
+
+var signatureTypes = []string{"func_decl", "method_decl"}
+
+// SignatureChange is a Go or Java function whose parameter count the diff changed
+type SignatureChange struct {
+	Name     string
+	FilePath string
+	// StartLine and EndLine delimit the new signature
+	StartLine    int
+	EndLine      int
+	OldSignature string
+	NewSignature string
+	OldParams    int
+	NewParams    int
+	// Variadic is set when the last new parameter takes any number of arguments
+	Variadic bool
+}
+
+// FindSignatureChanges returns the functions of a changed file whose number of parameters
+// the diff changed, the old signature being read from the removed lines. Changes to the
+// parameter types alone are left to the review.
+func FindSignatureChanges(filePath, diffContent string, content []byte, symbols []types.Symbol) []SignatureChange {
+	changes := parseHunks(diffContent)
+	lines := strings.Split(string(content), "\n")
+
+	var signatureChanges []SignatureChange
+	for _, symbol := range symbols {
+		if !slices.Contains(signatureTypes, symbol.Type) || symbol.StartLine <= 0 || symbol.EndLine > len(lines) {
+			continue
+		}
+		signatureEnd := findSignatureEnd(lines, symbol.StartLine, symbol.EndLine)
+		if !changes.touches(symbol.StartLine, signatureEnd) {
+			continue
+		}
+
+		var removed []string
+		for _, line := range changes.removed {
+			if line.anchor >= symbol.StartLine && line.anchor <= signatureEnd+1 {
+				removed = append(removed, strings.TrimSpace(line.text))
+			}
+		}
+		oldSignature := strings.Join(removed, " ")
+		newSignature := joinTrimmed(lines[symbol.StartLine-1 : signatureEnd])
+
+		oldParams, oldOK := argumentList(oldSignature, symbol.Name)
+		newParams, newOK := argumentList(newSignature, symbol.Name)
+		if !oldOK || !newOK {
+			continue
+		}
+		oldCount, newCount := len(splitArguments(oldParams, true)), len(splitArguments(newParams, true))
+		if oldCount == newCount {
+			continue
+		}
+
+		newList := splitArguments(newParams, true)
+		signatureChanges = append(signatureChanges, SignatureChange{
+			Name:         symbol.Name,
+			FilePath:     filePath,
+			StartLine:    symbol.StartLine,
+			EndLine:      signatureEnd,
+			OldSignature: strings.TrimSuffix(strings.TrimSpace(oldSignature), "{"),
+			NewSignature: strings.TrimSuffix(strings.TrimSpace(newSignature), "{"),
+			OldParams:    oldCount,
+			NewParams:    newCount,
+			Variadic:     newCount > 0 && strings.Contains(newList[newCount-1], "..."),
+		})
+	}
+	return signatureChanges
+}
+
+// StaleCallers returns the calls passing a number of arguments the new signature does not
+// accept. Calls whose arguments cannot be read are left out.
+func StaleCallers(change SignatureChange, callers []types.CallSite) []types.CallSite {
+	var stale []types.CallSite
+	for _, caller := range callers {
+		arguments, ok := argumentList(caller.Code, change.Name)
+		if !ok {
+			continue
+		}
+		count := len(splitArguments(arguments, false))
+		if count == change.NewParams || (change.Variadic && count >= change.NewParams-1) {
+			continue
+		}
+		stale = append(stale, caller)
+	}
+	return stale
+}
+
+// CheckBreakingChange reports a signature change whose callers outside the diff still pass
+// the old arguments, which no longer compiles
+func CheckBreakingChange(change SignatureChange, stale []types.CallSite) types.Issue {
+	var locations []string
+	for _, caller := range stale[:min(len(stale), maxListedCallers)] {
+		locations = append(locations, fmt.Sprintf("%s:%d", caller.FilePath, caller.Line))
+	}
+	if len(stale) > maxListedCallers {
+		locations = append(locations, fmt.Sprintf("and %d more", len(stale)-maxListedCallers))
+	}
+
+	return types.Issue{
+		Severity:  "CRITICAL",
+		Category:  CategoryBreakingChange,
+		FilePath:  change.FilePath,
+		StartLine: change.StartLine,
+		EndLine:   change.EndLine,
+		Description: fmt.Sprintf("%s now takes %d parameter(s) instead of %d, but %d caller(s) not updated in this diff still pass the old arguments: %s",
+			change.Name, change.NewParams, change.OldParams, len(stale), strings.Join(locations, ", ")),
+		CodeSnippet: change.NewSignature,
+	}
+}
+
+// argumentList returns the text between the parentheses following the first occurrence of
+// name, skipping the type parameters of generic Go functions
+func argumentList(text, name string) (string, bool) {
+	for offset := 0; offset < len(text); {
+		index := strings.Index(text[offset:], name)
+		if index < 0 {
+			return "", false
+		}
+		start := offset + index
+		offset = start + len(name)
+		if start > 0 && isIdentifierByte(text[start-1]) {
+			continue
+		}
+
+		rest := text[offset:]
+		if strings.HasPrefix(rest, "[") {
+			closing := matchingBracket(rest, 0)
+			if closing < 0 {
+				return "", false
+			}
+			rest = rest[closing+1:]
+		}
+		if !strings.HasPrefix(rest, "(") {
+			continue
+		}
+		closing := matchingBracket(rest, 0)
+		if closing < 0 {
+			return "", false
+		}
+		return rest[1:closing], true
+	}
+	return "", false
+}
+
+// matchingBracket returns the index of the bracket closing the one at open, skipping string
+// literals, or -1 when it is not closed
+func matchingBracket(text string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitArguments splits a parameter or argument list on its top-level commas. Angle brackets
+// only nest in declarations, in calls they are comparisons.
+func splitArguments(list string, declaration bool) []string {
+	var arguments []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(list); i++ {
+		c := list[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '(' || c == '[' || c == '{' || (declaration && c == '<'):
+			depth++
+		case c == ')' || c == ']' || c == '}' || (declaration && c == '>'):
+			depth--
+		case c == ',' && depth == 0:
+			arguments = append(arguments, strings.TrimSpace(list[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(list[start:]); last != "" {
+		arguments = append(arguments, last)
+	}
+	return arguments
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func joinTrimmed(lines []string) string {
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(trimmed, " ")
+}
@@ -0,0 +1,138 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+const signatureSource = `package store
+
+func Save(u User, ttl int, opts ...Option) string {
+	return db.Insert(u, ttl)
+}
+
+func Load(id string,
+	fresh bool) User {
+	return db.Get(id)
+}
+
+func Count() int64 {
+	return db.Count()
+}
+`
+
+func TestFindSignatureChanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		diff      string
+		expected  string
+		oldParams int
+		newParams int
+		variadic  bool
+	}{
+		{
+			name: "parameter added",
+			diff: `@@ -3,3 +3,3 @@
+-func Save(u User, ttl int) string {
++func Save(u User, ttl int, opts ...Option) string {
+ 	return db.Insert(u, ttl)
+ }
+`,
+			expected:  "Save",
+			oldParams: 2,
+			newParams: 3,
+			variadic:  true,
+		},
+		{
+			name: "multi-line signature",
+			diff: `@@ -7,3 +7,4 @@
+-func Load(id string) User {
++func Load(id string,
++	fresh bool) User {
+ 	return db.Get(id)
+ }
+`,
+			expected:  "Load",
+			oldParams: 1,
+			newParams: 2,
+		},
+		{
+			name: "result type changed only",
+			diff: `@@ -12,3 +12,3 @@
+-func Count() int {
++func Count() int64 {
+ 	return db.Count()
+ }
+`,
+		},
+		{
+			name: "body changed only",
+			diff: `@@ -3,3 +3,3 @@
+ func Save(u User, ttl int, opts ...Option) string {
+-	return db.Insert(u)
++	return db.Insert(u, ttl)
+ }
+`,
+		},
+	}
+
+	parser, err := tools.NewGoParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	symbols, err := parser.ParseFile("store.go", []byte(signatureSource))
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := FindSignatureChanges("store.go", tt.diff, []byte(signatureSource), symbols)
+
+			if tt.expected == "" {
+				if len(changes) != 0 {
+					t.Fatalf("Expected no signature change, got %+v", changes)
+				}
+				return
+			}
+			if len(changes) != 1 {
+				t.Fatalf("Expected one signature change, got %+v", changes)
+			}
+			change := changes[0]
+			if change.Name != tt.expected || change.OldParams != tt.oldParams || change.NewParams != tt.newParams || change.Variadic != tt.variadic {
+				t.Errorf("Unexpected change %+v", change)
+			}
+		})
+	}
+}
+
+func TestStaleCallers(t *testing.T) {
+	change := SignatureChange{Name: "Save", FilePath: "store.go", StartLine: 3, EndLine: 3, OldParams: 2, NewParams: 4}
+	callers := []types.CallSite{
+		{Name: "Save", FilePath: "api/users.go", Line: 12, Code: `key := store.Save(user, ttl)`},
+		{Name: "Save", FilePath: "api/admins.go", Line: 30, Code: "store.Save(admin,\n\tttl, fmt.Sprintf(\"%s,%d\", a, b), true)"},
+		{Name: "Save", FilePath: "api/batch.go", Line: 8, Code: `store.Save(u, f(a, b))`},
+		{Name: "Save", FilePath: "api/broken.go", Line: 2, Code: `store.Save(u,`},
+	}
+
+	stale := StaleCallers(change, callers)
+	if len(stale) != 2 || stale[0].FilePath != "api/users.go" || stale[1].FilePath != "api/batch.go" {
+		t.Fatalf("Expected the two callers passing two arguments, got %+v", stale)
+	}
+
+	change.Variadic = true
+	if stale := StaleCallers(change, callers[1:2]); len(stale) != 0 {
+		t.Errorf("Expected a variadic parameter to accept the call, got %+v", stale)
+	}
+
+	issue := CheckBreakingChange(change, stale)
+	if issue.Severity != "CRITICAL" || issue.Category != CategoryBreakingChange {
+		t.Errorf("Expected a CRITICAL breaking-change issue, got %s/%s", issue.Severity, issue.Category)
+	}
+	if !strings.Contains(issue.Description, "api/users.go:12, api/batch.go:8") {
+		t.Errorf("Expected the callers to be listed, got %q", issue.Description)
+	}
+}
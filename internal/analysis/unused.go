@@ -0,0 +1,95 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+const CategoryUnused = "unused-code"
+
+// Keywords and entry points that removed lines name without referencing a symbol
+var unreferencedIdentifiers = []string{
+	"break", "case", "continue", "default", "defer", "else", "for", "func", "go", "goto", "range",
+	"select", "switch", "struct", "interface", "map", "chan", "package", "import", "type", "catch",
+	"class", "extends", "implements", "instanceof", "throw", "throws", "try", "while", "super",
+	"main", "init",
+}
+
+// RemovedReference is an identifier of a removed line, Line being the new file line that
+// follows the removal
+type RemovedReference struct {
+	Name string
+	Line int
+	Code string
+}
+
+// RemovedReferences returns the identifiers the removed code lines of a diff name and none of
+// its added lines does, in the order they were removed. They are the candidates for a symbol
+// whose last reference the diff removed.
+func RemovedReferences(diffContent string) []RemovedReference {
+	changes := parseHunks(diffContent)
+
+	added := make(map[string]bool)
+	for _, line := range addedLines(diffContent) {
+		for _, identifier := range identifierRegex.FindAllString(line, -1) {
+			added[identifier] = true
+		}
+	}
+
+	var removed []RemovedReference
+	seen := make(map[string]bool)
+	for _, line := range changes.removed {
+		if isCommentLine(strings.TrimSpace(line.text)) {
+			continue
+		}
+		for _, identifier := range identifierRegex.FindAllString(line.text, -1) {
+			if added[identifier] || seen[identifier] || utf8.RuneCountInString(identifier) < 3 {
+				continue
+			}
+			lower := strings.ToLower(identifier)
+			if slices.Contains(ignoredIdentifiers, lower) || slices.Contains(unreferencedIdentifiers, lower) {
+				continue
+			}
+			seen[identifier] = true
+			removed = append(removed, RemovedReference{Name: identifier, Line: line.anchor, Code: strings.TrimSpace(line.text)})
+		}
+	}
+	return removed
+}
+
+// CheckUnusedSymbol reports, at the removed reference, a symbol defined in the repository that
+// nothing else references anymore. A symbol with no definition was removed too or is defined
+// outside the repository and is not reported.
+func CheckUnusedSymbol(filePath string, removed RemovedReference, references types.SymbolReferences) (types.Issue, bool) {
+	if references.References > 0 || len(references.Definitions) == 0 {
+		return types.Issue{}, false
+	}
+
+	var locations []string
+	for _, definition := range references.Definitions {
+		locations = append(locations, fmt.Sprintf("%s:%d", definition.FilePath, definition.StartLine))
+	}
+	return types.Issue{
+		Severity:    "MINOR",
+		Category:    CategoryUnused,
+		FilePath:    filePath,
+		StartLine:   removed.Line,
+		EndLine:     removed.Line,
+		Description: fmt.Sprintf("%s is now unused: this change removed its last reference outside tests, consider removing its definition at %s", removed.Name, strings.Join(locations, ", ")),
+		CodeSnippet: removed.Code,
+	}, true
+}
+
+func addedLines(diffContent string) []string {
+	var lines []string
+	for _, line := range strings.Split(diffContent, "\n") {
+		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+			lines = append(lines, line[1:])
+		}
+	}
+	return lines
+}
@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestRemovedReferences(t *testing.T) {
+	diff := `@@ -10,6 +10,5 @@ func handle() {
+ 	user := load(id)
+-	// legacyAudit is gone
+-	legacyAudit(user, cache)
++	audit(user)
+-	if cache != nil {
++	if user != nil {
+ 	}
+`
+
+	removed := RemovedReferences(diff)
+
+	var names []string
+	for _, reference := range removed {
+		names = append(names, reference.Name)
+	}
+	if strings.Join(names, ",") != "legacyAudit,cache" {
+		t.Fatalf("Expected legacyAudit and cache, got %v", names)
+	}
+	if removed[0].Line != 11 || removed[0].Code != "legacyAudit(user, cache)" {
+		t.Errorf("Expected the removed call anchored at line 11, got %+v", removed[0])
+	}
+}
+
+func TestCheckUnusedSymbol(t *testing.T) {
+	removed := RemovedReference{Name: "legacyAudit", Line: 11, Code: "legacyAudit(user, cache)"}
+	definition := types.Symbol{Name: "legacyAudit", Type: "func_decl", FilePath: "audit/legacy.go", StartLine: 7}
+
+	issue, ok := CheckUnusedSymbol("handler.go", removed, types.SymbolReferences{Name: "legacyAudit", Definitions: []types.Symbol{definition}})
+	if !ok {
+		t.Fatal("Expected an unreferenced definition to be reported")
+	}
+	if issue.Severity != "MINOR" || issue.Category != CategoryUnused || issue.FilePath != "handler.go" || issue.StartLine != 11 {
+		t.Errorf("Expected a MINOR unused-code issue at handler.go:11, got %+v", issue)
+	}
+	if !strings.Contains(issue.Description, "legacyAudit is now unused") || !strings.Contains(issue.Description, "audit/legacy.go:7") {
+		t.Errorf("Expected the description to name the definition, got %q", issue.Description)
+	}
+
+	if _, ok := CheckUnusedSymbol("handler.go", removed, types.SymbolReferences{Name: "legacyAudit", Definitions: []types.Symbol{definition}, References: 2}); ok {
+		t.Error("Expected a referenced symbol not to be reported")
+	}
+	if _, ok := CheckUnusedSymbol("handler.go", removed, types.SymbolReferences{Name: "legacyAudit"}); ok {
+		t.Error("Expected a symbol without definition not to be reported")
+	}
+}
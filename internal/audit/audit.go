@@ -0,0 +1,193 @@
+// Package audit records every prompt sent to the model and its answers, and the tools it
+// called, so teams can review what was sent to which provider and debug a bad review after
+// the fact.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/llm"
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/secrets"
+	"github.com/agusespa/diffpector/internal/tools"
+)
+
+// DefaultDir is where the logs go, relative to the repository root
+const DefaultDir = ".diffpector/audit"
+
+// Entry is a call to the model, one JSON line of the log
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Provider string    `json:"provider"`
+	BaseURL  string    `json:"base_url,omitempty"`
+	Model    string    `json:"model,omitempty"`
+	// Call is generate for single prompts and chat for conversations
+	Call     string        `json:"call"`
+	Messages []llm.Message `json:"messages"`
+	// Tools are the names of the tools offered to the model
+	Tools     []string   `json:"tools,omitempty"`
+	Response  string     `json:"response,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Duration  int64      `json:"duration_ms"`
+	// PromptHash and ResponseHash are the SHA-256 of what was exchanged, before redaction
+	PromptHash   string `json:"prompt_sha256"`
+	ResponseHash string `json:"response_sha256,omitempty"`
+}
+
+type ToolCall struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// Log appends the entries of a run to a JSON lines file of its own
+type Log struct {
+	path   string
+	redact bool
+	mu     sync.Mutex
+}
+
+// New starts the log of a run in dir. With redact the secrets of the prompts and answers are
+// replaced before they are written, their hashes still match what was sent. A dir in the
+// .diffpector directory is ignored by git, since the logs hold the prompts in full.
+func New(dir string, redact bool) (*Log, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	if err := platform.IgnoreStateDir(dir); err != nil {
+		return nil, fmt.Errorf("failed to ignore audit directory: %w", err)
+	}
+	name := fmt.Sprintf("%s-%d.jsonl", time.Now().Format("20060102-150405"), os.Getpid())
+	return &Log{path: filepath.Join(dir, name), redact: redact}, nil
+}
+
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Record appends an entry
+func (l *Log) Record(entry Entry) error {
+	if l.redact {
+		messages := make([]llm.Message, len(entry.Messages))
+		for i, message := range entry.Messages {
+			message.Content, _ = secrets.Redact(message.Content)
+			messages[i] = message
+		}
+		entry.Messages = messages
+		entry.Response, _ = secrets.Redact(entry.Response)
+	}
+	return l.append(entry)
+}
+
+// RecordTool appends a tool call of the model as checked by the tool policy, its call being
+// tool
+func (l *Log) RecordTool(invocation tools.Invocation) error {
+	return l.append(struct {
+		Call string `json:"call"`
+		tools.Invocation
+	}{"tool", invocation})
+}
+
+// append writes a JSON line. The file is opened for each line, so the log is complete even
+// when the run is interrupted.
+func (l *Log) append(entry any) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return file.Close()
+}
+
+// Wrap returns a provider recording every call to the provider in the log, as going to the
+// named provider at baseURL
+func (l *Log) Wrap(provider llm.Provider, name, baseURL string) llm.Provider {
+	return &auditedProvider{Provider: provider, log: l, name: name, baseURL: baseURL}
+}
+
+type auditedProvider struct {
+	llm.Provider
+	log     *Log
+	name    string
+	baseURL string
+}
+
+func (p *auditedProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	start := time.Now()
+	answer, err := p.Provider.Generate(ctx, prompt)
+	p.record("generate", []llm.Message{{Role: "user", Content: prompt}}, nil, &llm.ChatResponse{Content: answer}, err, start)
+	return answer, err
+}
+
+func (p *auditedProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	start := time.Now()
+	response, err := p.Provider.ChatWithTools(ctx, messages, tools)
+	p.record("chat", messages, tools, response, err, start)
+	return response, err
+}
+
+func (p *auditedProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, tools []llm.Tool, schema *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	start := time.Now()
+	response, err := p.Provider.ChatWithSchema(ctx, messages, tools, schema)
+	p.record("chat", messages, tools, response, err, start)
+	return response, err
+}
+
+// record logs the call, a failure to write it is only reported: the review goes on
+func (p *auditedProvider) record(call string, messages []llm.Message, tools []llm.Tool, response *llm.ChatResponse, callErr error, start time.Time) {
+	entry := Entry{
+		Time:       start.UTC(),
+		Provider:   p.name,
+		BaseURL:    p.baseURL,
+		Model:      p.GetModel(),
+		Call:       call,
+		Messages:   messages,
+		Duration:   time.Since(start).Milliseconds(),
+		PromptHash: hash(messages),
+	}
+	for _, tool := range tools {
+		entry.Tools = append(entry.Tools, tool.Function.Name)
+	}
+	if callErr != nil {
+		entry.Error = callErr.Error()
+	}
+	if response != nil && callErr == nil {
+		entry.Response = response.Content
+		for _, toolCall := range response.ToolCalls {
+			entry.ToolCalls = append(entry.ToolCalls, ToolCall{Name: toolCall.Name, Arguments: toolCall.Arguments})
+		}
+		entry.ResponseHash = hash(struct {
+			Content   string     `json:"content"`
+			ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+		}{entry.Response, entry.ToolCalls})
+	}
+
+	if err := p.log.Record(entry); err != nil {
+		fmt.Printf("[!] %v\n", err)
+	}
+}
+
+// hash returns the SHA-256 of the JSON encoding of the value
+func hash(value any) string {
+	encoded, _ := json.Marshal(value)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,225 @@
+// Package chaos injects faults into the review pipeline, to check that a failing tool or a
+// misbehaving model only costs the review of the affected file instead of the whole run.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/agusespa/diffpector/internal/llm"
+	"github.com/agusespa/diffpector/internal/tools"
+)
+
+type Fault string
+
+const (
+	// FaultToolError makes tool executions fail
+	FaultToolError Fault = "tool_error"
+	// FaultMalformedOutput replaces the model answer with prose that is not JSON
+	FaultMalformedOutput Fault = "malformed_output"
+	// FaultTimeout fails LLM requests as if their deadline was exceeded
+	FaultTimeout Fault = "timeout"
+	// FaultPartialJSON cuts the model answer in half, as a dropped stream would
+	FaultPartialJSON Fault = "partial_json"
+)
+
+var allFaults = []Fault{FaultToolError, FaultMalformedOutput, FaultTimeout, FaultPartialJSON}
+
+// ErrInjected marks the errors produced by the injector
+var ErrInjected = errors.New("chaos: injected fault")
+
+const malformedAnswer = "Looking at this change, I believe there might be an issue with {severity: CRITICAL"
+
+type Config struct {
+	Faults []Fault
+	// Rate is the probability that an eligible call fails
+	Rate float64
+	Seed int64
+}
+
+// ParseConfig reads a comma separated spec such as "timeout,partial_json,rate=0.3,seed=7".
+// "all" enables every fault. The rate defaults to 0.5 and the seed to 1.
+func ParseConfig(spec string) (Config, error) {
+	cfg := Config{Rate: 0.5, Seed: 1}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		key, value, hasValue := strings.Cut(part, "=")
+
+		switch {
+		case part == "":
+			continue
+		case hasValue && key == "rate":
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate < 0 || rate > 1 {
+				return Config{}, fmt.Errorf("invalid chaos rate %q, expected a number between 0 and 1", value)
+			}
+			cfg.Rate = rate
+		case hasValue && key == "seed":
+			seed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid chaos seed %q", value)
+			}
+			cfg.Seed = seed
+		case part == "all":
+			cfg.Faults = append(cfg.Faults, allFaults...)
+		default:
+			fault := Fault(part)
+			if !isKnownFault(fault) {
+				return Config{}, fmt.Errorf("unknown chaos fault %q", part)
+			}
+			cfg.Faults = append(cfg.Faults, fault)
+		}
+	}
+
+	if len(cfg.Faults) == 0 {
+		return Config{}, fmt.Errorf("no chaos fault enabled in %q", spec)
+	}
+	return cfg, nil
+}
+
+func isKnownFault(fault Fault) bool {
+	for _, known := range allFaults {
+		if fault == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Injector decides which calls fail. It is safe for concurrent use and, for a given seed,
+// injects the same faults on the same sequence of calls.
+type Injector struct {
+	mu       sync.Mutex
+	config   Config
+	rng      *rand.Rand
+	injected map[Fault]int
+}
+
+func NewInjector(config Config) *Injector {
+	return &Injector{
+		config:   config,
+		rng:      rand.New(rand.NewSource(config.Seed)),
+		injected: make(map[Fault]int),
+	}
+}
+
+// Injected returns how many times each fault was injected
+func (i *Injector) Injected() map[Fault]int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	counts := make(map[Fault]int, len(i.injected))
+	for fault, count := range i.injected {
+		counts[fault] = count
+	}
+	return counts
+}
+
+// pick returns the fault to inject among the candidates enabled in the config, if any
+func (i *Injector) pick(candidates ...Fault) (Fault, bool) {
+	var enabled []Fault
+	for _, candidate := range candidates {
+		for _, fault := range i.config.Faults {
+			if candidate == fault {
+				enabled = append(enabled, candidate)
+				break
+			}
+		}
+	}
+	if len(enabled) == 0 {
+		return "", false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.rng.Float64() >= i.config.Rate {
+		return "", false
+	}
+	fault := enabled[i.rng.Intn(len(enabled))]
+	i.injected[fault]++
+	return fault, true
+}
+
+// WrapProvider returns a provider whose answers are subject to the LLM faults
+func (i *Injector) WrapProvider(provider llm.Provider) llm.Provider {
+	return &faultyProvider{Provider: provider, injector: i}
+}
+
+// WrapTools replaces every tool of the registry with one subject to tool errors
+func (i *Injector) WrapTools(registry *tools.ToolRegistry) {
+	for name, tool := range registry.GetAll() {
+		registry.Register(name, &faultyTool{Tool: tool, injector: i})
+	}
+}
+
+type faultyProvider struct {
+	llm.Provider
+	injector *Injector
+}
+
+func (p *faultyProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	fault, ok := p.injector.pick(FaultTimeout, FaultMalformedOutput, FaultPartialJSON)
+	if ok && fault == FaultTimeout {
+		return "", timeoutError()
+	}
+
+	answer, err := p.Provider.Generate(ctx, prompt)
+	if err != nil || !ok {
+		return answer, err
+	}
+	return corrupt(fault, answer), nil
+}
+
+func (p *faultyProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	return p.ChatWithSchema(ctx, messages, tools, nil)
+}
+
+func (p *faultyProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, tools []llm.Tool, schema *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	fault, ok := p.injector.pick(FaultTimeout, FaultMalformedOutput, FaultPartialJSON)
+	if ok && fault == FaultTimeout {
+		return nil, timeoutError()
+	}
+
+	response, err := p.Provider.ChatWithSchema(ctx, messages, tools, schema)
+	if err != nil || !ok || response == nil {
+		return response, err
+	}
+
+	corrupted := *response
+	corrupted.Content = corrupt(fault, response.Content)
+	corrupted.ToolCalls = nil
+	return &corrupted, nil
+}
+
+func timeoutError() error {
+	return fmt.Errorf("%w: request timed out: %w", ErrInjected, context.DeadlineExceeded)
+}
+
+func corrupt(fault Fault, answer string) string {
+	if fault == FaultPartialJSON {
+		if answer == "" || answer == "APPROVED" {
+			return `[{"severity": "WARNING", "file_path": "`
+		}
+		return answer[:len(answer)/2]
+	}
+	return malformedAnswer
+}
+
+type faultyTool struct {
+	tools.Tool
+	injector *Injector
+}
+
+func (t *faultyTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	if _, ok := t.injector.pick(FaultToolError); ok {
+		return nil, fmt.Errorf("%w: %s failed", ErrInjected, t.Name())
+	}
+	return t.Tool.Execute(ctx, args)
+}
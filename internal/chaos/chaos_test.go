@@ -0,0 +1,119 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/llm"
+	"github.com/agusespa/diffpector/internal/tools"
+)
+
+type echoProvider struct {
+	answer string
+}
+
+func (p *echoProvider) GetModel() string                                 { return "echo" }
+func (p *echoProvider) HealthCheck() error                               { return nil }
+func (p *echoProvider) Generate(context.Context, string) (string, error) { return p.answer, nil }
+func (p *echoProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	return p.ChatWithSchema(ctx, messages, tools, nil)
+}
+func (p *echoProvider) ChatWithSchema(context.Context, []llm.Message, []llm.Tool, *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	return &llm.ChatResponse{Content: p.answer}, nil
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig("timeout, partial_json,rate=0.25,seed=9")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.Faults) != 2 || cfg.Rate != 0.25 || cfg.Seed != 9 {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+
+	all, err := ParseConfig("all")
+	if err != nil || len(all.Faults) != len(allFaults) || all.Rate != 0.5 {
+		t.Errorf("Expected every fault at the default rate, got %+v (%v)", all, err)
+	}
+
+	for _, spec := range []string{"", "rate=0.5", "explode", "timeout,rate=2", "timeout,seed=x"} {
+		if _, err := ParseConfig(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestWrapProvider(t *testing.T) {
+	answer := `[{"severity": "WARNING", "file_path": "a.go", "start_line": 1, "end_line": 1, "description": "x"}]`
+
+	tests := []struct {
+		fault Fault
+		check func(t *testing.T, response *llm.ChatResponse, err error)
+	}{
+		{FaultTimeout, func(t *testing.T, response *llm.ChatResponse, err error) {
+			if !errors.Is(err, ErrInjected) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected an injected timeout, got %v", err)
+			}
+		}},
+		{FaultMalformedOutput, func(t *testing.T, response *llm.ChatResponse, err error) {
+			if err != nil || response.Content != malformedAnswer {
+				t.Errorf("Expected a malformed answer, got %v %v", response, err)
+			}
+		}},
+		{FaultPartialJSON, func(t *testing.T, response *llm.ChatResponse, err error) {
+			if err != nil || response.Content != answer[:len(answer)/2] {
+				t.Errorf("Expected a truncated answer, got %v %v", response, err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.fault), func(t *testing.T) {
+			injector := NewInjector(Config{Faults: []Fault{tt.fault}, Rate: 1, Seed: 1})
+			response, err := injector.WrapProvider(&echoProvider{answer: answer}).ChatWithSchema(context.Background(), nil, nil, nil)
+			tt.check(t, response, err)
+			if injector.Injected()[tt.fault] != 1 {
+				t.Errorf("Expected the fault to be counted, got %v", injector.Injected())
+			}
+		})
+	}
+}
+
+func TestWrapTools(t *testing.T) {
+	registry := tools.NewToolRegistry()
+	registry.Register(tools.ToolNameReadFile, &tools.ReadFileTool{})
+
+	NewInjector(Config{Faults: []Fault{FaultToolError}, Rate: 1}).WrapTools(registry)
+
+	_, err := registry.Get(tools.ToolNameReadFile).Execute(context.Background(), map[string]any{"filename": "go.mod"})
+	if !errors.Is(err, ErrInjected) {
+		t.Errorf("Expected an injected tool error, got %v", err)
+	}
+}
+
+func TestInjectorIsDeterministic(t *testing.T) {
+	sequence := func() []bool {
+		injector := NewInjector(Config{Faults: []Fault{FaultToolError}, Rate: 0.5, Seed: 42})
+		var faults []bool
+		for range 20 {
+			_, ok := injector.pick(FaultToolError)
+			faults = append(faults, ok)
+		}
+		return faults
+	}
+
+	first, second := sequence(), sequence()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected the same faults for the same seed, differ at call %d", i)
+		}
+	}
+}
+
+func TestDisabledFaultsAreNeverInjected(t *testing.T) {
+	injector := NewInjector(Config{Faults: []Fault{FaultToolError}, Rate: 1})
+	if _, ok := injector.pick(FaultTimeout, FaultPartialJSON); ok {
+		t.Error("Expected no LLM fault when only tool errors are enabled")
+	}
+}
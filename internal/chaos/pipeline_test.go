@@ -0,0 +1,125 @@
+package chaos_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/agent"
+	"github.com/agusespa/diffpector/internal/chaos"
+	"github.com/agusespa/diffpector/internal/llm"
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+var files = []string{"alpha.go", "beta.go", "gamma.go"}
+
+// issueProvider reports one issue for the file named in the prompt
+type issueProvider struct{}
+
+func (p *issueProvider) GetModel() string                                 { return "fake" }
+func (p *issueProvider) HealthCheck() error                               { return nil }
+func (p *issueProvider) Generate(context.Context, string) (string, error) { return "APPROVED", nil }
+func (p *issueProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	return p.ChatWithSchema(ctx, messages, tools, nil)
+}
+func (p *issueProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, _ []llm.Tool, _ *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	file := files[0]
+	for _, candidate := range files {
+		if strings.Contains(messages[0].Content, candidate) {
+			file = candidate
+		}
+	}
+	return &llm.ChatResponse{Content: fmt.Sprintf(
+		`[{"severity": "WARNING", "file_path": "%s", "start_line": 4, "end_line": 4, "description": "Unchecked value"}]`, file)}, nil
+}
+
+// setupRepo writes a repository of three Go files and returns it with a diff changing all of them
+func setupRepo(t *testing.T) (string, []byte) {
+	root := t.TempDir()
+	var diff strings.Builder
+	for i, file := range files {
+		function := strings.ToUpper(file[:1]) + strings.TrimSuffix(file[1:], ".go")
+		content := fmt.Sprintf("package main\n\nfunc %s() int {\n\treturn %d\n}\n", function, i+1)
+		if err := os.WriteFile(filepath.Join(root, file), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+		fmt.Fprintf(&diff, "diff --git a/%[1]s b/%[1]s\n--- a/%[1]s\n+++ b/%[1]s\n@@ -1,5 +1,5 @@\n package main\n \n func %[2]s() int {\n-\treturn 0\n+\treturn %[3]d\n }\n", file, function, i+1)
+	}
+
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v, output: %s", args, err, out)
+		}
+	}
+
+	return root, []byte(diff.String())
+}
+
+func newChaosAgent(root string, injector *chaos.Injector) *agent.CodeReviewAgent {
+	parserRegistry := tools.NewParserRegistry()
+	toolRegistry := tools.NewToolRegistry()
+	toolRegistry.Register(tools.ToolNameSymbolContext, tools.NewSymbolContextTool(root, parserRegistry))
+	toolRegistry.Register(tools.ToolNameHumanLoop, &tools.HumanLoopTool{})
+	injector.WrapTools(toolRegistry)
+
+	reviewAgent := agent.NewCodeReviewAgent(injector.WrapProvider(&issueProvider{}), parserRegistry, toolRegistry, "default")
+	reviewAgent.SetChecksConfig(config.ChecksConfig{})
+	return reviewAgent
+}
+
+// TestChaosPipeline reviews the same diff under every fault and checks that each file is
+// either reviewed or reported as failed, and that the run itself never aborts
+func TestChaosPipeline(t *testing.T) {
+	tests := []struct {
+		spec           string
+		expectedFailed int
+	}{
+		{spec: "tool_error,rate=1", expectedFailed: 0},
+		{spec: "timeout,rate=1", expectedFailed: len(files)},
+		{spec: "malformed_output,rate=1", expectedFailed: len(files)},
+		{spec: "partial_json,rate=1", expectedFailed: -1},
+		{spec: "all,rate=0.5,seed=3", expectedFailed: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			root, diff := setupRepo(t)
+			chaosConfig, err := chaos.ParseConfig(tt.spec)
+			if err != nil {
+				t.Fatalf("Invalid spec: %v", err)
+			}
+			reviewAgent := newChaosAgent(root, chaos.NewInjector(chaosConfig))
+
+			issues, err := reviewAgent.ReviewDiff(context.Background(), diff, root)
+			if err != nil {
+				t.Fatalf("Expected the run to degrade per file, it aborted: %v", err)
+			}
+
+			result := reviewAgent.Result()
+			if result.Files != len(files) {
+				t.Errorf("Expected %d files reviewed, got %d", len(files), result.Files)
+			}
+			if tt.expectedFailed >= 0 && len(result.FailedFiles) != tt.expectedFailed {
+				t.Errorf("Expected %d failed files, got %v", tt.expectedFailed, result.FailedFiles)
+			}
+
+			reviewed := make(map[string]bool)
+			for _, issue := range issues {
+				reviewed[issue.FilePath] = true
+			}
+			for _, failed := range result.FailedFiles {
+				reviewed[failed] = true
+			}
+			if len(reviewed) != len(files) {
+				t.Errorf("Expected every file to be reviewed or reported as failed, got issues %v and failures %v", issues, result.FailedFiles)
+			}
+		})
+	}
+}
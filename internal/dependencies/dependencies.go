@@ -0,0 +1,330 @@
+// Package dependencies compares the versions of the dependency manifests a change modifies,
+// so the review knows which dependencies were added, removed or upgraded.
+package dependencies
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	EcosystemGo   = "Go"
+	EcosystemNPM  = "npm"
+	EcosystemPyPI = "PyPI"
+)
+
+const (
+	KindAdded      = "added"
+	KindRemoved    = "removed"
+	KindUpgraded   = "upgraded"
+	KindDowngraded = "downgraded"
+	// KindChanged is a version change that cannot be ordered, e.g. between two ranges
+	KindChanged = "changed"
+)
+
+// Change is a dependency added, removed or moved to another version by a manifest change
+type Change struct {
+	Kind      string
+	Ecosystem string
+	Name      string
+	// From and To are the versions or version constraints, From is empty for added
+	// dependencies and To for removed ones
+	From string
+	To   string
+	// Scope tells apart development and indirect dependencies, empty for the direct ones
+	Scope string
+	// Vulnerabilities lists the known vulnerabilities of the To version, see Lookup
+	Vulnerabilities []Vulnerability
+}
+
+type Vulnerability struct {
+	ID      string
+	Summary string
+}
+
+// MajorJump reports whether the change moves to another major version
+func (c Change) MajorJump() bool {
+	if c.From == "" || c.To == "" {
+		return false
+	}
+	from, fromOK := versionNumbers(c.From)
+	to, toOK := versionNumbers(c.To)
+	return fromOK && toOK && from[0] != to[0]
+}
+
+// dependency is a dependency declared by a manifest
+type dependency struct {
+	name    string
+	version string
+	scope   string
+}
+
+// manifests maps the manifest file names to their ecosystem and parser. Requirements files
+// are matched by prefix, e.g. requirements-dev.txt.
+var manifests = map[string]struct {
+	ecosystem string
+	parse     func(content []byte) (map[string]dependency, error)
+}{
+	"go.mod":           {EcosystemGo, parseGoMod},
+	"package.json":     {EcosystemNPM, parsePackageJSON},
+	"requirements.txt": {EcosystemPyPI, parseRequirements},
+}
+
+func manifestName(path string) string {
+	base := filepath.Base(path)
+	if strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt") {
+		return "requirements.txt"
+	}
+	return base
+}
+
+// IsManifest reports whether a file declares dependencies that Compare understands
+func IsManifest(path string) bool {
+	_, ok := manifests[manifestName(path)]
+	return ok
+}
+
+// Compare returns the dependency changes between two versions of a manifest, sorted by
+// name. Either version is nil when the manifest is added or deleted.
+func Compare(path string, oldContent, newContent []byte) ([]Change, error) {
+	manifest, ok := manifests[manifestName(path)]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a supported dependency manifest", path)
+	}
+
+	before, err := manifest.parse(oldContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the previous %s: %w", path, err)
+	}
+	after, err := manifest.parse(newContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var changes []Change
+	for key, dep := range after {
+		previous, existed := before[key]
+		switch {
+		case !existed:
+			changes = append(changes, Change{Kind: KindAdded, Ecosystem: manifest.ecosystem, Name: dep.name, To: dep.version, Scope: dep.scope})
+		case previous.version != dep.version || previous.name != dep.name:
+			changes = append(changes, Change{Kind: versionChangeKind(previous.version, dep.version), Ecosystem: manifest.ecosystem, Name: dep.name, From: previous.version, To: dep.version, Scope: dep.scope})
+		}
+	}
+	for key, dep := range before {
+		if _, kept := after[key]; !kept {
+			changes = append(changes, Change{Kind: KindRemoved, Ecosystem: manifest.ecosystem, Name: dep.name, From: dep.version, Scope: dep.scope})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		return changes[i].Scope < changes[j].Scope
+	})
+	return changes, nil
+}
+
+// Format describes the changes one per line, highlighting major version jumps and the known
+// vulnerabilities of the new versions
+func Format(changes []Change) string {
+	var summary strings.Builder
+	for _, change := range changes {
+		name := change.Name
+		if change.Scope != "" {
+			name += " (" + change.Scope + ")"
+		}
+
+		switch change.Kind {
+		case KindAdded:
+			fmt.Fprintf(&summary, "- added %s %s", name, change.To)
+		case KindRemoved:
+			fmt.Fprintf(&summary, "- removed %s %s", name, change.From)
+		default:
+			fmt.Fprintf(&summary, "- %s %s %s -> %s", change.Kind, name, change.From, change.To)
+		}
+		if change.MajorJump() {
+			summary.WriteString(" [MAJOR VERSION JUMP: check the breaking changes and the call sites]")
+		}
+		summary.WriteString("\n")
+
+		for _, vulnerability := range change.Vulnerabilities {
+			fmt.Fprintf(&summary, "  - known vulnerability %s: %s\n", vulnerability.ID, vulnerability.Summary)
+		}
+	}
+	return summary.String()
+}
+
+// goMajorSuffixRegex matches the major version suffix of a Go module path, so moving from
+// example.com/lib to example.com/lib/v2 is an upgrade rather than a removal and an addition
+var goMajorSuffixRegex = regexp.MustCompile(`/v[0-9]+$`)
+
+func parseGoMod(content []byte) (map[string]dependency, error) {
+	deps := make(map[string]dependency)
+	inRequire := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		indirect := strings.HasSuffix(line, "// indirect")
+		if comment := strings.Index(line, "//"); comment >= 0 {
+			line = strings.TrimSpace(line[:comment])
+		}
+
+		switch {
+		case line == "require (":
+			inRequire = true
+			continue
+		case inRequire && line == ")":
+			inRequire = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !inRequire:
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		dep := dependency{name: fields[0], version: fields[1]}
+		if indirect {
+			dep.scope = "indirect"
+		}
+		deps[goMajorSuffixRegex.ReplaceAllString(dep.name, "")] = dep
+	}
+	return deps, scanner.Err()
+}
+
+// packageJSONSections are the dependency sections of package.json and the scope of theirs
+var packageJSONSections = map[string]string{
+	"dependencies":         "",
+	"devDependencies":      "dev",
+	"peerDependencies":     "peer",
+	"optionalDependencies": "optional",
+}
+
+func parsePackageJSON(content []byte) (map[string]dependency, error) {
+	deps := make(map[string]dependency)
+	if len(bytes.TrimSpace(content)) == 0 {
+		return deps, nil
+	}
+
+	var manifest map[string]json.RawMessage
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	for section, scope := range packageJSONSections {
+		raw, ok := manifest[section]
+		if !ok {
+			continue
+		}
+		var versions map[string]string
+		if err := json.Unmarshal(raw, &versions); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", section, err)
+		}
+		for name, version := range versions {
+			deps[scope+":"+name] = dependency{name: name, version: version, scope: scope}
+		}
+	}
+	return deps, nil
+}
+
+// requirementRegex matches a requirement, its name, extras and version specifiers
+var requirementRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*(.*)$`)
+
+func parseRequirements(content []byte) (map[string]dependency, error) {
+	deps := make(map[string]dependency)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		// Environment markers do not change the version
+		line, _, _ = strings.Cut(line, ";")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		matches := requirementRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		name := strings.ToLower(strings.NewReplacer("_", "-", ".", "-").Replace(matches[1]))
+		version := strings.ReplaceAll(matches[2], " ", "")
+		version = strings.TrimPrefix(version, "==")
+		deps[name] = dependency{name: name, version: version}
+	}
+	return deps, scanner.Err()
+}
+
+// ExactVersion returns the version a constraint pins, e.g. 1.2.3 for v1.2.3, ^1.2.3 or
+// ~1.2.3, and false for ranges and tags
+func ExactVersion(constraint string) (string, bool) {
+	version := strings.TrimLeft(strings.TrimSpace(constraint), "^~=v")
+	if !exactVersionRegex.MatchString(version) {
+		return "", false
+	}
+	return version, true
+}
+
+var exactVersionRegex = regexp.MustCompile(`^[0-9]+(?:\.[0-9]+)*(?:[-+][0-9A-Za-z.+-]+)?$`)
+
+// versionNumbers returns the numeric components of a version or constraint
+func versionNumbers(constraint string) ([]int, bool) {
+	version, ok := ExactVersion(constraint)
+	if !ok {
+		version = strings.TrimLeft(strings.TrimSpace(constraint), "<>=!~^v")
+		if !exactVersionRegex.MatchString(version) {
+			return nil, false
+		}
+	}
+	version, _, _ = strings.Cut(version, "-")
+	version, _, _ = strings.Cut(version, "+")
+
+	var numbers []int
+	for _, part := range strings.Split(version, ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, true
+}
+
+func versionChangeKind(from, to string) string {
+	before, fromOK := versionNumbers(from)
+	after, toOK := versionNumbers(to)
+	if !fromOK || !toOK {
+		return KindChanged
+	}
+	for i := 0; i < max(len(before), len(after)); i++ {
+		var b, a int
+		if i < len(before) {
+			b = before[i]
+		}
+		if i < len(after) {
+			a = after[i]
+		}
+		if a != b {
+			if a > b {
+				return KindUpgraded
+			}
+			return KindDowngraded
+		}
+	}
+	return KindChanged
+}
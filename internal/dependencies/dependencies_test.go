@@ -0,0 +1,128 @@
+package dependencies
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompare_GoMod(t *testing.T) {
+	before := `module example.com/app
+
+go 1.24
+
+require (
+	github.com/google/uuid v1.6.0
+	github.com/labstack/echo/v4 v4.11.0
+	golang.org/x/text v0.14.0 // indirect
+)
+
+require github.com/pkg/errors v0.9.1
+`
+	after := `module example.com/app
+
+go 1.24
+
+require (
+	github.com/google/uuid v1.6.0
+	github.com/labstack/echo/v5 v5.0.0
+	golang.org/x/text v0.13.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
+)
+`
+	changes, err := Compare("go.mod", []byte(before), []byte(after))
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+
+	expected := `- upgraded github.com/labstack/echo/v5 v4.11.0 -> v5.0.0 [MAJOR VERSION JUMP: check the breaking changes and the call sites]
+- removed github.com/pkg/errors v0.9.1
+- downgraded golang.org/x/text (indirect) v0.14.0 -> v0.13.0
+- added gopkg.in/yaml.v3 v3.0.1
+`
+	if summary := Format(changes); summary != expected {
+		t.Errorf("Unexpected summary:\n%s\nexpected:\n%s", summary, expected)
+	}
+}
+
+func TestCompare_PackageJSON(t *testing.T) {
+	before := `{
+  "name": "web",
+  "dependencies": {"react": "^17.0.2", "lodash": "4.17.20"},
+  "devDependencies": {"jest": "~29.0.0"}
+}`
+	after := `{
+  "name": "web",
+  "dependencies": {"react": "^18.2.0", "lodash": "4.17.21", "left-pad": ">=1.0.0 <2"},
+  "devDependencies": {"jest": "~29.0.0", "react": "^18.2.0"}
+}`
+	changes, err := Compare("web/package.json", []byte(before), []byte(after))
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+
+	expected := `- added left-pad >=1.0.0 <2
+- upgraded lodash 4.17.20 -> 4.17.21
+- upgraded react ^17.0.2 -> ^18.2.0 [MAJOR VERSION JUMP: check the breaking changes and the call sites]
+- added react (dev) ^18.2.0
+`
+	if summary := Format(changes); summary != expected {
+		t.Errorf("Unexpected summary:\n%s\nexpected:\n%s", summary, expected)
+	}
+
+	if _, err := Compare("package.json", nil, []byte("{")); err == nil {
+		t.Error("Expected an error for an invalid package.json")
+	}
+}
+
+func TestCompare_Requirements(t *testing.T) {
+	before := "Django==3.2.18\nrequests>=2.28  # http\n-r base.txt\n"
+	after := "django==4.2.1\nrequests>=2.31\nuvicorn[standard]==0.22.0 ; python_version >= \"3.8\"\n"
+
+	changes, err := Compare("requirements-dev.txt", []byte(before), []byte(after))
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+
+	expected := `- upgraded django 3.2.18 -> 4.2.1 [MAJOR VERSION JUMP: check the breaking changes and the call sites]
+- upgraded requests >=2.28 -> >=2.31
+- added uvicorn 0.22.0
+`
+	if summary := Format(changes); summary != expected {
+		t.Errorf("Unexpected summary:\n%s\nexpected:\n%s", summary, expected)
+	}
+}
+
+func TestIsManifest(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"go.mod":                     true,
+		"frontend/package.json":      true,
+		"requirements.txt":           true,
+		"requirements/dev.txt":       false,
+		"requirements-test.txt":      true,
+		"go.sum":                     false,
+		"frontend/package-lock.json": false,
+	} {
+		if IsManifest(path) != expected {
+			t.Errorf("Expected IsManifest(%q) to be %v", path, expected)
+		}
+	}
+}
+
+func TestExactVersion(t *testing.T) {
+	for constraint, expected := range map[string]string{
+		"v1.2.3":                            "1.2.3",
+		"^18.2.0":                           "18.2.0",
+		"~29.0.0":                           "29.0.0",
+		"0.0.0-20240101000000-abcdef123456": "0.0.0-20240101000000-abcdef123456",
+		">=2.31":                            "",
+		"latest":                            "",
+	} {
+		version, ok := ExactVersion(constraint)
+		if version != expected || ok != (expected != "") {
+			t.Errorf("Expected ExactVersion(%q) to be %q, got %q", constraint, expected, version)
+		}
+	}
+	if strings.Contains(Format([]Change{{Kind: KindChanged, Name: "x", From: "latest", To: "next"}}), "MAJOR") {
+		t.Error("Expected no major version jump between tags")
+	}
+}
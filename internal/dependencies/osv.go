@@ -0,0 +1,107 @@
+package dependencies
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const defaultOSVURL = "https://api.osv.dev"
+
+// VulnerabilitySource returns the known vulnerabilities of a version of a dependency
+type VulnerabilitySource interface {
+	Query(ecosystem, name, version string) ([]Vulnerability, error)
+}
+
+// OSVClient queries the OSV database, https://osv.dev
+type OSVClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewOSVClient queries the OSV API at baseURL, empty uses the public api.osv.dev
+func NewOSVClient(baseURL string) *OSVClient {
+	if baseURL == "" {
+		baseURL = defaultOSVURL
+	}
+	return &OSVClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{},
+	}
+}
+
+func (c *OSVClient) Query(ecosystem, name, version string) ([]Vulnerability, error) {
+	payload, err := json.Marshal(map[string]any{
+		"version": version,
+		"package": map[string]string{"name": name, "ecosystem": ecosystem},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.baseURL+"/v1/query", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Warn("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("request failed with status: %d. Details: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Vulns []struct {
+			ID      string `json:"id"`
+			Summary string `json:"summary"`
+		} `json:"vulns"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var vulnerabilities []Vulnerability
+	for _, vuln := range result.Vulns {
+		vulnerabilities = append(vulnerabilities, Vulnerability{ID: vuln.ID, Summary: vuln.Summary})
+	}
+	return vulnerabilities, nil
+}
+
+// Lookup fills the known vulnerabilities of the added and upgraded dependencies. Versions
+// given as ranges cannot be looked up, and dependencies failing to load are skipped with a
+// warning.
+func Lookup(source VulnerabilitySource, changes []Change) {
+	for i, change := range changes {
+		if change.To == "" || change.Kind == KindRemoved {
+			continue
+		}
+		version, ok := ExactVersion(change.To)
+		if !ok {
+			continue
+		}
+
+		vulnerabilities, err := source.Query(change.Ecosystem, change.Name, version)
+		if err != nil {
+			fmt.Printf("[!] Failed to look up the vulnerabilities of %s %s: %v\n", change.Name, change.To, err)
+			continue
+		}
+		changes[i].Vulnerabilities = vulnerabilities
+	}
+}
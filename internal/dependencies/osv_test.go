@@ -0,0 +1,64 @@
+package dependencies
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLookup_OSV(t *testing.T) {
+	var queried []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1/query" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var query struct {
+			Version string `json:"version"`
+			Package struct {
+				Name      string `json:"name"`
+				Ecosystem string `json:"ecosystem"`
+			} `json:"package"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		queried = append(queried, query.Package.Ecosystem+" "+query.Package.Name+" "+query.Version)
+
+		switch query.Package.Name {
+		case "lodash":
+			_, _ = w.Write([]byte(`{"vulns":[{"id":"GHSA-35jh-r3h4-6jhm","summary":"Command injection in lodash"}]}`))
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	changes := []Change{
+		{Kind: KindUpgraded, Ecosystem: EcosystemNPM, Name: "lodash", From: "4.17.15", To: "^4.17.20"},
+		{Kind: KindAdded, Ecosystem: EcosystemGo, Name: "gopkg.in/yaml.v3", To: "v3.0.1"},
+		{Kind: KindAdded, Ecosystem: EcosystemPyPI, Name: "requests", To: ">=2.31"},
+		{Kind: KindRemoved, Ecosystem: EcosystemNPM, Name: "left-pad", From: "1.3.0"},
+		{Kind: KindAdded, Ecosystem: EcosystemNPM, Name: "broken", To: "1.0.0"},
+	}
+	Lookup(NewOSVClient(server.URL+"/"), changes)
+
+	expectedQueries := "npm lodash 4.17.20,Go gopkg.in/yaml.v3 3.0.1,npm broken 1.0.0"
+	if strings.Join(queried, ",") != expectedQueries {
+		t.Errorf("Expected the pinned added and upgraded versions queried, got %v", queried)
+	}
+
+	summary := Format(changes[:2])
+	expected := "- upgraded lodash 4.17.15 -> ^4.17.20\n  - known vulnerability GHSA-35jh-r3h4-6jhm: Command injection in lodash\n- added gopkg.in/yaml.v3 v3.0.1\n"
+	if summary != expected {
+		t.Errorf("Expected %q, got %q", expected, summary)
+	}
+	if changes[4].Vulnerabilities != nil {
+		t.Errorf("Expected the failing lookup to be skipped, got %+v", changes[4].Vulnerabilities)
+	}
+}
@@ -0,0 +1,154 @@
+// Package diffpos maps new-file line numbers, the numbering issues are reported in, to
+// positions in a unified diff, the coordinates code hosts anchor review comments to.
+package diffpos
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Kind string
+
+const (
+	Added   Kind = "ADDED"
+	Context Kind = "CONTEXT"
+)
+
+// Line is a new-file line visible in the diff
+type Line struct {
+	Number int
+	Kind   Kind
+	// Position counts the diff lines from the file's first hunk header: the line below it is
+	// position 1 and the headers of later hunks take a position too, as GitHub expects
+	Position int
+}
+
+// Location is where a comment for an issue should be anchored
+type Location struct {
+	Path string
+	Line
+	// FileLevel is set when no line of the issue is part of the diff, the comment then
+	// belongs to the file and Line is zero
+	FileLevel bool
+}
+
+// Map indexes the lines of a multi-file unified diff by path
+type Map struct {
+	files map[string]map[int]Line
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@\s+-\d+(?:,(\d+))?\s+\+(\d+)(?:,(\d+))?\s+@@`)
+
+// Parse indexes a unified diff as produced by git diff or served by code hosts. Paths are
+// the new file paths without the a/ and b/ prefixes.
+func Parse(diff []byte) *Map {
+	m := &Map{files: make(map[string]map[int]Line)}
+
+	var lines map[int]Line
+	var oldPath string
+	position, newLine := 0, 0
+	oldRemaining, newRemaining := 0, 0
+
+	for _, text := range strings.Split(string(diff), "\n") {
+		if oldRemaining > 0 || newRemaining > 0 {
+			position++
+			switch {
+			case strings.HasPrefix(text, "+"):
+				lines[newLine] = Line{Number: newLine, Kind: Added, Position: position}
+				newLine++
+				newRemaining--
+			case strings.HasPrefix(text, "-"):
+				oldRemaining--
+			case strings.HasPrefix(text, "\\"):
+				// "\ No newline at end of file" takes a position but no line
+			default:
+				// Context lines, some tools strip the leading space of empty ones
+				lines[newLine] = Line{Number: newLine, Kind: Context, Position: position}
+				newLine++
+				oldRemaining--
+				newRemaining--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "diff "):
+			lines = nil
+		case strings.HasPrefix(text, "--- "):
+			oldPath = stripPrefix(strings.TrimPrefix(text, "--- "))
+		case strings.HasPrefix(text, "+++ "):
+			path := stripPrefix(strings.TrimPrefix(text, "+++ "))
+			if path == "/dev/null" {
+				path = oldPath
+			}
+			lines = make(map[int]Line)
+			m.files[path] = lines
+			position = 0
+		case strings.HasPrefix(text, "@@") && lines != nil:
+			matches := hunkHeaderRegex.FindStringSubmatch(text)
+			if matches == nil {
+				continue
+			}
+			newLine, _ = strconv.Atoi(matches[2])
+			oldRemaining = hunkLength(matches[1])
+			newRemaining = hunkLength(matches[3])
+			if position > 0 {
+				position++
+			}
+		case strings.HasPrefix(text, "\\") && lines != nil:
+			// A missing newline reported after the last hunk line
+			position++
+		}
+	}
+
+	return m
+}
+
+// Locate anchors an issue spanning [startLine, endLine] to the last of its lines that is
+// visible in the diff, so the comment renders below the flagged code. Issues outside the
+// diff fall back to a file-level location.
+func (m *Map) Locate(path string, startLine, endLine int) Location {
+	location := Location{Path: path, FileLevel: true}
+
+	lines, ok := m.files[path]
+	if !ok {
+		return location
+	}
+
+	for number := endLine; number >= startLine && number > 0; number-- {
+		if line, ok := lines[number]; ok {
+			location.Line = line
+			location.FileLevel = false
+			return location
+		}
+	}
+	return location
+}
+
+// Contains reports whether the file is part of the diff
+func (m *Map) Contains(path string) bool {
+	_, ok := m.files[path]
+	return ok
+}
+
+// hunkLength parses the optional line count of a hunk header range, which defaults to 1
+func hunkLength(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func stripPrefix(path string) string {
+	// Plain diff -u appends a timestamp after a tab
+	path, _, _ = strings.Cut(path, "\t")
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		return path[2:]
+	}
+	return path
+}
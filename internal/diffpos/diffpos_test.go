@@ -0,0 +1,91 @@
+package diffpos
+
+import "testing"
+
+const multiFileDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,4 +10,5 @@ func main() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+ 	fmt.Println(a, b)
+ }
+@@ -30,3 +31,3 @@ func helper() {
+ 	x := 1
+-	y := 2
++	y := 3
+ 	return x + y
+diff --git a/new.go b/new.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,2 @@
++package main
++// --- not a header
+\ No newline at end of file
+diff --git a/old.go b/old.go
+deleted file mode 100644
+index 4444444..0000000
+--- a/old.go
++++ /dev/null
+@@ -1,1 +0,0 @@
+-package main
+`
+
+func TestLocate(t *testing.T) {
+	m := Parse([]byte(multiFileDiff))
+
+	tests := []struct {
+		name             string
+		path             string
+		start, end       int
+		expectedLine     int
+		expectedKind     Kind
+		expectedPosition int
+		expectFileLevel  bool
+	}{
+		{"context line", "main.go", 10, 10, 10, Context, 1, false},
+		{"added line", "main.go", 11, 11, 11, Added, 3, false},
+		{"prefers the last line", "main.go", 10, 12, 12, Added, 4, false},
+		{"range partially outside the diff", "main.go", 13, 20, 14, Context, 6, false},
+		{"second hunk counts its header", "main.go", 32, 32, 32, Added, 10, false},
+		{"line between hunks", "main.go", 20, 25, 0, "", 0, true},
+		{"new file", "new.go", 2, 2, 2, Added, 2, false},
+		{"file not in diff", "other.go", 1, 1, 0, "", 0, true},
+		{"deleted file", "old.go", 1, 1, 0, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := m.Locate(tt.path, tt.start, tt.end)
+
+			if location.Path != tt.path {
+				t.Errorf("Expected path %s, got %s", tt.path, location.Path)
+			}
+			if location.FileLevel != tt.expectFileLevel {
+				t.Errorf("Expected file level %v, got %v", tt.expectFileLevel, location.FileLevel)
+			}
+			if location.Number != tt.expectedLine || location.Kind != tt.expectedKind || location.Position != tt.expectedPosition {
+				t.Errorf("Expected line %d %s at position %d, got line %d %s at position %d",
+					tt.expectedLine, tt.expectedKind, tt.expectedPosition, location.Number, location.Kind, location.Position)
+			}
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	m := Parse([]byte(multiFileDiff))
+
+	for _, path := range []string{"main.go", "new.go", "old.go"} {
+		if !m.Contains(path) {
+			t.Errorf("Expected %s to be part of the diff", path)
+		}
+	}
+	if m.Contains("other.go") {
+		t.Error("Expected other.go not to be part of the diff")
+	}
+}
@@ -0,0 +1,154 @@
+package evaluation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// usageHeader matches the usage snippet headers written by the symbol context gatherer
+var usageHeader = regexp.MustCompile(`(?m)^>>>>>> Usage in (.+) \(line \d+(?:, module [^)]+)?\):$`)
+
+// RunContextEvaluation scores the context layer alone against the test cases annotated with
+// an expected context. No LLM is involved, so parser and filter regressions show directly.
+func (e *Evaluator) RunContextEvaluation() (*types.ContextEvaluationResult, error) {
+	result := &types.ContextEvaluationResult{Timestamp: time.Now()}
+
+	for _, testCase := range e.suite.TestCases {
+		if testCase.ExpectedContext == nil {
+			continue
+		}
+
+		caseResult := e.evaluateContext(testCase)
+		result.Cases = append(result.Cases, caseResult)
+		result.AverageScore += caseResult.Score
+	}
+
+	if len(result.Cases) == 0 {
+		return nil, fmt.Errorf("no test case has an expected_context annotation")
+	}
+	result.AverageScore /= float64(len(result.Cases))
+
+	return result, nil
+}
+
+func (e *Evaluator) evaluateContext(testCase types.TestCase) types.ContextCaseResult {
+	caseResult := types.ContextCaseResult{TestCaseName: testCase.Name}
+
+	symbols, usageFiles, err := e.gatherContext(testCase)
+	if err != nil {
+		caseResult.Error = err.Error()
+		return caseResult
+	}
+
+	expected := testCase.ExpectedContext
+	caseResult.SymbolRecall, caseResult.MissingSymbols = recall(expected.Symbols, func(symbol string) bool {
+		return slices.Contains(symbols, symbol)
+	})
+	caseResult.UsageRecall, caseResult.MissingUsages = recall(expected.UsageFiles, func(file string) bool {
+		return slices.ContainsFunc(usageFiles, func(usageFile string) bool {
+			return strings.HasSuffix(filepath.ToSlash(usageFile), file)
+		})
+	})
+	caseResult.Score = (caseResult.SymbolRecall + caseResult.UsageRecall) / 2
+
+	return caseResult
+}
+
+// gatherContext runs the context layer of a review on the test case diff and returns the
+// affected symbol names and the files their usages were found in
+func (e *Evaluator) gatherContext(testCase types.TestCase) ([]string, []string, error) {
+	diffMap, err := e.loadDiffMap(testCase)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	agent := e.createTestAgent(nil, "default")
+
+	changedFilesPaths := make([]string, 0, len(diffMap))
+	for fileName := range diffMap {
+		changedFilesPaths = append(changedFilesPaths, fileName)
+	}
+
+	primaryLanguage, err := agent.ValidateAndDetectLanguage(changedFilesPaths)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to detect language: %w", err)
+	}
+
+	if err := agent.UpdateDiffContext(context.Background(), diffMap, primaryLanguage); err != nil {
+		return nil, nil, err
+	}
+
+	var symbols, usageFiles []string
+	for _, diffData := range diffMap {
+		for _, affected := range diffData.AffectedSymbols {
+			symbols = append(symbols, affected.Symbol.Name)
+			for _, match := range usageHeader.FindAllStringSubmatch(affected.Snippets, -1) {
+				usageFiles = append(usageFiles, match[1])
+			}
+		}
+	}
+
+	return symbols, usageFiles, nil
+}
+
+// recall returns the share of expected values found and the missing ones. Nothing expected
+// counts as full recall.
+func recall(expected []string, found func(string) bool) (float64, []string) {
+	if len(expected) == 0 {
+		return 1, nil
+	}
+
+	var missing []string
+	for _, value := range expected {
+		if !found(value) {
+			missing = append(missing, value)
+		}
+	}
+	return float64(len(expected)-len(missing)) / float64(len(expected)), missing
+}
+
+func PrintContextEvaluation(result *types.ContextEvaluationResult) {
+	fmt.Println("=== Context Evaluation ===")
+	for _, caseResult := range result.Cases {
+		if caseResult.Error != "" {
+			fmt.Printf("%s: ERROR: %s\n", caseResult.TestCaseName, caseResult.Error)
+			continue
+		}
+		fmt.Printf("%s: %.2f (symbols %.2f, usages %.2f)\n", caseResult.TestCaseName, caseResult.Score, caseResult.SymbolRecall, caseResult.UsageRecall)
+		if len(caseResult.MissingSymbols) > 0 {
+			fmt.Printf("  missing symbols: %s\n", strings.Join(caseResult.MissingSymbols, ", "))
+		}
+		if len(caseResult.MissingUsages) > 0 {
+			fmt.Printf("  missing usages: %s\n", strings.Join(caseResult.MissingUsages, ", "))
+		}
+	}
+	fmt.Printf("\nAverage Score: %.2f\n", result.AverageScore)
+}
+
+func (e *Evaluator) SaveContextEvaluation(result *types.ContextEvaluationResult) error {
+	if err := os.MkdirAll(e.resultsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create results directory: %w", err)
+	}
+
+	path := filepath.Join(e.resultsDir, fmt.Sprintf("context_eval_%d.json", result.Timestamp.Unix()))
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write results file: %w", err)
+	}
+
+	fmt.Printf("Results saved to: %s\n", path)
+	return nil
+}
@@ -0,0 +1,96 @@
+package evaluation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestRunContextEvaluation(t *testing.T) {
+	tempDir := t.TempDir()
+	mockFiles := filepath.Join(tempDir, "mock_files")
+	if err := os.MkdirAll(mockFiles, 0755); err != nil {
+		t.Fatalf("Failed to create mock files dir: %v", err)
+	}
+
+	libPath := filepath.Join(mockFiles, "lib.go")
+	lib := "package main\n\nfunc Greet(name string) string {\n\treturn \"Hi \" + name\n}\n"
+	caller := "package main\n\nfunc main() {\n\tprintln(Greet(\"you\"))\n}\n"
+	if err := os.WriteFile(libPath, []byte(lib), 0644); err != nil {
+		t.Fatalf("Failed to write lib.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(mockFiles, "caller.go"), []byte(caller), 0644); err != nil {
+		t.Fatalf("Failed to write caller.go: %v", err)
+	}
+
+	// Usages are found with git grep, which searches tracked files
+	runGit(t, mockFiles, "init", "-q")
+	runGit(t, mockFiles, "add", ".")
+
+	diffContent := fmt.Sprintf(`--- a/%s
++++ b/%s
+@@ -1,5 +1,5 @@
+ package main
+ 
+ func Greet(name string) string {
+-	return "Hello " + name
++	return "Hi " + name
+ }
+`, libPath, libPath)
+	if err := os.WriteFile(filepath.Join(tempDir, "greet.diff"), []byte(diffContent), 0644); err != nil {
+		t.Fatalf("Failed to write diff: %v", err)
+	}
+
+	suite := &types.EvaluationSuite{
+		BaseDir:      tempDir,
+		MockFilesDir: mockFiles,
+		TestCases: []types.TestCase{
+			{
+				Name:     "found",
+				DiffFile: "greet.diff",
+				ExpectedContext: &types.ExpectedContext{
+					Symbols:    []string{"Greet"},
+					UsageFiles: []string{"caller.go"},
+				},
+			},
+			{
+				Name:     "missing",
+				DiffFile: "greet.diff",
+				ExpectedContext: &types.ExpectedContext{
+					Symbols: []string{"Greet", "Farewell"},
+				},
+			},
+			{Name: "not annotated", DiffFile: "greet.diff"},
+		},
+	}
+
+	result, err := newEvaluator(suite, tempDir).RunContextEvaluation()
+	if err != nil {
+		t.Fatalf("RunContextEvaluation() failed: %v", err)
+	}
+
+	if len(result.Cases) != 2 {
+		t.Fatalf("Expected only annotated cases to be scored, got %d", len(result.Cases))
+	}
+	if result.Cases[0].Score != 1 {
+		t.Errorf("Expected full score, got %+v", result.Cases[0])
+	}
+	missing := result.Cases[1]
+	if missing.SymbolRecall != 0.5 || len(missing.MissingSymbols) != 1 || missing.MissingSymbols[0] != "Farewell" {
+		t.Errorf("Expected Farewell to be reported missing, got %+v", missing)
+	}
+	if result.AverageScore != 0.875 {
+		t.Errorf("Expected average score 0.875, got %f", result.AverageScore)
+	}
+}
+
+func TestRunContextEvaluation_NoAnnotations(t *testing.T) {
+	suite := &types.EvaluationSuite{TestCases: []types.TestCase{{Name: "plain"}}}
+
+	if _, err := newEvaluator(suite, t.TempDir()).RunContextEvaluation(); err == nil {
+		t.Error("Expected an error when no test case is annotated")
+	}
+}
@@ -1,6 +1,7 @@
 package evaluation
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -19,6 +20,7 @@ type EvaluationConfig = types.EvaluationConfig
 type ServerConfig = types.ServerConfig
 type EvaluationRun = types.EvaluationRun
 type TestCaseResult = types.TestCaseResult
+type EvaluationResult = types.EvaluationResult
 
 type Evaluator struct {
 	suite          *types.EvaluationSuite
@@ -33,6 +35,10 @@ func NewEvaluator(suitePath string, resultsDir string) (*Evaluator, error) {
 		return nil, fmt.Errorf("failed to load evaluation suite: %w", err)
 	}
 
+	return newEvaluator(suite, resultsDir), nil
+}
+
+func newEvaluator(suite *types.EvaluationSuite, resultsDir string) *Evaluator {
 	parserRegistry := tools.NewParserRegistry()
 	toolRegistry := tools.NewToolRegistry()
 
@@ -45,19 +51,31 @@ func NewEvaluator(suitePath string, resultsDir string) (*Evaluator, error) {
 		resultsDir:     resultsDir,
 		toolRegistry:   toolRegistry,
 		parserRegistry: parserRegistry,
-	}, nil
+	}
 }
 
-func (e *Evaluator) RunEvaluation(modelConfig llm.ProviderConfig, serverName string, promptVariant string, numRuns int) (*types.EvaluationResult, error) {
-	if numRuns < 1 {
-		numRuns = 1
-	}
+// Fork returns an evaluator of the same suite with its own parsers and tools, so that
+// evaluations can run concurrently. Tree-sitter parsers must not be shared between goroutines.
+func (e *Evaluator) Fork() *Evaluator {
+	return newEvaluator(e.suite, e.resultsDir)
+}
 
+func (e *Evaluator) RunEvaluation(modelConfig llm.ProviderConfig, serverName string, promptVariant string, numRuns int) (*types.EvaluationResult, error) {
 	provider, err := llm.NewProvider(modelConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create provider: %w", err)
 	}
 
+	return e.RunEvaluationWithProvider(provider, serverName, promptVariant, numRuns)
+}
+
+// RunEvaluationWithProvider is RunEvaluation with an already built provider, e.g. one wrapped
+// by a RateLimiter
+func (e *Evaluator) RunEvaluationWithProvider(provider llm.Provider, serverName string, promptVariant string, numRuns int) (*types.EvaluationResult, error) {
+	if numRuns < 1 {
+		numRuns = 1
+	}
+
 	result := &types.EvaluationResult{
 		Model:          serverName,
 		Provider:       "openai",
@@ -116,6 +134,7 @@ func (e *Evaluator) runSingleEvaluation(modelIdentifier string, provider string,
 				Errors:        []string{err.Error()},
 				Timestamp:     time.Now(),
 				Issues:        []types.Issue{},
+				Metrics:       CalculateIssueMetrics(testCase.Expected.ExpectedIssues, nil),
 			}
 		}
 		run.Results = append(run.Results, *result)
@@ -134,44 +153,9 @@ func (e *Evaluator) runSingleTest(testCase types.TestCase, provider llm.Provider
 
 	agent := e.createTestAgent(provider, promptVariant)
 
-	diffPath := filepath.Join(e.suite.BaseDir, testCase.DiffFile)
-	diffContent, err := os.ReadFile(diffPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read diff file: %w", err)
-	}
-
-	fileDiffs, err := diff.ParseMultiFileDiff([]byte(diffContent))
+	diffMap, err := e.loadDiffMap(testCase)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse diff: %w", err)
-	}
-
-	diffMap := make(map[string]types.DiffData)
-	for _, fd := range fileDiffs {
-		name := fd.NewName
-		if name == "/dev/null" {
-			name = fd.OrigName
-		}
-		name = stripGitPrefix(name)
-
-		diffContentBytes, err := diff.PrintFileDiff(fd)
-		if err != nil {
-			return nil, fmt.Errorf("failed to print diff for file %s: %w", name, err)
-		}
-
-		absolutePath := name
-		if !filepath.IsAbs(name) {
-			var err error
-			absolutePath, err = filepath.Abs(name)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get absolute path for %s: %w", name, err)
-			}
-		}
-
-		diffData := types.DiffData{
-			AbsolutePath: absolutePath,
-			Diff:         string(diffContentBytes),
-		}
-		diffMap[name] = diffData
+		return nil, err
 	}
 
 	changedFilesPaths := make([]string, 0, len(diffMap))
@@ -184,7 +168,7 @@ func (e *Evaluator) runSingleTest(testCase types.TestCase, provider llm.Provider
 		return nil, fmt.Errorf("failed to detect language: %w", err)
 	}
 
-	review, err := agent.ReviewChangesWithoutReport(diffMap, primaryLanguage)
+	review, err := agent.ReviewChangesWithoutReport(context.Background(), diffMap, primaryLanguage)
 	if err != nil {
 		return nil, fmt.Errorf("agent review failed: %w", err)
 	}
@@ -203,6 +187,7 @@ func (e *Evaluator) runSingleTest(testCase types.TestCase, provider llm.Provider
 				ExecutionTime: time.Since(startTime),
 				Success:       false, // Mark as failure due to format violation
 				Score:         0.0,   // Zero score for format violations
+				Metrics:       CalculateIssueMetrics(testCase.Expected.ExpectedIssues, nil),
 				Errors:        []string{fmt.Sprintf("Format violation: %v", err)},
 				Timestamp:     time.Now(),
 			}, nil
@@ -221,10 +206,56 @@ func (e *Evaluator) runSingleTest(testCase types.TestCase, provider llm.Provider
 		ExecutionTime: time.Since(startTime),
 		Success:       true,
 		Score:         score,
+		Metrics:       CalculateIssueMetrics(testCase.Expected.ExpectedIssues, issues),
 		Timestamp:     time.Now(),
 	}, nil
 }
 
+// loadDiffMap reads the diff of a test case, keyed by the changed file paths
+func (e *Evaluator) loadDiffMap(testCase types.TestCase) (map[string]types.DiffData, error) {
+	diffPath := filepath.Join(e.suite.BaseDir, testCase.DiffFile)
+	diffContent, err := os.ReadFile(diffPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read diff file: %w", err)
+	}
+
+	fileDiffs, err := diff.ParseMultiFileDiff([]byte(diffContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse diff: %w", err)
+	}
+
+	diffMap := make(map[string]types.DiffData)
+	for _, fd := range fileDiffs {
+		name := fd.NewName
+		if name == "/dev/null" {
+			name = fd.OrigName
+		}
+		name = stripGitPrefix(name)
+
+		diffContentBytes, err := diff.PrintFileDiff(fd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to print diff for file %s: %w", name, err)
+		}
+
+		absolutePath := name
+		if !filepath.IsAbs(name) {
+			var err error
+			absolutePath, err = filepath.Abs(name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get absolute path for %s: %w", name, err)
+			}
+		}
+
+		diffData := types.DiffData{
+			AbsolutePath: absolutePath,
+			Diff:         string(diffContentBytes),
+		}
+		diffMap[name] = diffData
+	}
+
+	return diffMap, nil
+}
+
 func stripGitPrefix(path string) string {
 	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
 		return path[2:]
@@ -247,7 +278,7 @@ func WarmUpModel(modelConfig llm.ProviderConfig) error {
 		return fmt.Errorf("failed to create provider for warm-up: %w", err)
 	}
 
-	_, err = provider.Generate("Hello, this is a warm-up request to load the model.")
+	_, err = provider.Generate(context.Background(), "Hello, this is a warm-up request to load the model.")
 	if err != nil {
 		return fmt.Errorf("failed to warm up model: %w", err)
 	}
@@ -279,7 +310,7 @@ func (t *mockHumanLoopTool) Schema() map[string]any {
 	}
 }
 
-func (t *mockHumanLoopTool) Execute(args map[string]any) (any, error) {
+func (t *mockHumanLoopTool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	// For evaluation, we don't want interactive input, so return empty response
 	return "No additional context available during evaluation.", nil
 }
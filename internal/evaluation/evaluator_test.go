@@ -1,6 +1,7 @@
 package evaluation
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -22,14 +23,22 @@ func (m *mockProvider) GetModel() string {
 	return "mock-model"
 }
 
-func (m *mockProvider) Generate(prompt string) (string, error) {
+func (m *mockProvider) Generate(ctx context.Context, prompt string) (string, error) {
 	if m.err != nil {
 		return "", m.err
 	}
 	return m.response, nil
 }
 
-func (m *mockProvider) ChatWithTools(messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+func (m *mockProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	return m.ChatWithSchema(context.Background(), messages, tools, nil)
+}
+
+func (m *mockProvider) HealthCheck() error {
+	return nil
+}
+
+func (m *mockProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, tools []llm.Tool, schema *llm.ResponseSchema) (*llm.ChatResponse, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -0,0 +1,213 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/vcs"
+	"github.com/sourcegraph/go-diff/diff"
+)
+
+// BugFixCommit is a historical commit known to introduce a bug, paired with the commit that fixed it
+type BugFixCommit struct {
+	Name             string   `json:"name,omitempty"`
+	Commit           string   `json:"commit"`
+	FixCommit        string   `json:"fix_commit"`
+	Description      string   `json:"description,omitempty"`
+	ExpectedSeverity []string `json:"expected_severity,omitempty"`
+}
+
+var defaultGeneratedSeverity = []string{"CRITICAL", "WARNING"}
+
+func LoadBugFixCommits(path string) ([]BugFixCommit, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commits file at %s: %w", path, err)
+	}
+
+	var commits []BugFixCommit
+	if err := json.Unmarshal(data, &commits); err != nil {
+		return nil, fmt.Errorf("failed to parse commits file %s: %w", path, err)
+	}
+
+	for i, commit := range commits {
+		if commit.Commit == "" || commit.FixCommit == "" {
+			return nil, fmt.Errorf("entry %d must define both 'commit' and 'fix_commit'", i)
+		}
+	}
+
+	return commits, nil
+}
+
+// SuiteGenerator builds evaluation test cases from the history of a repository
+type SuiteGenerator struct {
+	repo     vcs.VCS
+	casesDir string
+	// taken holds the case names and diff files of the suite and of the cases generated so far
+	taken map[string]bool
+}
+
+// NewSuiteGenerator creates a generator extending the suite, writing the diff files of the
+// test cases to its base directory
+func NewSuiteGenerator(repo vcs.VCS, suite *types.EvaluationSuite) *SuiteGenerator {
+	taken := make(map[string]bool, 2*len(suite.TestCases))
+	for _, testCase := range suite.TestCases {
+		taken[testCase.Name] = true
+		taken[testCase.DiffFile] = true
+	}
+	return &SuiteGenerator{
+		repo:     repo,
+		casesDir: suite.BaseDir,
+		taken:    taken,
+	}
+}
+
+// Generate writes the diff introduced by the buggy commit and returns the test case scaffolding
+// for it. The files touched by both the commit and its fix are expected to be flagged; the
+// remaining expectations are conservative defaults meant to be refined by hand. Nothing is
+// written when the name is taken by a case of the suite, an earlier generated case or an
+// existing diff file.
+func (g *SuiteGenerator) Generate(commit BugFixCommit) (types.TestCase, error) {
+	name := commit.Name
+	if name == "" {
+		name = "generated_" + shortRev(commit.Commit)
+	}
+	if name == "." || name == ".." || name != filepath.Base(name) || strings.ContainsAny(name, `/\`) {
+		return types.TestCase{}, fmt.Errorf("test case name %q must be a single path element", name)
+	}
+	diffFile := name + ".diff"
+	if g.taken[name] || g.taken[diffFile] {
+		return types.TestCase{}, fmt.Errorf("a test case named '%s' already exists", name)
+	}
+	diffPath := filepath.Join(g.casesDir, diffFile)
+	if _, err := os.Stat(diffPath); err == nil {
+		return types.TestCase{}, fmt.Errorf("diff file %s already exists", diffPath)
+	}
+
+	buggyDiff, err := g.repo.RangeDiff(commit.Commit+"^", commit.Commit)
+	if err != nil {
+		return types.TestCase{}, fmt.Errorf("failed to get diff of %s: %w", commit.Commit, err)
+	}
+	buggyFiles, err := changedFiles(buggyDiff)
+	if err != nil {
+		return types.TestCase{}, fmt.Errorf("failed to parse diff of %s: %w", commit.Commit, err)
+	}
+	if len(buggyFiles) == 0 {
+		return types.TestCase{}, fmt.Errorf("commit %s has no changes", commit.Commit)
+	}
+
+	fixDiff, err := g.repo.RangeDiff(commit.FixCommit+"^", commit.FixCommit)
+	if err != nil {
+		return types.TestCase{}, fmt.Errorf("failed to get diff of %s: %w", commit.FixCommit, err)
+	}
+	fixFiles, err := changedFiles(fixDiff)
+	if err != nil {
+		return types.TestCase{}, fmt.Errorf("failed to parse diff of %s: %w", commit.FixCommit, err)
+	}
+
+	var expectedFiles []string
+	for _, file := range buggyFiles {
+		if slices.Contains(fixFiles, file) {
+			expectedFiles = append(expectedFiles, file)
+		}
+	}
+	if len(expectedFiles) == 0 {
+		expectedFiles = buggyFiles
+	}
+
+	description := commit.Description
+	if description == "" {
+		description = fmt.Sprintf("Bug introduced in %s and fixed in %s", shortRev(commit.Commit), shortRev(commit.FixCommit))
+	}
+
+	severity := commit.ExpectedSeverity
+	if len(severity) == 0 {
+		severity = defaultGeneratedSeverity
+	}
+
+	if err := os.MkdirAll(g.casesDir, 0755); err != nil {
+		return types.TestCase{}, fmt.Errorf("failed to create test cases directory: %w", err)
+	}
+	if err := os.WriteFile(diffPath, buggyDiff, 0644); err != nil {
+		return types.TestCase{}, fmt.Errorf("failed to write diff file: %w", err)
+	}
+	g.taken[name] = true
+	g.taken[diffFile] = true
+
+	return types.TestCase{
+		Name:        name,
+		Description: description,
+		DiffFile:    diffFile,
+		Expected: types.ExpectedResults{
+			ShouldFindIssues: true,
+			ExpectedSeverity: severity,
+			ExpectedFiles:    expectedFiles,
+			MinIssues:        1,
+			MaxIssues:        len(expectedFiles) + 2,
+		},
+	}, nil
+}
+
+// AddTestCases appends the test cases to the suite file, skipping names already present.
+// It returns the number of cases added.
+func AddTestCases(suitePath string, testCases []types.TestCase) (int, error) {
+	suite, err := LoadSuite(suitePath)
+	if err != nil {
+		return 0, err
+	}
+
+	existing := make(map[string]bool, len(suite.TestCases))
+	for _, testCase := range suite.TestCases {
+		existing[testCase.Name] = true
+	}
+
+	added := 0
+	for _, testCase := range testCases {
+		if existing[testCase.Name] {
+			fmt.Printf("Skipping '%s': a test case with that name already exists\n", testCase.Name)
+			continue
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+		existing[testCase.Name] = true
+		added++
+	}
+
+	data, err := json.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal suite: %w", err)
+	}
+	if err := os.WriteFile(suitePath, append(data, '\n'), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write suite file %s: %w", suitePath, err)
+	}
+
+	return added, nil
+}
+
+func changedFiles(diffContent []byte) ([]string, error) {
+	fileDiffs, err := diff.ParseMultiFileDiff(diffContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, fd := range fileDiffs {
+		name := fd.NewName
+		if name == "/dev/null" {
+			name = fd.OrigName
+		}
+		files = append(files, stripGitPrefix(name))
+	}
+	return files, nil
+}
+
+func shortRev(rev string) string {
+	if len(rev) > 8 {
+		return rev[:8]
+	}
+	return rev
+}
@@ -0,0 +1,146 @@
+package evaluation
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/vcs"
+)
+
+func TestSuiteGenerator_Generate(t *testing.T) {
+	repoDir := t.TempDir()
+	runGit(t, repoDir, "init")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test User")
+
+	writeRepoFile(t, repoDir, "calc.go", "package calc\n\nfunc Div(a, b int) int {\n\treturn a / b\n}\n")
+	writeRepoFile(t, repoDir, "README.md", "calc\n")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "Initial commit")
+
+	writeRepoFile(t, repoDir, "calc.go", "package calc\n\nfunc Div(a, b int) int {\n\treturn b / a\n}\n")
+	writeRepoFile(t, repoDir, "README.md", "calc library\n")
+	runGit(t, repoDir, "commit", "-am", "Refactor division")
+	buggy := revParse(t, repoDir, "HEAD")
+
+	writeRepoFile(t, repoDir, "calc.go", "package calc\n\nfunc Div(a, b int) int {\n\treturn a / b\n}\n")
+	runGit(t, repoDir, "commit", "-am", "Fix swapped operands")
+	fix := revParse(t, repoDir, "HEAD")
+
+	casesDir := filepath.Join(t.TempDir(), "test_cases")
+	generator := NewSuiteGenerator(vcs.NewGit(repoDir), &types.EvaluationSuite{BaseDir: casesDir})
+
+	testCase, err := generator.Generate(BugFixCommit{Commit: buggy, FixCommit: fix})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if testCase.Name != "generated_"+buggy[:8] {
+		t.Errorf("Unexpected test case name: %s", testCase.Name)
+	}
+	if !slices.Equal(testCase.Expected.ExpectedFiles, []string{"calc.go"}) {
+		t.Errorf("Expected only the file touched by the fix, got %v", testCase.Expected.ExpectedFiles)
+	}
+	if !testCase.Expected.ShouldFindIssues || testCase.Expected.MinIssues != 1 {
+		t.Errorf("Expected the case to require at least one issue, got %+v", testCase.Expected)
+	}
+
+	diffContent, err := os.ReadFile(filepath.Join(casesDir, testCase.DiffFile))
+	if err != nil {
+		t.Fatalf("Failed to read generated diff: %v", err)
+	}
+	if !strings.Contains(string(diffContent), "+\treturn b / a") {
+		t.Errorf("Generated diff does not contain the buggy change:\n%s", diffContent)
+	}
+}
+
+func TestSuiteGenerator_Generate_RefusesTakenNames(t *testing.T) {
+	repoDir := t.TempDir()
+	runGit(t, repoDir, "init")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test User")
+	writeRepoFile(t, repoDir, "calc.go", "package calc\n")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "Initial commit")
+	writeRepoFile(t, repoDir, "calc.go", "package calc\n\nvar x = 1\n")
+	runGit(t, repoDir, "commit", "-am", "Add x")
+	head := revParse(t, repoDir, "HEAD")
+
+	casesDir := t.TempDir()
+	writeRepoFile(t, casesDir, "on_disk.diff", "original")
+	suite := &types.EvaluationSuite{BaseDir: casesDir, TestCases: []types.TestCase{{Name: "existing", DiffFile: "existing.diff"}}}
+	generator := NewSuiteGenerator(vcs.NewGit(repoDir), suite)
+
+	for _, name := range []string{"existing", "on_disk", "../escape", "nested/case", ".."} {
+		if _, err := generator.Generate(BugFixCommit{Name: name, Commit: head, FixCommit: head}); err == nil {
+			t.Errorf("Expected the name %q to be refused", name)
+		}
+	}
+	if _, err := generator.Generate(BugFixCommit{Name: "fresh", Commit: head, FixCommit: head}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if _, err := generator.Generate(BugFixCommit{Name: "fresh", Commit: head, FixCommit: head}); err == nil {
+		t.Error("Expected a duplicate name within the batch to be refused")
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(casesDir, "on_disk.diff")); string(content) != "original" {
+		t.Errorf("Expected the existing diff file to be left alone, got %q", content)
+	}
+	entries, _ := os.ReadDir(casesDir)
+	if len(entries) != 2 {
+		t.Errorf("Expected only on_disk.diff and fresh.diff, got %v", entries)
+	}
+}
+
+func TestAddTestCases_SkipsExistingNames(t *testing.T) {
+	suitePath := filepath.Join(t.TempDir(), "test_suite.json")
+	writeRepoFile(t, filepath.Dir(suitePath), "test_suite.json", `{"base_dir": "cases/", "test_cases": [{"name": "existing", "description": "d", "diff_file": "existing.diff", "expected": {"should_find_issues": false}}]}`)
+
+	added, err := AddTestCases(suitePath, []types.TestCase{
+		{Name: "existing", DiffFile: "other.diff"},
+		{Name: "new_case", DiffFile: "new_case.diff"},
+	})
+	if err != nil {
+		t.Fatalf("AddTestCases failed: %v", err)
+	}
+	if added != 1 {
+		t.Errorf("Expected 1 added test case, got %d", added)
+	}
+
+	suite, err := LoadSuite(suitePath)
+	if err != nil {
+		t.Fatalf("Failed to reload suite: %v", err)
+	}
+	if suite.BaseDir != "cases/" || len(suite.TestCases) != 2 || suite.TestCases[1].Name != "new_case" {
+		t.Errorf("Unexpected suite after update: %+v", suite)
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v, output: %s", args, err, out)
+	}
+}
+
+func revParse(t *testing.T, dir, rev string) string {
+	cmd := exec.Command("git", "rev-parse", rev)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git rev-parse failed: %v", err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeRepoFile(t *testing.T, dir, name, content string) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file %s: %v", name, err)
+	}
+}
@@ -0,0 +1,69 @@
+package evaluation
+
+import (
+	"context"
+	"sync"
+
+	"github.com/agusespa/diffpector/internal/llm"
+)
+
+// RateLimiter bounds the number of requests in flight to each provider, identified by its
+// base URL, when several evaluations share a server
+type RateLimiter struct {
+	mu    sync.Mutex
+	limit int
+	slots map[string]chan struct{}
+}
+
+func NewRateLimiter(limit int) *RateLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &RateLimiter{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+// Acquire blocks until the provider has a free slot and returns the function releasing it
+func (l *RateLimiter) Acquire(provider string) func() {
+	l.mu.Lock()
+	slots, ok := l.slots[provider]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.slots[provider] = slots
+	}
+	l.mu.Unlock()
+
+	slots <- struct{}{}
+	return func() { <-slots }
+}
+
+// Wrap returns a provider whose requests are limited under the given provider key
+func (l *RateLimiter) Wrap(provider llm.Provider, key string) llm.Provider {
+	return &rateLimitedProvider{Provider: provider, limiter: l, key: key}
+}
+
+type rateLimitedProvider struct {
+	llm.Provider
+	limiter *RateLimiter
+	key     string
+}
+
+func (p *rateLimitedProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	release := p.limiter.Acquire(p.key)
+	defer release()
+	return p.Provider.Generate(ctx, prompt)
+}
+
+func (p *rateLimitedProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	release := p.limiter.Acquire(p.key)
+	defer release()
+	return p.Provider.ChatWithTools(ctx, messages, tools)
+}
+
+func (p *rateLimitedProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, tools []llm.Tool, schema *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	release := p.limiter.Acquire(p.key)
+	defer release()
+	return p.Provider.ChatWithSchema(ctx, messages, tools, schema)
+}
@@ -0,0 +1,69 @@
+package evaluation
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+type slowProvider struct {
+	mockProvider
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (p *slowProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	current := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		peak := p.maxInFlight.Load()
+		if current <= peak || p.maxInFlight.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return "ok", nil
+}
+
+func TestRateLimiterBoundsRequestsPerProvider(t *testing.T) {
+	limiter := NewRateLimiter(2)
+	shared := &slowProvider{}
+	other := &slowProvider{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = limiter.Wrap(shared, "http://localhost:8080").Generate(context.Background(), "hi")
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = limiter.Wrap(other, "http://localhost:8081").Generate(context.Background(), "hi")
+		}()
+	}
+	wg.Wait()
+
+	if got := shared.maxInFlight.Load(); got > 2 {
+		t.Errorf("Expected at most 2 concurrent requests per provider, got %d", got)
+	}
+	if got := other.maxInFlight.Load(); got > 2 {
+		t.Errorf("Expected at most 2 concurrent requests per provider, got %d", got)
+	}
+}
+
+func TestForkUsesSeparateParsers(t *testing.T) {
+	evaluator := newEvaluator(&types.EvaluationSuite{}, "results")
+	forked := evaluator.Fork()
+
+	if forked.parserRegistry == evaluator.parserRegistry || forked.toolRegistry == evaluator.toolRegistry {
+		t.Error("Expected the fork to have its own parsers and tools")
+	}
+	if forked.resultsDir != evaluator.resultsDir {
+		t.Errorf("Expected the fork to keep the results directory, got %s", forked.resultsDir)
+	}
+}
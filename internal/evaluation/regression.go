@@ -0,0 +1,121 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// significanceSigmas is the number of standard errors a score drop must exceed to count
+// as a regression rather than run to run noise
+const significanceSigmas = 2.0
+
+// minScoreDrop is the smallest drop reported, it also applies when neither result has the
+// variance of repeated runs
+const minScoreDrop = 0.05
+
+// Regression is a test case whose score dropped significantly from the baseline
+type Regression struct {
+	TestCase       string
+	BaselineScore  float64
+	CandidateScore float64
+	// Threshold is the drop that was considered significant for this test case
+	Threshold float64
+}
+
+// DetectRegressions compares the per test case scores of a candidate evaluation with a
+// baseline of the same model and prompt. Test cases missing from either side are skipped.
+func DetectRegressions(baseline, candidate *types.EvaluationResult) []Regression {
+	baselineRuns := max(len(baseline.IndividualRuns), 1)
+	candidateRuns := max(len(candidate.IndividualRuns), 1)
+
+	var regressions []Regression
+	for name, baselineStats := range baseline.TestCaseStats {
+		candidateStats, ok := candidate.TestCaseStats[name]
+		if !ok {
+			continue
+		}
+
+		standardError := math.Sqrt(baselineStats.ScoreStdDev*baselineStats.ScoreStdDev/float64(baselineRuns) +
+			candidateStats.ScoreStdDev*candidateStats.ScoreStdDev/float64(candidateRuns))
+		threshold := math.Max(significanceSigmas*standardError, minScoreDrop)
+
+		if baselineStats.AverageScore-candidateStats.AverageScore > threshold {
+			regressions = append(regressions, Regression{
+				TestCase:       name,
+				BaselineScore:  baselineStats.AverageScore,
+				CandidateScore: candidateStats.AverageScore,
+				Threshold:      threshold,
+			})
+		}
+	}
+
+	sort.Slice(regressions, func(i, j int) bool {
+		return regressions[i].TestCase < regressions[j].TestCase
+	})
+	return regressions
+}
+
+func LoadEvaluationResult(path string) (*types.EvaluationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var result types.EvaluationResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return &result, nil
+}
+
+// LatestEvaluationResult returns the most recent result of a model and prompt in the
+// results directory, together with its path
+func LatestEvaluationResult(resultsDir, model, promptVariant string) (*types.EvaluationResult, string, error) {
+	files, err := filepath.Glob(filepath.Join(resultsDir, "eval_*.json"))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to find result files: %w", err)
+	}
+
+	var latest *types.EvaluationResult
+	var latestPath string
+	for _, file := range files {
+		result, err := LoadEvaluationResult(file)
+		if err != nil {
+			return nil, "", err
+		}
+		if result.Model != model || result.PromptVariant != promptVariant {
+			continue
+		}
+		if latest == nil || result.StartTime.After(latest.StartTime) {
+			latest, latestPath = result, file
+		}
+	}
+
+	if latest == nil {
+		return nil, "", fmt.Errorf("no results for model %s with prompt %s in %s", model, promptVariant, resultsDir)
+	}
+	return latest, latestPath, nil
+}
+
+func PrintRegressions(baseline, candidate *types.EvaluationResult, regressions []Regression) {
+	fmt.Printf("=== Regression Check for %s (%s) ===\n", candidate.Model, candidate.PromptVariant)
+	fmt.Printf("Average Score: %.2f -> %.2f\n\n", baseline.AggregatedStats.AverageScore, candidate.AggregatedStats.AverageScore)
+
+	if len(regressions) == 0 {
+		fmt.Println("No significant regressions")
+		return
+	}
+
+	fmt.Printf("%d test case(s) regressed:\n", len(regressions))
+	for _, regression := range regressions {
+		fmt.Printf("  %s: %.2f -> %.2f (significant drop > %.2f)\n",
+			regression.TestCase, regression.BaselineScore, regression.CandidateScore, regression.Threshold)
+	}
+}
@@ -0,0 +1,80 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// evaluationResult builds a result with one run per score list entry, scoring each test case
+func evaluationResult(scores map[string][]float64, runs int) *types.EvaluationResult {
+	result := &types.EvaluationResult{Model: "qwen", PromptVariant: "default", TotalRuns: runs}
+	for i := 0; i < runs; i++ {
+		run := EvaluationRun{}
+		for name, caseScores := range scores {
+			run.Results = append(run.Results, TestCaseResult{TestCase: types.TestCase{Name: name}, Score: caseScores[i]})
+		}
+		CalculateRunSummary(&run)
+		result.IndividualRuns = append(result.IndividualRuns, run)
+	}
+	CalculateEvaluationStats(result)
+	return result
+}
+
+func TestDetectRegressions(t *testing.T) {
+	baseline := evaluationResult(map[string][]float64{
+		"stable": {1, 1, 1},
+		"noisy":  {1, 0.4, 0.7},
+		"broken": {1, 1, 0.9},
+	}, 3)
+	candidate := evaluationResult(map[string][]float64{
+		"stable": {1, 1, 1},
+		"noisy":  {0.4, 0.7, 0.7},
+		"broken": {0.2, 0.3, 0.2},
+	}, 3)
+
+	regressions := DetectRegressions(baseline, candidate)
+
+	if len(regressions) != 1 || regressions[0].TestCase != "broken" {
+		t.Fatalf("Expected only the broken test case to regress, got %+v", regressions)
+	}
+}
+
+func TestDetectRegressions_SingleRun(t *testing.T) {
+	baseline := evaluationResult(map[string][]float64{"a": {1}, "b": {0.8}}, 1)
+	candidate := evaluationResult(map[string][]float64{"a": {0.97}, "b": {0.5}, "new": {0}}, 1)
+
+	regressions := DetectRegressions(baseline, candidate)
+
+	if len(regressions) != 1 || regressions[0].TestCase != "b" {
+		t.Fatalf("Expected drops below the minimum to be ignored, got %+v", regressions)
+	}
+}
+
+func TestLatestEvaluationResult(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now()
+	for i, model := range []string{"qwen", "qwen", "llama"} {
+		result := types.EvaluationResult{Model: model, PromptVariant: "default", StartTime: start.Add(time.Duration(i) * time.Hour)}
+		data, _ := json.Marshal(result)
+		if err := os.WriteFile(filepath.Join(dir, "eval_"+model+"_"+string(rune('0'+i))+".json"), data, 0644); err != nil {
+			t.Fatalf("Failed to write result: %v", err)
+		}
+	}
+
+	latest, path, err := LatestEvaluationResult(dir, "qwen", "default")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if filepath.Base(path) != "eval_qwen_1.json" || !latest.StartTime.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected the latest qwen result, got %s", path)
+	}
+
+	if _, _, err := LatestEvaluationResult(dir, "mistral", "default"); err == nil {
+		t.Error("Expected an error when no result matches")
+	}
+}
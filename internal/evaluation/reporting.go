@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/agusespa/diffpector/internal/types"
 )
@@ -51,6 +52,19 @@ func CalculateRunSummary(r *types.EvaluationRun) {
 
 	r.AverageScore = totalScore / float64(len(r.Results))
 	r.SuccessRate = (float64(successfulTests) / float64(len(r.Results))) * 100
+
+	var precisions, recalls, f1s []float64
+	for _, result := range r.Results {
+		if result.Metrics != nil {
+			precisions = append(precisions, result.Metrics.Precision)
+			recalls = append(recalls, result.Metrics.Recall)
+			f1s = append(f1s, result.Metrics.F1)
+		}
+	}
+	r.LabeledTests = len(f1s)
+	r.Precision = calculateMean(precisions)
+	r.Recall = calculateMean(recalls)
+	r.F1 = calculateMean(f1s)
 }
 
 func CalculateEvaluationStats(result *types.EvaluationResult) {
@@ -59,10 +73,16 @@ func CalculateEvaluationStats(result *types.EvaluationResult) {
 	}
 
 	var scores, successRates, durations []float64
+	var precisions, recalls, f1s []float64
 	for _, run := range result.IndividualRuns {
 		scores = append(scores, run.AverageScore)
 		successRates = append(successRates, run.SuccessRate)
 		durations = append(durations, run.TotalDuration.Seconds())
+		if run.LabeledTests > 0 {
+			precisions = append(precisions, run.Precision)
+			recalls = append(recalls, run.Recall)
+			f1s = append(f1s, run.F1)
+		}
 	}
 
 	result.AggregatedStats = types.EvaluationStats{
@@ -72,6 +92,10 @@ func CalculateEvaluationStats(result *types.EvaluationResult) {
 		SuccessRateStdDev:  calculateStdDev(successRates),
 		AverageDuration:    calculateMean(durations),
 		DurationStdDev:     calculateStdDev(durations),
+		AveragePrecision:   calculateMean(precisions),
+		AverageRecall:      calculateMean(recalls),
+		AverageF1:          calculateMean(f1s),
+		F1StdDev:           calculateStdDev(f1s),
 	}
 
 	testCaseResults := make(map[string][]float64)
@@ -116,6 +140,9 @@ func PrintSummary(r *types.EvaluationRun) {
 	fmt.Printf("\n=== Evaluation Summary for %s (%s) ===\n", r.Model, r.PromptVariant)
 	fmt.Printf("Average Score: %.2f\n", r.AverageScore)
 	fmt.Printf("Success Rate:  %.2f%%\n", r.SuccessRate)
+	if r.LabeledTests > 0 {
+		fmt.Printf("Precision: %.2f  Recall: %.2f  F1: %.2f (%d labeled tests)\n", r.Precision, r.Recall, r.F1, r.LabeledTests)
+	}
 	fmt.Printf("Total Duration:  %.2fs\n", r.TotalDuration.Seconds())
 	fmt.Println()
 }
@@ -125,6 +152,10 @@ func PrintEvaluationSummary(r *types.EvaluationResult) {
 	fmt.Printf("Runs: %d\n", r.TotalRuns)
 	fmt.Printf("Average Score: %.2f (±%.2f)\n", r.AggregatedStats.AverageScore, r.AggregatedStats.ScoreStdDev)
 	fmt.Printf("Success Rate: %.2f%% (±%.2f%%)\n", r.AggregatedStats.AverageSuccessRate, r.AggregatedStats.SuccessRateStdDev)
+	if r.AggregatedStats.AverageF1 > 0 || r.AggregatedStats.AverageRecall > 0 {
+		fmt.Printf("Precision: %.2f  Recall: %.2f  F1: %.2f (±%.2f)\n",
+			r.AggregatedStats.AveragePrecision, r.AggregatedStats.AverageRecall, r.AggregatedStats.AverageF1, r.AggregatedStats.F1StdDev)
+	}
 	fmt.Printf("Average Duration: %.2fs (±%.2fs)\n", r.AggregatedStats.AverageDuration, r.AggregatedStats.DurationStdDev)
 	fmt.Printf("Total Duration: %.2fs\n", r.TotalDuration.Seconds())
 
@@ -303,7 +334,8 @@ func printComparison(groupName, groupType string, results []ComparisonResult) {
 func loadEvaluationRuns(dir string) ([]types.EvaluationRun, error) {
 	var runs []types.EvaluationRun
 
-	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	// Only model evaluations, context evaluations are saved next to them
+	files, err := filepath.Glob(filepath.Join(dir, "eval_*.json"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to find result files: %w", err)
 	}
@@ -353,7 +385,7 @@ func CalculateScore(expected types.ExpectedResults, actual []types.Issue) float6
 
 	if maxFoundSev < maxExpectedSev {
 		// Penalty: 50% reduction if severity isn't high enough
-		// e.g. Found WARNING (20), Expected CRITICAL (40).
+		// e.g. Found WARNING, Expected CRITICAL.
 		score -= 0.5
 	}
 
@@ -371,7 +403,67 @@ func CalculateScore(expected types.ExpectedResults, actual []types.Issue) float6
 	return score
 }
 
+// lineTolerance is how many lines a reported issue may be off from a labeled one and still match
+const lineTolerance = 3
+
+// CalculateIssueMetrics matches the reported issues one to one with the labeled expected
+// issues by file and overlapping lines. It returns nil when the test case has no labels.
+func CalculateIssueMetrics(expected []types.ExpectedIssue, actual []types.Issue) *types.IssueMetrics {
+	if len(expected) == 0 {
+		return nil
+	}
+
+	matched := make([]bool, len(expected))
+	truePositives := 0
+	for _, issue := range actual {
+		for i, label := range expected {
+			if !matched[i] && issueMatches(label, issue) {
+				matched[i] = true
+				truePositives++
+				break
+			}
+		}
+	}
+
+	metrics := &types.IssueMetrics{
+		Recall:        float64(truePositives) / float64(len(expected)),
+		TruePositives: truePositives,
+	}
+	if len(actual) > 0 {
+		metrics.Precision = float64(truePositives) / float64(len(actual))
+	}
+	if metrics.Precision+metrics.Recall > 0 {
+		metrics.F1 = 2 * metrics.Precision * metrics.Recall / (metrics.Precision + metrics.Recall)
+	}
+	return metrics
+}
+
+func issueMatches(label types.ExpectedIssue, issue types.Issue) bool {
+	if !samePath(label.FilePath, issue.FilePath) {
+		return false
+	}
+	if label.Category != "" && issue.Category != "" && label.Category != issue.Category {
+		return false
+	}
+
+	endLine := issue.EndLine
+	if endLine < issue.StartLine {
+		endLine = issue.StartLine
+	}
+	return issue.StartLine <= label.EndLine+lineTolerance && endLine >= label.StartLine-lineTolerance
+}
+
+// samePath compares paths that may be relative to different roots
+func samePath(a, b string) bool {
+	a, b = filepath.ToSlash(a), filepath.ToSlash(b)
+	return a == b || strings.HasSuffix(a, "/"+b) || strings.HasSuffix(b, "/"+a)
+}
+
 // Helpers for Severity Logic
+
+// getSeverityLevel ranks the severities of the suites. It keeps its own ranking rather than
+// the severity registry, which folds HIGH into CRITICAL: the expected results of the suites
+// tell HIGH from CRITICAL, and finding HIGH for a CRITICAL bug is scored as too low.
 func getSeverityLevel(s string) int {
 	switch s {
 	case "CRITICAL":
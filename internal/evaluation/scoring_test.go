@@ -55,6 +55,17 @@ func TestCalculateScore(t *testing.T) {
 			},
 			want: 0.5, // 1.0 - 0.5
 		},
+		{
+			name: "HIGH Below CRITICAL (Penalty)",
+			expected: types.ExpectedResults{
+				ShouldFindIssues: true,
+				ExpectedSeverity: []string{"CRITICAL"},
+			},
+			actual: []types.Issue{
+				{Severity: "HIGH", FilePath: "main.go"},
+			},
+			want: 0.5,
+		},
 		{
 			name: "Count Mismatch (Penalty)",
 			expected: types.ExpectedResults{
@@ -87,3 +98,71 @@ func TestCalculateScore(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateIssueMetrics(t *testing.T) {
+	expected := []types.ExpectedIssue{
+		{FilePath: "internal/database/user.go", StartLine: 25, EndLine: 30},
+		{FilePath: "internal/handler/user.go", StartLine: 14, EndLine: 14, Category: "doc_drift"},
+	}
+
+	tests := []struct {
+		name                      string
+		actual                    []types.Issue
+		precision, recall, wantF1 float64
+	}{
+		{
+			name: "all found",
+			actual: []types.Issue{
+				{FilePath: "evaluation/mocks/internal/database/user.go", StartLine: 27, EndLine: 28},
+				{FilePath: "internal/handler/user.go", StartLine: 16, EndLine: 16},
+			},
+			precision: 1, recall: 1, wantF1: 1,
+		},
+		{
+			name: "one found with a false positive",
+			actual: []types.Issue{
+				{FilePath: "internal/database/user.go", StartLine: 22, EndLine: 22},
+				{FilePath: "internal/database/user.go", StartLine: 60, EndLine: 61},
+			},
+			precision: 0.5, recall: 0.5, wantF1: 0.5,
+		},
+		{
+			name: "category mismatch",
+			actual: []types.Issue{
+				{FilePath: "internal/handler/user.go", StartLine: 14, EndLine: 14, Category: "secrets"},
+			},
+			precision: 0, recall: 0, wantF1: 0,
+		},
+		{
+			name:      "nothing reported",
+			precision: 0, recall: 0, wantF1: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := CalculateIssueMetrics(expected, tt.actual)
+			if metrics.Precision != tt.precision || metrics.Recall != tt.recall || math.Abs(metrics.F1-tt.wantF1) > 1e-9 {
+				t.Errorf("Expected P=%.2f R=%.2f F1=%.2f, got %+v", tt.precision, tt.recall, tt.wantF1, metrics)
+			}
+		})
+	}
+
+	if CalculateIssueMetrics(nil, []types.Issue{{FilePath: "a.go"}}) != nil {
+		t.Error("Expected no metrics for a test case without labeled issues")
+	}
+}
+
+func TestCalculateRunSummary_AveragesLabeledMetrics(t *testing.T) {
+	run := &types.EvaluationRun{Results: []types.TestCaseResult{
+		{Score: 1, Success: true, Metrics: &types.IssueMetrics{Precision: 1, Recall: 0.5, F1: 2.0 / 3}},
+		{Score: 1, Success: true, Metrics: &types.IssueMetrics{Precision: 0.5, Recall: 1, F1: 2.0 / 3}},
+		{Score: 0, Success: true},
+	}}
+
+	CalculateRunSummary(run)
+
+	if run.LabeledTests != 2 || run.Precision != 0.75 || run.Recall != 0.75 {
+		t.Errorf("Expected metrics averaged over the 2 labeled tests, got %+v", run)
+	}
+}
@@ -0,0 +1,231 @@
+// Package bitbucket fetches pull request diffs from Bitbucket Cloud or Server and posts
+// review findings back as inline comments.
+package bitbucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/diffpos"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+const defaultCloudURL = "https://api.bitbucket.org/2.0"
+
+type Client struct {
+	config config.BitbucketConfig
+	client *http.Client
+}
+
+// NewClient validates the configuration and creates a client. Authentication uses the
+// access token when set, and the username with the app password otherwise.
+func NewClient(cfg config.BitbucketConfig) (*Client, error) {
+	if cfg.Workspace == "" || cfg.Repository == "" {
+		return nil, fmt.Errorf("bitbucket workspace and repository are required")
+	}
+	if cfg.AccessToken == "" && (cfg.Username == "" || cfg.AppPassword == "") {
+		return nil, fmt.Errorf("bitbucket requires an access_token or a username with an app_password")
+	}
+	if cfg.BaseURL == "" {
+		if cfg.Server {
+			return nil, fmt.Errorf("bitbucket server requires a base_url")
+		}
+		cfg.BaseURL = defaultCloudURL
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &Client{
+		config: cfg,
+		client: &http.Client{},
+	}, nil
+}
+
+// PullRequestDiff returns the unified diff of a pull request
+func (c *Client) PullRequestDiff(id int) ([]byte, error) {
+	req, err := http.NewRequest("GET", c.pullRequestURL(id)+c.diffSuffix(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	return c.do(req)
+}
+
+// PostIssues posts one comment per issue, anchored to the last line of the issue that is
+// part of the diff and at file level otherwise. It returns the number of comments posted.
+func (c *Client) PostIssues(id int, prDiff []byte, issues []types.Issue) (int, error) {
+	positions := diffpos.Parse(prDiff)
+
+	posted := 0
+	for _, issue := range issues {
+		location := positions.Locate(issue.FilePath, issue.StartLine, issue.EndLine)
+
+		if err := c.postComment(id, formatComment(issue, location), location); err != nil {
+			return posted, fmt.Errorf("failed to comment on %s:%d: %w", issue.FilePath, issue.StartLine, err)
+		}
+		posted++
+	}
+
+	return posted, nil
+}
+
+// PostQuestions posts the questions for the author as comments on their line. They are
+// plain comments, not tasks, so they do not block the pull request.
+func (c *Client) PostQuestions(id int, prDiff []byte, questions []types.Question) (int, error) {
+	positions := diffpos.Parse(prDiff)
+
+	posted := 0
+	for _, question := range questions {
+		location := positions.Locate(question.FilePath, question.Line, question.Line)
+
+		if err := c.postComment(id, formatQuestion(question, location), location); err != nil {
+			return posted, fmt.Errorf("failed to ask on %s:%d: %w", question.FilePath, question.Line, err)
+		}
+		posted++
+	}
+
+	return posted, nil
+}
+
+type cloudCommentPayload struct {
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	Inline cloudInline `json:"inline"`
+}
+
+type cloudInline struct {
+	Path string `json:"path"`
+	To   int    `json:"to,omitempty"`
+}
+
+func cloudComment(text string, location diffpos.Location) cloudCommentPayload {
+	var payload cloudCommentPayload
+	payload.Content.Raw = text
+	payload.Inline = cloudInline{Path: location.Path, To: location.Number}
+	return payload
+}
+
+type serverCommentPayload struct {
+	Text   string       `json:"text"`
+	Anchor serverAnchor `json:"anchor"`
+}
+
+type serverAnchor struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line,omitempty"`
+	LineType string `json:"lineType,omitempty"`
+	FileType string `json:"fileType,omitempty"`
+	DiffType string `json:"diffType,omitempty"`
+}
+
+func serverComment(text string, location diffpos.Location) serverCommentPayload {
+	anchor := serverAnchor{Path: location.Path}
+	if !location.FileLevel {
+		anchor.Line = location.Number
+		anchor.LineType = string(location.Kind)
+		anchor.FileType = "TO"
+		anchor.DiffType = "EFFECTIVE"
+	}
+	return serverCommentPayload{Text: text, Anchor: anchor}
+}
+
+func formatComment(issue types.Issue, location diffpos.Location) string {
+	var comment strings.Builder
+	fmt.Fprintf(&comment, "**%s:** %s", issue.Severity, issue.Description)
+	if location.FileLevel {
+		// File-level comments lose the position, keep it in the text
+		fmt.Fprintf(&comment, "\n\nLines %d-%d", issue.StartLine, issue.EndLine)
+	}
+	if issue.CodeSnippet != "" {
+		fmt.Fprintf(&comment, "\n\n```\n%s\n```", issue.CodeSnippet)
+	}
+	comment.WriteString("\n\n_Reported by diffpector_")
+	return comment.String()
+}
+
+func formatQuestion(question types.Question, location diffpos.Location) string {
+	var comment strings.Builder
+	fmt.Fprintf(&comment, "**Question:** %s", question.Question)
+	if location.FileLevel {
+		fmt.Fprintf(&comment, "\n\nLine %d", question.Line)
+	}
+	comment.WriteString("\n\n_Asked by diffpector, non-blocking_")
+	return comment.String()
+}
+
+func (c *Client) postComment(id int, text string, location diffpos.Location) error {
+	var payload any
+	if c.config.Server {
+		payload = serverComment(text, location)
+	} else {
+		payload = cloudComment(text, location)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal comment: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.pullRequestURL(id)+"/comments", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.do(req)
+	return err
+}
+
+func (c *Client) pullRequestURL(id int) string {
+	workspace := url.PathEscape(c.config.Workspace)
+	repository := url.PathEscape(c.config.Repository)
+
+	if c.config.Server {
+		return fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d", c.config.BaseURL, workspace, repository, id)
+	}
+	return fmt.Sprintf("%s/repositories/%s/%s/pullrequests/%d", c.config.BaseURL, workspace, repository, id)
+}
+
+func (c *Client) diffSuffix() string {
+	if c.config.Server {
+		return ".diff"
+	}
+	return "/diff"
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	if c.config.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+	} else {
+		req.SetBasicAuth(c.config.Username, c.config.AppPassword)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Warn("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("bitbucket request failed with status: %d. Details: %s", resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
@@ -0,0 +1,208 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+const prDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,4 +10,5 @@ func main() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+ 	fmt.Println(a, b)
+ }
+`
+
+type recordedRequest struct {
+	method string
+	path   string
+	auth   string
+	body   map[string]any
+}
+
+func newTestServer(t *testing.T, requests *[]recordedRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := recordedRequest{method: r.Method, path: r.URL.Path, auth: r.Header.Get("Authorization")}
+		if r.Method == "POST" {
+			data, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(data, &req.body); err != nil {
+				t.Errorf("Invalid comment payload: %v", err)
+			}
+		}
+		*requests = append(*requests, req)
+
+		if r.Method == "GET" {
+			_, _ = w.Write([]byte(prDiff))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+}
+
+func TestNewClientValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      config.BitbucketConfig
+		expectError bool
+	}{
+		{"access token", config.BitbucketConfig{Workspace: "ws", Repository: "repo", AccessToken: "token"}, false},
+		{"app password", config.BitbucketConfig{Workspace: "ws", Repository: "repo", Username: "me", AppPassword: "secret"}, false},
+		{"missing credentials", config.BitbucketConfig{Workspace: "ws", Repository: "repo", Username: "me"}, true},
+		{"missing repository", config.BitbucketConfig{Workspace: "ws", AccessToken: "token"}, true},
+		{"server without base url", config.BitbucketConfig{Workspace: "PRJ", Repository: "repo", AccessToken: "token", Server: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.config)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCloudPullRequest(t *testing.T) {
+	var requests []recordedRequest
+	server := newTestServer(t, &requests)
+	defer server.Close()
+
+	client, err := NewClient(config.BitbucketConfig{
+		BaseURL:     server.URL,
+		Workspace:   "ws",
+		Repository:  "repo",
+		Username:    "me",
+		AppPassword: "secret",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	diff, err := client.PullRequestDiff(7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(diff) != prDiff {
+		t.Errorf("Unexpected diff: %s", diff)
+	}
+
+	issues := []types.Issue{
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 11, EndLine: 12, Description: "changed values"},
+		{Severity: "MINOR", FilePath: "main.go", StartLine: 40, EndLine: 42, Description: "outside the diff"},
+	}
+	posted, err := client.PostIssues(7, diff, issues)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if posted != 2 {
+		t.Errorf("Expected 2 comments posted, got %d", posted)
+	}
+
+	if len(requests) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(requests))
+	}
+	if requests[0].path != "/repositories/ws/repo/pullrequests/7/diff" {
+		t.Errorf("Unexpected diff path: %s", requests[0].path)
+	}
+	if requests[0].auth == "" || requests[0].auth[:6] != "Basic " {
+		t.Errorf("Expected basic auth, got '%s'", requests[0].auth)
+	}
+	if requests[1].path != "/repositories/ws/repo/pullrequests/7/comments" {
+		t.Errorf("Unexpected comment path: %s", requests[1].path)
+	}
+
+	inline := requests[1].body["inline"].(map[string]any)
+	if inline["path"] != "main.go" || inline["to"] != float64(12) {
+		t.Errorf("Unexpected inline anchor: %v", inline)
+	}
+	fileLevel := requests[2].body["inline"].(map[string]any)
+	if _, ok := fileLevel["to"]; ok {
+		t.Errorf("Expected file-level comment for an issue outside the diff, got %v", fileLevel)
+	}
+}
+
+func TestServerPullRequest(t *testing.T) {
+	var requests []recordedRequest
+	server := newTestServer(t, &requests)
+	defer server.Close()
+
+	client, err := NewClient(config.BitbucketConfig{
+		BaseURL:     server.URL,
+		Workspace:   "PRJ",
+		Repository:  "repo",
+		AccessToken: "token",
+		Server:      true,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	diff, err := client.PullRequestDiff(3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	issues := []types.Issue{
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 10, EndLine: 10, Description: "context line"},
+	}
+	if _, err := client.PostIssues(3, diff, issues); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if requests[0].path != "/rest/api/1.0/projects/PRJ/repos/repo/pull-requests/3.diff" {
+		t.Errorf("Unexpected diff path: %s", requests[0].path)
+	}
+	if requests[0].auth != "Bearer token" {
+		t.Errorf("Expected bearer auth, got '%s'", requests[0].auth)
+	}
+
+	anchor := requests[1].body["anchor"].(map[string]any)
+	if anchor["line"] != float64(10) || anchor["lineType"] != "CONTEXT" || anchor["fileType"] != "TO" {
+		t.Errorf("Unexpected anchor: %v", anchor)
+	}
+}
+
+func TestPostQuestions(t *testing.T) {
+	var requests []recordedRequest
+	server := newTestServer(t, &requests)
+	defer server.Close()
+
+	client, err := NewClient(config.BitbucketConfig{BaseURL: server.URL, Workspace: "ws", Repository: "repo", AccessToken: "token"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	posted, err := client.PostQuestions(7, []byte(prDiff), []types.Question{
+		{FilePath: "main.go", Line: 12, Question: "Should c be exported?"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if posted != 1 || len(requests) != 1 {
+		t.Fatalf("Expected 1 question posted, got %d", posted)
+	}
+
+	inline := requests[0].body["inline"].(map[string]any)
+	if inline["path"] != "main.go" || inline["to"] != float64(12) {
+		t.Errorf("Unexpected inline anchor: %v", inline)
+	}
+	raw := requests[0].body["content"].(map[string]any)["raw"].(string)
+	if !strings.HasPrefix(raw, "**Question:** Should c be exported?") {
+		t.Errorf("Unexpected question comment: %s", raw)
+	}
+}
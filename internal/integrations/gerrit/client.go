@@ -0,0 +1,253 @@
+// Package gerrit fetches the diff of a change's patch set from Gerrit and posts review
+// findings back as robot comments, voting on a label by their severity.
+package gerrit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+const (
+	defaultLabel   = "Code-Review"
+	defaultRobotID = "diffpector"
+	// CurrentRevision is the latest patch set of a change
+	CurrentRevision = "current"
+)
+
+// jsonPrefix guards Gerrit JSON responses against XSSI, it precedes every body
+const jsonPrefix = ")]}'"
+
+type Client struct {
+	config config.GerritConfig
+	client *http.Client
+}
+
+// NewClient validates the configuration and creates a client. Requests are authenticated
+// with the username and the HTTP password generated in the Gerrit settings.
+func NewClient(cfg config.GerritConfig) (*Client, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("gerrit base_url is required")
+	}
+	if cfg.Username == "" || cfg.Password == "" {
+		return nil, fmt.Errorf("gerrit requires a username with an HTTP password")
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.Label == "" {
+		cfg.Label = defaultLabel
+	}
+	if cfg.Votes == nil {
+		cfg.Votes = map[string]int{"CRITICAL": -1}
+	}
+	if cfg.RobotID == "" {
+		cfg.RobotID = defaultRobotID
+	}
+
+	return &Client{
+		config: cfg,
+		client: &http.Client{},
+	}, nil
+}
+
+// ParseChange splits a change reference of the form <change>[/<patch set>] into the change
+// id and the revision, the current patch set when none is given
+func ParseChange(reference string) (change, revision string, err error) {
+	change, revision = reference, CurrentRevision
+	if before, after, found := strings.Cut(reference, "/"); found {
+		if _, err := strconv.Atoi(after); err != nil {
+			return "", "", fmt.Errorf("invalid patch set %q in %q", after, reference)
+		}
+		change, revision = before, after
+	}
+	if change == "" {
+		return "", "", fmt.Errorf("missing change in %q", reference)
+	}
+	return change, revision, nil
+}
+
+// PatchSetDiff returns the unified diff of a patch set against its parent
+func (c *Client) PatchSetDiff(change, revision string) ([]byte, error) {
+	req, err := http.NewRequest("GET", c.revisionURL(change, revision)+"/patch", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode patch: %w", err)
+	}
+	return extractDiff(patch), nil
+}
+
+// extractDiff drops the mail headers and the commit message git format-patch writes before
+// the diff, and the version signature after it
+func extractDiff(patch []byte) []byte {
+	if start := bytes.Index(patch, []byte("diff --git ")); start > 0 {
+		patch = patch[start:]
+	}
+	if end := bytes.LastIndex(patch, []byte("\n-- \n")); end >= 0 {
+		patch = patch[:end+1]
+	}
+	return patch
+}
+
+type reviewInput struct {
+	Message       string                         `json:"message"`
+	Tag           string                         `json:"tag"`
+	Labels        map[string]int                 `json:"labels,omitempty"`
+	RobotComments map[string][]robotCommentInput `json:"robot_comments,omitempty"`
+	Comments      map[string][]commentInput      `json:"comments,omitempty"`
+	// Drafts are left alone, only the comments of this review are published
+	Drafts string `json:"drafts"`
+}
+
+type commentInput struct {
+	// Line is omitted for file comments
+	Line       int    `json:"line,omitempty"`
+	Message    string `json:"message"`
+	Unresolved bool   `json:"unresolved"`
+}
+
+type robotCommentInput struct {
+	commentInput
+	RobotID    string            `json:"robot_id"`
+	RobotRunID string            `json:"robot_run_id"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// PostReview publishes the issues as robot comments and the questions as resolved comments
+// in a single review of the patch set. The label is voted with the lowest vote configured
+// for the severities found, no vote is cast when none is configured for them.
+func (c *Client) PostReview(change, revision string, issues []types.Issue, questions []types.Question, severities *severity.Registry) error {
+	runID := strconv.FormatInt(time.Now().Unix(), 10)
+
+	review := reviewInput{
+		Message:       summaryMessage(issues, questions),
+		Tag:           "autogenerated:diffpector",
+		RobotComments: make(map[string][]robotCommentInput),
+		Comments:      make(map[string][]commentInput),
+		Drafts:        "KEEP",
+	}
+
+	vote, voted := 0, false
+	for _, issue := range issues {
+		level := severities.Normalize(issue.Severity)
+		review.RobotComments[issue.FilePath] = append(review.RobotComments[issue.FilePath], robotCommentInput{
+			commentInput: commentInput{Line: commentLine(issue), Message: formatComment(issue, level), Unresolved: true},
+			RobotID:      c.config.RobotID,
+			RobotRunID:   runID,
+			Properties:   map[string]string{"severity": level},
+		})
+
+		for configured, configuredVote := range c.config.Votes {
+			if severities.Normalize(configured) == level && (!voted || configuredVote < vote) {
+				vote, voted = configuredVote, true
+			}
+		}
+	}
+	if voted {
+		review.Labels = map[string]int{c.config.Label: vote}
+	}
+
+	for _, question := range questions {
+		review.Comments[question.FilePath] = append(review.Comments[question.FilePath], commentInput{
+			Line:    question.Line,
+			Message: "Question: " + question.Question + "\n\nAsked by diffpector, non-blocking",
+		})
+	}
+
+	body, err := json.Marshal(review)
+	if err != nil {
+		return fmt.Errorf("failed to marshal review: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.revisionURL(change, revision)+"/review", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.do(req)
+	return err
+}
+
+// commentLine anchors the comment below the flagged code, issues without lines are file comments
+func commentLine(issue types.Issue) int {
+	if issue.EndLine >= issue.StartLine && issue.EndLine > 0 {
+		return issue.EndLine
+	}
+	return max(issue.StartLine, 0)
+}
+
+func formatComment(issue types.Issue, level string) string {
+	var comment strings.Builder
+	fmt.Fprintf(&comment, "%s: %s", level, issue.Description)
+	if issue.EndLine > issue.StartLine && issue.StartLine > 0 {
+		fmt.Fprintf(&comment, "\n\nLines %d-%d", issue.StartLine, issue.EndLine)
+	}
+	if issue.CodeSnippet != "" {
+		// Gerrit renders lines indented by a space as preformatted text
+		comment.WriteString("\n")
+		for line := range strings.SplitSeq(issue.CodeSnippet, "\n") {
+			comment.WriteString("\n " + line)
+		}
+	}
+	return comment.String()
+}
+
+func summaryMessage(issues []types.Issue, questions []types.Question) string {
+	if len(issues) == 0 && len(questions) == 0 {
+		return "diffpector found no issues"
+	}
+	message := fmt.Sprintf("diffpector found %d issue(s)", len(issues))
+	if len(questions) > 0 {
+		message += fmt.Sprintf(" and has %d question(s) for the author", len(questions))
+	}
+	return message
+}
+
+func (c *Client) revisionURL(change, revision string) string {
+	return fmt.Sprintf("%s/a/changes/%s/revisions/%s", c.config.BaseURL, url.PathEscape(change), url.PathEscape(revision))
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	req.SetBasicAuth(c.config.Username, c.config.Password)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Warn("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("gerrit request failed with status: %d. Details: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return bytes.TrimPrefix(body, []byte(jsonPrefix)), nil
+}
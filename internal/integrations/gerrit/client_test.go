@@ -0,0 +1,206 @@
+package gerrit
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/severity"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+const changeDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,4 +10,5 @@ func main() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+ 	fmt.Println(a, b)
+ }
+`
+
+const formatPatch = `From 4a5b6c Mon Sep 17 00:00:00 2001
+From: Jane Doe <jane@example.com>
+Date: Tue, 1 Oct 2024 10:00:00 +0200
+Subject: [PATCH] Change values
+
+Change-Id: I0123456789abcdef
+---
+ main.go | 3 ++-
+ 1 file changed, 2 insertions(+), 1 deletion(-)
+
+` + changeDiff + "-- \n2.39.0\n"
+
+type recordedRequest struct {
+	method string
+	path   string
+	auth   string
+	body   []byte
+}
+
+func newTestServer(requests *[]recordedRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*requests = append(*requests, recordedRequest{method: r.Method, path: r.URL.EscapedPath(), auth: r.Header.Get("Authorization"), body: body})
+
+		if r.Method == "GET" {
+			_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString([]byte(formatPatch))))
+			return
+		}
+		_, _ = w.Write([]byte(")]}'\n{\"labels\":{}}"))
+	}))
+}
+
+func TestNewClientValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      config.GerritConfig
+		expectError bool
+	}{
+		{"http password", config.GerritConfig{BaseURL: "https://review.example.com", Username: "me", Password: "secret"}, false},
+		{"missing password", config.GerritConfig{BaseURL: "https://review.example.com", Username: "me"}, true},
+		{"missing base url", config.GerritConfig{Username: "me", Password: "secret"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.config)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseChange(t *testing.T) {
+	tests := []struct {
+		reference        string
+		change, revision string
+		expectError      bool
+	}{
+		{"1234", "1234", CurrentRevision, false},
+		{"1234/3", "1234", "3", false},
+		{"myProject~main~I0123456789abcdef", "myProject~main~I0123456789abcdef", CurrentRevision, false},
+		{"1234/latest", "", "", true},
+		{"/3", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			change, revision, err := ParseChange(tt.reference)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+			if err != nil || change != tt.change || revision != tt.revision {
+				t.Errorf("Expected %s/%s, got %s/%s, %v", tt.change, tt.revision, change, revision, err)
+			}
+		})
+	}
+}
+
+func TestChangeReview(t *testing.T) {
+	var requests []recordedRequest
+	server := newTestServer(&requests)
+	defer server.Close()
+
+	client, err := NewClient(config.GerritConfig{
+		BaseURL:  server.URL + "/",
+		Username: "me",
+		Password: "secret",
+		Votes:    map[string]int{"CRITICAL": -2, "WARNING": -1},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	diff, err := client.PatchSetDiff("1234", "2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(diff) != changeDiff {
+		t.Errorf("Expected the diff without the mail headers and signature, got:\n%s", diff)
+	}
+
+	issues := []types.Issue{
+		{Severity: "WARNING", FilePath: "main.go", StartLine: 11, EndLine: 12, Description: "changed values", CodeSnippet: "b := 3"},
+		{Severity: "MINOR", FilePath: "main.go", Description: "file level"},
+	}
+	questions := []types.Question{{FilePath: "main.go", Line: 12, Question: "Why 4?"}}
+	if err := client.PostReview("1234", "2", issues, questions, severity.Default()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	if requests[0].path != "/a/changes/1234/revisions/2/patch" || requests[0].auth[:6] != "Basic " {
+		t.Errorf("Unexpected patch request %s with auth %q", requests[0].path, requests[0].auth)
+	}
+	if requests[1].method != "POST" || requests[1].path != "/a/changes/1234/revisions/2/review" {
+		t.Errorf("Unexpected review request %s %s", requests[1].method, requests[1].path)
+	}
+
+	var review reviewInput
+	if err := json.Unmarshal(requests[1].body, &review); err != nil {
+		t.Fatalf("Invalid review payload: %v", err)
+	}
+	if review.Labels["Code-Review"] != -1 {
+		t.Errorf("Expected the WARNING vote on Code-Review, got %v", review.Labels)
+	}
+	comments := review.RobotComments["main.go"]
+	if len(comments) != 2 {
+		t.Fatalf("Expected 2 robot comments, got %+v", review.RobotComments)
+	}
+	if comments[0].Line != 12 || comments[0].RobotID != "diffpector" || comments[0].RobotRunID == "" || comments[0].Properties["severity"] != "WARNING" {
+		t.Errorf("Unexpected robot comment %+v", comments[0])
+	}
+	if comments[0].Message != "WARNING: changed values\n\nLines 11-12\n\n b := 3" {
+		t.Errorf("Unexpected comment message %q", comments[0].Message)
+	}
+	if comments[1].Line != 0 || !comments[1].Unresolved {
+		t.Errorf("Expected an unresolved file comment, got %+v", comments[1])
+	}
+	if asked := review.Comments["main.go"]; len(asked) != 1 || asked[0].Line != 12 || asked[0].Unresolved {
+		t.Errorf("Expected a resolved question comment, got %+v", asked)
+	}
+}
+
+func TestChangeReview_NoVote(t *testing.T) {
+	var requests []recordedRequest
+	server := newTestServer(&requests)
+	defer server.Close()
+
+	client, err := NewClient(config.GerritConfig{BaseURL: server.URL, Username: "me", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	issues := []types.Issue{{Severity: "MINOR", FilePath: "main.go", StartLine: 11, EndLine: 11, Description: "nit"}}
+	if err := client.PostReview("1234", CurrentRevision, issues, nil, severity.Default()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var review reviewInput
+	if err := json.Unmarshal(requests[0].body, &review); err != nil {
+		t.Fatalf("Invalid review payload: %v", err)
+	}
+	if review.Labels != nil {
+		t.Errorf("Expected no vote without a CRITICAL finding, got %v", review.Labels)
+	}
+	if review.Message != "diffpector found 1 issue(s)" {
+		t.Errorf("Unexpected review message %q", review.Message)
+	}
+}
@@ -0,0 +1,139 @@
+// Package slack posts review summaries to a Slack channel through an incoming webhook or a
+// bot token.
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+const defaultAPIURL = "https://slack.com/api"
+
+type Client struct {
+	config config.SlackConfig
+	apiURL string
+	client *http.Client
+}
+
+// NewClient validates the configuration and creates a client. The incoming webhook is used
+// when set, the bot token with the channel otherwise.
+func NewClient(cfg config.SlackConfig) (*Client, error) {
+	if cfg.WebhookURL == "" && cfg.BotToken == "" {
+		return nil, fmt.Errorf("slack requires a webhook_url or a bot_token")
+	}
+	if cfg.WebhookURL == "" && cfg.Channel == "" {
+		return nil, fmt.Errorf("slack bot_token requires a channel")
+	}
+
+	return &Client{
+		config: cfg,
+		apiURL: defaultAPIURL,
+		client: &http.Client{},
+	}, nil
+}
+
+// Message is a Slack message with Block Kit blocks, Text is the notification fallback
+type Message struct {
+	Channel string  `json:"channel,omitempty"`
+	Text    string  `json:"text"`
+	Blocks  []Block `json:"blocks,omitempty"`
+}
+
+type Block struct {
+	Type string `json:"type"`
+	Text *Text  `json:"text,omitempty"`
+}
+
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Header returns a header block with plain text
+func Header(text string) Block {
+	return Block{Type: "header", Text: &Text{Type: "plain_text", Text: text}}
+}
+
+// Section returns a section block with mrkdwn text
+func Section(markdown string) Block {
+	return Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: markdown}}
+}
+
+// Location formats a file position, as a link when a link template is configured. The
+// template placeholders {path} and {line} are replaced by the position.
+func (c *Client) Location(path string, line int) string {
+	path = platform.NormalizePath(path)
+	label := fmt.Sprintf("%s:%d", path, line)
+	if c.config.LinkTemplate == "" {
+		return "`" + label + "`"
+	}
+
+	link := strings.NewReplacer("{path}", path, "{line}", strconv.Itoa(line)).Replace(c.config.LinkTemplate)
+	return fmt.Sprintf("<%s|%s>", link, label)
+}
+
+// Post sends the message to the configured webhook or channel
+func (c *Client) Post(message Message) error {
+	if c.config.WebhookURL != "" {
+		_, err := c.post(c.config.WebhookURL, "", message)
+		return err
+	}
+
+	message.Channel = c.config.Channel
+	body, err := c.post(c.apiURL+"/chat.postMessage", c.config.BotToken, message)
+	if err != nil {
+		return err
+	}
+
+	// The Web API answers 200 with ok false on errors
+	var response struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to parse slack response: %w", err)
+	}
+	if !response.OK {
+		return fmt.Errorf("slack API error: %s", response.Error)
+	}
+	return nil
+}
+
+func (c *Client) post(url, token string, message Message) ([]byte, error) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("slack request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read slack response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("slack returned %s: %s", resp.Status, string(body))
+	}
+	return body, nil
+}
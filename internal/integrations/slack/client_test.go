@@ -0,0 +1,94 @@
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestNewClient_Validation(t *testing.T) {
+	if _, err := NewClient(config.SlackConfig{}); err == nil {
+		t.Error("Expected an error without webhook or token")
+	}
+	if _, err := NewClient(config.SlackConfig{BotToken: "xoxb-1"}); err == nil {
+		t.Error("Expected an error for a bot token without channel")
+	}
+	if _, err := NewClient(config.SlackConfig{WebhookURL: "https://hooks.slack.com/x"}); err != nil {
+		t.Errorf("Unexpected error for a webhook: %v", err)
+	}
+}
+
+func TestPost_Webhook(t *testing.T) {
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Webhook requests must not be authenticated")
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode message: %v", err)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(config.SlackConfig{WebhookURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	err = client.Post(Message{Text: "fallback", Blocks: []Block{Header("Review"), Section("*1* critical")}})
+	if err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	if received.Text != "fallback" || len(received.Blocks) != 2 || received.Blocks[1].Text.Type != "mrkdwn" {
+		t.Errorf("Unexpected message: %+v", received)
+	}
+}
+
+func TestPost_BotToken(t *testing.T) {
+	var auth, path string
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, path = r.Header.Get("Authorization"), r.URL.Path
+		json.NewDecoder(r.Body).Decode(&received)
+		if received.Channel == "#unknown" {
+			w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(config.SlackConfig{BotToken: "xoxb-1", Channel: "#reviews"})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.apiURL = server.URL
+
+	if err := client.Post(Message{Text: "summary"}); err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	if auth != "Bearer xoxb-1" || path != "/chat.postMessage" || received.Channel != "#reviews" {
+		t.Errorf("Unexpected request: auth=%q path=%q channel=%q", auth, path, received.Channel)
+	}
+
+	client.config.Channel = "#unknown"
+	if err := client.Post(Message{Text: "summary"}); err == nil {
+		t.Error("Expected the API error to be returned")
+	}
+}
+
+func TestLocation(t *testing.T) {
+	client, _ := NewClient(config.SlackConfig{WebhookURL: "https://hooks.slack.com/x"})
+	if got := client.Location("pkg/db.go", 12); got != "`pkg/db.go:12`" {
+		t.Errorf("Unexpected plain location: %s", got)
+	}
+
+	client.config.LinkTemplate = "https://github.com/org/repo/blob/main/{path}#L{line}"
+	if got := client.Location("pkg/db.go", 12); got != "<https://github.com/org/repo/blob/main/pkg/db.go#L12|pkg/db.go:12>" {
+		t.Errorf("Unexpected linked location: %s", got)
+	}
+}
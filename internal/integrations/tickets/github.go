@@ -0,0 +1,79 @@
+package tickets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+const defaultGitHubAPI = "https://api.github.com"
+
+// githubReferenceRegexes match #123 and GH-123 in messages and a leading issue number in
+// branch names such as 123-fix-login or fix/123-login
+var githubReferenceRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`(?:^|[^\w&])#([0-9]+)\b`),
+	regexp.MustCompile(`\bGH-([0-9]+)\b`),
+	regexp.MustCompile(`(?:^|/)([0-9]+)[-_]`),
+}
+
+type GitHubFetcher struct {
+	config config.TicketsConfig
+	client *http.Client
+}
+
+// NewGitHubFetcher resolves the references against the issues of the configured repository,
+// the token is only needed for private ones
+func NewGitHubFetcher(cfg config.TicketsConfig) (*GitHubFetcher, error) {
+	if owner, name, found := strings.Cut(cfg.Repository, "/"); !found || owner == "" || name == "" {
+		return nil, fmt.Errorf("github tickets require the repository as owner/name, got %q", cfg.Repository)
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultGitHubAPI
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &GitHubFetcher{
+		config: cfg,
+		client: &http.Client{},
+	}, nil
+}
+
+func (f *GitHubFetcher) References(text string) []string {
+	var ids []string
+	for _, regex := range githubReferenceRegexes {
+		for _, match := range regex.FindAllStringSubmatch(text, -1) {
+			ids = append(ids, "#"+match[1])
+		}
+	}
+	return ids
+}
+
+func (f *GitHubFetcher) Fetch(id string) (Ticket, error) {
+	number := strings.TrimPrefix(id, "#")
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/repos/%s/issues/%s", f.config.BaseURL, f.config.Repository, number), nil)
+	if err != nil {
+		return Ticket{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	if f.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+f.config.Token)
+	}
+
+	body, err := getJSON(f.client, req)
+	if err != nil {
+		return Ticket{}, err
+	}
+
+	var issue struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+	if err := json.Unmarshal(body, &issue); err != nil {
+		return Ticket{}, fmt.Errorf("failed to decode issue: %w", err)
+	}
+
+	return Ticket{ID: "#" + number, Title: issue.Title, Description: issue.Body}, nil
+}
@@ -0,0 +1,72 @@
+package tickets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// jiraKeyRegex matches issue keys such as PAY-42, also inside branch names
+var jiraKeyRegex = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-[1-9][0-9]*\b`)
+
+type JiraFetcher struct {
+	config config.TicketsConfig
+	client *http.Client
+}
+
+// NewJiraFetcher authenticates with the account email and an API token on Jira Cloud, or
+// with a personal access token alone on Jira Data Center
+func NewJiraFetcher(cfg config.TicketsConfig) (*JiraFetcher, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("jira base_url is required")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("jira requires an API token")
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &JiraFetcher{
+		config: cfg,
+		client: &http.Client{},
+	}, nil
+}
+
+func (f *JiraFetcher) References(text string) []string {
+	return jiraKeyRegex.FindAllString(text, -1)
+}
+
+func (f *JiraFetcher) Fetch(id string) (Ticket, error) {
+	endpoint := fmt.Sprintf("%s/rest/api/2/issue/%s?fields=summary,description", f.config.BaseURL, url.PathEscape(id))
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return Ticket{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	if f.config.Username != "" {
+		req.SetBasicAuth(f.config.Username, f.config.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+f.config.Token)
+	}
+
+	body, err := getJSON(f.client, req)
+	if err != nil {
+		return Ticket{}, err
+	}
+
+	var issue struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary     string `json:"summary"`
+			Description string `json:"description"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(body, &issue); err != nil {
+		return Ticket{}, fmt.Errorf("failed to decode issue: %w", err)
+	}
+
+	return Ticket{ID: issue.Key, Title: issue.Fields.Summary, Description: issue.Fields.Description}, nil
+}
@@ -0,0 +1,114 @@
+// Package tickets looks up the issue tracker tickets a change references, so the review
+// knows what the change is meant to do.
+package tickets
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+const (
+	// maxTickets bounds the tickets added to the prompt
+	maxTickets = 3
+	// maxDescriptionLength bounds the description of each ticket, in characters
+	maxDescriptionLength = 1500
+)
+
+type Ticket struct {
+	ID          string
+	Title       string
+	Description string
+}
+
+// Fetcher finds the references to the tickets of an issue tracker and loads them
+type Fetcher interface {
+	// References returns the ticket IDs mentioned in the text, in the tracker syntax
+	References(text string) []string
+	Fetch(id string) (Ticket, error)
+}
+
+// NewFetcher creates the fetcher of the configured provider
+func NewFetcher(cfg config.TicketsConfig) (Fetcher, error) {
+	switch cfg.Provider {
+	case "jira":
+		return NewJiraFetcher(cfg)
+	case "github":
+		return NewGitHubFetcher(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported tickets provider %q, use jira or github", cfg.Provider)
+	}
+}
+
+// Lookup fetches the tickets the texts reference, in the order they are first mentioned and
+// at most maxTickets. Tickets failing to load are skipped with a warning.
+func Lookup(fetcher Fetcher, texts ...string) []Ticket {
+	var ids []string
+	for _, text := range texts {
+		for _, id := range fetcher.References(text) {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) > maxTickets {
+		ids = ids[:maxTickets]
+	}
+
+	var found []Ticket
+	for _, id := range ids {
+		ticket, err := fetcher.Fetch(id)
+		if err != nil {
+			fmt.Printf("[!] Failed to fetch ticket %s: %v\n", id, err)
+			continue
+		}
+		found = append(found, ticket)
+	}
+	return found
+}
+
+// Format writes the tickets as the intent context of the review prompt
+func Format(tickets []Ticket) string {
+	var context strings.Builder
+	for _, ticket := range tickets {
+		fmt.Fprintf(&context, "[%s] %s\n", ticket.ID, ticket.Title)
+		if description := strings.TrimSpace(ticket.Description); description != "" {
+			if runes := []rune(description); len(runes) > maxDescriptionLength {
+				description = string(runes[:maxDescriptionLength]) + "\n... (truncated)"
+			}
+			context.WriteString(description + "\n")
+		}
+		context.WriteString("\n")
+	}
+	return strings.TrimSuffix(context.String(), "\n")
+}
+
+// getJSON sends an authenticated GET request and returns the body of a successful response
+func getJSON(client *http.Client, req *http.Request) ([]byte, error) {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Warn("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("request failed with status: %d. Details: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,122 @@
+package tickets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestNewFetcherValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      config.TicketsConfig
+		expectError bool
+	}{
+		{"jira", config.TicketsConfig{Provider: "jira", BaseURL: "https://example.atlassian.net", Username: "me@example.com", Token: "token"}, false},
+		{"jira without token", config.TicketsConfig{Provider: "jira", BaseURL: "https://example.atlassian.net"}, true},
+		{"github", config.TicketsConfig{Provider: "github", Repository: "org/repo"}, false},
+		{"github without repository", config.TicketsConfig{Provider: "github", Repository: "repo"}, true},
+		{"unknown provider", config.TicketsConfig{Provider: "linear"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFetcher(tt.config)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	jira, _ := NewJiraFetcher(config.TicketsConfig{BaseURL: "https://example.atlassian.net", Token: "token"})
+	github, _ := NewGitHubFetcher(config.TicketsConfig{Repository: "org/repo"})
+
+	tests := []struct {
+		fetcher  Fetcher
+		text     string
+		expected []string
+	}{
+		{jira, "feature/PAY-42-refunds", []string{"PAY-42"}},
+		{jira, "Refund partial payments\n\nRefs: PAY-43, OPS_2-7", []string{"PAY-43", "OPS_2-7"}},
+		{jira, "Lowercase pay-42 and PAY-0 are no keys", nil},
+		{github, "Fix login (#12), see GH-15", []string{"#12", "#15"}},
+		{github, "fix/123-login", []string{"#123"}},
+		{github, "Escape &#39; in titles", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := tt.fetcher.References(tt.text); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLookup_Jira(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if user, token, ok := r.BasicAuth(); !ok || user != "me@example.com" || token != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/rest/api/2/issue/PAY-42" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"key":"PAY-42","fields":{"summary":"Refund partial payments","description":"Only the captured amount is refunded."}}`))
+	}))
+	defer server.Close()
+
+	fetcher, err := NewFetcher(config.TicketsConfig{Provider: "jira", BaseURL: server.URL + "/", Username: "me@example.com", Token: "token"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	found := Lookup(fetcher, "feature/PAY-42-refunds", "Refund partial payments\n\nRefs: PAY-42, PAY-7")
+	if !slices.Equal(paths, []string{"/rest/api/2/issue/PAY-42", "/rest/api/2/issue/PAY-7"}) {
+		t.Errorf("Expected each ticket fetched once, got %v", paths)
+	}
+	if len(found) != 1 {
+		t.Fatalf("Expected the missing ticket to be skipped, got %+v", found)
+	}
+
+	expected := "[PAY-42] Refund partial payments\nOnly the captured amount is refunded.\n"
+	if context := Format(found); context != expected {
+		t.Errorf("Expected %q, got %q", expected, context)
+	}
+}
+
+func TestLookup_GitHub(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/org/repo/issues/12" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"number":12,"title":"Login fails with SSO","body":"` + strings.Repeat("a", maxDescriptionLength+10) + `"}`))
+	}))
+	defer server.Close()
+
+	fetcher, err := NewFetcher(config.TicketsConfig{Provider: "github", BaseURL: server.URL, Token: "token", Repository: "org/repo"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	found := Lookup(fetcher, "12-sso-login")
+	if len(found) != 1 || found[0].ID != "#12" || found[0].Title != "Login fails with SSO" {
+		t.Fatalf("Expected issue #12, got %+v", found)
+	}
+	if context := Format(found); !strings.HasSuffix(context, strings.Repeat("a", 10)+"\n... (truncated)\n") {
+		t.Errorf("Expected the description to be truncated, got %q", context[len(context)-40:])
+	}
+}
@@ -0,0 +1,213 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAzureAPIVersion is the GA api-version used when none is configured
+	DefaultAzureAPIVersion = "2024-10-21"
+
+	azureAuthorityURL  = "https://login.microsoftonline.com"
+	azureTokenScope    = "https://cognitiveservices.azure.com/.default"
+	azureTokenLeadTime = time.Minute
+)
+
+// AzureConfig routes the requests to an Azure OpenAI deployment. The resource is
+// authenticated with the API key of the provider config or, without one, with a Microsoft
+// Entra ID (AAD) token: either a static ADToken or one obtained with client credentials.
+type AzureConfig struct {
+	// Deployment defaults to the model name
+	Deployment   string
+	APIVersion   string
+	ADToken      string
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+}
+
+// AzureOpenAIProvider talks to the chat completions of an Azure OpenAI deployment. The
+// deployment is part of the URL, so the model is not sent in the request body.
+type AzureOpenAIProvider struct {
+	*OpenAIProvider
+	deployment string
+	apiVersion string
+	tokens     *aadTokenSource
+	adToken    string
+}
+
+func NewAzureOpenAIProvider(endpoint, model, apiKey string, azure AzureConfig) (*AzureOpenAIProvider, error) {
+	deployment := azure.Deployment
+	if deployment == "" {
+		deployment = model
+	}
+	if deployment == "" {
+		return nil, fmt.Errorf("azure-openai requires llm.azure.deployment or llm.model")
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("azure-openai requires llm.base_url, e.g. https://<resource>.openai.azure.com")
+	}
+
+	apiVersion := azure.APIVersion
+	if apiVersion == "" {
+		apiVersion = DefaultAzureAPIVersion
+	}
+
+	endpoint = strings.TrimRight(endpoint, "/")
+	p := &AzureOpenAIProvider{
+		OpenAIProvider: NewOpenAIProvider(endpoint, "", apiKey),
+		deployment:     deployment,
+		apiVersion:     apiVersion,
+		adToken:        azure.ADToken,
+	}
+	p.chatURL = p.url("/openai/deployments/" + url.PathEscape(deployment) + "/chat/completions")
+	p.authorize = p.azureAuth
+
+	if apiKey == "" && azure.ADToken == "" {
+		if azure.TenantID == "" || azure.ClientID == "" || azure.ClientSecret == "" {
+			return nil, fmt.Errorf("azure-openai requires llm.api_key, llm.azure.ad_token or the tenant_id, client_id and client_secret of an app registration")
+		}
+		p.tokens = &aadTokenSource{
+			authorityURL: azureAuthorityURL,
+			tenantID:     azure.TenantID,
+			clientID:     azure.ClientID,
+			clientSecret: azure.ClientSecret,
+			// Token requests stay off the provider transport, which may meter LLM traffic
+			client: &http.Client{},
+		}
+	}
+
+	return p, nil
+}
+
+func (p *AzureOpenAIProvider) GetModel() string {
+	return p.deployment
+}
+
+// HealthCheck verifies the resource is reachable and accepts the credentials. Deployments
+// cannot be listed with data plane credentials, a missing deployment is reported by the
+// first review request.
+func (p *AzureOpenAIProvider) HealthCheck() error {
+	req, err := http.NewRequest("GET", p.url("/openai/models"), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := p.authorize(req); err != nil {
+		return fmt.Errorf("cannot authenticate to Azure OpenAI: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach Azure OpenAI at %s: %w. Check llm.base_url", p.baseURL, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Warn("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("azure OpenAI at %s rejected the credentials, check llm.api_key or llm.azure", p.baseURL)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("azure OpenAI at %s answered with status %d: %s", p.baseURL, resp.StatusCode, string(body))
+	}
+}
+
+func (p *AzureOpenAIProvider) url(path string) string {
+	return p.baseURL + path + "?api-version=" + url.QueryEscape(p.apiVersion)
+}
+
+// azureAuth sends the API key in the api-key header, AAD tokens go in the Authorization header
+func (p *AzureOpenAIProvider) azureAuth(req *http.Request) error {
+	switch {
+	case p.apiKey != "":
+		req.Header.Set("api-key", p.apiKey)
+	case p.adToken != "":
+		req.Header.Set("Authorization", "Bearer "+p.adToken)
+	default:
+		token, err := p.tokens.Token()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// aadTokenSource obtains tokens with the client credentials flow and reuses them until
+// shortly before they expire
+type aadTokenSource struct {
+	authorityURL string
+	tenantID     string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+type aadTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (s *aadTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Add(azureTokenLeadTime).Before(s.expiresAt) {
+		return s.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {s.clientID},
+		"client_secret": {s.clientSecret},
+		"scope":         {azureTokenScope},
+	}
+	tokenURL := s.authorityURL + "/" + url.PathEscape(s.tenantID) + "/oauth2/v2.0/token"
+
+	resp, err := s.client.PostForm(tokenURL, form)
+	if err != nil {
+		return "", fmt.Errorf("failed to request an AAD token: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Warn("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the AAD token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("AAD token request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp aadTokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal the AAD token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("AAD token response carries no access token")
+	}
+
+	s.token = tokenResp.AccessToken
+	s.expiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	return s.token, nil
+}
@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const azureChatResponse = `{"choices": [{"message": {"role": "assistant", "content": "looks good"}, "finish_reason": "stop"}]}`
+
+func TestAzureOpenAIProvider_RoutesToDeployment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/deployments/review-gpt4o/chat/completions", r.URL.Path)
+		assert.Equal(t, "2024-06-01", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "secret", r.Header.Get("api-key"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.NotContains(t, body, "model")
+
+		_, _ = w.Write([]byte(azureChatResponse))
+	}))
+	defer server.Close()
+
+	provider, err := NewAzureOpenAIProvider(server.URL+"/", "gpt-4o", "secret", AzureConfig{
+		Deployment: "review-gpt4o",
+		APIVersion: "2024-06-01",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "review-gpt4o", provider.GetModel())
+
+	resp, err := provider.ChatWithTools(context.Background(), []Message{{Role: "user", Content: "review"}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "looks good", resp.Content)
+}
+
+func TestAzureOpenAIProvider_DefaultsDeploymentAndVersion(t *testing.T) {
+	provider, err := NewAzureOpenAIProvider("https://res.openai.azure.com", "gpt-4o", "secret", AzureConfig{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "gpt-4o", provider.GetModel())
+	assert.Equal(t, "https://res.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version="+DefaultAzureAPIVersion, provider.chatURL)
+}
+
+func TestAzureOpenAIProvider_RequiresCredentials(t *testing.T) {
+	_, err := NewAzureOpenAIProvider("https://res.openai.azure.com", "gpt-4o", "", AzureConfig{TenantID: "tenant"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "client_secret")
+
+	_, err = NewAzureOpenAIProvider("https://res.openai.azure.com", "", "secret", AzureConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deployment")
+}
+
+func TestAzureOpenAIProvider_StaticADToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer aad-token", r.Header.Get("Authorization"))
+		assert.Empty(t, r.Header.Get("api-key"))
+		_, _ = w.Write([]byte(azureChatResponse))
+	}))
+	defer server.Close()
+
+	provider, err := NewAzureOpenAIProvider(server.URL, "gpt-4o", "", AzureConfig{ADToken: "aad-token"})
+	require.NoError(t, err)
+
+	_, err = provider.Generate(context.Background(), "review")
+	require.NoError(t, err)
+}
+
+func TestAzureOpenAIProvider_ClientCredentials(t *testing.T) {
+	tokenRequests := 0
+	authority := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		assert.Equal(t, "/tenant/oauth2/v2.0/token", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+		assert.Equal(t, "app", r.Form.Get("client_id"))
+		assert.Equal(t, "app-secret", r.Form.Get("client_secret"))
+		assert.Equal(t, azureTokenScope, r.Form.Get("scope"))
+		_, _ = w.Write([]byte(`{"access_token": "issued", "expires_in": 3600}`))
+	}))
+	defer authority.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer issued", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(azureChatResponse))
+	}))
+	defer server.Close()
+
+	provider, err := NewAzureOpenAIProvider(server.URL, "gpt-4o", "", AzureConfig{
+		TenantID:     "tenant",
+		ClientID:     "app",
+		ClientSecret: "app-secret",
+	})
+	require.NoError(t, err)
+	provider.tokens.authorityURL = authority.URL
+
+	for range 2 {
+		_, err = provider.Generate(context.Background(), "review")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, tokenRequests, "the token should be reused until it expires")
+}
+
+func TestAzureOpenAIProvider_HealthCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		errorContains string
+	}{
+		{"credentials accepted", http.StatusOK, ""},
+		{"credentials rejected", http.StatusUnauthorized, "llm.api_key or llm.azure"},
+		{"server error", http.StatusInternalServerError, "status 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/openai/models", r.URL.Path)
+				assert.Equal(t, DefaultAzureAPIVersion, r.URL.Query().Get("api-version"))
+				assert.Equal(t, "secret", r.Header.Get("api-key"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			provider, err := NewAzureOpenAIProvider(server.URL, "gpt-4o", "secret", AzureConfig{})
+			require.NoError(t, err)
+
+			err = provider.HealthCheck()
+			if tt.errorContains == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("Expected error containing %q, got: %v", tt.errorContains, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultEmbeddedContextSize = 4096
+	embeddedMaxTokens          = 4096
+	// chatMLEnd closes a turn of the ChatML template most instruction tuned GGUF models use
+	chatMLEnd = "<|im_end|>"
+)
+
+// EmbeddedConfig configures the in-process inference of a GGUF model
+type EmbeddedConfig struct {
+	ModelPath string
+	// ContextSize is the context window in tokens, 4096 by default
+	ContextSize int
+	// Threads defaults to the number of CPUs
+	Threads int
+}
+
+// embeddedModel is a loaded GGUF model. Implementations are not safe for concurrent use.
+type embeddedModel interface {
+	Predict(prompt string, maxTokens, threads int, stopWords []string) (string, error)
+	Free()
+}
+
+// EmbeddedProvider runs a GGUF model inside the process through the llama.cpp bindings,
+// reviewing fully offline without a server. The bindings are only linked in binaries
+// built with the llama tag.
+type EmbeddedProvider struct {
+	modelPath string
+	threads   int
+	mu        sync.Mutex
+	model     embeddedModel
+}
+
+func NewEmbeddedProvider(cfg EmbeddedConfig) (*EmbeddedProvider, error) {
+	if cfg.ModelPath == "" {
+		return nil, fmt.Errorf("the embedded provider requires llm.embedded.model_path")
+	}
+	if cfg.ContextSize <= 0 {
+		cfg.ContextSize = defaultEmbeddedContextSize
+	}
+	if cfg.Threads <= 0 {
+		cfg.Threads = runtime.NumCPU()
+	}
+
+	model, err := loadEmbeddedModel(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EmbeddedProvider{
+		modelPath: cfg.ModelPath,
+		threads:   cfg.Threads,
+		model:     model,
+	}, nil
+}
+
+func (p *EmbeddedProvider) GetModel() string {
+	return p.modelPath
+}
+
+func (p *EmbeddedProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	return p.predict(ctx, renderChatPrompt([]Message{{Role: "user", Content: prompt}}, nil, nil))
+}
+
+func (p *EmbeddedProvider) ChatWithTools(ctx context.Context, messages []Message, tools []Tool) (*ChatResponse, error) {
+	return p.ChatWithSchema(ctx, messages, tools, nil)
+}
+
+// ChatWithSchema describes the tools and the schema in the prompt, the bindings cannot
+// constrain the generation. Tool calls are read from JSON objects in the answer.
+func (p *EmbeddedProvider) ChatWithSchema(ctx context.Context, messages []Message, tools []Tool, schema *ResponseSchema) (*ChatResponse, error) {
+	content, err := p.predict(ctx, renderChatPrompt(messages, tools, schema))
+	if err != nil {
+		return nil, err
+	}
+
+	chatResp := &ChatResponse{Content: content}
+	if schema == nil && len(tools) > 0 {
+		if toolCall, ok := parseToolCallContent(content); ok {
+			chatResp.ToolCalls = append(chatResp.ToolCalls, toolCall)
+			chatResp.Content = ""
+		}
+	}
+	return chatResp, nil
+}
+
+// HealthCheck always passes, the model is loaded when the provider is created
+func (p *EmbeddedProvider) HealthCheck() error {
+	return nil
+}
+
+// Close releases the memory of the model
+func (p *EmbeddedProvider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.model.Free()
+}
+
+// predict runs the model unless the context is done. The bindings cannot stop a running
+// prediction, so the context is only checked before it starts.
+func (p *EmbeddedProvider) predict(ctx context.Context, prompt string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	answer, err := p.model.Predict(prompt, embeddedMaxTokens, p.threads, []string{chatMLEnd})
+	if err != nil {
+		return "", fmt.Errorf("embedded inference failed: %w", err)
+	}
+	return strings.TrimSpace(strings.TrimSuffix(answer, chatMLEnd)), nil
+}
+
+// renderChatPrompt writes the conversation in the ChatML template, ending with an open
+// assistant turn. Tools and the schema are described in a leading system turn.
+func renderChatPrompt(messages []Message, tools []Tool, schema *ResponseSchema) string {
+	var builder strings.Builder
+
+	var instructions []string
+	if len(tools) > 0 {
+		definitions, _ := json.Marshal(tools)
+		instructions = append(instructions, "You can call these tools: "+string(definitions)+
+			"\nTo call a tool, answer only with a JSON object {\"name\": \"<tool>\", \"arguments\": {...}}.")
+	}
+	if schema != nil {
+		definition, _ := json.Marshal(schema.Schema)
+		instructions = append(instructions, "Answer only with a JSON object following this schema: "+string(definition))
+	}
+	if len(instructions) > 0 {
+		writeChatMLTurn(&builder, "system", strings.Join(instructions, "\n\n"))
+	}
+
+	for _, message := range messages {
+		writeChatMLTurn(&builder, message.Role, message.Content)
+	}
+	builder.WriteString("<|im_start|>assistant\n")
+
+	return builder.String()
+}
+
+func writeChatMLTurn(builder *strings.Builder, role, content string) {
+	builder.WriteString("<|im_start|>" + role + "\n")
+	builder.WriteString(content)
+	builder.WriteString(chatMLEnd + "\n")
+}
@@ -0,0 +1,34 @@
+//go:build llama
+
+package llm
+
+import (
+	"fmt"
+
+	llama "github.com/go-skynet/go-llama.cpp"
+)
+
+type llamaModel struct {
+	llm *llama.LLama
+}
+
+func loadEmbeddedModel(cfg EmbeddedConfig) (embeddedModel, error) {
+	model, err := llama.New(cfg.ModelPath, llama.SetContext(cfg.ContextSize), llama.EnableF16Memory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GGUF model %s: %w", cfg.ModelPath, err)
+	}
+	return &llamaModel{llm: model}, nil
+}
+
+func (m *llamaModel) Predict(prompt string, maxTokens, threads int, stopWords []string) (string, error) {
+	return m.llm.Predict(prompt,
+		llama.SetTokens(maxTokens),
+		llama.SetThreads(threads),
+		llama.SetTemperature(0.2),
+		llama.SetStopWords(stopWords...),
+	)
+}
+
+func (m *llamaModel) Free() {
+	m.llm.Free()
+}
@@ -0,0 +1,11 @@
+//go:build !llama
+
+package llm
+
+import "fmt"
+
+// The llama.cpp bindings need cgo and a compiled libbinding.a, they are left out of the
+// default build
+func loadEmbeddedModel(cfg EmbeddedConfig) (embeddedModel, error) {
+	return nil, fmt.Errorf("this binary was built without embedded inference, rebuild it with '-tags llama' to load %s", cfg.ModelPath)
+}
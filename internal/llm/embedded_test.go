@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEmbeddedModel struct {
+	prompts []string
+	answer  string
+	freed   bool
+}
+
+func (m *fakeEmbeddedModel) Predict(prompt string, maxTokens, threads int, stopWords []string) (string, error) {
+	m.prompts = append(m.prompts, prompt)
+	return m.answer, nil
+}
+
+func (m *fakeEmbeddedModel) Free() {
+	m.freed = true
+}
+
+func TestNewEmbeddedProvider_RequiresModelPath(t *testing.T) {
+	_, err := NewEmbeddedProvider(EmbeddedConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "model_path")
+}
+
+func TestEmbeddedProvider_Generate(t *testing.T) {
+	model := &fakeEmbeddedModel{answer: " looks good<|im_end|>"}
+	provider := &EmbeddedProvider{modelPath: "/models/qwen.gguf", threads: 2, model: model}
+
+	result, err := provider.Generate(context.Background(), "review this")
+	require.NoError(t, err)
+
+	assert.Equal(t, "looks good", result)
+	assert.Equal(t, "/models/qwen.gguf", provider.GetModel())
+	assert.Equal(t, "<|im_start|>user\nreview this<|im_end|>\n<|im_start|>assistant\n", model.prompts[0])
+}
+
+func TestEmbeddedProvider_ChatWithTools(t *testing.T) {
+	model := &fakeEmbeddedModel{answer: "```json\n{\"name\": \"read_file\", \"arguments\": {\"path\": \"main.go\"}}\n```"}
+	provider := &EmbeddedProvider{threads: 1, model: model}
+	tools := []Tool{{Type: "function", Function: ToolFunction{Name: "read_file", Description: "Reads a file"}}}
+
+	resp, err := provider.ChatWithTools(context.Background(), []Message{{Role: "system", Content: "You review code"}, {Role: "user", Content: "review"}}, tools)
+	require.NoError(t, err)
+
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "read_file", resp.ToolCalls[0].Name)
+	assert.Equal(t, "main.go", resp.ToolCalls[0].Arguments["path"])
+	assert.Empty(t, resp.Content)
+
+	prompt := model.prompts[0]
+	assert.True(t, strings.HasPrefix(prompt, "<|im_start|>system\nYou can call these tools:"), prompt)
+	assert.Contains(t, prompt, "<|im_start|>system\nYou review code<|im_end|>")
+}
+
+func TestEmbeddedProvider_ChatWithSchema(t *testing.T) {
+	model := &fakeEmbeddedModel{answer: `{"name": "not a tool call", "issues": []}`}
+	provider := &EmbeddedProvider{threads: 1, model: model}
+	schema := &ResponseSchema{Name: "review", Schema: map[string]any{"type": "object"}}
+
+	resp, err := provider.ChatWithSchema(context.Background(), []Message{{Role: "user", Content: "review"}}, nil, schema)
+	require.NoError(t, err)
+
+	assert.Empty(t, resp.ToolCalls)
+	assert.Equal(t, `{"name": "not a tool call", "issues": []}`, resp.Content)
+	assert.Contains(t, model.prompts[0], `following this schema: {"type":"object"}`)
+
+	provider.Close()
+	assert.True(t, model.freed)
+}
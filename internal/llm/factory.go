@@ -2,6 +2,7 @@ package llm
 
 import (
 	"fmt"
+	"net/http"
 )
 
 type ProviderType string
@@ -9,6 +10,10 @@ type ProviderType string
 const (
 	ProviderOllama ProviderType = "ollama"
 	ProviderOpenAI ProviderType = "openai"
+	// ProviderAzureOpenAI routes the OpenAI API to an Azure OpenAI deployment
+	ProviderAzureOpenAI ProviderType = "azure-openai"
+	// ProviderEmbedded runs a GGUF model in process, without a server
+	ProviderEmbedded ProviderType = "embedded"
 )
 
 type ProviderConfig struct {
@@ -16,15 +21,41 @@ type ProviderConfig struct {
 	Model   string
 	BaseURL string
 	APIKey  string
+	// Azure configures the azure-openai provider, BaseURL being the resource endpoint
+	Azure AzureConfig
+	// Embedded configures the embedded provider
+	Embedded EmbeddedConfig
+	// Transport optionally replaces the HTTP transport, e.g. to meter LLM traffic
+	Transport http.RoundTripper
+	// Policy decides whether the provider may receive the code, the zero value only allows
+	// local providers
+	Policy RemotePolicy
 }
 
 func NewProvider(config ProviderConfig) (Provider, error) {
+	if err := config.Policy.Check(config); err != nil {
+		return nil, err
+	}
+
 	switch config.Type {
 	case ProviderOllama:
-		return NewOllamaProvider(config.BaseURL, config.Model), nil
+		provider := NewOllamaProvider(config.BaseURL, config.Model)
+		provider.client.Transport = config.Transport
+		return provider, nil
 	case ProviderOpenAI:
-		return NewOpenAIProvider(config.BaseURL, config.Model, config.APIKey), nil
+		provider := NewOpenAIProvider(config.BaseURL, config.Model, config.APIKey)
+		provider.client.Transport = config.Transport
+		return provider, nil
+	case ProviderAzureOpenAI:
+		provider, err := NewAzureOpenAIProvider(config.BaseURL, config.Model, config.APIKey, config.Azure)
+		if err != nil {
+			return nil, err
+		}
+		provider.client.Transport = config.Transport
+		return provider, nil
+	case ProviderEmbedded:
+		return NewEmbeddedProvider(config.Embedded)
 	default:
-		return nil, fmt.Errorf("unsupported provider type: %s (supported: 'ollama', 'openai')", config.Type)
+		return nil, fmt.Errorf("unsupported provider type: %s (supported: 'ollama', 'openai', 'azure-openai', 'embedded')", config.Type)
 	}
 }
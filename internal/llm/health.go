@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ErrModelNotFound is returned by HealthCheck when the backend is reachable but does not
+// serve the configured model
+var ErrModelNotFound = errors.New("model not found")
+
+// ModelPuller is implemented by providers that can download a missing model
+type ModelPuller interface {
+	PullModel() error
+}
+
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// HealthCheck verifies Ollama is reachable and the model has been pulled
+func (p *OllamaProvider) HealthCheck() error {
+	body, status, err := getURL(p.client, p.baseURL+"/api/tags", "")
+	if err != nil {
+		return fmt.Errorf("cannot reach Ollama at %s: %w. Start it with 'ollama serve' or fix llm.base_url", p.baseURL, err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("ollama at %s answered with status %d: %s", p.baseURL, status, string(body))
+	}
+
+	var tags ollamaTagsResponse
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return fmt.Errorf("unexpected answer from Ollama at %s, is llm.base_url pointing to Ollama? %w", p.baseURL, err)
+	}
+
+	for _, model := range tags.Models {
+		// Models pulled without a tag are listed as name:latest
+		if model.Name == p.model || model.Name == p.model+":latest" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: '%s' is not available in Ollama at %s. Run 'ollama pull %s' or set llm.auto_pull", ErrModelNotFound, p.model, p.baseURL, p.model)
+}
+
+// ListModels returns the models pulled into Ollama, used to detect a local Ollama
+func (p *OllamaProvider) ListModels() ([]string, error) {
+	body, status, err := getURL(p.client, p.baseURL+"/api/tags", "")
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach Ollama at %s: %w", p.baseURL, err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("ollama at %s answered with status %d: %s", p.baseURL, status, string(body))
+	}
+
+	var tags ollamaTagsResponse
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return nil, fmt.Errorf("unexpected answer from Ollama at %s: %w", p.baseURL, err)
+	}
+	names := make([]string, len(tags.Models))
+	for i, model := range tags.Models {
+		names[i] = model.Name
+	}
+	return names, nil
+}
+
+// PullModel downloads the model into Ollama, blocking until the pull completes
+func (p *OllamaProvider) PullModel() error {
+	jsonData, err := json.Marshal(map[string]any{"model": p.model, "stream": false})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := p.client.Post(p.baseURL+"/api/pull", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Warn("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("pulling '%s' failed with status %d: %s", p.model, resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+type openAIModelsResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+// HealthCheck verifies the server is reachable, accepts the API key and serves the model.
+// llama.cpp serves the model it was started with, so an empty model skips the model check.
+func (p *OpenAIProvider) HealthCheck() error {
+	body, status, err := getURL(p.client, p.baseURL+"/v1/models", p.apiKey)
+	if err != nil {
+		return fmt.Errorf("cannot reach the LLM server at %s: %w. Start llama-server or fix llm.base_url", p.baseURL, err)
+	}
+
+	switch status {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("the LLM server at %s rejected the API key, check llm.api_key", p.baseURL)
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("the LLM server at %s is not ready, it may still be loading the model", p.baseURL)
+	default:
+		return fmt.Errorf("the LLM server at %s answered with status %d: %s", p.baseURL, status, string(body))
+	}
+
+	if p.model == "" {
+		return nil
+	}
+
+	var models openAIModelsResponse
+	if err := json.Unmarshal(body, &models); err != nil {
+		return fmt.Errorf("unexpected answer from the LLM server at %s: %w", p.baseURL, err)
+	}
+
+	ids := make([]string, len(models.Data))
+	for i, model := range models.Data {
+		ids[i] = model.ID
+	}
+	if !slices.Contains(ids, p.model) {
+		return fmt.Errorf("%w: '%s' is not served by %s (available: %s), check llm.model", ErrModelNotFound, p.model, p.baseURL, strings.Join(ids, ", "))
+	}
+	return nil
+}
+
+func getURL(client *http.Client, url, apiKey string) ([]byte, int, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Warn("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, resp.StatusCode, nil
+}
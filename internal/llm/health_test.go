@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOllamaProvider_ListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"models": [{"name": "qwen2.5-coder:7b"}, {"name": "llama3:latest"}]}`))
+	}))
+	defer server.Close()
+
+	models, err := NewOllamaProvider(server.URL, "").ListModels()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(models, ",") != "qwen2.5-coder:7b,llama3:latest" {
+		t.Errorf("Expected the pulled models, got %v", models)
+	}
+
+	server.Close()
+	if _, err := NewOllamaProvider(server.URL, "").ListModels(); err == nil {
+		t.Error("Expected an unreachable Ollama to fail")
+	}
+}
+
+func TestOllamaProvider_HealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			t.Errorf("Expected path /api/tags, got %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"models": [{"name": "qwen2.5-coder:7b"}, {"name": "llama3:latest"}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		model         string
+		expectMissing bool
+	}{
+		{"exact tag", "qwen2.5-coder:7b", false},
+		{"implicit latest tag", "llama3", false},
+		{"missing model", "mistral", true},
+		{"other tag of a pulled model", "qwen2.5-coder:14b", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewOllamaProvider(server.URL, tt.model).HealthCheck()
+
+			if tt.expectMissing {
+				if !errors.Is(err, ErrModelNotFound) {
+					t.Fatalf("Expected ErrModelNotFound, got %v", err)
+				}
+				if !strings.Contains(err.Error(), "ollama pull "+tt.model) {
+					t.Errorf("Expected the error to suggest pulling the model, got: %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestOllamaProvider_HealthCheck_Unreachable(t *testing.T) {
+	provider := NewOllamaProvider("http://invalid-url-that-does-not-exist:12345", "test-model")
+	provider.client.Timeout = 100 * time.Millisecond
+
+	err := provider.HealthCheck()
+
+	if err == nil || !strings.Contains(err.Error(), "ollama serve") {
+		t.Errorf("Expected an error suggesting to start Ollama, got: %v", err)
+	}
+	if errors.Is(err, ErrModelNotFound) {
+		t.Error("An unreachable server should not report a missing model")
+	}
+}
+
+func TestOllamaProvider_PullModel(t *testing.T) {
+	pulled := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/pull":
+			pulled = true
+			_, _ = w.Write([]byte(`{"status": "success"}`))
+		case "/api/tags":
+			if pulled {
+				_, _ = w.Write([]byte(`{"models": [{"name": "mistral:latest"}]}`))
+			} else {
+				_, _ = w.Write([]byte(`{"models": []}`))
+			}
+		}
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider(server.URL, "mistral")
+
+	if err := provider.HealthCheck(); !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("Expected ErrModelNotFound before pulling, got %v", err)
+	}
+	if err := provider.PullModel(); err != nil {
+		t.Fatalf("PullModel failed: %v", err)
+	}
+	if err := provider.HealthCheck(); err != nil {
+		t.Errorf("Expected the model to be available after pulling, got %v", err)
+	}
+}
+
+func TestOpenAIProvider_HealthCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		model         string
+		status        int
+		body          string
+		errorContains string
+		expectMissing bool
+	}{
+		{"model served", "gpt-4o", http.StatusOK, `{"data": [{"id": "gpt-4o"}]}`, "", false},
+		{"llama.cpp without model", "", http.StatusOK, `{"data": [{"id": "/models/qwen.gguf"}]}`, "", false},
+		{"model not served", "gpt-5", http.StatusOK, `{"data": [{"id": "gpt-4o"}]}`, "available: gpt-4o", true},
+		{"rejected key", "gpt-4o", http.StatusUnauthorized, `{"error": "invalid key"}`, "llm.api_key", false},
+		{"model loading", "", http.StatusServiceUnavailable, `{"error": "loading model"}`, "still be loading", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/models" {
+					t.Errorf("Expected path /v1/models, got %s", r.URL.Path)
+				}
+				if r.Header.Get("Authorization") != "Bearer secret" {
+					t.Errorf("Expected the API key to be sent, got %q", r.Header.Get("Authorization"))
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			err := NewOpenAIProvider(server.URL, tt.model, "secret").HealthCheck()
+
+			if tt.errorContains == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("Expected error containing %q, got: %v", tt.errorContains, err)
+			}
+			if errors.Is(err, ErrModelNotFound) != tt.expectMissing {
+				t.Errorf("Expected ErrModelNotFound %v, got %v", tt.expectMissing, err)
+			}
+		})
+	}
+}
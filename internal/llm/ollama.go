@@ -2,9 +2,11 @@ package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 )
@@ -37,6 +39,8 @@ type ollamaChatWithToolsRequest struct {
 	Tools    []Tool         `json:"tools,omitempty"`
 	Stream   bool           `json:"stream"`
 	Options  map[string]any `json:"options,omitempty"`
+	// Format holds the JSON schema the answer must follow
+	Format map[string]any `json:"format,omitempty"`
 }
 
 type ollamaToolCallResponse struct {
@@ -45,7 +49,9 @@ type ollamaToolCallResponse struct {
 		Content   string           `json:"content"`
 		ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
 	} `json:"message"`
-	Done bool `json:"done"`
+	Done            bool `json:"done"`
+	PromptEvalCount int  `json:"prompt_eval_count"`
+	EvalCount       int  `json:"eval_count"`
 }
 
 type ollamaToolCall struct {
@@ -63,11 +69,21 @@ func NewOllamaProvider(baseURL, model string) *OllamaProvider {
 	}
 }
 
+// post sends the JSON body to the API path, the request is canceled with the context
+func (p *OllamaProvider) post(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+path, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return p.client.Do(req)
+}
+
 func (p *OllamaProvider) GetModel() string {
 	return p.model
 }
 
-func (p *OllamaProvider) Generate(prompt string) (string, error) {
+func (p *OllamaProvider) Generate(ctx context.Context, prompt string) (string, error) {
 	reqBody := ollamaRequest{
 		Model:  p.model,
 		Prompt: prompt,
@@ -79,13 +95,13 @@ func (p *OllamaProvider) Generate(prompt string) (string, error) {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	resp, err := p.client.Post(p.baseURL+"/api/generate", "application/json", bytes.NewBuffer(jsonData))
+	resp, err := p.post(ctx, "/api/generate", jsonData)
 	if err != nil {
 		return "", fmt.Errorf("failed to make request: %w", err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			fmt.Printf("Error closing response body: %v", closeErr)
+			slog.Warn("failed to close response body", "error", closeErr)
 		}
 	}()
 
@@ -124,7 +140,11 @@ func (p *OllamaProvider) Generate(prompt string) (string, error) {
 	return ollamaResp.Response, nil
 }
 
-func (p *OllamaProvider) ChatWithTools(messages []Message, tools []Tool) (*ChatResponse, error) {
+func (p *OllamaProvider) ChatWithTools(ctx context.Context, messages []Message, tools []Tool) (*ChatResponse, error) {
+	return p.ChatWithSchema(ctx, messages, tools, nil)
+}
+
+func (p *OllamaProvider) ChatWithSchema(ctx context.Context, messages []Message, tools []Tool, schema *ResponseSchema) (*ChatResponse, error) {
 	tuningOptions := map[string]any{
 		"num_ctx":        16384,
 		"temperature":    0.2,
@@ -140,18 +160,22 @@ func (p *OllamaProvider) ChatWithTools(messages []Message, tools []Tool) (*ChatR
 		Options:  tuningOptions,
 	}
 
+	if schema != nil {
+		reqBody.Format = schema.Schema
+	}
+
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	resp, err := p.client.Post(p.baseURL+"/api/chat", "application/json", bytes.NewBuffer(jsonData))
+	resp, err := p.post(ctx, "/api/chat", jsonData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			fmt.Printf("Error closing response body: %v", closeErr)
+			slog.Warn("failed to close response body", "error", closeErr)
 		}
 	}()
 
@@ -177,6 +201,7 @@ func (p *OllamaProvider) ChatWithTools(messages []Message, tools []Tool) (*ChatR
 
 	chatResp := &ChatResponse{
 		Content: ollamaResp.Message.Content,
+		Usage:   Usage{PromptTokens: ollamaResp.PromptEvalCount, CompletionTokens: ollamaResp.EvalCount},
 	}
 
 	// First, check if Ollama returned tool calls in the proper field
@@ -187,36 +212,43 @@ func (p *OllamaProvider) ChatWithTools(messages []Message, tools []Tool) (*ChatR
 		})
 	}
 
-	// Fallback: If no tool calls but content looks like a tool call JSON, parse it
-	if len(chatResp.ToolCalls) == 0 && ollamaResp.Message.Content != "" {
-		content := ollamaResp.Message.Content
+	// Fallback: If no tool calls but content looks like a tool call JSON, parse it.
+	// Schema constrained answers are always JSON objects, so they are left untouched.
+	if schema == nil && len(chatResp.ToolCalls) == 0 && ollamaResp.Message.Content != "" {
+		if toolCall, ok := parseToolCallContent(ollamaResp.Message.Content); ok {
+			chatResp.ToolCalls = append(chatResp.ToolCalls, toolCall)
+			chatResp.Content = ""
+		}
+	}
+
+	return chatResp, nil
+}
 
+// parseToolCallContent reads a tool call written as a JSON object in the answer text, as
+// models without native tool calling do, optionally wrapped in a code fence
+func parseToolCallContent(content string) (ToolCall, bool) {
+	content = strings.TrimSpace(content)
+	if after, ok := strings.CutPrefix(content, "```json"); ok {
+		content = after
+		content = strings.TrimSuffix(content, "```")
 		content = strings.TrimSpace(content)
-		if after, ok := strings.CutPrefix(content, "```json"); ok {
-			content = after
-			content = strings.TrimSuffix(content, "```")
-			content = strings.TrimSpace(content)
-		} else if after, ok := strings.CutPrefix(content, "```"); ok {
-			content = after
-			content = strings.TrimSuffix(content, "```")
-			content = strings.TrimSpace(content)
-		}
+	} else if after, ok := strings.CutPrefix(content, "```"); ok {
+		content = after
+		content = strings.TrimSuffix(content, "```")
+		content = strings.TrimSpace(content)
+	}
 
-		// Only try to parse as tool call if it's an object (not an array) and has required fields
-		if strings.HasPrefix(content, "{") && !strings.HasPrefix(content, "[") {
-			var toolCallContent struct {
-				Name      string         `json:"name"`
-				Arguments map[string]any `json:"arguments"`
-			}
-			if err := json.Unmarshal([]byte(content), &toolCallContent); err == nil && toolCallContent.Name != "" {
-				chatResp.ToolCalls = append(chatResp.ToolCalls, ToolCall{
-					Name:      toolCallContent.Name,
-					Arguments: toolCallContent.Arguments,
-				})
-				chatResp.Content = ""
-			}
-		}
+	// Only try to parse as tool call if it's an object (not an array) and has required fields
+	if !strings.HasPrefix(content, "{") {
+		return ToolCall{}, false
 	}
 
-	return chatResp, nil
+	var toolCallContent struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	}
+	if err := json.Unmarshal([]byte(content), &toolCallContent); err != nil || toolCallContent.Name == "" {
+		return ToolCall{}, false
+	}
+	return ToolCall{Name: toolCallContent.Name, Arguments: toolCallContent.Arguments}, true
 }
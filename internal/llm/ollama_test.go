@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -76,7 +77,7 @@ func TestOllamaProvider_Generate_Success(t *testing.T) {
 	defer server.Close()
 
 	provider := NewOllamaProvider(server.URL, "test-model")
-	result, err := provider.Generate("test prompt")
+	result, err := provider.Generate(context.Background(), "test prompt")
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -93,7 +94,7 @@ func TestOllamaProvider_Generate_HTTPError(t *testing.T) {
 	defer server.Close()
 
 	provider := NewOllamaProvider(server.URL, "test-model")
-	_, err := provider.Generate("test prompt")
+	_, err := provider.Generate(context.Background(), "test prompt")
 
 	if err == nil {
 		t.Error("Expected error for HTTP 500 response")
@@ -114,7 +115,7 @@ func TestOllamaProvider_Generate_InvalidJSON(t *testing.T) {
 	defer server.Close()
 
 	provider := NewOllamaProvider(server.URL, "test-model")
-	_, err := provider.Generate("test prompt")
+	_, err := provider.Generate(context.Background(), "test prompt")
 
 	if err == nil {
 		t.Error("Expected error for invalid JSON response")
@@ -129,7 +130,7 @@ func TestOllamaProvider_Generate_NetworkError(t *testing.T) {
 
 	provider.client.Timeout = 100 * time.Millisecond
 
-	_, err := provider.Generate("test prompt")
+	_, err := provider.Generate(context.Background(), "test prompt")
 
 	if err == nil {
 		t.Error("Expected error for network failure")
@@ -146,7 +147,9 @@ func TestOllamaProvider_ChatWithTools_Success(t *testing.T) {
 		}
 
 		response := ollamaToolCallResponse{
-			Done: true,
+			Done:            true,
+			PromptEvalCount: 512,
+			EvalCount:       64,
 		}
 		response.Message.Role = "assistant"
 		response.Message.Content = "Here's the analysis"
@@ -168,7 +171,7 @@ func TestOllamaProvider_ChatWithTools_Success(t *testing.T) {
 		},
 	}}
 
-	result, err := provider.ChatWithTools(messages, tools)
+	result, err := provider.ChatWithTools(context.Background(), messages, tools)
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -176,6 +179,9 @@ func TestOllamaProvider_ChatWithTools_Success(t *testing.T) {
 	if result.Content != "Here's the analysis" {
 		t.Errorf("Expected content 'Here's the analysis', got %s", result.Content)
 	}
+	if result.Usage != (Usage{PromptTokens: 512, CompletionTokens: 64}) {
+		t.Errorf("Expected the token counts of the response, got %+v", result.Usage)
+	}
 }
 
 func TestOllamaProvider_ChatWithTools_WithToolCalls(t *testing.T) {
@@ -204,7 +210,7 @@ func TestOllamaProvider_ChatWithTools_WithToolCalls(t *testing.T) {
 	provider := NewOllamaProvider(server.URL, "test-model")
 	messages := []Message{{Role: "user", Content: "test"}}
 
-	result, err := provider.ChatWithTools(messages, nil)
+	result, err := provider.ChatWithTools(context.Background(), messages, nil)
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -233,7 +239,7 @@ func TestOllamaProvider_ChatWithTools_JSONFallback(t *testing.T) {
 	provider := NewOllamaProvider(server.URL, "test-model")
 	messages := []Message{{Role: "user", Content: "test"}}
 
-	result, err := provider.ChatWithTools(messages, nil)
+	result, err := provider.ChatWithTools(context.Background(), messages, nil)
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -243,6 +249,43 @@ func TestOllamaProvider_ChatWithTools_JSONFallback(t *testing.T) {
 	}
 }
 
+func TestOllamaProvider_ChatWithSchema(t *testing.T) {
+	content := `{"name": "structured", "issues": []}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaChatWithToolsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if req.Format["type"] != "object" {
+			t.Errorf("Expected format to carry the schema, got %v", req.Format)
+		}
+
+		response := ollamaToolCallResponse{Done: true}
+		response.Message.Role = "assistant"
+		response.Message.Content = content
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider(server.URL, "test-model")
+	messages := []Message{{Role: "user", Content: "test"}}
+	schema := &ResponseSchema{Name: "review", Schema: map[string]any{"type": "object"}}
+
+	result, err := provider.ChatWithSchema(context.Background(), messages, nil, schema)
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 0 {
+		t.Errorf("Expected structured answer not to be parsed as a tool call, got %d", len(result.ToolCalls))
+	}
+	if result.Content != content {
+		t.Errorf("Expected content %s, got %s", content, result.Content)
+	}
+}
+
 func TestOllamaProvider_ChatWithTools_HTTPError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
@@ -253,7 +296,7 @@ func TestOllamaProvider_ChatWithTools_HTTPError(t *testing.T) {
 	provider := NewOllamaProvider(server.URL, "test-model")
 	messages := []Message{{Role: "user", Content: "test"}}
 
-	_, err := provider.ChatWithTools(messages, nil)
+	_, err := provider.ChatWithTools(context.Background(), messages, nil)
 
 	if err == nil {
 		t.Error("Expected error for HTTP 400 response")
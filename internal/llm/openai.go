@@ -2,9 +2,11 @@ package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 )
 
@@ -13,6 +15,10 @@ type OpenAIProvider struct {
 	model   string
 	apiKey  string
 	client  *http.Client
+	// chatURL and authorize let variants such as Azure OpenAI route and authenticate the
+	// chat requests differently
+	chatURL   string
+	authorize func(req *http.Request) error
 }
 
 type openAIRequest struct {
@@ -40,10 +46,23 @@ type openAIChatWithToolsRequest struct {
 	Temperature float64   `json:"temperature,omitempty"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Stream      bool      `json:"stream"`
+	// Structured output format, supported by OpenAI and llama-server
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
 	// llama.cpp specific parameters (ignored by OpenAI)
 	Options map[string]any `json:"options,omitempty"`
 }
 
+type openAIResponseFormat struct {
+	Type       string           `json:"type"`
+	JSONSchema openAIJSONSchema `json:"json_schema"`
+}
+
+type openAIJSONSchema struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+	Strict bool           `json:"strict"`
+}
+
 type openAIToolCallResponse struct {
 	Choices []struct {
 		Message struct {
@@ -53,6 +72,10 @@ type openAIToolCallResponse struct {
 		} `json:"message"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
 }
 
 type openAIToolCall struct {
@@ -65,12 +88,22 @@ type openAIToolCall struct {
 }
 
 func NewOpenAIProvider(baseURL, model, apiKey string) *OpenAIProvider {
-	return &OpenAIProvider{
+	p := &OpenAIProvider{
 		baseURL: baseURL,
 		model:   model,
 		apiKey:  apiKey,
 		client:  &http.Client{},
+		chatURL: baseURL + "/v1/chat/completions",
+	}
+	p.authorize = p.bearerAuth
+	return p
+}
+
+func (p *OpenAIProvider) bearerAuth(req *http.Request) error {
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
 	}
+	return nil
 }
 
 func (p *OpenAIProvider) GetModel() string {
@@ -80,7 +113,7 @@ func (p *OpenAIProvider) GetModel() string {
 	return p.model
 }
 
-func (p *OpenAIProvider) Generate(prompt string) (string, error) {
+func (p *OpenAIProvider) Generate(ctx context.Context, prompt string) (string, error) {
 	messages := []Message{
 		{
 			Role:    "user",
@@ -99,14 +132,14 @@ func (p *OpenAIProvider) Generate(prompt string) (string, error) {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", p.baseURL+"/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.chatURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if p.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	if err := p.authorize(req); err != nil {
+		return "", fmt.Errorf("failed to authenticate request: %w", err)
 	}
 
 	resp, err := p.client.Do(req)
@@ -115,7 +148,7 @@ func (p *OpenAIProvider) Generate(prompt string) (string, error) {
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			fmt.Printf("Error closing response body: %v", closeErr)
+			slog.Warn("failed to close response body", "error", closeErr)
 		}
 	}()
 
@@ -146,7 +179,11 @@ func (p *OpenAIProvider) Generate(prompt string) (string, error) {
 	return openAIResp.Choices[0].Message.Content, nil
 }
 
-func (p *OpenAIProvider) ChatWithTools(messages []Message, tools []Tool) (*ChatResponse, error) {
+func (p *OpenAIProvider) ChatWithTools(ctx context.Context, messages []Message, tools []Tool) (*ChatResponse, error) {
+	return p.ChatWithSchema(ctx, messages, tools, nil)
+}
+
+func (p *OpenAIProvider) ChatWithSchema(ctx context.Context, messages []Message, tools []Tool, schema *ResponseSchema) (*ChatResponse, error) {
 	reqBody := openAIChatWithToolsRequest{
 		Model:       p.model,
 		Messages:    messages,
@@ -156,19 +193,30 @@ func (p *OpenAIProvider) ChatWithTools(messages []Message, tools []Tool) (*ChatR
 		Stream:      false,
 	}
 
+	if schema != nil {
+		reqBody.ResponseFormat = &openAIResponseFormat{
+			Type: "json_schema",
+			JSONSchema: openAIJSONSchema{
+				Name:   schema.Name,
+				Schema: schema.Schema,
+				Strict: true,
+			},
+		}
+	}
+
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", p.baseURL+"/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.chatURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if p.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	if err := p.authorize(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
 	}
 
 	resp, err := p.client.Do(req)
@@ -177,7 +225,7 @@ func (p *OpenAIProvider) ChatWithTools(messages []Message, tools []Tool) (*ChatR
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			fmt.Printf("Error closing response body: %v", closeErr)
+			slog.Warn("failed to close response body", "error", closeErr)
 		}
 	}()
 
@@ -207,6 +255,10 @@ func (p *OpenAIProvider) ChatWithTools(messages []Message, tools []Tool) (*ChatR
 
 	chatResp := &ChatResponse{
 		Content: openAIResp.Choices[0].Message.Content,
+		Usage: Usage{
+			PromptTokens:     openAIResp.Usage.PromptTokens,
+			CompletionTokens: openAIResp.Usage.CompletionTokens,
+		},
 	}
 
 	// Parse tool calls from the response
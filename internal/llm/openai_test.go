@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -59,7 +60,7 @@ func TestOpenAIProvider_Generate(t *testing.T) {
 			defer server.Close()
 
 			provider := NewOpenAIProvider(server.URL, "test-model", "")
-			result, err := provider.Generate(tt.prompt)
+			result, err := provider.Generate(context.Background(), tt.prompt)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -176,7 +177,7 @@ func TestOpenAIProvider_ChatWithTools(t *testing.T) {
 			defer server.Close()
 
 			provider := NewOpenAIProvider(server.URL, "test-model", "")
-			result, err := provider.ChatWithTools(tt.messages, tt.tools)
+			result, err := provider.ChatWithTools(context.Background(), tt.messages, tt.tools)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -195,6 +196,47 @@ func TestOpenAIProvider_ChatWithTools(t *testing.T) {
 	}
 }
 
+func TestOpenAIProvider_ChatWithSchema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIChatWithToolsRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.NotNil(t, req.ResponseFormat)
+		assert.Equal(t, "json_schema", req.ResponseFormat.Type)
+		assert.Equal(t, "review", req.ResponseFormat.JSONSchema.Name)
+		assert.True(t, req.ResponseFormat.JSONSchema.Strict)
+		assert.Equal(t, "object", req.ResponseFormat.JSONSchema.Schema["type"])
+
+		_, err := w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "{\"issues\": []}"}, "finish_reason": "stop"}], "usage": {"prompt_tokens": 812, "completion_tokens": 9}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL, "test-model", "")
+	schema := &ResponseSchema{Name: "review", Schema: map[string]any{"type": "object"}}
+	result, err := provider.ChatWithSchema(context.Background(), []Message{{Role: "user", Content: "test"}}, nil, schema)
+
+	require.NoError(t, err)
+	assert.Equal(t, `{"issues": []}`, result.Content)
+	assert.Equal(t, Usage{PromptTokens: 812, CompletionTokens: 9}, result.Usage)
+}
+
+func TestOpenAIProvider_ChatWithTools_OmitsResponseFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		_, hasFormat := req["response_format"]
+		assert.False(t, hasFormat)
+
+		_, err := w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "APPROVED"}, "finish_reason": "stop"}]}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL, "test-model", "")
+	_, err := provider.ChatWithTools(context.Background(), []Message{{Role: "user", Content: "test"}}, nil)
+	require.NoError(t, err)
+}
+
 func TestOpenAIProvider_GetModel(t *testing.T) {
 	provider := NewOpenAIProvider("http://localhost:8080", "test-model", "")
 	assert.Equal(t, "test-model", provider.GetModel())
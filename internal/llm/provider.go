@@ -1,9 +1,24 @@
 package llm
 
+import "context"
+
+// Provider calls a model. The calls give up when their context is done.
 type Provider interface {
 	GetModel() string
-	Generate(prompt string) (string, error)
-	ChatWithTools(messages []Message, tools []Tool) (*ChatResponse, error)
+	Generate(ctx context.Context, prompt string) (string, error)
+	ChatWithTools(ctx context.Context, messages []Message, tools []Tool) (*ChatResponse, error)
+	// ChatWithSchema behaves like ChatWithTools but constrains the final answer to the given
+	// JSON schema. A nil schema leaves the answer unconstrained.
+	ChatWithSchema(ctx context.Context, messages []Message, tools []Tool, schema *ResponseSchema) (*ChatResponse, error)
+	// HealthCheck verifies the backend is reachable and serves the model, returning an
+	// error that tells the user how to fix the setup
+	HealthCheck() error
+}
+
+// ResponseSchema is a JSON schema the provider asks the model to follow
+type ResponseSchema struct {
+	Name   string
+	Schema map[string]any
 }
 
 type Tool struct {
@@ -20,6 +35,14 @@ type ToolFunction struct {
 type ChatResponse struct {
 	Content   string
 	ToolCalls []ToolCall
+	// Usage is what the backend reported, zero when it reports nothing
+	Usage Usage
+}
+
+// Usage counts the tokens of a call to the model
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
 }
 
 type ToolCall struct {
@@ -34,4 +57,4 @@ type Config struct {
 	MaxTokens   int
 }
 
-var SupportedProviders = []string{"ollama", "openai"}
+var SupportedProviders = []string{"ollama", "openai", "azure-openai", "embedded"}
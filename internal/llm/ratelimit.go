@@ -0,0 +1,177 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit is the quota of a provider, a zero field is unlimited
+type RateLimit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// RateLimiter spreads the calls to hosted providers over time to stay under their quotas.
+// Each provider key has a token bucket for the requests and one for the tokens, shared by
+// every provider wrapped under that key, so concurrent reviews and evaluations queue behind
+// the same quota.
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*rateBuckets
+}
+
+type rateBuckets struct {
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{buckets: make(map[string]*rateBuckets)}
+}
+
+// Wrap returns a provider whose calls wait for the quota of key. The first limit given for
+// a key is the one applied. A limit without requests nor tokens returns provider unchanged.
+func (l *RateLimiter) Wrap(provider Provider, key string, limit RateLimit) Provider {
+	if limit.RequestsPerMinute <= 0 && limit.TokensPerMinute <= 0 {
+		return provider
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	buckets, ok := l.buckets[key]
+	if !ok {
+		buckets = &rateBuckets{
+			requests: newTokenBucket(limit.RequestsPerMinute),
+			tokens:   newTokenBucket(limit.TokensPerMinute),
+		}
+		l.buckets[key] = buckets
+	}
+	return &rateLimitedProvider{Provider: provider, limiter: l, buckets: buckets}
+}
+
+// wait blocks until the buckets have a request and tokens available, or the context is done
+func (l *RateLimiter) wait(ctx context.Context, buckets *rateBuckets, tokens int) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		delay := max(buckets.requests.delay(now, 1), buckets.tokens.delay(now, tokens))
+		if delay == 0 {
+			buckets.requests.take(1)
+			buckets.tokens.take(tokens)
+		}
+		l.mu.Unlock()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// settle charges the tokens the call used beyond the estimate taken before it, or returns
+// the ones it did not use
+func (l *RateLimiter) settle(buckets *rateBuckets, estimated, used int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	buckets.tokens.take(used - estimated)
+}
+
+// tokenBucket refills perMinute units over a minute, starting full. It goes negative when a
+// call uses more than estimated, which delays the next ones.
+type tokenBucket struct {
+	perMinute float64
+	available float64
+	updated   time.Time
+}
+
+// newTokenBucket returns nil for an unlimited bucket
+func newTokenBucket(perMinute int) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{perMinute: float64(perMinute), available: float64(perMinute), updated: time.Now()}
+}
+
+// delay returns how long until amount units are available, zero when they are. Amounts
+// above the capacity only wait for a full bucket.
+func (b *tokenBucket) delay(now time.Time, amount int) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.available = min(b.perMinute, b.available+now.Sub(b.updated).Minutes()*b.perMinute)
+	b.updated = now
+
+	missing := min(float64(amount), b.perMinute) - b.available
+	if missing <= 0 {
+		return 0
+	}
+	return max(time.Duration(missing/b.perMinute*float64(time.Minute)), time.Millisecond)
+}
+
+func (b *tokenBucket) take(amount int) {
+	if b == nil {
+		return
+	}
+	b.available = min(b.perMinute, b.available-float64(amount))
+}
+
+type rateLimitedProvider struct {
+	Provider
+	limiter *RateLimiter
+	buckets *rateBuckets
+}
+
+func (p *rateLimitedProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	estimated := estimateTokens(prompt)
+	if err := p.limiter.wait(ctx, p.buckets, estimated); err != nil {
+		return "", err
+	}
+	answer, err := p.Provider.Generate(ctx, prompt)
+	p.limiter.settle(p.buckets, estimated, estimated+estimateTokens(answer))
+	return answer, err
+}
+
+func (p *rateLimitedProvider) ChatWithTools(ctx context.Context, messages []Message, tools []Tool) (*ChatResponse, error) {
+	return p.ChatWithSchema(ctx, messages, tools, nil)
+}
+
+func (p *rateLimitedProvider) ChatWithSchema(ctx context.Context, messages []Message, tools []Tool, schema *ResponseSchema) (*ChatResponse, error) {
+	estimated := 0
+	for _, message := range messages {
+		estimated += estimateTokens(message.Content)
+	}
+	if err := p.limiter.wait(ctx, p.buckets, estimated); err != nil {
+		return nil, err
+	}
+
+	var response *ChatResponse
+	var err error
+	if schema == nil {
+		response, err = p.Provider.ChatWithTools(ctx, messages, tools)
+	} else {
+		response, err = p.Provider.ChatWithSchema(ctx, messages, tools, schema)
+	}
+
+	used := estimated
+	if response != nil {
+		if usage := response.Usage.PromptTokens + response.Usage.CompletionTokens; usage > 0 {
+			used = usage
+		} else {
+			used += estimateTokens(response.Content)
+		}
+	}
+	p.limiter.settle(p.buckets, estimated, used)
+	return response, err
+}
+
+// estimateTokens approximates the token count of text at four characters per token
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingProvider answers every call and counts them
+type countingProvider struct {
+	calls int
+	usage Usage
+}
+
+func (p *countingProvider) GetModel() string   { return "test-model" }
+func (p *countingProvider) HealthCheck() error { return nil }
+func (p *countingProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	p.calls++
+	return "ok", nil
+}
+func (p *countingProvider) ChatWithTools(ctx context.Context, messages []Message, tools []Tool) (*ChatResponse, error) {
+	return p.ChatWithSchema(ctx, messages, tools, nil)
+}
+func (p *countingProvider) ChatWithSchema(ctx context.Context, messages []Message, tools []Tool, schema *ResponseSchema) (*ChatResponse, error) {
+	p.calls++
+	return &ChatResponse{Content: "ok", Usage: p.usage}, nil
+}
+
+func TestRateLimiter_Requests(t *testing.T) {
+	inner := &countingProvider{}
+	limiter := NewRateLimiter()
+	provider := limiter.Wrap(inner, "http://api", RateLimit{RequestsPerMinute: 3})
+	// Another agent of the same provider shares the quota
+	other := limiter.Wrap(inner, "http://api", RateLimit{RequestsPerMinute: 3})
+
+	for range 2 {
+		if _, err := provider.Generate(context.Background(), "review"); err != nil {
+			t.Fatalf("Expected the calls within the quota to pass: %v", err)
+		}
+	}
+	if _, err := other.Generate(context.Background(), "review"); err != nil {
+		t.Fatalf("Expected the last call within the quota to pass: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := provider.Generate(ctx, "review"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the call over the quota to wait, got %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 calls to reach the provider, got %d", inner.calls)
+	}
+}
+
+func TestRateLimiter_Tokens(t *testing.T) {
+	inner := &countingProvider{usage: Usage{PromptTokens: 900, CompletionTokens: 100}}
+	provider := NewRateLimiter().Wrap(inner, "http://api", RateLimit{TokensPerMinute: 1000})
+
+	// The estimate lets the call through, the reported usage then drains the bucket
+	messages := []Message{{Role: "user", Content: "review this"}}
+	if _, err := provider.ChatWithTools(context.Background(), messages, nil); err != nil {
+		t.Fatalf("Expected the first call to pass: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := provider.ChatWithTools(ctx, messages, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the call to wait for the tokens, got %v", err)
+	}
+}
+
+func TestRateLimiter_Unlimited(t *testing.T) {
+	inner := &countingProvider{}
+	if provider := NewRateLimiter().Wrap(inner, "http://api", RateLimit{}); provider != Provider(inner) {
+		t.Error("Expected the provider to be returned unchanged without a limit")
+	}
+}
+
+func TestTokenBucket_Delay(t *testing.T) {
+	start := time.Now()
+	bucket := &tokenBucket{perMinute: 60, available: 0, updated: start}
+
+	if delay := bucket.delay(start, 1); delay != time.Second {
+		t.Errorf("Expected one second for one unit at 60 per minute, got %v", delay)
+	}
+	if delay := bucket.delay(start.Add(time.Second), 1); delay != 0 {
+		t.Errorf("Expected the unit refilled after one second, got %v", delay)
+	}
+	// Amounts above the capacity only wait for a full bucket
+	if delay := bucket.delay(start.Add(time.Second), 600); delay != 59*time.Second {
+		t.Errorf("Expected to wait for a full bucket, got %v", delay)
+	}
+}
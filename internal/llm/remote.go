@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// RemotePolicy decides which providers may receive the code. Local providers are always
+// allowed, remote ones only when AllowRemote is set, and the denied hosts never.
+type RemotePolicy struct {
+	AllowRemote bool
+	// DenyHosts are host names or patterns such as *.openai.com
+	DenyHosts []string
+}
+
+// Check returns an error telling how to allow the provider when the policy blocks it
+func (p RemotePolicy) Check(config ProviderConfig) error {
+	host := providerHost(config)
+	for _, denied := range p.DenyHosts {
+		denied = strings.ToLower(strings.TrimSpace(denied))
+		if matched, _ := path.Match(denied, host); matched && host != "" {
+			return fmt.Errorf("the %s provider at %s is blocked by security.deny_hosts", config.Type, host)
+		}
+	}
+
+	if IsRemote(config) && !p.AllowRemote {
+		target := host
+		if target == "" {
+			target = "an unknown host"
+		}
+		return fmt.Errorf("the %s provider sends the code to %s, which is not local: run with --allow-remote or set security.allow_remote to true", config.Type, target)
+	}
+	return nil
+}
+
+// IsRemote reports whether the provider sends the prompts out of the machine and its private
+// network. Embedded models and servers on loopback, private addresses or single-label hosts
+// such as a docker compose service are local, Azure OpenAI is always remote.
+func IsRemote(config ProviderConfig) bool {
+	switch config.Type {
+	case ProviderEmbedded:
+		return false
+	case ProviderAzureOpenAI:
+		return true
+	}
+
+	host := providerHost(config)
+	if host == "" {
+		return true
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".local") || !strings.Contains(host, ".") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast()
+	}
+	return true
+}
+
+// providerHost returns the lower-cased host of the provider, empty for embedded models and
+// invalid base URLs
+func providerHost(config ProviderConfig) string {
+	if config.Type == ProviderEmbedded {
+		return ""
+	}
+	parsed, err := url.Parse(config.BaseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
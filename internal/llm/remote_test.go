@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsRemote(t *testing.T) {
+	tests := []struct {
+		config   ProviderConfig
+		expected bool
+	}{
+		{ProviderConfig{Type: ProviderOllama, BaseURL: "http://localhost:11434"}, false},
+		{ProviderConfig{Type: ProviderOpenAI, BaseURL: "http://127.0.0.1:8080"}, false},
+		{ProviderConfig{Type: ProviderOpenAI, BaseURL: "http://[::1]:8080"}, false},
+		{ProviderConfig{Type: ProviderOpenAI, BaseURL: "http://192.168.1.20:8080"}, false},
+		{ProviderConfig{Type: ProviderOllama, BaseURL: "http://ollama:11434"}, false},
+		{ProviderConfig{Type: ProviderOllama, BaseURL: "http://gpu-box.local:11434"}, false},
+		{ProviderConfig{Type: ProviderEmbedded}, false},
+		{ProviderConfig{Type: ProviderOpenAI, BaseURL: "https://api.openai.com"}, true},
+		{ProviderConfig{Type: ProviderOpenAI, BaseURL: "https://8.8.8.8"}, true},
+		{ProviderConfig{Type: ProviderOpenAI, BaseURL: ""}, true},
+		{ProviderConfig{Type: ProviderAzureOpenAI, BaseURL: "http://localhost:8080"}, true},
+	}
+
+	for _, tt := range tests {
+		if remote := IsRemote(tt.config); remote != tt.expected {
+			t.Errorf("Expected IsRemote(%s %q) to be %v", tt.config.Type, tt.config.BaseURL, tt.expected)
+		}
+	}
+}
+
+func TestRemotePolicy_Check(t *testing.T) {
+	local := ProviderConfig{Type: ProviderOllama, BaseURL: "http://localhost:11434"}
+	remote := ProviderConfig{Type: ProviderOpenAI, BaseURL: "https://api.openai.com"}
+	azure := ProviderConfig{Type: ProviderAzureOpenAI, BaseURL: "https://team.openai.azure.com"}
+
+	tests := []struct {
+		name    string
+		policy  RemotePolicy
+		config  ProviderConfig
+		allowed bool
+	}{
+		{"local by default", RemotePolicy{}, local, true},
+		{"remote refused by default", RemotePolicy{}, remote, false},
+		{"remote allowed", RemotePolicy{AllowRemote: true}, remote, true},
+		{"denied host", RemotePolicy{AllowRemote: true, DenyHosts: []string{"API.openai.com"}}, remote, false},
+		{"denied pattern", RemotePolicy{AllowRemote: true, DenyHosts: []string{"*.openai.azure.com"}}, azure, false},
+		{"pattern of another host", RemotePolicy{AllowRemote: true, DenyHosts: []string{"*.openai.azure.com"}}, remote, true},
+		{"denied local host", RemotePolicy{DenyHosts: []string{"localhost"}}, local, false},
+		{"embedded", RemotePolicy{DenyHosts: []string{"*"}}, ProviderConfig{Type: ProviderEmbedded}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.config)
+			if (err == nil) != tt.allowed {
+				t.Errorf("Expected allowed %v, got %v", tt.allowed, err)
+			}
+		})
+	}
+}
+
+func TestNewProvider_RefusesRemote(t *testing.T) {
+	_, err := NewProvider(ProviderConfig{Type: ProviderOpenAI, BaseURL: "https://api.openai.com"})
+	if err == nil || !strings.Contains(err.Error(), "--allow-remote") {
+		t.Errorf("Expected the remote provider refused with a hint, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultTimeout bounds a model call when the configuration sets none
+const DefaultTimeout = 600 * time.Second
+
+// timeoutProvider gives up on the calls of the wrapped provider taking longer than timeout
+type timeoutProvider struct {
+	Provider
+	timeout time.Duration
+}
+
+// WithTimeout bounds every call of provider to timeout, DefaultTimeout when zero or less
+func WithTimeout(provider Provider, timeout time.Duration) Provider {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &timeoutProvider{Provider: provider, timeout: timeout}
+}
+
+func (p *timeoutProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return p.Provider.Generate(ctx, prompt)
+}
+
+func (p *timeoutProvider) ChatWithTools(ctx context.Context, messages []Message, tools []Tool) (*ChatResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return p.Provider.ChatWithTools(ctx, messages, tools)
+}
+
+func (p *timeoutProvider) ChatWithSchema(ctx context.Context, messages []Message, tools []Tool, schema *ResponseSchema) (*ChatResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return p.Provider.ChatWithSchema(ctx, messages, tools, schema)
+}
@@ -0,0 +1,44 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	provider := WithTimeout(NewOllamaProvider(server.URL, "test-model"), 50*time.Millisecond)
+
+	t.Run("gives up on a hanging call", func(t *testing.T) {
+		start := time.Now()
+		_, err := provider.ChatWithTools(context.Background(), []Message{{Role: "user", Content: "review"}}, nil)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected a deadline error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Expected the call to stop at the timeout, took %v", elapsed)
+		}
+	})
+
+	t.Run("stops when the caller cancels", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := WithTimeout(NewOllamaProvider(server.URL, "test-model"), time.Minute).Generate(ctx, "review")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected a canceled error, got %v", err)
+		}
+	})
+}
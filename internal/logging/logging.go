@@ -0,0 +1,107 @@
+// Package logging sets up the structured JSON log of the pipeline stages, which diagnoses a
+// failing review without recompiling. The console output of the review is not part of it.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/llm"
+)
+
+var levels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// Setup makes the default slog logger write JSON events of the level and above to the file,
+// or to stderr when file is empty. The level defaults to error, so a plain run only logs
+// what went wrong. The returned function closes the file.
+func Setup(level, file string) (func() error, error) {
+	if level == "" {
+		level = "error"
+	}
+	slogLevel, ok := levels[strings.ToLower(level)]
+	if !ok {
+		return nil, fmt.Errorf("unknown log level %q, use debug, info, warn or error", level)
+	}
+
+	var out io.Writer = os.Stderr
+	closeFile := func() error { return nil }
+	if file != "" {
+		logFile, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		out = logFile
+		closeFile = logFile.Close
+	}
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slogLevel})))
+	return closeFile, nil
+}
+
+// WrapProvider returns a provider logging the duration and outcome of every call of the
+// provider at debug level, and its failures at warn level
+func WrapProvider(provider llm.Provider) llm.Provider {
+	return &loggedProvider{Provider: provider}
+}
+
+type loggedProvider struct {
+	llm.Provider
+}
+
+func (p *loggedProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	start := time.Now()
+	answer, err := p.Provider.Generate(ctx, prompt)
+	p.log("generate", 1, len(prompt), len(answer), 0, err, start)
+	return answer, err
+}
+
+func (p *loggedProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	start := time.Now()
+	response, err := p.Provider.ChatWithTools(ctx, messages, tools)
+	p.logChat(messages, response, err, start)
+	return response, err
+}
+
+func (p *loggedProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, tools []llm.Tool, schema *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	start := time.Now()
+	response, err := p.Provider.ChatWithSchema(ctx, messages, tools, schema)
+	p.logChat(messages, response, err, start)
+	return response, err
+}
+
+func (p *loggedProvider) logChat(messages []llm.Message, response *llm.ChatResponse, err error, start time.Time) {
+	promptBytes := 0
+	for _, message := range messages {
+		promptBytes += len(message.Content)
+	}
+	answerBytes, toolCalls := 0, 0
+	if response != nil {
+		answerBytes, toolCalls = len(response.Content), len(response.ToolCalls)
+	}
+	p.log("chat", len(messages), promptBytes, answerBytes, toolCalls, err, start)
+}
+
+func (p *loggedProvider) log(call string, messages, promptBytes, answerBytes, toolCalls int, err error, start time.Time) {
+	attrs := []any{
+		"call", call,
+		"model", p.GetModel(),
+		"messages", messages,
+		"prompt_bytes", promptBytes,
+		"duration_ms", time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		slog.Warn("llm call failed", append(attrs, "error", err)...)
+		return
+	}
+	slog.Debug("llm call", append(attrs, "answer_bytes", answerBytes, "tool_calls", toolCalls)...)
+}
@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/llm"
+)
+
+type scriptedProvider struct {
+	llm.Provider
+	err error
+}
+
+func (p *scriptedProvider) GetModel() string { return "qwen2.5-coder" }
+
+func (p *scriptedProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &llm.ChatResponse{Content: "[]"}, nil
+}
+
+func setup(t *testing.T, level string) string {
+	t.Helper()
+	previous := slog.Default()
+	path := filepath.Join(t.TempDir(), "diffpector.log")
+	closeLog, err := Setup(level, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		closeLog()
+		slog.SetDefault(previous)
+	})
+	return path
+}
+
+func readEvents(t *testing.T, path string) []map[string]any {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open the log: %v", err)
+	}
+	defer file.Close()
+
+	var events []map[string]any
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Invalid log line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestSetup_Levels(t *testing.T) {
+	path := setup(t, "warn")
+	slog.Debug("diff collected")
+	slog.Warn("review failed", "file", "auth/login.go")
+
+	events := readEvents(t, path)
+	if len(events) != 1 {
+		t.Fatalf("Expected the warning alone, got %v", events)
+	}
+	if events[0]["msg"] != "review failed" || events[0]["file"] != "auth/login.go" || events[0]["level"] != "WARN" {
+		t.Errorf("Unexpected event: %v", events[0])
+	}
+}
+
+func TestSetup_DefaultsToErrors(t *testing.T) {
+	path := setup(t, "")
+	slog.Warn("failed to close response body")
+	slog.Error("review failed")
+
+	if events := readEvents(t, path); len(events) != 1 || events[0]["msg"] != "review failed" {
+		t.Errorf("Expected the error alone, got %v", events)
+	}
+}
+
+func TestSetup_UnknownLevel(t *testing.T) {
+	if _, err := Setup("verbose", ""); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
+
+func TestWrapProvider(t *testing.T) {
+	path := setup(t, "debug")
+	messages := []llm.Message{{Role: "user", Content: "Review this diff"}}
+
+	if _, err := WrapProvider(&scriptedProvider{}).ChatWithTools(context.Background(), messages, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err := WrapProvider(&scriptedProvider{err: errors.New("connection refused")}).ChatWithTools(context.Background(), messages, nil)
+	if err == nil {
+		t.Fatal("Expected the error of the provider")
+	}
+
+	events := readEvents(t, path)
+	if len(events) != 2 {
+		t.Fatalf("Expected an event per call, got %v", events)
+	}
+	call := events[0]
+	if call["msg"] != "llm call" || call["model"] != "qwen2.5-coder" || call["prompt_bytes"] != float64(16) || call["answer_bytes"] != float64(2) {
+		t.Errorf("Unexpected call event: %v", call)
+	}
+	if _, ok := call["duration_ms"]; !ok {
+		t.Errorf("Expected the duration of the call: %v", call)
+	}
+	failure := events[1]
+	if failure["msg"] != "llm call failed" || failure["level"] != "WARN" || failure["error"] != "connection refused" {
+		t.Errorf("Unexpected failure event: %v", failure)
+	}
+}
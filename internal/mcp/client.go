@@ -0,0 +1,153 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds each request to a server
+const DefaultTimeout = 60 * time.Second
+
+// transport carries the messages of a client to its server
+type transport interface {
+	// send writes a message and, when it is a request, waits for the response with its id
+	send(message Message) (*Message, error)
+	close() error
+}
+
+// Client uses the tools of one MCP server. Requests are sent one at a time.
+type Client struct {
+	name      string
+	transport transport
+
+	mu        sync.Mutex
+	nextID    int64
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newClient(name string, transport transport) *Client {
+	return &Client{name: name, transport: transport}
+}
+
+// Name returns the name the server was configured with
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Initialize performs the handshake, it must be called before any other request
+func (c *Client) Initialize() error {
+	params := map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "diffpector", "version": "1.0.0"},
+	}
+
+	var result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := c.call("initialize", params, &result); err != nil {
+		return fmt.Errorf("failed to initialize MCP server %s: %w", c.name, err)
+	}
+	return c.notify("notifications/initialized")
+}
+
+// ListTools returns every tool of the server, following the pagination cursors
+func (c *Client) ListTools() ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+
+		var page struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := c.call("tools/list", params, &page); err != nil {
+			return nil, fmt.Errorf("failed to list the tools of MCP server %s: %w", c.name, err)
+		}
+		tools = append(tools, page.Tools...)
+
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool runs a tool of the server. A tool reporting its own failure is not an error, see
+// CallToolResult.IsError.
+func (c *Client) CallTool(name string, arguments map[string]any) (CallToolResult, error) {
+	if arguments == nil {
+		arguments = map[string]any{}
+	}
+
+	var result CallToolResult
+	if err := c.call("tools/call", map[string]any{"name": name, "arguments": arguments}, &result); err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to call %s on MCP server %s: %w", name, c.name, err)
+	}
+	return result, nil
+}
+
+// Close ends the session, stopping the server process of stdio servers. It is safe to call
+// more than once.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.transport.close()
+	})
+	return c.closeErr
+}
+
+func (c *Client) call(method string, params any, result any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	message, err := newMessage(method, params)
+	if err != nil {
+		return err
+	}
+	message.ID = json.RawMessage(strconv.FormatInt(c.nextID, 10))
+
+	response, err := c.transport.send(message)
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("invalid %s result: %w", method, err)
+	}
+	return nil
+}
+
+func (c *Client) notify(method string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	message, err := newMessage(method, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.transport.send(message)
+	return err
+}
+
+func newMessage(method string, params any) (Message, error) {
+	message := Message{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return Message{}, fmt.Errorf("failed to encode %s params: %w", method, err)
+		}
+		message.Params = data
+	}
+	return message, nil
+}
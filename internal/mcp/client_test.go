@@ -0,0 +1,159 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// serve answers a request the way the fake server of the tests does: two pages of tools and
+// a tools/call echoing its arguments, "fail" reporting a tool error
+func serve(request Message) Message {
+	response := Message{JSONRPC: "2.0", ID: request.ID}
+
+	var params struct {
+		Cursor    string         `json:"cursor"`
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	}
+	_ = json.Unmarshal(request.Params, &params)
+
+	var result any
+	switch request.Method {
+	case "initialize":
+		result = map[string]any{"protocolVersion": ProtocolVersion, "capabilities": map[string]any{"tools": map[string]any{}}}
+	case "tools/list":
+		if params.Cursor == "" {
+			result = map[string]any{"tools": []Tool{{Name: "lookup", Description: "Look up a service"}}, "nextCursor": "2"}
+		} else {
+			result = map[string]any{"tools": []Tool{{Name: "fail"}}}
+		}
+	case "tools/call":
+		arguments, _ := json.Marshal(params.Arguments)
+		result = CallToolResult{Content: []Content{{Type: "text", Text: params.Name + " " + string(arguments)}}, IsError: params.Name == "fail"}
+	default:
+		response.Error = &RPCError{Code: CodeMethodNotFound, Message: "method not found"}
+		return response
+	}
+
+	response.Result, _ = json.Marshal(result)
+	return response
+}
+
+// TestHelperStdioServer is the server process of TestStdioClient, it only runs when started
+// by the test
+func TestHelperStdioServer(t *testing.T) {
+	if os.Getenv("DIFFPECTOR_MCP_HELPER") != "1" {
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var request Message
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil || !request.isRequest() {
+			continue
+		}
+		if request.Method == "tools/call" {
+			// Servers may ping the client and log while a call is running
+			fmt.Println(`{"jsonrpc":"2.0","id":"server-1","method":"ping"}`)
+			fmt.Println(`{"jsonrpc":"2.0","method":"notifications/message","params":{"level":"info","data":"calling"}}`)
+		}
+		data, _ := json.Marshal(serve(request))
+		fmt.Println(string(data))
+	}
+	os.Exit(0)
+}
+
+func TestStdioClient(t *testing.T) {
+	client, err := NewStdioClient("catalog", []string{os.Args[0], "-test.run=TestHelperStdioServer"}, map[string]string{"DIFFPECTOR_MCP_HELPER": "1"}, ".")
+	if err != nil {
+		t.Fatalf("NewStdioClient failed: %v", err)
+	}
+	defer client.Close()
+
+	exerciseClient(t, client)
+}
+
+func TestHTTPClient(t *testing.T) {
+	var sessions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			return
+		}
+		sessions = append(sessions, r.Header.Get("Mcp-Session-Id"))
+
+		var request Message
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !request.isRequest() {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		data, _ := json.Marshal(serve(request))
+		switch request.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "session-1")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(data)
+		default:
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", `{"jsonrpc":"2.0","method":"notifications/progress","params":{}}`)
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient("catalog", server.URL, map[string]string{"Authorization": "Bearer secret"}, nil)
+	defer client.Close()
+
+	exerciseClient(t, client)
+
+	if sessions[0] != "" || sessions[len(sessions)-1] != "session-1" {
+		t.Errorf("Expected the session id to be sent after initialize, got %v", sessions)
+	}
+}
+
+func exerciseClient(t *testing.T, client *Client) {
+	t.Helper()
+
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	tools, err := client.ListTools()
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "lookup" || tools[1].Name != "fail" {
+		t.Fatalf("Expected the tools of both pages, got %+v", tools)
+	}
+
+	result, err := client.CallTool("lookup", map[string]any{"service": "billing"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if result.IsError || result.Text() != `lookup {"service":"billing"}` {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	result, err = client.CallTool("fail", nil)
+	if err != nil || !result.IsError {
+		t.Errorf("Expected a tool error in the result, got %+v, %v", result, err)
+	}
+
+	if err := client.call("resources/list", nil, &struct{}{}); err == nil || !strings.Contains(err.Error(), "method not found") {
+		t.Errorf("Expected the RPC error of the server, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// httpTransport posts every message to the endpoint of a streamable HTTP server, which
+// answers with a JSON body or an event stream carrying the response
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+	// sessionID is assigned by the server on initialize and sent back with every message
+	sessionID string
+}
+
+// NewHTTPClient connects to the streamable HTTP endpoint at url, sending headers with every
+// request, e.g. an Authorization header. A nil httpClient uses one with DefaultTimeout.
+// Call Initialize before using it.
+func NewHTTPClient(name, url string, headers map[string]string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return newClient(name, &httpTransport{url: url, headers: headers, client: httpClient})
+}
+
+func (t *httpTransport) newRequest(method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, t.url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	return req, nil
+}
+
+func (t *httpTransport) send(message Message) (*Message, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	req, err := t.newRequest(http.MethodPost, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach MCP server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if sessionID := resp.Header.Get("Mcp-Session-Id"); sessionID != "" {
+		t.sessionID = sessionID
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("MCP server returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if len(message.ID) == 0 {
+		return nil, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		return readEventStream(resp.Body, message.ID)
+	}
+
+	var response Message
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode MCP response: %w", err)
+	}
+	return &response, nil
+}
+
+// readEventStream returns the response with the given id out of a server-sent event stream,
+// skipping the notifications and requests the server sends before it
+func readEventStream(body io.Reader, id json.RawMessage) (*Message, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	var data []string
+	matches := func() *Message {
+		var message Message
+		if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &message); err == nil && message.isResponse() && bytes.Equal(message.ID, id) {
+			return &message
+		}
+		return nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data = append(data, strings.TrimPrefix(value, " "))
+			continue
+		}
+		// A blank line ends the event
+		if line == "" && len(data) > 0 {
+			if message := matches(); message != nil {
+				return message, nil
+			}
+			data = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read MCP event stream: %w", err)
+	}
+	if message := matches(); message != nil {
+		return message, nil
+	}
+	return nil, fmt.Errorf("MCP event stream ended without a response")
+}
+
+// close ends the session on the server, failures are ignored as the session expires anyway
+func (t *httpTransport) close() error {
+	if t.sessionID == "" {
+		return nil
+	}
+	req, err := t.newRequest(http.MethodDelete, nil)
+	if err != nil {
+		return nil
+	}
+	if resp, err := t.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Package mcp speaks the Model Context Protocol, JSON-RPC 2.0 messages exchanged over stdio
+// or HTTP, to use the tools of MCP servers and to offer diffpector's own analysis as tools.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ProtocolVersion is the MCP revision diffpector implements
+const ProtocolVersion = "2025-03-26"
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Message is any JSON-RPC message: a request has a method and an id, a notification only a
+// method and a response an id with either a result or an error
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+func (m Message) isRequest() bool {
+	return m.Method != "" && len(m.ID) > 0
+}
+
+func (m Message) isResponse() bool {
+	return m.Method == "" && len(m.ID) > 0
+}
+
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Tool is a tool exposed by a server, InputSchema being the JSON schema of its arguments
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// Content is one item of a tool result, only text items are read by diffpector
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+type CallToolResult struct {
+	Content []Content `json:"content"`
+	// IsError reports a failure of the tool itself, as opposed to a protocol error
+	IsError bool `json:"isError,omitempty"`
+}
+
+// Text joins the text items of the result, other items are noted by their type
+func (r CallToolResult) Text() string {
+	parts := make([]string, len(r.Content))
+	for i, content := range r.Content {
+		if content.Type == "text" {
+			parts[i] = content.Text
+		} else {
+			parts[i] = "[" + content.Type + " content]"
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ToolHandler runs a tool of the server with the arguments sent by the client. An error is
+// reported to the client as a failed tool call, not as a protocol error.
+type ToolHandler func(arguments map[string]any) (string, error)
+
+// Server offers tools to MCP clients over newline-delimited JSON-RPC, the stdio transport.
+// Requests are handled one at a time in the order they arrive.
+type Server struct {
+	name     string
+	version  string
+	tools    []Tool
+	handlers map[string]ToolHandler
+}
+
+func NewServer(name, version string) *Server {
+	return &Server{name: name, version: version, handlers: make(map[string]ToolHandler)}
+}
+
+// AddTool offers tool to the clients, handler answering its calls
+func (s *Server) AddTool(tool Tool, handler ToolHandler) {
+	if tool.InputSchema == nil {
+		tool.InputSchema = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	s.tools = append(s.tools, tool)
+	s.handlers[tool.Name] = handler
+}
+
+// Serve answers the requests read from r on w until r is exhausted
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	encoder := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var request Message
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			response := Message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &RPCError{Code: CodeParseError, Message: "invalid JSON"}}
+			if err := encoder.Encode(response); err != nil {
+				return fmt.Errorf("failed to write MCP response: %w", err)
+			}
+			continue
+		}
+		// Notifications and responses to requests the server never sends need no answer
+		if !request.isRequest() {
+			continue
+		}
+
+		if err := encoder.Encode(s.handle(request)); err != nil {
+			return fmt.Errorf("failed to write MCP response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read MCP request: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handle(request Message) Message {
+	response := Message{JSONRPC: "2.0", ID: request.ID}
+
+	var result any
+	switch request.Method {
+	case "initialize":
+		result = map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": s.name, "version": s.version},
+		}
+	case "ping":
+		result = map[string]any{}
+	case "tools/list":
+		result = map[string]any{"tools": s.tools}
+	case "tools/call":
+		var params struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			response.Error = &RPCError{Code: CodeInvalidParams, Message: "invalid tools/call params"}
+			return response
+		}
+		handler, ok := s.handlers[params.Name]
+		if !ok {
+			response.Error = &RPCError{Code: CodeInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)}
+			return response
+		}
+		if params.Arguments == nil {
+			params.Arguments = map[string]any{}
+		}
+
+		text, err := handler(params.Arguments)
+		if err != nil {
+			result = CallToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}
+		} else {
+			result = CallToolResult{Content: []Content{{Type: "text", Text: text}}}
+		}
+	default:
+		response.Error = &RPCError{Code: CodeMethodNotFound, Message: "method not found"}
+		return response
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		response.Error = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		return response
+	}
+	response.Result = data
+	return response
+}
@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	server := NewServer("diffpector", "1.0.0")
+	server.AddTool(Tool{Name: "echo", Description: "Echo the text"}, func(arguments map[string]any) (string, error) {
+		text, _ := arguments["text"].(string)
+		if text == "" {
+			return "", errors.New("text is required")
+		}
+		return text, nil
+	})
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hello"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"missing"}}`,
+		`{"jsonrpc":"2.0","id":"six","method":"ping"}`,
+		`{"jsonrpc":"2.0","id":7,"method":"resources/list"}`,
+		`not json`,
+	}, "\n")
+
+	var output bytes.Buffer
+	if err := server.Serve(strings.NewReader(input), &output); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	var responses []Message
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var response Message
+		if err := json.Unmarshal([]byte(line), &response); err != nil {
+			t.Fatalf("Invalid response %q: %v", line, err)
+		}
+		responses = append(responses, response)
+	}
+	if len(responses) != 8 {
+		t.Fatalf("Expected a response per request, got %d:\n%s", len(responses), output.String())
+	}
+
+	if !strings.Contains(string(responses[0].Result), `"tools":{}`) || !strings.Contains(string(responses[0].Result), ProtocolVersion) {
+		t.Errorf("Unexpected initialize result %s", responses[0].Result)
+	}
+
+	var list struct {
+		Tools []Tool `json:"tools"`
+	}
+	if err := json.Unmarshal(responses[1].Result, &list); err != nil || len(list.Tools) != 1 || list.Tools[0].InputSchema == nil {
+		t.Errorf("Expected the echo tool with a default schema, got %s", responses[1].Result)
+	}
+
+	var result CallToolResult
+	if err := json.Unmarshal(responses[2].Result, &result); err != nil || result.IsError || result.Text() != "hello" {
+		t.Errorf("Unexpected tool result %s", responses[2].Result)
+	}
+	result = CallToolResult{}
+	if err := json.Unmarshal(responses[3].Result, &result); err != nil || !result.IsError || result.Text() != "text is required" {
+		t.Errorf("Expected the handler error as a tool error, got %s", responses[3].Result)
+	}
+
+	if responses[4].Error == nil || responses[4].Error.Code != CodeInvalidParams {
+		t.Errorf("Expected invalid params for an unknown tool, got %+v", responses[4])
+	}
+	if string(responses[5].ID) != `"six"` || string(responses[5].Result) != "{}" {
+		t.Errorf("Expected an empty ping result echoing the id, got %+v", responses[5])
+	}
+	if responses[6].Error == nil || responses[6].Error.Code != CodeMethodNotFound {
+		t.Errorf("Expected method not found, got %+v", responses[6])
+	}
+	if responses[7].Error == nil || responses[7].Error.Code != CodeParseError {
+		t.Errorf("Expected a parse error, got %+v", responses[7])
+	}
+}
@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxMessageSize bounds a single message read from a server
+const maxMessageSize = 16 * 1024 * 1024
+
+// stdioTransport exchanges newline delimited messages with a server process
+type stdioTransport struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stderr  *tailBuffer
+	timeout time.Duration
+
+	writeMu   sync.Mutex
+	responses chan Message
+}
+
+// NewStdioClient starts the server command from dir, env being added to the environment of
+// the process. Call Initialize before using it.
+func NewStdioClient(name string, command []string, env map[string]string, dir string) (*Client, error) {
+	if len(command) == 0 || command[0] == "" {
+		return nil, fmt.Errorf("MCP server %s has no command", name)
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin of MCP server %s: %w", name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout of MCP server %s: %w", name, err)
+	}
+	stderr := &tailBuffer{limit: 4096}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start MCP server %s: %w", name, err)
+	}
+
+	transport := &stdioTransport{
+		cmd:       cmd,
+		stdin:     stdin,
+		stderr:    stderr,
+		timeout:   DefaultTimeout,
+		responses: make(chan Message, 16),
+	}
+	go transport.read(stdout)
+
+	return newClient(name, transport), nil
+}
+
+// read dispatches the messages of the server until it closes its stdout. Responses are
+// queued for send, pings are answered and other requests and notifications are refused or
+// ignored, diffpector offering no capabilities to servers.
+func (t *stdioTransport) read(stdout io.Reader) {
+	defer close(t.responses)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var message Message
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			continue
+		}
+
+		switch {
+		case message.isResponse():
+			t.responses <- message
+		case message.isRequest():
+			reply := Message{JSONRPC: "2.0", ID: message.ID}
+			if message.Method == "ping" {
+				reply.Result = json.RawMessage("{}")
+			} else {
+				reply.Error = &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + message.Method}
+			}
+			_ = t.write(reply)
+		}
+	}
+}
+
+func (t *stdioTransport) write(message Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to MCP server: %w%s", err, t.stderr.details())
+	}
+	return nil
+}
+
+func (t *stdioTransport) send(message Message) (*Message, error) {
+	if err := t.write(message); err != nil {
+		return nil, err
+	}
+	if len(message.ID) == 0 {
+		return nil, nil
+	}
+
+	timer := time.NewTimer(t.timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case response, ok := <-t.responses:
+			if !ok {
+				return nil, fmt.Errorf("MCP server exited%s", t.stderr.details())
+			}
+			// Responses to earlier requests that timed out are skipped
+			if bytes.Equal(response.ID, message.ID) {
+				return &response, nil
+			}
+		case <-timer.C:
+			return nil, fmt.Errorf("no response to %s within %s", message.Method, t.timeout)
+		}
+	}
+}
+
+// close ends stdin, which asks the server to exit, and kills it when it does not
+func (t *stdioTransport) close() error {
+	_ = t.stdin.Close()
+
+	exited := make(chan error, 1)
+	go func() { exited <- t.cmd.Wait() }()
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(2 * time.Second):
+		_ = t.cmd.Process.Kill()
+		<-exited
+		return nil
+	}
+}
+
+// tailBuffer keeps the last bytes written to it, the stderr of a server explaining why it
+// failed without growing unbounded
+type tailBuffer struct {
+	mu    sync.Mutex
+	data  []byte
+	limit int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > b.limit {
+		b.data = b.data[len(b.data)-b.limit:]
+	}
+	return len(p), nil
+}
+
+// details formats the kept stderr to be appended to an error message
+func (b *tailBuffer) details() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if text := strings.TrimSpace(string(b.data)); text != "" {
+		return ": " + text
+	}
+	return ""
+}
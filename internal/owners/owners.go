@@ -0,0 +1,155 @@
+// Package owners reads CODEOWNERS files to find the owners of the changed files.
+package owners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/platform"
+)
+
+// DefaultLocations are searched in order for the CODEOWNERS file, as GitHub and GitLab do
+var DefaultLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+type rule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// Rules maps paths to their owners, the last rule matching a path wins
+type Rules struct {
+	rules []rule
+}
+
+// Load reads the owners file at path, relative to repoRoot, or the first CODEOWNERS file of
+// DefaultLocations when path is empty
+func Load(repoRoot, path string) (*Rules, error) {
+	locations := DefaultLocations
+	if path != "" {
+		locations = []string{path}
+	}
+
+	for _, location := range locations {
+		file, err := os.Open(filepath.Join(repoRoot, location))
+		if os.IsNotExist(err) && path == "" {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open owners file: %w", err)
+		}
+		defer file.Close()
+
+		rules, err := Parse(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", location, err)
+		}
+		return rules, nil
+	}
+	return nil, fmt.Errorf("no CODEOWNERS file found in %s", strings.Join(DefaultLocations, ", "))
+}
+
+// Parse reads rules in the CODEOWNERS syntax: a path pattern followed by its owners per
+// line. Comments and GitLab section headers are skipped.
+func Parse(r io.Reader) (*Rules, error) {
+	rules := &Rules{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.Index(line, " #"); index >= 0 {
+			line = line[:index]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[") || strings.HasPrefix(fields[0], "^[") {
+			continue
+		}
+
+		pattern, err := compilePattern(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		rules.rules = append(rules.rules, rule{pattern: pattern, owners: fields[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Owners returns the owners of a repository-relative path, none when no rule matches or the
+// matching rule lists no owners
+func (r *Rules) Owners(path string) []string {
+	path = platform.NormalizePath(path)
+	for i := len(r.rules) - 1; i >= 0; i-- {
+		if r.rules[i].pattern.MatchString(path) {
+			return r.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// compilePattern turns a gitignore-style pattern into a regular expression. Patterns with a
+// slash before their end are anchored to the root, others match at any depth, and a pattern
+// matches the files below the directories it names except when it ends in /*.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	trimmed := strings.Trim(pattern, "/")
+	if trimmed == "" {
+		trimmed = "**"
+	}
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(trimmed, "/")
+
+	var expression strings.Builder
+	expression.WriteString("^")
+	if !anchored {
+		expression.WriteString("(?:.*/)?")
+	}
+
+	segments := strings.Split(trimmed, "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if segment == "**" {
+			if last {
+				expression.WriteString(".*")
+			} else {
+				expression.WriteString("(?:.*/)?")
+			}
+			continue
+		}
+
+		for _, r := range segment {
+			switch r {
+			case '*':
+				expression.WriteString("[^/]*")
+			case '?':
+				expression.WriteString("[^/]")
+			default:
+				expression.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		if !last {
+			expression.WriteString("/")
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(trimmed, "/*") || trimmed == "*":
+		// Only the files directly in the directory
+	case strings.HasSuffix(pattern, "/"):
+		expression.WriteString("/.*")
+	default:
+		expression.WriteString("(?:/.*)?")
+	}
+	expression.WriteString("$")
+
+	compiled, err := regexp.Compile(expression.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return compiled, nil
+}
@@ -0,0 +1,89 @@
+package owners
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const codeowners = `# Default owners
+*                   @org/core
+
+*.js                @org/frontend # scripts
+/build/logs/        @org/ops
+docs/*              docs@example.com
+apps/               @org/apps
+/scripts/**/deploy  @org/ops @alice
+**/migrations       @org/data
+/vendor/
+
+[Security]
+internal/auth/      @org/security
+`
+
+func TestRules_Owners(t *testing.T) {
+	rules, err := Parse(strings.NewReader(codeowners))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"main.go", []string{"@org/core"}},
+		{"web/app.js", []string{"@org/frontend"}},
+		{"build/logs/today.log", []string{"@org/ops"}},
+		{"src/build/logs/today.log", []string{"@org/core"}},
+		{"docs/guide.md", []string{"docs@example.com"}},
+		{"docs/api/index.md", []string{"@org/core"}},
+		{"apps/web/main.go", []string{"@org/apps"}},
+		{"services/apps/main.go", []string{"@org/apps"}},
+		{"scripts/deploy", []string{"@org/ops", "@alice"}},
+		{"scripts/ci/prod/deploy", []string{"@org/ops", "@alice"}},
+		{"db/migrations/001.sql", []string{"@org/data"}},
+		{"vendor/lib/lib.go", nil},
+		{"internal/auth/token.go", []string{"@org/security"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := rules.Owners(tt.path); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(dir, ""); err == nil {
+		t.Error("Expected an error without CODEOWNERS file")
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ".github"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte("* @org/core\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "OWNERS.txt"), []byte("* @org/custom\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := Load(dir, "")
+	if err != nil || !slices.Equal(rules.Owners("main.go"), []string{"@org/core"}) {
+		t.Errorf("Expected the rules of .github/CODEOWNERS, got %v", err)
+	}
+
+	rules, err = Load(dir, "OWNERS.txt")
+	if err != nil || !slices.Equal(rules.Owners("main.go"), []string{"@org/custom"}) {
+		t.Errorf("Expected the rules of the custom file, got %v", err)
+	}
+
+	if _, err := Load(dir, "missing.txt"); err == nil {
+		t.Error("Expected an error for a missing custom file")
+	}
+}
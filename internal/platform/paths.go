@@ -0,0 +1,41 @@
+// Package platform keeps the paths of diffpector independent of the operating system. Paths
+// are slash separated and relative to the repository root within diffpector, like git prints
+// them, and only converted to the separator of the OS to access the file system.
+package platform
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// NormalizePath converts a path to the slash separated form, cleaned of ./ prefixes and of
+// . and .. elements. Backslashes are separators on every OS, git never prints them, so that
+// the paths typed on Windows match on any machine.
+func NormalizePath(p string) string {
+	if p == "" {
+		return ""
+	}
+	return path.Clean(strings.ReplaceAll(p, `\`, "/"))
+}
+
+// RelPath returns target relative to root in the slash separated form
+func RelPath(root, target string) (string, error) {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return "", err
+	}
+	return NormalizePath(rel), nil
+}
+
+// JoinPath joins a slash separated path relative to root onto root, with the separator of
+// the OS
+func JoinPath(root, rel string) string {
+	return filepath.Join(root, filepath.FromSlash(NormalizePath(rel)))
+}
+
+// IsOutside reports whether a relative path leaves its root, such as ../other
+func IsOutside(rel string) bool {
+	rel = NormalizePath(rel)
+	return rel == ".." || strings.HasPrefix(rel, "../")
+}
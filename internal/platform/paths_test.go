@@ -0,0 +1,60 @@
+package platform
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"internal/agent/agent.go", "internal/agent/agent.go"},
+		{`internal\agent\agent.go`, "internal/agent/agent.go"},
+		{`.\cmd\diffpector\`, "cmd/diffpector"},
+		{"./docs//guide/../README.md", "docs/README.md"},
+		{`C:\Users\dev\repo\main.go`, "C:/Users/dev/repo/main.go"},
+		{".", "."},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizePath(tt.input); got != tt.expected {
+			t.Errorf("NormalizePath(%q): expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestRelAndJoinPath(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "internal", "agent", "agent.go")
+
+	rel, err := RelPath(root, target)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rel != "internal/agent/agent.go" {
+		t.Errorf("Expected a slash separated relative path, got %q", rel)
+	}
+	if joined := JoinPath(root, rel); joined != target {
+		t.Errorf("Expected %q, got %q", target, joined)
+	}
+}
+
+func TestIsOutside(t *testing.T) {
+	tests := map[string]bool{
+		"..":            true,
+		"../other":      true,
+		`..\other`:      true,
+		"internal":      false,
+		"..hidden/file": false,
+		"a/../../b":     true,
+	}
+
+	for rel, expected := range tests {
+		if got := IsOutside(rel); got != expected {
+			t.Errorf("IsOutside(%q): expected %v, got %v", rel, expected, got)
+		}
+	}
+}
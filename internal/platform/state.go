@@ -0,0 +1,34 @@
+package platform
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// StateDir holds the files diffpector writes besides the report: the checkpoints, the
+// history and the audit logs, and the reports written into it
+const StateDir = ".diffpector"
+
+// IgnoreStateDir writes a .gitignore ignoring everything into the .diffpector directory
+// that path lies in, so that its files are ignored without an entry in the repository
+// .gitignore. Paths outside a .diffpector directory are left alone.
+func IgnoreStateDir(path string) error {
+	dir := filepath.Clean(path)
+	for filepath.Base(dir) != StateDir {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+
+	ignorePath := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignorePath); err == nil || !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(ignorePath, []byte("*\n"), 0644)
+}
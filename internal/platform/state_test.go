@@ -0,0 +1,37 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreStateDir(t *testing.T) {
+	root := t.TempDir()
+
+	if err := IgnoreStateDir(filepath.Join(root, StateDir, "audit", "run.jsonl")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(root, StateDir, ".gitignore"))
+	if err != nil || string(content) != "*\n" {
+		t.Errorf("Expected the state directory to ignore everything, got %q, %v", content, err)
+	}
+
+	// An existing .gitignore is kept as it is
+	if err := os.WriteFile(filepath.Join(root, StateDir, ".gitignore"), []byte("*.jsonl\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := IgnoreStateDir(filepath.Join(root, StateDir, "history.jsonl")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, StateDir, ".gitignore")); string(content) != "*.jsonl\n" {
+		t.Errorf("Expected the existing .gitignore to be kept, got %q", content)
+	}
+
+	if err := IgnoreStateDir(filepath.Join(root, "reports", "report.md")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "reports")); !os.IsNotExist(err) {
+		t.Error("Expected paths outside the state directory to be left alone")
+	}
+}
@@ -0,0 +1,236 @@
+package privacy
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// syntax is what the lexer needs to know of a language to find its strings, comments and
+// names
+type syntax struct {
+	lineComments []string
+	blockStart   string
+	blockEnd     string
+	quotes       string
+	// multilineQuotes are the quotes whose strings may span lines, e.g. Go raw strings
+	multilineQuotes string
+	// keywords are kept when the identifiers are anonymized. Languages without keywords
+	// keep all their names.
+	keywords map[string]bool
+}
+
+var (
+	cSyntax  = syntax{lineComments: []string{"//"}, blockStart: "/*", blockEnd: "*/", quotes: "\"'`"}
+	goSyntax = syntax{lineComments: []string{"//"}, blockStart: "/*", blockEnd: "*/", quotes: "\"'`",
+		multilineQuotes: "`", keywords: goKeywords}
+	javaSyntax = syntax{lineComments: []string{"//"}, blockStart: "/*", blockEnd: "*/", quotes: "\"'",
+		keywords: javaKeywords}
+	typescriptSyntax = syntax{lineComments: []string{"//"}, blockStart: "/*", blockEnd: "*/", quotes: "\"'`",
+		multilineQuotes: "`", keywords: typescriptKeywords}
+	hashSyntax = syntax{lineComments: []string{"#"}, quotes: "\"'"}
+	hclSyntax  = syntax{lineComments: []string{"#", "//"}, blockStart: "/*", blockEnd: "*/", quotes: "\""}
+	sqlSyntax  = syntax{lineComments: []string{"--"}, blockStart: "/*", blockEnd: "*/", quotes: "'\""}
+)
+
+var syntaxByExtension = map[string]syntax{
+	".go":   goSyntax,
+	".java": javaSyntax,
+	".ts":   typescriptSyntax,
+	".tsx":  typescriptSyntax,
+	".js":   typescriptSyntax,
+	".jsx":  typescriptSyntax,
+	".sh":   hashSyntax,
+	".bash": hashSyntax,
+	".yaml": hashSyntax,
+	".yml":  hashSyntax,
+	".py":   hashSyntax,
+	".rb":   hashSyntax,
+	".tf":   hclSyntax,
+	".hcl":  hclSyntax,
+	".sql":  sqlSyntax,
+}
+
+// syntaxFor picks the syntax by extension, C-like comments and quotes for the unknown ones
+func syntaxFor(filePath string) syntax {
+	if syntax, ok := syntaxByExtension[strings.ToLower(filepath.Ext(filePath))]; ok {
+		return syntax
+	}
+	return cSyntax
+}
+
+// lexer rewrites code line by line, carrying block comments and multiline strings over to
+// the next line
+type lexer struct {
+	anonymizer *Anonymizer
+	syntax     syntax
+	inComment  bool
+	// inString is the quote of the multiline string the previous line left open
+	inString byte
+}
+
+func (l *lexer) reset() {
+	l.inComment = false
+	l.inString = 0
+}
+
+func (l *lexer) line(line string) string {
+	var out strings.Builder
+	i := 0
+
+	if l.inComment {
+		i = l.comment(line, 0, &out)
+	} else if l.inString != 0 {
+		i = l.str(line, 0, l.inString, &out)
+	}
+
+	for i < len(line) {
+		if l.syntax.blockStart != "" && strings.HasPrefix(line[i:], l.syntax.blockStart) {
+			out.WriteString(l.syntax.blockStart)
+			l.inComment = true
+			i = l.comment(line, i+len(l.syntax.blockStart), &out)
+			continue
+		}
+		if marker, ok := l.lineComment(line, i); ok {
+			out.WriteString(marker)
+			l.writeComment(line[i+len(marker):], &out)
+			return out.String()
+		}
+
+		c := line[i]
+		switch {
+		case strings.IndexByte(l.syntax.quotes, c) >= 0:
+			out.WriteByte(c)
+			i = l.str(line, i+1, c, &out)
+		case isIdentifierStart(c):
+			end := i + 1
+			for end < len(line) && isIdentifierPart(line[end]) {
+				end++
+			}
+			out.WriteString(l.identifier(line[i:end]))
+			i = end
+		case c >= '0' && c <= '9':
+			// Numbers, with the letters of their suffixes and exponents
+			end := i + 1
+			for end < len(line) && isIdentifierPart(line[end]) {
+				end++
+			}
+			out.WriteString(line[i:end])
+			i = end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+func (l *lexer) lineComment(line string, i int) (string, bool) {
+	for _, marker := range l.syntax.lineComments {
+		if strings.HasPrefix(line[i:], marker) {
+			// A # starts a shell comment only at the start of a word, not in ${#array[@]}
+			if marker == "#" && i > 0 && line[i-1] != ' ' && line[i-1] != '\t' {
+				return "", false
+			}
+			return marker, true
+		}
+	}
+	return "", false
+}
+
+// comment writes the block comment from start up to its end marker, returning the index
+// after it, or the end of the line when the comment goes on
+func (l *lexer) comment(line string, start int, out *strings.Builder) int {
+	end := strings.Index(line[start:], l.syntax.blockEnd)
+	if end < 0 {
+		l.writeComment(line[start:], out)
+		return len(line)
+	}
+	l.writeComment(line[start:start+end], out)
+	out.WriteString(l.syntax.blockEnd)
+	l.inComment = false
+	return start + end + len(l.syntax.blockEnd)
+}
+
+func (l *lexer) writeComment(text string, out *strings.Builder) {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || strings.Trim(trimmed, "*/-=#") == "" {
+		out.WriteString(text)
+		return
+	}
+	leading := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+	out.WriteString(leading + l.anonymizer.placeholder(kindComment, trimmed))
+	if strings.HasSuffix(text, " ") {
+		out.WriteString(" ")
+	}
+}
+
+// str writes the string from start up to its closing quote, returning the index after it.
+// Strings that may span lines stay open when the line ends.
+func (l *lexer) str(line string, start int, quote byte, out *strings.Builder) int {
+	escapes := quote != '`'
+	end := start
+	for end < len(line) && line[end] != quote {
+		if escapes && line[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	end = min(end, len(line))
+
+	if content := line[start:end]; content != "" {
+		out.WriteString(l.anonymizer.placeholder(kindString, content))
+	}
+	if end == len(line) {
+		if strings.IndexByte(l.syntax.multilineQuotes, quote) >= 0 {
+			l.inString = quote
+		} else {
+			l.inString = 0
+		}
+		return end
+	}
+	out.WriteByte(quote)
+	l.inString = 0
+	return end + 1
+}
+
+func (l *lexer) identifier(name string) string {
+	if !l.anonymizer.identifiers || l.syntax.keywords == nil || l.syntax.keywords[name] {
+		return name
+	}
+	return l.anonymizer.placeholder(kindIdentifier, name)
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || (c >= '0' && c <= '9')
+}
+
+func keywordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+var goKeywords = keywordSet(`break case chan const continue default defer else fallthrough for func go goto
+	if import interface map package range return select struct switch type var
+	any bool byte comparable complex64 complex128 error float32 float64 int int8 int16 int32 int64
+	rune string uint uint8 uint16 uint32 uint64 uintptr true false iota nil
+	append cap clear close complex copy delete imag len make max min new panic print println real recover`)
+
+var javaKeywords = keywordSet(`abstract assert boolean break byte case catch char class const continue default
+	do double else enum extends final finally float for goto if implements import instanceof int interface
+	long native new package private protected public return short static strictfp super switch synchronized
+	this throw throws transient try void volatile while true false null var record yield sealed permits
+	String Object Integer Long Double Boolean Exception RuntimeException Override System`)
+
+var typescriptKeywords = keywordSet(`break case catch class const continue debugger default delete do else enum
+	export extends false finally for function if import in instanceof new null return super switch this throw
+	true try typeof var void while with as implements interface let package private protected public static
+	yield async await any boolean number string symbol never unknown object undefined type readonly keyof
+	declare namespace module from of get set constructor abstract is infer unique bigint
+	console Promise Array Object String Number Boolean Error Map Set JSON Math Date`)
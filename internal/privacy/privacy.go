@@ -0,0 +1,179 @@
+// Package privacy anonymizes the code sent to remote models: string literals, comments and
+// optionally identifiers are replaced with placeholders, which are restored in the answers.
+package privacy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	kindString     = "STR"
+	kindComment    = "COMMENT"
+	kindIdentifier = "ID"
+)
+
+// placeholderRegex matches the placeholders in the answers of the model
+var placeholderRegex = regexp.MustCompile(`\b(STR|COMMENT|ID)_([0-9]+)\b`)
+
+// Anonymizer rewrites code into placeholder tokens and keeps the map to restore them. The same
+// value always gets the same placeholder, so the model can still tell that two strings or
+// two uses of a name are the same.
+type Anonymizer struct {
+	identifiers bool
+
+	mu       sync.Mutex
+	forward  map[string]string
+	reverse  map[string]string
+	counters map[string]int
+}
+
+// NewAnonymizer replaces string literals and comments, and the identifiers as well when
+// identifiers is set
+func NewAnonymizer(identifiers bool) *Anonymizer {
+	return &Anonymizer{
+		identifiers: identifiers,
+		forward:     make(map[string]string),
+		reverse:     make(map[string]string),
+		counters:    make(map[string]int),
+	}
+}
+
+func (a *Anonymizer) placeholder(kind, value string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := kind + ":" + value
+	if placeholder, ok := a.forward[key]; ok {
+		return placeholder
+	}
+	a.counters[kind]++
+	placeholder := fmt.Sprintf("%s_%d", kind, a.counters[kind])
+	a.forward[key] = placeholder
+	a.reverse[placeholder] = value
+	return placeholder
+}
+
+func (a *Anonymizer) lookup(kind, value string) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	placeholder, ok := a.forward[kind+":"+value]
+	return placeholder, ok
+}
+
+// Restore replaces the placeholders of a text with the values they stand for. Unknown
+// placeholders are left as they are.
+func (a *Anonymizer) Restore(text string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return placeholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if value, ok := a.reverse[placeholder]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// AnonymizeDiff rewrites the code of a unified diff of the file, keeping the diff markers,
+// the file headers and the line numbers of the hunk headers
+func (a *Anonymizer) AnonymizeDiff(filePath, diff string) string {
+	return a.anonymize(filePath, diff, true)
+}
+
+// AnonymizeCode rewrites code of the file, e.g. the context gathered around a diff. Lines
+// starting with >>> are section headers, whose names are only replaced when the code
+// already had them replaced.
+func (a *Anonymizer) AnonymizeCode(filePath, code string) string {
+	return a.anonymize(filePath, code, false)
+}
+
+func (a *Anonymizer) anonymize(filePath, text string, isDiff bool) string {
+	lexer := &lexer{anonymizer: a, syntax: syntaxFor(filePath)}
+	lines := strings.Split(text, "\n")
+
+	var headers []int
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, ">>>"):
+			headers = append(headers, i)
+		case isDiff && isDiffHeader(line):
+			lexer.reset()
+		case isDiff && strings.HasPrefix(line, "@@"):
+			lexer.reset()
+			// The function git shows after the hunk range is code as well
+			if end := strings.Index(line[2:], "@@"); end >= 0 {
+				rangeEnd := end + 4
+				lines[i] = line[:rangeEnd] + lexer.line(line[rangeEnd:])
+				lexer.reset()
+			}
+		case isDiff && line != "" && strings.ContainsRune("+- ", rune(line[0])):
+			lines[i] = line[:1] + lexer.line(line[1:])
+		case isDiff:
+			// Such as "\ No newline at end of file"
+		default:
+			lines[i] = lexer.line(line)
+		}
+	}
+
+	// Headers are masked last, once the code has registered its names
+	for _, i := range headers {
+		lines[i] = a.maskIdentifiers(lines[i])
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isDiffHeader(line string) bool {
+	for _, prefix := range []string{"diff ", "index ", "--- ", "+++ ", "new file", "deleted file", "similarity ", "rename ", "old mode", "new mode"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask replaces the values already anonymized wherever they appear in a text, such as a
+// finding of the model restored for display that goes back to a model. Strings are only
+// replaced quoted and names as whole words.
+func (a *Anonymizer) Mask(text string) string {
+	a.mu.Lock()
+	var values []string
+	for key := range a.forward {
+		kind, value, _ := strings.Cut(key, ":")
+		if kind != kindIdentifier && len(strings.TrimSpace(value)) >= 4 {
+			values = append(values, key)
+		}
+	}
+	a.mu.Unlock()
+
+	// Longest first, so a value containing another one is replaced whole
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, key := range values {
+		kind, value, _ := strings.Cut(key, ":")
+		placeholder, _ := a.lookup(kind, value)
+		if kind == kindString {
+			for _, quote := range []string{`"`, `'`, "`"} {
+				text = strings.ReplaceAll(text, quote+value+quote, quote+placeholder+quote)
+			}
+			continue
+		}
+		text = strings.ReplaceAll(text, value, placeholder)
+	}
+	return a.maskIdentifiers(text)
+}
+
+var identifierRegex = regexp.MustCompile(`[A-Za-z_$][A-Za-z0-9_$]*`)
+
+func (a *Anonymizer) maskIdentifiers(text string) string {
+	if !a.identifiers {
+		return text
+	}
+	return identifierRegex.ReplaceAllStringFunc(text, func(name string) string {
+		if placeholder, ok := a.lookup(kindIdentifier, name); ok {
+			return placeholder
+		}
+		return name
+	})
+}
@@ -0,0 +1,79 @@
+package privacy
+
+import (
+	"strings"
+	"testing"
+)
+
+const billingDiff = `diff --git a/billing/charge.go b/billing/charge.go
+--- a/billing/charge.go
++++ b/billing/charge.go
+@@ -10,4 +10,7 @@ func Charge(customer Customer) error {
+ 	// Acme gets a discount until the renegotiation
+-	rate := 0.9
++	rate := lookupRate(customer, "acme-enterprise")
++	/* Temporary: see the
++	   contract of 2024 */
++	query := ` + "`SELECT rate\n+FROM contracts`" + `
+ 	return bill(customer, rate)`
+
+func TestAnonymizeDiff(t *testing.T) {
+	anonymizer := NewAnonymizer(false)
+	anonymized := anonymizer.AnonymizeDiff("billing/charge.go", billingDiff)
+
+	expected := `diff --git a/billing/charge.go b/billing/charge.go
+--- a/billing/charge.go
++++ b/billing/charge.go
+@@ -10,4 +10,7 @@ func Charge(customer Customer) error {
+ 	// COMMENT_1
+-	rate := 0.9
++	rate := lookupRate(customer, "STR_1")
++	/* COMMENT_2
++	   COMMENT_3 */
++	query := ` + "`STR_2\n+STR_3`" + `
+ 	return bill(customer, rate)`
+	if anonymized != expected {
+		t.Errorf("Unexpected anonymized diff:\n%s\nexpected:\n%s", anonymized, expected)
+	}
+
+	if restored := anonymizer.Restore("STR_1 is hardcoded, see COMMENT_1 and STR_9"); restored != "acme-enterprise is hardcoded, see Acme gets a discount until the renegotiation and STR_9" {
+		t.Errorf("Unexpected restored text %q", restored)
+	}
+}
+
+func TestAnonymizeCode_Identifiers(t *testing.T) {
+	anonymizer := NewAnonymizer(true)
+	code := ">>>>> Symbol: lookupRate (Package: billing)\n" +
+		"func lookupRate(customer Customer, plan string) float64 {\n" +
+		"\treturn rates[plan] * 1.5e3 // per plan\n" +
+		"}"
+
+	anonymized := anonymizer.AnonymizeCode("billing/rates.go", code)
+	expected := ">>>>> Symbol: ID_1 (Package: billing)\n" +
+		"func ID_1(ID_2 ID_3, ID_4 string) float64 {\n" +
+		"\treturn ID_5[ID_4] * 1.5e3 // COMMENT_1\n" +
+		"}"
+	if anonymized != expected {
+		t.Errorf("Unexpected anonymized code:\n%s\nexpected:\n%s", anonymized, expected)
+	}
+
+	if restored := anonymizer.Restore("ID_1 ignores ID_3.Region"); restored != "lookupRate ignores Customer.Region" {
+		t.Errorf("Unexpected restored text %q", restored)
+	}
+	if masked := anonymizer.Mask("lookupRate reads rates // per plan"); masked != "ID_1 reads ID_5 // COMMENT_1" {
+		t.Errorf("Unexpected masked text %q", masked)
+	}
+}
+
+func TestAnonymizeCode_Shell(t *testing.T) {
+	anonymizer := NewAnonymizer(true)
+	script := "echo \"deploying ${#TARGETS[@]} targets\" # to prod\ncurl -H 'Authorization: Bearer x' $URL"
+
+	anonymized := anonymizer.AnonymizeCode("deploy.sh", script)
+	if anonymized != "echo \"STR_1\" # COMMENT_1\ncurl -H 'STR_2' $URL" {
+		t.Errorf("Unexpected anonymized script %q", anonymized)
+	}
+	if strings.Contains(anonymized, "ID_") {
+		t.Error("Expected the names of languages without keywords kept")
+	}
+}
@@ -0,0 +1,136 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// DescribeVariants generate a description of the changes instead of reviewing them. They
+// receive the same payload as the review prompts.
+var DescribeVariants = map[string]types.PromptVariant{
+	"commit": {
+		Name:        "commit",
+		Description: "Commit message with summary, risk areas and test notes",
+		Template:    commitMessageTemplate,
+	},
+	"pr": {
+		Name:        "pr",
+		Description: "Markdown pull request description with summary, risk areas and test notes",
+		Template:    prDescriptionTemplate,
+	},
+}
+
+const DEFAULT_DESCRIBE_PROMPT = "commit"
+
+func GetDescribeVariant(name string) (types.PromptVariant, error) {
+	variant, exists := DescribeVariants[name]
+	if !exists {
+		return types.PromptVariant{}, fmt.Errorf("describe variant '%s' not found", name)
+	}
+	return variant, nil
+}
+
+func BuildDescribePrompt(variantName string, payload string) (string, error) {
+	variant, err := GetDescribeVariant(variantName)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(variant.Name).Parse(variant.Template)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", variant.Name, err)
+	}
+
+	var result strings.Builder
+	if err := tmpl.Execute(&result, payload); err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", variant.Name, err)
+	}
+
+	return result.String(), nil
+}
+
+// BuildSummaryPrompt asks for the summary of a whole changeset that precedes the review of
+// its files
+func BuildSummaryPrompt(payload string) (string, error) {
+	tmpl, err := template.New("summary").Parse(changesetSummaryTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template summary: %w", err)
+	}
+
+	var result strings.Builder
+	if err := tmpl.Execute(&result, payload); err != nil {
+		return "", fmt.Errorf("failed to execute template summary: %w", err)
+	}
+
+	return result.String(), nil
+}
+
+const changesetSummaryTemplate = `You are a Principal Software Engineer preparing the review of a changeset. Its files will be reviewed one by one, and your summary will be given to the reviewer of each file so it knows what the rest of the changeset does.
+
+=== CODE CHANGES ===
+{{.}}
+
+=== INSTRUCTIONS ===
+- Base the summary ONLY on lines starting with + or -
+- Name the files and symbols involved, so the reviewer of one file can relate it to the others
+- Do not review the code or report issues
+- Keep it under 200 words
+
+=== OUTPUT FORMAT ===
+Return ONLY Markdown, without code fences or commentary:
+
+**What changed:** <the changes across the files, grouped by purpose>
+
+**Likely intent:** <what the changeset is meant to achieve>
+
+**Architectural impact:** <interfaces, data flows or dependencies the changeset alters, or "None">`
+
+const commitMessageTemplate = `You are a Principal Software Engineer writing the commit message for the code changes below.
+
+=== CODE CHANGES ===
+{{.}}
+
+=== INSTRUCTIONS ===
+- Describe what the changes do and why, based ONLY on lines starting with + or -
+- Use the affected symbols and their usages to name the areas the change may break
+- Do not invent behavior, tickets or tests that do not appear in the changes
+
+=== OUTPUT FORMAT ===
+Return ONLY the commit message as plain text, without code fences or commentary:
+
+<imperative subject line, at most 72 characters, no trailing period>
+
+<one short paragraph summarizing the change and its motivation>
+
+Risk areas:
+- <code paths or callers that could be affected>
+
+Test notes:
+- <tests added or changed in the diff, or what should be verified manually>`
+
+const prDescriptionTemplate = `You are a Principal Software Engineer writing the pull request description for the code changes below.
+
+=== CODE CHANGES ===
+{{.}}
+
+=== INSTRUCTIONS ===
+- Describe what the changes do and why, based ONLY on lines starting with + or -
+- Use the affected symbols and their usages to name the areas the change may break
+- Do not invent behavior, tickets or tests that do not appear in the changes
+
+=== OUTPUT FORMAT ===
+Return ONLY the description as Markdown, without wrapping it in a code fence or adding commentary:
+
+# <imperative title, at most 72 characters>
+
+## Summary
+<2-4 sentences on what changed and why>
+
+## Risk areas
+- <code paths or callers that could be affected, with file paths>
+
+## Test notes
+- <tests added or changed in the diff, or what reviewers should verify manually>`
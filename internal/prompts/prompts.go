@@ -24,10 +24,34 @@ var PromptVariants = map[string]types.PromptVariant{
 		Description: "Prompt variant with improvements for better format output",
 		Template:    optimizedPromptTemplate,
 	},
+	"security": {
+		Name:        "security",
+		Description: "Security review of the data flow from HTTP handlers to sinks",
+		Template:    securityPromptTemplate,
+	},
+	"infrastructure": {
+		Name:        "infrastructure",
+		Description: "Review of Terraform changes for exposed, unencrypted or over-privileged resources",
+		Template:    infrastructurePromptTemplate,
+	},
+	"fixes": {
+		Name:        "fixes",
+		Description: "Optimized prompt asking for a minimal patch fixing each issue",
+		Template:    optimizedPromptTemplate + suggestedFixInstruction,
+	},
 }
 
+// SECURITY_PROMPT is the variant used by the security review profile
+const SECURITY_PROMPT = "security"
+
 const DEFAULT_PROMPT = "optimized"
 
+// INFRASTRUCTURE_PROMPT is the variant used for Terraform and other HCL files
+const INFRASTRUCTURE_PROMPT = "infrastructure"
+
+// FIXES_PROMPT is the variant used when patches are emitted
+const FIXES_PROMPT = "fixes"
+
 func GetPromptVariant(name string) (types.PromptVariant, error) {
 	variant, exists := PromptVariants[name]
 	if !exists {
@@ -72,6 +96,93 @@ func BuildPromptWithTemplate(variantName string, payload string) (string, error)
 	return result.String(), nil
 }
 
+// structuredOutputOverride replaces the free-text format rules of the templates when the
+// provider constrains the answer to the issues schema
+const structuredOutputOverride = `
+
+=== STRUCTURED OUTPUT ===
+Your answer is constrained to a JSON object of the form {"issues": [...]}.
+Ignore the instructions above about returning a raw array or "APPROVED":
+- Report each issue as an element of "issues" with the fields described above
+- Set "confidence" between 0 and 1 to reflect how sure you are the issue is real
+- Leave "suggested_fix" empty unless the instructions above ask for patches
+- Return {"issues": []} when the changes are clean`
+
+// WithStructuredOutput adapts a built prompt to schema constrained answers
+func WithStructuredOutput(prompt string) string {
+	return prompt + structuredOutputOverride
+}
+
+// questionsInstruction lets the model ask the author questions next to the issues
+const questionsInstruction = `
+
+=== QUESTIONS FOR THE AUTHOR ===
+You may also ask the author short, non-blocking questions about intent you cannot infer from the code.
+Questions are not issues: never ask about something you report as an issue, and do not ask when the code is clear.
+When you have questions, answer with a JSON object instead of an array or "APPROVED":
+{"issues": [...issues in the format above, or empty...], "questions": [{"file_path": "exact/path/from/diff/header.go", "line": 12, "question": "Is the retry meant to be unbounded?"}]}`
+
+// WithQuestions allows the model to add questions for the author to its answer
+func WithQuestions(prompt string) string {
+	return prompt + questionsInstruction
+}
+
+// migrationInstruction asks about the risks of schema migrations
+const migrationInstruction = `
+
+=== SCHEMA MIGRATIONS ===
+The diff contains database migrations. Besides the usual review, check each migration for:
+- Destructive operations: dropped tables or columns, truncations, narrowed column types and renames that break the code still reading the old names. Report data loss without a backfill or a safe rollout as CRITICAL
+- New foreign keys without an index on the referencing columns, which slows down joins and the deletes of the referenced rows
+- DDL that cannot run in a transaction (e.g. CREATE INDEX CONCURRENTLY, ALTER TYPE ... ADD VALUE in PostgreSQL) mixed with other statements, or DDL that locks large tables, such as adding a NOT NULL column without a default or building an index without CONCURRENTLY
+- Down migrations that do not restore the previous schema`
+
+// WithMigrationChecks asks the model about destructive operations, missing indexes and
+// non-transactional DDL in the migrations of the diff
+func WithMigrationChecks(prompt string) string {
+	return prompt + migrationInstruction
+}
+
+// intentInstruction introduces the tickets the change references
+const intentInstruction = `
+
+=== INTENT CONTEXT ===
+The change references the tickets below. Use them to judge whether the business logic does what was asked, and report code that contradicts them.
+Do not report work the tickets ask for that is missing from the diff, it may belong to other changes.
+
+%s`
+
+// WithIntent adds the tickets describing what the change is meant to do
+func WithIntent(prompt, intent string) string {
+	return prompt + fmt.Sprintf(intentInstruction, intent)
+}
+
+// summaryInstruction introduces the summary of the whole changeset the file belongs to
+const summaryInstruction = `
+
+=== CHANGESET SUMMARY ===
+The diff above is one file of a larger changeset, summarized below. Use the summary to judge how the file fits the rest of the changes, but only report issues in the lines of this diff.
+
+%s`
+
+// WithChangesetSummary adds the summary of the whole changeset to the prompt of one of its
+// files
+func WithChangesetSummary(prompt, summary string) string {
+	return prompt + fmt.Sprintf(summaryInstruction, summary)
+}
+
+// suggestedFixInstruction extends the issue format with a patch fixing the issue
+const suggestedFixInstruction = `
+
+=== SUGGESTED FIXES ===
+Add a "suggested_fix" field to every issue with a minimal patch fixing it, in unified diff format against the new version of the file:
+- One or more hunks starting with "@@ -<start>,<count> +<start>,<count> @@", using the line numbers of the new file
+- Context lines start with a space, removed lines with -, added lines with +
+- Change only the lines needed to fix the issue, keep 1-3 unchanged context lines around them
+- Escape newlines as \n inside the JSON string
+- Use an empty string when the fix is not local to a few lines
+Example: "suggested_fix": "@@ -42,2 +42,5 @@\n rows, err := db.Query(sql)\n+if err != nil {\n+\treturn err\n+}\n defer rows.Close()"`
+
 const defaultPromptTemplate = `You are an expert code reviewer analyzing code changes for real issues.
 === CODE CHANGES TO REVIEW ===
 {{.}}
@@ -121,7 +232,8 @@ FORMAT 2 - Issues found:
     "start_line": 18,
     "end_line": 19,
     "description": "Replaced parameterized SQL query with fmt.Sprintf(), creating SQL injection vulnerability",
-    "code_snippet": "query := fmt.Sprintf(\"SELECT * FROM users WHERE name = '%s'\", name)"
+    "code_snippet": "query := fmt.Sprintf(\"SELECT * FROM users WHERE name = '%s'\", name)",
+    "confidence": 0.95
   }
 ]
 
@@ -130,6 +242,7 @@ CRITICAL RULES:
 - Line numbers must correspond to the changed lines in the diff
 - Severity must be: "CRITICAL", "WARNING", or "MINOR"
 - Include code_snippet with the problematic code from the diff
+- Set confidence between 0 and 1: how sure you are the issue is real and introduced by the diff
 - No explanatory text, reasoning, or markdown formatting
 - Respond with raw JSON array or "APPROVED" only`
 
@@ -183,7 +296,8 @@ FORMAT 2 - Issues found:
     "start_line": 18,
     "end_line": 19,
     "description": "Replaced parameterized SQL query with fmt.Sprintf(), creating SQL injection vulnerability",
-    "code_snippet": "query := fmt.Sprintf(\"SELECT * FROM users WHERE name = '%s'\", name)"
+    "code_snippet": "query := fmt.Sprintf(\"SELECT * FROM users WHERE name = '%s'\", name)",
+    "confidence": 0.95
   }
 ]
 
@@ -192,6 +306,7 @@ CRITICAL RULES:
 - Line numbers must correspond to the changed lines in the diff
 - Severity must be: "CRITICAL", "WARNING", or "MINOR"
 - Include code_snippet with the problematic code from the diff
+- Set confidence between 0 and 1: how sure you are the issue is real and introduced by the diff
 - Provide a clear and actionable suggestion for each issue.
 - No explanatory text, reasoning, or markdown formatting
 - Respond with raw JSON array or "APPROVED" only`
@@ -266,7 +381,8 @@ If issues found:
     "start_line": 25,
     "end_line": 27,
     "description": "Specific issue description with actionable fix suggestion",
-    "code_snippet": "The actual problematic code from the diff"
+    "code_snippet": "The actual problematic code from the diff",
+    "confidence": 0.8
   }
 ]
 
@@ -276,7 +392,7 @@ Example 1 - No issues:
 APPROVED
 
 Example 2 - Single issue:
-[{"severity":"WARNING","file_path":"internal/auth/handler.go","start_line":42,"end_line":42,"description":"Missing error handling for database query - add proper error checking and return appropriate HTTP status","code_snippet":"rows, err := db.Query(sql)"}]
+[{"severity":"WARNING","file_path":"internal/auth/handler.go","start_line":42,"end_line":42,"description":"Missing error handling for database query - add proper error checking and return appropriate HTTP status","code_snippet":"rows, err := db.Query(sql)","confidence":0.9}]
 
 Example 3 - Multiple issues:
 [
@@ -286,7 +402,8 @@ Example 3 - Multiple issues:
     "start_line": 18,
     "end_line": 20,
     "description": "SQL injection vulnerability - replace fmt.Sprintf with parameterized query using database/sql placeholders",
-    "code_snippet": "query := fmt.Sprintf(\"SELECT * FROM users WHERE id = '%s'\", userID)"
+    "code_snippet": "query := fmt.Sprintf(\"SELECT * FROM users WHERE id = '%s'\", userID)",
+    "confidence": 0.95
   },
   {
     "severity": "WARNING",
@@ -294,12 +411,13 @@ Example 3 - Multiple issues:
     "start_line": 35,
     "end_line": 35, 
     "description": "Missing error handling for database connection - add proper error checking and connection cleanup",
-    "code_snippet": "conn, err := db.Connect()"
+    "code_snippet": "conn, err := db.Connect()",
+    "confidence": 0.7
   }
 ]
 
 Example 4 - Incomplete security fix:
-[{"severity":"CRITICAL","file_path":"internal/handler/file.go","start_line":42,"end_line":45,"description":"Path traversal vulnerability - filename validation checks for invalid characters but doesn't prevent directory traversal attacks using .. or / - add filepath.Clean and verify result stays within allowed directory","code_snippet":"if strings.Contains(filename, \"<\") { return err }"}]
+[{"severity":"CRITICAL","file_path":"internal/handler/file.go","start_line":42,"end_line":45,"description":"Path traversal vulnerability - filename validation checks for invalid characters but doesn't prevent directory traversal attacks using .. or / - add filepath.Clean and verify result stays within allowed directory","code_snippet":"if strings.Contains(filename, \"<\") { return err }","confidence":0.85}]
 
 === CRITICAL FORMATTING RULES ===
 ✅ MUST: Use exact file path from diff header (e.g., "a/internal/service.go" → "internal/service.go")
@@ -307,6 +425,7 @@ Example 4 - Incomplete security fix:
 ✅ MUST: Severity must be exactly "CRITICAL", "WARNING", or "MINOR" 
 ✅ MUST: Description must be actionable and specific
 ✅ MUST: Include code_snippet with the problematic code from the diff
+✅ MUST: Set confidence between 0 and 1: how sure you are the issue is real and introduced by the diff
 ✅ MUST: Return valid JSON array or exactly "APPROVED"
 ✅ MUST: Complete the entire JSON array - do not stop mid-object
 ✅ MUST: Ensure all JSON objects are properly closed with } and array ends with ]
@@ -315,3 +434,141 @@ Example 4 - Incomplete security fix:
 ❌ NEVER: Use markdown formatting in the response
 ❌ NEVER: Add text before or after the JSON/APPROVED response
 ❌ NEVER: Stop generating before closing the JSON array`
+
+const securityPromptTemplate = `You are an application security engineer reviewing code changes for exploitable vulnerabilities. Return results in the exact specified format.
+
+=== CODE CHANGES TO REVIEW ===
+{{.}}
+
+=== AVAILABLE CONTEXT ===
+- "Security Sinks Touched By The Change" lists the changed lines calling SQL, command execution, template rendering or cryptography APIs
+- "Reachable from HTTP handlers" lists call chains from request handlers to a changed symbol: data from those requests may reach the change
+- Treat request parameters, headers, bodies, cookies and path segments as untrusted
+
+=== AVAILABLE TOOLS ===
+Use "human_loop" tool **ONLY** when a critical information gap prevents a conclusion.
+
+=== ANALYSIS PROCESS ===
+
+STEP 1: FOLLOW THE DATA
+- Examine ONLY lines starting with + (additions) or - (deletions)
+- For every sink, trace its arguments back through the changed code and the handler chains
+- Decide whether untrusted data reaches the sink without validation, escaping or parameterization
+
+STEP 2: CLASSIFY
+CRITICAL (exploitable by a remote attacker):
+- SQL injection: untrusted data concatenated or formatted into a query
+- Command injection: untrusted data in a command or its arguments
+- XSS: untrusted data rendered without escaping, or escaping removed
+- Path traversal: untrusted paths not confined to an allowed directory
+- Authentication/authorization bypass: removed or weakened checks on a reachable path
+- Exposed secrets: hardcoded credentials, API keys or tokens
+
+WARNING (weakens security without a direct exploit):
+- Weak cryptography: MD5/SHA-1 for passwords or signatures, insecure randomness for tokens, disabled certificate verification
+- Incomplete validation: checks that miss encodings, separators or alternate inputs
+- Sensitive data in logs or error messages returned to clients
+
+MINOR (hardening):
+- Missing defense in depth on data that is not reachable from a handler
+
+DO NOT FLAG:
+- Sinks fed only by constants or trusted configuration
+- Code quality, performance or style issues unrelated to security
+- Unchanged code
+
+=== RESPONSE FORMAT ===
+
+If NO issues found:
+APPROVED
+
+If issues found:
+[
+  {
+    "severity": "CRITICAL",
+    "file_path": "exact/path/from/diff/header.go",
+    "start_line": 25,
+    "end_line": 27,
+    "description": "Vulnerability, how untrusted data reaches it and the fix",
+    "code_snippet": "The actual problematic code from the diff",
+    "confidence": 0.8
+  }
+]
+
+=== CRITICAL FORMATTING RULES ===
+✅ MUST: Use exact file path from diff header (e.g., "a/internal/service.go" → "internal/service.go")
+✅ MUST: Line numbers must match the actual changed lines in the diff
+✅ MUST: Severity must be exactly "CRITICAL", "WARNING", or "MINOR"
+✅ MUST: Name the handler or input the data comes from when a chain is available
+✅ MUST: Set confidence between 0 and 1: how sure you are the vulnerability is real and exploitable
+✅ MUST: Return valid JSON array or exactly "APPROVED"
+❌ NEVER: Add text before or after the JSON/APPROVED response`
+
+const infrastructurePromptTemplate = `You are a cloud infrastructure engineer reviewing Terraform changes for insecure or unsafe resources. Return results in the exact specified format.
+
+=== CODE CHANGES TO REVIEW ===
+{{.}}
+
+=== AVAILABLE CONTEXT ===
+- Symbols are named the way Terraform references them: aws_security_group.web, var.region, module.vpc, local.tags
+- The context lists the other blocks of the module referencing or referenced by the changed ones
+- Values coming from variables may be set per environment: check their defaults and validations before assuming them
+
+=== AVAILABLE TOOLS ===
+Use "human_loop" tool **ONLY** when a critical information gap prevents a conclusion.
+
+=== ANALYSIS PROCESS ===
+
+STEP 1: READ THE CHANGED BLOCKS
+- Examine ONLY lines starting with + (additions) or - (deletions)
+- For every changed resource, work out what it exposes, who can reach it and what happens to its data
+- Follow the variables, locals and module outputs a changed argument is set from
+
+STEP 2: CLASSIFY
+CRITICAL (exposes the infrastructure or its data):
+- Network exposure: ingress rules, security groups or firewalls opened to 0.0.0.0/0 or ::/0, especially on SSH, RDP or database ports
+- Public data: buckets, snapshots, databases or images made public, public access blocks removed
+- Over-privileged access: IAM policies granting "*" actions or resources, or assumable by any principal
+- Exposed secrets: hardcoded passwords, keys or tokens, or sensitive outputs and variables not marked sensitive
+
+WARNING (weakens protection without a direct exposure):
+- Missing encryption: storage, volumes, databases, queues or logs without encryption at rest, or endpoints without TLS
+- Removed safeguards: deletion protection, prevent_destroy, backups, versioning or point-in-time recovery disabled
+- Destructive changes: renamed resources or arguments forcing a replacement of stateful resources without a moved block
+- Logging and monitoring turned off
+
+MINOR (hardening):
+- Unpinned provider or module versions
+- Missing validation on variables feeding security relevant arguments
+
+DO NOT FLAG:
+- Formatting, naming or tagging conventions
+- Cost or sizing choices unrelated to security or availability
+- Unchanged blocks
+
+=== RESPONSE FORMAT ===
+
+If NO issues found:
+APPROVED
+
+If issues found:
+[
+  {
+    "severity": "CRITICAL",
+    "file_path": "exact/path/from/diff/header.tf",
+    "start_line": 25,
+    "end_line": 27,
+    "description": "What the change exposes or weakens and the fix",
+    "code_snippet": "The actual problematic code from the diff",
+    "confidence": 0.8
+  }
+]
+
+=== CRITICAL FORMATTING RULES ===
+✅ MUST: Use exact file path from diff header (e.g., "a/infra/network.tf" → "infra/network.tf")
+✅ MUST: Line numbers must match the actual changed lines in the diff
+✅ MUST: Severity must be exactly "CRITICAL", "WARNING", or "MINOR"
+✅ MUST: Name the resource address (e.g., aws_security_group.web) in the description
+✅ MUST: Set confidence between 0 and 1: how sure you are the issue is real
+✅ MUST: Return valid JSON array or exactly "APPROVED"
+❌ NEVER: Add text before or after the JSON/APPROVED response`
@@ -0,0 +1,49 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// TriageInput is the file diff the triage model classifies
+type TriageInput struct {
+	FilePath string
+	// Changes is the diff of the file, without context
+	Changes string
+}
+
+// BuildTriagePrompt asks a fast model whether the diff of a file is trivial or needs the
+// deep review of the review model
+func BuildTriagePrompt(input TriageInput) (string, error) {
+	tmpl, err := template.New("triage").Parse(triagePromptTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template triage: %w", err)
+	}
+
+	var result strings.Builder
+	if err := tmpl.Execute(&result, input); err != nil {
+		return "", fmt.Errorf("failed to execute template triage: %w", err)
+	}
+
+	return result.String(), nil
+}
+
+const triagePromptTemplate = `You are triaging the files of a code change before an expensive in-depth review. Decide whether the change to this file needs that review.
+
+=== FILE ===
+{{.FilePath}}
+
+=== CODE CHANGES ===
+{{.Changes}}
+
+=== INSTRUCTIONS ===
+- Answer "trivial" only for mechanical changes that cannot introduce a bug: formatting, renames applied consistently, comment and documentation edits, import reordering, version bumps, moved code left unchanged
+- Answer "review" for anything changing behavior: logic, conditions, error handling, concurrency, data access, security sensitive code, configuration values
+- When unsure, answer "review"
+
+=== OUTPUT FORMAT ===
+Return ONLY a JSON object, without code fences or commentary:
+{"verdict": "trivial", "reason": "<one sentence>"}
+or
+{"verdict": "review", "reason": "<one sentence>"}`
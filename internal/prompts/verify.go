@@ -0,0 +1,111 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// VerifyVariants ask the model whether a finding of the review is genuinely introduced by
+// the diff. They receive a VerifyInput.
+var VerifyVariants = map[string]types.PromptVariant{
+	"verify": {
+		Name:        "verify",
+		Description: "Confirms findings introduced by the diff, rejects pre-existing or speculative ones",
+		Template:    verifyPromptTemplate,
+	},
+	"strict": {
+		Name:        "strict",
+		Description: "Only confirms findings the shown code demonstrates, rejects anything depending on unseen code",
+		Template:    strictVerifyPromptTemplate,
+	},
+}
+
+const DEFAULT_VERIFY_PROMPT = "verify"
+
+// VerifyInput is the finding under verification with the changes it was found in
+type VerifyInput struct {
+	Issue types.Issue
+	// Changes is the diff of the file with its gathered context, as sent to the review
+	Changes string
+}
+
+func GetVerifyVariant(name string) (types.PromptVariant, error) {
+	variant, exists := VerifyVariants[name]
+	if !exists {
+		return types.PromptVariant{}, fmt.Errorf("verify variant '%s' not found", name)
+	}
+	return variant, nil
+}
+
+func BuildVerifyPrompt(variantName string, input VerifyInput) (string, error) {
+	variant, err := GetVerifyVariant(variantName)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(variant.Name).Parse(variant.Template)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", variant.Name, err)
+	}
+
+	var result strings.Builder
+	if err := tmpl.Execute(&result, input); err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", variant.Name, err)
+	}
+
+	return result.String(), nil
+}
+
+const verifyOutputFormat = `=== OUTPUT FORMAT ===
+Return ONLY a JSON object, without code fences or commentary:
+{"verdict": "confirmed", "reason": "<one sentence>"}
+or
+{"verdict": "rejected", "reason": "<one sentence>"}`
+
+const verifyPromptTemplate = `You are a Principal Software Engineer double-checking a finding of an automated code review before it reaches the author.
+
+=== FINDING ===
+File: {{.Issue.FilePath}} (lines {{.Issue.StartLine}}-{{.Issue.EndLine}})
+Severity: {{.Issue.Severity}}
+Description: {{.Issue.Description}}
+{{- if .Issue.CodeSnippet}}
+Code:
+{{.Issue.CodeSnippet}}
+{{- end}}
+
+=== CODE CHANGES ===
+{{.Changes}}
+
+=== INSTRUCTIONS ===
+- Confirm the finding only if it is a real problem AND it is introduced by lines starting with + or -
+- Reject it if the problem already existed before the change, is handled elsewhere in the shown code, or the finding misreads the code
+- Reject style preferences and speculation about code that is not shown
+- When unsure, confirm it
+
+` + verifyOutputFormat
+
+const strictVerifyPromptTemplate = `You are a Principal Software Engineer double-checking a finding of an automated code review before it reaches the author.
+
+=== FINDING ===
+File: {{.Issue.FilePath}} (lines {{.Issue.StartLine}}-{{.Issue.EndLine}})
+Severity: {{.Issue.Severity}}
+Description: {{.Issue.Description}}
+{{- if .Issue.CodeSnippet}}
+Code:
+{{.Issue.CodeSnippet}}
+{{- end}}
+
+=== CODE CHANGES ===
+{{.Changes}}
+
+=== INSTRUCTIONS ===
+- Confirm the finding only if the shown code demonstrates it: point to the added or removed lines causing it
+- Reject it if it depends on callers, inputs or configuration that are not shown
+- Reject it if the problem already existed before the change or is handled elsewhere in the shown code
+- Reject style preferences and missing features
+- When unsure, reject it
+
+` + verifyOutputFormat
@@ -0,0 +1,172 @@
+// Package search finds the files containing literal patterns in-process. The files of an
+// index are read once for a whole batch of patterns by a pool of workers, instead of running
+// a grep process per pattern.
+package search
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/agusespa/diffpector/internal/platform"
+)
+
+// binarySniffBytes is how much of a file is looked at for a NUL byte, the way git tells
+// binary files apart
+const binarySniffBytes = 8000
+
+// Index is a set of files to search, slash separated and relative to Root
+type Index struct {
+	Root  string
+	Files []string
+	// Read returns the content of a file of the index, such as the file at another revision,
+	// the working tree file is read when nil. It must be safe for concurrent use.
+	Read func(file string) ([]byte, error)
+}
+
+// NewIndex creates the index of the files, relative to root
+func NewIndex(root string, files []string) *Index {
+	normalized := make([]string, len(files))
+	for i, file := range files {
+		normalized[i] = platform.NormalizePath(file)
+	}
+	return &Index{Root: root, Files: normalized}
+}
+
+// Search returns the files containing each pattern, in the order of the index, restricted to
+// the files matching one of the pathspecs when there are any. The patterns are matched
+// literally. Binary files, and the files that cannot be read, such as tracked files deleted
+// from the working tree, are skipped.
+func (i *Index) Search(patterns, pathspecs []string) map[string][]string {
+	files := i.matching(pathspecs)
+	needles := make([][]byte, len(patterns))
+	for p, pattern := range patterns {
+		needles[p] = []byte(pattern)
+	}
+
+	// hits holds the indexes of the patterns found in each file, written by one worker each
+	hits := make([][]int, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				hits[f] = i.searchFile(files[f], needles)
+			}
+		}()
+	}
+	for f := range files {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+
+	found := make(map[string][]string, len(patterns))
+	for f, fileHits := range hits {
+		for _, p := range fileHits {
+			found[patterns[p]] = append(found[patterns[p]], files[f])
+		}
+	}
+	return found
+}
+
+// searchFile returns the indexes of the needles the file contains
+func (i *Index) searchFile(file string, needles [][]byte) []int {
+	var content []byte
+	var err error
+	if i.Read != nil {
+		content, err = i.Read(file)
+	} else {
+		content, err = os.ReadFile(platform.JoinPath(i.Root, file))
+	}
+	if err != nil || bytes.IndexByte(content[:min(len(content), binarySniffBytes)], 0) != -1 {
+		return nil
+	}
+
+	var hits []int
+	for n, needle := range needles {
+		if len(needle) > 0 && bytes.Contains(content, needle) {
+			hits = append(hits, n)
+		}
+	}
+	return hits
+}
+
+// matching returns the files of the index matching one of the pathspecs, all of them
+// without pathspecs
+func (i *Index) matching(pathspecs []string) []string {
+	if len(pathspecs) == 0 {
+		return i.Files
+	}
+
+	matchers := make([]func(string) bool, len(pathspecs))
+	for s, spec := range pathspecs {
+		matchers[s] = pathspecMatcher(spec)
+	}
+	var files []string
+	for _, file := range i.Files {
+		for _, match := range matchers {
+			if match(file) {
+				files = append(files, file)
+				break
+			}
+		}
+	}
+	return files
+}
+
+// pathspecMatcher matches paths against a pathspec like git does: a pathspec with wildcards
+// matches the whole path, its * also crossing directories so that *.go matches at any depth,
+// and one without matches the path itself or the files of the directory it names
+func pathspecMatcher(spec string) func(string) bool {
+	spec = platform.NormalizePath(spec)
+	if !strings.ContainsAny(spec, "*?[") {
+		return func(file string) bool {
+			return spec == "." || file == spec || strings.HasPrefix(file, spec+"/")
+		}
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for rest := spec; rest != ""; {
+		special := strings.IndexAny(rest, "*?[")
+		if special == -1 {
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		pattern.WriteString(regexp.QuoteMeta(rest[:special]))
+
+		wildcard := rest[special]
+		rest = rest[special+1:]
+		switch wildcard {
+		case '*':
+			pattern.WriteString(".*")
+		case '?':
+			pattern.WriteString(".")
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				pattern.WriteString(`\[`)
+				continue
+			}
+			class := rest[:end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			pattern.WriteString("[" + class + "]")
+			rest = rest[end+1:]
+		}
+	}
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return func(string) bool { return false }
+	}
+	return re.MatchString
+}
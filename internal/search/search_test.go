@@ -0,0 +1,77 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIndex_Search(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":          "package main\n\nfunc main() {\n\tTotal(1, 2)\n}\n",
+		"pkg/total.go":     "package pkg\n\nfunc Total(a, b int) int { return a + b }\n",
+		"pkg/report.go":    "package pkg\n\nfunc Report() string { return \"report\" }\n",
+		"docs/total.md":    "Total sums the amounts\n",
+		"assets/total.bin": "Total\x00\x01",
+		"pkg/café.go":      "package pkg\n\nvar Café = Total(0, 0)\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// deleted.go is tracked but missing from the working tree
+	index := NewIndex(root, []string{"main.go", "pkg/total.go", "pkg/report.go", "docs/total.md", "assets/total.bin", "pkg/café.go", "deleted.go"})
+
+	found := index.Search([]string{"Total", "Report", "Café", "Missing"}, nil)
+	expected := map[string][]string{
+		"Total":  {"main.go", "pkg/total.go", "docs/total.md", "pkg/café.go"},
+		"Report": {"pkg/report.go"},
+		"Café":   {"pkg/café.go"},
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected %v, got %v", expected, found)
+	}
+
+	found = index.Search([]string{"Total"}, []string{"*.go"})
+	if expected := []string{"main.go", "pkg/total.go", "pkg/café.go"}; !reflect.DeepEqual(found["Total"], expected) {
+		t.Errorf("Expected the Go files %v, got %v", expected, found["Total"])
+	}
+}
+
+func TestPathspecMatcher(t *testing.T) {
+	tests := []struct {
+		spec     string
+		file     string
+		expected bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "internal/tools/go_parser.go", true},
+		{"*.go", "go.mod", false},
+		{"go.mod", "go.mod", true},
+		{"go.mod", "services/billing/go.mod", false},
+		{"internal", "internal/tools/tool.go", true},
+		{"internal/", "internal/tools/tool.go", true},
+		{"internal", "internals/tool.go", false},
+		{"internal/*_test.go", "internal/tools/tool_test.go", true},
+		{"file?.txt", "file1.txt", true},
+		{"file[0-9].txt", "file7.txt", true},
+		{"file[!0-9].txt", "file7.txt", false},
+		{"docs/[.md", "docs/[.md", true},
+		{"*.tf", "modules/vpc/main.tf", true},
+		{".", "anything.go", true},
+	}
+
+	for _, tt := range tests {
+		if matched := pathspecMatcher(tt.spec)(tt.file); matched != tt.expected {
+			t.Errorf("pathspec %q on %q: expected %v, got %v", tt.spec, tt.file, tt.expected, matched)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package secrets
+
+import (
+	"context"
+
+	"github.com/agusespa/diffpector/internal/llm"
+)
+
+// RedactingProvider redacts the secrets of every prompt and message before passing them to
+// the wrapped provider
+type RedactingProvider struct {
+	llm.Provider
+}
+
+func NewRedactingProvider(provider llm.Provider) *RedactingProvider {
+	return &RedactingProvider{Provider: provider}
+}
+
+func (p *RedactingProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	redacted, _ := Redact(prompt)
+	return p.Provider.Generate(ctx, redacted)
+}
+
+func (p *RedactingProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	return p.Provider.ChatWithTools(ctx, redactMessages(messages), tools)
+}
+
+func (p *RedactingProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, tools []llm.Tool, schema *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	return p.Provider.ChatWithSchema(ctx, redactMessages(messages), tools, schema)
+}
+
+// redactMessages copies the messages, the caller keeps its history unredacted
+func redactMessages(messages []llm.Message) []llm.Message {
+	redacted := make([]llm.Message, len(messages))
+	for i, message := range messages {
+		message.Content, _ = Redact(message.Content)
+		redacted[i] = message
+	}
+	return redacted
+}
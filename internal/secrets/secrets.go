@@ -0,0 +1,236 @@
+// Package secrets finds credentials in code with regexes and an entropy check, so they can be
+// redacted before the code is sent to a remote model.
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Finding is a secret found on a line of the scanned text
+type Finding struct {
+	Line int
+	// Rule names the kind of secret, e.g. aws-access-key
+	Rule string
+}
+
+type rule struct {
+	name  string
+	regex *regexp.Regexp
+	// generic rules match any value assigned to a secret-looking name, which is only taken
+	// for a secret when it is random enough
+	generic bool
+}
+
+// rules match the secret as their first group, or as the whole match when they have none
+var rules = []rule{
+	{name: "aws-access-key", regex: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github-token", regex: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{name: "gitlab-token", regex: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{name: "slack-token", regex: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{name: "stripe-key", regex: regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}`)},
+	{name: "google-api-key", regex: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{name: "api-key", regex: regexp.MustCompile(`\bsk-(?:proj-|ant-[a-z0-9]+-)?[A-Za-z0-9_-]{32,}`)},
+	{name: "jwt", regex: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{name: "url-credentials", regex: regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@"']+:([^/\s:@"']{3,})@`)},
+	{name: "hardcoded-credential", generic: true, regex: regexp.MustCompile(
+		`(?i)[\w.-]*(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)[\w.-]*["']?\s*(?::=|=>|[:=])\s*["'` + "`" + `]([^"'` + "`" + `\s]{8,})["'` + "`" + `]`)},
+}
+
+var (
+	privateKeyBeginRegex = regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)
+	privateKeyEndRegex   = regexp.MustCompile(`-----END (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)
+	// placeholderRegex matches the values that are references or templates, not secrets
+	placeholderRegex = regexp.MustCompile(`(?i)^\$\{|^\$[A-Z_]|\{\{|%[sv]|^<.*>$|changeme|example|placeholder|redacted|x{6,}|\*{4,}`)
+)
+
+// minGenericEntropy is the Shannon entropy, in bits per character, a value assigned to a
+// secret-looking name needs to be taken for a secret
+const minGenericEntropy = 3.0
+
+// match is the position of a secret in a line
+type match struct {
+	rule       string
+	start, end int
+}
+
+func findInLine(line string) []match {
+	var found []match
+	for _, rule := range rules {
+		for _, indexes := range rule.regex.FindAllStringSubmatchIndex(line, -1) {
+			start, end := indexes[0], indexes[1]
+			if len(indexes) >= 4 && indexes[2] >= 0 {
+				start, end = indexes[2], indexes[3]
+			}
+			if rule.generic && !looksRandom(line[start:end]) {
+				continue
+			}
+			if overlaps(found, start, end) {
+				continue
+			}
+			found = append(found, match{rule: rule.name, start: start, end: end})
+		}
+	}
+	return found
+}
+
+func overlaps(found []match, start, end int) bool {
+	for _, m := range found {
+		if start < m.end && m.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+func looksRandom(value string) bool {
+	if placeholderRegex.MatchString(value) {
+		return false
+	}
+	return entropy(value) >= minGenericEntropy
+}
+
+// entropy returns the Shannon entropy of the value in bits per character
+func entropy(value string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+	var bits float64
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// Scan returns the secrets of a text, one finding per line and secret. The lines of a
+// private key are reported once, on its BEGIN line.
+func Scan(text string) []Finding {
+	var findings []Finding
+	inKey := false
+	for i, line := range strings.Split(text, "\n") {
+		if inKey {
+			inKey = !privateKeyEndRegex.MatchString(line)
+			continue
+		}
+		if begin := privateKeyBeginRegex.FindStringIndex(line); begin != nil {
+			findings = append(findings, Finding{Line: i + 1, Rule: "private-key"})
+			inKey = !privateKeyEndRegex.MatchString(line[begin[1]:])
+			continue
+		}
+		for _, m := range findInLine(line) {
+			findings = append(findings, Finding{Line: i + 1, Rule: m.rule})
+		}
+	}
+	return findings
+}
+
+// ScanDiff returns the secrets the lines added by a unified diff contain, in new file
+// numbering
+func ScanDiff(diff string) []Finding {
+	newLines := make(map[int]int)
+	newLine := 0
+	inHunk := false
+	for i, line := range strings.Split(diff, "\n") {
+		if matches := hunkHeaderRegex.FindStringSubmatch(line); matches != nil {
+			start, err := strconv.Atoi(matches[1])
+			inHunk = err == nil
+			newLine = start
+			continue
+		}
+		if !inHunk {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			newLines[i+1] = newLine
+			newLine++
+		case strings.HasPrefix(line, " "):
+			newLine++
+		}
+	}
+
+	var findings []Finding
+	for _, finding := range Scan(diff) {
+		if line, added := newLines[finding.Line]; added {
+			findings = append(findings, Finding{Line: line, Rule: finding.Rule})
+		}
+	}
+	return findings
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@\s+-\d+(?:,\d+)?\s+\+(\d+)(?:,\d+)?\s+@@`)
+
+// Redact replaces the secrets of a text with a [REDACTED:rule] marker and returns the number
+// of secrets replaced. Lines are kept, so line numbers and diff markers stay valid: the body
+// of a private key becomes one marker per line.
+func Redact(text string) (string, int) {
+	lines := strings.Split(text, "\n")
+	count := 0
+	inKey := false
+	for i, line := range lines {
+		if inKey {
+			if end := privateKeyEndRegex.FindStringIndex(line); end != nil {
+				inKey = false
+				if marker := diffMarker(line); strings.TrimSpace(line[len(marker):end[0]]) != "" {
+					lines[i] = marker + redacted("private-key") + line[end[0]:]
+				}
+				continue
+			}
+			lines[i] = diffMarker(line) + redacted("private-key")
+			continue
+		}
+
+		if begin := privateKeyBeginRegex.FindStringIndex(line); begin != nil {
+			count++
+			rest := line[begin[1]:]
+			if end := privateKeyEndRegex.FindStringIndex(rest); end != nil {
+				// A key written on one line, e.g. with escaped newlines in a string
+				lines[i] = line[:begin[1]] + redacted("private-key") + rest[end[0]:]
+			} else {
+				inKey = true
+			}
+			continue
+		}
+
+		found := findInLine(line)
+		if len(found) == 0 {
+			continue
+		}
+		count += len(found)
+		lines[i] = redactMatches(line, found)
+	}
+	return strings.Join(lines, "\n"), count
+}
+
+func redactMatches(line string, found []match) string {
+	sort.Slice(found, func(i, j int) bool { return found[i].start < found[j].start })
+
+	var result strings.Builder
+	last := 0
+	for _, m := range found {
+		result.WriteString(line[last:m.start])
+		result.WriteString(redacted(m.rule))
+		last = m.end
+	}
+	result.WriteString(line[last:])
+	return result.String()
+}
+
+func redacted(rule string) string {
+	return "[REDACTED:" + rule + "]"
+}
+
+// diffMarker keeps the +, - or space prefix of a diff line
+func diffMarker(line string) string {
+	if line != "" && strings.ContainsRune("+- ", rune(line[0])) {
+		return line[:1]
+	}
+	return ""
+}
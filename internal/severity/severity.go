@@ -0,0 +1,140 @@
+// Package severity maps the severities reported by the model and the checks onto the
+// configured severity levels.
+package severity
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+// ModelSeverities are the severities the prompts ask the model to use. Every registry must
+// be able to map them.
+var ModelSeverities = []string{"CRITICAL", "WARNING", "MINOR"}
+
+const unknownIcon = "⚪️"
+
+var defaultLevels = []config.SeverityLevel{
+	{Name: "CRITICAL", Icon: "🔴"},
+	{Name: "WARNING", Icon: "🟡"},
+	{Name: "MINOR", Icon: "🔵"},
+}
+
+// Aliases models commonly use instead of the requested severities
+var defaultMapping = map[string]string{
+	"HIGH":   "CRITICAL",
+	"MEDIUM": "WARNING",
+	"LOW":    "MINOR",
+}
+
+// Registry holds the severity levels ordered from most to least severe
+type Registry struct {
+	levels  []config.SeverityLevel
+	mapping map[string]string
+}
+
+// Default returns the CRITICAL/WARNING/MINOR taxonomy
+func Default() *Registry {
+	registry, _ := New(config.SeverityConfig{})
+	return registry
+}
+
+// New builds a registry from the config. Without levels the default taxonomy is used and
+// the configured mapping extends the default aliases.
+func New(cfg config.SeverityConfig) (*Registry, error) {
+	registry := &Registry{mapping: make(map[string]string)}
+
+	if len(cfg.Levels) == 0 {
+		registry.levels = defaultLevels
+		for from, to := range defaultMapping {
+			registry.mapping[from] = to
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, level := range cfg.Levels {
+		name := canonical(level.Name)
+		if name == "" {
+			return nil, fmt.Errorf("severity level without a name")
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate severity level: %s", name)
+		}
+		seen[name] = true
+		registry.levels = append(registry.levels, config.SeverityLevel{Name: name, Icon: level.Icon})
+	}
+
+	for from, to := range cfg.Mapping {
+		target := canonical(to)
+		if registry.index(target) == -1 {
+			return nil, fmt.Errorf("severity mapping %s -> %s targets an unknown level", from, to)
+		}
+		registry.mapping[canonical(from)] = target
+	}
+
+	for _, severity := range ModelSeverities {
+		if registry.index(registry.Normalize(severity)) == -1 {
+			return nil, fmt.Errorf("severity %s reported by the model is neither a level nor mapped to one", severity)
+		}
+	}
+
+	return registry, nil
+}
+
+// Normalize returns the configured level for a reported severity. Unknown severities are
+// returned upper-cased.
+func (r *Registry) Normalize(severity string) string {
+	name := canonical(severity)
+	if mapped, ok := r.mapping[name]; ok {
+		return mapped
+	}
+	return name
+}
+
+// NormalizeIssues rewrites the severity of every issue in place
+func (r *Registry) NormalizeIssues(issues []types.Issue) {
+	for i := range issues {
+		issues[i].Severity = r.Normalize(issues[i].Severity)
+	}
+}
+
+// Rank orders severities, higher is more severe. Unknown severities rank 0.
+func (r *Registry) Rank(severity string) int {
+	index := r.index(r.Normalize(severity))
+	if index == -1 {
+		return 0
+	}
+	return len(r.levels) - index
+}
+
+func (r *Registry) Icon(severity string) string {
+	index := r.index(r.Normalize(severity))
+	if index == -1 || r.levels[index].Icon == "" {
+		return unknownIcon
+	}
+	return r.levels[index].Icon
+}
+
+// Levels returns the level names from most to least severe
+func (r *Registry) Levels() []string {
+	names := make([]string, len(r.levels))
+	for i, level := range r.levels {
+		names[i] = level.Name
+	}
+	return names
+}
+
+func (r *Registry) index(name string) int {
+	for i, level := range r.levels {
+		if level.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func canonical(severity string) string {
+	return strings.ToUpper(strings.TrimSpace(severity))
+}
@@ -0,0 +1,104 @@
+package severity
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	registry := Default()
+
+	tests := []struct {
+		severity   string
+		normalized string
+		rank       int
+	}{
+		{"CRITICAL", "CRITICAL", 3},
+		{"high", "CRITICAL", 3},
+		{" warning ", "WARNING", 2},
+		{"MEDIUM", "WARNING", 2},
+		{"Minor", "MINOR", 1},
+		{"LOW", "MINOR", 1},
+		{"INFO", "INFO", 0},
+	}
+
+	for _, tt := range tests {
+		if got := registry.Normalize(tt.severity); got != tt.normalized {
+			t.Errorf("Normalize(%q) = %q, expected %q", tt.severity, got, tt.normalized)
+		}
+		if got := registry.Rank(tt.severity); got != tt.rank {
+			t.Errorf("Rank(%q) = %d, expected %d", tt.severity, got, tt.rank)
+		}
+	}
+
+	if registry.Icon("CRITICAL") != "🔴" || registry.Icon("INFO") != unknownIcon {
+		t.Errorf("Unexpected icons: %s, %s", registry.Icon("CRITICAL"), registry.Icon("INFO"))
+	}
+}
+
+func TestCustomRegistry(t *testing.T) {
+	registry, err := New(config.SeverityConfig{
+		Levels: []config.SeverityLevel{
+			{Name: "BLOCKER", Icon: "⛔"},
+			{Name: "major"},
+			{Name: "INFO", Icon: "💬"},
+		},
+		Mapping: map[string]string{
+			"CRITICAL": "BLOCKER",
+			"WARNING":  "MAJOR",
+			"MINOR":    "INFO",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !slices.Equal(registry.Levels(), []string{"BLOCKER", "MAJOR", "INFO"}) {
+		t.Errorf("Unexpected levels: %v", registry.Levels())
+	}
+	if registry.Normalize("critical") != "BLOCKER" || registry.Rank("CRITICAL") != 3 {
+		t.Errorf("Expected CRITICAL to map to the top level")
+	}
+	if registry.Normalize("HIGH") != "HIGH" {
+		t.Errorf("Expected default aliases to be dropped with custom levels")
+	}
+	if registry.Icon("WARNING") != unknownIcon {
+		t.Errorf("Expected the fallback icon for a level without one")
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.SeverityConfig
+		contains string
+	}{
+		{
+			name:     "unmapped model severity",
+			cfg:      config.SeverityConfig{Levels: []config.SeverityLevel{{Name: "BLOCKER"}}},
+			contains: "CRITICAL reported by the model",
+		},
+		{
+			name:     "unknown mapping target",
+			cfg:      config.SeverityConfig{Mapping: map[string]string{"HIGH": "BLOCKER"}},
+			contains: "unknown level",
+		},
+		{
+			name:     "duplicate level",
+			cfg:      config.SeverityConfig{Levels: []config.SeverityLevel{{Name: "INFO"}, {Name: "info"}}},
+			contains: "duplicate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("Expected error containing %q, got %v", tt.contains, err)
+			}
+		})
+	}
+}
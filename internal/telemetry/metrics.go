@@ -0,0 +1,93 @@
+package telemetry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The metrics of a review, the gen_ai ones following the OpenTelemetry semantic conventions
+const (
+	MetricLLMDuration   = "gen_ai.client.operation.duration"
+	MetricTokenUsage    = "gen_ai.client.token.usage"
+	MetricStageDuration = "diffpector.stage.duration"
+	MetricFilesReviewed = "diffpector.files.reviewed"
+	MetricIssues        = "diffpector.issues"
+)
+
+type instrument struct {
+	unit        string
+	description string
+	// bounds are the bucket boundaries of a histogram, counters have none
+	bounds []float64
+}
+
+var durationBounds = []float64{0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92}
+
+var instruments = map[string]instrument{
+	MetricLLMDuration: {unit: "s", description: "Duration of the calls to the model", bounds: durationBounds},
+	MetricTokenUsage: {unit: "{token}", description: "Tokens used by the calls to the model",
+		bounds: []float64{1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576}},
+	MetricStageDuration: {unit: "s", description: "Duration of the stages of the review pipeline", bounds: durationBounds},
+	MetricFilesReviewed: {unit: "{file}", description: "Files reviewed"},
+	MetricIssues:        {unit: "{issue}", description: "Issues found"},
+}
+
+// metric aggregates the measurements of an instrument, per set of attributes
+type metric struct {
+	instrument
+	points map[string]*point
+}
+
+type point struct {
+	attrs  []Attribute
+	count  int64
+	sum    float64
+	counts []int64
+}
+
+// Add increments a counter
+func (t *Telemetry) Add(name string, value int64, attrs ...Attribute) {
+	t.measure(name, float64(value), attrs)
+}
+
+// Record adds a measurement to a histogram
+func (t *Telemetry) Record(name string, value float64, attrs ...Attribute) {
+	t.measure(name, value, attrs)
+}
+
+func (t *Telemetry) measure(name string, value float64, attrs []Attribute) {
+	if t == nil {
+		return
+	}
+	inst, ok := instruments[name]
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m := t.metrics[name]
+	if m == nil {
+		m = &metric{instrument: inst, points: make(map[string]*point)}
+		t.metrics[name] = m
+	}
+	key := attributesKey(attrs)
+	p := m.points[key]
+	if p == nil {
+		p = &point{attrs: attrs, counts: make([]int64, len(inst.bounds)+1)}
+		m.points[key] = p
+	}
+	p.count++
+	p.sum += value
+	p.counts[sort.SearchFloat64s(inst.bounds, value)]++
+}
+
+func attributesKey(attrs []Attribute) string {
+	parts := make([]string, len(attrs))
+	for i, attr := range attrs {
+		parts[i] = fmt.Sprintf("%s=%v", attr.Key, attr.Value)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
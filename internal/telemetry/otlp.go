@@ -0,0 +1,208 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// The OTLP/HTTP JSON encoding, where 64-bit integers are strings and IDs are hex
+
+const scopeName = "github.com/agusespa/diffpector"
+
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// statusError is the OTLP status code of a failed span
+const statusError = 2
+
+// temporalityCumulative is the OTLP aggregation temporality of the metrics, each export
+// repeats the totals since the start of the run
+const temporalityCumulative = 2
+
+func (t *Telemetry) exportSpans() error {
+	if t.config.TracesURL == "" {
+		return nil
+	}
+	t.mu.Lock()
+	ended := t.ended
+	t.ended = nil
+	spans := make([]otlpSpan, 0, len(ended))
+	for _, span := range ended {
+		encoded := otlpSpan{
+			TraceID:           span.traceID,
+			SpanID:            span.spanID,
+			ParentSpanID:      span.parentID,
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: nanos(span.start),
+			EndTimeUnixNano:   nanos(span.end),
+			Attributes:        encodeAttributes(span.attrs),
+		}
+		if span.err != "" {
+			encoded.Status = &otlpStatus{Code: statusError, Message: span.err}
+		}
+		spans = append(spans, encoded)
+	}
+	t.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+	return t.post(t.config.TracesURL, map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource":   t.resource(),
+			"scopeSpans": []any{map[string]any{"scope": map[string]any{"name": scopeName}, "spans": spans}},
+		}},
+	})
+}
+
+func (t *Telemetry) exportMetrics() error {
+	if t.config.MetricsURL == "" {
+		return nil
+	}
+	t.mu.Lock()
+	now := nanos(time.Now())
+	names := make([]string, 0, len(t.metrics))
+	for name := range t.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var metrics []any
+	for _, name := range names {
+		m := t.metrics[name]
+		var points []any
+		for _, p := range m.points {
+			encoded := map[string]any{
+				"attributes":        encodeAttributes(p.attrs),
+				"startTimeUnixNano": nanos(t.started),
+				"timeUnixNano":      now,
+			}
+			if m.bounds == nil {
+				encoded["asInt"] = strconv.FormatInt(int64(p.sum), 10)
+			} else {
+				counts := make([]string, len(p.counts))
+				for i, count := range p.counts {
+					counts[i] = strconv.FormatInt(count, 10)
+				}
+				encoded["count"] = strconv.FormatInt(p.count, 10)
+				encoded["sum"] = p.sum
+				encoded["bucketCounts"] = counts
+				encoded["explicitBounds"] = m.bounds
+			}
+			points = append(points, encoded)
+		}
+
+		encoded := map[string]any{"name": name, "unit": m.unit, "description": m.description}
+		if m.bounds == nil {
+			encoded["sum"] = map[string]any{"dataPoints": points, "aggregationTemporality": temporalityCumulative, "isMonotonic": true}
+		} else {
+			encoded["histogram"] = map[string]any{"dataPoints": points, "aggregationTemporality": temporalityCumulative}
+		}
+		metrics = append(metrics, encoded)
+	}
+	t.mu.Unlock()
+
+	if len(metrics) == 0 {
+		return nil
+	}
+	return t.post(t.config.MetricsURL, map[string]any{
+		"resourceMetrics": []any{map[string]any{
+			"resource":     t.resource(),
+			"scopeMetrics": []any{map[string]any{"scope": map[string]any{"name": scopeName}, "metrics": metrics}},
+		}},
+	})
+}
+
+func (t *Telemetry) resource() map[string]any {
+	attrs := make([]Attribute, 0, len(t.config.Resource))
+	for key, value := range t.config.Resource {
+		attrs = append(attrs, String(key, value))
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	return map[string]any{"attributes": encodeAttributes(attrs)}
+}
+
+func (t *Telemetry) post(url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telemetry: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create telemetry request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export telemetry: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Warn("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		details, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("telemetry export to %s failed with status %d: %s", url, resp.StatusCode, details)
+	}
+	return nil
+}
+
+func encodeAttributes(attrs []Attribute) []otlpAttribute {
+	encoded := make([]otlpAttribute, 0, len(attrs))
+	for _, attr := range attrs {
+		var value map[string]any
+		switch v := attr.Value.(type) {
+		case string:
+			value = map[string]any{"stringValue": v}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		encoded = append(encoded, otlpAttribute{Key: attr.Key, Value: value})
+	}
+	return encoded
+}
+
+func nanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
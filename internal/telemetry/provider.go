@@ -0,0 +1,76 @@
+package telemetry
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/llm"
+)
+
+// WrapProvider returns a provider recording a client span, the duration and the token usage
+// of every call of the provider. The system names the provider, e.g. ollama.
+func (t *Telemetry) WrapProvider(provider llm.Provider, system string) llm.Provider {
+	if t == nil {
+		return provider
+	}
+	return &tracedProvider{Provider: provider, telemetry: t, system: system}
+}
+
+type tracedProvider struct {
+	llm.Provider
+	telemetry *Telemetry
+	system    string
+}
+
+func (p *tracedProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	span, start := p.start("text_completion")
+	answer, err := p.Provider.Generate(ctx, prompt)
+	p.end(span, start, "text_completion", nil, err)
+	return answer, err
+}
+
+func (p *tracedProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	span, start := p.start("chat")
+	response, err := p.Provider.ChatWithTools(ctx, messages, tools)
+	p.end(span, start, "chat", response, err)
+	return response, err
+}
+
+func (p *tracedProvider) ChatWithSchema(ctx context.Context, messages []llm.Message, tools []llm.Tool, schema *llm.ResponseSchema) (*llm.ChatResponse, error) {
+	span, start := p.start("chat")
+	response, err := p.Provider.ChatWithSchema(ctx, messages, tools, schema)
+	p.end(span, start, "chat", response, err)
+	return response, err
+}
+
+func (p *tracedProvider) start(operation string) (*Span, time.Time) {
+	return p.telemetry.startSpan(operation+" "+p.GetModel(), kindClient, p.attributes(operation)), time.Now()
+}
+
+func (p *tracedProvider) attributes(operation string) []Attribute {
+	return []Attribute{
+		String("gen_ai.system", p.system),
+		String("gen_ai.operation.name", operation),
+		String("gen_ai.request.model", p.GetModel()),
+	}
+}
+
+func (p *tracedProvider) end(span *Span, start time.Time, operation string, response *llm.ChatResponse, err error) {
+	attrs := p.attributes(operation)
+	if err != nil {
+		span.Fail(err)
+		attrs = append(attrs, String("error.type", "error"))
+	}
+	p.telemetry.Record(MetricLLMDuration, time.Since(start).Seconds(), attrs...)
+
+	if response != nil && err == nil {
+		usage := response.Usage
+		if usage.PromptTokens > 0 || usage.CompletionTokens > 0 {
+			span.SetAttributes(Int("gen_ai.usage.input_tokens", usage.PromptTokens), Int("gen_ai.usage.output_tokens", usage.CompletionTokens))
+			p.telemetry.Record(MetricTokenUsage, float64(usage.PromptTokens), slices.Concat(attrs, []Attribute{String("gen_ai.token.type", "input")})...)
+			p.telemetry.Record(MetricTokenUsage, float64(usage.CompletionTokens), slices.Concat(attrs, []Attribute{String("gen_ai.token.type", "output")})...)
+		}
+	}
+	span.End()
+}
@@ -0,0 +1,260 @@
+// Package telemetry records the spans and metrics of a review and exports them to an
+// OpenTelemetry collector with OTLP over HTTP, configured with the standard OTEL_ environment
+// variables. A nil *Telemetry records nothing, so callers need no checks of their own.
+package telemetry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBufferedSpans is the number of ended spans that triggers an export before the end of
+// the run, so long running modes like watch and serve do not grow their buffer forever
+const maxBufferedSpans = 512
+
+// Config says where the telemetry goes
+type Config struct {
+	// TracesURL and MetricsURL are the OTLP/HTTP endpoints, a signal is not exported when
+	// its URL is empty
+	TracesURL  string
+	MetricsURL string
+	Headers    map[string]string
+	// Resource describes the process, service.name included
+	Resource map[string]string
+	// Parent is the W3C traceparent the root spans continue, such as the one of a CI job
+	Parent string
+}
+
+// ConfigFromEnv reads the standard OTEL_ variables. It returns false when no OTLP endpoint
+// is configured or OTEL_SDK_DISABLED is set, only the http/json protocol is supported.
+func ConfigFromEnv() (Config, bool, error) {
+	if disabled, _ := strconv.ParseBool(os.Getenv("OTEL_SDK_DISABLED")); disabled {
+		return Config{}, false, nil
+	}
+
+	base := strings.TrimRight(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/")
+	config := Config{
+		TracesURL:  signalURL("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", base, "/v1/traces"),
+		MetricsURL: signalURL("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", base, "/v1/metrics"),
+		Headers:    parsePairs(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
+		Resource:   parsePairs(os.Getenv("OTEL_RESOURCE_ATTRIBUTES")),
+		Parent:     os.Getenv("TRACEPARENT"),
+	}
+	if strings.EqualFold(os.Getenv("OTEL_TRACES_EXPORTER"), "none") {
+		config.TracesURL = ""
+	}
+	if strings.EqualFold(os.Getenv("OTEL_METRICS_EXPORTER"), "none") {
+		config.MetricsURL = ""
+	}
+	if config.TracesURL == "" && config.MetricsURL == "" {
+		return Config{}, false, nil
+	}
+
+	if protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" && protocol != "http/json" {
+		return Config{}, false, fmt.Errorf("unsupported OTEL_EXPORTER_OTLP_PROTOCOL %q, only http/json is supported", protocol)
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		config.Resource["service.name"] = name
+	}
+	if config.Resource["service.name"] == "" {
+		config.Resource["service.name"] = "diffpector"
+	}
+	return config, true, nil
+}
+
+func signalURL(variable, base, path string) string {
+	if endpoint := os.Getenv(variable); endpoint != "" {
+		return endpoint
+	}
+	if base == "" {
+		return ""
+	}
+	return base + path
+}
+
+// parsePairs reads the key=value,key=value lists of the OTEL_ variables, whose values are
+// URL encoded
+func parsePairs(value string) map[string]string {
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		if decoded, err := url.QueryUnescape(strings.TrimSpace(val)); err == nil {
+			val = decoded
+		}
+		pairs[strings.TrimSpace(key)] = val
+	}
+	return pairs
+}
+
+// Telemetry buffers the spans and aggregates the metrics of a run until they are exported
+type Telemetry struct {
+	config  Config
+	client  *http.Client
+	started time.Time
+
+	mu sync.Mutex
+	// active are the spans started and not ended yet, the last one is the parent of the
+	// next span
+	active  []*Span
+	ended   []*Span
+	metrics map[string]*metric
+}
+
+func New(config Config) *Telemetry {
+	return &Telemetry{
+		config:  config,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		started: time.Now(),
+		metrics: make(map[string]*metric),
+	}
+}
+
+// FromEnv returns the telemetry configured by the environment, nil when it is not
+func FromEnv() (*Telemetry, error) {
+	config, ok, err := ConfigFromEnv()
+	if err != nil || !ok {
+		return nil, err
+	}
+	return New(config), nil
+}
+
+// Attribute is a key and a string, bool, int, int64 or float64 value
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: value} }
+
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// Span is an operation of the pipeline, timed from Start to End
+type Span struct {
+	telemetry *Telemetry
+	traceID   string
+	spanID    string
+	parentID  string
+	name      string
+	kind      int
+	start     time.Time
+	end       time.Time
+	attrs     []Attribute
+	err       string
+}
+
+const (
+	kindInternal = 1
+	kindClient   = 3
+)
+
+// Start begins a span, the child of the innermost span still running
+func (t *Telemetry) Start(name string, attrs ...Attribute) *Span {
+	return t.startSpan(name, kindInternal, attrs)
+}
+
+func (t *Telemetry) startSpan(name string, kind int, attrs []Attribute) *Span {
+	if t == nil {
+		return nil
+	}
+	span := &Span{telemetry: t, spanID: randomHex(8), name: name, kind: kind, start: time.Now(), attrs: attrs}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.active) > 0 {
+		parent := t.active[len(t.active)-1]
+		span.traceID, span.parentID = parent.traceID, parent.spanID
+	} else if traceID, parentID, ok := parseTraceparent(t.config.Parent); ok {
+		span.traceID, span.parentID = traceID, parentID
+	} else {
+		span.traceID = randomHex(16)
+	}
+	t.active = append(t.active, span)
+	return span
+}
+
+// SetAttributes adds attributes known once the operation is done
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.telemetry.mu.Lock()
+	defer s.telemetry.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// Fail marks the span as failed with the error, a nil error changes nothing
+func (s *Span) Fail(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.telemetry.mu.Lock()
+	defer s.telemetry.mu.Unlock()
+	s.err = err.Error()
+}
+
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	t := s.telemetry
+	t.mu.Lock()
+	s.end = time.Now()
+	for i, active := range t.active {
+		if active == s {
+			t.active = append(t.active[:i], t.active[i+1:]...)
+			break
+		}
+	}
+	t.ended = append(t.ended, s)
+	full := len(t.ended) >= maxBufferedSpans
+	t.mu.Unlock()
+
+	if full {
+		if err := t.exportSpans(); err != nil {
+			slog.Warn("failed to export spans", "error", err)
+		}
+	}
+}
+
+// Flush exports the spans ended so far and the metrics, which are cumulative
+func (t *Telemetry) Flush() error {
+	if t == nil {
+		return nil
+	}
+	if err := t.exportSpans(); err != nil {
+		return err
+	}
+	return t.exportMetrics()
+}
+
+// parseTraceparent reads the trace and span IDs of a W3C traceparent header
+func parseTraceparent(value string) (string, string, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	if _, err := hex.DecodeString(parts[1] + parts[2]); err != nil {
+		return "", "", false
+	}
+	return strings.ToLower(parts[1]), strings.ToLower(parts[2]), true
+}
+
+func randomHex(size int) string {
+	id := make([]byte, size)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,304 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/llm"
+)
+
+// collector records the OTLP requests it receives, by path
+type collector struct {
+	mu       sync.Mutex
+	requests map[string][]map[string]any
+	headers  http.Header
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	c := &collector{requests: make(map[string][]map[string]any)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("Invalid OTLP JSON: %v", err)
+		}
+		c.mu.Lock()
+		c.requests[r.URL.Path] = append(c.requests[r.URL.Path], payload)
+		c.headers = r.Header.Clone()
+		c.mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+type exportedSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	Attributes   []struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	} `json:"attributes"`
+	Status *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+func (s exportedSpan) attribute(key string) any {
+	for _, attr := range s.Attributes {
+		if attr.Key == key {
+			for _, value := range attr.Value {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+func spansOf(t *testing.T, payload map[string]any) []exportedSpan {
+	t.Helper()
+	var decoded struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []exportedSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	encoded, _ := json.Marshal(payload)
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	var spans []exportedSpan
+	for _, resource := range decoded.ResourceSpans {
+		for _, scope := range resource.ScopeSpans {
+			spans = append(spans, scope.Spans...)
+		}
+	}
+	return spans
+}
+
+type scriptedProvider struct {
+	llm.Provider
+	err error
+}
+
+func (p *scriptedProvider) GetModel() string { return "gpt-4o-mini" }
+
+func (p *scriptedProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &llm.ChatResponse{Content: "[]", Usage: llm.Usage{PromptTokens: 1200, CompletionTokens: 40}}, nil
+}
+
+func TestTelemetry_Spans(t *testing.T) {
+	c, server := newCollector(t)
+	tel := New(Config{TracesURL: server.URL + "/v1/traces", Resource: map[string]string{"service.name": "diffpector"}})
+
+	review := tel.Start("review", Int("files", 1))
+	file := tel.Start("review file", String("file", "auth/login.go"))
+	provider := tel.WrapProvider(&scriptedProvider{}, "openai")
+	if _, err := provider.ChatWithTools(context.Background(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	file.Fail(errors.New("failed to parse review"))
+	file.End()
+	review.End()
+
+	if err := tel.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.requests["/v1/traces"]) != 1 {
+		t.Fatalf("Expected an export of the spans, got %v", c.requests)
+	}
+	spans := spansOf(t, c.requests["/v1/traces"][0])
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %+v", spans)
+	}
+
+	byName := make(map[string]exportedSpan)
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	root, fileSpan, call := byName["review"], byName["review file"], byName["chat gpt-4o-mini"]
+	if root.ParentSpanID != "" || len(root.TraceID) != 32 {
+		t.Errorf("Expected a root span with a new trace, got %+v", root)
+	}
+	if fileSpan.ParentSpanID != root.SpanID || call.ParentSpanID != fileSpan.SpanID || call.TraceID != root.TraceID {
+		t.Errorf("Expected the spans nested in the order they were started: %+v", spans)
+	}
+	if fileSpan.Status == nil || fileSpan.Status.Code != statusError || fileSpan.Status.Message != "failed to parse review" {
+		t.Errorf("Expected the file span failed, got %+v", fileSpan.Status)
+	}
+	if call.Kind != kindClient || call.attribute("gen_ai.usage.input_tokens") != "1200" || call.attribute("gen_ai.system") != "openai" {
+		t.Errorf("Unexpected model call span: %+v", call)
+	}
+
+	// Exported spans are not sent again
+	if err := tel.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.requests["/v1/traces"]) != 1 {
+		t.Errorf("Expected no second export, got %d", len(c.requests["/v1/traces"]))
+	}
+}
+
+func TestTelemetry_Traceparent(t *testing.T) {
+	c, server := newCollector(t)
+	tel := New(Config{TracesURL: server.URL, Parent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+	tel.Start("diffpector").End()
+	if err := tel.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := spansOf(t, c.requests["/"][0])
+	if spans[0].TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spans[0].ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("Expected the run to continue the trace of the traceparent, got %+v", spans[0])
+	}
+}
+
+func TestTelemetry_Metrics(t *testing.T) {
+	c, server := newCollector(t)
+	tel := New(Config{MetricsURL: server.URL + "/v1/metrics"})
+
+	tel.Add(MetricIssues, 1, String("severity", "CRITICAL"))
+	tel.Add(MetricIssues, 1, String("severity", "CRITICAL"))
+	tel.Record(MetricStageDuration, 0.03, String("stage", "parse"))
+	tel.Record(MetricStageDuration, 12, String("stage", "parse"))
+	tel.Add("unknown.metric", 1)
+	if err := tel.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		ResourceMetrics []struct {
+			ScopeMetrics []struct {
+				Metrics []struct {
+					Name string `json:"name"`
+					Sum  *struct {
+						DataPoints []struct {
+							AsInt string `json:"asInt"`
+						} `json:"dataPoints"`
+						IsMonotonic bool `json:"isMonotonic"`
+					} `json:"sum"`
+					Histogram *struct {
+						DataPoints []struct {
+							Count        string   `json:"count"`
+							Sum          float64  `json:"sum"`
+							BucketCounts []string `json:"bucketCounts"`
+						} `json:"dataPoints"`
+					} `json:"histogram"`
+				} `json:"metrics"`
+			} `json:"scopeMetrics"`
+		} `json:"resourceMetrics"`
+	}
+	encoded, _ := json.Marshal(c.requests["/v1/metrics"][0])
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	metrics := decoded.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 2 {
+		t.Fatalf("Expected the two known metrics, got %+v", metrics)
+	}
+
+	issues, stages := metrics[0], metrics[1]
+	if issues.Name != MetricIssues || issues.Sum == nil || issues.Sum.DataPoints[0].AsInt != "2" || !issues.Sum.IsMonotonic {
+		t.Errorf("Unexpected issue counter: %+v", issues)
+	}
+	if stages.Name != MetricStageDuration || stages.Histogram == nil {
+		t.Fatalf("Unexpected stage histogram: %+v", stages)
+	}
+	point := stages.Histogram.DataPoints[0]
+	if point.Count != "2" || point.Sum != 12.03 || point.BucketCounts[2] != "1" || point.BucketCounts[11] != "1" {
+		t.Errorf("Unexpected histogram point: %+v", point)
+	}
+}
+
+func TestTelemetry_ExportFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	tel := New(Config{TracesURL: server.URL})
+	tel.Start("review").End()
+	if err := tel.Flush(); err == nil {
+		t.Error("Expected the rejected export to fail")
+	}
+}
+
+func TestTelemetry_Nil(t *testing.T) {
+	var tel *Telemetry
+	span := tel.Start("review")
+	span.SetAttributes(Int("files", 1))
+	span.Fail(errors.New("failed"))
+	span.End()
+	tel.Add(MetricIssues, 1)
+	if err := tel.Flush(); err != nil {
+		t.Error(err)
+	}
+	provider := &scriptedProvider{}
+	if tel.WrapProvider(provider, "openai") != llm.Provider(provider) {
+		t.Error("Expected the provider unwrapped without telemetry")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+		t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+		t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "")
+		if _, ok, err := ConfigFromEnv(); ok || err != nil {
+			t.Errorf("Expected no telemetry, got %v, %v", ok, err)
+		}
+	})
+
+	t.Run("endpoint", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://otel.example.com:4318/")
+		t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+		t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "https://metrics.example.com/v1/metrics")
+		t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer%20abc,x-team=platform")
+		t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=ci")
+		t.Setenv("OTEL_SERVICE_NAME", "")
+		t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "")
+
+		config, ok, err := ConfigFromEnv()
+		if !ok || err != nil {
+			t.Fatalf("Expected telemetry, got %v, %v", ok, err)
+		}
+		if config.TracesURL != "https://otel.example.com:4318/v1/traces" || config.MetricsURL != "https://metrics.example.com/v1/metrics" {
+			t.Errorf("Unexpected endpoints: %+v", config)
+		}
+		if config.Headers["Authorization"] != "Bearer abc" || config.Headers["x-team"] != "platform" {
+			t.Errorf("Unexpected headers: %v", config.Headers)
+		}
+		if config.Resource["service.name"] != "diffpector" || config.Resource["deployment.environment"] != "ci" {
+			t.Errorf("Unexpected resource: %v", config.Resource)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://otel.example.com:4318")
+		t.Setenv("OTEL_SDK_DISABLED", "true")
+		if _, ok, _ := ConfigFromEnv(); ok {
+			t.Error("Expected OTEL_SDK_DISABLED to disable the telemetry")
+		}
+	})
+
+	t.Run("unsupported protocol", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://otel.example.com:4317")
+		t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc")
+		if _, _, err := ConfigFromEnv(); err == nil {
+			t.Error("Expected an error for the grpc protocol")
+		}
+	})
+}
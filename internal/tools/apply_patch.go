@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// ApplyPatchTool applies a unified diff to the working tree of a git repository with
+// git apply, optionally staging the patched files
+type ApplyPatchTool struct {
+	dir string
+}
+
+func NewApplyPatchTool(dir string) *ApplyPatchTool {
+	return &ApplyPatchTool{dir: dir}
+}
+
+func (t *ApplyPatchTool) Name() string {
+	return string(ToolNameApplyPatch)
+}
+
+func (t *ApplyPatchTool) Description() string {
+	return "Apply a unified diff to the working tree"
+}
+
+func (t *ApplyPatchTool) Schema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"patch": map[string]any{
+				"type":        "string",
+				"description": "Unified diff with --- a/ and +++ b/ file headers",
+			},
+			"stage": map[string]any{
+				"type":        "boolean",
+				"description": "Stage the patched files after applying the patch",
+			},
+		},
+		"required": []string{"patch"},
+	}
+}
+
+// Execute applies the patch and returns the repository-relative paths of the patched files.
+// Nothing is changed when the patch does not apply cleanly.
+func (t *ApplyPatchTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	patch, ok := args["patch"].(string)
+	if !ok || patch == "" {
+		return nil, fmt.Errorf("patch parameter required")
+	}
+
+	files := patchedFiles(patch)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("patch has no +++ b/ file header")
+	}
+
+	// Suggested fixes do not always carry context lines, their line numbers place them instead
+	if err := t.git(ctx, patch, "apply", "--unidiff-zero", "--whitespace=nowarn", "-"); err != nil {
+		return nil, fmt.Errorf("failed to apply patch: %w", err)
+	}
+
+	if stage, _ := args["stage"].(bool); stage {
+		if err := t.git(ctx, "", append([]string{"add", "--"}, files...)...); err != nil {
+			return nil, fmt.Errorf("failed to stage patched files: %w", err)
+		}
+	}
+
+	return files, nil
+}
+
+func (t *ApplyPatchTool) git(ctx context.Context, stdin string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = t.dir
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if details := strings.TrimSpace(stderr.String()); details != "" {
+			return fmt.Errorf("%w: %s", err, details)
+		}
+		return err
+	}
+	return nil
+}
+
+// patchedFiles lists the files a patch changes, from its +++ headers
+func patchedFiles(patch string) []string {
+	var files []string
+	for line := range strings.SplitSeq(patch, "\n") {
+		name, ok := strings.CutPrefix(line, "+++ ")
+		if !ok {
+			continue
+		}
+		name = stripGitPrefix(strings.TrimSpace(name))
+		if name != "/dev/null" && !slices.Contains(files, name) {
+			files = append(files, name)
+		}
+	}
+	return files
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestApplyPatchTool_AppliesAndStages(t *testing.T) {
+	tempDir, cleanup := setupGitRepo(t)
+	defer cleanup()
+	createAndCommitFile(t, tempDir, "main.go", "package main\n\nfunc main() {\n\tx := 1\n}\n")
+
+	tool := NewApplyPatchTool(tempDir)
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"patch": "--- a/main.go\n+++ b/main.go\n@@ -4,1 +4,1 @@\n-\tx := 1\n+\tx := 2\n",
+		"stage": true,
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if files, _ := result.([]string); !slices.Equal(files, []string{"main.go"}) {
+		t.Errorf("Expected the patched files, got %v", result)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "main.go"))
+	if err != nil {
+		t.Fatalf("Failed to read patched file: %v", err)
+	}
+	if string(content) != "package main\n\nfunc main() {\n\tx := 2\n}\n" {
+		t.Errorf("Unexpected patched content:\n%s", content)
+	}
+
+	cmd := exec.Command("git", "diff", "--staged", "--name-only")
+	cmd.Dir = tempDir
+	staged, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git diff failed: %v", err)
+	}
+	if string(staged) != "main.go\n" {
+		t.Errorf("Expected main.go to be staged, got %q", staged)
+	}
+}
+
+func TestApplyPatchTool_RejectsStalePatch(t *testing.T) {
+	tempDir, cleanup := setupGitRepo(t)
+	defer cleanup()
+	createAndCommitFile(t, tempDir, "main.go", "package main\n")
+
+	tool := NewApplyPatchTool(tempDir)
+	if _, err := tool.Execute(context.Background(), map[string]any{"patch": "--- a/main.go\n+++ b/main.go\n@@ -4,1 +4,1 @@\n-\tx := 1\n+\tx := 2\n"}); err == nil {
+		t.Error("Expected an error for a patch that does not apply")
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"patch": "@@ -1,1 +1,1 @@\n-a\n+b\n"}); err == nil {
+		t.Error("Expected an error for a patch without file headers")
+	}
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "main.go"))
+	if err != nil || string(content) != "package main\n" {
+		t.Errorf("Expected the file to be left untouched, got %q (%v)", content, err)
+	}
+}
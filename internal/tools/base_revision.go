@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/vcs"
+)
+
+// baseRevision is the revision the usages of the changed symbols are searched in: the merge
+// base of HEAD with the target branch, the code as it is before the branch under review
+type baseRevision struct {
+	target string
+	commit string
+	// root is the absolute project root, the files of the revision are relative to it
+	root   string
+	reader *vcs.RevisionReader
+}
+
+// SetBase makes the context search the usages of the changed symbols, and read the files
+// they are in, at the merge base of HEAD with target, so the context shows the code the
+// branch will be merged into rather than the working tree, the diff showing the change
+// itself. It requires git, Close releases the reader.
+func (t *SymbolContextTool) SetBase(target string) error {
+	git, ok := t.gatherer.repo.(*vcs.Git)
+	if !ok {
+		return fmt.Errorf("searching a base revision requires a git repository")
+	}
+	commit, err := git.MergeBase(target, "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to find the merge base of HEAD with %s: %w", target, err)
+	}
+	root, err := filepath.Abs(t.projectRoot)
+	if err != nil {
+		return err
+	}
+	reader, err := git.NewRevisionReader(commit)
+	if err != nil {
+		return err
+	}
+
+	t.gatherer.base = &baseRevision{target: target, commit: commit, root: root, reader: reader}
+	return nil
+}
+
+// Close stops the reader of the base revision, if any
+func (t *SymbolContextTool) Close() error {
+	if t.gatherer.base == nil {
+		return nil
+	}
+	return t.gatherer.base.reader.Close()
+}
+
+// readFile reads a candidate file of the search, at the base revision when there is one
+func (g *SymbolContextGatherer) readFile(filePath string) ([]byte, error) {
+	if g.base == nil {
+		return os.ReadFile(filePath)
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := platform.RelPath(g.base.root, absPath)
+	if err != nil || platform.IsOutside(rel) {
+		// The workspace modules outside of the repository have no base
+		return os.ReadFile(filePath)
+	}
+	return g.base.reader.ReadFile(rel)
+}
+
+// shortCommit abbreviates a commit hash for the context
+func shortCommit(commit string) string {
+	return commit[:min(len(commit), 12)]
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestSymbolContextTool_SetBase(t *testing.T) {
+	root := t.TempDir()
+	runGitCmd(t, root, "git", "init")
+	runGitCmd(t, root, "git", "config", "user.email", "test@example.com")
+	runGitCmd(t, root, "git", "config", "user.name", "Test User")
+	writeGitFile(t, root, "total.go", "package main\n\nfunc Total(a, b int) int {\n\treturn a + b\n}\n")
+	writeGitFile(t, root, "invoice.go", "package main\n\nfunc Invoice() int {\n\treturn Total(1, 2)\n}\n")
+	runGitCmd(t, root, "git", "add", ".")
+	runGitCmd(t, root, "git", "commit", "-m", "Initial commit")
+	runGitCmd(t, root, "git", "branch", "-M", "main")
+
+	// The branch drops the usage in invoice.go and adds one in a new file
+	runGitCmd(t, root, "git", "checkout", "-b", "feature")
+	writeGitFile(t, root, "invoice.go", "package main\n\nfunc Invoice() int {\n\treturn 3\n}\n")
+	writeGitFile(t, root, "refund.go", "package main\n\nfunc Refund() int {\n\treturn -Total(1, 2)\n}\n")
+	runGitCmd(t, root, "git", "add", ".")
+	runGitCmd(t, root, "git", "commit", "-m", "Feature")
+
+	tool := NewSymbolContextTool(root, NewParserRegistry())
+	if err := tool.SetBase("main"); err != nil {
+		t.Fatalf("SetBase failed: %v", err)
+	}
+	defer tool.Close()
+
+	symbols := []types.SymbolUsage{{Symbol: types.Symbol{Name: "Total", FilePath: filepath.Join(root, "total.go")}}}
+	if err := tool.gatherer.GatherSymbolContext(symbols, root, "go"); err != nil {
+		t.Fatalf("GatherSymbolContext failed: %v", err)
+	}
+
+	snippets := symbols[0].Snippets
+	if !strings.Contains(snippets, "the merge base with main") {
+		t.Errorf("Expected the context to name the merge base, got:\n%s", snippets)
+	}
+	if !strings.Contains(snippets, "return Total(1, 2)") {
+		t.Errorf("Expected the usage in invoice.go at the merge base, got:\n%s", snippets)
+	}
+	if strings.Contains(snippets, "refund.go") {
+		t.Errorf("Expected no usage from the file added on the branch, got:\n%s", snippets)
+	}
+
+	if err := NewSymbolContextTool(t.TempDir(), NewParserRegistry()).SetBase("main"); err == nil {
+		t.Error("Expected SetBase to fail outside of a git repository")
+	}
+}
@@ -0,0 +1,264 @@
+package tools
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// BashParser extracts the functions and variables of shell scripts and the commands and
+// expansions using them. No tree-sitter bash grammar is vendored, so like the SQL parser it
+// works on the script once comments, strings and heredocs are blanked: functions are
+// delimited by their braces and commands are the first word of each pipeline element.
+type BashParser struct{}
+
+func NewBashParser() *BashParser {
+	return &BashParser{}
+}
+
+func (bp *BashParser) Language() string {
+	return "Bash"
+}
+
+func (bp *BashParser) SupportedExtensions() []string {
+	return []string{`.sh`, `.bash`}
+}
+
+func (bp *BashParser) ShouldExcludeFile(filePath, projectRoot string) bool {
+	lowerPath := strings.ToLower(platform.NormalizePath(filePath))
+	if bp.IsTestFile(lowerPath) {
+		return true
+	}
+	for _, pattern := range []string{"vendor/", "node_modules/", "testdata/", ".git/"} {
+		if strings.Contains(lowerPath, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func (bp *BashParser) IsTestFile(filePath string) bool {
+	base := strings.ToLower(filepath.Base(filePath))
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	return strings.HasSuffix(name, "_test") || strings.HasSuffix(name, ".test")
+}
+
+var (
+	shellFunctionRegex   = regexp.MustCompile(`^\s*(?:function\s+([A-Za-z_][\w:.-]*)\s*(?:\(\s*\))?|([A-Za-z_][\w:.-]*)\s*\(\s*\))`)
+	shellAssignmentRegex = regexp.MustCompile(`^\s*(?:(?:export|local|readonly|declare|typeset)(?:\s+-\w+)*\s+)?([A-Za-z_]\w*)(?:\[[^\]]*\])?\+?=`)
+	shellExpansionRegex  = regexp.MustCompile(`\$\{?[#!]?([A-Za-z_]\w*)`)
+	// shellSeparatorRegex splits a line into the elements of its lists and pipelines
+	shellSeparatorRegex = regexp.MustCompile("\\|\\||&&|\\$\\(|[;|&(){}`]")
+	// shellCasePatternRegex matches the pattern starting a case clause, e.g. start|restart)
+	shellCasePatternRegex = regexp.MustCompile(`^\s*\(?[\w*?.|"' -]+\)`)
+	shellWordRegex        = regexp.MustCompile(`^[A-Za-z_][\w:.-]*$`)
+)
+
+// shellPrefixKeywords come before the command they apply to
+var shellPrefixKeywords = []string{"if", "elif", "then", "else", "while", "until", "do", "!", "time", "command", "exec", "sudo", "nohup"}
+
+// shellNonCommands are the keywords and builtins which are not commands worth a usage
+var shellNonCommands = []string{
+	"for", "in", "case", "esac", "select", "function", "done", "fi", "[[", "]]",
+	"echo", "printf", "cd", "pwd", "export", "local", "readonly", "declare", "typeset", "set",
+	"unset", "shift", "return", "exit", "source", ".", "eval", "trap", "test", "[", "true",
+	"false", "read", "wait", "kill", "alias", "unalias", "type", "builtin", "let", "getopts",
+	"pushd", "popd", "ulimit", "umask", "hash", "break", "continue", "mapfile", "readarray", "shopt",
+}
+
+// ParseFile returns the functions as func_decl and the assigned variables as var_decl, in the
+// package of the script file, the commands calling a function or program as func_usage and
+// the variable expansions as var_usage
+func (bp *BashParser) ParseFile(filePath string, content []byte) ([]types.Symbol, error) {
+	expansions, structure := maskShell(string(content))
+	lineStarts := lineOffsets(structure)
+	structureLines := strings.Split(structure, "\n")
+	expansionLines := strings.Split(expansions, "\n")
+	script := filepath.Base(filePath)
+
+	var symbols []types.Symbol
+	// valuesEnd is the last line of a multi-line array, whose values are not commands
+	valuesEnd := 0
+	add := func(name, symbolType string, startLine, endLine int) {
+		symbols = append(symbols, types.Symbol{
+			Name:      name,
+			Type:      symbolType,
+			Package:   script,
+			FilePath:  filePath,
+			StartLine: startLine,
+			EndLine:   endLine,
+		})
+	}
+
+	for i, line := range structureLines {
+		lineNumber := i + 1
+		commands := line
+
+		if match := shellFunctionRegex.FindStringSubmatchIndex(line); match != nil {
+			// The function keyword form or the name() form
+			var name string
+			if match[2] >= 0 {
+				name = line[match[2]:match[3]]
+			} else {
+				name = line[match[4]:match[5]]
+			}
+			add(name, "func_decl", lineNumber, shellBlockEnd(structure, lineStarts, lineStarts[i]+match[1]))
+			commands = line[match[1]:]
+		} else if match := shellAssignmentRegex.FindStringSubmatchIndex(line); match != nil {
+			endLine := lineNumber
+			// Arrays may list their values over several lines
+			if value := line[match[1]:]; strings.HasPrefix(value, "(") {
+				endLine = shellBlockEnd(structure, lineStarts, lineStarts[i]+match[1])
+				valuesEnd = endLine
+			}
+			add(line[match[2]:match[3]], "var_decl", lineNumber, endLine)
+		}
+
+		if lineNumber > valuesEnd {
+			for _, command := range shellCommands(commands) {
+				add(command, "func_usage", lineNumber, lineNumber)
+			}
+		}
+		for _, expansion := range shellExpansionRegex.FindAllStringSubmatch(expansionLines[i], -1) {
+			add(expansion[1], "var_usage", lineNumber, lineNumber)
+		}
+	}
+
+	return symbols, nil
+}
+
+// shellCommands returns the commands run by a masked line, without keywords and builtins
+func shellCommands(line string) []string {
+	if match := shellCasePatternRegex.FindStringIndex(line); match != nil && !strings.Contains(line[:match[1]], "$(") {
+		line = line[match[1]:]
+	}
+
+	var commands []string
+	for _, element := range shellSeparatorRegex.Split(line, -1) {
+		for _, word := range strings.Fields(element) {
+			if shellAssignmentRegex.MatchString(word) || slices.Contains(shellPrefixKeywords, word) {
+				continue
+			}
+			if shellWordRegex.MatchString(word) && !slices.Contains(shellNonCommands, word) {
+				commands = append(commands, word)
+			}
+			break
+		}
+	}
+	return commands
+}
+
+// shellBlockEnd returns the line closing the brace or parenthesis block opened after an
+// offset of the masked script, the last line when it is never closed
+func shellBlockEnd(structure string, lineStarts []int, offset int) int {
+	rest := strings.TrimLeft(structure[offset:], " \t\r\n")
+	if rest == "" {
+		return len(lineStarts)
+	}
+	open := rest[0]
+	closing := map[byte]byte{'{': '}', '(': ')'}[open]
+	if closing == 0 {
+		return lineAt(lineStarts, offset)
+	}
+
+	start := len(structure) - len(rest)
+	depth := 0
+	for i := start; i < len(structure); i++ {
+		switch structure[i] {
+		case open:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return lineAt(lineStarts, i)
+			}
+		}
+	}
+	return len(lineStarts)
+}
+
+var shellHeredocRegex = regexp.MustCompile(`<<-?\s*(?:'([A-Za-z_]\w*)'|"([A-Za-z_]\w*)"|\\?([A-Za-z_]\w*))`)
+
+// maskShell blanks the comments of a script, then also the content of the strings and
+// heredocs in a second copy, keeping the offsets and line breaks of both. Single-quoted
+// strings and quoted heredocs do not expand variables, so they are blanked in both.
+func maskShell(source string) (expansions, structure string) {
+	expanding := []byte(source)
+	masked := []byte(source)
+	blank := func(text []byte, from, to int) {
+		for i := from; i < to && i < len(text); i++ {
+			if text[i] != '\n' {
+				text[i] = ' '
+			}
+		}
+	}
+
+	var heredocs []string
+	var literalHeredocs []bool
+	for i := 0; i < len(source); i++ {
+		switch c := source[i]; {
+		case c == '\\':
+			i++
+		case c == '\n' && len(heredocs) > 0:
+			// The bodies of the heredocs opened by a line follow it in order
+			bodyStart := i + 1
+			for len(heredocs) > 0 {
+				end := len(source)
+				if closing := regexp.MustCompile(`(?m)^\t*` + regexp.QuoteMeta(heredocs[0]) + `[ \t]*$`).FindStringIndex(source[bodyStart:]); closing != nil {
+					end = bodyStart + closing[0]
+				}
+				if literalHeredocs[0] {
+					blank(expanding, bodyStart, end)
+				}
+				// The next body starts after the closing delimiter line, blanked with the body
+				nextStart := len(source)
+				if next := strings.IndexByte(source[end:], '\n'); next >= 0 {
+					nextStart = end + next + 1
+				}
+				blank(masked, bodyStart, nextStart)
+				bodyStart = nextStart
+				heredocs, literalHeredocs = heredocs[1:], literalHeredocs[1:]
+			}
+			i = bodyStart - 1
+		case c == '#' && (i == 0 || strings.ContainsRune(" \t\n;", rune(source[i-1]))):
+			end := strings.IndexByte(source[i:], '\n')
+			if end < 0 {
+				end = len(source) - i
+			}
+			blank(expanding, i, i+end)
+			blank(masked, i, i+end)
+			i += end - 1
+		case c == '\'':
+			end := strings.IndexByte(source[i+1:], '\'')
+			if end < 0 {
+				end = len(source) - i - 1
+			}
+			blank(expanding, i+1, i+1+end)
+			blank(masked, i+1, i+1+end)
+			i += end + 1
+		case c == '"':
+			end := i + 1
+			for end < len(source) && source[end] != '"' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			blank(masked, i+1, end)
+			i = end
+		case c == '<' && strings.HasPrefix(source[i:], "<<") && !strings.HasPrefix(source[i:], "<<<"):
+			match := shellHeredocRegex.FindStringSubmatch(source[i:])
+			if match == nil || !strings.HasPrefix(source[i:], match[0]) {
+				continue
+			}
+			heredocs = append(heredocs, match[1]+match[2]+match[3])
+			literalHeredocs = append(literalHeredocs, match[3] == "" || strings.Contains(match[0], `\`))
+			i += len(match[0]) - 1
+		}
+	}
+	return string(expanding), string(masked)
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+const deployScript = `#!/usr/bin/env bash
+set -euo pipefail
+
+REGION="${AWS_REGION:-eu-west-1}" # default region { not a block
+TARGETS=(
+  web
+  worker
+)
+
+install() {
+  curl -fsSL "$INSTALL_URL" | sh
+}
+
+function deploy {
+  local name=$1
+  cat <<'EOF'
+  } $NOT_EXPANDED
+EOF
+  if [[ -n $name ]]; then
+    kubectl apply -f "deploy/$name.yaml" --region $REGION
+  fi
+}
+
+case "${1:-}" in
+  install) install ;;
+  *) for target in "${TARGETS[@]}"; do deploy "$target"; done ;;
+esac
+`
+
+func TestBashParser_ParseFile(t *testing.T) {
+	parser := NewBashParser()
+
+	symbols, err := parser.ParseFile("scripts/deploy.sh", []byte(deployScript))
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	var got []string
+	for _, symbol := range symbols {
+		if symbol.Package != "deploy.sh" {
+			t.Errorf("Expected the deploy.sh package for %s, got %q", symbol.Name, symbol.Package)
+		}
+		got = append(got, fmt.Sprintf("%s %s %d-%d", symbol.Type, symbol.Name, symbol.StartLine, symbol.EndLine))
+	}
+
+	expected := []string{
+		"var_decl REGION 4-4",
+		"var_usage AWS_REGION 4-4",
+		"var_decl TARGETS 5-8",
+		"func_decl install 10-12",
+		"func_usage curl 11-11",
+		"func_usage sh 11-11",
+		"var_usage INSTALL_URL 11-11",
+		"func_decl deploy 14-22",
+		"var_decl name 15-15",
+		"func_usage cat 16-16",
+		"var_usage name 19-19",
+		"func_usage kubectl 20-20",
+		"var_usage name 20-20",
+		"var_usage REGION 20-20",
+		"func_usage install 25-25",
+		"func_usage deploy 26-26",
+		"var_usage TARGETS 26-26",
+		"var_usage target 26-26",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Unexpected symbols:\n%v\nexpected:\n%v", got, expected)
+	}
+}
+
+func TestBashParser_IsTestFile(t *testing.T) {
+	parser := NewBashParser()
+	if !parser.IsTestFile("scripts/deploy_test.sh") {
+		t.Error("Expected deploy_test.sh to be a test file")
+	}
+	if parser.IsTestFile("scripts/test.sh") {
+		t.Error("Expected test.sh not to be a test file")
+	}
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// callGraphMaxCallers bounds the callers followed from each function, keeping the chains
+// of widely used functions from crowding out the others
+const callGraphMaxCallers = 8
+
+// callChain is a path from a changed symbol up to one of its transitive callers
+type callChain struct {
+	caller types.Symbol
+	path   string
+}
+
+// GatherCallGraph lists the chains of callers leading to the symbol, up to depth levels,
+// breadth first so the closest callers are kept when tokenBudget runs out
+func (g *SymbolContextGatherer) GatherCallGraph(symbol types.Symbol, projectRoot, primaryLanguage string, depth, tokenBudget int) (string, error) {
+	frontier := []callChain{{caller: symbol, path: symbol.Name}}
+	seen := map[string]bool{callGraphKey(symbol): true}
+
+	var contextBuilder strings.Builder
+	for level := 1; level <= depth && len(frontier) > 0; level++ {
+		var next []callChain
+
+		for _, chain := range frontier {
+			callers, err := g.findCallers(chain.caller, projectRoot, primaryLanguage)
+			if err != nil {
+				if level == 1 {
+					return "", err
+				}
+				continue
+			}
+
+			followed := 0
+			for _, caller := range callers {
+				if seen[callGraphKey(caller)] || followed == callGraphMaxCallers {
+					continue
+				}
+				seen[callGraphKey(caller)] = true
+				followed++
+
+				path := chain.path + " <- " + caller.Name
+				line := fmt.Sprintf("%s (%s:%d)\n", path, relativePath(projectRoot, caller.FilePath), caller.StartLine)
+				if estimateTokens(contextBuilder.String()+line) > tokenBudget {
+					return contextBuilder.String(), nil
+				}
+				contextBuilder.WriteString(line)
+				next = append(next, callChain{caller: caller, path: path})
+			}
+		}
+
+		frontier = next
+	}
+
+	return contextBuilder.String(), nil
+}
+
+func callGraphKey(symbol types.Symbol) string {
+	return symbol.FilePath + ":" + symbol.Name
+}
+
+// callGraphContext adds the transitive callers of the changed functions of a file, up to the
+// configured depth. The budget is shared by the file's symbols.
+func (t *SymbolContextTool) callGraphContext(affectedSymbols []types.SymbolUsage, primaryLanguage string) {
+	remaining := t.contextConfig.CallGraphTokenBudget
+
+	for i := range affectedSymbols {
+		if remaining <= 0 {
+			return
+		}
+		if !slices.Contains(callerTypes, affectedSymbols[i].Symbol.Type) {
+			continue
+		}
+
+		chains, err := t.gatherer.GatherCallGraph(affectedSymbols[i].Symbol, t.projectRoot, primaryLanguage, t.contextConfig.Depth, remaining)
+		if err != nil || chains == "" {
+			continue
+		}
+
+		affectedSymbols[i].Snippets += fmt.Sprintf(">>>>> Call graph: callers of %s up to depth %d\n%s", affectedSymbols[i].Symbol.Name, t.contextConfig.Depth, chains)
+		remaining -= estimateTokens(chains)
+	}
+}
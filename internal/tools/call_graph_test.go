@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestSymbolContextGatherer_GatherCallGraph(t *testing.T) {
+	tempDir := setupCallerRepo(t)
+	gatherer := NewSymbolContextGatherer(NewParserRegistry())
+	process := types.Symbol{Name: "Process", FilePath: filepath.Join(tempDir, "process.go")}
+
+	direct, err := gatherer.GatherCallGraph(process, tempDir, "go", 1, 10000)
+	if err != nil {
+		t.Fatalf("GatherCallGraph failed: %v", err)
+	}
+	for i := 0; i < expansionMinUsages; i++ {
+		if !strings.Contains(direct, fmt.Sprintf("Process <- Handler%d (handler%d.go:3)", i, i)) {
+			t.Errorf("Expected Handler%d as a caller, got:\n%s", i, direct)
+		}
+	}
+	if strings.Contains(direct, "main") {
+		t.Errorf("Expected depth 1 to stop at the direct callers, got:\n%s", direct)
+	}
+
+	transitive, err := gatherer.GatherCallGraph(process, tempDir, "go", 2, 10000)
+	if err != nil {
+		t.Fatalf("GatherCallGraph failed: %v", err)
+	}
+	if strings.Count(transitive, "<- main (main.go:3)") != 1 {
+		t.Errorf("Expected main once as a caller of a handler, got:\n%s", transitive)
+	}
+
+	limited, err := gatherer.GatherCallGraph(process, tempDir, "go", 2, 20)
+	if err != nil {
+		t.Fatalf("GatherCallGraph failed: %v", err)
+	}
+	if estimateTokens(limited) > 20 || limited == "" {
+		t.Errorf("Expected some chains within the budget, got %d tokens:\n%s", estimateTokens(limited), limited)
+	}
+}
+
+func TestSymbolContextTool_CallGraphDepth(t *testing.T) {
+	tempDir := setupCallerRepo(t)
+	diffData := types.DiffData{
+		AbsolutePath: filepath.Join(tempDir, "process.go"),
+		Diff:         "--- a/process.go\n+++ b/process.go\n@@ -5,1 +5,1 @@\n-\ttotal += n * 100\n+\ttotal += n * 1\n",
+	}
+
+	for _, depth := range []int{1, 2} {
+		t.Run(fmt.Sprintf("depth %d", depth), func(t *testing.T) {
+			tool := NewSymbolContextTool(tempDir, NewParserRegistry())
+			tool.SetContextConfig(config.ContextConfig{Depth: depth, CallGraphTokenBudget: 2000})
+
+			result, err := tool.Execute(context.Background(), map[string]any{"diffData": diffData, "primaryLanguage": "go"})
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+
+			snippets := result.(types.DiffData).AffectedSymbols[0].Snippets
+			hasGraph := strings.Contains(snippets, ">>>>> Call graph: callers of Process up to depth 2")
+			if hasGraph != (depth > 1) {
+				t.Errorf("Expected call graph %v, got snippets:\n%s", depth > 1, snippets)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/vcs"
+)
+
+// callSiteLines is the number of lines kept from each call, enough for the arguments of
+// most calls split over several lines
+const callSiteLines = 5
+
+// CallSitesTool finds the calls of a function or method across the repository: the files
+// naming it are found with the version control grep and parsed to keep the actual calls
+type CallSitesTool struct {
+	repoRoot string
+	gatherer *SymbolContextGatherer
+}
+
+func NewCallSitesTool(repoRoot string, registry *ParserRegistry) *CallSitesTool {
+	gatherer := NewSymbolContextGatherer(registry)
+	if repo, err := vcs.Detect(repoRoot); err == nil {
+		gatherer = NewSymbolContextGathererWithVCS(registry, repo)
+	}
+	return &CallSitesTool{repoRoot: repoRoot, gatherer: gatherer}
+}
+
+func (t *CallSitesTool) Name() string {
+	return string(ToolNameCallSites)
+}
+
+func (t *CallSitesTool) Description() string {
+	return "Find the calls of a function or method across the repository"
+}
+
+func (t *CallSitesTool) Schema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Name of the function or method",
+			},
+			"language": map[string]any{
+				"type":        "string",
+				"description": "Language of the files to search",
+			},
+		},
+		"required": []string{"name", "language"},
+	}
+}
+
+// Execute returns the calls as []types.CallSite, with repository-relative paths
+func (t *CallSitesTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("name parameter required")
+	}
+	language, _ := args["language"].(string)
+
+	symbol := types.Symbol{Name: NormalizeIdentifier(name)}
+	// Each call searches the working tree as it is now
+	t.gatherer.resetSearch()
+	files, err := t.gatherer.findCandidateFiles(symbol, t.repoRoot, language)
+	if err != nil {
+		return nil, err
+	}
+
+	var callSites []types.CallSite
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		symbols, err := t.gatherer.parserRegistry.ParseFile(file, content)
+		if err != nil {
+			slog.Debug("parse failed", "file", file, "error", err)
+			continue
+		}
+
+		relPath, err := platform.RelPath(t.repoRoot, file)
+		if err != nil {
+			relPath = platform.NormalizePath(file)
+		}
+		lines := strings.Split(string(content), "\n")
+		for _, s := range symbols {
+			if s.Name != symbol.Name || (s.Type != "func_usage" && s.Type != "method_usage") {
+				continue
+			}
+			end := min(len(lines), s.StartLine-1+callSiteLines)
+			callSites = append(callSites, types.CallSite{
+				Name:     symbol.Name,
+				FilePath: relPath,
+				Line:     s.StartLine,
+				Code:     strings.Join(lines[s.StartLine-1:end], "\n"),
+			})
+		}
+	}
+	return callSites, nil
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestCallSitesTool_Execute(t *testing.T) {
+	tempDir := t.TempDir()
+	runGitCmd(t, tempDir, "git", "init")
+
+	writeGitFile(t, tempDir, "store.go", `package main
+
+func Save(name string, ttl int) error {
+	return nil
+}
+`)
+	writeGitFile(t, tempDir, "handler.go", `package main
+
+func handle() {
+	_ = Save("user",
+		60)
+	SaveAll()
+}
+`)
+	runGitCmd(t, tempDir, "git", "add", ".")
+
+	tool := NewCallSitesTool(tempDir, NewParserRegistry())
+	result, err := tool.Execute(context.Background(), map[string]any{"name": "Save", "language": "go"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	callSites, ok := result.([]types.CallSite)
+	if !ok || len(callSites) != 1 {
+		t.Fatalf("Expected the call in handler.go only, got %+v", result)
+	}
+	callSite := callSites[0]
+	if callSite.FilePath != "handler.go" || callSite.Line != 4 {
+		t.Errorf("Expected handler.go:4, got %s:%d", callSite.FilePath, callSite.Line)
+	}
+	if callSite.Code != "\t_ = Save(\"user\",\n\t\t60)\n\tSaveAll()\n}\n" {
+		t.Errorf("Expected the call and the following lines, got %q", callSite.Code)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"language": "go"}); err == nil {
+		t.Error("Expected an error without a name")
+	}
+}
@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// A diff is low-information when it changes at most this many lines...
+const lowInformationMaxChangedLines = 3
+
+// ...of a symbol spanning at least this many lines with at least this many usages
+const (
+	expansionMinSymbolLines = 30
+	expansionMinUsages      = 5
+)
+
+var callerTypes = []string{"func_decl", "method_decl", "constructor_decl"}
+
+// isLowInformationDiff reports whether the diff adds and removes so few lines that it says
+// little about the change on its own
+func isLowInformationDiff(diff string) bool {
+	changed := 0
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+			continue
+		}
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			changed++
+		}
+	}
+	return changed > 0 && changed <= lowInformationMaxChangedLines
+}
+
+// needsExpansion reports whether the symbol is large and used widely enough for a small
+// change to it to have effects the regular context does not show
+func needsExpansion(usage types.SymbolUsage) bool {
+	lines := usage.Symbol.EndLine - usage.Symbol.StartLine + 1
+	return lines >= expansionMinSymbolLines && usage.Usages >= expansionMinUsages
+}
+
+// estimateTokens approximates the token count of text at four characters per token
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// GatherCallerContext shows where the functions calling the symbol are called in turn, the
+// callers of callers. Callers are added whole until the next one would exceed tokenBudget.
+func (g *SymbolContextGatherer) GatherCallerContext(symbol types.Symbol, projectRoot, primaryLanguage string, tokenBudget int) (string, error) {
+	callers, err := g.findCallers(symbol, projectRoot, primaryLanguage)
+	if err != nil {
+		return "", err
+	}
+
+	var contextBuilder strings.Builder
+	for _, caller := range callers {
+		usages, err := g.gatherUsagesOnly(caller, projectRoot, primaryLanguage)
+		if err != nil || usages == "" {
+			continue
+		}
+
+		block := fmt.Sprintf(">>>>> Callers of %s (calls %s)\n%s", caller.Name, symbol.Name, usages)
+		if estimateTokens(contextBuilder.String()+block) > tokenBudget {
+			break
+		}
+		contextBuilder.WriteString(block)
+	}
+
+	return contextBuilder.String(), nil
+}
+
+// findCallers returns the functions and methods enclosing the usages of the symbol
+func (g *SymbolContextGatherer) findCallers(symbol types.Symbol, projectRoot, primaryLanguage string) ([]types.Symbol, error) {
+	symbol.Name = NormalizeIdentifier(symbol.Name)
+
+	candidateFiles, err := g.findCandidateFiles(symbol, projectRoot, primaryLanguage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find candidate files for symbol %s: %w", symbol.Name, err)
+	}
+
+	var callers []types.Symbol
+	seen := map[string]bool{symbol.Name: true}
+
+	for _, filePath := range candidateFiles {
+		content, err := g.readFile(filePath)
+		if err != nil {
+			continue
+		}
+		symbols, err := g.parserRegistry.ParseFile(filePath, content)
+		if err != nil {
+			slog.Debug("parse failed", "file", filePath, "error", err)
+			continue
+		}
+
+		for _, s := range symbols {
+			if s.Name != symbol.Name || !IsUsageType(s.Type) {
+				continue
+			}
+			caller, ok := enclosingCallable(symbols, s.StartLine)
+			if ok && !seen[caller.Name] {
+				seen[caller.Name] = true
+				callers = append(callers, caller)
+			}
+		}
+	}
+
+	return callers, nil
+}
+
+// enclosingCallable returns the innermost function or method declared around the line
+func enclosingCallable(symbols []types.Symbol, line int) (types.Symbol, bool) {
+	var enclosing types.Symbol
+	found := false
+
+	for _, s := range symbols {
+		if !slices.Contains(callerTypes, s.Type) || line < s.StartLine || line > s.EndLine {
+			continue
+		}
+		if !found || s.EndLine-s.StartLine < enclosing.EndLine-enclosing.StartLine {
+			enclosing = s
+			found = true
+		}
+	}
+
+	return enclosing, found
+}
+
+// gatherUsagesOnly finds only the usages of a symbol
+func (g *SymbolContextGatherer) gatherUsagesOnly(symbol types.Symbol, projectRoot, primaryLanguage string) (string, error) {
+	candidateFiles, err := g.findCandidateFiles(symbol, projectRoot, primaryLanguage)
+	if err != nil {
+		return "", fmt.Errorf("failed to find candidate files for symbol %s: %w", symbol.Name, err)
+	}
+
+	var contextBuilder strings.Builder
+	seen := make(map[string]bool)
+
+	for _, filePath := range candidateFiles {
+		content, err := g.readFile(filePath)
+		if err != nil {
+			continue
+		}
+		symbols, err := g.parserRegistry.ParseFile(filePath, content)
+		if err != nil {
+			slog.Debug("parse failed", "file", filePath, "error", err)
+			continue
+		}
+
+		for _, s := range symbols {
+			if s.Name != symbol.Name || !IsUsageType(s.Type) {
+				continue
+			}
+
+			key := fmt.Sprintf("%s:%d", filePath, s.StartLine)
+			if !seen[key] {
+				seen[key] = true
+				contextBuilder.WriteString(fmt.Sprintf(">>>>>> Usage in %s (line %d):\n", filePath, s.StartLine))
+				contextBuilder.WriteString(extractSnippet(content, s.StartLine, s.EndLine))
+				contextBuilder.WriteString("\n")
+			}
+		}
+	}
+
+	return contextBuilder.String(), nil
+}
+
+// expandContext adds the callers of callers of the large, widely used symbols of a file
+// whose diff is too small to explain the change. The budget is shared by the file's symbols.
+func (t *SymbolContextTool) expandContext(affectedSymbols []types.SymbolUsage, primaryLanguage string) {
+	remaining := t.contextConfig.ExpansionTokenBudget
+
+	for i := range affectedSymbols {
+		if remaining <= 0 {
+			return
+		}
+		if !needsExpansion(affectedSymbols[i]) {
+			continue
+		}
+
+		expanded, err := t.gatherer.GatherCallerContext(affectedSymbols[i].Symbol, t.projectRoot, primaryLanguage, remaining)
+		if err != nil || expanded == "" {
+			continue
+		}
+
+		affectedSymbols[i].Snippets += ">>>>> Expanded context: small change to a large, widely used symbol\n" + expanded
+		remaining -= estimateTokens(expanded)
+	}
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestIsLowInformationDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		diff     string
+		expected bool
+	}{
+		{"one-liner", "--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n a\n-b\n+c\n d\n", true},
+		{"larger change", "@@ -1,2 +1,4 @@\n-a\n-b\n+c\n+d\n+e\n+f\n", false},
+		{"no changes", "@@ -1,1 +1,1 @@\n a\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLowInformationDiff(tt.diff); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// setupCallerRepo creates a repository where Process is a large function called from
+// every handler, and every handler is called from main
+func setupCallerRepo(t *testing.T) string {
+	tempDir := t.TempDir()
+	runGitCmd(t, tempDir, "git", "init")
+
+	var process strings.Builder
+	process.WriteString("package main\n\nfunc Process(n int) int {\n\ttotal := 0\n")
+	for i := 0; i < expansionMinSymbolLines; i++ {
+		fmt.Fprintf(&process, "\ttotal += n * %d\n", i)
+	}
+	process.WriteString("\treturn total\n}\n")
+	writeGitFile(t, tempDir, "process.go", process.String())
+
+	var main strings.Builder
+	main.WriteString("package main\n\nfunc main() {\n")
+	for i := 0; i < expansionMinUsages; i++ {
+		writeGitFile(t, tempDir, fmt.Sprintf("handler%d.go", i),
+			fmt.Sprintf("package main\n\nfunc Handler%d() int {\n\treturn Process(%d)\n}\n", i, i))
+		fmt.Fprintf(&main, "\tHandler%d()\n", i)
+	}
+	main.WriteString("}\n")
+	writeGitFile(t, tempDir, "main.go", main.String())
+
+	runGitCmd(t, tempDir, "git", "add", ".")
+	runGitCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+
+	return tempDir
+}
+
+func TestSymbolContextGatherer_GatherCallerContext(t *testing.T) {
+	tempDir := setupCallerRepo(t)
+	gatherer := NewSymbolContextGatherer(NewParserRegistry())
+
+	expanded, err := gatherer.GatherCallerContext(types.Symbol{Name: "Process"}, tempDir, "go", 10000)
+	if err != nil {
+		t.Fatalf("GatherCallerContext failed: %v", err)
+	}
+
+	for i := 0; i < expansionMinUsages; i++ {
+		if !strings.Contains(expanded, fmt.Sprintf(">>>>> Callers of Handler%d (calls Process)", i)) {
+			t.Errorf("Expected the callers of Handler%d, got:\n%s", i, expanded)
+		}
+	}
+	if !strings.Contains(expanded, "Usage in "+filepath.Join(tempDir, "main.go")) {
+		t.Errorf("Expected the usages in main.go, got:\n%s", expanded)
+	}
+
+	limited, err := gatherer.GatherCallerContext(types.Symbol{Name: "Process"}, tempDir, "go", 60)
+	if err != nil {
+		t.Fatalf("GatherCallerContext failed: %v", err)
+	}
+	if estimateTokens(limited) > 60 {
+		t.Errorf("Expected the context to fit the budget, got %d tokens", estimateTokens(limited))
+	}
+	if len(limited) >= len(expanded) {
+		t.Errorf("Expected the budget to drop callers")
+	}
+}
+
+func TestSymbolContextTool_AdaptiveExpansion(t *testing.T) {
+	tempDir := setupCallerRepo(t)
+
+	diffData := types.DiffData{
+		AbsolutePath: filepath.Join(tempDir, "process.go"),
+		Diff:         "--- a/process.go\n+++ b/process.go\n@@ -5,1 +5,1 @@\n-\ttotal += n * 100\n+\ttotal += n * 1\n",
+	}
+
+	tests := []struct {
+		name          string
+		contextConfig config.ContextConfig
+		expectExpand  bool
+	}{
+		{"enabled", config.DefaultContextConfig(), true},
+		{"disabled", config.ContextConfig{AdaptiveExpansion: false, ExpansionTokenBudget: 2000}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := NewSymbolContextTool(tempDir, NewParserRegistry())
+			tool.SetContextConfig(tt.contextConfig)
+
+			result, err := tool.Execute(context.Background(), map[string]any{"diffData": diffData, "primaryLanguage": "go"})
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+
+			updated := result.(types.DiffData)
+			if len(updated.AffectedSymbols) != 1 {
+				t.Fatalf("Expected Process to be affected, got %+v", updated.AffectedSymbols)
+			}
+			process := updated.AffectedSymbols[0]
+			if process.Usages < expansionMinUsages {
+				t.Errorf("Expected at least %d usages, got %d", expansionMinUsages, process.Usages)
+			}
+
+			expanded := strings.Contains(process.Snippets, "Expanded context")
+			if expanded != tt.expectExpand {
+				t.Errorf("Expected expansion %v, got snippets:\n%s", tt.expectExpand, process.Snippets)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package tools
+
+import (
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/utils"
+)
+
+const (
+	// duplicateKGram is the number of tokens hashed together into a fingerprint candidate
+	duplicateKGram = 12
+	// duplicateWindow is the winnowing window, a copy of at least duplicateKGram +
+	// duplicateWindow - 1 tokens always shares a fingerprint with the original
+	duplicateWindow = 6
+	// minDuplicateLines is the smallest block of added lines searched for duplicates
+	minDuplicateLines = 4
+	// minDuplicateFingerprints skips the blocks too short to compare meaningfully
+	minDuplicateFingerprints = 3
+	// minSimilarity is the share of the fingerprints of a block existing code must contain
+	minSimilarity = 0.6
+	// maxDuplicatesPerBlock bounds the similar code listed for each added block
+	maxDuplicatesPerBlock = 2
+)
+
+var (
+	codeCommentRegex = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+	codeTokenRegex   = regexp.MustCompile("[\\p{L}_][\\p{L}\\p{N}_]*|\\d[\\w.]*|\"(?:\\\\.|[^\"\\\\\\n])*\"|'(?:\\\\.|[^'\\\\\\n])*'|`[^`]*`|\\S")
+)
+
+type token struct {
+	text string
+	line int
+}
+
+type fingerprint struct {
+	hash      uint64
+	startLine int
+	endLine   int
+}
+
+type fingerprintLocation struct {
+	path      string
+	startLine int
+	endLine   int
+}
+
+// duplicateIndex holds the winnowed fingerprints of the files of a repository. Tokens are
+// compared ignoring whitespace, comments and the values of literals, so copies that only
+// differ in their layout or constants still match.
+type duplicateIndex struct {
+	locations map[uint64][]fingerprintLocation
+	lines     map[string][]string
+}
+
+func newDuplicateIndex() *duplicateIndex {
+	return &duplicateIndex{
+		locations: make(map[uint64][]fingerprintLocation),
+		lines:     make(map[string][]string),
+	}
+}
+
+// Add indexes the content of a file under its repository-relative path
+func (idx *duplicateIndex) Add(path string, content []byte) {
+	idx.lines[path] = strings.Split(string(content), "\n")
+	for _, fp := range winnow(tokenize(string(content), 1)) {
+		idx.locations[fp.hash] = append(idx.locations[fp.hash], fingerprintLocation{path: path, startLine: fp.startLine, endLine: fp.endLine})
+	}
+}
+
+// Find returns the indexed code the blocks of lines a diff adds to a file nearly duplicate,
+// the most similar first for each block. The added lines themselves are never reported.
+func (idx *duplicateIndex) Find(path, diff string, content []byte) []types.SimilarCode {
+	lines := strings.Split(string(content), "\n")
+
+	var similar []types.SimilarCode
+	for _, block := range addedBlocks(utils.GetDiffChangedLines(diff), len(lines)) {
+		blockText := strings.Join(lines[block[0]-1:block[1]], "\n")
+		prints := winnow(tokenize(blockText, block[0]))
+		if len(prints) < minDuplicateFingerprints {
+			continue
+		}
+
+		var found []types.SimilarCode
+		for candidate, locations := range idx.matches(prints, path, block) {
+			region, matched := densestRegion(locations, block[1]-block[0]+1)
+			similarity := float64(matched) / float64(len(prints))
+			if similarity < minSimilarity {
+				continue
+			}
+			found = append(found, types.SimilarCode{
+				AddedStart: block[0],
+				AddedEnd:   block[1],
+				FilePath:   candidate,
+				StartLine:  region[0],
+				EndLine:    region[1],
+				Similarity: similarity,
+				Snippet:    idx.snippet(candidate, region[0], region[1]),
+			})
+		}
+
+		sort.Slice(found, func(i, j int) bool {
+			if found[i].Similarity != found[j].Similarity {
+				return found[i].Similarity > found[j].Similarity
+			}
+			if found[i].FilePath != found[j].FilePath {
+				return found[i].FilePath < found[j].FilePath
+			}
+			return found[i].StartLine < found[j].StartLine
+		})
+		if len(found) > maxDuplicatesPerBlock {
+			found = found[:maxDuplicatesPerBlock]
+		}
+		similar = append(similar, found...)
+	}
+	return similar
+}
+
+// matchedLocation is an occurrence of one of the fingerprints of a block
+type matchedLocation struct {
+	fingerprintLocation
+	// fingerprint is the index of the matched fingerprint of the block
+	fingerprint int
+}
+
+// matches groups by file the indexed occurrences of the fingerprints, without those of the
+// block itself
+func (idx *duplicateIndex) matches(prints []fingerprint, path string, block [2]int) map[string][]matchedLocation {
+	byFile := make(map[string][]matchedLocation)
+	for i, fp := range prints {
+		for _, location := range idx.locations[fp.hash] {
+			if location.path == path && location.startLine <= block[1] && location.endLine >= block[0] {
+				continue
+			}
+			byFile[location.path] = append(byFile[location.path], matchedLocation{fingerprintLocation: location, fingerprint: i})
+		}
+	}
+	return byFile
+}
+
+// densestRegion returns the lines of the cluster of matches covering the most distinct
+// fingerprints. Matches further apart than the length of the block belong to different
+// clusters, e.g. two copies of the block in the same file.
+func densestRegion(locations []matchedLocation, blockLines int) ([2]int, int) {
+	sort.Slice(locations, func(i, j int) bool { return locations[i].startLine < locations[j].startLine })
+
+	var best [2]int
+	bestCount := 0
+	for start := 0; start < len(locations); {
+		end := start + 1
+		for end < len(locations) && locations[end].startLine-locations[end-1].endLine <= blockLines {
+			end++
+		}
+
+		distinct := make(map[int]bool)
+		region := [2]int{locations[start].startLine, locations[start].endLine}
+		for _, location := range locations[start:end] {
+			distinct[location.fingerprint] = true
+			region[1] = max(region[1], location.endLine)
+		}
+		if len(distinct) > bestCount {
+			best, bestCount = region, len(distinct)
+		}
+		start = end
+	}
+	return best, bestCount
+}
+
+func (idx *duplicateIndex) snippet(path string, start, end int) string {
+	lines := idx.lines[path]
+	if start < 1 || end > len(lines) || start > end {
+		return ""
+	}
+	return strings.Join(lines[start-1:end], "\n")
+}
+
+// addedBlocks returns the runs of at least minDuplicateLines consecutive added lines
+func addedBlocks(added map[int]bool, lineCount int) [][2]int {
+	var blocks [][2]int
+	start := 0
+	for line := 1; line <= lineCount+1; line++ {
+		if line <= lineCount && added[line] {
+			if start == 0 {
+				start = line
+			}
+			continue
+		}
+		if start != 0 && line-start >= minDuplicateLines {
+			blocks = append(blocks, [2]int{start, line - 1})
+		}
+		start = 0
+	}
+	return blocks
+}
+
+// tokenize splits code into tokens with their line, numbered from firstLine. Comments are
+// dropped and literals replaced by a placeholder.
+func tokenize(code string, firstLine int) []token {
+	code = codeCommentRegex.ReplaceAllStringFunc(code, func(comment string) string {
+		return strings.Repeat("\n", strings.Count(comment, "\n"))
+	})
+
+	var tokens []token
+	line := firstLine
+	offset := 0
+	for _, match := range codeTokenRegex.FindAllStringIndex(code, -1) {
+		line += strings.Count(code[offset:match[0]], "\n")
+		offset = match[0]
+
+		text := code[match[0]:match[1]]
+		if first := text[0]; first == '"' || first == '\'' || first == '`' || (first >= '0' && first <= '9') {
+			text = "$lit"
+		}
+		tokens = append(tokens, token{text: text, line: line})
+	}
+	return tokens
+}
+
+// winnow selects the minimum hash of every window of k-gram hashes, the rightmost one on
+// ties, keeping each selected k-gram once
+func winnow(tokens []token) []fingerprint {
+	if len(tokens) < duplicateKGram {
+		return nil
+	}
+
+	grams := make([]fingerprint, 0, len(tokens)-duplicateKGram+1)
+	for i := 0; i+duplicateKGram <= len(tokens); i++ {
+		hasher := fnv.New64a()
+		for _, tok := range tokens[i : i+duplicateKGram] {
+			hasher.Write([]byte(tok.text))
+			hasher.Write([]byte{0})
+		}
+		grams = append(grams, fingerprint{hash: hasher.Sum64(), startLine: tokens[i].line, endLine: tokens[i+duplicateKGram-1].line})
+	}
+
+	var prints []fingerprint
+	selected := -1
+	window := min(duplicateWindow, len(grams))
+	for start := 0; start+window <= len(grams); start++ {
+		minimum := start
+		for i := start; i < start+window; i++ {
+			if grams[i].hash <= grams[minimum].hash {
+				minimum = i
+			}
+		}
+		if minimum != selected {
+			prints = append(prints, grams[minimum])
+			selected = minimum
+		}
+	}
+	return prints
+}
@@ -1,10 +1,26 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agusespa/diffpector/internal/utils"
 )
 
+// defaultReadFileBytes bounds the content a read returns, so that a large file does not fill
+// the context of the model
+const defaultReadFileBytes = 64 * 1024
+
+// ErrLineRange is returned for a line range outside of the file
+var ErrLineRange = errors.New("line range outside of the file")
+
 type WriteFileTool struct{}
 
 func (t *WriteFileTool) Name() string {
@@ -32,7 +48,7 @@ func (t *WriteFileTool) Schema() map[string]any {
 	}
 }
 
-func (t *WriteFileTool) Execute(args map[string]any) (any, error) {
+func (t *WriteFileTool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	filename, ok := args["filename"].(string)
 	if !ok {
 		return "", fmt.Errorf("filename parameter required")
@@ -51,14 +67,19 @@ func (t *WriteFileTool) Execute(args map[string]any) (any, error) {
 	return fmt.Sprintf("Successfully wrote %d bytes to %s", len(content), filename), nil
 }
 
-type ReadFileTool struct{}
+// ReadFileTool reads a file, or a range of its lines
+type ReadFileTool struct {
+	// MaxBytes bounds the content returned, the rest is replaced by a truncation marker.
+	// defaultReadFileBytes when zero.
+	MaxBytes int
+}
 
 func (t *ReadFileTool) Name() string {
 	return string(ToolNameReadFile)
 }
 
 func (t *ReadFileTool) Description() string {
-	return "Read content from a specified file"
+	return "Read content from a specified file, or the range of its lines from start_line to end_line. Large content is truncated, the marker telling the line to continue from."
 }
 
 func (t *ReadFileTool) Schema() map[string]any {
@@ -69,12 +90,20 @@ func (t *ReadFileTool) Schema() map[string]any {
 				"type":        "string",
 				"description": "Path to the file to read",
 			},
+			"start_line": map[string]any{
+				"type":        "integer",
+				"description": "First line to read, starting at 1 (default 1)",
+			},
+			"end_line": map[string]any{
+				"type":        "integer",
+				"description": "Last line to read, included (default the last line of the file)",
+			},
 		},
 		"required": []string{"filename"},
 	}
 }
 
-func (t *ReadFileTool) Execute(args map[string]any) (any, error) {
+func (t *ReadFileTool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	filename, ok := args["filename"].(string)
 	if !ok {
 		return "", fmt.Errorf("filename parameter required")
@@ -85,5 +114,101 @@ func (t *ReadFileTool) Execute(args map[string]any) (any, error) {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
-	return string(content), nil
+	startLine, hasStart := intArg(args, "start_line")
+	endLine, hasEnd := intArg(args, "end_line")
+	if !hasStart && !hasEnd {
+		return t.truncate(string(content), 1), nil
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if !hasStart {
+		startLine = 1
+	}
+	if !hasEnd {
+		endLine = len(lines)
+	}
+	if startLine < 1 || endLine < startLine || endLine > len(lines) {
+		return "", fmt.Errorf("%w: lines %d-%d of %s, which has %d lines", ErrLineRange, startLine, endLine, filename, len(lines))
+	}
+	return t.truncate(strings.Join(lines[startLine-1:endLine], ""), startLine), nil
+}
+
+// truncate cuts content starting at startLine down to MaxBytes, after its last whole line
+// when there is one, and marks where it was cut
+func (t *ReadFileTool) truncate(content string, startLine int) string {
+	maxBytes := t.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultReadFileBytes
+	}
+	if len(content) <= maxBytes {
+		return content
+	}
+
+	cut := strings.LastIndexByte(content[:maxBytes], '\n') + 1
+	if cut == 0 {
+		cut = maxBytes
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+	}
+	nextLine := startLine + strings.Count(content[:cut], "\n")
+	return fmt.Sprintf("%s\n[... truncated after %d of %d bytes, continue with start_line %d]\n", content[:cut], cut, len(content), nextLine)
+}
+
+// RepositoryReadFileTool is the read_file offered to the model during the review. It only reads
+// the files of the repository matching its allowlist, never the .git directory nor a path
+// leaving the repository, symbolic links included.
+type RepositoryReadFileTool struct {
+	ReadFileTool
+	root string
+	// allowed are the globs of the readable repository-relative paths, see utils.MatchGlob,
+	// every file when empty
+	allowed []string
+}
+
+func NewRepositoryReadFileTool(root string, allowed []string) *RepositoryReadFileTool {
+	return &RepositoryReadFileTool{root: root, allowed: allowed}
+}
+
+func (t *RepositoryReadFileTool) Description() string {
+	return "Read a file of the repository, or the range of its lines from start_line to end_line. Large content is truncated, the marker telling the line to continue from."
+}
+
+func (t *RepositoryReadFileTool) Schema() map[string]any {
+	schema := t.ReadFileTool.Schema()
+	schema["properties"].(map[string]any)["filename"] = map[string]any{
+		"type":        "string",
+		"description": "Path of the file to read, relative to the repository root",
+	}
+	return schema
+}
+
+func (t *RepositoryReadFileTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	filename, ok := args["filename"].(string)
+	if !ok || filename == "" {
+		return "", fmt.Errorf("filename parameter required")
+	}
+	path, err := t.resolve(filename)
+	if err != nil {
+		return "", err
+	}
+
+	readArgs := maps.Clone(args)
+	readArgs["filename"] = path
+	return t.ReadFileTool.Execute(ctx, readArgs)
+}
+
+// resolve returns the path of a repository file the allowlist lets the model read
+func (t *RepositoryReadFileTool) resolve(filename string) (string, error) {
+	rel, err := Confine(t.root, filename)
+	if err != nil {
+		return "", err
+	}
+	if len(t.allowed) > 0 && !slices.ContainsFunc(t.allowed, func(pattern string) bool { return utils.MatchGlob(pattern, rel) }) {
+		return "", fmt.Errorf("%s is not readable, the readable files match %s", filename, strings.Join(t.allowed, ", "))
+	}
+	return filepath.Join(t.root, filepath.FromSlash(rel)), nil
 }
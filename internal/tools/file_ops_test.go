@@ -1,8 +1,11 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -37,7 +40,7 @@ func TestWriteFileTool_Execute(t *testing.T) {
 		"content":  content,
 	}
 
-	result, err := tool.Execute(args)
+	result, err := tool.Execute(context.Background(), args)
 	if err != nil {
 		t.Errorf("Expected no error, got: %v", err)
 	}
@@ -97,7 +100,7 @@ func TestReadFileTool_Execute(t *testing.T) {
 	}
 
 	args := map[string]any{"filename": tmpfile.Name()}
-	result, err := tool.Execute(args)
+	result, err := tool.Execute(context.Background(), args)
 	if err != nil {
 		t.Errorf("Expected no error, got: %v", err)
 	}
@@ -111,3 +114,126 @@ func TestReadFileTool_Execute(t *testing.T) {
 		t.Errorf("Expected result to contain file content, got: %s", resultStr)
 	}
 }
+
+func TestReadFileTool_Execute_LineRange(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(filename, []byte("one\ntwo\nthree\nfour\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tool := &ReadFileTool{}
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		expected string
+	}{
+		{"range", map[string]any{"start_line": 2, "end_line": 3}, "two\nthree\n"},
+		{"from JSON", map[string]any{"start_line": float64(3)}, "three\nfour\n"},
+		{"up to a line", map[string]any{"end_line": 1}, "one\n"},
+		{"last line", map[string]any{"start_line": 4, "end_line": 4}, "four\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["filename"] = filename
+			result, err := tool.Execute(context.Background(), tt.args)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	for _, lines := range [][2]int{{0, 2}, {3, 2}, {4, 5}} {
+		_, err := tool.Execute(context.Background(), map[string]any{"filename": filename, "start_line": lines[0], "end_line": lines[1]})
+		if !errors.Is(err, ErrLineRange) || !strings.Contains(err.Error(), "which has 4 lines") {
+			t.Errorf("Expected lines %d-%d to be out of range, got %v", lines[0], lines[1], err)
+		}
+	}
+}
+
+func TestReadFileTool_Execute_Truncates(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "large.txt")
+	if err := os.WriteFile(filename, []byte(strings.Repeat("0123456789\n", 10)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tool := &ReadFileTool{MaxBytes: 25}
+
+	result, err := tool.Execute(context.Background(), map[string]any{"filename": filename})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "0123456789\n0123456789\n\n[... truncated after 22 of 110 bytes, continue with start_line 3]\n"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	result, err = tool.Execute(context.Background(), map[string]any{"filename": filename, "start_line": 5})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(result.(string), "continue with start_line 7]\n") {
+		t.Errorf("Expected the marker to continue from line 7, got %q", result)
+	}
+
+	// A single line longer than the cap is cut on a character boundary
+	if err := os.WriteFile(filename, []byte(strings.Repeat("é", 20)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = tool.Execute(context.Background(), map[string]any{"filename": filename})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(result.(string), strings.Repeat("é", 12)+"\n[... truncated after 24 of 40 bytes") {
+		t.Errorf("Expected the line cut after 12 characters, got %q", result)
+	}
+}
+
+func TestRepositoryReadFileTool_Execute(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	for _, dir := range []string{"src", ".git", "docs"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range map[string]string{"src/main.go": "package main\n", ".git/config": "[core]\n", "docs/notes.md": "notes\n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("token\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "src", "link")); err != nil {
+		t.Skipf("Symbolic links unsupported: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "src", "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := NewRepositoryReadFileTool(root, []string{"src/**"})
+	if result, err := tool.Execute(context.Background(), map[string]any{"filename": "src/main.go"}); err != nil || result != "package main\n" {
+		t.Errorf("Expected src/main.go to be read, got %q, %v", result, err)
+	}
+
+	for filename, expected := range map[string]string{
+		"docs/notes.md":                  "not readable, the readable files match src/**",
+		".git/config":                    "inside a .git directory",
+		"../" + filepath.Base(outside):   "outside of the repository",
+		filepath.Join(outside, "secret"): "outside of the repository",
+		"src/link":                       "outside of the repository",
+		// Confined like the paths the policy checks, through the directory of a missing file
+		"src/escape/missing.go": "outside of the repository",
+	} {
+		_, err := tool.Execute(context.Background(), map[string]any{"filename": filename})
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected reading %s to fail with %q, got %v", filename, expected, err)
+		}
+	}
+
+	if _, err := NewRepositoryReadFileTool(root, nil).Execute(context.Background(), map[string]any{"filename": "docs/notes.md"}); err != nil {
+		t.Errorf("Expected every file to be readable without an allowlist, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHistoryCommits is the number of commits listed per hunk when none is configured
+const DefaultHistoryCommits = 3
+
+var oldRangeRegex = regexp.MustCompile(`^@@\s+-(\d+)(?:,(\d+))?\s+\+\d+(?:,\d+)?\s+@@`)
+
+var blameHeaderRegex = regexp.MustCompile(`^([0-9a-f]{40}) \d+ \d+`)
+
+// GitBlameTool lists the last commits that touched the lines a diff changes, read from HEAD
+type GitBlameTool struct {
+	repoRoot   string
+	maxCommits int
+}
+
+func NewGitBlameTool(repoRoot string, maxCommits int) *GitBlameTool {
+	if maxCommits <= 0 {
+		maxCommits = DefaultHistoryCommits
+	}
+	return &GitBlameTool{repoRoot: repoRoot, maxCommits: maxCommits}
+}
+
+func (t *GitBlameTool) Name() string {
+	return string(ToolNameGitBlame)
+}
+
+func (t *GitBlameTool) Description() string {
+	return "List the last commits (author, date, subject) touching the lines changed by a diff"
+}
+
+func (t *GitBlameTool) Schema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "Repository-relative path of the changed file",
+			},
+			"diff": map[string]any{
+				"type":        "string",
+				"description": "Unified diff of the file against HEAD",
+			},
+		},
+		"required": []string{"file_path", "diff"},
+	}
+}
+
+type blameCommit struct {
+	hash    string
+	author  string
+	time    int64
+	summary string
+}
+
+// Execute returns a compact history per hunk, or an empty string when the diff only adds lines
+func (t *GitBlameTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	filePath, ok := args["file_path"].(string)
+	if !ok || filePath == "" {
+		return "", fmt.Errorf("file_path parameter required")
+	}
+	diffText, ok := args["diff"].(string)
+	if !ok {
+		return "", fmt.Errorf("diff parameter required")
+	}
+
+	var history strings.Builder
+	for line := range strings.SplitSeq(diffText, "\n") {
+		matches := oldRangeRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		start, _ := strconv.Atoi(matches[1])
+		count := 1
+		if matches[2] != "" {
+			count, _ = strconv.Atoi(matches[2])
+		}
+		// Pure additions have no previous lines to blame
+		if count == 0 || start == 0 {
+			continue
+		}
+		end := start + count - 1
+
+		commits, err := t.blame(ctx, filePath, start, end)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&history, "Lines %d-%d:\n", start, end)
+		for _, commit := range commits {
+			date := time.Unix(commit.time, 0).UTC().Format("2006-01-02")
+			fmt.Fprintf(&history, "- %s %s %s: %s\n", commit.hash[:7], date, commit.author, commit.summary)
+		}
+	}
+
+	return history.String(), nil
+}
+
+// blame returns the distinct commits that last touched the lines, newest first
+func (t *GitBlameTool) blame(ctx context.Context, filePath string, start, end int) ([]blameCommit, error) {
+	cmd := exec.CommandContext(ctx, "git", "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", start, end), "HEAD", "--", filePath)
+	cmd.Dir = t.repoRoot
+	output, err := cmd.Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("failed to blame %s: %s", filePath, strings.TrimSpace(string(exitError.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to blame %s: %w", filePath, err)
+	}
+
+	commits := make(map[string]*blameCommit)
+	var current *blameCommit
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if matches := blameHeaderRegex.FindStringSubmatch(line); matches != nil {
+			if current = commits[matches[1]]; current == nil {
+				current = &blameCommit{hash: matches[1]}
+				commits[matches[1]] = current
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		// Porcelain details are only written the first time a commit appears
+		if value, found := strings.CutPrefix(line, "author "); found {
+			current.author = value
+		} else if value, found := strings.CutPrefix(line, "author-time "); found {
+			current.time, _ = strconv.ParseInt(value, 10, 64)
+		} else if value, found := strings.CutPrefix(line, "summary "); found {
+			current.summary = value
+		}
+	}
+
+	result := make([]blameCommit, 0, len(commits))
+	for _, commit := range commits {
+		result = append(result, *commit)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].time != result[j].time {
+			return result[i].time > result[j].time
+		}
+		return result[i].hash < result[j].hash
+	})
+	if len(result) > t.maxCommits {
+		result = result[:t.maxCommits]
+	}
+	return result, nil
+}
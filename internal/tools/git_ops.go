@@ -1,16 +1,28 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
-	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/agusespa/diffpector/internal/platform"
 	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/vcs"
 	"github.com/sourcegraph/go-diff/diff"
 )
 
-type GitDiffTool struct{}
+type GitDiffTool struct {
+	repo vcs.VCS
+}
+
+// NewGitDiffTool creates a diff tool backed by the given VCS. The zero value uses git
+// in the current directory.
+func NewGitDiffTool(repo vcs.VCS) *GitDiffTool {
+	return &GitDiffTool{repo: repo}
+}
 
 func (t *GitDiffTool) Name() string {
 	return string(ToolNameGitDiff)
@@ -22,25 +34,49 @@ func (t *GitDiffTool) Description() string {
 
 func (t *GitDiffTool) Schema() map[string]any {
 	return map[string]any{
-		"type":       "object",
-		"properties": map[string]any{},
+		"type": "object",
+		"properties": map[string]any{
+			"working_tree": map[string]any{
+				"type":        "boolean",
+				"description": "Diff all uncommitted changes instead of only the staged ones",
+			},
+			"paths": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Restrict the working tree diff to these paths",
+			},
+		},
 	}
 }
 
-func (t *GitDiffTool) Execute(args map[string]any) (any, error) {
-	rootCmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	repoRootBytes, err := rootCmd.Output()
+func (t *GitDiffTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	repo := t.repo
+	if repo == nil {
+		repo = vcs.NewGit(".")
+	}
+
+	repoRoot, err := repo.Root()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get repo root: %w", err)
 	}
-	repoRoot := strings.TrimSpace(string(repoRootBytes))
 
-	cmd := exec.Command("git", "diff", "--staged")
-	out, err := cmd.Output()
+	var out []byte
+	if workingTree, _ := args["working_tree"].(bool); workingTree {
+		out, err = repo.WorkingDiff(stringsArg(args, "paths")...)
+	} else {
+		out, err = repo.StagedDiff()
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to run git diff: %w", err)
+		return nil, fmt.Errorf("failed to run %s diff: %w", repo.Name(), err)
 	}
 
+	return ParseDiffData(out, repoRoot)
+}
+
+// ParseDiffData splits a multi-file unified diff into per-file DiffData keyed by the
+// repository-relative path. The submodules whose commit changed are left out, their code
+// being reviewed from within them.
+func ParseDiffData(out []byte, repoRoot string) (map[string]types.DiffData, error) {
 	fileDiffs, err := diff.ParseMultiFileDiff(out)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse diff: %w", err)
@@ -49,13 +85,17 @@ func (t *GitDiffTool) Execute(args map[string]any) (any, error) {
 	result := make(map[string]types.DiffData)
 
 	for _, fd := range fileDiffs {
+		if isSubmoduleDiff(fd) {
+			continue
+		}
+
 		name := fd.NewName
 		if name == "/dev/null" {
 			name = fd.OrigName
 		}
-		name = stripGitPrefix(name)
+		name = platform.NormalizePath(stripGitPrefix(name))
 
-		absPath := filepath.Join(repoRoot, name)
+		absPath := platform.JoinPath(repoRoot, name)
 
 		diffContentBytes, err := diff.PrintFileDiff(fd)
 		if err != nil {
@@ -72,6 +112,21 @@ func (t *GitDiffTool) Execute(args map[string]any) (any, error) {
 	return result, nil
 }
 
+// submoduleMode is the git mode of a submodule entry, the commit it points to
+const submoduleMode = "160000"
+
+// isSubmoduleDiff reports whether a file diff changes the commit of a submodule
+func isSubmoduleDiff(fd *diff.FileDiff) bool {
+	for _, line := range fd.Extended {
+		if strings.HasPrefix(line, "index ") || strings.Contains(line, " mode ") {
+			if strings.HasSuffix(line, " "+submoduleMode) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func stripGitPrefix(path string) string {
 	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
 		return path[2:]
@@ -79,6 +134,22 @@ func stripGitPrefix(path string) string {
 	return path
 }
 
+const (
+	// defaultGrepMatches bounds the matches of a search that sets no max_matches
+	defaultGrepMatches = 50
+	// grepLineBytes bounds the text kept of a matching line, minified files have long ones
+	grepLineBytes = 200
+)
+
+// grepModes maps the mode argument of the grep tool to the pattern syntax of git grep
+var grepModes = map[string]string{
+	"fixed":    "--fixed-strings",
+	"basic":    "--basic-regexp",
+	"extended": "--extended-regexp",
+	"perl":     "--perl-regexp",
+}
+
+// GitGrepTool searches the tracked files of the repository in the current directory
 type GitGrepTool struct{}
 
 func (t *GitGrepTool) Name() string {
@@ -97,30 +168,131 @@ func (t *GitGrepTool) Schema() map[string]any {
 				"type":        "string",
 				"description": "Pattern to search for in tracked files",
 			},
+			"mode": map[string]any{
+				"type":        "string",
+				"enum":        []string{"fixed", "basic", "extended", "perl"},
+				"description": "How the pattern is read: a literal string, or a basic, extended or Perl regular expression (default basic)",
+			},
+			"include": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Search only these paths or globs, e.g. *.go or internal/",
+			},
+			"exclude": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Skip these paths or globs, e.g. *_test.go",
+			},
+			"max_matches": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum number of matching lines returned (default %d)", defaultGrepMatches),
+			},
 		},
 		"required": []string{"pattern"},
 	}
 }
 
-func (t *GitGrepTool) Execute(args map[string]any) (any, error) {
+// Execute returns the matching lines as types.GrepResult, with paths relative to the
+// current directory
+func (t *GitGrepTool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	pattern, ok := args["pattern"].(string)
 	if !ok {
-		return "", fmt.Errorf("pattern parameter required")
+		return types.GrepResult{}, fmt.Errorf("pattern parameter required")
+	}
+	mode, _ := args["mode"].(string)
+	if mode == "" {
+		mode = "basic"
+	}
+	syntax, ok := grepModes[mode]
+	if !ok {
+		return types.GrepResult{}, fmt.Errorf("unknown mode %q, use fixed, basic, extended or perl", mode)
+	}
+	maxMatches := defaultGrepMatches
+	if limit, ok := intArg(args, "max_matches"); ok && limit > 0 {
+		maxMatches = limit
+	}
+
+	// -z separates the file, line and column with NUL bytes, as paths may hold colons
+	grepArgs := []string{"grep", "-n", "--column", "-z", "-I", syntax}
+	for _, form := range identifierSearchForms(pattern) {
+		grepArgs = append(grepArgs, "-e", form)
+	}
+	pathspecs := stringsArg(args, "include")
+	for _, exclude := range stringsArg(args, "exclude") {
+		pathspecs = append(pathspecs, ":(exclude)"+exclude)
+	}
+	if len(pathspecs) > 0 {
+		grepArgs = append(grepArgs, "--")
+		grepArgs = append(grepArgs, pathspecs...)
 	}
 
-	cmd := exec.Command("git", "grep", "-n", "--", pattern)
+	cmd := exec.CommandContext(ctx, "git", grepArgs...)
 	output, err := cmd.Output()
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
-			return fmt.Sprintf("No matches found for pattern: %s", pattern), nil
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) && exitError.ExitCode() == 1 {
+			return types.GrepResult{Pattern: pattern}, nil
 		}
-		return "", fmt.Errorf("failed to search pattern: %w", err)
+		if errors.As(err, &exitError) && len(exitError.Stderr) > 0 {
+			return types.GrepResult{}, fmt.Errorf("failed to search pattern: %s", strings.TrimSpace(string(exitError.Stderr)))
+		}
+		return types.GrepResult{}, fmt.Errorf("failed to search pattern: %w", err)
+	}
+
+	result := types.GrepResult{Pattern: pattern}
+	result.Matches, result.Truncated = parseGrepOutput(output, maxMatches)
+	return result, nil
+}
+
+// parseGrepOutput reads the file NUL line NUL column NUL text lines of git grep -z, up to
+// maxMatches of them
+func parseGrepOutput(output []byte, maxMatches int) ([]types.GrepMatch, bool) {
+	var matches []types.GrepMatch
+	for _, line := range strings.Split(strings.TrimSuffix(string(output), "\n"), "\n") {
+		fields := strings.SplitN(line, "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		if len(matches) == maxMatches {
+			return matches, true
+		}
+		lineNumber, _ := strconv.Atoi(fields[1])
+		column, _ := strconv.Atoi(fields[2])
+		matches = append(matches, types.GrepMatch{
+			FilePath: platform.NormalizePath(fields[0]),
+			Line:     lineNumber,
+			Column:   column,
+			Text:     truncateUTF8(fields[3], grepLineBytes),
+		})
 	}
+	return matches, false
+}
 
-	result := string(output)
-	if len(result) > 1000 {
-		result = result[:1000] + "\n... (truncated - too many matches)"
+// stringsArg reads a list argument, a []string when called from the code and a []any when
+// decoded from the JSON of a model tool call
+func stringsArg(args map[string]any, name string) []string {
+	switch value := args[name].(type) {
+	case []string:
+		return value
+	case []any:
+		var values []string
+		for _, item := range value {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
 	}
+	return nil
+}
 
-	return fmt.Sprintf("Search results for '%s':\n%s", pattern, result), nil
+// intArg reads an integer argument, a float64 when decoded from JSON
+func intArg(args map[string]any, name string) (int, bool) {
+	switch value := args[name].(type) {
+	case int:
+		return value, true
+	case float64:
+		return int(value), true
+	}
+	return 0, false
 }
@@ -1,13 +1,16 @@
 package tools
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/vcs"
 )
 
 func TestGitDiffTool_Name(t *testing.T) {
@@ -45,7 +48,7 @@ func TestGitDiffTool_Execute_NoChanges(t *testing.T) {
 
 	tool := &GitDiffTool{}
 
-	result, err := tool.Execute(nil)
+	result, err := tool.Execute(context.Background(), nil)
 	if err != nil {
 		t.Errorf("Expected no error, but got: %v", err)
 	}
@@ -93,7 +96,7 @@ func TestGitDiffTool_Execute_SingleModifiedFile(t *testing.T) {
 
 	tool := &GitDiffTool{}
 
-	result, err := tool.Execute(nil)
+	result, err := tool.Execute(context.Background(), nil)
 	if err != nil {
 		t.Errorf("Expected no error, but got: %v", err)
 	}
@@ -127,6 +130,32 @@ func TestGitDiffTool_Execute_SingleModifiedFile(t *testing.T) {
 	}
 }
 
+func TestGitDiffTool_Execute_WorkingTreeNewFile(t *testing.T) {
+	tempDir, cleanup := setupGitRepo(t)
+	defer cleanup()
+	createAndCommitFile(t, tempDir, "main.go", "package main\n")
+
+	// A file created under watch is untracked until it is added
+	if err := os.WriteFile(filepath.Join(tempDir, "handler.go"), []byte("package main\n\nfunc Handle() {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	tool := NewGitDiffTool(vcs.NewGit(tempDir))
+	result, err := tool.Execute(context.Background(), map[string]any{"working_tree": true, "paths": []any{"handler.go"}})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	resultMap := result.(map[string]types.DiffData)
+	diffData, ok := resultMap["handler.go"]
+	if !ok || len(resultMap) != 1 {
+		t.Fatalf("Expected only the diff of the new file, got: %v", resultMap)
+	}
+	if !strings.Contains(diffData.Diff, "+func Handle() {}") {
+		t.Errorf("Expected the content of the new file, got:\n%s", diffData.Diff)
+	}
+}
+
 func TestGitDiffTool_Execute_DeletedFile(t *testing.T) {
 	tempDir, cleanup := setupGitRepo(t)
 	defer cleanup()
@@ -154,7 +183,7 @@ func TestGitDiffTool_Execute_DeletedFile(t *testing.T) {
 	}
 
 	tool := &GitDiffTool{}
-	result, err := tool.Execute(nil)
+	result, err := tool.Execute(context.Background(), nil)
 	if err != nil {
 		t.Errorf("Expected no error, got: %v", err)
 	}
@@ -206,7 +235,7 @@ func TestGitDiffTool_Execute_RenamedFile(t *testing.T) {
 	}
 
 	tool := &GitDiffTool{}
-	result, err := tool.Execute(nil)
+	result, err := tool.Execute(context.Background(), nil)
 	if err != nil {
 		t.Errorf("Expected no error, got: %v", err)
 	}
@@ -273,7 +302,7 @@ func TestGitDiffTool_Execute_MultipleModifiedFiles(t *testing.T) {
 	}
 
 	tool := &GitDiffTool{}
-	result, err := tool.Execute(nil)
+	result, err := tool.Execute(context.Background(), nil)
 	if err != nil {
 		t.Errorf("Expected no error, got: %v", err)
 	}
@@ -304,6 +333,32 @@ func TestGitDiffTool_Execute_MultipleModifiedFiles(t *testing.T) {
 	}
 }
 
+func TestParseDiffData_SkipsSubmodules(t *testing.T) {
+	out := []byte(`diff --git a/lib b/lib
+index 1111111..2222222 160000
+--- a/lib
++++ b/lib
+@@ -1 +1 @@
+-Subproject commit 1111111111111111111111111111111111111111
++Subproject commit 2222222222222222222222222222222222222222
+diff --git a/main.go b/main.go
+index 3333333..4444444 100644
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-package main
++package app
+`)
+
+	result, err := ParseDiffData(out, "/repo")
+	if err != nil {
+		t.Fatalf("ParseDiffData failed: %v", err)
+	}
+	if _, ok := result["lib"]; ok || len(result) != 1 {
+		t.Errorf("Expected only main.go, the submodule left out, got %v", result)
+	}
+}
+
 func setupGitRepo(t *testing.T) (string, func()) {
 	tempDir, err := os.MkdirTemp("", "git-test-*")
 	if err != nil {
@@ -362,7 +417,7 @@ func TestGitGrepTool_Description(t *testing.T) {
 
 func TestGitGrepTool_Execute_MissingPattern(t *testing.T) {
 	tool := &GitGrepTool{}
-	_, err := tool.Execute(map[string]any{})
+	_, err := tool.Execute(context.Background(), map[string]any{})
 
 	if err == nil {
 		t.Error("Expected error for missing pattern")
@@ -375,18 +430,122 @@ func TestGitGrepTool_Execute_MissingPattern(t *testing.T) {
 func TestGitGrepTool_Execute_WithPattern(t *testing.T) {
 	tool := &GitGrepTool{}
 
-	result, err := tool.Execute(map[string]any{"pattern": "package"})
+	result, err := tool.Execute(context.Background(), map[string]any{"pattern": "package"})
 
 	if err != nil {
 		t.Errorf("Expected no error, got: %v", err)
 	}
 
-	resultStr, ok := result.(string)
+	grepResult, ok := result.(types.GrepResult)
 	if !ok {
-		t.Fatalf("Expected result to be a string, but got %T", result)
+		t.Fatalf("Expected result to be a types.GrepResult, but got %T", result)
 	}
 
+	resultStr := grepResult.String()
 	if !strings.Contains(resultStr, "Search results for 'package'") && !strings.Contains(resultStr, "No matches found for pattern: package") {
 		t.Errorf("Expected search results or no matches format, got: %s", resultStr)
 	}
 }
+
+func TestGitGrepTool_Execute_StructuredMatches(t *testing.T) {
+	tempDir := t.TempDir()
+	runGitCmd(t, tempDir, "git", "init")
+	if err := os.MkdirAll(filepath.Join(tempDir, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeGitFile(t, tempDir, "main.go", "package main\n\nfunc main() {\n\tTotal(1, 2)\n}\n")
+	writeGitFile(t, tempDir, "pkg/total.go", "package pkg\n\nfunc Total(a, b int) int { return a + b }\n")
+	writeGitFile(t, tempDir, "pkg/total_test.go", "package pkg\n\nvar _ = Total(0, 0)\n")
+	writeGitFile(t, tempDir, "notes.txt", "Total(a+b) is the sum\n")
+	runGitCmd(t, tempDir, "git", "add", ".")
+	t.Chdir(tempDir)
+
+	tool := &GitGrepTool{}
+	search := func(args map[string]any) types.GrepResult {
+		t.Helper()
+		result, err := tool.Execute(context.Background(), args)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return result.(types.GrepResult)
+	}
+
+	result := search(map[string]any{"pattern": "Total", "include": []any{"*.go"}, "exclude": []any{"*_test.go"}})
+	expected := []types.GrepMatch{
+		{FilePath: "main.go", Line: 4, Column: 2, Text: "\tTotal(1, 2)"},
+		{FilePath: "pkg/total.go", Line: 3, Column: 6, Text: "func Total(a, b int) int { return a + b }"},
+	}
+	if !reflect.DeepEqual(result.Matches, expected) || result.Truncated {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+
+	// In the fixed mode the parentheses and the plus are literal, an extended regex reads them
+	// as a group and a repetition
+	if result := search(map[string]any{"pattern": "Total(a+b)", "mode": "fixed"}); len(result.Matches) != 1 || result.Matches[0].FilePath != "notes.txt" {
+		t.Errorf("Expected the literal match in notes.txt, got %+v", result)
+	}
+	if result := search(map[string]any{"pattern": "Total(a+b)", "mode": "extended"}); len(result.Matches) != 0 {
+		t.Errorf("Expected no extended regex match, got %+v", result)
+	}
+	if result := search(map[string]any{"pattern": "Total\\([0-9]", "mode": "extended"}); len(result.Matches) != 2 {
+		t.Errorf("Expected the two calls with numbers, got %+v", result)
+	}
+
+	result = search(map[string]any{"pattern": "Total", "max_matches": float64(2)})
+	if len(result.Matches) != 2 || !result.Truncated || !strings.Contains(result.String(), "truncated after 2 matches") {
+		t.Errorf("Expected 2 matches and a truncated result, got %+v", result)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"pattern": "Total", "mode": "glob"}); err == nil {
+		t.Error("Expected an unknown mode to fail")
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"pattern": "Total(", "mode": "extended"}); err == nil {
+		t.Error("Expected an invalid regex to fail")
+	}
+}
+
+func TestGitBlameTool_Execute(t *testing.T) {
+	tempDir, cleanup := setupGitRepo(t)
+	defer cleanup()
+
+	commit := func(content, message, date string) {
+		if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		for _, args := range [][]string{{"add", "main.go"}, {"commit", "-m", message}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = tempDir
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Jane Doe", "GIT_AUTHOR_EMAIL=jane@example.com", "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, output)
+			}
+		}
+	}
+	commit("package main\n\nfunc a() {}\n\nfunc b() {}\n", "Add functions", "2024-01-10T10:00:00Z")
+	commit("package main\n\nfunc a() { retry() }\n\nfunc b() {}\n", "Retry in a", "2024-03-05T10:00:00Z")
+
+	diff := "@@ -3,3 +3,3 @@\n-func a() { retry() }\n+func a() { retry(3) }\n \n func b() {}\n@@ -5,0 +6,2 @@\n+\n+func c() {}\n"
+
+	result, err := NewGitBlameTool(tempDir, 0).Execute(context.Background(), map[string]any{"file_path": "main.go", "diff": diff})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	history := result.(string)
+
+	lines := strings.Split(strings.TrimSuffix(history, "\n"), "\n")
+	if len(lines) != 3 || lines[0] != "Lines 3-5:" {
+		t.Fatalf("Expected the history of the replaced lines only, got:\n%s", history)
+	}
+	if !strings.HasSuffix(lines[1], " 2024-03-05 Jane Doe: Retry in a") || !strings.HasSuffix(lines[2], " 2024-01-10 Jane Doe: Add functions") {
+		t.Errorf("Expected the commits newest first, got:\n%s", history)
+	}
+
+	result, err = NewGitBlameTool(tempDir, 1).Execute(context.Background(), map[string]any{"file_path": "main.go", "diff": diff})
+	if err != nil || strings.Count(result.(string), "\n- ") != 1 {
+		t.Errorf("Expected a single commit, got %q, %v", result, err)
+	}
+
+	if _, err := NewGitBlameTool(tempDir, 0).Execute(context.Background(), map[string]any{"file_path": "missing.go", "diff": diff}); err == nil {
+		t.Error("Expected an error for a file missing from HEAD")
+	}
+}
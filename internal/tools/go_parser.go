@@ -2,8 +2,10 @@ package tools
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/agusespa/diffpector/internal/platform"
 	"github.com/agusespa/diffpector/internal/types"
 	sitter "github.com/tree-sitter/go-tree-sitter"
 	tree_sitter_go "github.com/tree-sitter/tree-sitter-go/bindings/go"
@@ -43,7 +45,7 @@ func (gp *GoParser) SupportedExtensions() []string {
 }
 
 func (gp *GoParser) ShouldExcludeFile(filePath, projectRoot string) bool {
-	lowerPath := strings.ToLower(filePath)
+	lowerPath := strings.ToLower(platform.NormalizePath(filePath))
 
 	if strings.HasSuffix(lowerPath, "_test.go") {
 		return true
@@ -64,13 +66,32 @@ func (gp *GoParser) ShouldExcludeFile(filePath, projectRoot string) bool {
 	return false
 }
 
+func (gp *GoParser) IsTestFile(filePath string) bool {
+	return strings.HasSuffix(strings.ToLower(filePath), "_test.go")
+}
+
 func (gp *GoParser) ParseFile(filePath string, content []byte) ([]types.Symbol, error) {
-	tree := gp.parser.Parse(content, nil)
+	tree, err := gp.ParseTree(filePath, content, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	return gp.ExtractSymbols(filePath, tree, content)
+}
+
+// ParseTree parses the file, reusing the unchanged parts of oldTree when it is not nil.
+// The caller closes the returned tree.
+func (gp *GoParser) ParseTree(filePath string, content []byte, oldTree *sitter.Tree) (*sitter.Tree, error) {
+	tree := gp.parser.Parse(content, oldTree)
 	if tree == nil {
 		return nil, fmt.Errorf("failed to parse Go file")
 	}
-	defer tree.Close()
+	return tree, nil
+}
 
+// ExtractSymbols collects the declarations and usages of a parsed file
+func (gp *GoParser) ExtractSymbols(filePath string, tree *sitter.Tree, content []byte) ([]types.Symbol, error) {
 	packageName := gp.extractPackageName(tree.RootNode(), content)
 
 	queryText := `
@@ -124,6 +145,7 @@ func (gp *GoParser) ParseFile(filePath string, content []byte) ([]types.Symbol,
 				name = strings.TrimSpace(c.Node.Utf8Text(content))
 			}
 
+			name = NormalizeIdentifier(name)
 			if name == "" {
 				continue
 			}
@@ -275,3 +297,140 @@ func (gp *GoParser) extractPackageName(rootNode *sitter.Node, sourceBytes []byte
 	}
 	return "main"
 }
+
+// TypeDeclarations lists the types of the file with their methods. Methods are attached to
+// their receiver type, which may be declared in another file of the package.
+func (gp *GoParser) TypeDeclarations(filePath string, content []byte) ([]types.TypeDeclaration, error) {
+	tree, err := gp.ParseTree(filePath, content, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	var declarations []types.TypeDeclaration
+	indexOf := make(map[string]int)
+	declaration := func(name string) *types.TypeDeclaration {
+		if i, ok := indexOf[name]; ok {
+			return &declarations[i]
+		}
+		indexOf[name] = len(declarations)
+		declarations = append(declarations, types.TypeDeclaration{Name: name, Kind: "type", FilePath: filePath})
+		return &declarations[len(declarations)-1]
+	}
+
+	root := tree.RootNode()
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		node := root.NamedChild(i)
+		switch node.Kind() {
+		case "type_declaration":
+			for j := uint(0); j < node.NamedChildCount(); j++ {
+				spec := node.NamedChild(j)
+				if spec.Kind() != "type_spec" {
+					continue
+				}
+				gp.addTypeSpec(declaration, spec, content)
+			}
+		case "method_declaration":
+			receiver := goReceiverType(node, content)
+			name := node.ChildByFieldName("name")
+			if receiver == "" || name == nil {
+				continue
+			}
+			decl := declaration(receiver)
+			decl.Methods = append(decl.Methods, types.MethodDeclaration{
+				Name:      name.Utf8Text(content),
+				StartLine: int(node.StartPosition().Row) + 1,
+				EndLine:   int(node.EndPosition().Row) + 1,
+			})
+		}
+	}
+
+	return declarations, nil
+}
+
+func (gp *GoParser) addTypeSpec(declaration func(string) *types.TypeDeclaration, spec *sitter.Node, content []byte) {
+	nameNode := spec.ChildByFieldName("name")
+	typeNode := spec.ChildByFieldName("type")
+	if nameNode == nil || typeNode == nil {
+		return
+	}
+
+	decl := declaration(nameNode.Utf8Text(content))
+	decl.StartLine = int(spec.StartPosition().Row) + 1
+	decl.EndLine = int(spec.EndPosition().Row) + 1
+
+	switch typeNode.Kind() {
+	case "interface_type":
+		decl.Kind = "interface"
+		for i := uint(0); i < typeNode.NamedChildCount(); i++ {
+			elem := typeNode.NamedChild(i)
+			switch elem.Kind() {
+			case "method_elem":
+				if name := elem.ChildByFieldName("name"); name != nil {
+					decl.Methods = append(decl.Methods, types.MethodDeclaration{
+						Name:      name.Utf8Text(content),
+						StartLine: int(elem.StartPosition().Row) + 1,
+						EndLine:   int(elem.EndPosition().Row) + 1,
+					})
+				}
+			case "type_elem":
+				if embedded := elem.NamedChild(0); embedded != nil {
+					if name := typeNodeName(embedded, content); name != "" {
+						decl.Supertypes = append(decl.Supertypes, name)
+					}
+				}
+			}
+		}
+	case "struct_type":
+		decl.Kind = "struct"
+	}
+}
+
+// goReceiverType returns the name of the receiver type of a method declaration
+func goReceiverType(method *sitter.Node, content []byte) string {
+	receiver := method.ChildByFieldName("receiver")
+	if receiver == nil || receiver.NamedChildCount() == 0 {
+		return ""
+	}
+	typeNode := receiver.NamedChild(0).ChildByFieldName("type")
+	if typeNode == nil {
+		return ""
+	}
+	return typeNodeName(typeNode, content)
+}
+
+// typeNodeName returns the name of the type a type expression refers to, without pointers,
+// type arguments or qualifiers
+func typeNodeName(node *sitter.Node, content []byte) string {
+	switch node.Kind() {
+	case "type_identifier", "identifier":
+		return node.Utf8Text(content)
+	case "qualified_type":
+		if name := node.ChildByFieldName("name"); name != nil {
+			return name.Utf8Text(content)
+		}
+	case "scoped_type_identifier":
+		if count := node.NamedChildCount(); count > 0 {
+			return typeNodeName(node.NamedChild(count-1), content)
+		}
+	case "pointer_type", "generic_type":
+		if node.NamedChildCount() > 0 {
+			return typeNodeName(node.NamedChild(0), content)
+		}
+	}
+	return ""
+}
+
+// Implements reports whether typ implements the interface super, Go interfaces being
+// satisfied implicitly by any type declaring all their methods
+func (gp *GoParser) Implements(typ types.TypeDeclaration, methods []string, super types.TypeDeclaration) bool {
+	if super.Kind != "interface" || typ.Kind == "interface" || len(super.Methods) == 0 {
+		return false
+	}
+	for _, method := range super.Methods {
+		if !slices.Contains(methods, method.Name) {
+			return false
+		}
+	}
+	return true
+}
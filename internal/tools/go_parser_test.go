@@ -146,6 +146,7 @@ func TestGoParser_ShouldExcludeFile(t *testing.T) {
 			{"internal/vendor.go", false, "should not exclude files that contain 'vendor' in name but not in path"},
 			{"pkg/vendor_utils.go", false, "should not exclude files with 'vendor' in filename"},
 			{"src/vendor/lib.go", true, "should exclude files in vendor subdirectory"},
+			{`vendor\github.com\pkg\errors\errors.go`, true, "should exclude files in vendor directory with Windows separators"},
 		}
 
 		for _, tc := range testCases {
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/platform"
+)
+
+// goWorkspaceModules returns the absolute directories of the modules the go.work file of
+// projectRoot uses, following GOWORK like the go command. It is empty without a workspace.
+func goWorkspaceModules(projectRoot string) []string {
+	workFile := filepath.Join(projectRoot, "go.work")
+	switch gowork := os.Getenv("GOWORK"); gowork {
+	case "off":
+		return nil
+	case "":
+	default:
+		workFile = gowork
+	}
+
+	content, err := os.ReadFile(workFile)
+	if err != nil {
+		return nil
+	}
+	workDir, err := filepath.Abs(filepath.Dir(workFile))
+	if err != nil {
+		return nil
+	}
+
+	var modules []string
+	for _, dir := range parseGoWorkUses(content) {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workDir, filepath.FromSlash(dir))
+		}
+		modules = append(modules, filepath.Clean(dir))
+	}
+	return modules
+}
+
+// parseGoWorkUses returns the directories of the use directives of a go.work file, single or
+// in a block
+func parseGoWorkUses(content []byte) []string {
+	var uses []string
+	inBlock := false
+	for _, line := range strings.Split(string(content), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		line = strings.TrimSpace(line)
+
+		if inBlock {
+			if line == ")" {
+				inBlock = false
+			} else if line != "" {
+				uses = append(uses, unquoteGoWorkPath(line))
+			}
+			continue
+		}
+
+		rest, ok := strings.CutPrefix(line, "use")
+		if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t' && rest[0] != '(') {
+			continue
+		}
+		if rest = strings.TrimSpace(rest); rest == "(" {
+			inBlock = true
+		} else {
+			uses = append(uses, unquoteGoWorkPath(rest))
+		}
+	}
+	return uses
+}
+
+func unquoteGoWorkPath(path string) string {
+	if unquoted, err := strconv.Unquote(path); err == nil {
+		return unquoted
+	}
+	return path
+}
+
+// externalGoModules returns the workspace modules a search of the repository at projectRoot
+// misses: the ones outside it, and the ones within a submodule or another nested repository,
+// which git grep does not descend into
+func externalGoModules(projectRoot string) []string {
+	root, err := filepath.Abs(projectRoot)
+	if err != nil {
+		return nil
+	}
+
+	var external []string
+	for _, module := range goWorkspaceModules(projectRoot) {
+		rel, err := platform.RelPath(root, module)
+		if err != nil || platform.IsOutside(rel) || inNestedRepository(root, module) {
+			external = append(external, module)
+		}
+	}
+	return external
+}
+
+// inNestedRepository reports whether dir, within root, belongs to a repository of its own
+func inNestedRepository(root, dir string) bool {
+	for current := dir; current != root; current = filepath.Dir(current) {
+		if _, err := os.Lstat(filepath.Join(current, ".git")); err == nil {
+			return true
+		}
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseGoWorkUses(t *testing.T) {
+	content := []byte(`go 1.24
+
+use ./app // the service
+
+use (
+	./lib
+	"../shared"
+	// ./disabled
+)
+
+replace example.com/old => ./old
+`)
+
+	expected := []string{"./app", "./lib", "../shared"}
+	if uses := parseGoWorkUses(content); !slices.Equal(uses, expected) {
+		t.Errorf("Expected %q, got %q", expected, uses)
+	}
+}
+
+func TestExternalGoModules(t *testing.T) {
+	t.Setenv("GOWORK", "")
+	parent := t.TempDir()
+	repoRoot := filepath.Join(parent, "repo")
+	for _, dir := range []string{"app", filepath.Join("vendor-lib", ".git"), "../shared"} {
+		if err := os.MkdirAll(filepath.Join(repoRoot, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeGitFile(t, repoRoot, "go.work", "go 1.24\n\nuse (\n\t./app\n\t./vendor-lib\n\t../shared\n)\n")
+
+	expected := []string{filepath.Join(repoRoot, "vendor-lib"), filepath.Join(parent, "shared")}
+	if modules := externalGoModules(repoRoot); !slices.Equal(modules, expected) {
+		t.Errorf("Expected the submodule and the module outside the repository %q, got %q", expected, modules)
+	}
+
+	t.Setenv("GOWORK", "off")
+	if modules := externalGoModules(repoRoot); len(modules) != 0 {
+		t.Errorf("Expected no modules with GOWORK=off, got %q", modules)
+	}
+}
+
+func TestSymbolContextGatherer_WorkspaceModules(t *testing.T) {
+	t.Setenv("GOWORK", "")
+	parent := t.TempDir()
+	repoRoot := filepath.Join(parent, "app")
+	shared := filepath.Join(parent, "shared")
+	for _, dir := range []string{repoRoot, shared} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		runGitCmd(t, dir, "git", "init")
+	}
+
+	writeGitFile(t, repoRoot, "go.work", "go 1.24\n\nuse (\n\t.\n\t../shared\n)\n")
+	writeGitFile(t, repoRoot, "main.go", "package main\n\nfunc main() {\n\tshared.Greet()\n}\n")
+	writeGitFile(t, shared, "greet.go", "package shared\n\nfunc Greet() {}\n")
+	runGitCmd(t, repoRoot, "git", "add", ".")
+	runGitCmd(t, shared, "git", "add", ".")
+
+	tool := NewSymbolContextTool(repoRoot, NewParserRegistry())
+	found, err := tool.gatherer.searchPatterns([]string{"Greet"}, repoRoot, "go")
+	if err != nil {
+		t.Fatalf("searchPatterns failed: %v", err)
+	}
+	files := found["Greet"]
+
+	foundShared := false
+	for _, file := range files {
+		if strings.HasSuffix(filepath.ToSlash(file), "shared/greet.go") && filepath.IsAbs(file) {
+			foundShared = true
+		}
+	}
+	if len(files) != 2 || !foundShared {
+		t.Errorf("Expected main.go and the absolute path of shared/greet.go, got %v", files)
+	}
+}
@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// HclParser extracts the resources, data sources, modules, variables, outputs, providers
+// and locals of Terraform and other HCL files, and the references between them. Like the
+// SQL parser it does not rely on a tree-sitter grammar: blocks are delimited by their braces
+// once comments, strings and heredocs are blanked.
+type HclParser struct{}
+
+func NewHclParser() *HclParser {
+	return &HclParser{}
+}
+
+func (hp *HclParser) Language() string {
+	return "HCL"
+}
+
+func (hp *HclParser) SupportedExtensions() []string {
+	return []string{`.tf`, `.tfvars`, `.hcl`}
+}
+
+func (hp *HclParser) ShouldExcludeFile(filePath, projectRoot string) bool {
+	lowerPath := strings.ToLower(platform.NormalizePath(filePath))
+	if hp.IsTestFile(lowerPath) {
+		return true
+	}
+	for _, pattern := range []string{".terraform/", "vendor/", "testdata/", ".git/"} {
+		if strings.Contains(lowerPath, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func (hp *HclParser) IsTestFile(filePath string) bool {
+	return strings.HasSuffix(strings.ToLower(filePath), ".tftest.hcl")
+}
+
+// hclBlockRegex matches the header of a block, its type and labels, on a masked line
+var hclBlockRegex = regexp.MustCompile(`^\s*([A-Za-z_][\w-]*)((?:\s+(?:"[^"]*"|[A-Za-z_][\w-]*))*)\s*\{`)
+
+var hclLabelRegex = regexp.MustCompile(`"[^"]*"|[A-Za-z_][\w-]*`)
+
+// hclAttributeRegex matches the name of an attribute assignment on a masked line
+var hclAttributeRegex = regexp.MustCompile(`^\s*([A-Za-z_][\w-]*)\s*=`)
+
+var (
+	hclNamedReferenceRegex    = regexp.MustCompile(`\b(?:var|local|module)\.[A-Za-z_][\w-]*`)
+	hclDataReferenceRegex     = regexp.MustCompile(`\bdata\.[A-Za-z_][\w-]*\.[A-Za-z_][\w-]*`)
+	hclResourceReferenceRegex = regexp.MustCompile(`\b[a-z][a-z0-9]*_[A-Za-z0-9_-]*\.[A-Za-z_][\w-]*`)
+)
+
+// hclBlockTypes maps the block types declaring symbols to the symbol type, the prefix of
+// the name Terraform references them by and the number of labels forming the name
+var hclBlockTypes = map[string]struct {
+	symbolType string
+	prefix     string
+	labels     int
+}{
+	"resource": {"resource_decl", "", 2},
+	"data":     {"data_decl", "data.", 2},
+	"module":   {"module_decl", "module.", 1},
+	"variable": {"variable_decl", "var.", 1},
+	"output":   {"output_decl", "output.", 1},
+	"provider": {"provider_decl", "provider.", 1},
+}
+
+// ParseFile returns the blocks under the name expressions reference them by, e.g.
+// aws_instance.web, var.region or module.vpc, each local value as local.<name> and the
+// references as reference_usage symbols. The package of a symbol is the directory of its
+// file, the Terraform module it belongs to.
+func (hp *HclParser) ParseFile(filePath string, content []byte) ([]types.Symbol, error) {
+	withoutComments, masked := maskHCL(string(content))
+	lines := strings.Split(withoutComments, "\n")
+	maskedLines := strings.Split(masked, "\n")
+	module := filepath.Dir(filePath)
+
+	var symbols []types.Symbol
+	var block, local *types.Symbol
+	inLocals := false
+	depth := 0
+
+	for i, maskedLine := range maskedLines {
+		lineNumber := i + 1
+		line := lines[i]
+
+		if depth == 0 {
+			if match := hclBlockRegex.FindStringSubmatchIndex(maskedLine); match != nil {
+				blockType := line[match[2]:match[3]]
+				inLocals = blockType == "locals"
+				if kind, ok := hclBlockTypes[blockType]; ok {
+					var labels []string
+					for _, label := range hclLabelRegex.FindAllStringIndex(maskedLine[match[4]:match[5]], -1) {
+						labels = append(labels, strings.Trim(line[match[4]+label[0]:match[4]+label[1]], `"`))
+					}
+					if len(labels) >= kind.labels {
+						block = &types.Symbol{
+							Name:      kind.prefix + strings.Join(labels[:kind.labels], "."),
+							Type:      kind.symbolType,
+							Package:   module,
+							FilePath:  filePath,
+							StartLine: lineNumber,
+						}
+					}
+				}
+			}
+		} else if depth == 1 && inLocals && local == nil {
+			if match := hclAttributeRegex.FindStringSubmatchIndex(maskedLine); match != nil {
+				local = &types.Symbol{
+					Name:      "local." + line[match[2]:match[3]],
+					Type:      "local_decl",
+					Package:   module,
+					FilePath:  filePath,
+					StartLine: lineNumber,
+				}
+			}
+		}
+
+		symbols = append(symbols, hclReferences(filePath, module, line, lineNumber)...)
+
+		depth += strings.Count(maskedLine, "{") + strings.Count(maskedLine, "[") + strings.Count(maskedLine, "(")
+		depth -= strings.Count(maskedLine, "}") + strings.Count(maskedLine, "]") + strings.Count(maskedLine, ")")
+		depth = max(depth, 0)
+
+		if local != nil && depth <= 1 {
+			local.EndLine = lineNumber
+			symbols = append(symbols, *local)
+			local = nil
+		}
+		if depth == 0 {
+			if block != nil {
+				block.EndLine = lineNumber
+				symbols = append(symbols, *block)
+				block = nil
+			}
+			inLocals = false
+		}
+	}
+
+	return symbols, nil
+}
+
+// hclReferences returns the references of a line to variables, locals, modules, data
+// sources and resources
+func hclReferences(filePath, module, line string, lineNumber int) []types.Symbol {
+	var references []types.Symbol
+	add := func(name string) {
+		references = append(references, types.Symbol{
+			Name:      name,
+			Type:      "reference_usage",
+			Package:   module,
+			FilePath:  filePath,
+			StartLine: lineNumber,
+			EndLine:   lineNumber,
+		})
+	}
+
+	for _, reference := range hclNamedReferenceRegex.FindAllString(line, -1) {
+		add(reference)
+	}
+	for _, reference := range hclDataReferenceRegex.FindAllString(line, -1) {
+		add(reference)
+	}
+	for _, match := range hclResourceReferenceRegex.FindAllStringIndex(line, -1) {
+		// Attributes of other references, e.g. the type and name of data.aws_ami.ubuntu
+		if match[0] > 0 && line[match[0]-1] == '.' {
+			continue
+		}
+		add(line[match[0]:match[1]])
+	}
+	return references
+}
+
+// maskHCL blanks the comments of the HCL source, then also the content of the strings and
+// heredocs in a second copy, keeping the offsets and line breaks of both
+func maskHCL(source string) (withoutComments, masked string) {
+	commentFree := []byte(source)
+	structure := []byte(source)
+	blank := func(text []byte, from, to int) {
+		for i := from; i < to && i < len(text); i++ {
+			if text[i] != '\n' {
+				text[i] = ' '
+			}
+		}
+	}
+
+	for i := 0; i < len(source); i++ {
+		switch {
+		case source[i] == '#' || strings.HasPrefix(source[i:], "//"):
+			end := strings.IndexByte(source[i:], '\n')
+			if end < 0 {
+				end = len(source) - i
+			}
+			blank(commentFree, i, i+end)
+			blank(structure, i, i+end)
+			i += end
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				end = len(source) - i - 4
+			}
+			blank(commentFree, i, i+end+4)
+			blank(structure, i, i+end+4)
+			i += end + 3
+		case source[i] == '"':
+			end := i + 1
+			for end < len(source) && source[end] != '"' && source[end] != '\n' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			blank(structure, i+1, end)
+			i = end
+		case strings.HasPrefix(source[i:], "<<"):
+			marker := hclHeredocRegex.FindStringSubmatch(source[i:])
+			if marker == nil {
+				continue
+			}
+			bodyStart := i + len(marker[0])
+			end := len(source)
+			if closing := regexp.MustCompile(`(?m)^\s*` + regexp.QuoteMeta(marker[1]) + `\s*$`).FindStringIndex(source[bodyStart:]); closing != nil {
+				end = bodyStart + closing[0]
+			}
+			blank(structure, bodyStart, end)
+			i = end - 1
+		}
+	}
+	return string(commentFree), string(structure)
+}
+
+var hclHeredocRegex = regexp.MustCompile(`^<<-?([A-Za-z_]\w*)[ \t]*\n`)
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+const networkTF = `# Web tier { not a block
+resource "aws_security_group" "web" {
+  name   = "web-${var.env}"
+  vpc_id = module.vpc.vpc_id
+
+  ingress {
+    cidr_blocks = ["0.0.0.0/0"] // } not a brace
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+module "vpc" {
+  source = "./vpc"
+}
+
+variable "env" {
+  type = string
+}
+
+locals {
+  tags = {
+    Env = var.env
+  }
+  ami = data.aws_ami.ubuntu.id
+}
+
+resource "aws_instance" "web" {
+  ami                    = local.ami
+  vpc_security_group_ids = [aws_security_group.web.id]
+  user_data              = <<-EOT
+    echo "{"
+  EOT
+}
+
+output "instance_id" {
+  value = aws_instance.web.id
+}
+`
+
+func TestHclParser_ParseFile(t *testing.T) {
+	parser := NewHclParser()
+
+	symbols, err := parser.ParseFile("infra/network.tf", []byte(networkTF))
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	var got []string
+	for _, symbol := range symbols {
+		got = append(got, fmt.Sprintf("%s %s %d-%d", symbol.Type, symbol.Name, symbol.StartLine, symbol.EndLine))
+		if symbol.Package != "infra" {
+			t.Errorf("Expected the infra package for %s, got %q", symbol.Name, symbol.Package)
+		}
+	}
+
+	expected := []string{
+		"reference_usage var.env 3-3",
+		"reference_usage module.vpc 4-4",
+		"resource_decl aws_security_group.web 2-9",
+		"data_decl data.aws_ami.ubuntu 11-13",
+		"module_decl module.vpc 15-17",
+		"variable_decl var.env 19-21",
+		"reference_usage var.env 25-25",
+		"local_decl local.tags 24-26",
+		"reference_usage data.aws_ami.ubuntu 27-27",
+		"local_decl local.ami 27-27",
+		"reference_usage local.ami 31-31",
+		"reference_usage aws_security_group.web 32-32",
+		"resource_decl aws_instance.web 30-36",
+		"reference_usage aws_instance.web 39-39",
+		"output_decl output.instance_id 38-40",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Unexpected symbols:\n%v\nexpected:\n%v", got, expected)
+	}
+}
+
+func TestHclParser_Registered(t *testing.T) {
+	registry := NewParserRegistry()
+	for _, path := range []string{"infra/main.tf", "envs/prod.tfvars", "terragrunt.hcl"} {
+		if parser := registry.GetParser(path); parser == nil || parser.Language() != "HCL" {
+			t.Errorf("Expected the HCL parser for %s, got %v", path, parser)
+		}
+	}
+
+	parser := NewHclParser()
+	if !parser.ShouldExcludeFile("infra/.terraform/modules/vpc/main.tf", "") {
+		t.Error("Expected the .terraform directory to be excluded")
+	}
+	if !parser.IsTestFile("tests/network.tftest.hcl") {
+		t.Error("Expected .tftest.hcl files to be test files")
+	}
+}
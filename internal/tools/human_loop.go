@@ -2,6 +2,7 @@ package tools
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -30,7 +31,7 @@ func (t *HumanLoopTool) Schema() map[string]any {
 	}
 }
 
-func (t *HumanLoopTool) Execute(args map[string]any) (any, error) {
+func (t *HumanLoopTool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	question, ok := args["question"].(string)
 	if !ok || question == "" {
 		return nil, fmt.Errorf("question parameter is required and must be a string")
@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeIdentifier returns the NFC form of a symbol name. Editors may save non-ASCII
+// identifiers such as 测试 or café decomposed, normalizing lets the same name written in
+// either form be matched across files.
+func NormalizeIdentifier(name string) string {
+	return norm.NFC.String(strings.TrimSpace(name))
+}
+
+// identifierSearchForms returns the byte sequences a name can appear as in source files,
+// the composed form first
+func identifierSearchForms(name string) []string {
+	composed := norm.NFC.String(name)
+	decomposed := norm.NFD.String(composed)
+	if decomposed == composed {
+		return []string{composed}
+	}
+	return []string{composed, decomposed}
+}
+
+// truncateUTF8 cuts s to at most maxBytes without splitting a multi-byte character
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// "café" written with a combining acute accent, as some editors save it
+const decomposedCafe = "cafe\u0301"
+
+func TestNormalizeIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"ascii", "getUser", "getUser"},
+		{"cjk", "测试", "测试"},
+		{"decomposed", decomposedCafe, "café"},
+		{"surrounding space", " 测试\n", "测试"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeIdentifier(tt.input); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestIdentifierSearchForms(t *testing.T) {
+	if forms := identifierSearchForms("测试"); !slices.Equal(forms, []string{"测试"}) {
+		t.Errorf("Expected a single form for 测试, got %q", forms)
+	}
+	if forms := identifierSearchForms(decomposedCafe); !slices.Equal(forms, []string{"café", decomposedCafe}) {
+		t.Errorf("Expected composed and decomposed forms, got %q", forms)
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxBytes int
+		expected string
+	}{
+		{"shorter than limit", "测试", 10, "测试"},
+		{"ascii", "abcdef", 3, "abc"},
+		{"cut inside character", "测试", 4, "测"},
+		{"cut on boundary", "测试", 3, "测"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateUTF8(tt.input, tt.maxBytes); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParsers_NonASCIIIdentifiers(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{"main.go", "package main\n\nfunc 测试() {}\n\nfunc " + decomposedCafe + "() {\n\t测试()\n}\n"},
+		{"Main.java", "package demo;\n\npublic class Main {\n    void 测试() {}\n\n    void " + decomposedCafe + "() {\n        测试();\n    }\n}\n"},
+		{"main.ts", "function 测试() {}\n\nfunction " + decomposedCafe + "() {\n  测试();\n}\n"},
+	}
+
+	registry := NewParserRegistry()
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			symbols, err := registry.ParseFile(tt.file, []byte(tt.content))
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+
+			var declared, used []string
+			for _, s := range symbols {
+				if strings.HasSuffix(s.Type, "_decl") {
+					declared = append(declared, s.Name)
+				} else if IsUsageType(s.Type) {
+					used = append(used, s.Name)
+				}
+			}
+
+			for _, name := range []string{"测试", "café"} {
+				if !slices.Contains(declared, name) {
+					t.Errorf("Expected declaration of %q, got %q", name, declared)
+				}
+			}
+			if !slices.Contains(used, "测试") {
+				t.Errorf("Expected usage of 测试, got %q", used)
+			}
+		})
+	}
+}
+
+func TestSymbolContextGatherer_NonASCIISymbol(t *testing.T) {
+	tempDir := t.TempDir()
+	runGitCmd(t, tempDir, "git", "init")
+
+	writeGitFile(t, tempDir, "greet.go", "package main\n\nfunc "+decomposedCafe+"() string {\n\treturn \"☕\"\n}\n")
+	writeGitFile(t, tempDir, "main.go", "package main\n\nfunc main() {\n\tcafé()\n}\n")
+	runGitCmd(t, tempDir, "git", "add", ".")
+	runGitCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+
+	gatherer := NewSymbolContextGatherer(NewParserRegistry())
+
+	files, err := gatherer.findCandidateFiles(types.Symbol{Name: "café"}, tempDir, "go")
+	if err != nil {
+		t.Fatalf("findCandidateFiles failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("Expected both spellings to be found, got %v", files)
+	}
+
+	context, _, _, err := gatherer.gatherContextWithRefs(types.Symbol{Name: decomposedCafe}, tempDir, "go")
+	if err != nil {
+		t.Fatalf("gatherContextWithRefs failed: %v", err)
+	}
+	if !strings.Contains(context, "Definition in "+filepath.Join(tempDir, "greet.go")) {
+		t.Errorf("Expected the definition in greet.go, got:\n%s", context)
+	}
+	if !strings.Contains(context, "Usage in "+filepath.Join(tempDir, "main.go")) {
+		t.Errorf("Expected the usage in main.go, got:\n%s", context)
+	}
+}
+
+func TestGitGrepTool_Execute_NonASCIIPattern(t *testing.T) {
+	tempDir := t.TempDir()
+	runGitCmd(t, tempDir, "git", "init")
+	writeGitFile(t, tempDir, "greet.go", "package main\n\nfunc "+decomposedCafe+"() {}\n\nfunc 测试() {}\n")
+	runGitCmd(t, tempDir, "git", "add", ".")
+	runGitCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+
+	t.Chdir(tempDir)
+	tool := &GitGrepTool{}
+
+	for _, pattern := range []string{"测试", "café"} {
+		result, err := tool.Execute(context.Background(), map[string]any{"pattern": pattern})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if !strings.Contains(result.(types.GrepResult).String(), "greet.go:") {
+			t.Errorf("Expected a match for %q, got: %s", pattern, result)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// implementationMaxSiblings bounds the sibling implementations shown for each relation
+const implementationMaxSiblings = 5
+
+// ImplementationResolver is implemented by the parsers of languages with interfaces or
+// inheritance, to relate a changed method to the methods it implements or overrides
+type ImplementationResolver interface {
+	// TypeDeclarations lists the types declared in the file with their methods
+	TypeDeclarations(filePath string, content []byte) ([]types.TypeDeclaration, error)
+	// Implements reports whether typ, declaring the given methods, implements or extends super
+	Implements(typ types.TypeDeclaration, methods []string, super types.TypeDeclaration) bool
+}
+
+// GatherImplementations shows the interface methods a changed method implements, the
+// superclass methods it overrides and the sibling implementations of the same method
+func (g *SymbolContextGatherer) GatherImplementations(symbol types.Symbol, projectRoot, primaryLanguage string) (string, error) {
+	resolver, ok := g.parserRegistry.GetParser(symbol.FilePath).(ImplementationResolver)
+	if !ok {
+		return "", nil
+	}
+	index := &typeIndex{resolver: resolver, registry: g.parserRegistry, files: make(map[string][]types.TypeDeclaration)}
+
+	owner, found := index.methodOwner(symbol)
+	if !found {
+		return "", nil
+	}
+
+	candidateFiles, err := g.findCandidateFiles(symbol, projectRoot, primaryLanguage)
+	if err != nil {
+		return "", fmt.Errorf("failed to find candidate files for symbol %s: %w", symbol.Name, err)
+	}
+	var declaring []types.TypeDeclaration
+	for _, file := range append(candidateFiles, symbol.FilePath) {
+		for _, decl := range index.types(file) {
+			if declaresMethod(decl, symbol.Name) && !sameType(decl, owner) && !containsType(declaring, decl) {
+				declaring = append(declaring, decl)
+			}
+		}
+	}
+
+	var contextBuilder strings.Builder
+	for _, super := range declaring {
+		if !resolver.Implements(owner, index.methodSet(owner), super) {
+			continue
+		}
+
+		verb := "Overrides"
+		if super.Kind == "interface" {
+			verb = "Implements"
+		}
+		contextBuilder.WriteString(fmt.Sprintf("%s %s.%s\n", verb, super.Name, symbol.Name))
+		index.writeMethod(&contextBuilder, projectRoot, super, symbol.Name)
+
+		siblings := 0
+		for _, sibling := range declaring {
+			if siblings == implementationMaxSiblings {
+				break
+			}
+			if sameType(sibling, super) || !resolver.Implements(sibling, index.methodSet(sibling), super) {
+				continue
+			}
+			siblings++
+			contextBuilder.WriteString(fmt.Sprintf("Sibling implementation %s.%s\n", sibling.Name, symbol.Name))
+			index.writeMethod(&contextBuilder, projectRoot, sibling, symbol.Name)
+		}
+	}
+
+	return contextBuilder.String(), nil
+}
+
+// typeIndex caches the type declarations of the files read while resolving a method
+type typeIndex struct {
+	resolver ImplementationResolver
+	registry *ParserRegistry
+	files    map[string][]types.TypeDeclaration
+}
+
+func (idx *typeIndex) types(filePath string) []types.TypeDeclaration {
+	if declarations, ok := idx.files[filePath]; ok {
+		return declarations
+	}
+
+	var declarations []types.TypeDeclaration
+	if parser, ok := idx.registry.GetParser(filePath).(ImplementationResolver); ok && parser == idx.resolver {
+		if content, err := os.ReadFile(filePath); err == nil {
+			declarations, _ = idx.resolver.TypeDeclarations(filePath, content)
+		}
+	}
+	idx.files[filePath] = declarations
+	return declarations
+}
+
+// methodOwner returns the type declaring the method at the symbol's position
+func (idx *typeIndex) methodOwner(symbol types.Symbol) (types.TypeDeclaration, bool) {
+	for _, decl := range idx.types(symbol.FilePath) {
+		for _, method := range decl.Methods {
+			if method.Name == symbol.Name && symbol.StartLine >= method.StartLine && symbol.StartLine <= method.EndLine {
+				return decl, true
+			}
+		}
+	}
+	return types.TypeDeclaration{}, false
+}
+
+// methodSet returns the method names of a type. Go methods may be declared in any file of
+// the package, so the declarations of the type in the whole directory are merged.
+func (idx *typeIndex) methodSet(typ types.TypeDeclaration) []string {
+	var methods []string
+	dir := filepath.Dir(typ.FilePath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return methodNames(typ)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		for _, decl := range idx.types(filepath.Join(dir, entry.Name())) {
+			if decl.Name == typ.Name && decl.Kind != "interface" {
+				methods = append(methods, methodNames(decl)...)
+			}
+		}
+	}
+	if len(methods) == 0 {
+		return methodNames(typ)
+	}
+	return methods
+}
+
+func (idx *typeIndex) writeMethod(contextBuilder *strings.Builder, projectRoot string, typ types.TypeDeclaration, name string) {
+	content, err := os.ReadFile(typ.FilePath)
+	if err != nil {
+		return
+	}
+
+	for _, method := range typ.Methods {
+		if method.Name == name {
+			contextBuilder.WriteString(fmt.Sprintf(">>>>>> In %s (line %d):\n", relativePath(projectRoot, typ.FilePath), method.StartLine))
+			contextBuilder.WriteString(extractSnippet(content, method.StartLine, method.EndLine))
+			contextBuilder.WriteString("\n")
+			return
+		}
+	}
+}
+
+func methodNames(typ types.TypeDeclaration) []string {
+	names := make([]string, len(typ.Methods))
+	for i, method := range typ.Methods {
+		names[i] = method.Name
+	}
+	return names
+}
+
+func declaresMethod(typ types.TypeDeclaration, name string) bool {
+	for _, method := range typ.Methods {
+		if method.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sameType(a, b types.TypeDeclaration) bool {
+	return a.Name == b.Name && a.FilePath == b.FilePath
+}
+
+func containsType(declarations []types.TypeDeclaration, typ types.TypeDeclaration) bool {
+	for _, decl := range declarations {
+		if sameType(decl, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// implementationContext adds the implemented and overridden methods of the changed methods
+func (t *SymbolContextTool) implementationContext(affectedSymbols []types.SymbolUsage, primaryLanguage string) {
+	for i := range affectedSymbols {
+		if affectedSymbols[i].Symbol.Type != "method_decl" {
+			continue
+		}
+
+		relations, err := t.gatherer.GatherImplementations(affectedSymbols[i].Symbol, t.projectRoot, primaryLanguage)
+		if err != nil || relations == "" {
+			continue
+		}
+
+		affectedSymbols[i].Snippets += fmt.Sprintf(">>>>> Implements/Overrides: %s\n%s", affectedSymbols[i].Symbol.Name, relations)
+	}
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestGoParser_TypeDeclarations(t *testing.T) {
+	parser, _ := NewGoParser()
+	content := []byte(`package store
+
+type Repository interface {
+	io.Closer
+	Save(id string) error
+}
+
+type DiskStore struct{}
+
+func (s *DiskStore) Save(id string) error { return nil }
+
+func (s Remote[T]) Load() {}
+`)
+
+	declarations, err := parser.TypeDeclarations("store.go", content)
+	if err != nil {
+		t.Fatalf("TypeDeclarations failed: %v", err)
+	}
+	if len(declarations) != 3 {
+		t.Fatalf("Expected 3 declarations, got %+v", declarations)
+	}
+
+	repository := declarations[0]
+	if repository.Kind != "interface" || len(repository.Methods) != 1 || repository.Methods[0].Name != "Save" {
+		t.Errorf("Unexpected interface: %+v", repository)
+	}
+	if len(repository.Supertypes) != 1 || repository.Supertypes[0] != "Closer" {
+		t.Errorf("Expected the embedded interface, got %v", repository.Supertypes)
+	}
+	if store := declarations[1]; store.Kind != "struct" || len(store.Methods) != 1 || store.Methods[0].StartLine != 10 {
+		t.Errorf("Expected Save attached to DiskStore, got %+v", store)
+	}
+	if remote := declarations[2]; remote.Name != "Remote" || remote.StartLine != 0 || remote.Methods[0].Name != "Load" {
+		t.Errorf("Expected a receiver-only declaration for Remote, got %+v", remote)
+	}
+}
+
+func TestJavaParser_TypeDeclarations(t *testing.T) {
+	parser, _ := NewJavaParser()
+	content := []byte(`class DiskStore extends BaseStore<String> implements Repository, io.Closeable {
+    void save() {}
+    class Inner implements Runnable { public void run() {} }
+}
+enum Mode implements Labeled {
+    A, B;
+    String label() { return ""; }
+}
+`)
+
+	declarations, err := parser.TypeDeclarations("DiskStore.java", content)
+	if err != nil {
+		t.Fatalf("TypeDeclarations failed: %v", err)
+	}
+
+	byName := make(map[string]types.TypeDeclaration)
+	for _, decl := range declarations {
+		byName[decl.Name] = decl
+	}
+	if got := strings.Join(byName["DiskStore"].Supertypes, ","); got != "BaseStore,Repository,Closeable" {
+		t.Errorf("Unexpected supertypes: %s", got)
+	}
+	if methods := byName["DiskStore"].Methods; len(methods) != 1 || methods[0].Name != "save" {
+		t.Errorf("Expected only save on DiskStore, got %+v", methods)
+	}
+	if methods := byName["Inner"].Methods; len(methods) != 1 || methods[0].Name != "run" {
+		t.Errorf("Expected the nested class, got %+v", byName["Inner"])
+	}
+	if methods := byName["Mode"].Methods; len(methods) != 1 || methods[0].Name != "label" {
+		t.Errorf("Expected the enum method, got %+v", byName["Mode"])
+	}
+}
+
+func TestSymbolContextGatherer_GatherImplementations_Go(t *testing.T) {
+	tempDir := t.TempDir()
+	runGitCmd(t, tempDir, "git", "init")
+	for _, dir := range []string{"store", "mem", "cache"} {
+		if err := os.Mkdir(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	writeGitFile(t, tempDir, "store/repository.go", "package store\n\ntype Repository interface {\n\tSave(id string) error\n\tClose() error\n}\n")
+	writeGitFile(t, tempDir, "store/disk.go", "package store\n\ntype DiskStore struct{}\n\nfunc (s *DiskStore) Save(id string) error {\n\treturn nil\n}\n")
+	// Close is declared in another file of the package
+	writeGitFile(t, tempDir, "store/disk_close.go", "package store\n\nfunc (s *DiskStore) Close() error { return nil }\n")
+	writeGitFile(t, tempDir, "mem/mem.go", "package mem\n\ntype MemStore struct{}\n\nfunc (m MemStore) Save(id string) error { return nil }\nfunc (m MemStore) Close() error { return nil }\n")
+	// Declares Save but not Close, so it is no Repository
+	writeGitFile(t, tempDir, "cache/cache.go", "package cache\n\ntype Cache struct{}\n\nfunc (c Cache) Save(id string) error { return nil }\n")
+	runGitCmd(t, tempDir, "git", "add", ".")
+	runGitCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+
+	gatherer := NewSymbolContextGatherer(NewParserRegistry())
+	symbol := types.Symbol{Name: "Save", Type: "method_decl", FilePath: filepath.Join(tempDir, "store/disk.go"), StartLine: 5, EndLine: 7}
+
+	relations, err := gatherer.GatherImplementations(symbol, tempDir, "go")
+	if err != nil {
+		t.Fatalf("GatherImplementations failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"Implements Repository.Save\n>>>>>> In store/repository.go (line 4):",
+		"Sibling implementation MemStore.Save\n>>>>>> In mem/mem.go (line 5):",
+	} {
+		if !strings.Contains(relations, expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, relations)
+		}
+	}
+	if strings.Contains(relations, "Cache") {
+		t.Errorf("Cache does not implement Repository, got:\n%s", relations)
+	}
+}
+
+func TestSymbolContextGatherer_GatherImplementations_Java(t *testing.T) {
+	tempDir := t.TempDir()
+	runGitCmd(t, tempDir, "git", "init")
+	if err := os.Mkdir(filepath.Join(tempDir, "src"), 0755); err != nil {
+		t.Fatalf("Failed to create src: %v", err)
+	}
+	writeGitFile(t, tempDir, "src/Repository.java", "interface Repository {\n    void save(String id);\n}\n")
+	writeGitFile(t, tempDir, "src/BaseStore.java", "abstract class BaseStore {\n    void save(String id) {}\n}\n")
+	writeGitFile(t, tempDir, "src/DiskStore.java", "class DiskStore extends BaseStore implements Repository {\n    @Override\n    void save(String id) {\n        write(id);\n    }\n}\n")
+	writeGitFile(t, tempDir, "src/MemStore.java", "class MemStore implements Repository {\n    public void save(String id) {}\n}\n")
+	runGitCmd(t, tempDir, "git", "add", ".")
+	runGitCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+
+	gatherer := NewSymbolContextGatherer(NewParserRegistry())
+	symbol := types.Symbol{Name: "save", Type: "method_decl", FilePath: filepath.Join(tempDir, "src/DiskStore.java"), StartLine: 2, EndLine: 5}
+
+	relations, err := gatherer.GatherImplementations(symbol, tempDir, "java")
+	if err != nil {
+		t.Fatalf("GatherImplementations failed: %v", err)
+	}
+
+	for _, expected := range []string{"Implements Repository.save", "Overrides BaseStore.save", "Sibling implementation MemStore.save"} {
+		if !strings.Contains(relations, expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, relations)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -171,7 +172,7 @@ func TestGatherEnhancedContext(t *testing.T) {
 			args["diffData"] = diffData
 			args["primaryLanguage"] = tc.language
 
-			result, err := symbolContextTool.Execute(args)
+			result, err := symbolContextTool.Execute(context.Background(), args)
 			require.NoError(t, err, "Tool execution should not fail")
 
 			resultData, ok := result.(types.DiffData)
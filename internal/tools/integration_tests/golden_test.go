@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden context files with the current output")
+
+// TestSymbolContextGolden compares the full context produced for each fixture diff with
+// its golden file. Run with -update after an intended change and review the golden diff.
+func TestSymbolContextGolden(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	projectRoot := wd
+
+	testCases := []struct {
+		name        string
+		language    string
+		diffFile    string
+		changedFile string
+	}{
+		{"go_func_decl", "go", "diff/go_func_decl.diff", "code_samples/go/utils/user_service.go"},
+		{"java_method_decl", "java", "diff/java_method_decl.diff", "code_samples/java/service/UserService.java"},
+		{"typescript_method_decl", "typescript", "diff/typescript_method_decl.diff", "code_samples/typescript/services/userService.ts"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			symbolContextTool := tools.NewSymbolContextTool(projectRoot, tools.NewParserRegistry())
+
+			diff, err := os.ReadFile(filepath.Join(projectRoot, tc.diffFile))
+			require.NoError(t, err, "Failed to read diff file")
+
+			result, err := symbolContextTool.Execute(context.Background(), map[string]any{
+				"diffData": types.DiffData{
+					AbsolutePath: filepath.Join(projectRoot, tc.changedFile),
+					Diff:         string(diff),
+				},
+				"primaryLanguage": tc.language,
+			})
+			require.NoError(t, err, "Tool execution should not fail")
+
+			resultData, ok := result.(types.DiffData)
+			require.True(t, ok, "Tool result should be of type types.DiffData")
+
+			actual := renderContext(resultData, projectRoot)
+			goldenPath := filepath.Join(projectRoot, "golden", tc.name+".golden")
+
+			if *update {
+				require.NoError(t, os.WriteFile(goldenPath, []byte(actual), 0644))
+				return
+			}
+
+			expected, err := os.ReadFile(goldenPath)
+			require.NoError(t, err, "Missing golden file, run the test with -update to create it")
+			assert.Equal(t, string(expected), actual, "Context differs from %s, run with -update if the change is intended", goldenPath)
+		})
+	}
+}
+
+// renderContext writes the diff context and affected symbols in a stable, reviewable form
+// with paths relative to the fixture root
+func renderContext(data types.DiffData, projectRoot string) string {
+	var builder strings.Builder
+	builder.WriteString("=== DiffContext ===\n")
+	builder.WriteString(data.DiffContext)
+	builder.WriteString("\n")
+
+	for _, affected := range data.AffectedSymbols {
+		symbol := affected.Symbol
+		builder.WriteString(fmt.Sprintf("=== AffectedSymbol: %s (type: %s, package: %s, lines %d-%d, usages: %d) ===\n",
+			symbol.Name, symbol.Type, symbol.Package, symbol.StartLine, symbol.EndLine, affected.Usages))
+		builder.WriteString(affected.Snippets)
+		builder.WriteString("\n")
+	}
+
+	return strings.ReplaceAll(builder.String(), projectRoot+string(filepath.Separator), "")
+}
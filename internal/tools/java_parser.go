@@ -2,8 +2,11 @@ package tools
 
 import (
 	"fmt"
+	"path"
+	"slices"
 	"strings"
 
+	"github.com/agusespa/diffpector/internal/platform"
 	"github.com/agusespa/diffpector/internal/types"
 	sitter "github.com/tree-sitter/go-tree-sitter"
 	tree_sitter_java "github.com/tree-sitter/tree-sitter-java/bindings/go"
@@ -43,7 +46,7 @@ func (jp *JavaParser) SupportedExtensions() []string {
 }
 
 func (jp *JavaParser) ShouldExcludeFile(filePath, projectRoot string) bool {
-	lowerPath := strings.ToLower(filePath)
+	lowerPath := strings.ToLower(platform.NormalizePath(filePath))
 
 	javaExcludePatterns := []string{
 		"test/",
@@ -62,13 +65,40 @@ func (jp *JavaParser) ShouldExcludeFile(filePath, projectRoot string) bool {
 	return false
 }
 
+func (jp *JavaParser) IsTestFile(filePath string) bool {
+	slashPath := platform.NormalizePath(filePath)
+	for _, dir := range strings.Split(strings.ToLower(path.Dir(slashPath)), "/") {
+		if dir == "test" || dir == "tests" {
+			return true
+		}
+	}
+
+	name := strings.TrimSuffix(path.Base(slashPath), ".java")
+	return strings.HasSuffix(name, "Test") || strings.HasSuffix(name, "Tests") || strings.HasSuffix(name, "IT")
+}
+
 func (jp *JavaParser) ParseFile(filePath string, content []byte) ([]types.Symbol, error) {
-	tree := jp.parser.Parse(content, nil)
+	tree, err := jp.ParseTree(filePath, content, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	return jp.ExtractSymbols(filePath, tree, content)
+}
+
+// ParseTree parses the file, reusing the unchanged parts of oldTree when it is not nil.
+// The caller closes the returned tree.
+func (jp *JavaParser) ParseTree(filePath string, content []byte, oldTree *sitter.Tree) (*sitter.Tree, error) {
+	tree := jp.parser.Parse(content, oldTree)
 	if tree == nil {
 		return nil, fmt.Errorf("failed to parse Java file")
 	}
-	defer tree.Close()
+	return tree, nil
+}
 
+// ExtractSymbols collects the declarations and usages of a parsed file
+func (jp *JavaParser) ExtractSymbols(filePath string, tree *sitter.Tree, content []byte) ([]types.Symbol, error) {
 	packageName := jp.extractPackageName(tree.RootNode(), content)
 
 	queryText := `
@@ -120,6 +150,7 @@ func (jp *JavaParser) ParseFile(filePath string, content []byte) ([]types.Symbol
 				name = strings.TrimSpace(c.Node.Utf8Text(content))
 			}
 
+			name = NormalizeIdentifier(name)
 			if name == "" {
 				continue
 			}
@@ -185,3 +216,109 @@ func (jp *JavaParser) extractPackageName(rootNode *sitter.Node, sourceBytes []by
 	}
 	return ""
 }
+
+// javaTypeKinds maps the Java type declarations to TypeDeclaration kinds
+var javaTypeKinds = map[string]string{
+	"class_declaration":     "class",
+	"enum_declaration":      "class",
+	"record_declaration":    "class",
+	"interface_declaration": "interface",
+}
+
+// TypeDeclarations lists the classes, interfaces, enums and records of the file, nested ones
+// included, with their declared methods and supertypes
+func (jp *JavaParser) TypeDeclarations(filePath string, content []byte) ([]types.TypeDeclaration, error) {
+	tree, err := jp.ParseTree(filePath, content, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	var declarations []types.TypeDeclaration
+	jp.collectTypes(tree.RootNode(), filePath, content, &declarations)
+	return declarations, nil
+}
+
+func (jp *JavaParser) collectTypes(node *sitter.Node, filePath string, content []byte, declarations *[]types.TypeDeclaration) {
+	for i := uint(0); i < node.NamedChildCount(); i++ {
+		child := node.NamedChild(i)
+
+		kind, isType := javaTypeKinds[child.Kind()]
+		if !isType {
+			jp.collectTypes(child, filePath, content, declarations)
+			continue
+		}
+
+		nameNode := child.ChildByFieldName("name")
+		if nameNode == nil {
+			continue
+		}
+		decl := types.TypeDeclaration{
+			Name:      nameNode.Utf8Text(content),
+			Kind:      kind,
+			FilePath:  filePath,
+			StartLine: int(child.StartPosition().Row) + 1,
+			EndLine:   int(child.EndPosition().Row) + 1,
+		}
+
+		body := child.ChildByFieldName("body")
+		for j := uint(0); j < child.NamedChildCount(); j++ {
+			part := child.NamedChild(j)
+			switch part.Kind() {
+			case "superclass":
+				if part.NamedChildCount() > 0 {
+					decl.Supertypes = appendTypeName(decl.Supertypes, part.NamedChild(0), content)
+				}
+			case "super_interfaces", "extends_interfaces":
+				if list := part.NamedChild(0); list != nil {
+					for k := uint(0); k < list.NamedChildCount(); k++ {
+						decl.Supertypes = appendTypeName(decl.Supertypes, list.NamedChild(k), content)
+					}
+				}
+			}
+		}
+
+		if body != nil {
+			decl.Methods = javaMethods(body, content)
+		}
+
+		*declarations = append(*declarations, decl)
+		if body != nil {
+			jp.collectTypes(body, filePath, content, declarations)
+		}
+	}
+}
+
+// javaMethods returns the methods declared directly in a type body
+func javaMethods(body *sitter.Node, content []byte) []types.MethodDeclaration {
+	var methods []types.MethodDeclaration
+	for i := uint(0); i < body.NamedChildCount(); i++ {
+		member := body.NamedChild(i)
+		switch member.Kind() {
+		case "method_declaration":
+			if name := member.ChildByFieldName("name"); name != nil {
+				methods = append(methods, types.MethodDeclaration{
+					Name:      name.Utf8Text(content),
+					StartLine: int(member.StartPosition().Row) + 1,
+					EndLine:   int(member.EndPosition().Row) + 1,
+				})
+			}
+		case "enum_body_declarations":
+			// Enum methods follow the constants
+			methods = append(methods, javaMethods(member, content)...)
+		}
+	}
+	return methods
+}
+
+func appendTypeName(names []string, node *sitter.Node, content []byte) []string {
+	if name := typeNodeName(node, content); name != "" {
+		return append(names, name)
+	}
+	return names
+}
+
+// Implements reports whether typ extends or implements super, Java requiring it to be declared
+func (jp *JavaParser) Implements(typ types.TypeDeclaration, methods []string, super types.TypeDeclaration) bool {
+	return slices.Contains(typ.Supertypes, super.Name)
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/mcp"
+)
+
+// MCPTool offers a tool of an MCP server to the model, named after the server and the tool
+// so tools of different servers do not clash
+type MCPTool struct {
+	client      *mcp.Client
+	name        string
+	remoteName  string
+	description string
+	schema      map[string]any
+}
+
+// NewMCPTools wraps the tools of an initialized client, only the allowed ones when the list
+// is not empty
+func NewMCPTools(client *mcp.Client, allowed []string) ([]*MCPTool, error) {
+	remoteTools, err := client.ListTools()
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapped []*MCPTool
+	for _, remote := range remoteTools {
+		if len(allowed) > 0 && !slices.Contains(allowed, remote.Name) {
+			continue
+		}
+
+		schema := remote.InputSchema
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		wrapped = append(wrapped, &MCPTool{
+			client:      client,
+			name:        mcpToolName(client.Name(), remote.Name),
+			remoteName:  remote.Name,
+			description: remote.Description,
+			schema:      schema,
+		})
+	}
+	return wrapped, nil
+}
+
+// mcpToolName joins the server and tool names into a function name providers accept:
+// letters, digits, _ and -, at most 64 characters
+func mcpToolName(server, tool string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 128 && (r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, server+"_"+tool)
+	return truncateUTF8(name, 64)
+}
+
+func (t *MCPTool) Name() string {
+	return t.name
+}
+
+func (t *MCPTool) Description() string {
+	return t.description
+}
+
+func (t *MCPTool) Schema() map[string]any {
+	return t.schema
+}
+
+func (t *MCPTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	// The client bounds its requests with its own timeout
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	result, err := t.client.CallTool(t.remoteName, args)
+	if err != nil {
+		return "", err
+	}
+
+	text := result.Text()
+	if len(text) > maxPluginOutput {
+		text = truncateUTF8(text, maxPluginOutput) + "\n... (truncated)"
+	}
+	if result.IsError {
+		return "", fmt.Errorf("%s failed: %s", t.remoteName, text)
+	}
+	return text, nil
+}
+
+// Close ends the session with the server, shared by all the tools of the server
+func (t *MCPTool) Close() error {
+	return t.client.Close()
+}
@@ -0,0 +1,24 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMCPToolName(t *testing.T) {
+	tests := []struct {
+		server   string
+		tool     string
+		expected string
+	}{
+		{"jira", "get_issue", "jira_get_issue"},
+		{"docs.internal", "search docs", "docs_internal_search_docs"},
+		{"db", strings.Repeat("x", 80), "db_" + strings.Repeat("x", 61)},
+	}
+
+	for _, tt := range tests {
+		if got := mcpToolName(tt.server, tt.tool); got != tt.expected {
+			t.Errorf("mcpToolName(%q, %q) = %q, expected %q", tt.server, tt.tool, got, tt.expected)
+		}
+	}
+}
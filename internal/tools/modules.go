@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/agusespa/diffpector/internal/platform"
+)
+
+// moduleMarkers are the files marking the root of a module of a monorepo: a Go module, an npm
+// package, such as the ones of a workspace, or a Bazel package
+var moduleMarkers = []string{"go.mod", "package.json", "BUILD.bazel", "BUILD"}
+
+// moduleIndex finds the module owning a file, caching the module of every directory looked up
+type moduleIndex struct {
+	mu   sync.Mutex
+	dirs map[string]string
+}
+
+func newModuleIndex() *moduleIndex {
+	return &moduleIndex{dirs: make(map[string]string)}
+}
+
+// owningModule returns the directory of the nearest module enclosing filePath, slash
+// separated and relative to projectRoot, "." when the file belongs to no module below the
+// root. Files outside of projectRoot keep the module directory as is.
+func (m *moduleIndex) owningModule(projectRoot, filePath string) string {
+	root, err := filepath.Abs(projectRoot)
+	if err != nil {
+		return "."
+	}
+	if !filepath.IsAbs(filePath) {
+		filePath = platform.JoinPath(root, filePath)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir := filepath.Dir(filePath)
+	var visited []string
+	module := ""
+	for {
+		if cached, ok := m.dirs[dir]; ok {
+			module = cached
+			break
+		}
+		visited = append(visited, dir)
+		if dir == root || hasModuleMarker(dir) {
+			module = moduleName(root, dir)
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			module = moduleName(root, dir)
+			break
+		}
+		dir = parent
+	}
+
+	for _, visitedDir := range visited {
+		m.dirs[visitedDir] = module
+	}
+	return module
+}
+
+func hasModuleMarker(dir string) bool {
+	for _, marker := range moduleMarkers {
+		// BUILD may be a build directory on case-insensitive file systems
+		if info, err := os.Stat(filepath.Join(dir, marker)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+func moduleName(root, dir string) string {
+	rel, err := platform.RelPath(root, dir)
+	if err != nil || platform.IsOutside(rel) {
+		return platform.NormalizePath(dir)
+	}
+	return rel
+}
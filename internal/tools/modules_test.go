@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestModuleIndex_OwningModule(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                       "module example.com/mono\n",
+		"services/billing/go.mod":      "module example.com/billing\n",
+		"web/package.json":             `{"name": "web"}`,
+		"tools/lint/BUILD.bazel":       "",
+		"services/billing/api/http.go": "package api\n",
+		"web/src/app.ts":               "",
+		"tools/lint/main.go":           "package main\n",
+		"docs/build/index.md":          "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A build directory is no Bazel package, even where it matches BUILD
+	if err := os.MkdirAll(filepath.Join(root, "docs", "BUILD"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{"services/billing/api/http.go", "services/billing"},
+		{filepath.Join(root, "web", "src", "app.ts"), "web"},
+		{"tools/lint/main.go", "tools/lint"},
+		{"docs/build/index.md", "."},
+		{"main.go", "."},
+	}
+
+	modules := newModuleIndex()
+	for _, tt := range tests {
+		if module := modules.owningModule(root, tt.file); module != tt.expected {
+			t.Errorf("Expected %s in module %s, got %s", tt.file, tt.expected, module)
+		}
+	}
+}
+
+func TestSymbolContextGatherer_CrossModuleUsages(t *testing.T) {
+	root := t.TempDir()
+	runGitCmd(t, root, "git", "init")
+	for _, dir := range []string{"lib", "app"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeGitFile(t, root, "lib/go.mod", "module example.com/lib\n")
+	writeGitFile(t, root, "lib/total.go", "package lib\n\nfunc Total(a, b int) int {\n\treturn a + b\n}\n")
+	writeGitFile(t, root, "lib/report.go", "package lib\n\nfunc Report() int {\n\treturn Total(1, 2)\n}\n")
+	writeGitFile(t, root, "app/go.mod", "module example.com/app\n")
+	writeGitFile(t, root, "app/main.go", "package main\n\nfunc main() {\n\tlib.Total(3, 4)\n}\n")
+	runGitCmd(t, root, "git", "add", ".")
+
+	gatherer := NewSymbolContextGatherer(NewParserRegistry())
+	symbols := []types.SymbolUsage{{Symbol: types.Symbol{Name: "Total", FilePath: filepath.Join(root, "lib", "total.go")}}}
+	if err := gatherer.GatherSymbolContext(symbols, root, "go"); err != nil {
+		t.Fatalf("GatherSymbolContext failed: %v", err)
+	}
+
+	snippets := symbols[0].Snippets
+	if !strings.Contains(snippets, "(line 4, module app):") {
+		t.Errorf("Expected the usage in app labeled with its module, got:\n%s", snippets)
+	}
+	if !strings.Contains(snippets, "Used from other modules: app") {
+		t.Errorf("Expected the other modules listed, got:\n%s", snippets)
+	}
+	own := strings.Index(snippets, filepath.Join("lib", "report.go"))
+	other := strings.Index(snippets, filepath.Join("app", "main.go"))
+	if own == -1 || other == -1 || own > other {
+		t.Errorf("Expected the usages of the changed module first, got:\n%s", snippets)
+	}
+	if strings.Contains(snippets, "module lib") {
+		t.Errorf("Expected no label on the changed module, got:\n%s", snippets)
+	}
+}
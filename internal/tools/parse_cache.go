@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"slices"
+
+	"github.com/agusespa/diffpector/internal/types"
+	sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// maxCachedFiles bounds the parse trees kept in memory, the oldest are evicted first
+const maxCachedFiles = 256
+
+// IncrementalParser is implemented by the parsers that can reparse a new version of a file
+// reusing the tree of the previous one
+type IncrementalParser interface {
+	// ParseTree parses the file, reusing the unchanged parts of oldTree when it is not nil
+	ParseTree(filePath string, content []byte, oldTree *sitter.Tree) (*sitter.Tree, error)
+	// ExtractSymbols collects the symbols of a parsed file
+	ExtractSymbols(filePath string, tree *sitter.Tree, content []byte) ([]types.Symbol, error)
+}
+
+type parseEntry struct {
+	hash    [sha256.Size]byte
+	content []byte
+	tree    *sitter.Tree
+	symbols []types.Symbol
+}
+
+// parseCache keeps the symbols and the parse tree of the last parsed version of each file.
+// Context gathering parses the same files once per affected symbol, unchanged content is
+// answered from the cache and changed content is reparsed incrementally.
+type parseCache struct {
+	entries map[string]*parseEntry
+	// order lists the cached paths, least recently stored first
+	order []string
+
+	hits        int
+	misses      int
+	incremental int
+}
+
+func newParseCache() *parseCache {
+	return &parseCache{entries: make(map[string]*parseEntry)}
+}
+
+func (c *parseCache) get(filePath string) *parseEntry {
+	return c.entries[filePath]
+}
+
+func (c *parseCache) put(filePath string, entry *parseEntry) {
+	c.remove(filePath)
+
+	if len(c.order) == maxCachedFiles {
+		c.remove(c.order[0])
+	}
+	c.entries[filePath] = entry
+	c.order = append(c.order, filePath)
+}
+
+func (c *parseCache) remove(filePath string) {
+	entry, ok := c.entries[filePath]
+	if !ok {
+		return
+	}
+
+	entry.tree.Close()
+	delete(c.entries, filePath)
+	c.order = slices.DeleteFunc(c.order, func(path string) bool { return path == filePath })
+}
+
+// parse returns the symbols of the file, from the cache when its content did not change
+func (c *parseCache) parse(parser LanguageParser, filePath string, content []byte) ([]types.Symbol, error) {
+	hash := sha256.Sum256(content)
+	entry := c.get(filePath)
+	if entry != nil && entry.hash == hash {
+		c.hits++
+		return slices.Clone(entry.symbols), nil
+	}
+	c.misses++
+
+	incremental, ok := parser.(IncrementalParser)
+	if !ok {
+		symbols, err := parser.ParseFile(filePath, content)
+		if err != nil {
+			return nil, err
+		}
+		c.put(filePath, &parseEntry{hash: hash, symbols: symbols})
+		return slices.Clone(symbols), nil
+	}
+
+	var oldTree *sitter.Tree
+	if entry != nil && entry.tree != nil {
+		edit := contentEdit(entry.content, content)
+		entry.tree.Edit(&edit)
+		oldTree = entry.tree
+		c.incremental++
+	}
+
+	tree, err := incremental.ParseTree(filePath, content, oldTree)
+	if err != nil {
+		// The edited tree no longer matches the cached content
+		c.remove(filePath)
+		return nil, err
+	}
+
+	symbols, err := incremental.ExtractSymbols(filePath, tree, content)
+	if err != nil {
+		tree.Close()
+		c.remove(filePath)
+		return nil, err
+	}
+
+	c.put(filePath, &parseEntry{hash: hash, content: bytes.Clone(content), tree: tree, symbols: symbols})
+	return slices.Clone(symbols), nil
+}
+
+// contentEdit describes the change from oldContent to newContent as a single edit spanning
+// everything between their common prefix and common suffix
+func contentEdit(oldContent, newContent []byte) sitter.InputEdit {
+	prefix := 0
+	for prefix < len(oldContent) && prefix < len(newContent) && oldContent[prefix] == newContent[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(oldContent)-prefix && suffix < len(newContent)-prefix &&
+		oldContent[len(oldContent)-1-suffix] == newContent[len(newContent)-1-suffix] {
+		suffix++
+	}
+
+	oldEnd := len(oldContent) - suffix
+	newEnd := len(newContent) - suffix
+	return sitter.InputEdit{
+		StartByte:      uint(prefix),
+		OldEndByte:     uint(oldEnd),
+		NewEndByte:     uint(newEnd),
+		StartPosition:  pointAt(oldContent, prefix),
+		OldEndPosition: pointAt(oldContent, oldEnd),
+		NewEndPosition: pointAt(newContent, newEnd),
+	}
+}
+
+// pointAt returns the row and byte column of an offset
+func pointAt(content []byte, offset int) sitter.Point {
+	before := content[:offset]
+	row := bytes.Count(before, []byte("\n"))
+	column := offset - (bytes.LastIndexByte(before, '\n') + 1)
+	return sitter.Point{Row: uint(row), Column: uint(column)}
+}
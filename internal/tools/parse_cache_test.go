@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const cachedGoSource = `package main
+
+func helper() int {
+	return 1
+}
+
+func main() {
+	helper()
+}
+`
+
+func TestParserRegistry_CachesUnchangedContent(t *testing.T) {
+	registry := NewParserRegistry()
+
+	first, err := registry.ParseFile("main.go", []byte(cachedGoSource))
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	first[0].Name = "mutated"
+
+	second, err := registry.ParseFile("main.go", []byte(cachedGoSource))
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if registry.cache.hits != 1 || registry.cache.misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %d hits and %d misses", registry.cache.hits, registry.cache.misses)
+	}
+	if second[0].Name == "mutated" {
+		t.Error("Cached symbols must not be shared with callers")
+	}
+}
+
+func TestParserRegistry_IncrementalParseMatchesFullParse(t *testing.T) {
+	edits := []struct {
+		name    string
+		content string
+	}{
+		{"rename", strings.Replace(cachedGoSource, "helper", "assist", 2)},
+		{"insert function", strings.Replace(cachedGoSource, "func main", "func extra() {}\n\nfunc main", 1)},
+		{"delete body", strings.Replace(cachedGoSource, "\treturn 1\n", "", 1)},
+		{"multibyte", strings.Replace(cachedGoSource, "helper()\n}", "helper() // ñandú\n}", 1)},
+	}
+
+	for _, edit := range edits {
+		t.Run(edit.name, func(t *testing.T) {
+			registry := NewParserRegistry()
+			if _, err := registry.ParseFile("main.go", []byte(cachedGoSource)); err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+
+			incremental, err := registry.ParseFile("main.go", []byte(edit.content))
+			if err != nil {
+				t.Fatalf("Incremental ParseFile failed: %v", err)
+			}
+			if registry.cache.incremental != 1 {
+				t.Errorf("Expected an incremental parse, got %d", registry.cache.incremental)
+			}
+
+			goParser, _ := NewGoParser()
+			full, err := goParser.ParseFile("main.go", []byte(edit.content))
+			if err != nil {
+				t.Fatalf("Full ParseFile failed: %v", err)
+			}
+			if !reflect.DeepEqual(incremental, full) {
+				t.Errorf("Incremental symbols differ from a full parse:\n%v\n%v", incremental, full)
+			}
+		})
+	}
+}
+
+func TestParserRegistry_EvictsOldestFiles(t *testing.T) {
+	registry := NewParserRegistry()
+	for i := 0; i <= maxCachedFiles; i++ {
+		if _, err := registry.ParseFile(fmt.Sprintf("file%d.go", i), []byte(cachedGoSource)); err != nil {
+			t.Fatalf("ParseFile failed: %v", err)
+		}
+	}
+
+	if len(registry.cache.entries) != maxCachedFiles {
+		t.Errorf("Expected %d cached files, got %d", maxCachedFiles, len(registry.cache.entries))
+	}
+	if registry.cache.get("file0.go") != nil {
+		t.Error("Expected the oldest file to be evicted")
+	}
+}
+
+func TestContentEdit(t *testing.T) {
+	edit := contentEdit([]byte("a\nbc\nd"), []byte("a\nbXYc\nd"))
+
+	if edit.StartByte != 3 || edit.OldEndByte != 3 || edit.NewEndByte != 5 {
+		t.Errorf("Unexpected byte range: %+v", edit)
+	}
+	if edit.StartPosition.Row != 1 || edit.StartPosition.Column != 1 || edit.NewEndPosition.Column != 3 {
+		t.Errorf("Unexpected positions: %+v", edit)
+	}
+}
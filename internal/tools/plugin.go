@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+const (
+	defaultPluginTimeout = 30 * time.Second
+	// maxPluginOutput bounds the result handed to the model
+	maxPluginOutput = 8000
+)
+
+// PluginTool runs an external executable declared in the plugins config. The arguments of
+// the call are written to its stdin as a JSON object and its stdout is the result. A non-zero
+// exit status fails the call with the output of stderr.
+type PluginTool struct {
+	name        string
+	description string
+	command     []string
+	parameters  map[string]any
+	timeout     time.Duration
+	dir         string
+}
+
+// NewPluginTool creates the tool declared by plugin, running its command from dir
+func NewPluginTool(plugin config.PluginConfig, dir string) (*PluginTool, error) {
+	if plugin.Name == "" {
+		return nil, fmt.Errorf("plugin without name")
+	}
+	if len(plugin.Command) == 0 || plugin.Command[0] == "" {
+		return nil, fmt.Errorf("plugin %s has no command", plugin.Name)
+	}
+
+	parameters := plugin.Parameters
+	if parameters == nil {
+		parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+
+	timeout := defaultPluginTimeout
+	if plugin.TimeoutSeconds > 0 {
+		timeout = time.Duration(plugin.TimeoutSeconds) * time.Second
+	}
+
+	return &PluginTool{
+		name:        plugin.Name,
+		description: plugin.Description,
+		command:     plugin.Command,
+		parameters:  parameters,
+		timeout:     timeout,
+		dir:         dir,
+	}, nil
+}
+
+func (t *PluginTool) Name() string {
+	return t.name
+}
+
+func (t *PluginTool) Description() string {
+	return t.description
+}
+
+func (t *PluginTool) Schema() map[string]any {
+	return t.parameters
+}
+
+func (t *PluginTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	input, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode arguments of %s: %w", t.name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, t.command[0], t.command[1:]...)
+	cmd.Dir = t.dir
+	cmd.Stdin = bytes.NewReader(input)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("plugin %s timed out after %s", t.name, t.timeout)
+	}
+	if err != nil {
+		if details := strings.TrimSpace(stderr.String()); details != "" {
+			return "", fmt.Errorf("plugin %s failed: %w: %s", t.name, err, details)
+		}
+		return "", fmt.Errorf("plugin %s failed: %w", t.name, err)
+	}
+
+	result := strings.TrimSpace(string(out))
+	if len(result) > maxPluginOutput {
+		result = truncateUTF8(result, maxPluginOutput) + "\n... (truncated)"
+	}
+	return result, nil
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestPluginTool_Execute(t *testing.T) {
+	tool, err := NewPluginTool(config.PluginConfig{
+		Name:        "service_catalog",
+		Description: "Look up the owner of a service",
+		Command:     []string{"sh", "-c", "cat; echo; echo owner: payments"},
+	}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewPluginTool failed: %v", err)
+	}
+
+	if tool.Name() != "service_catalog" {
+		t.Errorf("Expected the configured name, got %s", tool.Name())
+	}
+	if tool.Schema()["type"] != "object" {
+		t.Errorf("Expected an object schema by default, got %v", tool.Schema())
+	}
+
+	result, err := tool.Execute(context.Background(), map[string]any{"service": "billing"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result != "{\"service\":\"billing\"}\nowner: payments" {
+		t.Errorf("Expected the arguments on stdin and the stdout as result, got %q", result)
+	}
+}
+
+func TestPluginTool_Failures(t *testing.T) {
+	if _, err := NewPluginTool(config.PluginConfig{Name: "empty"}, "."); err == nil {
+		t.Error("Expected an error for a plugin without command")
+	}
+
+	failing, err := NewPluginTool(config.PluginConfig{Name: "failing", Command: []string{"sh", "-c", "echo unknown service >&2; exit 3"}}, ".")
+	if err != nil {
+		t.Fatalf("NewPluginTool failed: %v", err)
+	}
+	if _, err := failing.Execute(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "unknown service") {
+		t.Errorf("Expected the stderr of the plugin in the error, got %v", err)
+	}
+
+	slow, err := NewPluginTool(config.PluginConfig{Name: "slow", Command: []string{"sleep", "5"}, TimeoutSeconds: 1}, ".")
+	if err != nil {
+		t.Fatalf("NewPluginTool failed: %v", err)
+	}
+	if _, err := slow.Execute(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+}
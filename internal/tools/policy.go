@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/platform"
+)
+
+// defaultMaxArgumentBytes bounds the JSON encoding of the arguments of a tool call
+const defaultMaxArgumentBytes = 64 * 1024
+
+// pathArguments are the arguments holding paths, of the built-in tools as well as of the
+// plugins and MCP tools naming them alike
+var pathArguments = []string{"filename", "file_path", "file", "path", "paths", "dir", "directory"}
+
+// writeTools are the tools writing to the paths of their arguments
+var writeTools = []ToolName{ToolNameWriteFile, ToolNameApplyPatch}
+
+// ErrPolicy is returned for the tool calls the policy refuses
+var ErrPolicy = errors.New("denied by the tool policy")
+
+// Policy confines the tool calls of the model to the project: the paths of their arguments
+// must be inside Root, the written ones among WritePaths, and the arguments are bounded in
+// size. Every invocation is recorded, whether it ran or not.
+type Policy struct {
+	// Root is the directory the paths are confined to, relative paths are relative to it
+	Root string
+	// WritePaths are the files and directories, relative to Root, the write tools may write,
+	// a directory allowing every file below it. Nothing may be written when empty.
+	WritePaths []string
+	// MaxArgumentBytes bounds the JSON encoding of the arguments of a call,
+	// defaultMaxArgumentBytes when zero
+	MaxArgumentBytes int
+	// Record receives every invocation, nil records nothing
+	Record func(Invocation)
+}
+
+// Invocation is a tool call of the model as checked by the policy
+type Invocation struct {
+	Time      time.Time      `json:"time"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+	// Denied is why the policy refused the call, empty when it ran
+	Denied   string `json:"denied,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// Check returns an ErrPolicy error when the call of the tool with the arguments breaks the
+// policy
+func (p *Policy) Check(tool string, args map[string]any) error {
+	maxBytes := p.MaxArgumentBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxArgumentBytes
+	}
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("%w: arguments are not JSON: %v", ErrPolicy, err)
+	}
+	if len(encoded) > maxBytes {
+		return fmt.Errorf("%w: arguments of %d bytes exceed the limit of %d", ErrPolicy, len(encoded), maxBytes)
+	}
+
+	writes := slices.Contains(writeTools, ToolName(tool))
+	for _, name := range pathArguments {
+		for _, path := range pathValues(args[name]) {
+			if err := p.checkPath(path, writes); err != nil {
+				return err
+			}
+		}
+	}
+	if patch, ok := args["patch"].(string); ok && tool == string(ToolNameApplyPatch) {
+		for _, path := range patchedFiles(patch) {
+			if err := p.checkPath(path, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkPath confines a path to Root, and to WritePaths when it is written
+func (p *Policy) checkPath(path string, write bool) error {
+	rel, err := Confine(p.Root, path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPolicy, err)
+	}
+	if !write {
+		return nil
+	}
+	for _, writable := range p.WritePaths {
+		writable = platform.NormalizePath(filepath.Clean(writable))
+		if writable == "." || rel == writable || strings.HasPrefix(rel, writable+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s may not be written", ErrPolicy, path)
+}
+
+// Confine returns the slash separated path of path relative to root, an error when it is
+// outside of root, symbolic links included, or in a .git directory. Relative paths are
+// relative to root. The read tool of the exploration and the symbols of the context API are
+// confined with it too.
+func Confine(root, path string) (string, error) {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	original := path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(resolvedRoot, path)
+	}
+	// The links are followed before checking, so that none leads out of the root. A file
+	// about to be written does not exist yet, its directory does.
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	} else if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		path = filepath.Join(dir, filepath.Base(path))
+	}
+
+	rel, err := platform.RelPath(resolvedRoot, path)
+	if err != nil || platform.IsOutside(rel) {
+		return "", fmt.Errorf("%s is outside of the repository", original)
+	}
+	if slices.Contains(strings.Split(rel, "/"), ".git") {
+		return "", fmt.Errorf("%s is inside a .git directory", original)
+	}
+	return rel, nil
+}
+
+// pathValues returns the paths of an argument holding one or a list of them
+func pathValues(value any) []string {
+	switch value := value.(type) {
+	case string:
+		if value != "" {
+			return []string{value}
+		}
+	case []string, []any:
+		return stringsArg(map[string]any{"paths": value}, "paths")
+	}
+	return nil
+}
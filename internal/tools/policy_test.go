@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "reports"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Skipf("Symbolic links unsupported: %v", err)
+	}
+	policy := &Policy{Root: root, WritePaths: []string{"reports", "diffpector_report.md"}, MaxArgumentBytes: 200}
+
+	tests := []struct {
+		name   string
+		tool   ToolName
+		args   map[string]any
+		denied string
+	}{
+		{"read inside", ToolNameReadFile, map[string]any{"filename": "src/main.go"}, ""},
+		{"absolute inside", ToolNameReadFile, map[string]any{"filename": filepath.Join(root, "go.mod")}, ""},
+		{"read outside", ToolNameReadFile, map[string]any{"filename": "../other/main.go"}, "outside of the repository"},
+		{"absolute outside", ToolNameReadFile, map[string]any{"filename": filepath.Join(outside, "key")}, "outside of the repository"},
+		{"link outside", ToolNameReadFile, map[string]any{"filename": "escape/key"}, "outside of the repository"},
+		{"git directory", ToolNameReadFile, map[string]any{"filename": ".git/config"}, "inside a .git directory"},
+		{"path list", ToolNameGitDiff, map[string]any{"paths": []any{"main.go", "../x"}}, "outside of the repository"},
+		{"plugin path argument", "lint", map[string]any{"file_path": "/etc/passwd"}, "outside of the repository"},
+		{"write report", ToolNameWriteFile, map[string]any{"filename": "diffpector_report.md", "content": "# Report"}, ""},
+		{"write report directory", ToolNameWriteFile, map[string]any{"filename": "reports/2024/report.md", "content": ""}, ""},
+		{"write code", ToolNameWriteFile, map[string]any{"filename": "main.go", "content": ""}, "main.go may not be written"},
+		{"patch code", ToolNameApplyPatch, map[string]any{"patch": "--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n"}, "main.go may not be written"},
+		{"too large", ToolNameWriteFile, map[string]any{"filename": "diffpector_report.md", "content": strings.Repeat("x", 200)}, "exceed the limit of 200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(string(tt.tool), tt.args)
+			if tt.denied == "" {
+				if err != nil {
+					t.Errorf("Expected the call to be allowed, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrPolicy) || !strings.Contains(err.Error(), tt.denied) {
+				t.Errorf("Expected the call to be denied with %q, got %v", tt.denied, err)
+			}
+		})
+	}
+}
+
+func TestToolRegistry_Invoke(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var recorded []Invocation
+	registry := NewToolRegistry()
+	registry.SetPolicy(&Policy{Root: root, Record: func(invocation Invocation) { recorded = append(recorded, invocation) }})
+	tool := NewRepositoryReadFileTool(root, nil)
+
+	result, err := registry.Invoke(context.Background(), tool, map[string]any{"filename": "main.go"})
+	if err != nil || result != "package main\n" {
+		t.Errorf("Expected main.go to be read, got %q, %v", result, err)
+	}
+	if _, err := registry.Invoke(context.Background(), tool, map[string]any{"filename": "../main.go"}); !errors.Is(err, ErrPolicy) {
+		t.Errorf("Expected the policy to deny the call, got %v", err)
+	}
+	if _, err := registry.Invoke(context.Background(), tool, map[string]any{"filename": "missing.go"}); err == nil || errors.Is(err, ErrPolicy) {
+		t.Errorf("Expected the tool to fail, got %v", err)
+	}
+
+	if len(recorded) != 3 {
+		t.Fatalf("Expected 3 recorded invocations, got %+v", recorded)
+	}
+	if recorded[0].Tool != "read_file" || recorded[0].Denied != "" || recorded[0].Error != "" {
+		t.Errorf("Expected the first call to have run, got %+v", recorded[0])
+	}
+	if recorded[1].Denied == "" || recorded[2].Denied != "" || recorded[2].Error == "" {
+		t.Errorf("Expected a denied then a failed call, got %+v", recorded[1:])
+	}
+}
@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/vcs"
+)
+
+// referenceableTypes are the declarations whose references are counted, the members of a
+// type such as fields are referenced through too many unrelated names to be told apart
+var referenceableTypes = []string{
+	"func_decl", "method_decl", "type_decl", "const_decl", "var_decl",
+	"class_decl", "interface_decl", "enum_decl",
+}
+
+// ReferencesTool counts the references of a name across the repository. The files naming it
+// are found with the version control grep, its definitions with the parsers, and every other
+// code line naming it, strings included, is a reference. Test files are left out.
+type ReferencesTool struct {
+	repoRoot string
+	gatherer *SymbolContextGatherer
+}
+
+func NewReferencesTool(repoRoot string, registry *ParserRegistry) *ReferencesTool {
+	gatherer := NewSymbolContextGatherer(registry)
+	if repo, err := vcs.Detect(repoRoot); err == nil {
+		gatherer = NewSymbolContextGathererWithVCS(registry, repo)
+	}
+	return &ReferencesTool{repoRoot: repoRoot, gatherer: gatherer}
+}
+
+func (t *ReferencesTool) Name() string {
+	return string(ToolNameReferences)
+}
+
+func (t *ReferencesTool) Description() string {
+	return "Find the definitions of a symbol and count its references across the repository"
+}
+
+func (t *ReferencesTool) Schema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Name of the symbol",
+			},
+			"language": map[string]any{
+				"type":        "string",
+				"description": "Language of the files to search",
+			},
+		},
+		"required": []string{"name", "language"},
+	}
+}
+
+// Execute returns a types.SymbolReferences, the definitions having repository-relative paths
+func (t *ReferencesTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("name parameter required")
+	}
+	language, _ := args["language"].(string)
+
+	symbol := types.Symbol{Name: NormalizeIdentifier(name)}
+	// Each call searches the working tree as it is now
+	t.gatherer.resetSearch()
+	files, err := t.gatherer.findCandidateFiles(symbol, t.repoRoot, language)
+	if err != nil {
+		return nil, err
+	}
+	wordRegex := regexp.MustCompile(`(^|[^\p{L}\p{Nd}_])` + regexp.QuoteMeta(symbol.Name) + `($|[^\p{L}\p{Nd}_])`)
+
+	result := types.SymbolReferences{Name: symbol.Name}
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if t.gatherer.parserRegistry.GetParser(file) == nil {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		symbols, err := t.gatherer.parserRegistry.ParseFile(file, content)
+		if err != nil {
+			slog.Debug("parse failed", "file", file, "error", err)
+			continue
+		}
+
+		relPath, err := platform.RelPath(t.repoRoot, file)
+		if err != nil {
+			relPath = platform.NormalizePath(file)
+		}
+		var definitions []types.Symbol
+		for _, s := range symbols {
+			if s.Name == symbol.Name && slices.Contains(referenceableTypes, s.Type) {
+				s.FilePath = relPath
+				definitions = append(definitions, s)
+			}
+		}
+		result.Definitions = append(result.Definitions, definitions...)
+
+		for i, line := range strings.Split(string(content), "\n") {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*") {
+				continue
+			}
+			if wordRegex.MatchString(line) && !withinDefinition(definitions, i+1) {
+				result.References++
+			}
+		}
+	}
+	return result, nil
+}
+
+func withinDefinition(definitions []types.Symbol, line int) bool {
+	for _, definition := range definitions {
+		if line >= definition.StartLine && line <= definition.EndLine {
+			return true
+		}
+	}
+	return false
+}
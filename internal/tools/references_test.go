@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestReferencesTool_Execute(t *testing.T) {
+	tempDir := t.TempDir()
+	runGitCmd(t, tempDir, "git", "init")
+
+	writeGitFile(t, tempDir, "store.go", `package main
+
+// Purge deletes the expired entries, Purge(nil) deletes them all
+func Purge(keys []string) {
+	Purge(keys[1:])
+}
+
+func Save(name string) error {
+	return nil
+}
+`)
+	writeGitFile(t, tempDir, "handler.go", `package main
+
+func handle() {
+	_ = Save("user")
+}
+`)
+	writeGitFile(t, tempDir, "store_test.go", `package main
+
+func TestPurge(t *testing.T) {
+	Purge(nil)
+}
+`)
+	runGitCmd(t, tempDir, "git", "add", ".")
+
+	tool := NewReferencesTool(tempDir, NewParserRegistry())
+
+	result, err := tool.Execute(context.Background(), map[string]any{"name": "Purge", "language": "go"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	references := result.(types.SymbolReferences)
+	if len(references.Definitions) != 1 || references.Definitions[0].FilePath != "store.go" || references.Definitions[0].StartLine != 4 {
+		t.Fatalf("Expected the definition at store.go:4, got %+v", references.Definitions)
+	}
+	if references.References != 0 {
+		t.Errorf("Expected the comment, the recursion and the test not to count, got %d reference(s)", references.References)
+	}
+
+	result, err = tool.Execute(context.Background(), map[string]any{"name": "Save", "language": "go"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if references := result.(types.SymbolReferences); references.References != 1 {
+		t.Errorf("Expected the call in handler.go, got %d reference(s)", references.References)
+	}
+}
@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// securitySink is a family of calls where tainted data becomes dangerous
+type securitySink struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+var securitySinks = []securitySink{
+	{"SQL execution", regexp.MustCompile(`\.(Query|QueryRow|QueryContext|QueryRowContext|Exec|ExecContext|Prepare)\(|\b(executeQuery|executeUpdate|prepareStatement|createNativeQuery|createQuery)\(|\.(query|raw)\(`)},
+	{"command execution", regexp.MustCompile(`exec\.Command(Context)?\(|syscall\.Exec\(|Runtime\.getRuntime\(\)\.exec\(|new ProcessBuilder\(|\b(execSync|execFile|spawn)\(|child_process`)},
+	{"template rendering", regexp.MustCompile(`template\.(HTML|JS|URL)\(|\.ExecuteTemplate\(|\b(tmpl|tpl)\w*\.Execute\(|\.innerHTML\s*=|dangerouslySetInnerHTML|\.html\(`)},
+	{"cryptography", regexp.MustCompile(`\b(md5|sha1|des|rc4)\.New|math/rand|InsecureSkipVerify|MessageDigest\.getInstance\(|Cipher\.getInstance\(|createHash\(|createCipheriv\(|Math\.random\(`)},
+}
+
+// handlerSignatures recognizes HTTP handler entry points from their signature, or from the
+// annotations and decorators right above it
+var handlerSignatures = regexp.MustCompile(`http\.ResponseWriter|\*http\.Request|\*gin\.Context|echo\.Context|\*fiber\.Ctx|@(Get|Post|Put|Delete|Patch|Request)Mapping|@(GET|POST|PUT|DELETE|Path)\b|\(\s*req\w*\s*(:\s*\w+)?\s*,\s*res\w*|NextApiRequest|@(Get|Post|Put|Delete|Patch)\(`)
+
+// Bounds of the search from a changed symbol back to the handlers reaching it
+const (
+	entryPointMaxDepth = 4
+	entryPointMaxPaths = 5
+)
+
+// findSecuritySinks lists the added lines of the diff that call a known sink
+func findSecuritySinks(diff string) []string {
+	var hits []string
+	newLine := 0
+
+	for _, line := range strings.Split(diff, "\n") {
+		if matches := hunkHeaderRegex.FindStringSubmatch(line); matches != nil {
+			newLine, _ = strconv.Atoi(matches[1])
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+++"):
+		case strings.HasPrefix(line, "+"):
+			for _, sink := range securitySinks {
+				if sink.pattern.MatchString(line) {
+					hits = append(hits, fmt.Sprintf("line %d (%s): %s", newLine, sink.kind, strings.TrimSpace(line[1:])))
+					break
+				}
+			}
+			newLine++
+		case strings.HasPrefix(line, " "):
+			newLine++
+		}
+	}
+
+	return hits
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@\s+-\d+(?:,\d+)?\s+\+(\d+)(?:,\d+)?\s+@@`)
+
+// TraceEntryPoints follows the callers of the symbol back to HTTP handlers and returns the
+// call chains found, e.g. "Save <- updateUser <- HandleUpdate (internal/api/user.go:42)"
+func (g *SymbolContextGatherer) TraceEntryPoints(symbol types.Symbol, projectRoot, primaryLanguage string) []string {
+	type chain struct {
+		symbol types.Symbol
+		path   string
+	}
+
+	var paths []string
+	visited := map[string]bool{symbol.Name: true}
+	frontier := []chain{{symbol: symbol, path: symbol.Name}}
+
+	for depth := 0; depth < entryPointMaxDepth && len(frontier) > 0; depth++ {
+		var next []chain
+		for _, current := range frontier {
+			callers, err := g.findCallers(current.symbol, projectRoot, primaryLanguage)
+			if err != nil {
+				continue
+			}
+
+			for _, caller := range callers {
+				if visited[caller.Name] {
+					continue
+				}
+				visited[caller.Name] = true
+
+				path := current.path + " <- " + caller.Name
+				if isHTTPHandler(caller) {
+					paths = append(paths, fmt.Sprintf("%s (%s:%d)", path, relativePath(projectRoot, caller.FilePath), caller.StartLine))
+					if len(paths) == entryPointMaxPaths {
+						return paths
+					}
+					continue
+				}
+				next = append(next, chain{symbol: caller, path: path})
+			}
+		}
+		frontier = next
+	}
+
+	return paths
+}
+
+// isHTTPHandler checks the declaration's signature and the lines above it for handler markers
+func isHTTPHandler(symbol types.Symbol) bool {
+	content, err := os.ReadFile(symbol.FilePath)
+	if err != nil {
+		return false
+	}
+
+	lines := strings.Split(string(content), "\n")
+	start := max(0, symbol.StartLine-4)
+	end := min(len(lines), symbol.StartLine+1)
+	if start >= end {
+		return false
+	}
+
+	return handlerSignatures.MatchString(strings.Join(lines[start:end], "\n"))
+}
+
+func relativePath(root, path string) string {
+	if rel, err := platform.RelPath(root, path); err == nil {
+		return rel
+	}
+	return platform.NormalizePath(path)
+}
+
+// securityContext describes the sinks touched by the diff and the handlers reaching the
+// changed symbols, for the security profile
+func (t *SymbolContextTool) securityContext(diffData *types.DiffData, primaryLanguage string) {
+	if sinks := findSecuritySinks(diffData.Diff); len(sinks) > 0 {
+		diffData.DiffContext += "\n\n>>>> Security Sinks Touched By The Change\n" + strings.Join(sinks, "\n")
+	}
+
+	for i := range diffData.AffectedSymbols {
+		entryPoints := t.gatherer.TraceEntryPoints(diffData.AffectedSymbols[i].Symbol, t.projectRoot, primaryLanguage)
+		if len(entryPoints) == 0 {
+			continue
+		}
+		diffData.AffectedSymbols[i].Snippets += ">>>>> Reachable from HTTP handlers:\n" + strings.Join(entryPoints, "\n") + "\n"
+	}
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+func TestFindSecuritySinks(t *testing.T) {
+	diff := `--- a/store.go
++++ b/store.go
+@@ -10,4 +10,7 @@ func Save(id string) error {
+ 	query := "SELECT * FROM users WHERE id = " + id
+-	rows, err := db.Query(query, nil)
++	rows, err := db.Query(query)
++	out, _ := exec.Command("sh", "-c", id).Output()
++	sum := md5.New()
++	log.Println(out, sum)
+ 	return err
+`
+
+	sinks := findSecuritySinks(diff)
+
+	expected := []string{
+		"line 11 (SQL execution): rows, err := db.Query(query)",
+		"line 12 (command execution): out, _ := exec.Command(\"sh\", \"-c\", id).Output()",
+		"line 13 (cryptography): sum := md5.New()",
+	}
+	if len(sinks) != len(expected) {
+		t.Fatalf("Expected %d sinks, got %d: %q", len(expected), len(sinks), sinks)
+	}
+	for i, sink := range expected {
+		if sinks[i] != sink {
+			t.Errorf("Expected %q, got %q", sink, sinks[i])
+		}
+	}
+}
+
+func TestIsHTTPHandler(t *testing.T) {
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		startLine int
+		expected  bool
+	}{
+		{"go net/http", "handler.go", "package api\n\nfunc HandleUser(w http.ResponseWriter, r *http.Request) {\n}\n", 3, true},
+		{"spring mapping", "UserController.java", "class UserController {\n    @GetMapping(\"/users/{id}\")\n    public User get(@PathVariable String id) {\n    }\n}\n", 3, true},
+		{"express handler", "routes.ts", "router.get('/users', getUsers);\n\nfunction getUsers(req: Request, res: Response) {\n}\n", 3, true},
+		{"plain function", "service.go", "package api\n\nfunc loadUser(id string) User {\n}\n", 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+
+			if got := isHTTPHandler(types.Symbol{FilePath: path, StartLine: tt.startLine}); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSymbolContextGatherer_TraceEntryPoints(t *testing.T) {
+	tempDir := t.TempDir()
+	runGitCmd(t, tempDir, "git", "init")
+
+	writeGitFile(t, tempDir, "handler.go", `package main
+
+func HandleUser(w http.ResponseWriter, r *http.Request) {
+	loadUser(r.URL.Query().Get("id"))
+}
+`)
+	writeGitFile(t, tempDir, "service.go", `package main
+
+func loadUser(id string) {
+	Save(id)
+}
+`)
+	writeGitFile(t, tempDir, "store.go", `package main
+
+func Save(id string) {
+	db.Exec("DELETE FROM users WHERE id = " + id)
+}
+`)
+	runGitCmd(t, tempDir, "git", "add", ".")
+	runGitCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+
+	gatherer := NewSymbolContextGatherer(NewParserRegistry())
+
+	paths := gatherer.TraceEntryPoints(types.Symbol{Name: "Save"}, tempDir, "go")
+	if len(paths) != 1 {
+		t.Fatalf("Expected one entry point, got %q", paths)
+	}
+	if expected := "Save <- loadUser <- HandleUser (handler.go:3)"; paths[0] != expected {
+		t.Errorf("Expected %q, got %q", expected, paths[0])
+	}
+
+	if paths := gatherer.TraceEntryPoints(types.Symbol{Name: "HandleUser"}, tempDir, "go"); len(paths) != 0 {
+		t.Errorf("Expected no entry point above a handler, got %q", paths)
+	}
+
+	tool := NewSymbolContextTool(tempDir, NewParserRegistry())
+	tool.SetSecurityProfile(true)
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"diffData": types.DiffData{
+			AbsolutePath: filepath.Join(tempDir, "store.go"),
+			Diff:         "--- a/store.go\n+++ b/store.go\n@@ -4,1 +4,1 @@\n-\tdb.Exec(\"DELETE FROM users WHERE id = ?\", id)\n+\tdb.Exec(\"DELETE FROM users WHERE id = \" + id)\n",
+		},
+		"primaryLanguage": "go",
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	updated := result.(types.DiffData)
+	if !strings.Contains(updated.DiffContext, "line 4 (SQL execution)") {
+		t.Errorf("Expected the SQL sink in the diff context, got:\n%s", updated.DiffContext)
+	}
+	if len(updated.AffectedSymbols) == 0 || !strings.Contains(updated.AffectedSymbols[0].Snippets, "Reachable from HTTP handlers:\nSave <- loadUser <- HandleUser") {
+		t.Errorf("Expected the handler chain in the symbol context, got %+v", updated.AffectedSymbols)
+	}
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/agusespa/diffpector/internal/platform"
+)
+
+// maxIndexedFileSize skips the files too large to be handwritten code
+const maxIndexedFileSize = 512 * 1024
+
+// indexSkippedDirs are never walked when indexing the repository
+var indexSkippedDirs = []string{".git", "node_modules", "vendor", ".terraform", "dist", "build", "target"}
+
+// SimilarCodeTool finds the existing code of the repository that the lines added by a diff
+// nearly duplicate. The repository is indexed on first use: the source files every parser
+// reviews, without tests.
+type SimilarCodeTool struct {
+	repoRoot       string
+	parserRegistry *ParserRegistry
+	once           sync.Once
+	index          *duplicateIndex
+	indexErr       error
+}
+
+func NewSimilarCodeTool(repoRoot string, registry *ParserRegistry) *SimilarCodeTool {
+	return &SimilarCodeTool{repoRoot: repoRoot, parserRegistry: registry}
+}
+
+func (t *SimilarCodeTool) Name() string {
+	return string(ToolNameSimilarCode)
+}
+
+func (t *SimilarCodeTool) Description() string {
+	return "Find existing code in the repository that the lines added by a diff nearly duplicate"
+}
+
+func (t *SimilarCodeTool) Schema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "Repository-relative path of the changed file",
+			},
+			"diff": map[string]any{
+				"type":        "string",
+				"description": "Unified diff of the file",
+			},
+		},
+		"required": []string{"file_path", "diff"},
+	}
+}
+
+// Execute returns the similar code as []types.SimilarCode
+func (t *SimilarCodeTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	filePath, ok := args["file_path"].(string)
+	if !ok || filePath == "" {
+		return nil, fmt.Errorf("file_path parameter required")
+	}
+	diffText, ok := args["diff"].(string)
+	if !ok {
+		return nil, fmt.Errorf("diff parameter required")
+	}
+
+	t.once.Do(t.buildIndex)
+	if t.indexErr != nil {
+		return nil, t.indexErr
+	}
+
+	content, err := os.ReadFile(filepath.Join(t.repoRoot, filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	return t.index.Find(platform.NormalizePath(filePath), diffText, content), nil
+}
+
+func (t *SimilarCodeTool) buildIndex() {
+	t.index = newDuplicateIndex()
+	t.indexErr = filepath.WalkDir(t.repoRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			for _, skipped := range indexSkippedDirs {
+				if entry.Name() == skipped && path != t.repoRoot {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		relPath, err := platform.RelPath(t.repoRoot, path)
+		if err != nil {
+			return nil
+		}
+		parser := t.parserRegistry.GetParser(relPath)
+		if parser == nil || parser.IsTestFile(relPath) || parser.ShouldExcludeFile(relPath, t.repoRoot) {
+			return nil
+		}
+		if info, err := entry.Info(); err != nil || info.Size() > maxIndexedFileSize {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		t.index.Add(relPath, content)
+		return nil
+	})
+	if t.indexErr != nil {
+		t.indexErr = fmt.Errorf("failed to index %s: %w", t.repoRoot, t.indexErr)
+	}
+}
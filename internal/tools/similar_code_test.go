@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+const existingStore = `package store
+
+// Load reads the users of a team
+func Load(db *sql.DB, team string) ([]User, error) {
+	rows, err := db.Query("SELECT id, name FROM users WHERE team = ?", team)
+	if err != nil {
+		return nil, fmt.Errorf("query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Name); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+`
+
+const changedHandler = `package store
+
+func Admins(db *sql.DB, team string) ([]User, error) {
+	rows, err := db.Query("SELECT id, name FROM admins WHERE team = ?", team)
+	if err != nil {
+		return nil, fmt.Errorf("query admins: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Name); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+`
+
+func TestSimilarCodeTool_Execute(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"store/users.go":      existingStore,
+		"store/admins.go":     changedHandler,
+		"store/users_test.go": existingStore,
+		"README.md":           existingStore,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(changedHandler, "\n"), "\n")
+	diff := "@@ -0,0 +1,19 @@\n+" + strings.Join(lines, "\n+") + "\n"
+
+	tool := NewSimilarCodeTool(dir, NewParserRegistry())
+	result, err := tool.Execute(context.Background(), map[string]any{"file_path": "store/admins.go", "diff": diff})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	similar, ok := result.([]types.SimilarCode)
+	if !ok || len(similar) != 1 {
+		t.Fatalf("Expected the users.go function only, got %+v", result)
+	}
+	code := similar[0]
+	if code.FilePath != "store/users.go" || code.AddedStart != 1 || code.AddedEnd != 19 {
+		t.Errorf("Unexpected match %+v", code)
+	}
+	if code.StartLine > 5 || code.EndLine < 18 || code.Similarity < 0.9 {
+		t.Errorf("Expected the Load function to match closely, got lines %d-%d at %.2f", code.StartLine, code.EndLine, code.Similarity)
+	}
+	if !strings.Contains(code.Snippet, "rows.Scan(&user.ID, &user.Name)") {
+		t.Errorf("Expected the snippet of the existing code, got %q", code.Snippet)
+	}
+
+	unrelated := "@@ -0,0 +1,5 @@\n+package store\n+\n+func Count(items []int) int {\n+\treturn len(items)\n+}\n"
+	if err := os.WriteFile(filepath.Join(dir, "store/count.go"), []byte("package store\n\nfunc Count(items []int) int {\n\treturn len(items)\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = tool.Execute(context.Background(), map[string]any{"file_path": "store/count.go", "diff": unrelated})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if similar := result.([]types.SimilarCode); len(similar) != 0 {
+		t.Errorf("Expected no similar code for a new function, got %+v", similar)
+	}
+}
@@ -0,0 +1,302 @@
+package tools
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// SQLParser extracts the tables, columns, indexes and other schema objects of SQL files,
+// statement by statement. No tree-sitter SQL grammar is vendored and the dialects differ
+// widely, so statements are split and recognized by their leading keywords instead.
+type SQLParser struct{}
+
+func NewSQLParser() *SQLParser {
+	return &SQLParser{}
+}
+
+func (sp *SQLParser) Language() string {
+	return "SQL"
+}
+
+func (sp *SQLParser) SupportedExtensions() []string {
+	return []string{`.sql`}
+}
+
+func (sp *SQLParser) ShouldExcludeFile(filePath, projectRoot string) bool {
+	lowerPath := strings.ToLower(platform.NormalizePath(filePath))
+	for _, pattern := range []string{"vendor/", "node_modules/", "testdata/", ".git/"} {
+		if strings.Contains(lowerPath, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sp *SQLParser) IsTestFile(filePath string) bool {
+	return false
+}
+
+// migrationFileRegex matches the file names of the common migration tools: golang-migrate,
+// goose and Rails style timestamps and Flyway versions
+var migrationFileRegex = regexp.MustCompile(`^(?:\d+[_.-]|V\d+(?:[._]\d+)*__|R__)`)
+
+// IsMigrationFile reports whether a SQL file is a schema migration, by its directory or the
+// versioned name migration tools give it
+func IsMigrationFile(filePath string) bool {
+	if !strings.EqualFold(filepath.Ext(filePath), ".sql") {
+		return false
+	}
+	for _, dir := range strings.Split(path.Dir(platform.NormalizePath(filePath)), "/") {
+		if strings.Contains(strings.ToLower(dir), "migrat") {
+			return true
+		}
+	}
+	return migrationFileRegex.MatchString(filepath.Base(filePath))
+}
+
+// sqlName matches an identifier, quoted or not, possibly qualified by its schema
+const sqlName = `((?:"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[\w$]+)(?:\s*\.\s*(?:"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[\w$]+))*)`
+
+var sqlStatementRegexes = []struct {
+	symbolType string
+	regex      *regexp.Regexp
+}{
+	{"table_decl", regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:(?:GLOBAL|LOCAL)\s+)?(?:TEMP(?:ORARY)?\s+|UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + sqlName)},
+	{"view_decl", regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:MATERIALIZED\s+)?VIEW\s+(?:IF\s+NOT\s+EXISTS\s+)?` + sqlName)},
+	{"index_decl", regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?(?:CLUSTERED\s+|NONCLUSTERED\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(?:` + sqlName + `\s+)?ON\s+(?:ONLY\s+)?` + sqlName)},
+	{"routine_decl", regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:FUNCTION|PROCEDURE|TRIGGER)\s+(?:IF\s+NOT\s+EXISTS\s+)?` + sqlName)},
+	{"alter_decl", regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?` + sqlName)},
+	{"drop_decl", regexp.MustCompile(`(?is)^DROP\s+(?:TABLE|VIEW|MATERIALIZED\s+VIEW|INDEX|FUNCTION|PROCEDURE|TRIGGER|SCHEMA|TYPE|SEQUENCE)\s+(?:CONCURRENTLY\s+)?(?:IF\s+EXISTS\s+)?` + sqlName)},
+}
+
+// sqlAddColumnRegex matches the columns added by an ALTER TABLE statement
+var sqlAddColumnRegex = regexp.MustCompile(`(?is)\bADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?` + sqlName)
+
+// sqlColumnRegex matches the name starting a column definition
+var sqlColumnRegex = regexp.MustCompile(`^\s*` + sqlName)
+
+// sqlConstraintKeywords start the table elements which are not columns
+var sqlConstraintKeywords = []string{"CONSTRAINT", "PRIMARY", "FOREIGN", "UNIQUE", "CHECK", "INDEX", "KEY", "EXCLUDE", "LIKE", "PERIOD", "FULLTEXT", "SPATIAL"}
+
+// ParseFile returns a declaration per statement creating, altering or dropping a schema
+// object, and the columns of the created and altered tables. Indexes are declared in the
+// package of their table, columns in the package of theirs.
+func (sp *SQLParser) ParseFile(filePath string, content []byte) ([]types.Symbol, error) {
+	masked := maskSQL(string(content))
+	lineStarts := lineOffsets(masked)
+
+	var symbols []types.Symbol
+	for _, statement := range splitSQLStatements(masked) {
+		text := masked[statement.start:statement.end]
+		startLine := lineAt(lineStarts, statement.start)
+		endLine := lineAt(lineStarts, statement.end-1)
+
+		for _, candidate := range sqlStatementRegexes {
+			matches := candidate.regex.FindStringSubmatchIndex(text)
+			if matches == nil {
+				continue
+			}
+
+			name := text[matches[len(matches)-2]:matches[len(matches)-1]]
+			symbol := types.Symbol{Type: candidate.symbolType, FilePath: filePath, StartLine: startLine, EndLine: endLine}
+			symbol.Package, symbol.Name = splitSQLName(name)
+			if candidate.symbolType == "index_decl" {
+				_, table := splitSQLName(name)
+				symbol.Package = table
+				symbol.Name = table
+				if matches[2] >= 0 {
+					_, symbol.Name = splitSQLName(text[matches[2]:matches[3]])
+				}
+			}
+			symbols = append(symbols, symbol)
+
+			switch candidate.symbolType {
+			case "table_decl":
+				// CREATE TABLE ... AS SELECT has no column definitions
+				nameEnd := matches[len(matches)-1]
+				if strings.HasPrefix(strings.TrimSpace(text[nameEnd:]), "(") {
+					symbols = append(symbols, tableColumns(filePath, symbol.Name, text[nameEnd:], statement.start+nameEnd, lineStarts)...)
+				}
+			case "alter_decl":
+				for _, added := range sqlAddColumnRegex.FindAllStringSubmatchIndex(text, -1) {
+					_, column := splitSQLName(text[added[2]:added[3]])
+					if isSQLConstraint(column) {
+						continue
+					}
+					line := lineAt(lineStarts, statement.start+added[0])
+					symbols = append(symbols, types.Symbol{Name: column, Type: "column_decl", Package: symbol.Name, FilePath: filePath, StartLine: line, EndLine: line})
+				}
+			}
+			break
+		}
+	}
+
+	return symbols, nil
+}
+
+// tableColumns declares the columns listed in the parentheses of a CREATE TABLE statement
+func tableColumns(filePath, table, text string, offset int, lineStarts []int) []types.Symbol {
+	open := strings.Index(text, "(")
+	if open < 0 {
+		return nil
+	}
+
+	var columns []types.Symbol
+	depth, elementStart := 0, open+1
+	for i := open; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')', ',':
+			if text[i] == ')' {
+				depth--
+			}
+			if (text[i] == ',' && depth == 1) || depth == 0 {
+				element := text[elementStart:i]
+				if match := sqlColumnRegex.FindStringSubmatchIndex(element); match != nil && !isSQLConstraint(element[match[2]:match[3]]) {
+					_, name := splitSQLName(element[match[2]:match[3]])
+					first := elementStart + match[2]
+					last := elementStart + len(strings.TrimRight(element, " \t\r\n")) - 1
+					columns = append(columns, types.Symbol{
+						Name:      name,
+						Type:      "column_decl",
+						Package:   table,
+						FilePath:  filePath,
+						StartLine: lineAt(lineStarts, offset+first),
+						EndLine:   lineAt(lineStarts, offset+last),
+					})
+				}
+				elementStart = i + 1
+			}
+			if depth == 0 {
+				return columns
+			}
+		}
+	}
+	return columns
+}
+
+type sqlStatement struct {
+	start, end int
+}
+
+// splitSQLStatements returns the ranges of the statements of masked SQL, without their
+// leading blanks and including the terminating semicolon
+func splitSQLStatements(masked string) []sqlStatement {
+	var statements []sqlStatement
+	start := 0
+	for i := 0; i <= len(masked); i++ {
+		if i < len(masked) && masked[i] != ';' {
+			continue
+		}
+		end := min(i+1, len(masked))
+		text := masked[start:end]
+		if trimmed := strings.TrimSpace(text); strings.Trim(trimmed, ";") != "" {
+			leading := len(text) - len(strings.TrimLeft(text, " \t\r\n"))
+			statements = append(statements, sqlStatement{start: start + leading, end: start + leading + len(trimmed)})
+		}
+		start = i + 1
+	}
+	return statements
+}
+
+// maskSQL blanks comments, string literals and dollar-quoted bodies, keeping the offsets
+// and line breaks, so statements can be split on semicolons and matched by keywords
+func maskSQL(sql string) string {
+	masked := []byte(sql)
+	blank := func(from, to int) {
+		for i := from; i < to && i < len(masked); i++ {
+			if masked[i] != '\n' {
+				masked[i] = ' '
+			}
+		}
+	}
+
+	for i := 0; i < len(sql); i++ {
+		switch {
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			blank(i, i+end)
+			i += end
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 4
+			}
+			blank(i, i+end+4)
+			i += end + 3
+		case sql[i] == '\'':
+			end := i + 1
+			for end < len(sql) {
+				if sql[end] == '\'' {
+					if end+1 < len(sql) && sql[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			blank(i+1, end)
+			i = end
+		case sql[i] == '$':
+			tag := dollarQuoteRegex.FindString(sql[i:])
+			if tag == "" {
+				continue
+			}
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				end = len(sql) - i - len(tag)
+			}
+			blank(i+len(tag), i+len(tag)+end)
+			i += len(tag) + end + len(tag) - 1
+		}
+	}
+	return string(masked)
+}
+
+var dollarQuoteRegex = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// splitSQLName separates the schema from the name of a qualified identifier and unquotes them
+func splitSQLName(name string) (schema, object string) {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), "\"`[]")
+	}
+	if len(parts) == 1 {
+		return "", parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], "."), parts[len(parts)-1]
+}
+
+func isSQLConstraint(word string) bool {
+	return slices.ContainsFunc(sqlConstraintKeywords, func(keyword string) bool {
+		return strings.EqualFold(keyword, word)
+	})
+}
+
+// lineOffsets returns the offset at which each line starts
+func lineOffsets(text string) []int {
+	offsets := []int{0}
+	for i, r := range text {
+		if r == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// lineAt returns the 1-based line of an offset
+func lineAt(lineStarts []int, offset int) int {
+	return sort.SearchInts(lineStarts, offset+1)
+}
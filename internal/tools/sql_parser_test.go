@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+const migrationSQL = `-- Users and their orders
+CREATE TABLE IF NOT EXISTS public.users (
+    id BIGSERIAL PRIMARY KEY,
+    email TEXT NOT NULL DEFAULT 'a;b', -- not a statement end
+    "display name" VARCHAR(100),
+    CONSTRAINT users_email_key UNIQUE (email)
+);
+
+CREATE TABLE orders (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users (id),
+    total NUMERIC(10, 2)
+);
+
+/* Faster lookups; by email */
+CREATE UNIQUE INDEX CONCURRENTLY idx_users_email ON users (lower(email));
+
+ALTER TABLE orders
+    ADD COLUMN status TEXT,
+    ADD CONSTRAINT orders_total_check CHECK (total >= 0);
+
+CREATE OR REPLACE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS legacy_orders;
+UPDATE users SET email = lower(email);
+`
+
+func TestSQLParser_ParseFile(t *testing.T) {
+	parser := NewSQLParser()
+
+	symbols, err := parser.ParseFile("db/migrations/001_init.sql", []byte(migrationSQL))
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	var got []string
+	for _, symbol := range symbols {
+		got = append(got, fmt.Sprintf("%s %s.%s %d-%d", symbol.Type, symbol.Package, symbol.Name, symbol.StartLine, symbol.EndLine))
+	}
+
+	expected := []string{
+		"table_decl public.users 2-7",
+		"column_decl users.id 3-3",
+		"column_decl users.email 4-4",
+		"column_decl users.display name 5-5",
+		"table_decl .orders 9-13",
+		"column_decl orders.id 10-10",
+		"column_decl orders.user_id 11-11",
+		"column_decl orders.total 12-12",
+		"index_decl users.idx_users_email 16-16",
+		"alter_decl .orders 18-20",
+		"column_decl orders.status 19-19",
+		"routine_decl .touch 22-27",
+		"drop_decl .legacy_orders 29-29",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Unexpected symbols:\n%v\nexpected:\n%v", got, expected)
+	}
+}
+
+func TestSQLParser_Registered(t *testing.T) {
+	registry := NewParserRegistry()
+	if parser := registry.GetParser("db/schema.SQL"); parser == nil || parser.Language() != "SQL" {
+		t.Errorf("Expected the SQL parser for .sql files, got %v", parser)
+	}
+}
+
+func TestIsMigrationFile(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"db/migrations/001_users.sql", true},
+		{"internal/migrate/users.sql", true},
+		{"20240101120000_add_users.sql", true},
+		{"sql/V2_1__add_orders.sql", true},
+		{"sql/R__views.sql", true},
+		{"queries/users.sql", false},
+		{"db/migrations/001_users.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsMigrationFile(tt.path); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -2,21 +2,51 @@ package tools
 
 import (
 	"fmt"
-	"os"
-	"os/exec"
+	"log/slog"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/search"
 	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/vcs"
 )
 
 type SymbolContextGatherer struct {
 	parserRegistry *ParserRegistry
+	repo           vcs.VCS
+	// workspaceModules are the go.work modules the repository search misses, searched too
+	// for the Go symbols
+	workspaceModules []string
+	// modules finds the module of each candidate file, so the ones of the changed module come
+	// first and the others are labeled
+	modules *moduleIndex
+
+	// searchMu guards the search caches until resetSearch: indexes holds the tracked files of
+	// each searched root, found the files containing each searched pattern
+	searchMu sync.Mutex
+	indexes  map[string]*search.Index
+	found    map[string][]string
+	// base is the revision the candidate files are searched and read in, the working tree
+	// when nil
+	base *baseRevision
 }
 
 func NewSymbolContextGatherer(registry *ParserRegistry) *SymbolContextGatherer {
 	return &SymbolContextGatherer{
 		parserRegistry: registry,
+		modules:        newModuleIndex(),
+	}
+}
+
+// NewSymbolContextGathererWithVCS creates a gatherer that searches candidate files through the given VCS
+func NewSymbolContextGathererWithVCS(registry *ParserRegistry, repo vcs.VCS) *SymbolContextGatherer {
+	return &SymbolContextGatherer{
+		parserRegistry: registry,
+		repo:           repo,
+		modules:        newModuleIndex(),
 	}
 }
 
@@ -25,14 +55,30 @@ func (g *SymbolContextGatherer) GatherSymbolContext(affectedSymbols []types.Symb
 		return nil
 	}
 
+	// The symbols are searched together, then the symbols they reference together
+	names := make([]string, len(affectedSymbols))
+	for i := range affectedSymbols {
+		names[i] = affectedSymbols[i].Symbol.Name
+	}
+	g.prefetchSymbols(names, projectRoot, primaryLanguage)
+
+	// Primary Pass: Gather context for the affected symbols and extract references
+	primaries := make([]primaryPass, len(affectedSymbols))
+	var allRefs []string
+	for i := range affectedSymbols {
+		primary := &primaries[i]
+		primary.context, primary.refs, primary.usages, primary.err = g.gatherContextWithRefs(affectedSymbols[i].Symbol, projectRoot, primaryLanguage)
+		allRefs = append(allRefs, primary.refs...)
+	}
+	g.prefetchSymbols(allRefs, projectRoot, primaryLanguage)
+
 	// Track processed symbols to avoid duplication in secondary pass
 	processedRefs := make(map[string]bool)
 
 	for i := range affectedSymbols {
 		var contextBuilder strings.Builder
 
-		// Primary Pass: Gather context for the affected symbol and extract references
-		primaryContext, refs, err := g.gatherContextWithRefs(affectedSymbols[i].Symbol, projectRoot, primaryLanguage)
+		primaryContext, refs, usages, err := primaries[i].context, primaries[i].refs, primaries[i].usages, primaries[i].err
 		if err != nil {
 			continue
 		}
@@ -41,6 +87,9 @@ func (g *SymbolContextGatherer) GatherSymbolContext(affectedSymbols []types.Symb
 			contextBuilder.WriteString(fmt.Sprintf(">>>>> Symbol: %s (Package: %s)\n",
 				affectedSymbols[i].Symbol.Name,
 				affectedSymbols[i].Symbol.Package))
+			if g.base != nil {
+				contextBuilder.WriteString(fmt.Sprintf(">>>>>> Usages searched in %s, the merge base with %s\n", shortCommit(g.base.commit), g.base.target))
+			}
 			contextBuilder.WriteString(primaryContext)
 		}
 
@@ -52,8 +101,9 @@ func (g *SymbolContextGatherer) GatherSymbolContext(affectedSymbols []types.Symb
 			}
 			processedRefs[refName] = true
 
-			// Create a symbol with just the name
-			refSym := types.Symbol{Name: refName}
+			// Create a symbol with just the name, in the file of the changed symbol to label
+			// the definitions of other modules
+			refSym := types.Symbol{Name: refName, FilePath: affectedSymbols[i].Symbol.FilePath}
 
 			// Find definitions only (no recursive ref extraction)
 			secondaryContext, err := g.gatherDefinitionsOnly(refSym, projectRoot, primaryLanguage)
@@ -68,34 +118,53 @@ func (g *SymbolContextGatherer) GatherSymbolContext(affectedSymbols []types.Symb
 		}
 
 		affectedSymbols[i].Snippets = contextBuilder.String()
+		affectedSymbols[i].Usages = usages
 	}
 
 	return nil
 }
 
+// primaryPass is what the primary pass gathered for a symbol
+type primaryPass struct {
+	context string
+	refs    []string
+	usages  int
+	err     error
+}
+
 // gatherContextWithRefs finds definitions and usages of a symbol,
 // and extracts references to other symbols used within the definition.
-func (g *SymbolContextGatherer) gatherContextWithRefs(symbol types.Symbol, projectRoot, primaryLanguage string) (string, []string, error) {
+// It also returns the number of usage sites found.
+func (g *SymbolContextGatherer) gatherContextWithRefs(symbol types.Symbol, projectRoot, primaryLanguage string) (string, []string, int, error) {
+	symbol.Name = NormalizeIdentifier(symbol.Name)
+
 	candidateFiles, err := g.findCandidateFiles(symbol, projectRoot, primaryLanguage)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to find candidate files for symbol %s: %w", symbol.Name, err)
+		return "", nil, 0, fmt.Errorf("failed to find candidate files for symbol %s: %w", symbol.Name, err)
 	}
 	if len(candidateFiles) == 0 {
-		return "", nil, nil
+		return "", nil, 0, nil
 	}
+	home := g.moduleOf(projectRoot, symbol.FilePath)
+	candidateFiles = g.ownModuleFirst(candidateFiles, projectRoot, home)
 
 	var contextBuilder strings.Builder
 	var references []string
+	var otherModules []string
 	refMap := make(map[string]bool)
 	seen := make(map[string]bool)
+	usages := 0
 
 	for _, filePath := range candidateFiles {
-		content, err := os.ReadFile(filepath.Join(filePath))
+		module := g.moduleOf(projectRoot, filePath)
+		label := moduleLabel(module, home)
+		content, err := g.readFile(filePath)
 		if err != nil {
 			continue
 		}
 		symbols, err := g.parserRegistry.ParseFile(filePath, content)
 		if err != nil {
+			slog.Debug("parse failed", "file", filePath, "error", err)
 			continue
 		}
 
@@ -110,14 +179,14 @@ func (g *SymbolContextGatherer) gatherContextWithRefs(symbol types.Symbol, proje
 				key := fmt.Sprintf("decl:%s:%d-%d", filePath, s.StartLine, s.EndLine)
 				if !seen[key] {
 					seen[key] = true
-					contextBuilder.WriteString(fmt.Sprintf(">>>>>> Definition in %s (lines %d-%d):\n", filePath, s.StartLine, s.EndLine))
+					contextBuilder.WriteString(fmt.Sprintf(">>>>>> Definition in %s (lines %d-%d%s):\n", filePath, s.StartLine, s.EndLine, label))
 					contextBuilder.WriteString(snippet)
 					contextBuilder.WriteString("\n")
 
 					// Extract references: scan for usages INSIDE this declaration
 					for _, inner := range symbols {
 						if inner.StartLine >= s.StartLine && inner.EndLine <= s.EndLine {
-							if isUsageType(inner.Type) && inner.Name != symbol.Name {
+							if IsUsageType(inner.Type) && inner.Name != symbol.Name {
 								if !refMap[inner.Name] {
 									refMap[inner.Name] = true
 									references = append(references, inner.Name)
@@ -128,23 +197,33 @@ func (g *SymbolContextGatherer) gatherContextWithRefs(symbol types.Symbol, proje
 				}
 			}
 
-			if isUsageType(s.Type) {
+			if IsUsageType(s.Type) {
 				key := fmt.Sprintf("usage:%s:%d-%d", filePath, s.StartLine, s.EndLine)
 				if !seen[key] {
 					seen[key] = true
-					contextBuilder.WriteString(fmt.Sprintf(">>>>>> Usage in %s (line %d):\n", filePath, s.StartLine))
+					usages++
+					contextBuilder.WriteString(fmt.Sprintf(">>>>>> Usage in %s (line %d%s):\n", filePath, s.StartLine, label))
 					contextBuilder.WriteString(snippet)
 					contextBuilder.WriteString("\n")
+					if label != "" && !slices.Contains(otherModules, module) {
+						otherModules = append(otherModules, module)
+					}
 				}
 			}
 		}
 	}
 
-	return contextBuilder.String(), references, nil
+	if len(otherModules) > 0 {
+		contextBuilder.WriteString(fmt.Sprintf(">>>>>> Used from other modules: %s, a change of its contract breaks them\n", strings.Join(otherModules, ", ")))
+	}
+
+	return contextBuilder.String(), references, usages, nil
 }
 
 // gatherDefinitionsOnly finds only definitions of a symbol (no usages, no recursive refs).
 func (g *SymbolContextGatherer) gatherDefinitionsOnly(symbol types.Symbol, projectRoot, primaryLanguage string) (string, error) {
+	symbol.Name = NormalizeIdentifier(symbol.Name)
+
 	candidateFiles, err := g.findCandidateFiles(symbol, projectRoot, primaryLanguage)
 	if err != nil {
 		return "", fmt.Errorf("failed to find candidate files for symbol %s: %w", symbol.Name, err)
@@ -152,17 +231,21 @@ func (g *SymbolContextGatherer) gatherDefinitionsOnly(symbol types.Symbol, proje
 	if len(candidateFiles) == 0 {
 		return "", nil
 	}
+	home := g.moduleOf(projectRoot, symbol.FilePath)
+	candidateFiles = g.ownModuleFirst(candidateFiles, projectRoot, home)
 
 	var contextBuilder strings.Builder
 	seen := make(map[string]bool)
 
 	for _, filePath := range candidateFiles {
-		content, err := os.ReadFile(filepath.Join(filePath))
+		label := moduleLabel(g.moduleOf(projectRoot, filePath), home)
+		content, err := g.readFile(filePath)
 		if err != nil {
 			continue
 		}
 		symbols, err := g.parserRegistry.ParseFile(filePath, content)
 		if err != nil {
+			slog.Debug("parse failed", "file", filePath, "error", err)
 			continue
 		}
 
@@ -176,7 +259,7 @@ func (g *SymbolContextGatherer) gatherDefinitionsOnly(symbol types.Symbol, proje
 				if !seen[key] {
 					seen[key] = true
 					snippet := extractSnippet(content, s.StartLine, s.EndLine)
-					contextBuilder.WriteString(fmt.Sprintf(">>>>>> Definition in %s (lines %d-%d):\n", filePath, s.StartLine, s.EndLine))
+					contextBuilder.WriteString(fmt.Sprintf(">>>>>> Definition in %s (lines %d-%d%s):\n", filePath, s.StartLine, s.EndLine, label))
 					contextBuilder.WriteString(snippet)
 					contextBuilder.WriteString("\n")
 				}
@@ -187,44 +270,65 @@ func (g *SymbolContextGatherer) gatherDefinitionsOnly(symbol types.Symbol, proje
 	return contextBuilder.String(), nil
 }
 
-func isUsageType(symbolType string) bool {
-	return strings.HasSuffix(symbolType, "_usage") || strings.Contains(symbolType, "jsx_") || symbolType == "type_usage"
+// moduleOf returns the module owning filePath, empty when it is unknown
+func (g *SymbolContextGatherer) moduleOf(projectRoot, filePath string) string {
+	if g.modules == nil || filePath == "" {
+		return ""
+	}
+	return g.modules.owningModule(projectRoot, filePath)
 }
 
-func (g *SymbolContextGatherer) findCandidateFiles(symbol types.Symbol, projectRoot, primaryLanguage string) ([]string, error) {
-	files, err := g.gitGrepSearch(symbol.Name, projectRoot, primaryLanguage)
-	if err != nil {
-		return nil, err
+// ownModuleFirst orders the files of the module home before the files of the other modules,
+// keeping the search order otherwise
+func (g *SymbolContextGatherer) ownModuleFirst(files []string, projectRoot, home string) []string {
+	if home == "" {
+		return files
+	}
+	var own, others []string
+	for _, file := range files {
+		if g.moduleOf(projectRoot, file) == home {
+			own = append(own, file)
+		} else {
+			others = append(others, file)
+		}
 	}
+	return append(own, others...)
+}
 
-	return g.validateFiles(files, projectRoot), nil
+// moduleLabel is the suffix of the snippet headers of the files of another module than home
+func moduleLabel(module, home string) string {
+	if module == "" || home == "" || module == home {
+		return ""
+	}
+	return ", module " + module
 }
 
-func (g *SymbolContextGatherer) gitGrepSearch(pattern, projectRoot, primaryLanguage string) ([]string, error) {
-	includePatterns := g.getIncludePatterns(primaryLanguage)
+// IsUsageType reports whether a parsed symbol is a reference to a declaration rather than
+// the declaration itself
+func IsUsageType(symbolType string) bool {
+	return strings.HasSuffix(symbolType, "_usage") || strings.Contains(symbolType, "jsx_") || symbolType == "type_usage"
+}
 
-	args := []string{"grep", "-l", pattern}
-	if len(includePatterns) > 0 {
-		args = append(args, "--")
-		args = append(args, includePatterns...)
-	}
+func (g *SymbolContextGatherer) findCandidateFiles(symbol types.Symbol, projectRoot, primaryLanguage string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
 
-	cmd := exec.Command("git", args...)
-	cmd.Dir = projectRoot
-	output, err := cmd.Output()
+	// Non-ASCII names may be stored composed or decomposed, search for both
+	forms := identifierSearchForms(symbol.Name)
+	found, err := g.searchPatterns(forms, projectRoot, primaryLanguage)
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
-			return []string{}, nil
-		}
-		return nil, fmt.Errorf("git grep command failed: %w", err)
+		return nil, err
 	}
-
-	if len(output) == 0 {
-		return []string{}, nil
+	for _, form := range forms {
+		for _, file := range found[form] {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
 	}
 
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	return files, nil
+	return g.validateFiles(files, projectRoot), nil
 }
 
 // validateFiles ensures files exist and converts relative paths to absolute if needed
@@ -237,12 +341,12 @@ func (g *SymbolContextGatherer) validateFiles(files []string, projectRoot string
 			continue
 		}
 
-		relPath := file
+		relPath := platform.NormalizePath(file)
 		if filepath.IsAbs(file) {
 			var err error
-			relPath, err = filepath.Rel(projectRoot, file)
+			relPath, err = platform.RelPath(projectRoot, file)
 			if err != nil {
-				relPath = file
+				relPath = platform.NormalizePath(file)
 			}
 		}
 
@@ -253,7 +357,7 @@ func (g *SymbolContextGatherer) validateFiles(files []string, projectRoot string
 
 		absPath := file
 		if !filepath.IsAbs(file) {
-			absPath = filepath.Join(projectRoot, file)
+			absPath = platform.JoinPath(projectRoot, file)
 		}
 
 		validFiles = append(validFiles, absPath)
@@ -267,6 +371,12 @@ func (g *SymbolContextGatherer) getIncludePatterns(language string) []string {
 		"*.json", "*.yaml", "*.yml", "*.toml", "*.xml",
 		"*.md", "*.txt", "*.conf", "*.config", "*.ini",
 		"Dockerfile", "Makefile", "*.mk",
+		// Earlier migrations define the tables the changed ones alter
+		"*.sql",
+		// Infrastructure code references the resources of the other files of its module
+		"*.tf", "*.tfvars",
+		// Scripts call the functions of the scripts they source
+		"*.sh", "*.bash",
 	}
 
 	languagePatterns := g.getLanguageSpecificPatterns(language)
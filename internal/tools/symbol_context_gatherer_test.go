@@ -8,7 +8,7 @@ import (
 	"testing"
 )
 
-func TestSymbolContextGatherer_gitGrepSearch(t *testing.T) {
+func TestSymbolContextGatherer_searchPatterns(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "git_grep_test")
 	if err != nil {
 		t.Fatalf("Failed to create temp dir: %v", err)
@@ -42,10 +42,11 @@ func Add(a, b int) int {
 
 	gatherer := &SymbolContextGatherer{}
 
-	files, err := gatherer.gitGrepSearch("Add", tempDir, "go")
+	found, err := gatherer.searchPatterns([]string{"Add"}, tempDir, "go")
 	if err != nil {
-		t.Fatalf("gitGrepSearch failed: %v", err)
+		t.Fatalf("searchPatterns failed: %v", err)
 	}
+	files := found["Add"]
 
 	if len(files) != 2 {
 		t.Errorf("Expected 2 files, got %d: %v", len(files), files)
@@ -119,12 +120,12 @@ type User struct {
 	registry := NewParserRegistry()
 	gatherer := NewSymbolContextGatherer(registry)
 
-	rawFiles, err := gatherer.gitGrepSearch("GetUser", tempDir, "go")
+	found, err := gatherer.searchPatterns([]string{"GetUser"}, tempDir, "go")
 	if err != nil {
-		t.Fatalf("gitGrepSearch failed: %v", err)
+		t.Fatalf("searchPatterns failed: %v", err)
 	}
 
-	filteredFiles := gatherer.validateFiles(rawFiles, tempDir)
+	filteredFiles := gatherer.validateFiles(found["GetUser"], tempDir)
 
 	for _, file := range filteredFiles {
 		if strings.Contains(file, "_test.go") {
@@ -1,27 +1,49 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/agusespa/diffpector/internal/types"
 	"github.com/agusespa/diffpector/internal/utils"
+	"github.com/agusespa/diffpector/internal/vcs"
+	"github.com/agusespa/diffpector/pkg/config"
 )
 
 type SymbolContextTool struct {
 	parserRegistry *ParserRegistry
 	gatherer       *SymbolContextGatherer
 	projectRoot    string
+	contextConfig  config.ContextConfig
+	// security adds the sinks touched and the HTTP handlers reaching the changed symbols
+	security bool
 }
 
 func NewSymbolContextTool(projectRoot string, registry *ParserRegistry) *SymbolContextTool {
+	gatherer := NewSymbolContextGatherer(registry)
+	if repo, err := vcs.Detect(projectRoot); err == nil {
+		gatherer = NewSymbolContextGathererWithVCS(registry, repo)
+	}
+	gatherer.workspaceModules = externalGoModules(projectRoot)
+
 	return &SymbolContextTool{
 		parserRegistry: registry,
-		gatherer:       NewSymbolContextGatherer(registry),
+		gatherer:       gatherer,
 		projectRoot:    projectRoot,
+		contextConfig:  config.DefaultContextConfig(),
 	}
 }
 
+// SetSecurityProfile enables the taint-style context of the security review profile
+func (t *SymbolContextTool) SetSecurityProfile(enabled bool) {
+	t.security = enabled
+}
+
+func (t *SymbolContextTool) SetContextConfig(contextConfig config.ContextConfig) {
+	t.contextConfig = contextConfig
+}
+
 func (t *SymbolContextTool) Name() string {
 	return string(ToolNameSymbolContext)
 }
@@ -47,7 +69,7 @@ func (t *SymbolContextTool) Schema() map[string]any {
 	}
 }
 
-func (t *SymbolContextTool) Execute(args map[string]any) (any, error) {
+func (t *SymbolContextTool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	diffData, hasDiff := args["diffData"].(types.DiffData)
 	if !hasDiff {
 		return types.DiffData{}, fmt.Errorf("diffData parameter required for symbol analysis")
@@ -75,10 +97,30 @@ func (t *SymbolContextTool) Execute(args map[string]any) (any, error) {
 	diffData.DiffContext = diffContext.Context
 	diffData.AffectedSymbols = diffContext.AffectedSymbols
 
+	// The later context passes search the index and the names the gathering searched
+	t.gatherer.resetSearch()
 	err = t.gatherer.GatherSymbolContext(diffData.AffectedSymbols, t.projectRoot, primaryLanguage)
 	if err != nil {
 		return types.DiffData{}, fmt.Errorf("failed to gather symbol usage context: %w", err)
 	}
 
+	t.implementationContext(diffData.AffectedSymbols, primaryLanguage)
+
+	if t.contextConfig.Tests {
+		t.testContext(diffData.AffectedSymbols, primaryLanguage)
+	}
+
+	if t.contextConfig.Depth > 1 {
+		t.callGraphContext(diffData.AffectedSymbols, primaryLanguage)
+	}
+
+	if t.contextConfig.AdaptiveExpansion && isLowInformationDiff(diffData.Diff) {
+		t.expandContext(diffData.AffectedSymbols, primaryLanguage)
+	}
+
+	if t.security {
+		t.securityContext(&diffData, primaryLanguage)
+	}
+
 	return diffData, nil
 }
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// SymbolLookupTool offers the context gathering of a SymbolContextTool to the model during the
+// review: the definitions and usages of a symbol it names, searched like the ones of the
+// changed symbols
+type SymbolLookupTool struct {
+	symbols *SymbolContextTool
+}
+
+func NewSymbolLookupTool(symbols *SymbolContextTool) *SymbolLookupTool {
+	return &SymbolLookupTool{symbols: symbols}
+}
+
+func (t *SymbolLookupTool) Name() string {
+	return string(ToolNameSymbolContext)
+}
+
+func (t *SymbolLookupTool) Description() string {
+	return "Find the definitions and usages of a function, method, type or variable across the repository"
+}
+
+func (t *SymbolLookupTool) Schema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Name of the symbol",
+			},
+			"language": map[string]any{
+				"type":        "string",
+				"description": "Language of the files to search, all languages when empty",
+			},
+		},
+		"required": []string{"name"},
+	}
+}
+
+func (t *SymbolLookupTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return "", fmt.Errorf("name parameter required")
+	}
+	language, _ := args["language"].(string)
+
+	symbolContext, _, _, err := t.symbols.gatherer.gatherContextWithRefs(types.Symbol{Name: name}, t.symbols.projectRoot, language)
+	if err != nil {
+		return "", err
+	}
+	if symbolContext == "" {
+		return fmt.Sprintf("No definition or usage of %s found", name), nil
+	}
+	return symbolContext, nil
+}
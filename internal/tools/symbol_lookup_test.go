@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSymbolLookupTool_Execute(t *testing.T) {
+	root := t.TempDir()
+	runGitCmd(t, root, "git", "init")
+	writeGitFile(t, root, "total.go", "package main\n\nfunc Total(a, b int) int {\n\treturn a + b\n}\n")
+	writeGitFile(t, root, "invoice.go", "package main\n\nfunc Invoice() int {\n\treturn Total(1, 2)\n}\n")
+	runGitCmd(t, root, "git", "add", ".")
+
+	tool := NewSymbolLookupTool(NewSymbolContextTool(root, NewParserRegistry()))
+	result, err := tool.Execute(context.Background(), map[string]any{"name": "Total", "language": "go"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	symbolContext := result.(string)
+	if !strings.Contains(symbolContext, "Definition in") || !strings.Contains(symbolContext, "return Total(1, 2)") {
+		t.Errorf("Expected the definition and the usage of Total, got:\n%s", symbolContext)
+	}
+
+	if result, err := tool.Execute(context.Background(), map[string]any{"name": "Missing"}); err != nil || result != "No definition or usage of Missing found" {
+		t.Errorf("Expected no context for an unknown symbol, got %q, %v", result, err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{}); err == nil {
+		t.Error("Expected an error without a name")
+	}
+}
@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// moveMinSimilarity is the share of body lines two functions must have in common
+	moveMinSimilarity = 0.8
+	// moveMinLines leaves out one-liners, whose bodies match too easily
+	moveMinLines = 2
+)
+
+var moveHunkHeaderRegex = regexp.MustCompile(`^@@\s+-\d+(?:,\d+)?\s+\+(\d+)(?:,(\d+))?\s+@@`)
+
+// FileVersions holds a changed file before and after the diff, Old is nil for added files
+// and New for deleted ones
+type FileVersions struct {
+	Path string
+	Old  []byte
+	New  []byte
+}
+
+// SymbolMove is a function the diff removed and added back under another name or in
+// another file, with a body similar enough to be the same function
+type SymbolMove struct {
+	OldName string
+	NewName string
+	OldFile string
+	NewFile string
+}
+
+// Describe tells the model what happened to the function
+func (m SymbolMove) Describe() string {
+	switch {
+	case m.OldFile == m.NewFile:
+		return fmt.Sprintf("function %s was renamed to %s in %s", m.OldName, m.NewName, m.NewFile)
+	case m.OldName == m.NewName:
+		return fmt.Sprintf("function %s was moved from %s to %s", m.OldName, m.OldFile, m.NewFile)
+	default:
+		return fmt.Sprintf("function %s was moved from %s to %s and renamed to %s", m.OldName, m.OldFile, m.NewFile, m.NewName)
+	}
+}
+
+type moveCandidate struct {
+	file  string
+	name  string
+	lines []string
+}
+
+// DetectSymbolMoves pairs the functions removed from the old versions of the files with the
+// functions added to their new versions, by the similarity of their bodies. The name of the
+// function is ignored when comparing, so renamed recursive functions still match.
+func DetectSymbolMoves(registry *ParserRegistry, files []FileVersions) []SymbolMove {
+	var removed, added []moveCandidate
+	for _, file := range files {
+		parser := registry.GetParser(file.Path)
+		if parser == nil {
+			continue
+		}
+		oldFunctions := parseFunctions(parser, file.Path, file.Old)
+		newFunctions := parseFunctions(parser, file.Path, file.New)
+
+		for name, candidate := range oldFunctions {
+			if _, kept := newFunctions[name]; !kept {
+				removed = append(removed, candidate)
+			}
+		}
+		for name, candidate := range newFunctions {
+			if _, existed := oldFunctions[name]; !existed {
+				added = append(added, candidate)
+			}
+		}
+	}
+
+	type pairing struct {
+		removed, added int
+		similarity     float64
+	}
+	var pairings []pairing
+	for i, oldFunction := range removed {
+		for j, newFunction := range added {
+			if similarity := lineSimilarity(oldFunction.lines, newFunction.lines); similarity >= moveMinSimilarity {
+				pairings = append(pairings, pairing{i, j, similarity})
+			}
+		}
+	}
+
+	// Best matches first, every function takes part in one move at most
+	slices.SortStableFunc(pairings, func(a, b pairing) int {
+		return cmp.Or(
+			cmp.Compare(b.similarity, a.similarity),
+			strings.Compare(removed[a.removed].file+removed[a.removed].name, removed[b.removed].file+removed[b.removed].name),
+			strings.Compare(added[a.added].file+added[a.added].name, added[b.added].file+added[b.added].name),
+		)
+	})
+
+	var moves []SymbolMove
+	usedRemoved := make(map[int]bool)
+	usedAdded := make(map[int]bool)
+	for _, p := range pairings {
+		if usedRemoved[p.removed] || usedAdded[p.added] {
+			continue
+		}
+		usedRemoved[p.removed] = true
+		usedAdded[p.added] = true
+		moves = append(moves, SymbolMove{
+			OldName: removed[p.removed].name,
+			NewName: added[p.added].name,
+			OldFile: removed[p.removed].file,
+			NewFile: added[p.added].file,
+		})
+	}
+
+	return moves
+}
+
+// parseFunctions returns the functions of the content with their normalized body lines,
+// keyed by name. The content is parsed without the registry cache, which holds the
+// current version of the files.
+func parseFunctions(parser LanguageParser, filePath string, content []byte) map[string]moveCandidate {
+	functions := make(map[string]moveCandidate)
+	if content == nil {
+		return functions
+	}
+
+	symbols, err := parser.ParseFile(filePath, content)
+	if err != nil {
+		slog.Debug("parse failed", "file", filePath, "error", err)
+		return functions
+	}
+
+	lines := strings.Split(string(content), "\n")
+	for _, symbol := range symbols {
+		if !slices.Contains(callerTypes, symbol.Type) || symbol.StartLine <= 0 || symbol.EndLine > len(lines) {
+			continue
+		}
+		body := normalizeBody(lines[symbol.StartLine-1:symbol.EndLine], symbol.Name)
+		if len(body) < moveMinLines {
+			continue
+		}
+		functions[symbol.Name] = moveCandidate{file: filePath, name: symbol.Name, lines: body}
+	}
+	return functions
+}
+
+// normalizeBody trims the lines and replaces the function's own name, dropping blank lines
+func normalizeBody(lines []string, name string) []string {
+	nameRegex := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+
+	var normalized []string
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		normalized = append(normalized, nameRegex.ReplaceAllString(line, "_"))
+	}
+	return normalized
+}
+
+// lineSimilarity is the Dice coefficient of the two multisets of lines
+func lineSimilarity(a, b []string) float64 {
+	counts := make(map[string]int)
+	for _, line := range a {
+		counts[line]++
+	}
+
+	common := 0
+	for _, line := range b {
+		if counts[line] > 0 {
+			counts[line]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
+
+// ReconstructOriginal reverts a unified diff on the new content of a file, returning the
+// content before the change. New content is nil for deleted files.
+func ReconstructOriginal(newContent []byte, diff string) ([]byte, error) {
+	var newLines []string
+	if newContent != nil {
+		newLines = strings.Split(string(newContent), "\n")
+	}
+
+	var oldLines []string
+	next := 0
+	inHunk := false
+	for _, line := range strings.Split(diff, "\n") {
+		if matches := moveHunkHeaderRegex.FindStringSubmatch(line); matches != nil {
+			start, err := strconv.Atoi(matches[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid hunk header %q: %w", line, err)
+			}
+			// An empty new side starts after the line it names
+			if matches[2] != "0" {
+				start--
+			}
+			if start < next || start > len(newLines) {
+				return nil, fmt.Errorf("hunk %q does not match the file", line)
+			}
+			oldLines = append(oldLines, newLines[next:start]...)
+			next = start
+			inHunk = true
+			continue
+		}
+		if !inHunk {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, " "):
+			oldLines = append(oldLines, line[1:])
+			next++
+		case strings.HasPrefix(line, "-"):
+			oldLines = append(oldLines, line[1:])
+		case strings.HasPrefix(line, "+"):
+			next++
+		}
+	}
+	if next > len(newLines) {
+		return nil, fmt.Errorf("diff does not match the file")
+	}
+	oldLines = append(oldLines, newLines[next:]...)
+
+	return []byte(strings.Join(oldLines, "\n")), nil
+}
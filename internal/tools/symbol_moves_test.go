@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"testing"
+)
+
+const movesOldStore = `package store
+
+func validateUser(u User) error {
+	if u.Name == "" {
+		return errMissingName
+	}
+	if len(u.Email) > 254 {
+		return errEmailTooLong
+	}
+	return nil
+}
+
+func Save(u User) error {
+	return db.Insert(u)
+}
+`
+
+const movesNewStore = `package store
+
+func Save(u User) error {
+	return db.Insert(u)
+}
+`
+
+const movesNewValidation = `package store
+
+func checkUser(u User) error {
+	if u.Name == "" {
+		return errMissingName
+	}
+	if len(u.Email) > 254 {
+		return errEmailTooLong
+	}
+	return nil
+}
+`
+
+func TestDetectSymbolMoves(t *testing.T) {
+	registry := NewParserRegistry()
+
+	tests := []struct {
+		name     string
+		files    []FileVersions
+		expected []string
+	}{
+		{
+			name: "moved and renamed",
+			files: []FileVersions{
+				{Path: "store/store.go", Old: []byte(movesOldStore), New: []byte(movesNewStore)},
+				{Path: "store/validation.go", New: []byte(movesNewValidation)},
+			},
+			expected: []string{"function validateUser was moved from store/store.go to store/validation.go and renamed to checkUser"},
+		},
+		{
+			name: "renamed in place",
+			files: []FileVersions{
+				{Path: "store/store.go", Old: []byte(movesOldStore), New: []byte(movesNewStore + "\n" + movesNewValidation[len("package store\n"):])},
+			},
+			expected: []string{"function validateUser was renamed to checkUser in store/store.go"},
+		},
+		{
+			name: "removed without replacement",
+			files: []FileVersions{
+				{Path: "store/store.go", Old: []byte(movesOldStore), New: []byte(movesNewStore)},
+			},
+		},
+		{
+			name: "different body",
+			files: []FileVersions{
+				{Path: "store/store.go", Old: []byte(movesOldStore), New: []byte(movesNewStore)},
+				{Path: "store/validation.go", New: []byte("package store\n\nfunc checkUser(u User) error {\n\tlog.Println(u)\n\treturn validate(u)\n}\n")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moves := DetectSymbolMoves(registry, tt.files)
+
+			if len(moves) != len(tt.expected) {
+				t.Fatalf("Expected %d moves, got %+v", len(tt.expected), moves)
+			}
+			for i, move := range moves {
+				if move.Describe() != tt.expected[i] {
+					t.Errorf("Expected %q, got %q", tt.expected[i], move.Describe())
+				}
+			}
+		})
+	}
+}
+
+func TestReconstructOriginal(t *testing.T) {
+	tests := []struct {
+		name       string
+		newContent []byte
+		diff       string
+		expected   string
+	}{
+		{
+			name:       "modified lines",
+			newContent: []byte("a\nB\nc\nd\nE\n"),
+			diff:       "--- a/f.go\n+++ b/f.go\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n@@ -5 +5 @@\n-e\n+E\n",
+			expected:   "a\nb\nc\nd\ne\n",
+		},
+		{
+			name:       "added and removed lines",
+			newContent: []byte("a\nnew\nc\n"),
+			diff:       "@@ -1,4 +1,3 @@\n a\n-b\n-b2\n+new\n c\n",
+			expected:   "a\nb\nb2\nc\n",
+		},
+		{
+			name:       "insertion after a line",
+			newContent: []byte("a\nb\n"),
+			diff:       "@@ -1,0 +2 @@\n+b\n",
+			expected:   "a\n",
+		},
+		{
+			name:       "pure deletion",
+			newContent: []byte("a\nc\n"),
+			diff:       "@@ -2 +1,0 @@\n-b\n",
+			expected:   "a\nb\nc\n",
+		},
+		{
+			name:     "deleted file",
+			diff:     "--- a/f.go\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+			expected: "a\nb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original, err := ReconstructOriginal(tt.newContent, tt.diff)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(original) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(original))
+			}
+		})
+	}
+
+	if _, err := ReconstructOriginal([]byte("a\n"), "@@ -5,1 +5,1 @@\n-x\n+y\n"); err == nil {
+		t.Error("Expected an error for a diff that does not match the file")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/agusespa/diffpector/internal/types"
 )
@@ -21,15 +22,23 @@ type LanguageParser interface {
 	// ShouldExcludeFile determines if a file should be excluded from symbol context gathering
 	// This allows language-specific filtering (e.g., Go excludes *_test.go, JS excludes node_modules)
 	ShouldExcludeFile(filePath, projectRoot string) bool
+
+	// IsTestFile reports whether the file holds tests, following the language's conventions
+	IsTestFile(filePath string) bool
 }
 
+// ParserRegistry picks the parser of a file by extension and caches the parse results.
+// It serializes parsing, the tree-sitter parsers are not safe for concurrent use.
 type ParserRegistry struct {
 	parsers map[string]LanguageParser
+	mu      sync.Mutex
+	cache   *parseCache
 }
 
 func NewParserRegistry() *ParserRegistry {
 	registry := &ParserRegistry{
 		parsers: make(map[string]LanguageParser),
+		cache:   newParseCache(),
 	}
 
 	goParser, err := NewGoParser()
@@ -50,6 +59,11 @@ func NewParserRegistry() *ParserRegistry {
 	}
 	registry.RegisterParser(tsParser)
 
+	registry.RegisterParser(NewSQLParser())
+	registry.RegisterParser(NewHclParser())
+	registry.RegisterParser(NewYAMLParser())
+	registry.RegisterParser(NewBashParser())
+
 	return registry
 }
 
@@ -65,7 +79,9 @@ func (pr *ParserRegistry) ParseFile(filePath string, content []byte) ([]types.Sy
 		return []types.Symbol{}, nil
 	}
 
-	return parser.ParseFile(filePath, content)
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	return pr.cache.parse(parser, filePath, content)
 }
 
 func (pr *ParserRegistry) GetParser(filePath string) LanguageParser {
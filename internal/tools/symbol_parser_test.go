@@ -65,8 +65,13 @@ func TestParserRegistry_GetParser(t *testing.T) {
 		}
 	}
 
-	// Script files should NOT have parsers
-	scriptFiles := []string{"deploy.sh", "setup.bash", "config.zsh", "build.ps1", "run.bat"}
+	// Bash scripts have a parser, the other shells do not
+	for _, file := range []string{"deploy.sh", "setup.bash"} {
+		if parser := registry.GetParser(file); parser == nil || parser.Language() != "Bash" {
+			t.Errorf("Expected the Bash parser for %s, got %v", file, parser)
+		}
+	}
+	scriptFiles := []string{"config.zsh", "build.ps1", "run.bat"}
 	for _, file := range scriptFiles {
 		parser := registry.GetParser(file)
 		if parser != nil {
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/search"
+	"github.com/agusespa/diffpector/internal/vcs"
+)
+
+// resetSearch forgets the tracked files and the searches made so far, which the changes to
+// the working tree since may have made stale
+func (g *SymbolContextGatherer) resetSearch() {
+	g.searchMu.Lock()
+	defer g.searchMu.Unlock()
+	g.indexes = nil
+	g.found = nil
+}
+
+// prefetchSymbols searches the names in one pass over the repository, so that gathering the
+// context of many symbols does not read it once per symbol. A failed search is left to the
+// lookups of the symbols to report.
+func (g *SymbolContextGatherer) prefetchSymbols(names []string, projectRoot, language string) {
+	var patterns []string
+	for _, name := range names {
+		patterns = append(patterns, identifierSearchForms(NormalizeIdentifier(name))...)
+	}
+	_, _ = g.searchPatterns(patterns, projectRoot, language)
+}
+
+// searchPatterns returns the files containing each pattern, searching the patterns not
+// searched yet together: in the repository at projectRoot, and for Go in the workspace
+// modules outside of it. The files of the repository are relative to projectRoot, the ones
+// of the modules absolute.
+func (g *SymbolContextGatherer) searchPatterns(patterns []string, projectRoot, language string) (map[string][]string, error) {
+	g.searchMu.Lock()
+	defer g.searchMu.Unlock()
+	if g.found == nil {
+		g.found = make(map[string][]string)
+		g.indexes = make(map[string]*search.Index)
+	}
+
+	var missing []string
+	for _, pattern := range patterns {
+		if _, ok := g.found[searchKey(projectRoot, language, pattern)]; !ok && !slices.Contains(missing, pattern) {
+			missing = append(missing, pattern)
+		}
+	}
+
+	if len(missing) > 0 {
+		repo := g.repo
+		if repo == nil {
+			repo = vcs.NewGit(projectRoot)
+		}
+		index, err := g.index(projectRoot, repo)
+		if g.base != nil {
+			index, err = g.baseIndex(projectRoot)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		includePatterns := g.getIncludePatterns(language)
+		found := index.Search(missing, includePatterns)
+		if language == "go" {
+			for _, module := range g.workspaceModules {
+				moduleRepo, err := vcs.Detect(module)
+				if err != nil {
+					continue
+				}
+				moduleIndex, err := g.index(module, moduleRepo)
+				if err != nil {
+					continue
+				}
+				for pattern, files := range moduleIndex.Search(missing, includePatterns) {
+					for _, file := range files {
+						found[pattern] = append(found[pattern], platform.JoinPath(module, file))
+					}
+				}
+			}
+		}
+
+		// The patterns found nowhere are kept too, so they are not searched again
+		for _, pattern := range missing {
+			g.found[searchKey(projectRoot, language, pattern)] = found[pattern]
+		}
+	}
+
+	result := make(map[string][]string, len(patterns))
+	for _, pattern := range patterns {
+		result[pattern] = g.found[searchKey(projectRoot, language, pattern)]
+	}
+	return result, nil
+}
+
+// index returns the index of the files tracked under root, listed on first use. It must be
+// called with searchMu held.
+func (g *SymbolContextGatherer) index(root string, repo vcs.VCS) (*search.Index, error) {
+	if index, ok := g.indexes[root]; ok {
+		return index, nil
+	}
+	files, err := repo.TrackedFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files tracked by %s: %w", repo.Name(), err)
+	}
+	index := search.NewIndex(root, files)
+	g.indexes[root] = index
+	return index, nil
+}
+
+// baseIndex returns the index of the files of the base revision. It must be called with
+// searchMu held.
+func (g *SymbolContextGatherer) baseIndex(projectRoot string) (*search.Index, error) {
+	key := projectRoot + "@" + g.base.commit
+	if index, ok := g.indexes[key]; ok {
+		return index, nil
+	}
+	files, err := g.repo.(*vcs.Git).TrackedFilesAt(g.base.commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files of %s: %w", g.base.target, err)
+	}
+	index := search.NewIndex(projectRoot, files)
+	index.Read = g.base.reader.ReadFile
+	g.indexes[key] = index
+	return index, nil
+}
+
+func searchKey(projectRoot, language, pattern string) string {
+	return projectRoot + "\x00" + language + "\x00" + pattern
+}
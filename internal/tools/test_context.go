@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// testMaxReferences bounds the test references shown for each symbol
+const testMaxReferences = 5
+
+// GatherTestReferences lists the tests using the symbol with a snippet of each use. Test
+// files are excluded from the regular context, they are searched apart here.
+func (g *SymbolContextGatherer) GatherTestReferences(symbol types.Symbol, projectRoot, primaryLanguage string) (string, error) {
+	symbol.Name = NormalizeIdentifier(symbol.Name)
+
+	testFiles, err := g.findTestFiles(symbol, projectRoot, primaryLanguage)
+	if err != nil {
+		return "", fmt.Errorf("failed to find test files for symbol %s: %w", symbol.Name, err)
+	}
+
+	var contextBuilder strings.Builder
+	references := 0
+	for _, filePath := range testFiles {
+		content, err := g.readFile(filePath)
+		if err != nil {
+			continue
+		}
+		symbols, err := g.parserRegistry.ParseFile(filePath, content)
+		if err != nil {
+			slog.Debug("parse failed", "file", filePath, "error", err)
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, s := range symbols {
+			if s.Name != symbol.Name || !IsUsageType(s.Type) {
+				continue
+			}
+
+			// Name the test by its enclosing function, once per test
+			testName := "top level"
+			if test, ok := enclosingCallable(symbols, s.StartLine); ok {
+				testName = test.Name
+			}
+			if seen[testName] {
+				continue
+			}
+			seen[testName] = true
+
+			if references == testMaxReferences {
+				contextBuilder.WriteString("...more tests reference it\n")
+				return contextBuilder.String(), nil
+			}
+			references++
+
+			contextBuilder.WriteString(fmt.Sprintf(">>>>>> Test %s in %s (line %d):\n", testName, relativePath(projectRoot, filePath), s.StartLine))
+			contextBuilder.WriteString(extractSnippet(content, s.StartLine, s.EndLine))
+			contextBuilder.WriteString("\n")
+		}
+	}
+
+	return contextBuilder.String(), nil
+}
+
+// findTestFiles returns the absolute paths of the test files mentioning the symbol
+func (g *SymbolContextGatherer) findTestFiles(symbol types.Symbol, projectRoot, primaryLanguage string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+
+	forms := identifierSearchForms(symbol.Name)
+	found, err := g.searchPatterns(forms, projectRoot, primaryLanguage)
+	if err != nil {
+		return nil, err
+	}
+	for _, form := range forms {
+		for _, file := range found[form] {
+			file = strings.TrimSpace(file)
+			parser := g.parserRegistry.GetParser(file)
+			if file == "" || seen[file] || parser == nil || !parser.IsTestFile(file) {
+				continue
+			}
+			seen[file] = true
+
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(projectRoot, file)
+			}
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// testContext adds the existing tests of the changed symbols, and notes the symbols no test
+// references so the model can flag changes lacking tests
+func (t *SymbolContextTool) testContext(affectedSymbols []types.SymbolUsage, primaryLanguage string) {
+	for i := range affectedSymbols {
+		name := affectedSymbols[i].Symbol.Name
+
+		tests, err := t.gatherer.GatherTestReferences(affectedSymbols[i].Symbol, t.projectRoot, primaryLanguage)
+		if err != nil {
+			continue
+		}
+
+		if tests == "" {
+			affectedSymbols[i].Snippets += fmt.Sprintf(">>>>> Existing tests: no test references %s\n", name)
+			continue
+		}
+		affectedSymbols[i].Snippets += fmt.Sprintf(">>>>> Existing tests referencing %s\n%s", name, tests)
+	}
+}
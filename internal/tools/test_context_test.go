@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/pkg/config"
+)
+
+func TestParsers_IsTestFile(t *testing.T) {
+	goParser, _ := NewGoParser()
+	javaParser, _ := NewJavaParser()
+	tsParser, _ := NewTypeScriptParser()
+
+	tests := []struct {
+		parser   LanguageParser
+		path     string
+		expected bool
+	}{
+		{goParser, "internal/store/store_test.go", true},
+		{goParser, "internal/store/store.go", false},
+		{javaParser, "src/test/java/com/app/StoreTest.java", true},
+		{javaParser, "src/main/java/com/app/StoreIT.java", true},
+		{javaParser, "src/main/java/com/app/Contest.java", false},
+		{tsParser, "src/store.spec.ts", true},
+		{tsParser, "src/__tests__/store.tsx", true},
+		{tsParser, "src/store.ts", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.parser.IsTestFile(tt.path); got != tt.expected {
+			t.Errorf("IsTestFile(%s): expected %v, got %v", tt.path, tt.expected, got)
+		}
+	}
+}
+
+func TestSymbolContextTool_ExistingTests(t *testing.T) {
+	tempDir := t.TempDir()
+	runGitCmd(t, tempDir, "git", "init")
+	writeGitFile(t, tempDir, "calc.go", "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\nfunc Sub(a, b int) int {\n\treturn a - b\n}\n")
+	writeGitFile(t, tempDir, "calc_test.go", "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 2) != 3 {\n\t\tt.Fail()\n\t}\n\tif Add(2, 2) != 4 {\n\t\tt.Fail()\n\t}\n}\n")
+	runGitCmd(t, tempDir, "git", "add", ".")
+	runGitCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+
+	diffData := types.DiffData{
+		AbsolutePath: filepath.Join(tempDir, "calc.go"),
+		Diff:         "--- a/calc.go\n+++ b/calc.go\n@@ -3,7 +3,7 @@\n func Add(a, b int) int {\n-\treturn a - b\n+\treturn a + b\n }\n \n func Sub(a, b int) int {\n-\treturn a + b\n+\treturn a - b\n }\n",
+	}
+
+	tool := NewSymbolContextTool(tempDir, NewParserRegistry())
+	tool.SetContextConfig(config.ContextConfig{Tests: true})
+
+	result, err := tool.Execute(context.Background(), map[string]any{"diffData": diffData, "primaryLanguage": "go"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	snippets := make(map[string]string)
+	for _, usage := range result.(types.DiffData).AffectedSymbols {
+		snippets[usage.Symbol.Name] = usage.Snippets
+	}
+
+	add := snippets["Add"]
+	if !strings.Contains(add, ">>>>> Existing tests referencing Add\n>>>>>> Test TestAdd in calc_test.go (line 6):") {
+		t.Errorf("Expected TestAdd in the context of Add, got:\n%s", add)
+	}
+	if strings.Count(add, ">>>>>> Test TestAdd") != 1 {
+		t.Errorf("Expected TestAdd to be listed once, got:\n%s", add)
+	}
+	if !strings.Contains(snippets["Sub"], ">>>>> Existing tests: no test references Sub") {
+		t.Errorf("Expected Sub to be noted as untested, got:\n%s", snippets["Sub"])
+	}
+}
@@ -1,12 +1,18 @@
 package tools
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 type Tool interface {
 	Name() string
 	Description() string
 	Schema() map[string]any
-	Execute(args map[string]any) (any, error)
+	// Execute runs the tool, the tools running commands or requests stop them when the
+	// context is done
+	Execute(ctx context.Context, args map[string]any) (any, error)
 }
 
 type ToolName string
@@ -18,10 +24,18 @@ const (
 	ToolNameWriteFile     ToolName = "write_file"
 	ToolNameGitGrep       ToolName = "git_grep"
 	ToolNameHumanLoop     ToolName = "human_loop"
+	ToolNameApplyPatch    ToolName = "apply_patch"
+	ToolNameGitBlame      ToolName = "git_blame"
+	ToolNameSimilarCode   ToolName = "similar_code"
+	ToolNameCallSites     ToolName = "call_sites"
+	ToolNameReferences    ToolName = "symbol_references"
 )
 
 type ToolRegistry struct {
 	tools map[ToolName]Tool
+	// policy checks and records the tool calls of the model, see Invoke. Nil runs them
+	// unchecked.
+	policy *Policy
 }
 
 func NewToolRegistry() *ToolRegistry {
@@ -42,6 +56,39 @@ func (r *ToolRegistry) Get(name ToolName) Tool {
 	return tool
 }
 
+// SetPolicy confines the tool calls of the model run with Invoke to the policy
+func (r *ToolRegistry) SetPolicy(policy *Policy) {
+	r.policy = policy
+}
+
+// Invoke runs a tool call of the model. With a policy the call is refused with an ErrPolicy
+// error when it breaks it, and recorded either way. The tool need not be registered, like
+// the tools only offered to the model.
+func (r *ToolRegistry) Invoke(ctx context.Context, tool Tool, args map[string]any) (any, error) {
+	if r.policy == nil {
+		return tool.Execute(ctx, args)
+	}
+
+	invocation := Invocation{Time: time.Now().UTC(), Tool: tool.Name(), Arguments: args}
+	defer func() {
+		if r.policy.Record != nil {
+			r.policy.Record(invocation)
+		}
+	}()
+	if err := r.policy.Check(tool.Name(), args); err != nil {
+		invocation.Denied = err.Error()
+		return nil, err
+	}
+
+	start := time.Now()
+	result, err := tool.Execute(ctx, args)
+	invocation.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		invocation.Error = err.Error()
+	}
+	return result, err
+}
+
 func (r *ToolRegistry) GetAll() map[ToolName]Tool {
 	return r.tools
 }
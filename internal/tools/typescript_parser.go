@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/agusespa/diffpector/internal/platform"
 	"github.com/agusespa/diffpector/internal/types"
 	sitter "github.com/tree-sitter/go-tree-sitter"
 	tree_sitter_typescript "github.com/tree-sitter/tree-sitter-typescript/bindings/go"
@@ -56,7 +57,7 @@ func (tp *TypeScriptParser) SupportedExtensions() []string {
 }
 
 func (tp *TypeScriptParser) ShouldExcludeFile(filePath, projectRoot string) bool {
-	lowerPath := strings.ToLower(filePath)
+	lowerPath := strings.ToLower(platform.NormalizePath(filePath))
 
 	tsExcludePatterns := []string{
 		"test/",
@@ -81,24 +82,51 @@ func (tp *TypeScriptParser) ShouldExcludeFile(filePath, projectRoot string) bool
 	return false
 }
 
+func (tp *TypeScriptParser) IsTestFile(filePath string) bool {
+	lowerPath := strings.ToLower(platform.NormalizePath(filePath))
+
+	for _, suffix := range []string{".spec.ts", ".test.ts", ".spec.tsx", ".test.tsx"} {
+		if strings.HasSuffix(lowerPath, suffix) {
+			return true
+		}
+	}
+	return strings.Contains(lowerPath, "__tests__/")
+}
+
 func (tp *TypeScriptParser) ParseFile(filePath string, content []byte) ([]types.Symbol, error) {
+	tree, err := tp.ParseTree(filePath, content, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	return tp.ExtractSymbols(filePath, tree, content)
+}
+
+// ParseTree parses the file, reusing the unchanged parts of oldTree when it is not nil.
+// The caller closes the returned tree.
+func (tp *TypeScriptParser) ParseTree(filePath string, content []byte, oldTree *sitter.Tree) (*sitter.Tree, error) {
 	// Use TSX parser for .tsx files, TypeScript parser for .ts files
-	isTSX := strings.HasSuffix(filePath, ".tsx")
 	var tree *sitter.Tree
-	var lang *sitter.Language
-
-	if isTSX {
-		tree = tp.tsxParser.Parse(content, nil)
-		lang = tp.tsxLanguage
+	if strings.HasSuffix(filePath, ".tsx") {
+		tree = tp.tsxParser.Parse(content, oldTree)
 	} else {
-		tree = tp.parser.Parse(content, nil)
-		lang = tp.language
+		tree = tp.parser.Parse(content, oldTree)
 	}
 
 	if tree == nil {
 		return nil, fmt.Errorf("failed to parse TypeScript file")
 	}
-	defer tree.Close()
+	return tree, nil
+}
+
+// ExtractSymbols collects the declarations and usages of a parsed file
+func (tp *TypeScriptParser) ExtractSymbols(filePath string, tree *sitter.Tree, content []byte) ([]types.Symbol, error) {
+	isTSX := strings.HasSuffix(filePath, ".tsx")
+	lang := tp.language
+	if isTSX {
+		lang = tp.tsxLanguage
+	}
 
 	packageName := tp.extractModuleName(filePath)
 
@@ -191,6 +219,7 @@ func (tp *TypeScriptParser) ParseFile(filePath string, content []byte) ([]types.
 				name = strings.TrimSpace(c.Node.Utf8Text(content))
 			}
 
+			name = NormalizeIdentifier(name)
 			if name == "" {
 				continue
 			}
@@ -241,7 +270,7 @@ func (tp *TypeScriptParser) extractDeclarationName(node sitter.Node, content []b
 }
 
 func (tp *TypeScriptParser) extractModuleName(filePath string) string {
-	parts := strings.Split(filePath, "/")
+	parts := strings.Split(platform.NormalizePath(filePath), "/")
 	if len(parts) > 0 {
 		fileName := parts[len(parts)-1]
 		return strings.TrimSuffix(strings.TrimSuffix(fileName, ".ts"), ".tsx")
@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// YAMLParser extracts the structure of Kubernetes manifests and GitHub Actions workflows:
+// the objects and their containers, the jobs and their steps, and the references between
+// them. Other YAML files have no symbols.
+type YAMLParser struct{}
+
+func NewYAMLParser() *YAMLParser {
+	return &YAMLParser{}
+}
+
+func (yp *YAMLParser) Language() string {
+	return "YAML"
+}
+
+func (yp *YAMLParser) SupportedExtensions() []string {
+	return []string{`.yaml`, `.yml`}
+}
+
+func (yp *YAMLParser) ShouldExcludeFile(filePath, projectRoot string) bool {
+	lowerPath := strings.ToLower(platform.NormalizePath(filePath))
+	for _, pattern := range []string{"vendor/", "node_modules/", "testdata/", ".git/"} {
+		if strings.Contains(lowerPath, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func (yp *YAMLParser) IsTestFile(filePath string) bool {
+	return false
+}
+
+// containerKeys hold the containers of a pod spec, wherever the pod template is nested
+var containerKeys = []string{"containers", "initContainers", "ephemeralContainers"}
+
+// objectReferenceKeys maps the keys referencing another object of the cluster to the key
+// holding its name, e.g. envFrom.configMapRef.name or volumes.secret.secretName
+var objectReferenceKeys = map[string]string{
+	"configMapRef":          "name",
+	"configMapKeyRef":       "name",
+	"secretRef":             "name",
+	"secretKeyRef":          "name",
+	"configMap":             "name",
+	"secret":                "secretName",
+	"persistentVolumeClaim": "claimName",
+}
+
+// ParseFile returns a manifest_decl per Kubernetes object, named by its metadata.name in
+// the package of its kind, and a container_decl per container in the package Kind/name. A
+// workflow has a job_decl per job in the package of the workflow name and a step_decl per
+// named step in the package of its job. The objects referenced by name, such as config maps,
+// secrets and needed jobs, are reference_usage symbols. Templated files which are not valid
+// YAML, e.g. Helm charts, keep the symbols of the documents before the first invalid one.
+func (yp *YAMLParser) ParseFile(filePath string, content []byte) ([]types.Symbol, error) {
+	var symbols []types.Symbol
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		// Besides the end of the file, templates are not YAML until rendered
+		if err := decoder.Decode(&document); err != nil {
+			break
+		}
+		if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		root := document.Content[0]
+
+		switch {
+		case mappingValue(root, "apiVersion") != nil && mappingValue(root, "kind") != nil:
+			symbols = append(symbols, kubernetesSymbols(filePath, root)...)
+		case isWorkflow(filePath, root):
+			symbols = append(symbols, workflowSymbols(filePath, root)...)
+		}
+	}
+
+	return symbols, nil
+}
+
+func kubernetesSymbols(filePath string, root *yaml.Node) []types.Symbol {
+	kind := mappingValue(root, "kind").Value
+	name := ""
+	if metadata := mappingValue(root, "metadata"); metadata != nil {
+		if value := mappingValue(metadata, "name"); value != nil {
+			name = value.Value
+		}
+	}
+
+	var symbols []types.Symbol
+	if name != "" {
+		symbols = append(symbols, yamlSymbol(filePath, name, "manifest_decl", kind, root))
+	}
+
+	walkYAML(root, func(key string, value *yaml.Node) {
+		switch {
+		case slices.Contains(containerKeys, key) && value.Kind == yaml.SequenceNode:
+			for _, container := range value.Content {
+				if containerName := mappingValue(container, "name"); containerName != nil {
+					symbols = append(symbols, yamlSymbol(filePath, containerName.Value, "container_decl", kind+"/"+name, container))
+				}
+			}
+		case key == "serviceAccountName" && value.Kind == yaml.ScalarNode:
+			symbols = append(symbols, yamlSymbol(filePath, value.Value, "reference_usage", kind+"/"+name, value))
+		case objectReferenceKeys[key] != "":
+			if referenced := mappingValue(value, objectReferenceKeys[key]); referenced != nil && referenced.Kind == yaml.ScalarNode {
+				symbols = append(symbols, yamlSymbol(filePath, referenced.Value, "reference_usage", kind+"/"+name, referenced))
+			}
+		}
+	})
+	return symbols
+}
+
+// isWorkflow reports whether a document is a GitHub Actions workflow, by its location or
+// its on and jobs keys
+func isWorkflow(filePath string, root *yaml.Node) bool {
+	jobs := mappingValue(root, "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return false
+	}
+	return strings.Contains(platform.NormalizePath(filePath), ".github/workflows/") || mappingValue(root, "on") != nil
+}
+
+func workflowSymbols(filePath string, root *yaml.Node) []types.Symbol {
+	workflow := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	if name := mappingValue(root, "name"); name != nil && name.Kind == yaml.ScalarNode {
+		workflow = name.Value
+	}
+
+	var symbols []types.Symbol
+	jobs := mappingValue(root, "jobs")
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		jobID, job := jobs.Content[i].Value, jobs.Content[i+1]
+		symbol := yamlSymbol(filePath, jobID, "job_decl", workflow, job)
+		symbol.StartLine = jobs.Content[i].Line
+		symbols = append(symbols, symbol)
+
+		if needs := mappingValue(job, "needs"); needs != nil {
+			for _, needed := range append([]*yaml.Node{needs}, needs.Content...) {
+				if needed.Kind == yaml.ScalarNode {
+					symbols = append(symbols, yamlSymbol(filePath, needed.Value, "reference_usage", workflow, needed))
+				}
+			}
+		}
+
+		if steps := mappingValue(job, "steps"); steps != nil && steps.Kind == yaml.SequenceNode {
+			for _, step := range steps.Content {
+				for _, key := range []string{"name", "id", "uses"} {
+					if stepName := mappingValue(step, key); stepName != nil && stepName.Kind == yaml.ScalarNode {
+						symbols = append(symbols, yamlSymbol(filePath, stepName.Value, "step_decl", jobID, step))
+						break
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+func yamlSymbol(filePath, name, symbolType, pkg string, node *yaml.Node) types.Symbol {
+	return types.Symbol{
+		Name:      name,
+		Type:      symbolType,
+		Package:   pkg,
+		FilePath:  filePath,
+		StartLine: node.Line,
+		EndLine:   lastLine(node),
+	}
+}
+
+// mappingValue returns the value of a key of a mapping node, nil when absent
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// walkYAML calls visit with every key and value of the mappings under a node
+func walkYAML(node *yaml.Node, visit func(key string, value *yaml.Node)) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			visit(node.Content[i].Value, node.Content[i+1])
+		}
+	}
+	for _, child := range node.Content {
+		walkYAML(child, visit)
+	}
+}
+
+// lastLine returns the last line a node spans. Nodes only record where they start, so this
+// is the start of the last descendant, plus the lines of a block scalar.
+func lastLine(node *yaml.Node) int {
+	last := node.Line
+	if node.Kind == yaml.ScalarNode && (node.Style == yaml.LiteralStyle || node.Style == yaml.FoldedStyle) {
+		last += strings.Count(strings.TrimRight(node.Value, "\n"), "\n") + 1
+	}
+	for _, child := range node.Content {
+		last = max(last, lastLine(child))
+	}
+	return last
+}
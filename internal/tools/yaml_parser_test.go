@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+const deploymentYAML = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  LOG_LEVEL: info
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      serviceAccountName: web-sa
+      initContainers:
+        - name: migrate
+          image: web:1.2
+      containers:
+        - name: app
+          image: web:1.2
+          envFrom:
+            - configMapRef:
+                name: web-config
+          command:
+            - |
+              ./serve
+              --port 8080
+`
+
+const workflowYAML = `name: CI
+on: [push]
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - name: Test
+        run: go test ./...
+      - run: echo unnamed
+  deploy:
+    needs: build
+    runs-on: ubuntu-latest
+    steps:
+      - id: release
+        run: ./release.sh
+`
+
+func TestYAMLParser_ParseFile(t *testing.T) {
+	parser := NewYAMLParser()
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		expected []string
+	}{
+		{
+			name:    "kubernetes manifests",
+			path:    "deploy/web.yaml",
+			content: deploymentYAML,
+			expected: []string{
+				"manifest_decl ConfigMap.web-config 1-6",
+				"manifest_decl Deployment.web 8-28",
+				"reference_usage Deployment/web.web-sa 15-15",
+				"container_decl Deployment/web.migrate 17-18",
+				"container_decl Deployment/web.app 20-28",
+				"reference_usage Deployment/web.web-config 24-24",
+			},
+		},
+		{
+			name:    "github actions workflow",
+			path:    ".github/workflows/ci.yml",
+			content: workflowYAML,
+			expected: []string{
+				"job_decl CI.build 4-10",
+				"step_decl build.actions/checkout@v4 7-7",
+				"step_decl build.Test 8-9",
+				"job_decl CI.deploy 11-16",
+				"reference_usage CI.build 12-12",
+				"step_decl deploy.release 15-16",
+			},
+		},
+		{
+			name:     "plain configuration",
+			path:     "config.yaml",
+			content:  "server:\n  port: 8080\n",
+			expected: nil,
+		},
+		{
+			name:     "helm template",
+			path:     "chart/templates/deployment.yaml",
+			content:  "apiVersion: v1\nkind: Service\nmetadata:\n  name: {{ .Release.Name }}\n",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			symbols, err := parser.ParseFile(tt.path, []byte(tt.content))
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+
+			var got []string
+			for _, symbol := range symbols {
+				got = append(got, fmt.Sprintf("%s %s.%s %d-%d", symbol.Type, symbol.Package, symbol.Name, symbol.StartLine, symbol.EndLine))
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Unexpected symbols:\n%v\nexpected:\n%v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+// Package trends records a summary of every review run and computes how the findings evolve
+// over time, such as the issues per thousand changed lines or the most flagged directories.
+package trends
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/platform"
+	"github.com/agusespa/diffpector/internal/usage"
+)
+
+// DefaultPath is where the runs are recorded, relative to the repository root
+const DefaultPath = ".diffpector/history.jsonl"
+
+// Run is the summary of a review, one JSON line of the history
+type Run struct {
+	Time   time.Time `json:"time"`
+	Commit string    `json:"commit,omitempty"`
+	Branch string    `json:"branch,omitempty"`
+	Model  string    `json:"model,omitempty"`
+	Files  []string  `json:"files"`
+	// ChangedLines counts the lines the diffs of the files added and removed
+	ChangedLines int `json:"changed_lines"`
+	// Counts holds the number of issues per severity level
+	Counts map[string]int `json:"counts"`
+	// Directories holds the number of issues per directory of the flagged files
+	Directories map[string]int `json:"directories,omitempty"`
+	Duration    int64          `json:"duration_ms"`
+	// Usage holds the resources the run consumed, nil when they were not measured
+	Usage *usage.Summary `json:"usage,omitempty"`
+}
+
+// Issues counts the issues of the run, whatever their severity
+func (r Run) Issues() int {
+	total := 0
+	for _, count := range r.Counts {
+		total += count
+	}
+	return total
+}
+
+// Store appends the runs to a JSON lines file shared by every review of the repository
+type Store struct {
+	path string
+}
+
+// Open returns the store of the history file at path, creating its directory. A path in the
+// .diffpector directory is ignored by git.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	if err := platform.IgnoreStateDir(path); err != nil {
+		return nil, fmt.Errorf("failed to ignore history directory: %w", err)
+	}
+	return &Store{path: path}, nil
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Record appends a run to the history
+func (s *Store) Record(run Run) error {
+	line, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode review run: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Load reads the recorded runs, oldest first. A missing history has no runs.
+func (s *Store) Load() ([]Run, error) {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for number := 1; scanner.Scan(); number++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("failed to parse line %d of %s: %w", number, s.path, err)
+		}
+		runs = append(runs, run)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return runs, nil
+}
+
+// Directory returns the directory counted for an issue of the file, "." for the files at
+// the repository root
+func Directory(filePath string) string {
+	return path.Dir(platform.NormalizePath(filePath))
+}
+
+// DirectoryCount is the number of issues found in a directory over all runs
+type DirectoryCount struct {
+	Directory string
+	Issues    int
+}
+
+// Week groups the runs started in the week beginning on Monday Start
+type Week struct {
+	Start    time.Time
+	Runs     int
+	Critical int
+}
+
+// AverageCritical is the number of critical issues per run of the week
+func (w Week) AverageCritical() float64 {
+	if w.Runs == 0 {
+		return 0
+	}
+	return float64(w.Critical) / float64(w.Runs)
+}
+
+// Trends summarizes the recorded runs
+type Trends struct {
+	Runs         int
+	Issues       int
+	ChangedLines int
+	// Directories are sorted from the most flagged
+	Directories []DirectoryCount
+	// Weeks are sorted from the oldest, weeks without runs are left out
+	Weeks []Week
+	// MeasuredRuns counts the runs that recorded their resource usage, Usage adding it up
+	// except for the peak memory, the highest of them
+	MeasuredRuns int
+	Usage        usage.Summary
+}
+
+// AverageUsage is the resource usage per measured run, with the highest peak memory
+func (t Trends) AverageUsage() usage.Summary {
+	if t.MeasuredRuns == 0 {
+		return usage.Summary{}
+	}
+	runs := int64(t.MeasuredRuns)
+	return usage.Summary{
+		WallTime:          t.Usage.WallTime / time.Duration(runs),
+		PeakMemoryBytes:   t.Usage.PeakMemoryBytes,
+		SubprocessCPUTime: t.Usage.SubprocessCPUTime / time.Duration(runs),
+		LLMCalls:          t.Usage.LLMCalls / t.MeasuredRuns,
+		LLMWallTime:       t.Usage.LLMWallTime / time.Duration(runs),
+		BytesSent:         t.Usage.BytesSent / runs,
+		BytesReceived:     t.Usage.BytesReceived / runs,
+	}
+}
+
+// IssuesPerKLOC is the number of issues per thousand changed lines
+func (t Trends) IssuesPerKLOC() float64 {
+	if t.ChangedLines == 0 {
+		return 0
+	}
+	return float64(t.Issues) * 1000 / float64(t.ChangedLines)
+}
+
+// Compute summarizes the runs, critical being the name of the most severe level
+func Compute(runs []Run, critical string) Trends {
+	trends := Trends{Runs: len(runs)}
+	directories := make(map[string]int)
+	weeks := make(map[time.Time]*Week)
+	for _, run := range runs {
+		trends.Issues += run.Issues()
+		trends.ChangedLines += run.ChangedLines
+		for directory, count := range run.Directories {
+			directories[directory] += count
+		}
+
+		start := weekStart(run.Time)
+		week, ok := weeks[start]
+		if !ok {
+			week = &Week{Start: start}
+			weeks[start] = week
+		}
+		week.Runs++
+		week.Critical += run.Counts[critical]
+
+		if run.Usage != nil {
+			trends.MeasuredRuns++
+			trends.Usage.WallTime += run.Usage.WallTime
+			trends.Usage.PeakMemoryBytes = max(trends.Usage.PeakMemoryBytes, run.Usage.PeakMemoryBytes)
+			trends.Usage.SubprocessCPUTime += run.Usage.SubprocessCPUTime
+			trends.Usage.LLMCalls += run.Usage.LLMCalls
+			trends.Usage.LLMWallTime += run.Usage.LLMWallTime
+			trends.Usage.BytesSent += run.Usage.BytesSent
+			trends.Usage.BytesReceived += run.Usage.BytesReceived
+		}
+	}
+
+	for directory, count := range directories {
+		trends.Directories = append(trends.Directories, DirectoryCount{Directory: directory, Issues: count})
+	}
+	slices.SortFunc(trends.Directories, func(a, b DirectoryCount) int {
+		if a.Issues != b.Issues {
+			return b.Issues - a.Issues
+		}
+		return strings.Compare(a.Directory, b.Directory)
+	})
+	for _, week := range weeks {
+		trends.Weeks = append(trends.Weeks, *week)
+	}
+	slices.SortFunc(trends.Weeks, func(a, b Week) int { return a.Start.Compare(b.Start) })
+	return trends
+}
+
+// Format prints the trends for the console, listing the top most flagged directories and
+// the weeks with the average critical count, critical being the name of the most severe level
+func (t Trends) Format(critical string, top int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Reviews: %d\n", t.Runs)
+	fmt.Fprintf(&b, "Issues: %d in %d changed line(s), %.1f per KLOC\n", t.Issues, t.ChangedLines, t.IssuesPerKLOC())
+
+	if len(t.Directories) > 0 {
+		b.WriteString("\nMost flagged directories:\n")
+		for _, directory := range t.Directories[:min(len(t.Directories), top)] {
+			fmt.Fprintf(&b, "  %-40s %d\n", directory.Directory, directory.Issues)
+		}
+	}
+
+	if len(t.Weeks) > 0 {
+		fmt.Fprintf(&b, "\nAverage %s issues per review, by week:\n", critical)
+		for _, week := range t.Weeks {
+			fmt.Fprintf(&b, "  %s  %.2f (%d review(s))\n", week.Start.Format("2006-01-02"), week.AverageCritical(), week.Runs)
+		}
+	}
+
+	if t.MeasuredRuns > 0 {
+		average := t.AverageUsage()
+		fmt.Fprintf(&b, "\nResource usage per review (%d measured):\n", t.MeasuredRuns)
+		fmt.Fprintf(&b, "  LLM time: %s (%d requests)\n", average.LLMWallTime.Round(time.Millisecond), average.LLMCalls)
+		fmt.Fprintf(&b, "  LLM traffic: %s sent, %s received\n", usage.FormatBytes(average.BytesSent), usage.FormatBytes(average.BytesReceived))
+		if average.PeakMemoryBytes > 0 {
+			fmt.Fprintf(&b, "  Peak memory: %s at most\n", usage.FormatBytes(average.PeakMemoryBytes))
+		}
+		fmt.Fprintf(&b, "  Subprocess CPU time: %s\n", average.SubprocessCPUTime.Round(time.Millisecond))
+	}
+	return b.String()
+}
+
+// weekStart returns the Monday starting the week of t, in the time zone of t
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	year, month, day := t.AddDate(0, 0, -offset).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,113 @@
+package trends
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/usage"
+)
+
+func TestStoreRecordAndLoad(t *testing.T) {
+	root := t.TempDir()
+	store, err := Open(filepath.Join(root, ".diffpector", "history.jsonl"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".diffpector", ".gitignore")); err != nil {
+		t.Errorf("Expected the .diffpector directory to be ignored: %v", err)
+	}
+
+	runs, err := store.Load()
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("Expected no runs before the first review, got %v, %v", runs, err)
+	}
+
+	first := Run{Time: time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC), Commit: "abc123", Model: "qwen", Files: []string{"main.go"}, ChangedLines: 40, Counts: map[string]int{"CRITICAL": 1}}
+	second := Run{Time: time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC), Files: []string{"util.go"}, ChangedLines: 10, Counts: map[string]int{}}
+	for _, run := range []Run{first, second} {
+		if err := store.Record(run); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	runs, err = store.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Commit != "abc123" || runs[0].Counts["CRITICAL"] != 1 || runs[1].ChangedLines != 10 {
+		t.Errorf("Expected the recorded runs in order, got %+v", runs)
+	}
+}
+
+func TestStoreLoadMalformedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path, []byte("{\"files\":[]}\nnot json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := store.Load(); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected the malformed line to be reported, got %v", err)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	runs := []Run{
+		// Monday and Sunday of the same week
+		{Time: time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC), ChangedLines: 500, Counts: map[string]int{"CRITICAL": 2, "WARNING": 1}, Directories: map[string]int{"internal/api": 2, ".": 1}},
+		{Time: time.Date(2025, 3, 9, 22, 0, 0, 0, time.UTC), ChangedLines: 300, Counts: map[string]int{"MINOR": 1}, Directories: map[string]int{"internal/db": 1}},
+		{Time: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), ChangedLines: 200, Counts: map[string]int{"CRITICAL": 1}, Directories: map[string]int{"internal/api": 1}},
+	}
+	runs[1].Usage = &usage.Summary{LLMCalls: 2, LLMWallTime: 3 * time.Second, BytesSent: 4096, BytesReceived: 1024, PeakMemoryBytes: 64 << 20, SubprocessCPUTime: 100 * time.Millisecond}
+	runs[2].Usage = &usage.Summary{LLMCalls: 4, LLMWallTime: 5 * time.Second, BytesSent: 2048, BytesReceived: 3072, PeakMemoryBytes: 32 << 20, SubprocessCPUTime: 300 * time.Millisecond}
+
+	trends := Compute(runs, "CRITICAL")
+
+	if trends.Runs != 3 || trends.Issues != 5 || trends.ChangedLines != 1000 || trends.IssuesPerKLOC() != 5 {
+		t.Errorf("Expected 5 issues in 1000 lines, got %+v", trends)
+	}
+	if len(trends.Directories) != 3 || trends.Directories[0] != (DirectoryCount{"internal/api", 3}) || trends.Directories[1].Directory != "." {
+		t.Errorf("Expected internal/api to be the most flagged, got %+v", trends.Directories)
+	}
+	if len(trends.Weeks) != 2 {
+		t.Fatalf("Expected two weeks, got %+v", trends.Weeks)
+	}
+	if !trends.Weeks[0].Start.Equal(time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)) || trends.Weeks[0].Runs != 2 || trends.Weeks[0].AverageCritical() != 1 {
+		t.Errorf("Expected the first week to average one critical issue, got %+v", trends.Weeks[0])
+	}
+	if trends.Weeks[1].AverageCritical() != 1 || trends.Weeks[1].Runs != 1 {
+		t.Errorf("Expected the second week to have one run, got %+v", trends.Weeks[1])
+	}
+
+	output := trends.Format("CRITICAL", 1)
+	if !strings.Contains(output, "5.0 per KLOC") || !strings.Contains(output, "internal/api") || strings.Contains(output, "internal/db") {
+		t.Errorf("Expected the KLOC rate and the top directory only, got:\n%s", output)
+	}
+	if !strings.Contains(output, "2025-03-10  1.00 (1 review(s))") {
+		t.Errorf("Expected the weekly critical average, got:\n%s", output)
+	}
+
+	if trends.MeasuredRuns != 2 {
+		t.Errorf("Expected the runs without usage to be left out, got %d", trends.MeasuredRuns)
+	}
+	for _, part := range []string{"(2 measured)", "LLM time: 4s (3 requests)", "3.0 KiB sent, 2.0 KiB received", "Peak memory: 64.0 MiB at most", "Subprocess CPU time: 200ms"} {
+		if !strings.Contains(output, part) {
+			t.Errorf("Expected %q in the resource usage, got:\n%s", part, output)
+		}
+	}
+}
+
+func TestDirectory(t *testing.T) {
+	if got := Directory("main.go"); got != "." {
+		t.Errorf("Expected the root directory, got %q", got)
+	}
+	if got := Directory("internal/api/handler.go"); got != "internal/api" {
+		t.Errorf("Expected internal/api, got %q", got)
+	}
+}
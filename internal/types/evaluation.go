@@ -26,6 +26,17 @@ type TestCase struct {
 	Description string          `json:"description"`
 	DiffFile    string          `json:"diff_file,omitempty"`
 	Expected    ExpectedResults `json:"expected"`
+	// ExpectedContext annotates the context the review should be given, scored by the
+	// context-only evaluation
+	ExpectedContext *ExpectedContext `json:"expected_context,omitempty"`
+}
+
+// ExpectedContext lists the ground truth of the context layer for a test case
+type ExpectedContext struct {
+	// Symbols must be among the affected symbols of the changed files
+	Symbols []string `json:"symbols,omitempty"`
+	// UsageFiles must appear in the usage snippets, matched as path suffixes
+	UsageFiles []string `json:"usage_files,omitempty"`
 }
 
 type ExpectedResults struct {
@@ -34,6 +45,24 @@ type ExpectedResults struct {
 	ExpectedFiles    []string `json:"expected_files,omitempty"`
 	MinIssues        int      `json:"min_issues,omitempty"`
 	MaxIssues        int      `json:"max_issues,omitempty"`
+	// ExpectedIssues labels the issues a review should report, enabling precision and recall
+	ExpectedIssues []ExpectedIssue `json:"expected_issues,omitempty"`
+}
+
+type ExpectedIssue struct {
+	FilePath  string `json:"file_path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	// Category must match the category of the reported issue when both are set
+	Category string `json:"category,omitempty"`
+}
+
+// IssueMetrics compares the reported issues with the labeled expected issues of a test case
+type IssueMetrics struct {
+	Precision     float64 `json:"precision"`
+	Recall        float64 `json:"recall"`
+	F1            float64 `json:"f1"`
+	TruePositives int     `json:"true_positives"`
 }
 
 type EvaluationRun struct {
@@ -47,6 +76,11 @@ type EvaluationRun struct {
 	AverageScore  float64          `json:"average_score"`
 	SuccessRate   float64          `json:"success_rate"`
 	RunNumber     int              `json:"run_number,omitempty"`
+	// Precision, Recall and F1 average the metrics of the test cases with labeled issues
+	LabeledTests int     `json:"labeled_tests,omitempty"`
+	Precision    float64 `json:"precision,omitempty"`
+	Recall       float64 `json:"recall,omitempty"`
+	F1           float64 `json:"f1,omitempty"`
 }
 
 type EvaluationResult struct {
@@ -69,6 +103,10 @@ type EvaluationStats struct {
 	SuccessRateStdDev  float64 `json:"success_rate_std_dev"`
 	AverageDuration    float64 `json:"average_duration_seconds"`
 	DurationStdDev     float64 `json:"duration_std_dev_seconds"`
+	AveragePrecision   float64 `json:"average_precision,omitempty"`
+	AverageRecall      float64 `json:"average_recall,omitempty"`
+	AverageF1          float64 `json:"average_f1,omitempty"`
+	F1StdDev           float64 `json:"f1_std_dev,omitempty"`
 }
 
 type TestCaseStats struct {
@@ -85,6 +123,23 @@ type TestCaseResult struct {
 	ExecutionTime time.Duration `json:"execution_time"`
 	Success       bool          `json:"success"`
 	Score         float64       `json:"score"`
+	Metrics       *IssueMetrics `json:"metrics,omitempty"`
 	Errors        []string      `json:"errors,omitempty"`
 	Timestamp     time.Time     `json:"timestamp"`
 }
+
+type ContextCaseResult struct {
+	TestCaseName   string   `json:"test_case_name"`
+	SymbolRecall   float64  `json:"symbol_recall"`
+	UsageRecall    float64  `json:"usage_recall"`
+	Score          float64  `json:"score"`
+	MissingSymbols []string `json:"missing_symbols,omitempty"`
+	MissingUsages  []string `json:"missing_usages,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+type ContextEvaluationResult struct {
+	Timestamp    time.Time           `json:"timestamp"`
+	Cases        []ContextCaseResult `json:"cases"`
+	AverageScore float64             `json:"average_score"`
+}
@@ -1,15 +1,98 @@
 package types
 
+import (
+	"fmt"
+	"strings"
+)
+
 type DiffData struct {
 	AbsolutePath    string
 	Diff            string
 	DiffContext     string
 	AffectedSymbols []SymbolUsage
+	// SymbolMoves describes the functions of the file the diff renamed or moved, which
+	// would otherwise look like unrelated removals and additions
+	SymbolMoves []string
+	// ChangeHistory lists the last commits that touched the changed lines, so the model
+	// knows how recently and why the code was changed before
+	ChangeHistory string
+	// SimilarCode lists the existing code the added lines nearly duplicate
+	SimilarCode []SimilarCode
+	// DependencyChanges summarizes the dependencies a manifest change adds, removes or
+	// upgrades, empty for the other files
+	DependencyChanges string
+	// StaleCallers lists the calls of the functions whose parameters the diff changed that
+	// still pass the old arguments, outside the lines of the diff
+	StaleCallers []CallSite
+}
+
+// CallSite is a call of a function, Code holding the lines from the call on
+type CallSite struct {
+	Name     string
+	FilePath string
+	Line     int
+	Code     string
+}
+
+// GrepMatch is a line matching a search, Column being the byte position of the first match
+// on the line, from 1
+type GrepMatch struct {
+	FilePath string
+	Line     int
+	Column   int
+	Text     string
+}
+
+// GrepResult holds the matches of a search, Truncated when the match limit left some out
+type GrepResult struct {
+	Pattern   string
+	Matches   []GrepMatch
+	Truncated bool
+}
+
+// String prints the matches as file:line:column:text lines, the way the model reads them
+func (r GrepResult) String() string {
+	if len(r.Matches) == 0 {
+		return fmt.Sprintf("No matches found for pattern: %s", r.Pattern)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Search results for '%s':\n", r.Pattern)
+	for _, match := range r.Matches {
+		fmt.Fprintf(&b, "%s:%d:%d:%s\n", match.FilePath, match.Line, match.Column, match.Text)
+	}
+	if r.Truncated {
+		fmt.Fprintf(&b, "... (truncated after %d matches, narrow the search with a pathspec or a more specific pattern)\n", len(r.Matches))
+	}
+	return b.String()
+}
+
+// SymbolReferences are the definitions of a name across the repository and the number of
+// lines referencing it outside of them
+type SymbolReferences struct {
+	Name        string
+	Definitions []Symbol
+	References  int
+}
+
+// SimilarCode is existing code found nearly identical to a block of added lines
+type SimilarCode struct {
+	// AddedStart and AddedEnd delimit the added block, in new file numbering
+	AddedStart int
+	AddedEnd   int
+	FilePath   string
+	StartLine  int
+	EndLine    int
+	// Similarity is the share of the fingerprints of the added block found in the code
+	Similarity float64
+	Snippet    string
 }
 
 type SymbolUsage struct {
 	Symbol   Symbol
 	Snippets string
+	// Usages counts the usage sites found while gathering the snippets
+	Usages int
 }
 
 type Symbol struct {
@@ -21,6 +104,27 @@ type Symbol struct {
 	EndLine   int
 }
 
+// TypeDeclaration is a type with its methods and the types it extends or implements, used to
+// relate a changed method to the interface methods it implements and the methods it overrides
+type TypeDeclaration struct {
+	Name string
+	// Kind is "interface" or "class", Go uses "struct" and "type" for the other types
+	Kind string
+	// Supertypes lists the extended and implemented types (Java) or embedded interfaces (Go)
+	Supertypes []string
+	Methods    []MethodDeclaration
+	FilePath   string
+	// StartLine is 0 for Go receiver types whose type declaration is in another file
+	StartLine int
+	EndLine   int
+}
+
+type MethodDeclaration struct {
+	Name      string
+	StartLine int
+	EndLine   int
+}
+
 type ContextResult struct {
 	Context         string
 	AffectedSymbols []SymbolUsage
@@ -1,12 +1,31 @@
 package types
 
 type Issue struct {
-	Severity    string `json:"severity"`
-	FilePath    string `json:"file_path"`
-	StartLine   int    `json:"start_line"`
-	EndLine     int    `json:"end_line"`
-	Description string `json:"description"`
-	CodeSnippet string `json:"code_snippet,omitempty"`
+	Severity    string  `json:"severity"`
+	FilePath    string  `json:"file_path"`
+	StartLine   int     `json:"start_line"`
+	EndLine     int     `json:"end_line"`
+	Description string  `json:"description"`
+	CodeSnippet string  `json:"code_snippet,omitempty"`
+	Confidence  float64 `json:"confidence,omitempty"`
+	// SuggestedFix is a minimal unified diff fixing the issue, asked for by the fixes prompt
+	SuggestedFix string `json:"suggested_fix,omitempty"`
+	// Category is set by the deterministic checks, LLM findings leave it empty
+	Category string `json:"category,omitempty"`
+	// Occurrences counts the near-duplicate findings merged into this one, 0 when none were
+	Occurrences int `json:"occurrences,omitempty"`
+	// Unchanged marks issues reused from the last review of a file whose diff did not change
+	Unchanged bool `json:"unchanged,omitempty"`
+	// Models lists the ensemble members that found the issue, empty outside an ensemble
+	Models []string `json:"models,omitempty"`
+}
+
+// Question is a non-blocking clarification the reviewer asks the author. Questions are
+// reported apart from issues and do not fail the review.
+type Question struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Question string `json:"question"`
 }
 
 type PromptVariant struct {
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package usage
+
+import "time"
+
+// Peak memory and subprocess CPU time are only reported on unix systems
+func processUsage() (peakMemory int64, childCPU time.Duration) {
+	return 0, 0
+}
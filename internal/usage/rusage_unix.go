@@ -0,0 +1,27 @@
+//go:build unix
+
+package usage
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+func processUsage() (peakMemory int64, childCPU time.Duration) {
+	var self syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &self); err == nil {
+		peakMemory = int64(self.Maxrss)
+		// Linux reports the resident set size in kilobytes, macOS in bytes
+		if runtime.GOOS != "darwin" {
+			peakMemory *= 1024
+		}
+	}
+
+	var children syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_CHILDREN, &children); err == nil {
+		childCPU = time.Duration(children.Utime.Nano() + children.Stime.Nano())
+	}
+
+	return peakMemory, childCPU
+}
@@ -0,0 +1,145 @@
+// Package usage measures the resources consumed by a review run.
+package usage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Recorder accumulates the LLM traffic of a run. It is safe for concurrent use.
+type Recorder struct {
+	mu            sync.Mutex
+	start         time.Time
+	llmCalls      int
+	llmWallTime   time.Duration
+	bytesSent     int64
+	bytesReceived int64
+}
+
+// Summary is a snapshot of the resources used since the recorder was created
+type Summary struct {
+	WallTime        time.Duration `json:"wall_time"`
+	PeakMemoryBytes int64         `json:"peak_memory_bytes"`
+	// SubprocessCPUTime is the user and system time of the git/hg subprocesses that have exited
+	SubprocessCPUTime time.Duration `json:"subprocess_cpu_time"`
+	LLMCalls          int           `json:"llm_calls"`
+	LLMWallTime       time.Duration `json:"llm_wall_time"`
+	BytesSent         int64         `json:"bytes_sent"`
+	BytesReceived     int64         `json:"bytes_received"`
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{start: time.Now()}
+}
+
+// Transport wraps base so the requests made through it are counted. A nil base uses
+// http.DefaultTransport. Wall time runs from sending the request until its body is closed.
+func (r *Recorder) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &countingTransport{base: base, recorder: r}
+}
+
+func (r *Recorder) Summary() Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	peakMemory, childCPU := processUsage()
+
+	return Summary{
+		WallTime:          time.Since(r.start),
+		PeakMemoryBytes:   peakMemory,
+		SubprocessCPUTime: childCPU,
+		LLMCalls:          r.llmCalls,
+		LLMWallTime:       r.llmWallTime,
+		BytesSent:         r.bytesSent,
+		BytesReceived:     r.bytesReceived,
+	}
+}
+
+func (r *Recorder) addRequest(sent int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.llmCalls++
+	r.bytesSent += sent
+}
+
+func (r *Recorder) addResponse(received int64, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bytesReceived += received
+	r.llmWallTime += elapsed
+}
+
+func (s Summary) Print() {
+	fmt.Println("Resource usage:")
+	fmt.Printf("- Total time: %s\n", s.WallTime.Round(time.Millisecond))
+	fmt.Printf("- LLM time: %s (%d requests)\n", s.LLMWallTime.Round(time.Millisecond), s.LLMCalls)
+	fmt.Printf("- LLM traffic: %s sent, %s received\n", FormatBytes(s.BytesSent), FormatBytes(s.BytesReceived))
+	if s.PeakMemoryBytes > 0 {
+		fmt.Printf("- Peak memory: %s\n", FormatBytes(s.PeakMemoryBytes))
+	}
+	fmt.Printf("- Subprocess CPU time: %s\n", s.SubprocessCPUTime.Round(time.Millisecond))
+}
+
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+type countingTransport struct {
+	base     http.RoundTripper
+	recorder *Recorder
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	var sent int64
+	if req.ContentLength > 0 {
+		sent = req.ContentLength
+	}
+	t.recorder.addRequest(sent)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.recorder.addResponse(0, time.Since(start))
+		return nil, err
+	}
+
+	resp.Body = &countingBody{ReadCloser: resp.Body, start: start, recorder: t.recorder}
+	return resp, nil
+}
+
+type countingBody struct {
+	io.ReadCloser
+	start    time.Time
+	recorder *Recorder
+	read     int64
+	once     sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.once.Do(func() {
+		b.recorder.addResponse(b.read, time.Since(b.start))
+	})
+	return b.ReadCloser.Close()
+}
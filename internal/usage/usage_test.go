@@ -0,0 +1,60 @@
+package usage
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecorder_CountsLLMTraffic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	recorder := NewRecorder()
+	client := &http.Client{Transport: recorder.Transport(nil)}
+
+	for range 2 {
+		resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"prompt":"x"}`))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		_, _ = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+	}
+
+	summary := recorder.Summary()
+	if summary.LLMCalls != 2 {
+		t.Errorf("Expected 2 calls, got %d", summary.LLMCalls)
+	}
+	if summary.BytesSent != 28 {
+		t.Errorf("Expected 28 bytes sent, got %d", summary.BytesSent)
+	}
+	if summary.BytesReceived != 20 {
+		t.Errorf("Expected 20 bytes received, got %d", summary.BytesReceived)
+	}
+	if summary.LLMWallTime <= 0 || summary.LLMWallTime > summary.WallTime {
+		t.Errorf("Expected LLM time within the run time, got %s of %s", summary.LLMWallTime, summary.WallTime)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes    int64
+		expected string
+	}{
+		{512, "512 B"},
+		{2048, "2.0 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBytes(tt.bytes); got != tt.expected {
+			t.Errorf("FormatBytes(%d) = %q, expected %q", tt.bytes, got, tt.expected)
+		}
+	}
+}
@@ -12,7 +12,7 @@ import (
 func GetDiffContext(diffData types.DiffData, allSymbols []types.Symbol, fileContent []byte) (types.ContextResult, error) {
 	fileLines := strings.Split(string(fileContent), "\n")
 
-	changedLinesSet := getDiffChangedLines(diffData.Diff)
+	changedLinesSet := GetDiffChangedLines(diffData.Diff)
 	if len(changedLinesSet) == 0 {
 		return types.ContextResult{}, nil
 	}
@@ -21,7 +21,7 @@ func GetDiffContext(diffData types.DiffData, allSymbols []types.Symbol, fileCont
 	var affectedSymbols []types.SymbolUsage
 
 	for _, symbol := range allSymbols {
-		if containsChangedLines(symbol, changedLinesSet) && isDeclaration(symbol.Type) {
+		if containsChangedLines(symbol, changedLinesSet) && IsDeclaration(symbol.Type) {
 			affectedSymbols = append(affectedSymbols, types.SymbolUsage{Symbol: symbol})
 			content := extractSymbolContent(symbol, fileLines)
 			contextBlocks = append(contextBlocks, content)
@@ -34,7 +34,8 @@ func GetDiffContext(diffData types.DiffData, allSymbols []types.Symbol, fileCont
 	}, nil
 }
 
-func getDiffChangedLines(diffContent string) map[int]bool {
+// GetDiffChangedLines returns the lines a diff adds, in new file numbering
+func GetDiffChangedLines(diffContent string) map[int]bool {
 	addedLines := make(map[int]bool)
 	lines := strings.Split(diffContent, "\n")
 
@@ -75,6 +76,74 @@ func getDiffChangedLines(diffContent string) map[int]bool {
 	return addedLines
 }
 
+// CountChangedLines counts the lines a single file diff adds and removes
+func CountChangedLines(diffContent string) int {
+	_, hunks := SplitDiffHunks(diffContent)
+	count := 0
+	for _, hunk := range hunks {
+		// The first line is the hunk header
+		for _, line := range strings.Split(hunk.Text, "\n")[1:] {
+			if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// DiffHunk is a hunk of a single file diff
+type DiffHunk struct {
+	Text string
+	// StartLine and EndLine delimit the hunk in new file numbering
+	StartLine int
+	EndLine   int
+	// Lines counts the lines of the hunk, its header excluded
+	Lines int
+}
+
+var hunkRangeRegex = regexp.MustCompile(`^@@\s+-\d+(?:,\d+)?\s+\+(\d+)(?:,(\d+))?\s+@@`)
+
+// SplitDiffHunks splits a single file diff into the lines preceding its first hunk, such as
+// the file headers, and its hunks
+func SplitDiffHunks(diffContent string) (string, []DiffHunk) {
+	var header []string
+	var hunks []DiffHunk
+	var hunkLines []string
+
+	flush := func() {
+		if len(hunkLines) == 0 {
+			return
+		}
+		hunks[len(hunks)-1].Text = strings.Join(hunkLines, "\n")
+		hunks[len(hunks)-1].Lines = len(hunkLines) - 1
+		hunkLines = nil
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(diffContent, "\n"), "\n") {
+		matches := hunkRangeRegex.FindStringSubmatch(line)
+		if matches == nil {
+			if len(hunks) == 0 {
+				header = append(header, line)
+			} else {
+				hunkLines = append(hunkLines, line)
+			}
+			continue
+		}
+
+		flush()
+		start, _ := strconv.Atoi(matches[1])
+		count := 1
+		if matches[2] != "" {
+			count, _ = strconv.Atoi(matches[2])
+		}
+		hunks = append(hunks, DiffHunk{StartLine: start, EndLine: start + max(count, 1) - 1})
+		hunkLines = []string{line}
+	}
+	flush()
+
+	return strings.Join(header, "\n"), hunks
+}
+
 func containsChangedLines(symbol types.Symbol, changedLines map[int]bool) bool {
 	for line := symbol.StartLine; line <= symbol.EndLine; line++ {
 		if changedLines[line] {
@@ -84,7 +153,7 @@ func containsChangedLines(symbol types.Symbol, changedLines map[int]bool) bool {
 	return false
 }
 
-func isDeclaration(symbolType string) bool {
+func IsDeclaration(symbolType string) bool {
 	declarationTypes := []string{
 		// Go declarations
 		"func_decl",
@@ -95,6 +164,26 @@ func isDeclaration(symbolType string) bool {
 		"field_decl",
 		"iface_method_decl",
 		"import_decl",
+		// SQL statements, their columns are part of the table statement
+		"table_decl",
+		"view_decl",
+		"index_decl",
+		"routine_decl",
+		"alter_decl",
+		"drop_decl",
+		// Terraform blocks and local values
+		"resource_decl",
+		"data_decl",
+		"module_decl",
+		"variable_decl",
+		"output_decl",
+		"provider_decl",
+		"local_decl",
+		// Kubernetes objects and workflow jobs with the containers and steps they run
+		"manifest_decl",
+		"container_decl",
+		"job_decl",
+		"step_decl",
 	}
 
 	return slices.Contains(declarationTypes, symbolType)
@@ -78,10 +78,10 @@ func TestParseGitDiffForChangedLines(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := getDiffChangedLines(tt.diffContent)
+			got := GetDiffChangedLines(tt.diffContent)
 
 			if !maps.Equal(got, tt.want) {
-				t.Errorf("getDiffChangedLines() got = %v, want %v", got, tt.want)
+				t.Errorf("GetDiffChangedLines() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -720,10 +720,61 @@ func TestIsDeclaration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isDeclaration(tt.symbolType)
+			result := IsDeclaration(tt.symbolType)
 			if result != tt.expected {
 				t.Errorf("IsDeclaration(%q) = %v, expected %v", tt.symbolType, result, tt.expected)
 			}
 		})
 	}
 }
+
+func TestSplitDiffHunks(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -3,2 +3,3 @@ func main() {
+ 	a := 1
++	b := 2
+ 	c := 3
+@@ -20 +21 @@
+-old
++new
+`
+
+	header, hunks := SplitDiffHunks(diff)
+
+	if header != "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go" {
+		t.Errorf("Unexpected header %q", header)
+	}
+	if len(hunks) != 2 {
+		t.Fatalf("Expected 2 hunks, got %+v", hunks)
+	}
+	if hunks[0].StartLine != 3 || hunks[0].EndLine != 5 || hunks[0].Lines != 3 {
+		t.Errorf("Unexpected first hunk %+v", hunks[0])
+	}
+	if hunks[1].StartLine != 21 || hunks[1].EndLine != 21 || hunks[1].Text != "@@ -20 +21 @@\n-old\n+new" {
+		t.Errorf("Unexpected second hunk %+v", hunks[1])
+	}
+}
+
+func TestCountChangedLines(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -3,2 +3,3 @@ func main() {
+ 	a := 1
++	b := 2
+ 	c := 3
+@@ -20,2 +21 @@
+-old
+--- a removed comment
++new
+`
+
+	if got := CountChangedLines(diff); got != 4 {
+		t.Errorf("Expected 4 changed lines, got %d", got)
+	}
+	if got := CountChangedLines(""); got != 0 {
+		t.Errorf("Expected no changed lines in an empty diff, got %d", got)
+	}
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether a slash separated path matches a glob pattern. Besides the
+// path.Match syntax, "**" matches any number of directories. Patterns without a slash
+// match the file name at any depth, so "*.sql" matches "db/migrations/001.sql".
+func MatchGlob(pattern, filePath string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	filePath = strings.TrimPrefix(filePath, "./")
+
+	if !strings.Contains(pattern, "/") {
+		matched, err := path.Match(pattern, path.Base(filePath))
+		return err == nil && matched
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(filePath, "/"))
+}
+
+func matchSegments(patternParts, pathParts []string) bool {
+	if len(patternParts) == 0 {
+		return len(pathParts) == 0
+	}
+
+	if patternParts[0] == "**" {
+		for i := 0; i <= len(pathParts); i++ {
+			if matchSegments(patternParts[1:], pathParts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(pathParts) == 0 {
+		return false
+	}
+
+	matched, err := path.Match(patternParts[0], pathParts[0])
+	if err != nil || !matched {
+		return false
+	}
+
+	return matchSegments(patternParts[1:], pathParts[1:])
+}
@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"internal/auth/**", "internal/auth/token.go", true},
+		{"internal/auth/**", "internal/auth/oauth/client.go", true},
+		{"internal/auth/**", "internal/authz/policy.go", false},
+		{"*.sql", "db/migrations/001_init.sql", true},
+		{"*.sql", "db/migrations/001_init.go", false},
+		{"db/migrations/*.sql", "db/migrations/001_init.sql", true},
+		{"db/migrations/*.sql", "db/migrations/old/001_init.sql", false},
+		{"**/migrations/**", "services/billing/migrations/002.sql", true},
+		{"cmd/*/main.go", "cmd/diffpector/main.go", true},
+		{"./pkg/**", "pkg/config/config.go", true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.path); got != tt.expected {
+			t.Errorf("MatchGlob(%q, %q) = %v, expected %v", tt.pattern, tt.path, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package utils
+
+// IssuesSchemaName identifies the issues schema in provider requests
+const IssuesSchemaName = "code_review_issues"
+
+// IssuesSchema returns the JSON schema of a structured review answer, an object wrapping the
+// list of issues. Every property is required so the schema is valid in OpenAI strict mode.
+func IssuesSchema() map[string]any {
+	return reviewSchema(false)
+}
+
+// IssuesWithQuestionsSchema extends IssuesSchema with the questions for the author
+func IssuesWithQuestionsSchema() map[string]any {
+	return reviewSchema(true)
+}
+
+func reviewSchema(withQuestions bool) map[string]any {
+	issue := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"severity": map[string]any{
+				"type": "string",
+				"enum": []string{"CRITICAL", "WARNING", "MINOR"},
+			},
+			"file_path":    map[string]any{"type": "string"},
+			"start_line":   map[string]any{"type": "integer"},
+			"end_line":     map[string]any{"type": "integer"},
+			"description":  map[string]any{"type": "string"},
+			"code_snippet": map[string]any{"type": "string"},
+			"confidence":   map[string]any{"type": "number"},
+			// Empty unless the prompt asks for patches
+			"suggested_fix": map[string]any{"type": "string"},
+		},
+		"required":             []string{"severity", "file_path", "start_line", "end_line", "description", "code_snippet", "confidence", "suggested_fix"},
+		"additionalProperties": false,
+	}
+
+	properties := map[string]any{
+		"issues": map[string]any{
+			"type":  "array",
+			"items": issue,
+		},
+	}
+	required := []string{"issues"}
+
+	if withQuestions {
+		properties["questions"] = map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"file_path": map[string]any{"type": "string"},
+					"line":      map[string]any{"type": "integer"},
+					"question":  map[string]any{"type": "string"},
+				},
+				"required":             []string{"file_path", "line", "question"},
+				"additionalProperties": false,
+			},
+		}
+		required = append(required, "questions")
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+type patchHunk struct {
+	oldStart int
+	// fromHeader is false for the hunk made up for a fix without hunk header
+	fromHeader bool
+	lines      []string
+}
+
+// NormalizePatch turns the suggested fix of an issue into a patch git apply accepts. Models
+// get the line counts of hunk headers wrong and often leave out the file headers or the
+// hunk header altogether, so the file headers are rewritten for filePath, the counts are
+// recomputed and a fix without hunk header is placed at startLine. Blank lines inside a
+// hunk are taken as empty context lines.
+func NormalizePatch(filePath, fix string, startLine int) (string, error) {
+	var hunks []*patchHunk
+	var current *patchHunk
+
+	for line := range strings.SplitSeq(strings.TrimRight(fix, "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		switch {
+		case strings.HasPrefix(line, "```"), strings.HasPrefix(line, "diff --git"), strings.HasPrefix(line, "index "):
+			continue
+		case current == nil && (strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ")):
+			continue
+		case strings.HasPrefix(line, "@@"):
+			match := hunkHeaderPattern.FindStringSubmatch(line)
+			if match == nil {
+				return "", fmt.Errorf("malformed hunk header %q", line)
+			}
+			oldStart, _ := strconv.Atoi(match[1])
+			current = &patchHunk{oldStart: oldStart, fromHeader: true}
+			hunks = append(hunks, current)
+			continue
+		}
+
+		if current == nil {
+			if startLine <= 0 {
+				return "", fmt.Errorf("fix without hunk header and line number")
+			}
+			current = &patchHunk{oldStart: startLine}
+			hunks = append(hunks, current)
+		}
+
+		switch {
+		case line == "":
+			current.lines = append(current.lines, " ")
+		case strings.HasPrefix(line, " "), strings.HasPrefix(line, "-"), strings.HasPrefix(line, "+"), strings.HasPrefix(line, `\`):
+			current.lines = append(current.lines, line)
+		default:
+			return "", fmt.Errorf("unexpected patch line %q", line)
+		}
+	}
+
+	var patch strings.Builder
+	fmt.Fprintf(&patch, "--- a/%s\n+++ b/%s\n", filePath, filePath)
+
+	changed := false
+	offset := 0
+	for _, hunk := range hunks {
+		oldCount, newCount := 0, 0
+		for _, line := range hunk.lines {
+			switch line[0] {
+			case ' ':
+				oldCount++
+				newCount++
+			case '-':
+				oldCount++
+				changed = true
+			case '+':
+				newCount++
+				changed = true
+			}
+		}
+		if oldCount == 0 && newCount == 0 {
+			continue
+		}
+
+		// A side without lines starts on the line before the change, as diff writes it
+		oldStart := hunk.oldStart
+		if oldCount == 0 && !hunk.fromHeader {
+			oldStart--
+		}
+		newStart := oldStart + offset
+		if oldCount == 0 {
+			newStart++
+		}
+		if newCount == 0 {
+			newStart--
+		}
+
+		fmt.Fprintf(&patch, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, line := range hunk.lines {
+			patch.WriteString(line + "\n")
+		}
+		offset += newCount - oldCount
+	}
+
+	if !changed {
+		return "", fmt.Errorf("fix does not change any line")
+	}
+	return patch.String(), nil
+}
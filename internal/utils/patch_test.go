@@ -0,0 +1,67 @@
+package utils
+
+import "testing"
+
+func TestNormalizePatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		fix       string
+		startLine int
+		expected  string
+		wantErr   bool
+	}{
+		{
+			name:      "wrong counts are recomputed",
+			fix:       "@@ -2,9 +2,9 @@\n f, err := os.Open(name)\n+if err != nil {\n+\treturn err\n+}\n defer f.Close()",
+			startLine: 2,
+			expected:  "--- a/main.go\n+++ b/main.go\n@@ -2,2 +2,5 @@\n f, err := os.Open(name)\n+if err != nil {\n+\treturn err\n+}\n defer f.Close()\n",
+		},
+		{
+			name:      "file headers and code fence are replaced",
+			fix:       "```diff\n--- main.go\n+++ main.go\n@@ -5 +5 @@\n-x := 1\n+x := 2\n```",
+			startLine: 5,
+			expected:  "--- a/main.go\n+++ b/main.go\n@@ -5,1 +5,1 @@\n-x := 1\n+x := 2\n",
+		},
+		{
+			name:      "missing hunk header uses the issue line",
+			fix:       "-x := 1\n+x := 2\n\n y := x",
+			startLine: 7,
+			expected:  "--- a/main.go\n+++ b/main.go\n@@ -7,3 +7,3 @@\n-x := 1\n+x := 2\n \n y := x\n",
+		},
+		{
+			name:      "later hunks are shifted by the earlier ones",
+			fix:       "@@ -1,1 +1,1 @@\n a\n+b\n@@ -10,2 +10,1 @@\n c\n-d",
+			startLine: 1,
+			expected:  "--- a/main.go\n+++ b/main.go\n@@ -1,1 +1,2 @@\n a\n+b\n@@ -10,2 +11,1 @@\n c\n-d\n",
+		},
+		{
+			name:      "insertion after a line",
+			fix:       "@@ -3,0 +4,1 @@\n+z := 3",
+			startLine: 3,
+			expected:  "--- a/main.go\n+++ b/main.go\n@@ -3,0 +4,1 @@\n+z := 3\n",
+		},
+		{
+			name:    "prose is rejected",
+			fix:     "Check the error returned by os.Open",
+			wantErr: true,
+		},
+		{
+			name:      "context only is rejected",
+			fix:       "@@ -1,1 +1,1 @@\n a",
+			startLine: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := NormalizePatch("main.go", tt.fix, tt.startLine)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if patch != tt.expected {
+				t.Errorf("Expected patch:\n%q\ngot:\n%q", tt.expected, patch)
+			}
+		})
+	}
+}
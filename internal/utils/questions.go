@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/types"
+)
+
+// ParseQuestions extracts the questions for the author from a review answer of the form
+// {"issues": [...], "questions": [...]}. Answers without questions return none.
+func ParseQuestions(review string) []types.Question {
+	review = strings.TrimSpace(review)
+
+	candidates := []string{review}
+	if block := extractFromCodeBlock(review); block != "" {
+		candidates = append(candidates, block)
+	}
+	if start, end := strings.Index(review, "{"), strings.LastIndex(review, "}"); start != -1 && end > start {
+		candidates = append(candidates, review[start:end+1])
+	}
+
+	for _, candidate := range candidates {
+		var envelope struct {
+			Questions []types.Question `json:"questions"`
+		}
+		if err := json.Unmarshal([]byte(candidate), &envelope); err != nil {
+			continue
+		}
+
+		var questions []types.Question
+		for _, question := range envelope.Questions {
+			question.Question = strings.TrimSpace(question.Question)
+			if question.Question != "" {
+				questions = append(questions, question)
+			}
+		}
+		return questions
+	}
+
+	return nil
+}
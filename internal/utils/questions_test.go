@@ -0,0 +1,38 @@
+package utils
+
+import "testing"
+
+func TestParseQuestions(t *testing.T) {
+	tests := []struct {
+		name     string
+		review   string
+		expected int
+	}{
+		{"approved", "APPROVED", 0},
+		{"issues array", `[{"severity": "MINOR", "file_path": "a.go", "start_line": 1, "end_line": 1, "description": "x"}]`, 0},
+		{"envelope", `{"issues": [], "questions": [{"file_path": "a.go", "line": 4, "question": "Why 3 retries?"}]}`, 1},
+		{"code block", "Here you go:\n```json\n{\"issues\": [], \"questions\": [{\"file_path\": \"a.go\", \"line\": 4, \"question\": \"Why?\"}]}\n```", 1},
+		{"empty question dropped", `{"issues": [], "questions": [{"file_path": "a.go", "line": 4, "question": "  "}]}`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			questions := ParseQuestions(tt.review)
+			if len(questions) != tt.expected {
+				t.Fatalf("Expected %d questions, got %d: %v", tt.expected, len(questions), questions)
+			}
+		})
+	}
+}
+
+func TestParseIssuesIgnoresQuestions(t *testing.T) {
+	review := `{"issues": [{"severity": "WARNING", "file_path": "a.go", "start_line": 2, "end_line": 2, "description": "leak"}], "questions": [{"file_path": "a.go", "line": 4, "question": "Why?"}]}`
+
+	issues, err := ParseIssuesFromResponse(review)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(issues) != 1 {
+		t.Errorf("Expected the questions not to count as issues, got %d issues", len(issues))
+	}
+}
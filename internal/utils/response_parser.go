@@ -5,12 +5,42 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/agusespa/diffpector/internal/severity"
 	"github.com/agusespa/diffpector/internal/types"
 )
 
 // ParseIssuesFromResponse parses LLM response into issues
 func ParseIssuesFromResponse(review string) ([]types.Issue, error) {
+	return ParseIssuesWithSeverities(review, severity.Default())
+}
+
+// ParseIssuesWithSeverities parses LLM response into issues whose severities are mapped
+// onto the registry levels
+func ParseIssuesWithSeverities(review string, severities *severity.Registry) ([]types.Issue, error) {
+	issues, err := parseIssues(review)
+	if err != nil {
+		return nil, err
+	}
+	severities.NormalizeIssues(issues)
+	normalizeConfidence(issues)
+	return issues, nil
+}
+
+// normalizeConfidence maps the confidence of the issues into [0, 1]. Models sometimes
+// answer with a percentage, values above 1 up to 100 are read as one.
+func normalizeConfidence(issues []types.Issue) {
+	for i := range issues {
+		confidence := issues[i].Confidence
+		if confidence > 1 && confidence <= 100 {
+			confidence /= 100
+		}
+		issues[i].Confidence = min(max(confidence, 0), 1)
+	}
+}
+
+func parseIssues(review string) ([]types.Issue, error) {
 	review = strings.TrimSpace(review)
 
 	// 1. Check for approval responses (flexible matching)
@@ -18,27 +48,32 @@ func ParseIssuesFromResponse(review string) ([]types.Issue, error) {
 		return []types.Issue{}, nil
 	}
 
-	// 2. Try direct JSON parsing
+	// 2. Try the structured output envelope {"issues": [...]}
+	if issues, err := tryStructuredParse(review); err == nil {
+		return issues, nil
+	}
+
+	// 3. Try direct JSON parsing
 	if issues, err := tryDirectJSONParse(review); err == nil {
 		return issues, nil
 	}
 
-	// 3. Try to extract and parse JSON from mixed content
+	// 4. Try to extract and parse JSON from mixed content
 	if issues, err := tryExtractAndParseJSON(review); err == nil {
 		return issues, nil
 	}
 
-	// 4. Try to repair incomplete JSON
+	// 5. Try to repair incomplete JSON
 	if issues, err := tryRepairIncompleteJSON(review); err == nil {
 		return issues, nil
 	}
 
-	// 5. Try to extract individual issue objects (even if array is malformed)
+	// 6. Try to extract individual issue objects (even if array is malformed)
 	if issues, err := tryExtractIndividualIssues(review); err == nil && len(issues) > 0 {
 		return issues, nil
 	}
 
-	// 6. If all else fails, return format violation
+	// 7. If all else fails, return format violation
 	return nil, &FormatViolationError{
 		Response: truncateString(review, 500),
 		Reason:   "Could not parse response as APPROVED or valid JSON array",
@@ -86,6 +121,19 @@ func isApprovalResponse(response string) bool {
 	return false
 }
 
+func tryStructuredParse(response string) ([]types.Issue, error) {
+	var envelope struct {
+		Issues *[]types.Issue `json:"issues"`
+	}
+	if err := json.Unmarshal([]byte(response), &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Issues == nil {
+		return nil, fmt.Errorf("no issues field found")
+	}
+	return *envelope.Issues, nil
+}
+
 func tryDirectJSONParse(response string) ([]types.Issue, error) {
 	var issues []types.Issue
 	err := json.Unmarshal([]byte(response), &issues)
@@ -234,5 +282,9 @@ func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
 	}
+	// Back off to a character boundary so multi-byte identifiers are not split
+	for maxLen > 0 && !utf8.RuneStart(s[maxLen]) {
+		maxLen--
+	}
 	return s[:maxLen] + "..."
 }
@@ -209,3 +209,56 @@ func TestParseIssuesFromResponse_EmptyArray(t *testing.T) {
 		t.Errorf("Empty array should return 0 issues, got %d", len(issues))
 	}
 }
+
+func TestParseIssuesFromResponse_StructuredEnvelope(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected int
+	}{
+		{"empty issues", `{"issues": []}`, 0},
+		{"one issue", `{"issues": [{"severity": "WARNING", "file_path": "main.go", "start_line": 3, "end_line": 4, "description": "Unchecked error", "code_snippet": "f.Close()", "confidence": 0.8}]}`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := ParseIssuesFromResponse(tt.response)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(issues) != tt.expected {
+				t.Errorf("Expected %d issues, got %d", tt.expected, len(issues))
+			}
+			if tt.expected == 1 && issues[0].Confidence != 0.8 {
+				t.Errorf("Expected confidence 0.8, got %v", issues[0].Confidence)
+			}
+		})
+	}
+}
+
+func TestParseIssuesFromResponse_Confidence(t *testing.T) {
+	tests := []struct {
+		name       string
+		confidence string
+		expected   float64
+	}{
+		{"fraction", "0.65", 0.65},
+		{"percentage", "85", 0.85},
+		{"missing", "0", 0},
+		{"negative", "-0.3", 0},
+		{"out of range", "250", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := `[{"severity": "WARNING", "file_path": "main.go", "start_line": 3, "end_line": 4, "description": "Unchecked error", "confidence": ` + tt.confidence + `}]`
+			issues, err := ParseIssuesFromResponse(response)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(issues) != 1 || issues[0].Confidence != tt.expected {
+				t.Errorf("Expected confidence %v, got %+v", tt.expected, issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package vcs
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agusespa/diffpector/internal/platform"
+)
+
+type Git struct {
+	dir string
+}
+
+func NewGit(dir string) *Git {
+	return &Git{dir: dir}
+}
+
+func (g *Git) Name() string {
+	return "git"
+}
+
+func (g *Git) Root() (string, error) {
+	out, err := run(g.dir, 0, "git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// SuperprojectRoot returns the root of the repository the repository is a submodule of,
+// empty when it is not a submodule
+func (g *Git) SuperprojectRoot() (string, error) {
+	out, err := run(g.dir, 0, "git", "rev-parse", "--show-superproject-working-tree")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CommitMessagePath returns the path of the COMMIT_EDITMSG file, which git uses as the
+// initial message of the next commit. Worktrees keep it in their own git directory.
+func (g *Git) CommitMessagePath() (string, error) {
+	out, err := run(g.dir, 0, "git", "rev-parse", "--git-path", "COMMIT_EDITMSG")
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.dir, path)
+	}
+	return path, nil
+}
+
+// Branch returns the name of the checked out branch, empty when HEAD is detached
+func (g *Git) Branch() (string, error) {
+	out, err := run(g.dir, 0, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	branch := strings.TrimSpace(string(out))
+	if branch == "HEAD" {
+		return "", nil
+	}
+	return branch, nil
+}
+
+// HeadCommit returns the hash of the checked out commit, empty before the first commit
+func (g *Git) HeadCommit() (string, error) {
+	out, err := run(g.dir, 1, "git", "rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// PendingCommitMessage returns the message prepared in COMMIT_EDITMSG for the next commit,
+// without its comment lines. It is empty when the file is missing or was written for the
+// last commit.
+func (g *Git) PendingCommitMessage() (string, error) {
+	path, err := g.CommitMessagePath()
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Commit times have a second precision, the message of the last commit is written
+	// within the same second
+	if out, err := run(g.dir, 0, "git", "log", "-1", "--format=%ct"); err == nil {
+		committed, _ := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+		if !info.ModTime().After(time.Unix(committed+1, 0)) {
+			return "", nil
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var message []string
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			message = append(message, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(message, "\n")), nil
+}
+
+func (g *Git) StagedDiff() ([]byte, error) {
+	return run(g.dir, 0, "git", "diff", "--staged")
+}
+
+// WorkingDiff diffs the working tree against HEAD, against the empty tree before the first
+// commit, and adds the untracked files that are not ignored as new files
+func (g *Git) WorkingDiff(paths ...string) ([]byte, error) {
+	base, err := g.HeadCommit()
+	if err != nil {
+		return nil, err
+	}
+	if base == "" {
+		out, err := run(g.dir, 0, "git", "hash-object", "-t", "tree", "--stdin")
+		if err != nil {
+			return nil, err
+		}
+		base = strings.TrimSpace(string(out))
+	}
+
+	args := []string{"diff", base}
+	if len(paths) > 0 {
+		args = append(args, "--")
+		args = append(args, paths...)
+	}
+	out, err := run(g.dir, 0, "git", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := g.untrackedDiff(paths)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, untracked...), nil
+}
+
+// untrackedDiff returns the diff of the untracked files that are not ignored, as new files
+// named relative to the repository root like the other diffs
+func (g *Git) untrackedDiff(paths []string) ([]byte, error) {
+	args := []string{"ls-files", "-z", "--others", "--exclude-standard"}
+	if len(paths) > 0 {
+		args = append(args, "--")
+		args = append(args, paths...)
+	}
+	out, err := run(g.dir, 0, "git", args...)
+	if err != nil {
+		return nil, err
+	}
+	files := splitNul(out)
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	// ls-files lists the files relative to the directory, git diff names them from the root
+	prefix, err := run(g.dir, 0, "git", "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(string(prefix))
+
+	var diffs []byte
+	for _, file := range files {
+		// --no-index exits with 1 when the files differ, which a new file always does
+		out, err := run(g.dir, 1, "git", "diff", "--no-index", "--src-prefix=a/"+root, "--dst-prefix=b/"+root, "--", "/dev/null", file)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, out...)
+	}
+	return diffs, nil
+}
+
+func (g *Git) RangeDiff(base, head string) ([]byte, error) {
+	return run(g.dir, 0, "git", "diff", base+"..."+head)
+}
+
+func (g *Git) FileAtRevision(rev, path string) ([]byte, error) {
+	return run(g.dir, 0, "git", "show", rev+":"+platform.NormalizePath(path))
+}
+
+func (g *Git) TrackedFiles() ([]string, error) {
+	// -z prints the paths as they are, instead of quoting the unusual ones
+	out, err := run(g.dir, 0, "git", "ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// TrackedFilesAt returns the files of a revision, relative to the directory of the backend
+// like TrackedFiles
+func (g *Git) TrackedFilesAt(rev string) ([]string, error) {
+	out, err := run(g.dir, 0, "git", "ls-tree", "-r", "-z", "--name-only", rev)
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// MergeBase returns the best common ancestor of two revisions, the commit a branch forked
+// from its target
+func (g *Git) MergeBase(a, b string) (string, error) {
+	out, err := run(g.dir, 0, "git", "merge-base", a, b)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func splitNul(output []byte) []string {
+	var items []string
+	for item := range strings.SplitSeq(string(output), "\x00") {
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (g *Git) GrepFiles(pattern string, pathspecs []string) ([]string, error) {
+	args := []string{"grep", "-l", "-F", "-e", pattern}
+	if len(pathspecs) > 0 {
+		args = append(args, "--")
+		args = append(args, pathspecs...)
+	}
+
+	out, err := run(g.dir, 1, "git", args...)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
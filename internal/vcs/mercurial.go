@@ -0,0 +1,94 @@
+package vcs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/platform"
+)
+
+// Mercurial has no staging area, so the pending changes are the uncommitted
+// modifications of the working directory.
+type Mercurial struct {
+	dir string
+}
+
+func NewMercurial(dir string) *Mercurial {
+	return &Mercurial{dir: dir}
+}
+
+func (m *Mercurial) Name() string {
+	return "hg"
+}
+
+func (m *Mercurial) Root() (string, error) {
+	out, err := run(m.dir, 0, "hg", "root")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (m *Mercurial) StagedDiff() ([]byte, error) {
+	return run(m.dir, 0, "hg", "diff", "--git")
+}
+
+func (m *Mercurial) WorkingDiff(paths ...string) ([]byte, error) {
+	args := []string{"diff", "--git"}
+	if len(paths) > 0 {
+		args = append(args, "--")
+		args = append(args, paths...)
+	}
+	return run(m.dir, 0, "hg", args...)
+}
+
+// RangeDiff diffs head against its common ancestor with base, like git diff base...head,
+// so that the changes made on base since are left out
+func (m *Mercurial) RangeDiff(base, head string) ([]byte, error) {
+	ancestor := fmt.Sprintf("ancestor(%s, %s)", hgRevsetString(base), hgRevsetString(head))
+	return run(m.dir, 0, "hg", "diff", "--git", "-r", ancestor, "-r", head)
+}
+
+// hgRevsetString quotes a revision for a revset, where a name such as feature-x would
+// otherwise be read as a range difference
+func hgRevsetString(rev string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(rev) + "'"
+}
+
+func (m *Mercurial) FileAtRevision(rev, path string) ([]byte, error) {
+	return run(m.dir, 0, "hg", "cat", "-r", rev, path)
+}
+
+func (m *Mercurial) GrepFiles(pattern string, pathspecs []string) ([]string, error) {
+	args := []string{"grep", "--files-with-matches", regexp.QuoteMeta(pattern)}
+	args = append(args, hgIncludeArgs(pathspecs)...)
+
+	out, err := run(m.dir, 1, "hg", args...)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+func (m *Mercurial) TrackedFiles() ([]string, error) {
+	out, err := run(m.dir, 0, "hg", "files")
+	if err != nil {
+		return nil, err
+	}
+	files := splitLines(out)
+	for i, file := range files {
+		files[i] = platform.NormalizePath(file)
+	}
+	return files, nil
+}
+
+// hgIncludeArgs converts git-style pathspecs into Mercurial include patterns.
+// relglob is used so that "*.go" matches at any depth, like it does for git.
+func hgIncludeArgs(pathspecs []string) []string {
+	var args []string
+	for _, spec := range pathspecs {
+		args = append(args, "-I", "relglob:"+spec)
+	}
+	return args
+}
@@ -0,0 +1,87 @@
+package vcs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/agusespa/diffpector/internal/platform"
+)
+
+// RevisionReader reads the files of a revision through a single git cat-file process,
+// instead of starting a git show per file. It is safe for concurrent use.
+type RevisionReader struct {
+	mu     sync.Mutex
+	rev    string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// NewRevisionReader starts the reader of the files of rev, Close stops it
+func (g *Git) NewRevisionReader(rev string) (*RevisionReader, error) {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Dir = g.dir
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start git cat-file: %w", err)
+	}
+	return &RevisionReader{rev: rev, cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// ReadFile returns the content of a file at the revision, the path being relative to the
+// directory of the backend. A file missing from the revision fails with fs.ErrNotExist.
+func (r *RevisionReader) ReadFile(path string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// ./ makes the path relative to the directory git runs in, like the tracked files
+	if _, err := fmt.Fprintf(r.stdin, "%s:./%s\n", r.rev, platform.NormalizePath(path)); err != nil {
+		return nil, fmt.Errorf("failed to request %s from git cat-file: %w", path, err)
+	}
+	header, err := r.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from git cat-file: %w", path, err)
+	}
+
+	// The header is "<object> <type> <size>", or "<request> missing"
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("%s at %s: %w", path, r.rev, fs.ErrNotExist)
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected git cat-file header %q", strings.TrimSpace(header))
+	}
+	// The content is followed by a newline
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(r.stdout, content); err != nil {
+		return nil, fmt.Errorf("failed to read %s from git cat-file: %w", path, err)
+	}
+	if fields[1] != "blob" {
+		return nil, fmt.Errorf("%s is a %s at %s, not a file", path, fields[1], r.rev)
+	}
+	return content[:size], nil
+}
+
+// Close stops the git cat-file process
+func (r *RevisionReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.stdin.Close(); err != nil {
+		return err
+	}
+	return r.cmd.Wait()
+}
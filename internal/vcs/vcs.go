@@ -0,0 +1,111 @@
+package vcs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// VCS abstracts the version control operations the review agent relies on,
+// so repositories that are not managed by git can still be reviewed.
+type VCS interface {
+	// Name returns the identifier of the backend (e.g. "git", "hg")
+	Name() string
+
+	// Root returns the absolute path of the repository root
+	Root() (string, error)
+
+	// StagedDiff returns the unified diff of the changes pending to be committed
+	StagedDiff() ([]byte, error)
+
+	// WorkingDiff returns the unified diff of all uncommitted changes (staged or not), the
+	// new files not yet tracked included, optionally restricted to the given paths
+	WorkingDiff(paths ...string) ([]byte, error)
+
+	// RangeDiff returns the unified diff between two revisions
+	RangeDiff(base, head string) ([]byte, error)
+
+	// FileAtRevision returns the content of a file as it was at the given revision
+	FileAtRevision(rev, path string) ([]byte, error)
+
+	// GrepFiles returns the tracked files containing the literal pattern, restricted to the given path globs
+	GrepFiles(pattern string, pathspecs []string) ([]string, error)
+
+	// TrackedFiles returns the files under version control, relative to the directory of the
+	// backend and slash separated
+	TrackedFiles() ([]string, error)
+}
+
+// Detect walks up from dir looking for a known repository marker and returns the matching backend.
+// Git is preferred when several markers are present in the same directory.
+func Detect(dir string) (VCS, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory %s: %w", dir, err)
+	}
+
+	for current := absDir; ; {
+		if exists(filepath.Join(current, ".git")) {
+			return NewGit(dir), nil
+		}
+		if exists(filepath.Join(current, ".hg")) {
+			return NewMercurial(dir), nil
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+
+	return nil, fmt.Errorf("no supported repository found in %s or its parents", absDir)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// run executes a VCS command in dir. noMatchCode is an exit code that is not a failure, such
+// as grep without matches or git diff --no-index finding differences; the output is kept.
+func run(dir string, noMatchCode int, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok && noMatchCode > 0 && exitError.ExitCode() == noMatchCode {
+			return append([]byte{}, out...), nil
+		}
+		details := strings.TrimSpace(stderr.String())
+		if details != "" {
+			return nil, fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args, " "), err, details)
+		}
+		return nil, fmt.Errorf("%s %s failed: %w", name, strings.Join(args, " "), err)
+	}
+
+	return out, nil
+}
+
+func splitLines(output []byte) []string {
+	trimmed := strings.TrimSpace(string(output))
+	if trimmed == "" {
+		return []string{}
+	}
+
+	var lines []string
+	for line := range strings.SplitSeq(trimmed, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,404 @@
+package vcs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetect(t *testing.T) {
+	tempDir := t.TempDir()
+	runCmd(t, tempDir, "git", "init")
+
+	subDir := filepath.Join(tempDir, "pkg", "sub")
+	if err := os.MkdirAll(subDir, 0755); err != nil {
+		t.Fatalf("Failed to create sub dir: %v", err)
+	}
+
+	repo, err := Detect(subDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if repo.Name() != "git" {
+		t.Errorf("Expected git backend, got %s", repo.Name())
+	}
+}
+
+func TestDetect_Mercurial(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tempDir, ".hg"), 0755); err != nil {
+		t.Fatalf("Failed to create .hg dir: %v", err)
+	}
+
+	repo, err := Detect(tempDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if repo.Name() != "hg" {
+		t.Errorf("Expected hg backend, got %s", repo.Name())
+	}
+}
+
+func TestDetect_NoRepository(t *testing.T) {
+	tempDir := t.TempDir()
+
+	if _, err := Detect(tempDir); err == nil && !insideRepository(tempDir) {
+		t.Error("Expected error when no repository is present")
+	}
+}
+
+func TestGit_Operations(t *testing.T) {
+	tempDir := setupGitRepo(t)
+
+	writeFile(t, tempDir, "main.go", "package main\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+	writeFile(t, tempDir, "README.md", "Add numbers\n")
+	runCmd(t, tempDir, "git", "add", ".")
+	runCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+
+	writeFile(t, tempDir, "main.go", "package main\n\nfunc Add(a, b int) int {\n\treturn b + a\n}\n")
+	runCmd(t, tempDir, "git", "add", "main.go")
+
+	repo := NewGit(tempDir)
+
+	root, err := repo.Root()
+	if err != nil {
+		t.Fatalf("Root failed: %v", err)
+	}
+	expectedRoot, _ := filepath.EvalSymlinks(tempDir)
+	actualRoot, _ := filepath.EvalSymlinks(root)
+	if actualRoot != expectedRoot {
+		t.Errorf("Expected root %s, got %s", expectedRoot, actualRoot)
+	}
+
+	messagePath, err := repo.CommitMessagePath()
+	if err != nil {
+		t.Fatalf("CommitMessagePath failed: %v", err)
+	}
+	if messagePath != filepath.Join(tempDir, ".git", "COMMIT_EDITMSG") {
+		t.Errorf("Expected the commit message in the git directory, got %s", messagePath)
+	}
+
+	staged, err := repo.StagedDiff()
+	if err != nil {
+		t.Fatalf("StagedDiff failed: %v", err)
+	}
+	if !strings.Contains(string(staged), "+\treturn b + a") {
+		t.Errorf("Staged diff missing change:\n%s", staged)
+	}
+
+	writeFile(t, tempDir, "README.md", "Add numbers quickly\n")
+	working, err := repo.WorkingDiff("README.md")
+	if err != nil {
+		t.Fatalf("WorkingDiff failed: %v", err)
+	}
+	if !strings.Contains(string(working), "+Add numbers quickly") || strings.Contains(string(working), "main.go") {
+		t.Errorf("Working diff should only contain README.md changes:\n%s", working)
+	}
+
+	content, err := repo.FileAtRevision("HEAD", "main.go")
+	if err != nil {
+		t.Fatalf("FileAtRevision failed: %v", err)
+	}
+	if !strings.Contains(string(content), "return a + b") {
+		t.Errorf("Expected committed content, got:\n%s", content)
+	}
+
+	runCmd(t, tempDir, "git", "commit", "-m", "Swap operands")
+	rangeDiff, err := repo.RangeDiff("HEAD~1", "HEAD")
+	if err != nil {
+		t.Fatalf("RangeDiff failed: %v", err)
+	}
+	if !strings.Contains(string(rangeDiff), "-\treturn a + b") {
+		t.Errorf("Range diff missing change:\n%s", rangeDiff)
+	}
+
+	files, err := repo.GrepFiles("Add", []string{"*.go"})
+	if err != nil {
+		t.Fatalf("GrepFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "main.go" {
+		t.Errorf("Expected [main.go], got %v", files)
+	}
+
+	files, err = repo.GrepFiles("NotPresentAnywhere", nil)
+	if err != nil {
+		t.Fatalf("GrepFiles failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected no matches, got %v", files)
+	}
+
+	files, err = repo.TrackedFiles()
+	if err != nil {
+		t.Fatalf("TrackedFiles failed: %v", err)
+	}
+	if len(files) != 2 || files[0] != "README.md" || files[1] != "main.go" {
+		t.Errorf("Expected [README.md main.go], got %v", files)
+	}
+}
+
+func TestHgIncludeArgs(t *testing.T) {
+	args := hgIncludeArgs([]string{"*.go", "go.mod"})
+	expected := []string{"-I", "relglob:*.go", "-I", "relglob:go.mod"}
+
+	if strings.Join(args, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected %v, got %v", expected, args)
+	}
+}
+
+func TestMercurial_Operations(t *testing.T) {
+	if _, err := exec.LookPath("hg"); err != nil {
+		t.Skip("hg is not installed")
+	}
+
+	tempDir := t.TempDir()
+	runCmd(t, tempDir, "hg", "init")
+	writeFile(t, tempDir, "main.go", "package main\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+	runCmd(t, tempDir, "hg", "add", "main.go")
+	runCmd(t, tempDir, "hg", "commit", "-m", "Initial commit", "-u", "test")
+
+	writeFile(t, tempDir, "main.go", "package main\n\nfunc Add(a, b int) int {\n\treturn b + a\n}\n")
+
+	repo := NewMercurial(tempDir)
+
+	staged, err := repo.StagedDiff()
+	if err != nil {
+		t.Fatalf("StagedDiff failed: %v", err)
+	}
+	if !strings.Contains(string(staged), "+\treturn b + a") {
+		t.Errorf("Diff missing change:\n%s", staged)
+	}
+
+	content, err := repo.FileAtRevision(".", "main.go")
+	if err != nil {
+		t.Fatalf("FileAtRevision failed: %v", err)
+	}
+	if !strings.Contains(string(content), "return a + b") {
+		t.Errorf("Expected committed content, got:\n%s", content)
+	}
+
+	files, err := repo.TrackedFiles()
+	if err != nil || len(files) != 1 || files[0] != "main.go" {
+		t.Errorf("Expected [main.go], got %v, %v", files, err)
+	}
+
+	// The changes committed on the base after the fork are not part of the range
+	runCmd(t, tempDir, "hg", "commit", "-m", "Swap operands", "-u", "test")
+	runCmd(t, tempDir, "hg", "update", "0")
+	writeFile(t, tempDir, "README.md", "calc\n")
+	runCmd(t, tempDir, "hg", "add", "README.md")
+	runCmd(t, tempDir, "hg", "commit", "-m", "Add readme", "-u", "test")
+	rangeDiff, err := repo.RangeDiff("2", "1")
+	if err != nil {
+		t.Fatalf("RangeDiff failed: %v", err)
+	}
+	if !strings.Contains(string(rangeDiff), "+\treturn b + a") || strings.Contains(string(rangeDiff), "README.md") {
+		t.Errorf("Expected only the changes of the head since the fork:\n%s", rangeDiff)
+	}
+}
+
+func TestHgRevsetString(t *testing.T) {
+	if got := hgRevsetString(`feature-x`); got != `'feature-x'` {
+		t.Errorf("Expected the name quoted, got %s", got)
+	}
+	if got := hgRevsetString(`it's\`); got != `'it\'s\\'` {
+		t.Errorf("Expected the quote and backslash escaped, got %s", got)
+	}
+}
+
+func insideRepository(dir string) bool {
+	for current := dir; ; {
+		if exists(filepath.Join(current, ".git")) || exists(filepath.Join(current, ".hg")) {
+			return true
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return false
+		}
+		current = parent
+	}
+}
+
+func TestGit_BranchAndPendingCommitMessage(t *testing.T) {
+	tempDir := setupGitRepo(t)
+	writeFile(t, tempDir, "main.go", "package main\n")
+	runCmd(t, tempDir, "git", "add", ".")
+	runCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+	runCmd(t, tempDir, "git", "checkout", "-b", "feature/PAY-42-refunds")
+
+	repo := NewGit(tempDir)
+
+	branch, err := repo.Branch()
+	if err != nil || branch != "feature/PAY-42-refunds" {
+		t.Errorf("Expected the checked out branch, got %q, %v", branch, err)
+	}
+	if commit, err := repo.HeadCommit(); err != nil || len(commit) != 40 {
+		t.Errorf("Expected the hash of the checked out commit, got %q, %v", commit, err)
+	}
+
+	message, err := repo.PendingCommitMessage()
+	if err != nil || message != "" {
+		t.Errorf("Expected the message of the last commit to be ignored, got %q, %v", message, err)
+	}
+
+	messagePath := filepath.Join(tempDir, ".git", "COMMIT_EDITMSG")
+	writeFile(t, tempDir, filepath.Join(".git", "COMMIT_EDITMSG"), "Refund partial payments\n\nRefs: PAY-43\n# Please enter the commit message\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(messagePath, later, later); err != nil {
+		t.Fatalf("Failed to touch the commit message: %v", err)
+	}
+
+	message, err = repo.PendingCommitMessage()
+	if err != nil || message != "Refund partial payments\n\nRefs: PAY-43" {
+		t.Errorf("Expected the prepared message without comments, got %q, %v", message, err)
+	}
+
+	runCmd(t, tempDir, "git", "checkout", "--detach")
+	if branch, err := repo.Branch(); err != nil || branch != "" {
+		t.Errorf("Expected no branch on a detached HEAD, got %q, %v", branch, err)
+	}
+}
+
+func TestGit_SuperprojectRoot(t *testing.T) {
+	library := setupGitRepo(t)
+	writeFile(t, library, "lib.go", "package lib\n")
+	runCmd(t, library, "git", "add", ".")
+	runCmd(t, library, "git", "commit", "-m", "Initial commit")
+
+	app := setupGitRepo(t)
+	runCmd(t, app, "git", "-c", "protocol.file.allow=always", "submodule", "add", library, "lib")
+
+	if superproject, err := NewGit(app).SuperprojectRoot(); err != nil || superproject != "" {
+		t.Errorf("Expected no superproject for the top repository, got %q, %v", superproject, err)
+	}
+
+	submodule := NewGit(filepath.Join(app, "lib"))
+	root, err := submodule.Root()
+	if err != nil {
+		t.Fatalf("Root failed: %v", err)
+	}
+	expectedRoot, _ := filepath.EvalSymlinks(filepath.Join(app, "lib"))
+	if actualRoot, _ := filepath.EvalSymlinks(root); actualRoot != expectedRoot {
+		t.Errorf("Expected the submodule root %s, got %s", expectedRoot, actualRoot)
+	}
+
+	superproject, err := submodule.SuperprojectRoot()
+	if err != nil {
+		t.Fatalf("SuperprojectRoot failed: %v", err)
+	}
+	expectedSuperproject, _ := filepath.EvalSymlinks(app)
+	if actualSuperproject, _ := filepath.EvalSymlinks(superproject); actualSuperproject != expectedSuperproject {
+		t.Errorf("Expected the superproject %s, got %s", expectedSuperproject, actualSuperproject)
+	}
+}
+
+func TestGit_MergeBaseRevision(t *testing.T) {
+	tempDir := setupGitRepo(t)
+	writeFile(t, tempDir, "main.go", "package main\n\nfunc main() {\n\tAdd(1, 2)\n}\n")
+	runCmd(t, tempDir, "git", "add", ".")
+	runCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+	runCmd(t, tempDir, "git", "branch", "-M", "main")
+	runCmd(t, tempDir, "git", "checkout", "-b", "feature")
+	writeFile(t, tempDir, "main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, tempDir, "feature.go", "package main\n")
+	runCmd(t, tempDir, "git", "add", ".")
+	runCmd(t, tempDir, "git", "commit", "-m", "Feature")
+
+	repo := NewGit(tempDir)
+	base, err := repo.MergeBase("main", "HEAD")
+	if err != nil {
+		t.Fatalf("MergeBase failed: %v", err)
+	}
+
+	files, err := repo.TrackedFilesAt(base)
+	if err != nil {
+		t.Fatalf("TrackedFilesAt failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "main.go" {
+		t.Errorf("Expected [main.go] at the merge base, got %v", files)
+	}
+
+	reader, err := repo.NewRevisionReader(base)
+	if err != nil {
+		t.Fatalf("NewRevisionReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	content, err := reader.ReadFile("main.go")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !strings.Contains(string(content), "Add(1, 2)") {
+		t.Errorf("Expected the content of the merge base, got:\n%s", content)
+	}
+	if _, err := reader.ReadFile("feature.go"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a file added on the branch not to exist at the merge base, got %v", err)
+	}
+	// The reader stays usable after a missing file
+	if content, err := reader.ReadFile("main.go"); err != nil || len(content) == 0 {
+		t.Errorf("Expected main.go to be read again, got %q, %v", content, err)
+	}
+}
+
+func TestGit_WorkingDiffUntrackedFiles(t *testing.T) {
+	tempDir := setupGitRepo(t)
+	if err := os.MkdirAll(filepath.Join(tempDir, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, tempDir, ".gitignore", "*.log\n")
+	writeFile(t, tempDir, "pkg/new.go", "package pkg\n")
+	writeFile(t, tempDir, "debug.log", "ignored\n")
+
+	// Before the first commit the tracked changes are diffed against the empty tree
+	runCmd(t, tempDir, "git", "add", ".gitignore")
+	working, err := NewGit(tempDir).WorkingDiff()
+	if err != nil {
+		t.Fatalf("WorkingDiff failed: %v", err)
+	}
+	for _, part := range []string{"+*.log", "+++ b/pkg/new.go", "+package pkg"} {
+		if !strings.Contains(string(working), part) {
+			t.Errorf("Expected %q in the working diff:\n%s", part, working)
+		}
+	}
+	if strings.Contains(string(working), "debug.log") {
+		t.Errorf("Expected ignored files to be left out:\n%s", working)
+	}
+
+	// From a subdirectory, new files are named from the root like the tracked ones
+	runCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+	writeFile(t, tempDir, "pkg/other.go", "package pkg\n\nvar x = 1\n")
+	working, err = NewGit(filepath.Join(tempDir, "pkg")).WorkingDiff("other.go")
+	if err != nil {
+		t.Fatalf("WorkingDiff failed: %v", err)
+	}
+	if !strings.Contains(string(working), "+++ b/pkg/other.go") || strings.Contains(string(working), "new.go") {
+		t.Errorf("Expected only the diff of pkg/other.go:\n%s", working)
+	}
+}
+
+func setupGitRepo(t *testing.T) string {
+	tempDir := t.TempDir()
+	runCmd(t, tempDir, "git", "init")
+	runCmd(t, tempDir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, tempDir, "git", "config", "user.name", "Test User")
+	return tempDir
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file %s: %v", name, err)
+	}
+}
+
+func runCmd(t *testing.T, dir, name string, args ...string) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Command failed: %s %v, error: %v, output: %s", name, args, err, out)
+	}
+}
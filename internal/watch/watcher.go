@@ -0,0 +1,162 @@
+package watch
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/agusespa/diffpector/internal/platform"
+)
+
+// Watcher monitors a directory tree and reports the files modified during a burst of
+// changes once the tree has been quiet for the debounce interval.
+type Watcher struct {
+	root     string
+	debounce time.Duration
+	ignore   func(relPath string) bool
+	fsw      *fsnotify.Watcher
+}
+
+var skippedDirs = []string{"node_modules", "vendor", "build", "dist"}
+
+// New creates a watcher for root. ignore may be nil; it receives paths relative to root.
+func New(root string, debounce time.Duration, ignore func(relPath string) bool) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	w := &Watcher{
+		root:     root,
+		debounce: debounce,
+		ignore:   ignore,
+		fsw:      fsw,
+	}
+
+	if err := w.addTree(root); err != nil {
+		_ = fsw.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Run blocks until stop is closed, invoking onChange with the sorted, root-relative paths
+// of the files changed in each debounced burst. onChange runs synchronously, so changes
+// made while it executes are reported in the next burst.
+func (w *Watcher) Run(stop <-chan struct{}, onChange func(paths []string)) error {
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-stop:
+			timer.Stop()
+			return nil
+
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			if relPath, changed := w.handleEvent(event); changed {
+				pending[relPath] = true
+				timer.Reset(w.debounce)
+			}
+
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("file watcher error: %w", err)
+
+		case <-timer.C:
+			if len(pending) == 0 {
+				continue
+			}
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			slices.Sort(paths)
+			clear(pending)
+
+			onChange(paths)
+		}
+	}
+}
+
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+func (w *Watcher) handleEvent(event fsnotify.Event) (string, bool) {
+	if event.Op == fsnotify.Chmod {
+		return "", false
+	}
+
+	relPath, err := platform.RelPath(w.root, event.Name)
+	if err != nil {
+		return "", false
+	}
+
+	if w.isIgnored(relPath) {
+		return "", false
+	}
+
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			// New directories are watched, their files will produce their own events
+			_ = w.addTree(event.Name)
+			return "", false
+		}
+	}
+
+	return relPath, true
+}
+
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		if path != w.root {
+			if relPath, relErr := platform.RelPath(w.root, path); relErr == nil && w.isIgnored(relPath) {
+				return filepath.SkipDir
+			}
+		}
+
+		if err := w.fsw.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *Watcher) isIgnored(relPath string) bool {
+	for _, part := range strings.Split(relPath, "/") {
+		if strings.HasPrefix(part, ".") && part != "." {
+			return true
+		}
+		if slices.Contains(skippedDirs, part) {
+			return true
+		}
+	}
+
+	// Editor swap and backup files
+	if strings.HasSuffix(relPath, "~") || strings.HasSuffix(relPath, ".swp") {
+		return true
+	}
+
+	return w.ignore != nil && w.ignore(relPath)
+}
@@ -0,0 +1,81 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWatcher_DebouncesBurstOfChanges(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "pkg"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+
+	w, err := New(root, 150*time.Millisecond, func(relPath string) bool {
+		return relPath == "diffpector_report.md"
+	})
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	stop := make(chan struct{})
+	changes := make(chan []string, 4)
+	go func() {
+		_ = w.Run(stop, func(paths []string) { changes <- paths })
+	}()
+	defer close(stop)
+
+	writeTestFile(t, filepath.Join(root, "main.go"), "package main\n")
+	writeTestFile(t, filepath.Join(root, "pkg", "util.go"), "package pkg\n")
+	writeTestFile(t, filepath.Join(root, "diffpector_report.md"), "# report\n")
+	writeTestFile(t, filepath.Join(root, ".hidden"), "ignored\n")
+
+	select {
+	case paths := <-changes:
+		expected := []string{"main.go", "pkg/util.go"}
+		if !slices.Equal(paths, expected) {
+			t.Errorf("Expected %v, got %v", expected, paths)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timed out waiting for debounced change notification")
+	}
+
+	select {
+	case paths := <-changes:
+		t.Errorf("Expected a single notification for the burst, got another: %v", paths)
+	case <-time.After(400 * time.Millisecond):
+	}
+}
+
+func TestWatcher_IsIgnored(t *testing.T) {
+	w := &Watcher{}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"main.go", false},
+		{"internal/agent/agent.go", false},
+		{".git/index", true},
+		{"web/node_modules/lib/index.js", true},
+		{"vendor/github.com/x/y.go", true},
+		{"main.go~", true},
+		{".main.go.swp", true},
+	}
+
+	for _, tt := range tests {
+		if got := w.isIgnored(tt.path); got != tt.expected {
+			t.Errorf("isIgnored(%q) = %v, expected %v", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file %s: %v", path, err)
+	}
+}
@@ -1,13 +1,39 @@
 package config
 
-import (
-	"encoding/json"
-	"fmt"
-	"os"
-)
-
 type Config struct {
-	LLM LLMConfig `json:"llm"`
+	LLM          LLMConfig          `json:"llm"`
+	Report       ReportConfig       `json:"report"`
+	Prompts      PromptConfig       `json:"prompts"`
+	Checks       ChecksConfig       `json:"checks"`
+	Context      ContextConfig      `json:"context"`
+	Verification VerificationConfig `json:"verification"`
+	Severities   SeverityConfig     `json:"severities"`
+	Integrations IntegrationsConfig `json:"integrations"`
+	Generated    GeneratedConfig    `json:"generated"`
+	Privacy      PrivacyConfig      `json:"privacy"`
+	Security     SecurityConfig     `json:"security"`
+	Audit        AuditConfig        `json:"audit"`
+	History      HistoryConfig      `json:"history"`
+	Ensemble     EnsembleConfig     `json:"ensemble"`
+	Pipeline     PipelineConfig     `json:"pipeline"`
+	Exploration  ExplorationConfig  `json:"exploration"`
+	Plugins      []PluginConfig     `json:"plugins,omitempty"`
+	MCPServers   []MCPServerConfig  `json:"mcp_servers,omitempty"`
+	// Profiles are named sets of settings selected with --profile, e.g. quick or thorough
+	Profiles map[string]ProfileConfig `json:"profiles,omitempty"`
+}
+
+// ProfileConfig bundles the settings a profile replaces, the empty ones keep the configured
+// values. The security profile also gathers the taint-style context.
+type ProfileConfig struct {
+	// Model replaces llm.model
+	Model string `json:"model,omitempty"`
+	// Prompt reviews every file with this prompt variant, ignoring the prompts section
+	Prompt string `json:"prompt,omitempty"`
+	// ContextStrategy replaces context.strategy
+	ContextStrategy string `json:"context_strategy,omitempty"`
+	// FailOn is the severity gate, the least severe level of the issues failing the review
+	FailOn string `json:"fail_on,omitempty"`
 }
 
 type LLMConfig struct {
@@ -15,6 +41,381 @@ type LLMConfig struct {
 	Model    string `json:"model"`
 	BaseURL  string `json:"base_url"`
 	APIKey   string `json:"api_key,omitempty"`
+	// StructuredOutput constrains review answers to the issues JSON schema
+	StructuredOutput bool `json:"structured_output,omitempty"`
+	// AutoPull downloads the model when Ollama does not have it yet
+	AutoPull bool `json:"auto_pull,omitempty"`
+	// TimeoutSeconds bounds each call to the model, 600 when unset
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// RateLimit keeps the calls under the quota of a hosted provider
+	RateLimit RateLimitConfig `json:"rate_limit"`
+	// Azure configures the azure-openai provider, base_url being the resource endpoint
+	Azure AzureConfig `json:"azure"`
+	// Embedded configures the embedded provider, which loads a GGUF model in process
+	Embedded EmbeddedConfig `json:"embedded"`
+}
+
+// EnsembleConfig reviews every file with several models and merges their findings
+type EnsembleConfig struct {
+	// Models are the members of the ensemble, see Inherit for the fields taken from the llm
+	// section. The llm section itself still runs the verifier and the descriptions.
+	Models []LLMConfig `json:"models,omitempty"`
+	// Policy is "consensus", reporting the issues found by min_agreement models, or "union",
+	// reporting every issue with the models that found it
+	Policy string `json:"policy,omitempty"`
+	// MinAgreement defaults to a majority of the models
+	MinAgreement int `json:"min_agreement,omitempty"`
+}
+
+// Inherit fills the empty fields of an ensemble member with the ones of the llm section, so
+// that members sharing its server only name their model. The connection fields, the server,
+// API key, rate limit and the azure and embedded settings, are only taken when the member
+// uses the provider of the llm section, so that neither its requests nor its key go to the
+// server of another provider. The credentials are also kept from a member of another server.
+func (c LLMConfig) Inherit(base LLMConfig) LLMConfig {
+	if c.TimeoutSeconds == 0 {
+		c.TimeoutSeconds = base.TimeoutSeconds
+	}
+	if c.Provider != "" && c.Provider != base.Provider {
+		return c
+	}
+	c.Provider = base.Provider
+	sameServer := c.BaseURL == "" || c.BaseURL == base.BaseURL
+	if c.BaseURL == "" {
+		c.BaseURL = base.BaseURL
+	}
+	if c.APIKey == "" && sameServer {
+		c.APIKey = base.APIKey
+	}
+	if c.RateLimit == (RateLimitConfig{}) {
+		c.RateLimit = base.RateLimit
+	}
+	if c.Azure == (AzureConfig{}) && sameServer {
+		c.Azure = base.Azure
+	}
+	if c.Embedded == (EmbeddedConfig{}) {
+		c.Embedded = base.Embedded
+	}
+	return c
+}
+
+// PipelineConfig configures the stages the files go through before the review
+type PipelineConfig struct {
+	// TriageModel is a fast model of the llm section provider classifying every file diff as
+	// trivial or in need of a review, only the latter are reviewed. Empty reviews all files.
+	TriageModel string `json:"triage_model,omitempty"`
+	// Summary summarizes the whole changeset first and gives the summary to the review of
+	// each file, for cross-file awareness. The report shows it too.
+	Summary bool `json:"summary,omitempty"`
+}
+
+// ExplorationConfig offers read-only tools to the model during the review, so that it can
+// pull the context it is missing itself: read_file, git_grep and symbol_context
+type ExplorationConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// ReadPaths are the globs of the repository files read_file may read, e.g. "src/**",
+	// every file but the .git directory when empty
+	ReadPaths []string `json:"read_paths,omitempty"`
+	// MaxCalls bounds the calls to the tools in the review of each file, 10 when unset
+	MaxCalls int `json:"max_calls,omitempty"`
+}
+
+// RateLimitConfig is the quota of the provider, a zero field is unlimited
+type RateLimitConfig struct {
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	TokensPerMinute   int `json:"tokens_per_minute,omitempty"`
+}
+
+type EmbeddedConfig struct {
+	ModelPath string `json:"model_path"`
+	// ContextSize is the context window in tokens, 4096 when unset
+	ContextSize int `json:"context_size,omitempty"`
+	// Threads defaults to the number of CPUs
+	Threads int `json:"threads,omitempty"`
+}
+
+type AzureConfig struct {
+	// Deployment defaults to the model name
+	Deployment string `json:"deployment,omitempty"`
+	// APIVersion is sent as the api-version query parameter
+	APIVersion string `json:"api_version,omitempty"`
+	// Without an API key, requests use a Microsoft Entra ID (AAD) token: a static ADToken
+	// or one obtained with the client credentials of an app registration
+	ADToken      string `json:"ad_token,omitempty"`
+	TenantID     string `json:"tenant_id,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+type ReportConfig struct {
+	// CollapseSeverities lists the severities folded under the "possibly noteworthy" section
+	CollapseSeverities []string `json:"collapse_severities"`
+	// CollapseBelowConfidence folds issues whose model confidence is lower than this value
+	CollapseBelowConfidence float64 `json:"collapse_below_confidence"`
+	// MinConfidence hides the model findings whose confidence is lower than this value
+	MinConfidence float64 `json:"min_confidence,omitempty"`
+	// Sampling aggregates repeated similar issues into a single entry
+	Sampling SamplingConfig `json:"sampling"`
+	// Sinks lists where the final report goes: file, stdout and webhook. Defaults to file.
+	Sinks []string `json:"sinks,omitempty"`
+	// WebhookURL receives the report as JSON when the webhook sink is selected
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// Slack configures the summary posted by the slack sink
+	Slack SlackConfig `json:"slack"`
+	// Owners groups the issues of the report by the owners of their files
+	Owners OwnersConfig `json:"owners"`
+	// Format of the report file: markdown, the default, or html
+	Format string `json:"format,omitempty"`
+	// Path of the report file relative to the repository root, diffpector_report.md or
+	// diffpector_report.html by default. Reports in .diffpector/ need no .gitignore entry.
+	Path string `json:"path,omitempty"`
+	// Template is a Go text/template file, relative to the repository root, replacing the
+	// default layout of the markdown report
+	Template string `json:"template,omitempty"`
+}
+
+// OwnersConfig reads the owners of the files from CODEOWNERS or a file in its syntax
+type OwnersConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// File is the owners file relative to the repository root, the CODEOWNERS file of
+	// .github, the root, docs or .gitlab by default
+	File string `json:"file,omitempty"`
+}
+
+// SlackConfig posts a review summary through an incoming webhook or a bot token
+type SlackConfig struct {
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// BotToken posts with chat.postMessage to Channel when no webhook is set
+	BotToken string `json:"bot_token,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+	// MinSeverity only notifies reviews with an issue at least this severe, empty always notifies
+	MinSeverity string `json:"min_severity,omitempty"`
+	// LinkTemplate links the issue locations, {path} and {line} are replaced,
+	// e.g. https://github.com/org/repo/blob/main/{path}#L{line}
+	LinkTemplate string `json:"link_template,omitempty"`
+}
+
+// SamplingConfig keeps the first issues of a repeated pattern and aggregates the rest.
+// Issues are similar when they share severity, category and description wording.
+type SamplingConfig struct {
+	// Severities lists the severities sampled, issues of other severities are all reported
+	Severities []string `json:"severities"`
+	// Keep is the number of similar issues reported before the rest are aggregated, 0 disables sampling
+	Keep int `json:"keep"`
+	// Categories overrides Keep per check category, "llm" covers the model's findings
+	Categories map[string]int `json:"categories,omitempty"`
+}
+
+// PromptConfig selects the prompt variant used to review each file. Path rules take
+// precedence over languages, files matching neither use the default variant.
+type PromptConfig struct {
+	// Languages maps a language name (go, java, typescript) to a prompt variant
+	Languages map[string]string `json:"languages,omitempty"`
+	// Paths maps path globs to prompt variants, the first matching rule wins
+	Paths []PromptPathRule `json:"paths,omitempty"`
+	// Questions lets the model ask the author non-blocking questions, reported apart from issues
+	Questions bool `json:"questions,omitempty"`
+}
+
+type PromptPathRule struct {
+	Pattern string `json:"pattern"`
+	Variant string `json:"variant"`
+}
+
+// ChecksConfig toggles the deterministic checks that run alongside the LLM review
+type ChecksConfig struct {
+	// DocDrift flags changed functions whose doc comment may no longer match
+	DocDrift bool `json:"doc_drift"`
+	// MissingTests flags changed public functions without a test update in the same diff
+	MissingTests bool `json:"missing_tests"`
+	// MissingTestsSeverity is the severity of the missing test issues
+	MissingTestsSeverity string `json:"missing_tests_severity,omitempty"`
+	// Duplicates flags added code nearly duplicating existing code and shows it to the model
+	Duplicates bool `json:"duplicates"`
+	// BreakingChanges flags the Go and Java functions whose parameter count changed while
+	// callers outside the diff still pass the old arguments
+	BreakingChanges bool `json:"breaking_changes"`
+	// UnusedSymbols flags the symbols the repository no longer references once the diff
+	// removed their last reference
+	UnusedSymbols bool `json:"unused_symbols"`
+	// Secrets redacts the credentials of the prompts sent to a remote provider and flags
+	// the ones the diff adds
+	Secrets bool `json:"secrets"`
+}
+
+// PrivacyConfig anonymizes the code sent to remote providers: string literals and comments
+// become placeholders, restored in the findings
+type PrivacyConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Identifiers anonymizes the names of Go, Java and TypeScript code as well
+	Identifiers bool `json:"identifiers,omitempty"`
+}
+
+// SecurityConfig decides which LLM providers may receive the code. Local providers are
+// allowed by default, remote ones need AllowRemote.
+type SecurityConfig struct {
+	AllowRemote bool `json:"allow_remote,omitempty"`
+	// DenyHosts blocks providers at these hosts, e.g. api.openai.com or *.example.com
+	DenyHosts []string `json:"deny_hosts,omitempty"`
+}
+
+// AuditConfig records every prompt, tool call and answer of the model in a JSON lines file
+// per run
+type AuditConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Dir defaults to .diffpector/audit, relative paths are resolved from the repository root
+	Dir string `json:"dir,omitempty"`
+	// Redact replaces the secrets of the recorded prompts and answers
+	Redact bool `json:"redact,omitempty"`
+}
+
+// HistoryConfig records a summary of every review in a JSON lines file, read by the stats
+// command
+type HistoryConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Path defaults to .diffpector/history.jsonl, relative paths are resolved from the
+	// repository root
+	Path string `json:"path,omitempty"`
+}
+
+// GeneratedConfig classifies lockfiles and generated files, which are noted in the report
+// instead of reviewed
+type GeneratedConfig struct {
+	// Patterns are globs of generated files, added to the built-in ones
+	Patterns []string `json:"patterns,omitempty"`
+	// Review turns the classification off, generated files are reviewed like the others
+	Review bool `json:"review,omitempty"`
+}
+
+// VerificationConfig runs a second LLM pass asking whether each finding of the model is
+// genuinely introduced by the diff
+type VerificationConfig struct {
+	Enabled bool `json:"enabled"`
+	// Action is what happens to the rejected findings: drop (default) or downgrade to the
+	// least severe level
+	Action string `json:"action,omitempty"`
+	// Prompt is the verify prompt variant, verify when unset
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// ContextConfig tunes the context gathered around the changed symbols
+type ContextConfig struct {
+	// Strategy picks how much context the model gets: hunks-only, affected-symbols or full-file
+	Strategy string `json:"strategy"`
+	// AdaptiveExpansion adds the callers of callers of large, widely used symbols when the
+	// diff of their file is only a few lines
+	AdaptiveExpansion bool `json:"adaptive_expansion"`
+	// ExpansionTokenBudget bounds the context added by the expansion to each file
+	ExpansionTokenBudget int `json:"expansion_token_budget"`
+	// Depth follows the callers of the changed functions transitively: 1 only shows their
+	// usages, 2 adds the callers of their callers and so on
+	Depth int `json:"depth"`
+	// CallGraphTokenBudget bounds the call chains added to each file when Depth is above 1
+	CallGraphTokenBudget int `json:"call_graph_token_budget"`
+	// Tests adds the existing tests referencing the changed symbols
+	Tests bool `json:"tests"`
+	// History adds the last commits touching the changed lines of staged and working tree
+	// reviews, up to HistoryCommits per hunk
+	History        bool `json:"history"`
+	HistoryCommits int  `json:"history_commits"`
+	// ChunkLines reviews the file diffs of more lines in chunks of about this size, grouping
+	// their hunks by the declaration they change, 0 reviews every diff whole
+	ChunkLines int `json:"chunk_lines"`
+}
+
+// SeverityConfig replaces the CRITICAL/WARNING/MINOR taxonomy. The model keeps reporting
+// those severities, Mapping translates them into the configured levels.
+type SeverityConfig struct {
+	// Levels lists the severities from most to least severe
+	Levels  []SeverityLevel   `json:"levels,omitempty"`
+	Mapping map[string]string `json:"mapping,omitempty"`
+}
+
+type SeverityLevel struct {
+	Name string `json:"name"`
+	Icon string `json:"icon,omitempty"`
+}
+
+// PluginConfig declares an external executable the model may call as a tool during the
+// review. The tool arguments are written to its stdin as JSON, its stdout is the result.
+type PluginConfig struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Command is the executable and its arguments, run from the repository root
+	Command []string `json:"command"`
+	// Parameters is the JSON schema of the arguments, an object without properties when unset
+	Parameters map[string]any `json:"parameters,omitempty"`
+	// TimeoutSeconds bounds each call, 30 when unset
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// MCPServerConfig connects to a Model Context Protocol server whose tools the model may call
+// during the review, either a command speaking over stdio or a streamable HTTP endpoint
+type MCPServerConfig struct {
+	Name    string            `json:"name"`
+	Command []string          `json:"command,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	// Headers are sent with every HTTP request, e.g. Authorization
+	Headers map[string]string `json:"headers,omitempty"`
+	// Tools restricts the offered tools to these names, all when empty
+	Tools []string `json:"tools,omitempty"`
+}
+
+type IntegrationsConfig struct {
+	Bitbucket BitbucketConfig `json:"bitbucket"`
+	Gerrit    GerritConfig    `json:"gerrit"`
+	Tickets   TicketsConfig   `json:"tickets"`
+	OSV       OSVConfig       `json:"osv"`
+}
+
+type BitbucketConfig struct {
+	// BaseURL defaults to the Bitbucket Cloud API, it is required for Bitbucket Server
+	BaseURL string `json:"base_url,omitempty"`
+	// Workspace is the Cloud workspace or the Server project key
+	Workspace  string `json:"workspace"`
+	Repository string `json:"repository"`
+	// Either an access token or a username with an app password
+	Username    string `json:"username,omitempty"`
+	AppPassword string `json:"app_password,omitempty"`
+	AccessToken string `json:"access_token,omitempty"`
+	Server      bool   `json:"server,omitempty"`
+}
+
+type GerritConfig struct {
+	BaseURL string `json:"base_url"`
+	// Username and the HTTP password generated in the Gerrit settings
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Label is voted on, Code-Review by default
+	Label string `json:"label,omitempty"`
+	// Votes maps severities to the vote cast when a finding has them, the lowest applies.
+	// Defaults to -1 for CRITICAL.
+	Votes   map[string]int `json:"votes,omitempty"`
+	RobotID string         `json:"robot_id,omitempty"`
+}
+
+// TicketsConfig looks up the tickets referenced by the branch name and the commit message,
+// their title and description tell the review what the change is meant to do
+type TicketsConfig struct {
+	// Provider is "jira" or "github", empty disables the lookup
+	Provider string `json:"provider"`
+	// BaseURL is the Jira site, or the GitHub API for GitHub Enterprise
+	BaseURL string `json:"base_url,omitempty"`
+	// Username is the Jira account email the API token belongs to
+	Username string `json:"username,omitempty"`
+	// Token is the Jira API token or the GitHub token, optional for public GitHub repositories
+	Token string `json:"token,omitempty"`
+	// Repository is the owner/name of the GitHub repository the #123 references point to
+	Repository string `json:"repository,omitempty"`
+}
+
+// OSVConfig looks up the known vulnerabilities of the dependency versions a change adds or
+// upgrades in the OSV database
+type OSVConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// BaseURL defaults to the public https://api.osv.dev, set it for a mirror
+	BaseURL string `json:"base_url,omitempty"`
 }
 
 func DefaultConfig() *Config {
@@ -24,21 +425,49 @@ func DefaultConfig() *Config {
 			Model:    "",
 			BaseURL:  "http://localhost:8080",
 		},
+		Report:  DefaultReportConfig(),
+		Checks:  DefaultChecksConfig(),
+		Context: DefaultContextConfig(),
 	}
 }
 
-func LoadConfig(filename string) (*Config, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		fmt.Printf("WARNING: Failed to read config file '%s': %v. Using default configuration.\n", filename, err)
-		return DefaultConfig(), nil
+func DefaultReportConfig() ReportConfig {
+	return ReportConfig{
+		CollapseSeverities:      []string{"MINOR"},
+		CollapseBelowConfidence: 0.5,
+		Sampling: SamplingConfig{
+			Severities: []string{"MINOR"},
+			Keep:       3,
+		},
+	}
+}
+
+func DefaultChecksConfig() ChecksConfig {
+	return ChecksConfig{
+		DocDrift:             true,
+		MissingTestsSeverity: "MINOR",
+		Secrets:              true,
 	}
+}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file '%s': %w", filename, err)
+func DefaultContextConfig() ContextConfig {
+	return ContextConfig{
+		AdaptiveExpansion:    true,
+		ExpansionTokenBudget: 2000,
+		Depth:                1,
+		CallGraphTokenBudget: 2000,
+		Strategy:             "affected-symbols",
+		HistoryCommits:       3,
+		ChunkLines:           400,
 	}
+}
 
-	fmt.Printf("INFO: Successfully loaded configuration from '%s'.\n", filename)
-	return &config, nil
+// LoadConfig loads the configuration merged from the system and user config files and the
+// repository one at filename, see LoadEffective
+func LoadConfig(filename string) (*Config, error) {
+	effective, err := LoadEffective(filename)
+	if err != nil {
+		return nil, err
+	}
+	return effective.Config, nil
 }
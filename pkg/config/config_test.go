@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -32,6 +33,81 @@ func TestLoadConfig(t *testing.T) {
 					Model:    "qwen2.5-coder",
 					BaseURL:  "http://localhost:11434",
 				},
+				Report:  DefaultReportConfig(),
+				Checks:  DefaultChecksConfig(),
+				Context: DefaultContextConfig(),
+			},
+		},
+		{
+			name:     "structured output enabled",
+			filename: "structured_config.json",
+			configJSON: `{
+				"llm": {
+					"provider": "openai",
+					"base_url": "http://localhost:8080",
+					"structured_output": true
+				}
+			}`,
+			expectError: false,
+			expected: &Config{
+				LLM: LLMConfig{
+					Provider:         "openai",
+					BaseURL:          "http://localhost:8080",
+					StructuredOutput: true,
+				},
+				Report:  DefaultReportConfig(),
+				Checks:  DefaultChecksConfig(),
+				Context: DefaultContextConfig(),
+			},
+		},
+		{
+			name:     "checks can be disabled",
+			filename: "checks_config.json",
+			configJSON: `{
+				"checks": {
+					"doc_drift": false,
+					"secrets": false
+				}
+			}`,
+			expectError: false,
+			expected: &Config{
+				Report:  DefaultReportConfig(),
+				Checks:  ChecksConfig{DocDrift: false, MissingTestsSeverity: "MINOR"},
+				Context: DefaultContextConfig(),
+			},
+		},
+		{
+			name:     "context budget overrides default",
+			filename: "context_config.json",
+			configJSON: `{
+				"context": {
+					"expansion_token_budget": 500,
+					"strategy": "full-file"
+				}
+			}`,
+			expectError: false,
+			expected: &Config{
+				Report:  DefaultReportConfig(),
+				Checks:  DefaultChecksConfig(),
+				Context: ContextConfig{AdaptiveExpansion: true, ExpansionTokenBudget: 500, Depth: 1, CallGraphTokenBudget: 2000, Strategy: "full-file", HistoryCommits: 3, ChunkLines: 400},
+			},
+		},
+		{
+			name:     "partial report config keeps defaults",
+			filename: "report_config.json",
+			configJSON: `{
+				"report": {
+					"collapse_severities": []
+				}
+			}`,
+			expectError: false,
+			expected: &Config{
+				Report: ReportConfig{
+					CollapseSeverities:      []string{},
+					CollapseBelowConfidence: 0.5,
+				},
+				Checks:  DefaultChecksConfig(),
+				Context: DefaultContextConfig(),
 			},
 		},
 		{
@@ -61,7 +137,10 @@ func TestLoadConfig(t *testing.T) {
 			configJSON:  `{}`,
 			expectError: false,
 			expected: &Config{
-				LLM: LLMConfig{},
+				LLM:     LLMConfig{},
+				Report:  DefaultReportConfig(),
+				Checks:  DefaultChecksConfig(),
+				Context: DefaultContextConfig(),
 			},
 		},
 	}
@@ -119,7 +198,48 @@ func TestLoadConfig(t *testing.T) {
 				if config.LLM.BaseURL != tt.expected.LLM.BaseURL {
 					t.Errorf("LLM BaseURL mismatch: Expected %q, Got %q", tt.expected.LLM.BaseURL, config.LLM.BaseURL)
 				}
+				if config.LLM.StructuredOutput != tt.expected.LLM.StructuredOutput {
+					t.Errorf("LLM StructuredOutput mismatch: Expected %v, Got %v", tt.expected.LLM.StructuredOutput, config.LLM.StructuredOutput)
+				}
+				if config.Checks != tt.expected.Checks {
+					t.Errorf("Checks mismatch: Expected %+v, Got %+v", tt.expected.Checks, config.Checks)
+				}
+				if config.Context != tt.expected.Context {
+					t.Errorf("Context mismatch: Expected %+v, Got %+v", tt.expected.Context, config.Context)
+				}
+				if config.Report.CollapseBelowConfidence != tt.expected.Report.CollapseBelowConfidence {
+					t.Errorf("Report CollapseBelowConfidence mismatch: Expected %v, Got %v", tt.expected.Report.CollapseBelowConfidence, config.Report.CollapseBelowConfidence)
+				}
+				if strings.Join(config.Report.CollapseSeverities, ",") != strings.Join(tt.expected.Report.CollapseSeverities, ",") {
+					t.Errorf("Report CollapseSeverities mismatch: Expected %v, Got %v", tt.expected.Report.CollapseSeverities, config.Report.CollapseSeverities)
+				}
 			}
 		})
 	}
 }
+
+func TestLLMConfig_Inherit(t *testing.T) {
+	base := LLMConfig{Provider: "ollama", Model: "qwen2.5-coder:7b", BaseURL: "http://localhost:11434", APIKey: "local-key", TimeoutSeconds: 120,
+		RateLimit: RateLimitConfig{RequestsPerMinute: 10}}
+
+	sameProvider := LLMConfig{Model: "llama3.1:8b"}.Inherit(base)
+	if sameProvider.Provider != "ollama" || sameProvider.BaseURL != base.BaseURL || sameProvider.APIKey != base.APIKey || sameProvider.RateLimit != base.RateLimit {
+		t.Errorf("Expected a member without provider to share the connection of the llm section, got %+v", sameProvider)
+	}
+	if sameProvider.Model != "llama3.1:8b" {
+		t.Errorf("Expected the member to keep its model, got %s", sameProvider.Model)
+	}
+
+	otherServer := LLMConfig{Model: "llama3.1:8b", BaseURL: "http://gpu-box:11434"}.Inherit(base)
+	if otherServer.BaseURL != "http://gpu-box:11434" || otherServer.APIKey != "" {
+		t.Errorf("Expected a member of another server to keep its server without the key of the llm section, got %+v", otherServer)
+	}
+
+	otherProvider := LLMConfig{Provider: "openai", Model: "gpt-4o"}.Inherit(base)
+	if otherProvider.BaseURL != "" || otherProvider.APIKey != "" || otherProvider.RateLimit != (RateLimitConfig{}) {
+		t.Errorf("Expected a member of another provider not to get the connection of the llm section, got %+v", otherProvider)
+	}
+	if otherProvider.Provider != "openai" || otherProvider.TimeoutSeconds != 120 {
+		t.Errorf("Expected the member to keep its provider and take the timeout, got %+v", otherProvider)
+	}
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// secretScheme prefixes the values read from a secret store, as secret://<store>/<path>
+const secretScheme = "secret://"
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// SecretStores resolve the path of a secret:// reference to its value by store name
+var SecretStores = map[string]func(path string) (string, error){
+	"keychain": readKeychain,
+	"file":     readSecretFile,
+}
+
+// expandReferences replaces the ${VAR} references of every string of the config with the
+// environment variable, and the values that are a secret:// reference with the secret, so
+// that credentials need not be committed. An unset variable or an unresolved secret fails.
+func expandReferences(cfg *Config) error {
+	return expandValue(reflect.ValueOf(cfg).Elem(), "")
+}
+
+func expandValue(value reflect.Value, path string) error {
+	switch value.Kind() {
+	case reflect.String:
+		expanded, err := expandString(value.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		value.SetString(expanded)
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if err := expandValue(value.Field(i), joinPath(path, name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			if err := expandValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// Only string values, such as env and headers, the JSON schemas of plugins are kept as is
+		if value.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range value.MapKeys() {
+			expanded, err := expandString(value.MapIndex(key).String())
+			if err != nil {
+				return fmt.Errorf("%s: %w", joinPath(path, key.String()), err)
+			}
+			value.SetMapIndex(key, reflect.ValueOf(expanded).Convert(value.Type().Elem()))
+		}
+	case reflect.Pointer:
+		if !value.IsNil() {
+			return expandValue(value.Elem(), path)
+		}
+	}
+	return nil
+}
+
+// expandString resolves a secret:// reference, or the ${VAR} references within the value
+func expandString(value string) (string, error) {
+	if reference, ok := strings.CutPrefix(value, secretScheme); ok {
+		store, secretPath, _ := strings.Cut(reference, "/")
+		resolve, ok := SecretStores[store]
+		if !ok {
+			return "", fmt.Errorf("unknown secret store %q", store)
+		}
+		if secretPath == "" {
+			return "", fmt.Errorf("missing secret path in %q", value)
+		}
+		secret, err := resolve(secretPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret %q: %w", value, err)
+		}
+		return secret, nil
+	}
+
+	var missing []string
+	expanded := envReference.ReplaceAllStringFunc(value, func(reference string) string {
+		name := envReference.FindStringSubmatch(reference)[1]
+		variable, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return variable
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// readKeychain reads a password of the OS keychain, the path being <service>/<account> or
+// <service>: the macOS keychain with security, the Secret Service with secret-tool elsewhere
+func readKeychain(path string) (string, error) {
+	service, account, _ := strings.Cut(path, "/")
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		args := []string{"find-generic-password", "-w", "-s", service}
+		if account != "" {
+			args = append(args, "-a", account)
+		}
+		cmd = exec.Command("security", args...)
+	} else {
+		args := []string{"lookup", "service", service}
+		if account != "" {
+			args = append(args, "account", account)
+		}
+		cmd = exec.Command("secret-tool", args...)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// readSecretFile reads a secret mounted as a file, such as a Docker or Kubernetes secret,
+// the path being absolute once the scheme is removed, as in secret://file//run/secrets/key
+func readSecretFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
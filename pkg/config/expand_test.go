@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandReferences(t *testing.T) {
+	t.Setenv("DIFFPECTOR_TEST_KEY", "sk-from-env")
+	t.Setenv("DIFFPECTOR_TEST_HOST", "llm.internal")
+	secretFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secretFile, []byte("bb-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stores := SecretStores
+	SecretStores = map[string]func(string) (string, error){
+		"keychain": func(path string) (string, error) {
+			if path != "diffpector/gerrit" {
+				return "", errors.New("no such item")
+			}
+			return "gerrit-password", nil
+		},
+		"file": readSecretFile,
+	}
+	defer func() { SecretStores = stores }()
+
+	cfg := DefaultConfig()
+	cfg.LLM.APIKey = "${DIFFPECTOR_TEST_KEY}"
+	cfg.LLM.BaseURL = "https://${DIFFPECTOR_TEST_HOST}:8443/v1"
+	cfg.Integrations.Bitbucket.AccessToken = "secret://file/" + secretFile
+	cfg.Integrations.Gerrit.Password = "secret://keychain/diffpector/gerrit"
+	cfg.MCPServers = []MCPServerConfig{{Name: "docs", Env: map[string]string{"TOKEN": "${DIFFPECTOR_TEST_KEY}"}, Command: []string{"docs-mcp", "--key=${DIFFPECTOR_TEST_KEY}"}}}
+
+	if err := expandReferences(cfg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.LLM.APIKey != "sk-from-env" || cfg.LLM.BaseURL != "https://llm.internal:8443/v1" {
+		t.Errorf("Expected the environment variables expanded, got %+v", cfg.LLM)
+	}
+	if cfg.Integrations.Bitbucket.AccessToken != "bb-token" || cfg.Integrations.Gerrit.Password != "gerrit-password" {
+		t.Errorf("Expected the secrets resolved, got %q and %q", cfg.Integrations.Bitbucket.AccessToken, cfg.Integrations.Gerrit.Password)
+	}
+	if cfg.MCPServers[0].Env["TOKEN"] != "sk-from-env" || cfg.MCPServers[0].Command[1] != "--key=sk-from-env" {
+		t.Errorf("Expected the MCP server values expanded, got %+v", cfg.MCPServers[0])
+	}
+}
+
+func TestExpandReferencesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		apiKey   string
+		expected string
+	}{
+		{"unset variable", "${DIFFPECTOR_TEST_UNSET}", "llm.api_key: environment variable DIFFPECTOR_TEST_UNSET is not set"},
+		{"unknown store", "secret://vault/llm", `unknown secret store "vault"`},
+		{"missing path", "secret://file/", "missing secret path"},
+		{"unreadable secret", "secret://file//nonexistent/diffpector/key", "failed to read secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.LLM.APIKey = tt.apiKey
+
+			err := expandReferences(cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
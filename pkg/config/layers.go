@@ -0,0 +1,259 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// SystemConfigPath is the configuration shared by every user of the machine, such as the
+// provider and model an organization mandates
+var SystemConfigPath = "/etc/diffpector/config.json"
+
+// Origins of the fields of the effective configuration
+const (
+	OriginDefault    = "default"
+	OriginSystem     = "system"
+	OriginUser       = "user"
+	OriginRepository = "repository"
+)
+
+// secretFields are the fields whose values are not shown, the ones of env and headers
+// holding secrets in every value
+var secretFields = []string{"api_key", "ad_token", "client_secret", "bot_token", "app_password", "access_token", "password", "token", "env", "headers"}
+
+// Layer is a config file merged into the configuration
+type Layer struct {
+	Origin string
+	Path   string
+}
+
+// UserConfigPath returns the configuration of the user, ~/.config/diffpector/config.json on
+// Linux, empty when the user config directory is unknown
+func UserConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "diffpector", "config.json")
+}
+
+// Layers returns the config files merged into the configuration from the least to the most
+// specific: the system, the user and the repository ones
+func Layers(repoConfig string) []Layer {
+	return []Layer{
+		{Origin: OriginSystem, Path: SystemConfigPath},
+		{Origin: OriginUser, Path: UserConfigPath()},
+		{Origin: OriginRepository, Path: repoConfig},
+	}
+}
+
+// Effective is the configuration merged from its layers
+type Effective struct {
+	Config *Config
+	// Origins maps the JSON path of the fields set by a layer or a flag, such as llm.model, to
+	// where their value comes from, the other fields keep their default
+	Origins map[string]string
+	// Loaded lists the layers read, missing ones are skipped
+	Loaded []Layer
+	// Warnings are the unknown keys of the layers, prefixed with their file
+	Warnings []string
+}
+
+// Field is a field of the effective configuration
+type Field struct {
+	Path   string
+	Value  string
+	Origin string
+}
+
+// LoadEffective merges the system, user and repository config files, each overriding the
+// fields the previous ones set. Objects are merged field by field, lists are replaced whole.
+// The repository file, which comes with the code reviewed, cannot loosen the security of the
+// other files though, see restrictRepository. Without any config file the default
+// configuration is used. The environment variable and secret references of the merged
+// values are then resolved, see expandReferences.
+func LoadEffective(repoConfig string) (*Effective, error) {
+	// Sections other than llm start from their defaults so partial configs keep sensible values
+	config := Config{
+		Report:  DefaultReportConfig(),
+		Checks:  DefaultChecksConfig(),
+		Context: DefaultContextConfig(),
+	}
+	effective := &Effective{Config: &config, Origins: make(map[string]string)}
+
+	var repoErr error
+	for _, layer := range Layers(repoConfig) {
+		if layer.Path == "" {
+			continue
+		}
+		data, err := os.ReadFile(layer.Path)
+		if err != nil {
+			if layer.Origin == OriginRepository {
+				repoErr = err
+			} else if !errors.Is(err, os.ErrNotExist) {
+				fmt.Printf("WARNING: Failed to read %s config file '%s': %v.\n", layer.Origin, layer.Path, err)
+			}
+			continue
+		}
+
+		lower := config
+		lower.Security.DenyHosts = slices.Clone(config.Security.DenyHosts)
+		lowerOrigins := maps.Clone(effective.Origins)
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse config file '%s': %w", layer.Path, err)
+		}
+		unknown, _ := UnknownKeys(data)
+		for _, warning := range unknown {
+			warning = fmt.Sprintf("%s: %s", layer.Path, warning)
+			effective.Warnings = append(effective.Warnings, warning)
+			fmt.Printf("WARNING: %s\n", warning)
+		}
+		var raw map[string]any
+		if err := json.Unmarshal(data, &raw); err == nil {
+			fields := make(map[string]any)
+			flatten(fields, "", raw)
+			for path := range fields {
+				effective.Origins[path] = layer.Origin
+			}
+			if layer.Origin == OriginRepository && len(effective.Loaded) > 0 {
+				for _, warning := range restrictRepository(&config, lower, fields, effective.Origins, lowerOrigins) {
+					warning = fmt.Sprintf("%s: %s", layer.Path, warning)
+					effective.Warnings = append(effective.Warnings, warning)
+					fmt.Printf("WARNING: %s\n", warning)
+				}
+			}
+		}
+		effective.Loaded = append(effective.Loaded, layer)
+		fmt.Printf("INFO: Successfully loaded configuration from '%s'.\n", layer.Path)
+	}
+
+	if len(effective.Loaded) == 0 {
+		if repoConfig != "" {
+			fmt.Printf("WARNING: Failed to read config file '%s': %v. Using default configuration.\n", repoConfig, repoErr)
+		}
+		effective.Config = DefaultConfig()
+	}
+	if err := expandReferences(effective.Config); err != nil {
+		return nil, fmt.Errorf("failed to resolve config references: %w", err)
+	}
+	return effective, nil
+}
+
+// restrictRepository undoes what the repository layer loosened of the lower layers: it may
+// only allow remote providers when they do, its denied hosts are added to theirs, and it may
+// only point the llm section to another server along with its own credentials, so that
+// their key is not sent to a server of the repository's choosing. fields are the values the
+// repository layer set, by JSON path. It returns a warning per field it undid.
+func restrictRepository(config *Config, lower Config, fields map[string]any, origins, lowerOrigins map[string]string) []string {
+	var warnings []string
+	restore := func(path string) {
+		if origin, ok := lowerOrigins[path]; ok {
+			origins[path] = origin
+		} else {
+			delete(origins, path)
+		}
+	}
+
+	if config.Security.AllowRemote && !lower.Security.AllowRemote {
+		config.Security.AllowRemote = false
+		restore("security.allow_remote")
+		warnings = append(warnings, "security.allow_remote ignored, remote providers can only be allowed by the system or user config or --allow-remote")
+	}
+	if _, ok := fields["security.deny_hosts"]; ok {
+		for _, host := range lower.Security.DenyHosts {
+			if !slices.Contains(config.Security.DenyHosts, host) {
+				config.Security.DenyHosts = append(config.Security.DenyHosts, host)
+			}
+		}
+	}
+
+	_, setsURL := fields["llm.base_url"]
+	_, setsKey := fields["llm.api_key"]
+	_, setsToken := fields["llm.azure.ad_token"]
+	_, setsSecret := fields["llm.azure.client_secret"]
+	lowerCredentials := lower.LLM.APIKey != "" || lower.LLM.Azure.ADToken != "" || lower.LLM.Azure.ClientSecret != ""
+	if setsURL && config.LLM.BaseURL != lower.LLM.BaseURL && lowerCredentials && !setsKey && !setsToken && !setsSecret {
+		config.LLM.BaseURL = lower.LLM.BaseURL
+		restore("llm.base_url")
+		warnings = append(warnings, "llm.base_url ignored, the repository config must set the credentials of the server it points to")
+	}
+	return warnings
+}
+
+// Set records a field set outside the config files, such as by a flag
+func (e *Effective) Set(path, origin string) {
+	e.Origins[path] = origin
+}
+
+// Fields lists every field of the effective configuration with its value and origin, in
+// path order. Secrets are masked.
+func (e *Effective) Fields() ([]Field, error) {
+	data, err := json.Marshal(e.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+
+	values := make(map[string]any)
+	flatten(values, "", mask(raw, false).(map[string]any))
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	fields := make([]Field, 0, len(paths))
+	for _, path := range paths {
+		origin, ok := e.Origins[path]
+		if !ok {
+			origin = OriginDefault
+		}
+		value, _ := json.Marshal(values[path])
+		fields = append(fields, Field{Path: path, Value: string(value), Origin: origin})
+	}
+	return fields, nil
+}
+
+// flatten maps the JSON path of every non-object value of raw to the value, lists and empty
+// objects being values
+func flatten(values map[string]any, prefix string, raw map[string]any) {
+	for key, value := range raw {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if object, ok := value.(map[string]any); ok && len(object) > 0 {
+			flatten(values, path, object)
+			continue
+		}
+		values[path] = value
+	}
+}
+
+// mask replaces the non-empty strings of the secret fields of a decoded config
+func mask(value any, secret bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			v[key] = mask(field, secret || slices.Contains(secretFields, key))
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = mask(item, secret)
+		}
+	case string:
+		if secret && v != "" {
+			return "********"
+		}
+	}
+	return value
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeLayer(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadEffective(t *testing.T) {
+	dir := t.TempDir()
+	systemPath := SystemConfigPath
+	SystemConfigPath = filepath.Join(dir, "etc", "config.json")
+	defer func() { SystemConfigPath = systemPath }()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "home"))
+
+	writeLayer(t, SystemConfigPath, `{"llm": {"provider": "ollama", "base_url": "http://llm.internal:11434", "api_key": "org-key"}, "report": {"collapse_severities": ["MINOR", "WARNING"]}}`)
+	writeLayer(t, UserConfigPath(), `{"llm": {"model": "qwen2.5-coder"}, "checks": {"secrets": false}}`)
+	repoPath := filepath.Join(dir, "diffpectrc.json")
+	writeLayer(t, repoPath, `{"llm": {"model": "llama3"}, "report": {"collapse_severities": []}, "mcp_servers": [{"name": "docs", "env": {"DOCS_TOKEN": "abc"}}]}`)
+
+	effective, err := LoadEffective(repoPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cfg := effective.Config
+	if cfg.LLM.Provider != "ollama" || cfg.LLM.BaseURL != "http://llm.internal:11434" || cfg.LLM.Model != "llama3" {
+		t.Errorf("Expected the llm fields merged across layers, got %+v", cfg.LLM)
+	}
+	if cfg.Checks.Secrets || !cfg.Checks.DocDrift {
+		t.Errorf("Expected the user to disable the secrets check only, got %+v", cfg.Checks)
+	}
+	if len(cfg.Report.CollapseSeverities) != 0 || cfg.Report.CollapseBelowConfidence != 0.5 {
+		t.Errorf("Expected the repository to replace the list and keep the defaults, got %+v", cfg.Report)
+	}
+	if len(effective.Loaded) != 3 {
+		t.Errorf("Expected the three layers to be loaded, got %+v", effective.Loaded)
+	}
+
+	effective.Set("llm.model", "flag --model")
+	fields, err := effective.Fields()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	byPath := make(map[string]Field)
+	for _, field := range fields {
+		byPath[field.Path] = field
+	}
+
+	expected := map[string]Field{
+		"llm.provider":               {Path: "llm.provider", Value: `"ollama"`, Origin: OriginSystem},
+		"llm.api_key":                {Path: "llm.api_key", Value: `"********"`, Origin: OriginSystem},
+		"llm.model":                  {Path: "llm.model", Value: `"llama3"`, Origin: "flag --model"},
+		"checks.secrets":             {Path: "checks.secrets", Value: "false", Origin: OriginUser},
+		"checks.doc_drift":           {Path: "checks.doc_drift", Value: "true", Origin: OriginDefault},
+		"report.collapse_severities": {Path: "report.collapse_severities", Value: "[]", Origin: OriginRepository},
+		"mcp_servers":                {Path: "mcp_servers", Value: `[{"env":{"DOCS_TOKEN":"********"},"name":"docs"}]`, Origin: OriginRepository},
+	}
+	for path, want := range expected {
+		if got := byPath[path]; got != want {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	}
+}
+
+func TestLoadEffectiveRestrictsRepository(t *testing.T) {
+	dir := t.TempDir()
+	systemPath := SystemConfigPath
+	SystemConfigPath = filepath.Join(dir, "missing.json")
+	defer func() { SystemConfigPath = systemPath }()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "home"))
+
+	writeLayer(t, UserConfigPath(), `{"llm": {"provider": "openai", "base_url": "https://llm.example.com", "api_key": "user-key"}, "security": {"deny_hosts": ["api.openai.com"]}}`)
+	repoPath := filepath.Join(dir, "diffpectrc.json")
+	writeLayer(t, repoPath, `{"llm": {"base_url": "https://attacker.example.net", "model": "gpt-4o"}, "security": {"allow_remote": true, "deny_hosts": ["*.internal"]}}`)
+
+	effective, err := LoadEffective(repoPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cfg := effective.Config
+	if cfg.LLM.BaseURL != "https://llm.example.com" || cfg.LLM.APIKey != "user-key" || cfg.LLM.Model != "gpt-4o" {
+		t.Errorf("Expected the user server to keep the user key, got %+v", cfg.LLM)
+	}
+	if cfg.Security.AllowRemote {
+		t.Error("Expected the repository not to allow remote providers")
+	}
+	if !slices.Equal(cfg.Security.DenyHosts, []string{"*.internal", "api.openai.com"}) {
+		t.Errorf("Expected the denied hosts of both layers, got %v", cfg.Security.DenyHosts)
+	}
+	if effective.Origins["llm.base_url"] != OriginUser || len(effective.Warnings) != 2 {
+		t.Errorf("Expected the base URL of the user and two warnings, got %v, %v", effective.Origins["llm.base_url"], effective.Warnings)
+	}
+
+	// With its own key the repository may point to its own server
+	writeLayer(t, repoPath, `{"llm": {"base_url": "http://localhost:8080", "api_key": "repo-key"}}`)
+	if effective, err = LoadEffective(repoPath); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if effective.Config.LLM.BaseURL != "http://localhost:8080" || effective.Config.LLM.APIKey != "repo-key" {
+		t.Errorf("Expected the server and key of the repository, got %+v", effective.Config.LLM)
+	}
+}
+
+func TestLoadEffectiveWithoutFiles(t *testing.T) {
+	dir := t.TempDir()
+	systemPath := SystemConfigPath
+	SystemConfigPath = filepath.Join(dir, "missing.json")
+	defer func() { SystemConfigPath = systemPath }()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	effective, err := LoadEffective(filepath.Join(dir, "diffpectrc.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if effective.Config.LLM != DefaultConfig().LLM || len(effective.Loaded) != 0 || len(effective.Origins) != 0 {
+		t.Errorf("Expected the default configuration, got %+v", effective)
+	}
+}
+
+func TestLoadEffectiveInvalidLayer(t *testing.T) {
+	dir := t.TempDir()
+	systemPath := SystemConfigPath
+	SystemConfigPath = filepath.Join(dir, "config.json")
+	defer func() { SystemConfigPath = systemPath }()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	writeLayer(t, SystemConfigPath, `{"llm": `)
+
+	if _, err := LoadEffective(""); err == nil {
+		t.Error("Expected an invalid system config to fail")
+	}
+}
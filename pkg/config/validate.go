@@ -0,0 +1,228 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/llm"
+)
+
+// maxSuggestionDistance bounds the edits between an unknown key and the field suggested for it
+const maxSuggestionDistance = 2
+
+// Validate checks the values of the configuration that would otherwise fail deep in the
+// review, or be silently ignored. Every problem is returned, joined, each naming its field.
+func Validate(cfg *Config) error {
+	var problems []error
+	add := func(path, format string, args ...any) {
+		problems = append(problems, fmt.Errorf("%s %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	validateLLM(cfg.LLM, "llm", false, add)
+	for i, member := range cfg.Ensemble.Models {
+		validateLLM(member, fmt.Sprintf("ensemble.models[%d]", i), true, add)
+	}
+
+	confidences := []struct {
+		path  string
+		value float64
+	}{
+		{"report.collapse_below_confidence", cfg.Report.CollapseBelowConfidence},
+		{"report.min_confidence", cfg.Report.MinConfidence},
+	}
+	for _, field := range confidences {
+		if field.value < 0 || field.value > 1 {
+			add(field.path, "must be between 0 and 1, got %v", field.value)
+		}
+	}
+	counts := []struct {
+		path  string
+		value int
+	}{
+		{"report.sampling.keep", cfg.Report.Sampling.Keep},
+		{"context.expansion_token_budget", cfg.Context.ExpansionTokenBudget},
+		{"context.call_graph_token_budget", cfg.Context.CallGraphTokenBudget},
+		{"context.history_commits", cfg.Context.HistoryCommits},
+		{"context.chunk_lines", cfg.Context.ChunkLines},
+		{"ensemble.min_agreement", cfg.Ensemble.MinAgreement},
+		{"exploration.max_calls", cfg.Exploration.MaxCalls},
+	}
+	for _, field := range counts {
+		if field.value < 0 {
+			add(field.path, "must not be negative, got %d", field.value)
+		}
+	}
+
+	for i, plugin := range cfg.Plugins {
+		path := fmt.Sprintf("plugins[%d]", i)
+		if plugin.Name == "" {
+			add(path+".name", "is required")
+		}
+		if len(plugin.Command) == 0 {
+			add(path+".command", "is required")
+		}
+	}
+	for i, server := range cfg.MCPServers {
+		path := fmt.Sprintf("mcp_servers[%d]", i)
+		if server.Name == "" {
+			add(path+".name", "is required")
+		}
+		if (len(server.Command) == 0) == (server.URL == "") {
+			add(path, "requires either command or url")
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// validateLLM checks a model section. The model of an ensemble member is its own, its other
+// empty fields being inherited from the llm section.
+func validateLLM(cfg LLMConfig, path string, member bool, add func(path, format string, args ...any)) {
+	switch {
+	case cfg.Provider == "" && member:
+		return
+	case cfg.Provider == "":
+		add(path+".provider", "is required, use one of %s", strings.Join(llm.SupportedProviders, ", "))
+		return
+	case !slices.Contains(llm.SupportedProviders, cfg.Provider):
+		add(path+".provider", "must be one of %s, got %q", strings.Join(llm.SupportedProviders, ", "), cfg.Provider)
+		return
+	}
+
+	if cfg.TimeoutSeconds < 0 {
+		add(path+".timeout_seconds", "must not be negative, got %d", cfg.TimeoutSeconds)
+	}
+	if cfg.RateLimit.RequestsPerMinute < 0 || cfg.RateLimit.TokensPerMinute < 0 {
+		add(path+".rate_limit", "must not be negative")
+	}
+
+	switch llm.ProviderType(cfg.Provider) {
+	case llm.ProviderOllama:
+		if cfg.Model == "" {
+			add(path+".model", "is required when provider is ollama")
+		}
+	case llm.ProviderAzureOpenAI:
+		if member {
+			return
+		}
+		if cfg.BaseURL == "" {
+			add(path+".base_url", "is required when provider is azure-openai, e.g. https://<resource>.openai.azure.com")
+		}
+		if cfg.Model == "" && cfg.Azure.Deployment == "" {
+			add(path+".model", "or %s.azure.deployment is required when provider is azure-openai", path)
+		}
+		credentials := cfg.APIKey != "" || cfg.Azure.ADToken != "" || (cfg.Azure.TenantID != "" && cfg.Azure.ClientID != "" && cfg.Azure.ClientSecret != "")
+		if !credentials {
+			add(path+".api_key", "is required when provider is azure-openai, unless azure.ad_token or the tenant_id, client_id and client_secret of azure are set")
+		}
+	case llm.ProviderEmbedded:
+		if cfg.Embedded.ModelPath == "" && !member {
+			add(path+".embedded.model_path", "is required when provider is embedded")
+		}
+	}
+}
+
+// UnknownKeys returns a warning for every key of a config file matching no field, with the
+// closest field when it is likely a typo
+func UnknownKeys(data []byte) ([]string, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	var warnings []string
+	unknownKeys(reflect.TypeOf(Config{}), "", raw, &warnings)
+	sort.Strings(warnings)
+	return warnings, nil
+}
+
+func unknownKeys(structType reflect.Type, prefix string, raw map[string]any, warnings *[]string) {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.IsExported() && name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+
+	for key, value := range raw {
+		path := joinPath(prefix, key)
+		// Keys are matched case-insensitively, like encoding/json does
+		fieldType, ok := fields[strings.ToLower(key)]
+		if !ok {
+			warning := fmt.Sprintf("unknown key %s", path)
+			if suggestion := closestKey(key, fields); suggestion != "" {
+				warning += fmt.Sprintf(", did you mean %s?", joinPath(prefix, suggestion))
+			}
+			*warnings = append(*warnings, warning)
+			continue
+		}
+
+		switch fieldType.Kind() {
+		case reflect.Struct:
+			if object, ok := value.(map[string]any); ok {
+				unknownKeys(fieldType, path, object, warnings)
+			}
+		case reflect.Map:
+			object, ok := value.(map[string]any)
+			if !ok || fieldType.Elem().Kind() != reflect.Struct {
+				continue
+			}
+			for name, item := range object {
+				if entry, ok := item.(map[string]any); ok {
+					unknownKeys(fieldType.Elem(), joinPath(path, name), entry, warnings)
+				}
+			}
+		case reflect.Slice:
+			items, ok := value.([]any)
+			if !ok || fieldType.Elem().Kind() != reflect.Struct {
+				continue
+			}
+			for i, item := range items {
+				if object, ok := item.(map[string]any); ok {
+					unknownKeys(fieldType.Elem(), fmt.Sprintf("%s[%d]", path, i), object, warnings)
+				}
+			}
+		}
+	}
+}
+
+// closestKey returns the field name closest to key, empty when none is close enough
+func closestKey(key string, fields map[string]reflect.Type) string {
+	best, bestDistance := "", maxSuggestionDistance+1
+	for name := range fields {
+		if distance := editDistance(strings.ToLower(key), name); distance < bestDistance || (distance == bestDistance && name < best) {
+			best, bestDistance = name, distance
+		}
+	}
+	if bestDistance > maxSuggestionDistance {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		expected []string
+	}{
+		{
+			name:   "default config",
+			modify: func(cfg *Config) {},
+		},
+		{
+			name:     "missing provider",
+			modify:   func(cfg *Config) { cfg.LLM.Provider = "" },
+			expected: []string{"llm.provider is required, use one of ollama, openai, azure-openai, embedded"},
+		},
+		{
+			name:     "unknown provider",
+			modify:   func(cfg *Config) { cfg.LLM.Provider = "anthropic" },
+			expected: []string{`llm.provider must be one of ollama, openai, azure-openai, embedded, got "anthropic"`},
+		},
+		{
+			name:     "ollama without model",
+			modify:   func(cfg *Config) { cfg.LLM.Provider = "ollama" },
+			expected: []string{"llm.model is required when provider is ollama"},
+		},
+		{
+			name: "azure without deployment and credentials",
+			modify: func(cfg *Config) {
+				cfg.LLM = LLMConfig{Provider: "azure-openai", BaseURL: "https://example.openai.azure.com"}
+			},
+			expected: []string{
+				"llm.model or llm.azure.deployment is required when provider is azure-openai",
+				"llm.api_key is required when provider is azure-openai",
+			},
+		},
+		{
+			name:     "embedded without model path",
+			modify:   func(cfg *Config) { cfg.LLM.Provider = "embedded" },
+			expected: []string{"llm.embedded.model_path is required when provider is embedded"},
+		},
+		{
+			name: "ensemble members inherit the connection",
+			modify: func(cfg *Config) {
+				cfg.LLM = LLMConfig{Provider: "azure-openai", BaseURL: "https://example.openai.azure.com", Model: "gpt-4o", APIKey: "key"}
+				cfg.Ensemble.Models = []LLMConfig{{Model: "gpt-4o-mini"}, {Provider: "ollama"}}
+			},
+			expected: []string{"ensemble.models[1].model is required when provider is ollama"},
+		},
+		{
+			name: "out of range values",
+			modify: func(cfg *Config) {
+				cfg.Report.MinConfidence = 1.5
+				cfg.Context.ChunkLines = -1
+			},
+			expected: []string{
+				"report.min_confidence must be between 0 and 1, got 1.5",
+				"context.chunk_lines must not be negative, got -1",
+			},
+		},
+		{
+			name: "incomplete plugins and MCP servers",
+			modify: func(cfg *Config) {
+				cfg.Plugins = []PluginConfig{{Name: "lint"}}
+				cfg.MCPServers = []MCPServerConfig{{Name: "docs", Command: []string{"docs-mcp"}, URL: "http://localhost:9000"}}
+			},
+			expected: []string{
+				"plugins[0].command is required",
+				"mcp_servers[0] requires either command or url",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+
+			err := Validate(cfg)
+			if len(tt.expected) == 0 {
+				if err != nil {
+					t.Errorf("Expected a valid config, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected %v, got no error", tt.expected)
+			}
+			problems := strings.Split(err.Error(), "\n")
+			if len(problems) != len(tt.expected) {
+				t.Fatalf("Expected %d problem(s), got %q", len(tt.expected), problems)
+			}
+			for i, expected := range tt.expected {
+				if !strings.HasPrefix(problems[i], expected) {
+					t.Errorf("Expected %q, got %q", expected, problems[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	data := []byte(`{
+		"LLM": {"provider": "ollama", "modle": "qwen2.5-coder"},
+		"report": {"sampling": {"keep": 3, "categories": {"llm": 2}}},
+		"plugins": [{"name": "lint", "command": ["lint"], "parameters": {"type": "object"}, "timeout": 10}],
+		"profiles": {"quick": {"model": "qwen2.5-coder:7b", "fail-on": "CRITICAL"}},
+		"verbose": true
+	}`)
+
+	warnings, err := UnknownKeys(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"unknown key LLM.modle, did you mean LLM.model?",
+		"unknown key plugins[0].timeout",
+		"unknown key profiles.quick.fail-on, did you mean profiles.quick.fail_on?",
+		"unknown key verbose",
+	}
+	if !slices.Equal(warnings, expected) {
+		t.Errorf("Expected %q, got %q", expected, warnings)
+	}
+
+	if _, err := UnknownKeys([]byte(`{"llm": `)); err == nil {
+		t.Error("Expected invalid JSON to fail")
+	}
+}
@@ -0,0 +1,173 @@
+// Package context exposes diffpector's diff-aware symbol context extraction so other
+// tools can reuse it without running a review.
+package context
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/agusespa/diffpector/internal/tools"
+	"github.com/agusespa/diffpector/internal/types"
+	"github.com/agusespa/diffpector/internal/vcs"
+)
+
+// FileContext is the context gathered for a single changed file
+type FileContext struct {
+	Path     string `json:"path"`
+	Language string `json:"language,omitempty"`
+	Diff     string `json:"diff"`
+	// Context holds the full source of the declarations touched by the diff
+	Context         string           `json:"context,omitempty"`
+	AffectedSymbols []AffectedSymbol `json:"affected_symbols,omitempty"`
+}
+
+// AffectedSymbol is a declaration touched by the diff, with the definitions and usages found in the project
+type AffectedSymbol struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Package   string `json:"package,omitempty"`
+	FilePath  string `json:"file_path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Snippets  string `json:"snippets,omitempty"`
+}
+
+// Symbol is a declaration found in a source file
+type Symbol struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Package   string `json:"package,omitempty"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+type Extractor struct {
+	projectRoot    string
+	parserRegistry *tools.ParserRegistry
+	symbolTool     *tools.SymbolContextTool
+}
+
+// NewExtractor creates an extractor for the repository rooted at projectRoot
+func NewExtractor(projectRoot string) *Extractor {
+	registry := tools.NewParserRegistry()
+	return &Extractor{
+		projectRoot:    projectRoot,
+		parserRegistry: registry,
+		symbolTool:     tools.NewSymbolContextTool(projectRoot, registry),
+	}
+}
+
+// FromStagedChanges extracts the context of the changes staged in the repository
+func (e *Extractor) FromStagedChanges() ([]FileContext, error) {
+	repo, err := vcs.Detect(e.projectRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := repo.StagedDiff()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staged diff: %w", err)
+	}
+
+	return e.FromDiff(diff)
+}
+
+// FromDiff extracts the context of a unified diff whose paths are relative to the project root.
+// Files without a supported parser are returned with their diff only.
+func (e *Extractor) FromDiff(diff []byte) ([]FileContext, error) {
+	absRoot, err := filepath.Abs(e.projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project root: %w", err)
+	}
+
+	diffMap, err := tools.ParseDiffData(diff, absRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(diffMap))
+	for path := range diffMap {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var results []FileContext
+	for _, path := range paths {
+		diffData := diffMap[path]
+		fileContext := FileContext{
+			Path: path,
+			Diff: diffData.Diff,
+		}
+
+		parser := e.parserRegistry.GetParser(path)
+		if parser == nil {
+			results = append(results, fileContext)
+			continue
+		}
+		fileContext.Language = strings.ToLower(parser.Language())
+
+		result, err := e.symbolTool.Execute(context.Background(), map[string]any{"diffData": diffData, "primaryLanguage": fileContext.Language})
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract context for %s: %w", path, err)
+		}
+		updatedData, ok := result.(types.DiffData)
+		if !ok {
+			return nil, fmt.Errorf("symbol context tool returned unexpected type: %T", result)
+		}
+
+		fileContext.Context = updatedData.DiffContext
+		for _, usage := range updatedData.AffectedSymbols {
+			fileContext.AffectedSymbols = append(fileContext.AffectedSymbols, AffectedSymbol{
+				Name:      usage.Symbol.Name,
+				Kind:      usage.Symbol.Type,
+				Package:   usage.Symbol.Package,
+				FilePath:  usage.Symbol.FilePath,
+				StartLine: usage.Symbol.StartLine,
+				EndLine:   usage.Symbol.EndLine,
+				Snippets:  usage.Snippets,
+			})
+		}
+
+		results = append(results, fileContext)
+	}
+
+	return results, nil
+}
+
+// FileSymbols parses the file at path, relative to the project root, and returns the
+// symbols it declares. Files without a supported parser have none. Paths outside of the
+// project, or in its .git directory, are refused.
+func (e *Extractor) FileSymbols(path string) ([]Symbol, error) {
+	rel, err := tools.Confine(e.projectRoot, path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filepath.Join(e.projectRoot, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	parsed, err := e.parserRegistry.ParseFile(path, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	symbols := []Symbol{}
+	for _, symbol := range parsed {
+		if tools.IsUsageType(symbol.Type) {
+			continue
+		}
+		symbols = append(symbols, Symbol{
+			Name:      symbol.Name,
+			Kind:      symbol.Type,
+			Package:   symbol.Package,
+			StartLine: symbol.StartLine,
+			EndLine:   symbol.EndLine,
+		})
+	}
+	return symbols, nil
+}
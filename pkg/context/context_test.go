@@ -0,0 +1,104 @@
+package context
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractor_FromStagedChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	runCmd(t, tempDir, "git", "init")
+	runCmd(t, tempDir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, tempDir, "git", "config", "user.name", "Test User")
+
+	writeFile(t, tempDir, "math.go", "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+	writeFile(t, tempDir, "main.go", "package calc\n\nfunc Total() int {\n\treturn Add(1, 2)\n}\n")
+	writeFile(t, tempDir, "notes.txt", "first\n")
+	runCmd(t, tempDir, "git", "add", ".")
+	runCmd(t, tempDir, "git", "commit", "-m", "Initial commit")
+
+	writeFile(t, tempDir, "math.go", "package calc\n\nfunc Add(a, b int) int {\n\treturn b + a\n}\n")
+	writeFile(t, tempDir, "notes.txt", "second\n")
+	runCmd(t, tempDir, "git", "add", ".")
+
+	extractor := NewExtractor(tempDir)
+	results, err := extractor.FromStagedChanges()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 file contexts, got %d", len(results))
+	}
+
+	goFile := results[0]
+	if goFile.Path != "math.go" || goFile.Language != "go" {
+		t.Errorf("Expected math.go with language go, got %s (%s)", goFile.Path, goFile.Language)
+	}
+	if !strings.Contains(goFile.Context, "func Add(a, b int) int") {
+		t.Errorf("Expected context to contain the changed function, got:\n%s", goFile.Context)
+	}
+	if len(goFile.AffectedSymbols) != 1 || goFile.AffectedSymbols[0].Name != "Add" {
+		t.Fatalf("Expected Add as the single affected symbol, got %+v", goFile.AffectedSymbols)
+	}
+	if !strings.Contains(goFile.AffectedSymbols[0].Snippets, "Usage in") {
+		t.Errorf("Expected snippets to include usages, got:\n%s", goFile.AffectedSymbols[0].Snippets)
+	}
+
+	textFile := results[1]
+	if textFile.Path != "notes.txt" || textFile.Language != "" || len(textFile.AffectedSymbols) != 0 {
+		t.Errorf("Expected notes.txt without language or symbols, got %+v", textFile)
+	}
+	if !strings.Contains(textFile.Diff, "+second") {
+		t.Errorf("Expected diff for notes.txt, got:\n%s", textFile.Diff)
+	}
+}
+
+func TestExtractor_FileSymbols(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFile(t, tempDir, "math.go", "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+	writeFile(t, tempDir, "notes.txt", "first\n")
+
+	extractor := NewExtractor(tempDir)
+	symbols, err := extractor.FileSymbols("math.go")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(symbols) != 1 || symbols[0].Name != "Add" || symbols[0].Package != "calc" || symbols[0].StartLine != 3 {
+		t.Errorf("Expected Add declared at line 3, got %+v", symbols)
+	}
+
+	symbols, err = extractor.FileSymbols("notes.txt")
+	if err != nil || len(symbols) != 0 {
+		t.Errorf("Expected no symbols for a text file, got %+v, %v", symbols, err)
+	}
+
+	if _, err := extractor.FileSymbols("missing.go"); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+
+	outside := t.TempDir()
+	writeFile(t, outside, "secret.go", "package secret\n\nfunc Key() {}\n")
+	for _, path := range []string{"../" + filepath.Base(outside) + "/secret.go", filepath.Join(outside, "secret.go")} {
+		if _, err := extractor.FileSymbols(path); err == nil || !strings.Contains(err.Error(), "outside of the repository") {
+			t.Errorf("Expected %s to be refused, got %v", path, err)
+		}
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file %s: %v", name, err)
+	}
+}
+
+func runCmd(t *testing.T, dir, name string, args ...string) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Command failed: %s %v, error: %v, output: %s", name, args, err, out)
+	}
+}